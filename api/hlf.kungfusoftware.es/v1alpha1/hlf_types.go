@@ -23,7 +23,10 @@ import (
 	"k8s.io/api/networking/v1beta1"
 	kubeclock "k8s.io/apimachinery/pkg/util/clock"
 
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -78,12 +81,43 @@ const StateDBCouchDB StateDB = "couchdb"
 
 type ExternalBuilder struct {
 	Name string `json:"name"`
+	// Path of the builder in the peer container, it's not needed when the builder is provided by the operator
+	// +optional
 	Path string `json:"path"`
 	// +nullable
 	// +kubebuilder:validation:Optional
 	// +optional
 	// +kubebuilder:validation:Default={}
 	PropagateEnvironment []string `json:"propagateEnvironment"`
+	// Kubernetes makes the operator provide the builder, it launches the chaincode packages of type k8s as deployments
+	// in the namespace of the peer
+	// +optional
+	// +nullable
+	Kubernetes *ExternalBuilderKubernetes `json:"kubernetes,omitempty"`
+}
+
+type ExternalBuilderKubernetes struct {
+	// Image of the builder, the image of the operator when it's not set
+	// +optional
+	Image string `json:"image,omitempty"`
+	// +optional
+	Tag string `json:"tag,omitempty"`
+	// +kubebuilder:default:="IfNotPresent"
+	PullPolicy corev1.PullPolicy `json:"pullPolicy,omitempty"`
+	// Pull policy of the chaincode images
+	// +kubebuilder:default:="IfNotPresent"
+	ChaincodePullPolicy corev1.PullPolicy `json:"chaincodePullPolicy,omitempty"`
+	// Secrets to pull the chaincode images
+	// +optional
+	// +nullable
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// Resources of the chaincode containers
+	// +optional
+	// +nullable
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Service account of the chaincode pods
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 }
 
 const DefaultImagePullPolicy = corev1.PullAlways
@@ -133,6 +167,20 @@ type GRPCProxy struct {
 	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets"`
 }
 
+// FabricPodDisruptionBudget configures the PodDisruptionBudget the operator creates for the pods of a node
+type FabricPodDisruptionBudget struct {
+	// +kubebuilder:default:=false
+	// Includes the pods in a PodDisruptionBudget, peers are grouped by MSP ID and orderers by the consenter sets of
+	// their channels
+	Enabled bool `json:"enabled"`
+	// +optional
+	// +nullable
+	// +kubebuilder:validation:Minimum=0
+	// Pods that can be evicted at the same time, 1 by default. For orderers it's lowered to keep the quorum of
+	// their channels, orderers of a channel that can't lose any consenter get no PodDisruptionBudget
+	MaxUnavailable *int `json:"maxUnavailable,omitempty"`
+}
+
 // FabricPeerSpec defines the desired state of FabricPeer
 type FabricPeerSpec struct {
 	// +optional
@@ -144,6 +192,16 @@ type FabricPeerSpec struct {
 	Affinity *corev1.Affinity `json:"affinity"`
 	// +optional
 	// +nullable
+	// Topology spread constraints of the pods
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// +optional
+	// Priority class of the pods
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// +optional
+	// +nullable
+	PodDisruptionBudget *FabricPodDisruptionBudget `json:"podDisruptionBudget,omitempty"`
+	// +optional
+	// +nullable
 	ServiceMonitor *ServiceMonitor `json:"serviceMonitor"`
 	// +optional
 	// +nullable
@@ -218,6 +276,26 @@ type FabricPeerSpec struct {
 	// +optional
 	// +kubebuilder:validation:Default={}
 	Env []corev1.EnvVar `json:"env"`
+
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// PKCS#11 token that holds the signing key of the peer, the TLS keys are still stored in secrets
+	PKCS11 *BCCSPPKCS11 `json:"pkcs11,omitempty"`
+
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// Mutual TLS of the peer, when enabled the clients must present a TLS certificate issued by a trusted root
+	ClientAuth *FabricTLSClientAuth `json:"clientAuth,omitempty"`
+
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// +kubebuilder:validation:Type=object
+	// Keys of the core.yaml of the peer to override, with the same nesting as the file. The keys are validated against
+	// the known keys of core.yaml, unknown keys are applied as is and reported in the status
+	CoreOverrides *apiextensionsv1.JSON `json:"coreOverrides,omitempty"`
 }
 type FabricPeerResources struct {
 	Peer      corev1.ResourceRequirements `json:"peer"`
@@ -277,6 +355,57 @@ type FabricPeerExternalCouchDB struct {
 	Enabled bool   `json:"enabled"`
 	Host    string `json:"host"`
 	Port    int    `json:"port"`
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-z][a-z0-9_$()+-]*$`
+	// Prefix added to the names of the databases of the peer, so several peers can share a CouchDB cluster
+	DatabasePrefix string `json:"databasePrefix,omitempty"`
+	// +optional
+	// +nullable
+	// Secret with the user and password of CouchDB, the user and password of the couchdb section are used when it's
+	// not set
+	Credentials *FabricPeerCouchDBCredentials `json:"credentials,omitempty"`
+	// +optional
+	// +nullable
+	// TLS settings to connect to CouchDB, the connection isn't encrypted when it's not set
+	TLS *FabricPeerCouchDBTLS `json:"tls,omitempty"`
+	// +optional
+	// +nullable
+	// Image of the proxy that runs next to the peer when TLS or a database prefix are set, since the peer only
+	// speaks plain HTTP to CouchDB and doesn't prefix the names of the databases
+	Proxy *FabricPeerCouchDBProxy `json:"proxy,omitempty"`
+}
+
+type FabricPeerCouchDBCredentials struct {
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+	// +optional
+	// +kubebuilder:default:="username"
+	UserKey string `json:"userKey"`
+	// +optional
+	// +kubebuilder:default:="password"
+	PasswordKey string `json:"passwordKey"`
+}
+
+type FabricPeerCouchDBTLS struct {
+	// Certificate of the CA that signed the certificate of CouchDB
+	CACert corev1.SecretKeySelector `json:"caCert"`
+	// +optional
+	// +nullable
+	// Client certificate, for CouchDB servers that require client authentication
+	ClientCert *corev1.SecretKeySelector `json:"clientCert,omitempty"`
+	// +optional
+	// +nullable
+	// Private key of the client certificate
+	ClientKey *corev1.SecretKeySelector `json:"clientKey,omitempty"`
+}
+
+type FabricPeerCouchDBProxy struct {
+	// +kubebuilder:default:="nginx"
+	Image string `json:"image"`
+	// +kubebuilder:default:="1.25-alpine"
+	Tag string `json:"tag"`
+	// +kubebuilder:default:="IfNotPresent"
+	PullPolicy corev1.PullPolicy `json:"pullPolicy"`
 }
 type FabricIstio struct {
 	// +optional
@@ -312,6 +441,10 @@ type Component struct {
 	Enrollid string `json:"enrollid"`
 	// +kubebuilder:validation:MinLength=1
 	Enrollsecret string `json:"enrollsecret"`
+	// +optional
+	// +nullable
+	// Private key generated for the sign certificate, only ECDSA keys can be used to sign
+	KeyRequest *KeyRequest `json:"keyRequest,omitempty"`
 }
 
 func (c *Component) CAUrl() string {
@@ -333,7 +466,33 @@ type TLS struct {
 	Csr          Csr    `json:"csr"`
 	Enrollid     string `json:"enrollid"`
 	Enrollsecret string `json:"enrollsecret"`
+	// +optional
+	// +nullable
+	// Private key generated for the TLS certificate
+	KeyRequest *KeyRequest `json:"keyRequest,omitempty"`
+}
+
+type KeyAlgorithm string
+
+const (
+	KeyAlgorithmECDSA KeyAlgorithm = "ECDSA"
+	KeyAlgorithmRSA   KeyAlgorithm = "RSA"
+)
+
+// KeyRequest is the algorithm and size of a private key
+type KeyRequest struct {
+	// +kubebuilder:validation:Enum=ECDSA;RSA
+	// +kubebuilder:default:="ECDSA"
+	Algorithm KeyAlgorithm `json:"algorithm"`
+	// +optional
+	// +kubebuilder:validation:Enum=P256;P384
+	// Curve of the ECDSA key, P256 by default
+	Curve string `json:"curve,omitempty"`
+	// +optional
+	// Size in bits of the RSA key, 2048 by default
+	Size int `json:"size,omitempty"`
 }
+
 type Enrollment struct {
 	Component Component `json:"component"`
 	TLS       TLS       `json:"tls"`
@@ -400,6 +559,83 @@ type FabricPeerStatus struct {
 	SignCACert string `json:"signCaCert"`
 	// +optional
 	NodePort int `json:"port"`
+	// +optional
+	// Unknown keys of the core overrides
+	Warnings []string `json:"warnings,omitempty"`
+	// +optional
+	// +nullable
+	// Health reported by the operations endpoint of the peer
+	Health *FabricNodeHealth `json:"health,omitempty"`
+	// +optional
+	// Channels joined by the peer with their heights
+	Channels []FabricPeerChannelStatus `json:"channels,omitempty"`
+	// +optional
+	// +nullable
+	// Last migration of the state database of the peer
+	StateDbMigration *FabricPeerStateDbMigration `json:"stateDbMigration,omitempty"`
+
+	// +optional
+	// +nullable
+	// Reachability of the external CouchDB of the peer
+	CouchDB *FabricPeerCouchDBStatus `json:"couchDB,omitempty"`
+}
+
+// FabricPeerCouchDBStatus is the result of the connectivity check of an external CouchDB, done from the operator
+type FabricPeerCouchDBStatus struct {
+	Reachable bool `json:"reachable"`
+	// +optional
+	Version string `json:"version,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type StateDbMigrationStep string
+
+const (
+	// the peer is being stopped
+	StateDbMigrationScalingDown StateDbMigrationStep = "ScalingDown"
+	// the job that runs `peer node rebuild-dbs` against the ledger of the peer is running
+	StateDbMigrationRebuildingDbs StateDbMigrationStep = "RebuildingDbs"
+	// the peer is being started with the new state database, it rebuilds the state from the blocks of its ledger
+	StateDbMigrationStarting StateDbMigrationStep = "Starting"
+	// the peer is running with the new state database
+	StateDbMigrationCompleted StateDbMigrationStep = "Completed"
+	// the rebuild job failed, the peer stays stopped until the job is deleted to retry
+	StateDbMigrationFailed StateDbMigrationStep = "Failed"
+)
+
+// FabricPeerStateDbMigration tracks the switch of the state database of a peer between LevelDB and CouchDB
+type FabricPeerStateDbMigration struct {
+	From StateDB              `json:"from"`
+	To   StateDB              `json:"to"`
+	Step StateDbMigrationStep `json:"step"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// +optional
+	// +nullable
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// +optional
+	// +nullable
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// FabricNodeHealth is the result of the /healthz endpoint of the operations service of a node
+type FabricNodeHealth struct {
+	// OK or Service Unavailable
+	Status string `json:"status"`
+	// +optional
+	FailedChecks []FabricNodeFailedCheck `json:"failedChecks,omitempty"`
+}
+
+type FabricNodeFailedCheck struct {
+	// Component that failed the check, for example couchdb
+	Component string `json:"component"`
+	Reason    string `json:"reason"`
+}
+
+type FabricPeerChannelStatus struct {
+	Name   string `json:"name"`
+	Height uint64 `json:"height"`
 }
 type OrdererService struct {
 	// +kubebuilder:validation:Enum=NodePort;ClusterIP;LoadBalancer
@@ -453,6 +689,16 @@ type FabricOrdererNodeSpec struct {
 	UpdateCertificateTime *metav1.Time `json:"updateCertificateTime"`
 	// +optional
 	// +nullable
+	// Topology spread constraints of the pods
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// +optional
+	// Priority class of the pods
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// +optional
+	// +nullable
+	PodDisruptionBudget *FabricPodDisruptionBudget `json:"podDisruptionBudget,omitempty"`
+	// +optional
+	// +nullable
 	ServiceMonitor *ServiceMonitor `json:"serviceMonitor"`
 	// +optional
 	// +nullable
@@ -466,6 +712,10 @@ type FabricOrdererNodeSpec struct {
 
 	Resources corev1.ResourceRequirements `json:"resources"`
 
+	// +optional
+	// +nullable
+	Logging *FabricOrdererNodeLogging `json:"logging"`
+
 	// +kubebuilder:default:=1
 	Replicas int `json:"replicas"`
 	// +kubebuilder:validation:MinLength=1
@@ -507,6 +757,26 @@ type FabricOrdererNodeSpec struct {
 	// +optional
 	// +kubebuilder:validation:Default={}
 	Env []corev1.EnvVar `json:"env"`
+
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// PKCS#11 token that holds the signing key of the orderer, the TLS keys are still stored in secrets
+	PKCS11 *BCCSPPKCS11 `json:"pkcs11,omitempty"`
+
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// Mutual TLS of the orderer, when enabled the clients must present a TLS certificate issued by a trusted root
+	ClientAuth *FabricTLSClientAuth `json:"clientAuth,omitempty"`
+
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// +kubebuilder:validation:Type=object
+	// Keys of the orderer.yaml of the orderer to override, with the same nesting as the file. The keys are validated
+	// against the known keys of orderer.yaml, unknown keys are applied as is and reported in the status
+	OrdererOverrides *apiextensionsv1.JSON `json:"ordererOverrides,omitempty"`
 }
 
 type OrdererSystemChannel struct {
@@ -545,6 +815,12 @@ type FabricOrderingServiceStatus struct {
 }
 
 // FabricOrdererNodeStatus defines the observed state of FabricOrdererNode
+type FabricOrdererNodeLogging struct {
+	// Logging spec of the orderer, for example info or info:orderer.consensus.etcdraft=debug
+	// +kubebuilder:default:="info"
+	Spec string `json:"spec"`
+}
+
 type FabricOrdererNodeStatus struct {
 	Conditions status.Conditions `json:"conditions"`
 	Status     DeploymentStatus  `json:"status"`
@@ -571,6 +847,32 @@ type FabricOrdererNodeStatus struct {
 	NodePort int `json:"port"`
 	// +optional
 	Message string `json:"message"`
+	// +optional
+	// Unknown keys of the orderer overrides
+	Warnings []string `json:"warnings,omitempty"`
+	// +optional
+	// +nullable
+	// Health reported by the operations endpoint of the orderer
+	Health *FabricNodeHealth `json:"health,omitempty"`
+	// +optional
+	// Channels joined by the orderer, read from the channel participation API
+	Channels []FabricOrdererNodeChannelStatus `json:"channels,omitempty"`
+	// +optional
+	// ID of the orderer in the consenter sets of the BFT channels, assigned by the operator once and persisted in the
+	// `hlf.kungfusoftware.es/consenter-id` annotation, it's never reused by another orderer node
+	ConsenterID uint32 `json:"consenterID,omitempty"`
+}
+
+type FabricOrdererNodeChannelStatus struct {
+	Name   string `json:"name"`
+	Height uint64 `json:"height"`
+	// consenter, follower, config-tracker or other
+	ConsensusRelation string `json:"consensusRelation"`
+	// onboarding, active, inactive or failed
+	Status string `json:"status"`
+	// +optional
+	// Raft role of a consenter, leader or follower
+	RaftRole string `json:"raftRole,omitempty"`
 }
 
 type Cors struct {
@@ -579,8 +881,52 @@ type Cors struct {
 	Origins []string `json:"origins"`
 }
 type FabricCADatabase struct {
-	Type       string `json:"type"`
+	// +kubebuilder:validation:Enum=sqlite3;postgres;mysql
+	Type string `json:"type"`
+	// +optional
+	// Datasource of the database, it's rendered from the host, port, database name and credentials when the host is set
 	Datasource string `json:"datasource"`
+	// +optional
+	// Host of the postgres or mysql server shared by the replicas of the CA
+	Host string `json:"host,omitempty"`
+	// +optional
+	// Port of the database server, 5432 for postgres and 3306 for mysql by default
+	Port int `json:"port,omitempty"`
+	// +optional
+	// +kubebuilder:default:="fabric_ca"
+	DBName string `json:"dbName,omitempty"`
+	// +optional
+	// +nullable
+	// Secret with the user and password of the database
+	Credentials *FabricCADatabaseCredentials `json:"credentials,omitempty"`
+	// +optional
+	// +nullable
+	// TLS settings to connect to the database, the connection isn't encrypted when it's not set
+	TLS *FabricCADatabaseTLS `json:"tls,omitempty"`
+}
+
+type FabricCADatabaseCredentials struct {
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+	// +optional
+	// +kubebuilder:default:="username"
+	UserKey string `json:"userKey"`
+	// +optional
+	// +kubebuilder:default:="password"
+	PasswordKey string `json:"passwordKey"`
+}
+
+type FabricCADatabaseTLS struct {
+	// Certificate of the CA that signed the certificate of the database server
+	CACert corev1.SecretKeySelector `json:"caCert"`
+	// +optional
+	// +nullable
+	// Client certificate, for database servers that require client authentication
+	ClientCert *corev1.SecretKeySelector `json:"clientCert,omitempty"`
+	// +optional
+	// +nullable
+	// Private key of the client certificate
+	ClientKey *corev1.SecretKeySelector `json:"clientKey,omitempty"`
 }
 
 // FabricCASpec defines the desired state of FabricCA
@@ -590,6 +936,16 @@ type FabricCASpec struct {
 	// +optional
 	Affinity *corev1.Affinity `json:"affinity"`
 	// +optional
+	// +nullable
+	// Topology spread constraints of the pods
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// +optional
+	// Priority class of the pods
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// +optional
+	// +nullable
+	PodDisruptionBudget *FabricPodDisruptionBudget `json:"podDisruptionBudget,omitempty"`
+	// +optional
 	// +kubebuilder:validation:Optional
 	// +nullable
 	// +kubebuilder:validation:Default={}
@@ -614,6 +970,12 @@ type FabricCASpec struct {
 	// +nullable
 	Istio    *FabricIstio     `json:"istio"`
 	Database FabricCADatabase `json:"db"`
+	// +optional
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=1
+	// Number of replicas of the CA, more than one requires a postgres or mysql database and storage with the
+	// ReadWriteMany access mode
+	Replicas int `json:"replicas,omitempty"`
 	// +kubebuilder:validation:MinItems=1
 	// Hosts for the Fabric CA
 	Hosts   []string            `json:"hosts"`
@@ -640,6 +1002,27 @@ type FabricCASpec struct {
 	// +optional
 	// +kubebuilder:validation:Default={}
 	Env []corev1.EnvVar `json:"env"`
+
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// Rotation of the root certificates of the CA
+	Rotation *FabricCARotation `json:"rotation"`
+}
+
+type FabricCARotation struct {
+	// Changing the time starts a new rotation of the root certificates of the CA
+	RequestTime metav1.Time `json:"requestTime"`
+	// +optional
+	// Rotate the root certificate of the TLS CA too
+	TLSCA bool `json:"tlsCA"`
+	// +optional
+	// Sign the new root certificates with the previous keys too, for clients that only trust the previous roots
+	CrossSign bool `json:"crossSign"`
+	// +optional
+	// +kubebuilder:default:="24h"
+	// Time during which the channels trust the previous root certificates after the CA switches to the new keys
+	TransitionWindow string `json:"transitionWindow"`
 }
 
 type FabricCATLSConf struct {
@@ -742,6 +1125,10 @@ type FabricCAItemConf struct {
 	Registry     FabricCARegistry     `json:"registry"`
 	Intermediate FabricCAIntermediate `json:"intermediate"`
 	BCCSP        FabricCABCCSP        `json:"bccsp"`
+	// +optional
+	// +nullable
+	// Private key generated for the root certificate, the Fabric CA server only supports ECDSA keys
+	KeyRequest *KeyRequest `json:"keyRequest,omitempty"`
 	// +nullable
 	// +kubebuilder:validation:Optional
 	// +optional
@@ -755,6 +1142,103 @@ type FabricCAItemConf struct {
 	// +kubebuilder:validation:Optional
 	// +nullable
 	TlsCA *FabricTLSCACrypto `json:"tlsCa"`
+	// +optional
+	// +nullable
+	// LDAP server that authenticates the enrollments instead of the registry of the CA
+	LDAP *FabricCALDAP `json:"ldap,omitempty"`
+	// +optional
+	// +nullable
+	// Settings of the CA as issuer of Idemix credentials
+	Idemix *FabricCAIdemix `json:"idemix,omitempty"`
+}
+
+type FabricCAIdemix struct {
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// Number of revocation handles fetched from the database at once, 1000 by default
+	RHPoolSize int `json:"rhPoolSize,omitempty"`
+	// +optional
+	// Time after which the nonces issued to request a credential expire, 15s by default
+	NonceExpiration string `json:"nonceExpiration,omitempty"`
+	// +optional
+	// Interval at which the expired nonces are removed, 15m by default
+	NonceSweepInterval string `json:"nonceSweepInterval,omitempty"`
+	// +optional
+	// +kubebuilder:validation:Enum=amcl.Fp256bn;gurvy.Bn254;amcl.Fp256Miraclbn
+	// Curve of the issuer keys, only supported by Fabric CA 1.5 or later
+	Curve string `json:"curve,omitempty"`
+}
+
+type FabricCALDAP struct {
+	// +kubebuilder:validation:MinLength=1
+	// URL of the LDAP server with the base DN of the users, e.g. ldaps://ldap.example.com:636/dc=example,dc=com
+	URL string `json:"url"`
+	// +optional
+	// DN of the user that searches the directory, the search is anonymous if it's not set
+	BindDN string `json:"bindDN,omitempty"`
+	// +optional
+	// +nullable
+	// Secret with the password of the bind DN
+	BindPassword *corev1.SecretKeySelector `json:"bindPassword,omitempty"`
+	// +optional
+	// +kubebuilder:default:="(uid=%s)"
+	// Filter to find the user with the enrollment ID, %s is replaced with the enrollment ID
+	UserFilter string `json:"userFilter,omitempty"`
+	// +optional
+	// +kubebuilder:default:="(memberUid=%s)"
+	// Filter to find the groups of the user, %s is replaced with the enrollment ID
+	GroupFilter string `json:"groupFilter,omitempty"`
+	// +optional
+	// +nullable
+	// TLS settings to connect to an ldaps URL
+	TLS *FabricCALDAPTLS `json:"tls,omitempty"`
+	// +optional
+	// Mapping of the LDAP attributes of the user to Fabric CA attributes
+	Attribute FabricCALDAPAttribute `json:"attribute,omitempty"`
+}
+
+type FabricCALDAPTLS struct {
+	// Certificate of the CA that signed the certificate of the LDAP server
+	CACert corev1.SecretKeySelector `json:"caCert"`
+	// +optional
+	// +nullable
+	// Client certificate, for LDAP servers that require client authentication
+	ClientCert *corev1.SecretKeySelector `json:"clientCert,omitempty"`
+	// +optional
+	// +nullable
+	// Private key of the client certificate
+	ClientKey *corev1.SecretKeySelector `json:"clientKey,omitempty"`
+}
+
+type FabricCALDAPAttribute struct {
+	// +optional
+	// +kubebuilder:default:={"uid","member"}
+	// LDAP attributes requested for the user
+	Names []string `json:"names,omitempty"`
+	// +optional
+	// Fabric CA attributes computed from the LDAP attributes, e.g. hf.Revoker with attr("uid") =~ "revoker*"
+	Converters []FabricCALDAPConverter `json:"converters,omitempty"`
+	// +optional
+	// Named maps referenced by the map function of the converters
+	Maps []FabricCALDAPMap `json:"maps,omitempty"`
+}
+
+type FabricCALDAPMap struct {
+	// Name of the map used in the map function
+	Name    string                 `json:"name"`
+	Entries []FabricCALDAPMapEntry `json:"entries"`
+}
+
+type FabricCALDAPConverter struct {
+	// Name of the Fabric CA attribute
+	Name string `json:"name"`
+	// Expression that computes the value of the attribute
+	Value string `json:"value"`
+}
+
+type FabricCALDAPMapEntry struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 type FabricTLSCACrypto struct {
 	Key        string             `json:"key"`
@@ -787,9 +1271,57 @@ type FabricCASubject struct {
 }
 type FabricCABCCSP struct {
 	// +kubebuilder:default:="SW"
+	// +kubebuilder:validation:Enum=SW;PKCS11
 	Default string          `json:"default"`
 	SW      FabricCABCCSPSW `json:"sw"`
+	// +optional
+	// +nullable
+	// Token that holds the key of the CA when the default BCCSP is PKCS11
+	PKCS11 *BCCSPPKCS11 `json:"pkcs11,omitempty"`
+}
+
+// BCCSPPKCS11 is a PKCS#11 token that holds the signing keys, the keys are generated inside the token and aren't stored
+// in Kubernetes secrets
+type BCCSPPKCS11 struct {
+	// +kubebuilder:validation:MinLength=1
+	// Path of the PKCS#11 library, it must exist at the same path in the operator and in the container of the node
+	Library string `json:"library"`
+	// +kubebuilder:validation:MinLength=1
+	// Label of the token
+	Label string `json:"label"`
+	// Secret in the namespace of the node with the user PIN of the token
+	Pin corev1.SecretKeySelector `json:"pin"`
+	// +optional
+	// +kubebuilder:default:="SHA2"
+	Hash string `json:"hash,omitempty"`
+	// +optional
+	// +kubebuilder:default:=256
+	Security int `json:"security,omitempty"`
+}
+
+// FabricTLSClientAuth is the mutual TLS configuration of a peer or an orderer, the TLS root certificate of the node is
+// always trusted
+type FabricTLSClientAuth struct {
+	// Require the clients to present a TLS certificate
+	Enabled bool `json:"enabled"`
+	// +optional
+	// +nullable
+	// FabricCAs whose TLS root certificates are trusted to issue client certificates, when neither CAs nor root
+	// certificates are set the TLS roots of the organizations of the FabricMainChannels of the node are trusted
+	CAs []FabricTLSClientAuthCA `json:"cas,omitempty"`
+	// +optional
+	// +nullable
+	// PEM encoded root certificates trusted to issue client certificates
+	RootCerts []string `json:"rootCerts,omitempty"`
+}
+
+type FabricTLSClientAuthCA struct {
+	// Name of the FabricCA
+	Name string `json:"name"`
+	// Namespace of the FabricCA
+	Namespace string `json:"namespace"`
 }
+
 type FabricCABCCSPSW struct {
 	// +kubebuilder:default:="SHA2"
 	Hash string `json:"hash"`
@@ -799,24 +1331,58 @@ type FabricCABCCSPSW struct {
 
 type FabricCAIntermediate struct {
 	ParentServer FabricCAIntermediateParentServer `json:"parentServer"`
+	// +optional
+	// +kubebuilder:validation:Optional
+	// +nullable
+	// FabricCA in the cluster that signs the certificate of this CA, the URL and the TLS root of the parent server are taken from it
+	Parent *FabricCAIntermediateParent `json:"parent"`
+	// +optional
+	// +kubebuilder:validation:Optional
+	Enrollment FabricCAIntermediateEnrollment `json:"enrollment"`
+	// +optional
+	// +kubebuilder:validation:Optional
+	TLS FabricCAIntermediateTLS `json:"tls"`
 }
 type FabricCAIntermediateParentServer struct {
 	URL string `json:"url"`
 	// FabricCA Name of the organization
 	CAName string `json:"caName"`
 }
+type FabricCAIntermediateParent struct {
+	// Name of the parent FabricCA
+	Name string `json:"name"`
+	// Namespace of the parent FabricCA
+	Namespace string `json:"namespace"`
+	// MSP ID of the organization of the parent FabricCA
+	MSPID string `json:"mspID"`
+	// +optional
+	// Enroll ID of the identity registered on the parent for this CA, defaults to `<name>.<namespace>` of this FabricCA, suffixed with `-tls` for the TLS CA
+	EnrollID string `json:"enrollId"`
+	// +optional
+	// Enroll secret of the identity registered on the parent for this CA, defaults to a random secret generated by the operator and stored in the `<name>--intermediate-enrollment` secret
+	EnrollSecret string `json:"enrollSecret"`
+}
 type FabricCAIntermediateEnrollment struct {
-	Hosts   string `json:"hosts"`
+	// +optional
+	Hosts string `json:"hosts"`
+	// +optional
+	// +kubebuilder:default:="ca"
 	Profile string `json:"profile"`
-	Label   string `json:"label"`
+	// +optional
+	Label string `json:"label"`
 }
 type FabricCAIntermediateTLS struct {
-	CertFiles []string                      `json:"certFiles"`
-	Client    FabricCAIntermediateTLSClient `json:"client"`
+	// +optional
+	// +nullable
+	CertFiles []string `json:"certFiles"`
+	// +optional
+	Client FabricCAIntermediateTLSClient `json:"client"`
 }
 type FabricCAIntermediateTLSClient struct {
+	// +optional
 	CertFile string `json:"certFile"`
-	KeyFile  string `json:"keyFile"`
+	// +optional
+	KeyFile string `json:"keyFile"`
 }
 type FabricCARegistry struct {
 	MaxEnrollments int                `json:"max_enrollments"`
@@ -888,6 +1454,11 @@ const (
 	UnknownStatus        DeploymentStatus = "UNKNOWN"
 	UpdatingVersion      DeploymentStatus = "UPDATING_VERSION"
 	UpdatingCertificates DeploymentStatus = "UPDATING_CERTIFICATES"
+	// DegradedStatus is the status of a node that is running but fails a health check of its operations endpoint or
+	// lags behind the rest of the nodes of a channel
+	DegradedStatus DeploymentStatus = "DEGRADED"
+	// MigratingStateDb is the status of a peer whose state database is being switched
+	MigratingStateDb DeploymentStatus = "MIGRATING_STATE_DB"
 )
 
 // FabricCAStatus defines the observed state of FabricCA
@@ -905,6 +1476,57 @@ type FabricCAStatus struct {
 	CACert string `json:"ca_cert"`
 	// Root certificate for TLS certificates generated by FabricCA
 	TLSCACert string `json:"tlsca_cert"`
+	// +optional
+	// Base64 encoded Idemix issuer public key of the CA
+	IssuerPublicKey string `json:"issuer_public_key,omitempty"`
+	// +optional
+	// PEM encoded Idemix revocation public key of the CA
+	IssuerRevocationPublicKey string `json:"issuer_revocation_public_key,omitempty"`
+	// +optional
+	// +nullable
+	// Progress of the last rotation of the root certificates
+	Rotation *FabricCARotationStatus `json:"rotation,omitempty"`
+}
+
+type FabricCARotationPhase string
+
+const (
+	// The new roots are generated and trusted by the channels, the CA still signs with the previous keys
+	CARotationTransitionPhase FabricCARotationPhase = "TRANSITION"
+	// The CA signs with the new keys, the channels still trust the previous roots and the nodes are re-enrolled
+	CARotationSwitchedPhase FabricCARotationPhase = "SWITCHED"
+	// The previous roots are no longer trusted by the channels
+	CARotationCompletedPhase FabricCARotationPhase = "COMPLETED"
+	CARotationFailedPhase    FabricCARotationPhase = "FAILED"
+)
+
+type FabricCARotationStatus struct {
+	// Phase of the rotation, can be `TRANSITION`, `SWITCHED`, `COMPLETED` or `FAILED`
+	Phase FabricCARotationPhase `json:"phase"`
+	// +optional
+	Message string `json:"message"`
+	// Request time of the rotation in progress
+	RequestTime metav1.Time `json:"requestTime"`
+	// +optional
+	// +nullable
+	// Time at which the CA switched to the new keys
+	SwitchTime *metav1.Time `json:"switchTime,omitempty"`
+	// +optional
+	// Sign root certificate trusted by the channels besides `ca_cert`, the new root during `TRANSITION` and the previous
+	// root once `SWITCHED`
+	TrustedCACert string `json:"trustedCACert,omitempty"`
+	// +optional
+	// TLS root certificate trusted by the channels besides `tlsca_cert`
+	TrustedTLSCACert string `json:"trustedTLSCACert,omitempty"`
+	// +optional
+	// New sign root certificate signed by the previous key
+	CrossSignedCACert string `json:"crossSignedCACert,omitempty"`
+	// +optional
+	// New TLS root certificate signed by the previous key
+	CrossSignedTLSCACert string `json:"crossSignedTLSCACert,omitempty"`
+	// +optional
+	// Peers and orderers re-enrolled with the new roots
+	ReenrolledNodes []string `json:"reenrolledNodes,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -1494,6 +2116,125 @@ type FabricChaincodeSpec struct {
 	// +optional
 	// +kubebuilder:validation:Default={}
 	Env []corev1.EnvVar `json:"env"`
+
+	// +optional
+	// +nullable
+	// CouchDB indexes of the chaincode by channel, they are created in the CouchDB of every peer joined to the channel
+	CouchDBIndexes []FabricChaincodeCouchDBIndexes `json:"couchDBIndexes,omitempty"`
+
+	// +optional
+	// +nullable
+	// Scales the replicas of the chaincode with a HorizontalPodAutoscaler, Replicas is ignored when it's enabled
+	Autoscaling *FabricChaincodeAutoscaling `json:"autoscaling,omitempty"`
+
+	// +optional
+	// +nullable
+	// Liveness probe of the chaincode container, a TCP probe of the chaincode port by default
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// +optional
+	// +nullable
+	// Readiness probe of the chaincode container, a TCP probe of the chaincode port by default
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// +optional
+	// +nullable
+	// Startup probe of the chaincode container
+	StartupProbe *corev1.Probe `json:"startupProbe,omitempty"`
+
+	// +optional
+	// +nullable
+	PodDisruptionBudget *FabricPodDisruptionBudget `json:"podDisruptionBudget,omitempty"`
+
+	// +optional
+	// +nullable
+	// Pods that can be created over the replicas and pods that can be unavailable during a rolling update
+	RollingUpdate *appsv1.RollingUpdateDeployment `json:"rollingUpdate,omitempty"`
+}
+
+type FabricChaincodeAutoscaling struct {
+	// +kubebuilder:default:=false
+	Enabled bool `json:"enabled"`
+	// +optional
+	// +nullable
+	// +kubebuilder:validation:Minimum=1
+	// Minimum replicas of the chaincode, 1 by default
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// Maximum replicas of the chaincode
+	MaxReplicas int32 `json:"maxReplicas"`
+	// +optional
+	// +nullable
+	// +kubebuilder:validation:Minimum=1
+	// Average CPU utilization of the pods, relative to their requests, 80 when no metrics are set. It needs a CPU request in resources
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// +optional
+	// +nullable
+	// +kubebuilder:validation:Minimum=1
+	// Average memory utilization of the pods, relative to their requests. It needs a memory request in resources
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+	// +optional
+	// +nullable
+	// Additional metrics of the HorizontalPodAutoscaler, like the endorsements per second of a custom metrics API
+	Metrics []autoscalingv2.MetricSpec `json:"metrics,omitempty"`
+	// +optional
+	// +nullable
+	// Scale up and scale down policies of the HorizontalPodAutoscaler
+	Behavior *autoscalingv2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+}
+
+type FabricChaincodeCouchDBIndexes struct {
+	// +kubebuilder:validation:MinLength=1
+	// Channel where the chaincode is defined
+	Channel string `json:"channel"`
+	// +kubebuilder:validation:MinLength=1
+	// Name of the chaincode definition in the channel
+	ChaincodeName string                        `json:"chaincodeName"`
+	Indexes       []FabricChaincodeCouchDBIndex `json:"indexes"`
+}
+
+type FabricChaincodeCouchDBIndex struct {
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_.-]+$`
+	// Name of the index file, as it would be named under META-INF/statedb/couchdb/indexes
+	Name string `json:"name"`
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_-]+$`
+	// Private data collection of the index, the index is created in the private database of the collection
+	Collection string `json:"collection,omitempty"`
+	// +kubebuilder:validation:Type=object
+	// Index definition, the same JSON as an index file of the chaincode package
+	Definition apiextensionsv1.JSON `json:"definition"`
+}
+
+type CouchDBIndexState string
+
+const (
+	// the database of the chaincode doesn't exist yet in the CouchDB of the peer
+	CouchDBIndexPending CouchDBIndexState = "Pending"
+	// CouchDB is building the index
+	CouchDBIndexBuilding CouchDBIndexState = "Building"
+	CouchDBIndexReady    CouchDBIndexState = "Ready"
+	CouchDBIndexFailed   CouchDBIndexState = "Failed"
+	// the peer joined the channel doesn't use CouchDB as its state database
+	CouchDBIndexSkipped CouchDBIndexState = "Skipped"
+)
+
+// FabricChaincodeCouchDBIndexStatus is the state of an index in the CouchDB of a peer
+type FabricChaincodeCouchDBIndexStatus struct {
+	// Peer as namespace/name
+	Peer string `json:"peer"`
+	// +optional
+	// Pod of the peer, each replica of a peer has its own CouchDB unless it's external
+	Pod     string `json:"pod,omitempty"`
+	Channel string `json:"channel"`
+	Index   string `json:"index"`
+	// +optional
+	Collection string `json:"collection,omitempty"`
+	// Name of the database in CouchDB
+	Database string            `json:"database"`
+	State    CouchDBIndexState `json:"state"`
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // FabricChaincodeStatus defines the observed state of FabricChaincode
@@ -1502,6 +2243,10 @@ type FabricChaincodeStatus struct {
 	Message    string            `json:"message"`
 	// Status of the FabricChaincode
 	Status DeploymentStatus `json:"status"`
+	// +optional
+	// +nullable
+	// State of the CouchDB indexes in the peers of their channels
+	CouchDBIndexes []FabricChaincodeCouchDBIndexStatus `json:"couchDBIndexes,omitempty"`
 }
 
 // +genclient
@@ -1538,6 +2283,38 @@ type FabricMainChannelStatus struct {
 	Message    string            `json:"message"`
 	// Status of the FabricCA
 	Status DeploymentStatus `json:"status"`
+	// +optional
+	// +nullable
+	// Outcome of the last capability upgrade of the channel
+	CapabilityUpgrade *FabricMainChannelCapabilityUpgradeStatus `json:"capabilityUpgrade,omitempty"`
+}
+
+type CapabilityUpgradePhase string
+
+const (
+	CapabilityUpgradeInProgressPhase CapabilityUpgradePhase = "IN_PROGRESS"
+	CapabilityUpgradeCompletedPhase  CapabilityUpgradePhase = "COMPLETED"
+	CapabilityUpgradeRefusedPhase    CapabilityUpgradePhase = "REFUSED"
+	CapabilityUpgradeFailedPhase     CapabilityUpgradePhase = "FAILED"
+)
+
+type FabricMainChannelCapabilityUpgradeStatus struct {
+	// Phase of the capability upgrade, can be `IN_PROGRESS`, `COMPLETED`, `REFUSED` or `FAILED`
+	Phase CapabilityUpgradePhase `json:"phase"`
+	// Details about the outcome of the upgrade
+	Message string `json:"message"`
+	// Orderer capabilities currently set in the channel
+	OrdererCapabilities []string `json:"ordererCapabilities"`
+	// Channel capabilities currently set in the channel
+	ChannelCapabilities []string `json:"channelCapabilities"`
+	// Application capabilities currently set in the channel
+	ApplicationCapabilities []string `json:"applicationCapabilities"`
+	// +optional
+	// Peers and orderers running a version that doesn't support the requested capabilities
+	OutdatedComponents []string `json:"outdatedComponents,omitempty"`
+	// +optional
+	// +nullable
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
 // +genclient
@@ -1582,6 +2359,10 @@ type FabricMainChannelSpec struct {
 	PeerOrganizations []FabricMainChannelPeerOrganization `json:"peerOrganizations"`
 	// External peer organizations that are inside the kubernetes cluster
 	ExternalPeerOrganizations []FabricMainChannelExternalPeerOrganization `json:"externalPeerOrganizations"`
+	// +optional
+	// +nullable
+	// Idemix organizations of the application channel, their members sign anonymously with Idemix credentials
+	IdemixOrganizations []FabricMainChannelIdemixOrganization `json:"idemixOrganizations,omitempty"`
 
 	// +nullable
 	// Configuration about the channel
@@ -1642,7 +2423,7 @@ type FabricMainChannelApplicationConfig struct {
 	ACLs *map[string]string `json:"acls"`
 }
 type FabricMainChannelOrdererConfig struct {
-	// OrdererType of the consensus, default "etcdraft"
+	// OrdererType of the consensus, can be `etcdraft` or `BFT`, default "etcdraft"
 	// +kubebuilder:default:="etcdraft"
 	OrdererType string `json:"ordererType"`
 	// Capabilities of the channel
@@ -1667,6 +2448,54 @@ type FabricMainChannelOrdererConfig struct {
 	// +kubebuilder:validation:Optional
 	// +optional
 	EtcdRaft *FabricMainChannelEtcdRaft `json:"etcdRaft"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// SmartBFT options, used when the orderer type is `BFT`
+	SmartBFT *FabricMainChannelSmartBFT `json:"smartBFT"`
+}
+
+type FabricMainChannelSmartBFT struct {
+	// +kubebuilder:default:=100
+	RequestBatchMaxCount uint64 `json:"requestBatchMaxCount"`
+	// +kubebuilder:default:=10485760
+	RequestBatchMaxBytes uint64 `json:"requestBatchMaxBytes"`
+	// +kubebuilder:default:="50ms"
+	RequestBatchMaxInterval string `json:"requestBatchMaxInterval"`
+	// +kubebuilder:default:=200
+	IncomingMessageBufferSize uint64 `json:"incomingMessageBufferSize"`
+	// +kubebuilder:default:=100000
+	RequestPoolSize uint64 `json:"requestPoolSize"`
+	// +kubebuilder:default:="2s"
+	RequestForwardTimeout string `json:"requestForwardTimeout"`
+	// +kubebuilder:default:="20s"
+	RequestComplainTimeout string `json:"requestComplainTimeout"`
+	// +kubebuilder:default:="3m0s"
+	RequestAutoRemoveTimeout string `json:"requestAutoRemoveTimeout"`
+	// +kubebuilder:default:="5s"
+	ViewChangeResendInterval string `json:"viewChangeResendInterval"`
+	// +kubebuilder:default:="20s"
+	ViewChangeTimeout string `json:"viewChangeTimeout"`
+	// +kubebuilder:default:="1m0s"
+	LeaderHeartbeatTimeout string `json:"leaderHeartbeatTimeout"`
+	// +kubebuilder:default:=10
+	LeaderHeartbeatCount uint64 `json:"leaderHeartbeatCount"`
+	// +kubebuilder:default:="1s"
+	CollectTimeout string `json:"collectTimeout"`
+	// +optional
+	SyncOnStart bool `json:"syncOnStart"`
+	// +optional
+	SpeedUpViewChange bool `json:"speedUpViewChange"`
+	// Leader rotation, can be `ROTATION_UNSPECIFIED`, `ROTATION_OFF` or `ROTATION_ON`
+	// +kubebuilder:validation:Enum=ROTATION_UNSPECIFIED;ROTATION_OFF;ROTATION_ON
+	// +kubebuilder:default:="ROTATION_UNSPECIFIED"
+	LeaderRotation string `json:"leaderRotation"`
+	// +optional
+	DecisionsPerLeader uint64 `json:"decisionsPerLeader"`
+	// +optional
+	RequestMaxBytes uint64 `json:"requestMaxBytes"`
+	// +optional
+	RequestPoolSubmitTimeout string `json:"requestPoolSubmitTimeout"`
 }
 
 type FabricMainChannelEtcdRaft struct {
@@ -1727,15 +2556,38 @@ type FabricMainChannelIdentity struct {
 	SecretName string `json:"secretName"`
 	// Key inside the secret that holds the private key and certificate to interact with the network
 	SecretKey string `json:"secretKey"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// TLS private key and certificate presented to the peers and orderers that require client authentication
+	ClientTLS *HLFIdentity `json:"clientTLS,omitempty"`
 }
 
 type FabricMainChannelConsenter struct {
+	// +optional
 	// Orderer host of the consenter
 	Host string `json:"host"`
+	// +optional
 	// Orderer port of the consenter
 	Port int `json:"port"`
+	// +optional
 	// TLS Certificate of the orderer node
 	TLSCert string `json:"tlsCert"`
+	// +optional
+	// ID of the consenter for BFT ordering, it must be unique in the channel and never be reused. The consenter ID in
+	// the status of the orderer node is used when it's not set
+	ID uint32 `json:"id,omitempty"`
+	// +optional
+	// MSP ID of the orderer node, used for BFT ordering
+	MSPID string `json:"mspID,omitempty"`
+	// +optional
+	// Signing certificate of the orderer node, used for BFT ordering
+	Identity string `json:"identity,omitempty"`
+	// +optional
+	// +nullable
+	// Orderer node within the kubernetes cluster, the host, port, TLS certificate, MSP ID and signing certificate
+	// not set in the consenter are taken from it
+	OrdererNode *FabricMainChannelOrdererNode `json:"ordererNode,omitempty"`
 }
 
 type FabricMainChannelExternalPeerOrganization struct {
@@ -1745,6 +2597,42 @@ type FabricMainChannelExternalPeerOrganization struct {
 	TLSRootCert string `json:"tlsRootCert"`
 	// Root certificate authority for signing
 	SignRootCert string `json:"signRootCert"`
+	// +optional
+	// +nullable
+	// Anchor peers of the organization, if not set the anchor peers in the channel are left untouched
+	AnchorPeers []FabricMainChannelAnchorPeer `json:"anchorPeers"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// Policies of the organization, the default `Readers`, `Writers`, `Admins` and `Endorsement` policies are used for the ones not set
+	Policies *map[string]FabricMainChannelPoliciesConfig `json:"policies"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// NodeOUs configuration of the organization MSP
+	NodeOUs *FabricMainChannelNodeOUs `json:"nodeOUs"`
+}
+
+type FabricMainChannelIdemixOrganization struct {
+	// MSP ID of the organization
+	MSPID string `json:"mspID"`
+	// +optional
+	// FabricCA that issues the Idemix credentials of the organization, the issuer keys are read from its status
+	CAName string `json:"caName,omitempty"`
+	// +optional
+	// Namespace of the FabricCA
+	CANamespace string `json:"caNamespace,omitempty"`
+	// +optional
+	// Base64 encoded issuer public key, used when the issuer isn't a FabricCA
+	IssuerPublicKey string `json:"issuerPublicKey,omitempty"`
+	// +optional
+	// PEM encoded revocation public key of the issuer, used when the issuer isn't a FabricCA
+	RevocationPublicKey string `json:"revocationPublicKey,omitempty"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// Policies of the organization, the default `Readers`, `Writers`, `Admins` and `Endorsement` policies are used for the ones not set
+	Policies *map[string]FabricMainChannelPoliciesConfig `json:"policies"`
 }
 
 type FabricMainChannelExternalOrdererOrganization struct {
@@ -1756,6 +2644,16 @@ type FabricMainChannelExternalOrdererOrganization struct {
 	SignRootCert string `json:"signRootCert"`
 	// Orderer endpoints for the organization in the channel configuration
 	OrdererEndpoints []string `json:"ordererEndpoints"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// Policies of the organization, the default `Readers`, `Writers`, `Admins` and `Endorsement` policies are used for the ones not set
+	Policies *map[string]FabricMainChannelPoliciesConfig `json:"policies"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// NodeOUs configuration of the organization MSP
+	NodeOUs *FabricMainChannelNodeOUs `json:"nodeOUs"`
 }
 type OrgCertsRef struct {
 }
@@ -1771,6 +2669,25 @@ type FabricMainChannelPeerOrganization struct {
 	CAName string `json:"caName"`
 	// FabricCA Namespace of the organization
 	CANamespace string `json:"caNamespace"`
+	// +optional
+	// +nullable
+	// Anchor peers of the organization, if not set the anchor peers in the channel are left untouched
+	AnchorPeers []FabricMainChannelAnchorPeer `json:"anchorPeers"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// Policies of the organization, the default `Readers`, `Writers`, `Admins` and `Endorsement` policies are used for the ones not set
+	Policies *map[string]FabricMainChannelPoliciesConfig `json:"policies"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// NodeOUs configuration of the organization MSP
+	NodeOUs *FabricMainChannelNodeOUs `json:"nodeOUs"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// Embeds the current CRL of the FabricCA in the revocation list of the organization MSP
+	RevocationList *FabricChannelRevocationList `json:"revocationList"`
 }
 
 type FabricMainChannelOrdererOrganization struct {
@@ -1794,6 +2711,52 @@ type FabricMainChannelOrdererOrganization struct {
 	OrderersToJoin []FabricMainChannelOrdererNode `json:"orderersToJoin"`
 	// External orderers to be added to the channel
 	ExternalOrderersToJoin []FabricMainChannelExternalOrdererNode `json:"externalOrderersToJoin"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// Policies of the organization, the default `Readers`, `Writers`, `Admins` and `Endorsement` policies are used for the ones not set
+	Policies *map[string]FabricMainChannelPoliciesConfig `json:"policies"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// NodeOUs configuration of the organization MSP
+	NodeOUs *FabricMainChannelNodeOUs `json:"nodeOUs"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// Embeds the current CRL of the FabricCA in the revocation list of the organization MSP
+	RevocationList *FabricChannelRevocationList `json:"revocationList"`
+}
+
+// FabricChannelRevocationList references the FabricCA whose certificate revocation list is embedded in an organization MSP
+type FabricChannelRevocationList struct {
+	// +optional
+	// FabricCA name, defaults to the FabricCA of the organization
+	CAName string `json:"caName"`
+	// +optional
+	// FabricCA namespace, defaults to the FabricCA of the organization
+	CANamespace string `json:"caNamespace"`
+	// +optional
+	// Identity of the FabricCA registry used to generate the CRL, defaults to the first identity with the hf.GenCRL attribute
+	EnrollID string `json:"enrollId"`
+}
+
+type FabricMainChannelNodeOUs struct {
+	// Whether the identities are classified by their organizational unit
+	// +kubebuilder:default:=true
+	Enable bool `json:"enable"`
+	// Organizational unit of the client identities
+	// +kubebuilder:default:="client"
+	ClientOUIdentifier string `json:"clientOUIdentifier"`
+	// Organizational unit of the peer identities
+	// +kubebuilder:default:="peer"
+	PeerOUIdentifier string `json:"peerOUIdentifier"`
+	// Organizational unit of the admin identities
+	// +kubebuilder:default:="admin"
+	AdminOUIdentifier string `json:"adminOUIdentifier"`
+	// Organizational unit of the orderer identities
+	// +kubebuilder:default:="orderer"
+	OrdererOUIdentifier string `json:"ordererOUIdentifier"`
 }
 
 type FabricMainChannelExternalOrdererNode struct {
@@ -1870,6 +2833,16 @@ type FabricFollowerChannelSpec struct {
 	AnchorPeers []FabricFollowerChannelAnchorPeer `json:"anchorPeers"`
 	// Identity to use to interact with the peers and the orderers
 	HLFIdentity HLFIdentity `json:"hlfIdentity"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// TLS private key and certificate presented to the peers and orderers that require client authentication
+	ClientTLSIdentity *HLFIdentity `json:"clientTLSIdentity,omitempty"`
+	// +nullable
+	// +kubebuilder:validation:Optional
+	// +optional
+	// Embeds the current CRL of a FabricCA in the revocation list of the organization MSP, `caName` and `caNamespace` are required
+	RevocationList *FabricChannelRevocationList `json:"revocationList"`
 }
 
 type FabricFollowerChannelAnchorPeer struct {
@@ -1909,6 +2882,105 @@ type FabricFollowerChannelOrderer struct {
 	Certificate string `json:"certificate"`
 }
 
+// FabricChannelUpdateProposalStatus defines the observed state of FabricChannelUpdateProposal
+type FabricChannelUpdateProposalStatus struct {
+	Conditions status.Conditions `json:"conditions"`
+	Message    string            `json:"message"`
+	// Status of the FabricChannelUpdateProposal
+	Status DeploymentStatus `json:"status"`
+	// +optional
+	// Phase of the proposal, can be `PENDING`, `SIGNED`, `SUBMITTED` or `REJECTED`
+	Phase ChannelUpdateProposalPhase `json:"phase"`
+	// +optional
+	// Base64 encoded config update envelope that is being signed
+	ConfigUpdate string `json:"configUpdate"`
+	// +optional
+	// +nullable
+	// MSP IDs of the organizations that have signed the config update
+	SignedBy []string `json:"signedBy"`
+	// +optional
+	// Transaction ID of the submitted config update
+	TransactionID string `json:"transactionId"`
+}
+
+type ChannelUpdateProposalPhase string
+
+const (
+	// The collected signatures don't satisfy the mod policies yet
+	ProposalPendingPhase ChannelUpdateProposalPhase = "PENDING"
+	// The collected signatures satisfy the mod policies and the update is being submitted
+	ProposalSignedPhase ChannelUpdateProposalPhase = "SIGNED"
+	// The config update has been accepted by the ordering service
+	ProposalSubmittedPhase ChannelUpdateProposalPhase = "SUBMITTED"
+	// The config update can't be applied to the current channel configuration
+	ProposalRejectedPhase ChannelUpdateProposalPhase = "REJECTED"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:defaulter-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=fabricchannelupdateproposal,singular=fabricchannelupdateproposal
+// +kubebuilder:printcolumn:name="Channel",type="string",JSONPath=".spec.channelName"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +k8s:openapi-gen=true
+
+// FabricChannelUpdateProposal is the Schema for the hlfs API
+type FabricChannelUpdateProposal struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              FabricChannelUpdateProposalSpec   `json:"spec,omitempty"`
+	Status            FabricChannelUpdateProposalStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FabricChannelUpdateProposalList contains a list of FabricChannelUpdateProposal
+type FabricChannelUpdateProposalList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FabricChannelUpdateProposal `json:"items"`
+}
+
+// FabricChannelUpdateProposalSpec defines the desired state of FabricChannelUpdateProposal
+type FabricChannelUpdateProposalSpec struct {
+	// Name of the channel
+	ChannelName string `json:"channelName"`
+	// +optional
+	// FabricMainChannel used to compute the config update and to reach the orderers
+	MainChannel string `json:"mainChannel"`
+	// +optional
+	// +nullable
+	// Orderers to fetch the configuration block from and to submit the update to, ignored if `mainChannel` is set
+	Orderers []FabricFollowerChannelOrderer `json:"orderers"`
+	// +optional
+	// Base64 encoded config update envelope, computed from `mainChannel` if empty
+	ConfigUpdate string `json:"configUpdate"`
+	// MSP ID of the identity used to fetch the channel configuration and submit the update
+	SubmitterMSPID string `json:"submitterMspID"`
+	// +optional
+	// +nullable
+	// HLF Identities available in the cluster to sign the config update
+	Identities map[string]FabricMainChannelIdentity `json:"identities"`
+	// +optional
+	// +nullable
+	// Signatures of the config update collected from organizations outside the cluster
+	Signatures []FabricChannelUpdateSignature `json:"signatures"`
+}
+
+type FabricChannelUpdateSignature struct {
+	// +optional
+	// MSP ID of the organization that signed the config update, informative only
+	MSPID string `json:"mspID"`
+	// Base64 encoded config signature, as produced by `kubectl hlf channel signupdate`
+	Signature string `json:"signature"`
+}
+
 func init() {
 	SchemeBuilder.Register(&FabricPeer{}, &FabricPeerList{})
 	SchemeBuilder.Register(&FabricOrderingService{}, &FabricOrderingServiceList{})
@@ -1922,4 +2994,5 @@ func init() {
 	SchemeBuilder.Register(&FabricOperatorAPI{}, &FabricOperatorAPIList{})
 	SchemeBuilder.Register(&FabricMainChannel{}, &FabricMainChannelList{})
 	SchemeBuilder.Register(&FabricFollowerChannel{}, &FabricFollowerChannelList{})
+	SchemeBuilder.Register(&FabricChannelUpdateProposal{}, &FabricChannelUpdateProposalList{})
 }
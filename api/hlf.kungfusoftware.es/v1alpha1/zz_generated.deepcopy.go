@@ -23,8 +23,11 @@ package v1alpha1
 
 import (
 	"github.com/kfsoftware/hlf-operator/pkg/status"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/autoscaling/v2"
 	"k8s.io/api/core/v1"
 	"k8s.io/api/networking/v1beta1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -43,6 +46,22 @@ func (in *ApplicationCapabilities) DeepCopy() *ApplicationCapabilities {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BCCSPPKCS11) DeepCopyInto(out *BCCSPPKCS11) {
+	*out = *in
+	in.Pin.DeepCopyInto(&out.Pin)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BCCSPPKCS11.
+func (in *BCCSPPKCS11) DeepCopy() *BCCSPPKCS11 {
+	if in == nil {
+		return nil
+	}
+	out := new(BCCSPPKCS11)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CA) DeepCopyInto(out *CA) {
 	*out = *in
@@ -125,6 +144,11 @@ func (in *ChannelConfig) DeepCopy() *ChannelConfig {
 func (in *Component) DeepCopyInto(out *Component) {
 	*out = *in
 	out.Catls = in.Catls
+	if in.KeyRequest != nil {
+		in, out := &in.KeyRequest, &out.KeyRequest
+		*out = new(KeyRequest)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Component.
@@ -196,7 +220,7 @@ func (in *Csr) DeepCopy() *Csr {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Enrollment) DeepCopyInto(out *Enrollment) {
 	*out = *in
-	out.Component = in.Component
+	in.Component.DeepCopyInto(&out.Component)
 	in.TLS.DeepCopyInto(&out.TLS)
 }
 
@@ -218,6 +242,11 @@ func (in *ExternalBuilder) DeepCopyInto(out *ExternalBuilder) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Kubernetes != nil {
+		in, out := &in.Kubernetes, &out.Kubernetes
+		*out = new(ExternalBuilderKubernetes)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalBuilder.
@@ -230,6 +259,31 @@ func (in *ExternalBuilder) DeepCopy() *ExternalBuilder {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalBuilderKubernetes) DeepCopyInto(out *ExternalBuilderKubernetes) {
+	*out = *in
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalBuilderKubernetes.
+func (in *ExternalBuilderKubernetes) DeepCopy() *ExternalBuilderKubernetes {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalBuilderKubernetes)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricCA) DeepCopyInto(out *FabricCA) {
 	*out = *in
@@ -281,6 +335,11 @@ func (in *FabricCAAffiliation) DeepCopy() *FabricCAAffiliation {
 func (in *FabricCABCCSP) DeepCopyInto(out *FabricCABCCSP) {
 	*out = *in
 	out.SW = in.SW
+	if in.PKCS11 != nil {
+		in, out := &in.PKCS11, &out.PKCS11
+		*out = new(BCCSPPKCS11)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCABCCSP.
@@ -449,6 +508,16 @@ func (in *FabricCACrypto) DeepCopy() *FabricCACrypto {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricCADatabase) DeepCopyInto(out *FabricCADatabase) {
 	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(FabricCADatabaseCredentials)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(FabricCADatabaseTLS)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCADatabase.
@@ -461,6 +530,62 @@ func (in *FabricCADatabase) DeepCopy() *FabricCADatabase {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCADatabaseCredentials) DeepCopyInto(out *FabricCADatabaseCredentials) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCADatabaseCredentials.
+func (in *FabricCADatabaseCredentials) DeepCopy() *FabricCADatabaseCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCADatabaseCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCADatabaseTLS) DeepCopyInto(out *FabricCADatabaseTLS) {
+	*out = *in
+	in.CACert.DeepCopyInto(&out.CACert)
+	if in.ClientCert != nil {
+		in, out := &in.ClientCert, &out.ClientCert
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientKey != nil {
+		in, out := &in.ClientKey, &out.ClientKey
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCADatabaseTLS.
+func (in *FabricCADatabaseTLS) DeepCopy() *FabricCADatabaseTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCADatabaseTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCAIdemix) DeepCopyInto(out *FabricCAIdemix) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCAIdemix.
+func (in *FabricCAIdemix) DeepCopy() *FabricCAIdemix {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCAIdemix)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricCAIdentity) DeepCopyInto(out *FabricCAIdentity) {
 	*out = *in
@@ -496,6 +621,13 @@ func (in *FabricCAIdentityAttrs) DeepCopy() *FabricCAIdentityAttrs {
 func (in *FabricCAIntermediate) DeepCopyInto(out *FabricCAIntermediate) {
 	*out = *in
 	out.ParentServer = in.ParentServer
+	if in.Parent != nil {
+		in, out := &in.Parent, &out.Parent
+		*out = new(FabricCAIntermediateParent)
+		**out = **in
+	}
+	out.Enrollment = in.Enrollment
+	in.TLS.DeepCopyInto(&out.TLS)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCAIntermediate.
@@ -523,6 +655,21 @@ func (in *FabricCAIntermediateEnrollment) DeepCopy() *FabricCAIntermediateEnroll
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCAIntermediateParent) DeepCopyInto(out *FabricCAIntermediateParent) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCAIntermediateParent.
+func (in *FabricCAIntermediateParent) DeepCopy() *FabricCAIntermediateParent {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCAIntermediateParent)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricCAIntermediateParentServer) DeepCopyInto(out *FabricCAIntermediateParentServer) {
 	*out = *in
@@ -587,8 +734,13 @@ func (in *FabricCAItemConf) DeepCopyInto(out *FabricCAItemConf) {
 	}
 	out.CRL = in.CRL
 	in.Registry.DeepCopyInto(&out.Registry)
-	out.Intermediate = in.Intermediate
-	out.BCCSP = in.BCCSP
+	in.Intermediate.DeepCopyInto(&out.Intermediate)
+	in.BCCSP.DeepCopyInto(&out.BCCSP)
+	if in.KeyRequest != nil {
+		in, out := &in.KeyRequest, &out.KeyRequest
+		*out = new(KeyRequest)
+		**out = **in
+	}
 	if in.Affiliations != nil {
 		in, out := &in.Affiliations, &out.Affiliations
 		*out = make([]FabricCAAffiliation, len(*in))
@@ -606,6 +758,16 @@ func (in *FabricCAItemConf) DeepCopyInto(out *FabricCAItemConf) {
 		*out = new(FabricTLSCACrypto)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LDAP != nil {
+		in, out := &in.LDAP, &out.LDAP
+		*out = new(FabricCALDAP)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Idemix != nil {
+		in, out := &in.Idemix, &out.Idemix
+		*out = new(FabricCAIdemix)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCAItemConf.
@@ -618,6 +780,140 @@ func (in *FabricCAItemConf) DeepCopy() *FabricCAItemConf {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCALDAP) DeepCopyInto(out *FabricCALDAP) {
+	*out = *in
+	if in.BindPassword != nil {
+		in, out := &in.BindPassword, &out.BindPassword
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(FabricCALDAPTLS)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Attribute.DeepCopyInto(&out.Attribute)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCALDAP.
+func (in *FabricCALDAP) DeepCopy() *FabricCALDAP {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCALDAP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCALDAPAttribute) DeepCopyInto(out *FabricCALDAPAttribute) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Converters != nil {
+		in, out := &in.Converters, &out.Converters
+		*out = make([]FabricCALDAPConverter, len(*in))
+		copy(*out, *in)
+	}
+	if in.Maps != nil {
+		in, out := &in.Maps, &out.Maps
+		*out = make([]FabricCALDAPMap, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCALDAPAttribute.
+func (in *FabricCALDAPAttribute) DeepCopy() *FabricCALDAPAttribute {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCALDAPAttribute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCALDAPConverter) DeepCopyInto(out *FabricCALDAPConverter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCALDAPConverter.
+func (in *FabricCALDAPConverter) DeepCopy() *FabricCALDAPConverter {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCALDAPConverter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCALDAPMap) DeepCopyInto(out *FabricCALDAPMap) {
+	*out = *in
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]FabricCALDAPMapEntry, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCALDAPMap.
+func (in *FabricCALDAPMap) DeepCopy() *FabricCALDAPMap {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCALDAPMap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCALDAPMapEntry) DeepCopyInto(out *FabricCALDAPMapEntry) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCALDAPMapEntry.
+func (in *FabricCALDAPMapEntry) DeepCopy() *FabricCALDAPMapEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCALDAPMapEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCALDAPTLS) DeepCopyInto(out *FabricCALDAPTLS) {
+	*out = *in
+	in.CACert.DeepCopyInto(&out.CACert)
+	if in.ClientCert != nil {
+		in, out := &in.ClientCert, &out.ClientCert
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientKey != nil {
+		in, out := &in.ClientKey, &out.ClientKey
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCALDAPTLS.
+func (in *FabricCALDAPTLS) DeepCopy() *FabricCALDAPTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCALDAPTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricCAList) DeepCopyInto(out *FabricCAList) {
 	*out = *in
@@ -720,6 +1016,47 @@ func (in *FabricCARegistry) DeepCopy() *FabricCARegistry {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCARotation) DeepCopyInto(out *FabricCARotation) {
+	*out = *in
+	in.RequestTime.DeepCopyInto(&out.RequestTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCARotation.
+func (in *FabricCARotation) DeepCopy() *FabricCARotation {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCARotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricCARotationStatus) DeepCopyInto(out *FabricCARotationStatus) {
+	*out = *in
+	in.RequestTime.DeepCopyInto(&out.RequestTime)
+	if in.SwitchTime != nil {
+		in, out := &in.SwitchTime, &out.SwitchTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ReenrolledNodes != nil {
+		in, out := &in.ReenrolledNodes, &out.ReenrolledNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCARotationStatus.
+func (in *FabricCARotationStatus) DeepCopy() *FabricCARotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricCARotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricCASigning) DeepCopyInto(out *FabricCASigning) {
 	*out = *in
@@ -838,6 +1175,18 @@ func (in *FabricCASpec) DeepCopyInto(out *FabricCASpec) {
 		*out = new(v1.Affinity)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(FabricPodDisruptionBudget)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
 		*out = make([]v1.Toleration, len(*in))
@@ -865,7 +1214,7 @@ func (in *FabricCASpec) DeepCopyInto(out *FabricCASpec) {
 		*out = new(FabricIstio)
 		(*in).DeepCopyInto(*out)
 	}
-	out.Database = in.Database
+	in.Database.DeepCopyInto(&out.Database)
 	if in.Hosts != nil {
 		in, out := &in.Hosts, &out.Hosts
 		*out = make([]string, len(*in))
@@ -886,6 +1235,11 @@ func (in *FabricCASpec) DeepCopyInto(out *FabricCASpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(FabricCARotation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCASpec.
@@ -923,6 +1277,11 @@ func (in *FabricCAStatus) DeepCopyInto(out *FabricCAStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(FabricCARotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricCAStatus.
@@ -994,41 +1353,136 @@ func (in *FabricChaincode) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FabricChaincodeList) DeepCopyInto(out *FabricChaincodeList) {
+func (in *FabricChaincodeAutoscaling) DeepCopyInto(out *FabricChaincodeAutoscaling) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]FabricChaincode, len(*in))
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetCPUUtilizationPercentage != nil {
+		in, out := &in.TargetCPUUtilizationPercentage, &out.TargetCPUUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetMemoryUtilizationPercentage != nil {
+		in, out := &in.TargetMemoryUtilizationPercentage, &out.TargetMemoryUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]v2.MetricSpec, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Behavior != nil {
+		in, out := &in.Behavior, &out.Behavior
+		*out = new(v2.HorizontalPodAutoscalerBehavior)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChaincodeList.
-func (in *FabricChaincodeList) DeepCopy() *FabricChaincodeList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChaincodeAutoscaling.
+func (in *FabricChaincodeAutoscaling) DeepCopy() *FabricChaincodeAutoscaling {
 	if in == nil {
 		return nil
 	}
-	out := new(FabricChaincodeList)
+	out := new(FabricChaincodeAutoscaling)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *FabricChaincodeList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FabricChaincodeSpec) DeepCopyInto(out *FabricChaincodeSpec) {
+func (in *FabricChaincodeCouchDBIndex) DeepCopyInto(out *FabricChaincodeCouchDBIndex) {
 	*out = *in
-	if in.ImagePullSecrets != nil {
+	in.Definition.DeepCopyInto(&out.Definition)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChaincodeCouchDBIndex.
+func (in *FabricChaincodeCouchDBIndex) DeepCopy() *FabricChaincodeCouchDBIndex {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricChaincodeCouchDBIndex)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricChaincodeCouchDBIndexStatus) DeepCopyInto(out *FabricChaincodeCouchDBIndexStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChaincodeCouchDBIndexStatus.
+func (in *FabricChaincodeCouchDBIndexStatus) DeepCopy() *FabricChaincodeCouchDBIndexStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricChaincodeCouchDBIndexStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricChaincodeCouchDBIndexes) DeepCopyInto(out *FabricChaincodeCouchDBIndexes) {
+	*out = *in
+	if in.Indexes != nil {
+		in, out := &in.Indexes, &out.Indexes
+		*out = make([]FabricChaincodeCouchDBIndex, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChaincodeCouchDBIndexes.
+func (in *FabricChaincodeCouchDBIndexes) DeepCopy() *FabricChaincodeCouchDBIndexes {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricChaincodeCouchDBIndexes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricChaincodeList) DeepCopyInto(out *FabricChaincodeList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FabricChaincode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChaincodeList.
+func (in *FabricChaincodeList) DeepCopy() *FabricChaincodeList {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricChaincodeList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FabricChaincodeList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricChaincodeSpec) DeepCopyInto(out *FabricChaincodeSpec) {
+	*out = *in
+	if in.ImagePullSecrets != nil {
 		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
 		*out = make([]v1.LocalObjectReference, len(*in))
 		copy(*out, *in)
@@ -1062,6 +1516,43 @@ func (in *FabricChaincodeSpec) DeepCopyInto(out *FabricChaincodeSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CouchDBIndexes != nil {
+		in, out := &in.CouchDBIndexes, &out.CouchDBIndexes
+		*out = make([]FabricChaincodeCouchDBIndexes, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(FabricChaincodeAutoscaling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(v1.Probe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(FabricPodDisruptionBudget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(appsv1.RollingUpdateDeployment)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChaincodeSpec.
@@ -1084,6 +1575,11 @@ func (in *FabricChaincodeStatus) DeepCopyInto(out *FabricChaincodeStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CouchDBIndexes != nil {
+		in, out := &in.CouchDBIndexes, &out.CouchDBIndexes
+		*out = make([]FabricChaincodeCouchDBIndexStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChaincodeStatus.
@@ -1096,6 +1592,154 @@ func (in *FabricChaincodeStatus) DeepCopy() *FabricChaincodeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricChannelRevocationList) DeepCopyInto(out *FabricChannelRevocationList) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChannelRevocationList.
+func (in *FabricChannelRevocationList) DeepCopy() *FabricChannelRevocationList {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricChannelRevocationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricChannelUpdateProposal) DeepCopyInto(out *FabricChannelUpdateProposal) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChannelUpdateProposal.
+func (in *FabricChannelUpdateProposal) DeepCopy() *FabricChannelUpdateProposal {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricChannelUpdateProposal)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FabricChannelUpdateProposal) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricChannelUpdateProposalList) DeepCopyInto(out *FabricChannelUpdateProposalList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FabricChannelUpdateProposal, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChannelUpdateProposalList.
+func (in *FabricChannelUpdateProposalList) DeepCopy() *FabricChannelUpdateProposalList {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricChannelUpdateProposalList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FabricChannelUpdateProposalList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricChannelUpdateProposalSpec) DeepCopyInto(out *FabricChannelUpdateProposalSpec) {
+	*out = *in
+	if in.Orderers != nil {
+		in, out := &in.Orderers, &out.Orderers
+		*out = make([]FabricFollowerChannelOrderer, len(*in))
+		copy(*out, *in)
+	}
+	if in.Identities != nil {
+		in, out := &in.Identities, &out.Identities
+		*out = make(map[string]FabricMainChannelIdentity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Signatures != nil {
+		in, out := &in.Signatures, &out.Signatures
+		*out = make([]FabricChannelUpdateSignature, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChannelUpdateProposalSpec.
+func (in *FabricChannelUpdateProposalSpec) DeepCopy() *FabricChannelUpdateProposalSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricChannelUpdateProposalSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricChannelUpdateProposalStatus) DeepCopyInto(out *FabricChannelUpdateProposalStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(status.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SignedBy != nil {
+		in, out := &in.SignedBy, &out.SignedBy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChannelUpdateProposalStatus.
+func (in *FabricChannelUpdateProposalStatus) DeepCopy() *FabricChannelUpdateProposalStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricChannelUpdateProposalStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricChannelUpdateSignature) DeepCopyInto(out *FabricChannelUpdateSignature) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricChannelUpdateSignature.
+func (in *FabricChannelUpdateSignature) DeepCopy() *FabricChannelUpdateSignature {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricChannelUpdateSignature)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricExplorer) DeepCopyInto(out *FabricExplorer) {
 	*out = *in
@@ -1351,6 +1995,16 @@ func (in *FabricFollowerChannelSpec) DeepCopyInto(out *FabricFollowerChannelSpec
 		copy(*out, *in)
 	}
 	out.HLFIdentity = in.HLFIdentity
+	if in.ClientTLSIdentity != nil {
+		in, out := &in.ClientTLSIdentity, &out.ClientTLSIdentity
+		*out = new(HLFIdentity)
+		**out = **in
+	}
+	if in.RevocationList != nil {
+		in, out := &in.RevocationList, &out.RevocationList
+		*out = new(FabricChannelRevocationList)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricFollowerChannelSpec.
@@ -1519,6 +2173,45 @@ func (in *FabricMainChannelApplicationConfig) DeepCopy() *FabricMainChannelAppli
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricMainChannelCapabilityUpgradeStatus) DeepCopyInto(out *FabricMainChannelCapabilityUpgradeStatus) {
+	*out = *in
+	if in.OrdererCapabilities != nil {
+		in, out := &in.OrdererCapabilities, &out.OrdererCapabilities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ChannelCapabilities != nil {
+		in, out := &in.ChannelCapabilities, &out.ChannelCapabilities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ApplicationCapabilities != nil {
+		in, out := &in.ApplicationCapabilities, &out.ApplicationCapabilities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OutdatedComponents != nil {
+		in, out := &in.OutdatedComponents, &out.OutdatedComponents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastUpdateTime != nil {
+		in, out := &in.LastUpdateTime, &out.LastUpdateTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelCapabilityUpgradeStatus.
+func (in *FabricMainChannelCapabilityUpgradeStatus) DeepCopy() *FabricMainChannelCapabilityUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricMainChannelCapabilityUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricMainChannelConfig) DeepCopyInto(out *FabricMainChannelConfig) {
 	*out = *in
@@ -1563,6 +2256,11 @@ func (in *FabricMainChannelConfig) DeepCopy() *FabricMainChannelConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricMainChannelConsenter) DeepCopyInto(out *FabricMainChannelConsenter) {
 	*out = *in
+	if in.OrdererNode != nil {
+		in, out := &in.OrdererNode, &out.OrdererNode
+		*out = new(FabricMainChannelOrdererNode)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelConsenter.
@@ -1633,6 +2331,22 @@ func (in *FabricMainChannelExternalOrdererOrganization) DeepCopyInto(out *Fabric
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = new(map[string]FabricMainChannelPoliciesConfig)
+		if **in != nil {
+			in, out := *in, *out
+			*out = make(map[string]FabricMainChannelPoliciesConfig, len(*in))
+			for key, val := range *in {
+				(*out)[key] = val
+			}
+		}
+	}
+	if in.NodeOUs != nil {
+		in, out := &in.NodeOUs, &out.NodeOUs
+		*out = new(FabricMainChannelNodeOUs)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelExternalOrdererOrganization.
@@ -1648,6 +2362,27 @@ func (in *FabricMainChannelExternalOrdererOrganization) DeepCopy() *FabricMainCh
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricMainChannelExternalPeerOrganization) DeepCopyInto(out *FabricMainChannelExternalPeerOrganization) {
 	*out = *in
+	if in.AnchorPeers != nil {
+		in, out := &in.AnchorPeers, &out.AnchorPeers
+		*out = make([]FabricMainChannelAnchorPeer, len(*in))
+		copy(*out, *in)
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = new(map[string]FabricMainChannelPoliciesConfig)
+		if **in != nil {
+			in, out := *in, *out
+			*out = make(map[string]FabricMainChannelPoliciesConfig, len(*in))
+			for key, val := range *in {
+				(*out)[key] = val
+			}
+		}
+	}
+	if in.NodeOUs != nil {
+		in, out := &in.NodeOUs, &out.NodeOUs
+		*out = new(FabricMainChannelNodeOUs)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelExternalPeerOrganization.
@@ -1660,9 +2395,40 @@ func (in *FabricMainChannelExternalPeerOrganization) DeepCopy() *FabricMainChann
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricMainChannelIdemixOrganization) DeepCopyInto(out *FabricMainChannelIdemixOrganization) {
+	*out = *in
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = new(map[string]FabricMainChannelPoliciesConfig)
+		if **in != nil {
+			in, out := *in, *out
+			*out = make(map[string]FabricMainChannelPoliciesConfig, len(*in))
+			for key, val := range *in {
+				(*out)[key] = val
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelIdemixOrganization.
+func (in *FabricMainChannelIdemixOrganization) DeepCopy() *FabricMainChannelIdemixOrganization {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricMainChannelIdemixOrganization)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricMainChannelIdentity) DeepCopyInto(out *FabricMainChannelIdentity) {
 	*out = *in
+	if in.ClientTLS != nil {
+		in, out := &in.ClientTLS, &out.ClientTLS
+		*out = new(HLFIdentity)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelIdentity.
@@ -1707,6 +2473,21 @@ func (in *FabricMainChannelList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricMainChannelNodeOUs) DeepCopyInto(out *FabricMainChannelNodeOUs) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelNodeOUs.
+func (in *FabricMainChannelNodeOUs) DeepCopy() *FabricMainChannelNodeOUs {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricMainChannelNodeOUs)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricMainChannelOrdererBatchSize) DeepCopyInto(out *FabricMainChannelOrdererBatchSize) {
 	*out = *in
@@ -1751,6 +2532,11 @@ func (in *FabricMainChannelOrdererConfig) DeepCopyInto(out *FabricMainChannelOrd
 		*out = new(FabricMainChannelEtcdRaft)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SmartBFT != nil {
+		in, out := &in.SmartBFT, &out.SmartBFT
+		*out = new(FabricMainChannelSmartBFT)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelOrdererConfig.
@@ -1796,6 +2582,27 @@ func (in *FabricMainChannelOrdererOrganization) DeepCopyInto(out *FabricMainChan
 		*out = make([]FabricMainChannelExternalOrdererNode, len(*in))
 		copy(*out, *in)
 	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = new(map[string]FabricMainChannelPoliciesConfig)
+		if **in != nil {
+			in, out := *in, *out
+			*out = make(map[string]FabricMainChannelPoliciesConfig, len(*in))
+			for key, val := range *in {
+				(*out)[key] = val
+			}
+		}
+	}
+	if in.NodeOUs != nil {
+		in, out := &in.NodeOUs, &out.NodeOUs
+		*out = new(FabricMainChannelNodeOUs)
+		**out = **in
+	}
+	if in.RevocationList != nil {
+		in, out := &in.RevocationList, &out.RevocationList
+		*out = new(FabricChannelRevocationList)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelOrdererOrganization.
@@ -1811,6 +2618,32 @@ func (in *FabricMainChannelOrdererOrganization) DeepCopy() *FabricMainChannelOrd
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricMainChannelPeerOrganization) DeepCopyInto(out *FabricMainChannelPeerOrganization) {
 	*out = *in
+	if in.AnchorPeers != nil {
+		in, out := &in.AnchorPeers, &out.AnchorPeers
+		*out = make([]FabricMainChannelAnchorPeer, len(*in))
+		copy(*out, *in)
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = new(map[string]FabricMainChannelPoliciesConfig)
+		if **in != nil {
+			in, out := *in, *out
+			*out = make(map[string]FabricMainChannelPoliciesConfig, len(*in))
+			for key, val := range *in {
+				(*out)[key] = val
+			}
+		}
+	}
+	if in.NodeOUs != nil {
+		in, out := &in.NodeOUs, &out.NodeOUs
+		*out = new(FabricMainChannelNodeOUs)
+		**out = **in
+	}
+	if in.RevocationList != nil {
+		in, out := &in.RevocationList, &out.RevocationList
+		*out = new(FabricChannelRevocationList)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelPeerOrganization.
@@ -1818,22 +2651,37 @@ func (in *FabricMainChannelPeerOrganization) DeepCopy() *FabricMainChannelPeerOr
 	if in == nil {
 		return nil
 	}
-	out := new(FabricMainChannelPeerOrganization)
+	out := new(FabricMainChannelPeerOrganization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricMainChannelPoliciesConfig) DeepCopyInto(out *FabricMainChannelPoliciesConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelPoliciesConfig.
+func (in *FabricMainChannelPoliciesConfig) DeepCopy() *FabricMainChannelPoliciesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricMainChannelPoliciesConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FabricMainChannelPoliciesConfig) DeepCopyInto(out *FabricMainChannelPoliciesConfig) {
+func (in *FabricMainChannelSmartBFT) DeepCopyInto(out *FabricMainChannelSmartBFT) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelPoliciesConfig.
-func (in *FabricMainChannelPoliciesConfig) DeepCopy() *FabricMainChannelPoliciesConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelSmartBFT.
+func (in *FabricMainChannelSmartBFT) DeepCopy() *FabricMainChannelSmartBFT {
 	if in == nil {
 		return nil
 	}
-	out := new(FabricMainChannelPoliciesConfig)
+	out := new(FabricMainChannelSmartBFT)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1845,7 +2693,7 @@ func (in *FabricMainChannelSpec) DeepCopyInto(out *FabricMainChannelSpec) {
 		in, out := &in.Identities, &out.Identities
 		*out = make(map[string]FabricMainChannelIdentity, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	if in.AdminPeerOrganizations != nil {
@@ -1856,12 +2704,23 @@ func (in *FabricMainChannelSpec) DeepCopyInto(out *FabricMainChannelSpec) {
 	if in.PeerOrganizations != nil {
 		in, out := &in.PeerOrganizations, &out.PeerOrganizations
 		*out = make([]FabricMainChannelPeerOrganization, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.ExternalPeerOrganizations != nil {
 		in, out := &in.ExternalPeerOrganizations, &out.ExternalPeerOrganizations
 		*out = make([]FabricMainChannelExternalPeerOrganization, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.IdemixOrganizations != nil {
+		in, out := &in.IdemixOrganizations, &out.IdemixOrganizations
+		*out = make([]FabricMainChannelIdemixOrganization, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.ChannelConfig != nil {
 		in, out := &in.ChannelConfig, &out.ChannelConfig
@@ -1890,7 +2749,9 @@ func (in *FabricMainChannelSpec) DeepCopyInto(out *FabricMainChannelSpec) {
 	if in.Consenters != nil {
 		in, out := &in.Consenters, &out.Consenters
 		*out = make([]FabricMainChannelConsenter, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -1914,6 +2775,11 @@ func (in *FabricMainChannelStatus) DeepCopyInto(out *FabricMainChannelStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CapabilityUpgrade != nil {
+		in, out := &in.CapabilityUpgrade, &out.CapabilityUpgrade
+		*out = new(FabricMainChannelCapabilityUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricMainChannelStatus.
@@ -2027,6 +2893,41 @@ func (in *FabricNetworkConfigStatus) DeepCopy() *FabricNetworkConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricNodeFailedCheck) DeepCopyInto(out *FabricNodeFailedCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricNodeFailedCheck.
+func (in *FabricNodeFailedCheck) DeepCopy() *FabricNodeFailedCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricNodeFailedCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricNodeHealth) DeepCopyInto(out *FabricNodeHealth) {
+	*out = *in
+	if in.FailedChecks != nil {
+		in, out := &in.FailedChecks, &out.FailedChecks
+		*out = make([]FabricNodeFailedCheck, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricNodeHealth.
+func (in *FabricNodeHealth) DeepCopy() *FabricNodeHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricNodeHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricOperationsConsole) DeepCopyInto(out *FabricOperationsConsole) {
 	*out = *in
@@ -2556,6 +3457,21 @@ func (in *FabricOrdererNode) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricOrdererNodeChannelStatus) DeepCopyInto(out *FabricOrdererNodeChannelStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricOrdererNodeChannelStatus.
+func (in *FabricOrdererNodeChannelStatus) DeepCopy() *FabricOrdererNodeChannelStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricOrdererNodeChannelStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricOrdererNodeList) DeepCopyInto(out *FabricOrdererNodeList) {
 	*out = *in
@@ -2588,6 +3504,21 @@ func (in *FabricOrdererNodeList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricOrdererNodeLogging) DeepCopyInto(out *FabricOrdererNodeLogging) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricOrdererNodeLogging.
+func (in *FabricOrdererNodeLogging) DeepCopy() *FabricOrdererNodeLogging {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricOrdererNodeLogging)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricOrdererNodeSpec) DeepCopyInto(out *FabricOrdererNodeSpec) {
 	*out = *in
@@ -2612,6 +3543,18 @@ func (in *FabricOrdererNodeSpec) DeepCopyInto(out *FabricOrdererNodeSpec) {
 		in, out := &in.UpdateCertificateTime, &out.UpdateCertificateTime
 		*out = (*in).DeepCopy()
 	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(FabricPodDisruptionBudget)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ServiceMonitor != nil {
 		in, out := &in.ServiceMonitor, &out.ServiceMonitor
 		*out = new(ServiceMonitor)
@@ -2630,6 +3573,11 @@ func (in *FabricOrdererNodeSpec) DeepCopyInto(out *FabricOrdererNodeSpec) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Logging != nil {
+		in, out := &in.Logging, &out.Logging
+		*out = new(FabricOrdererNodeLogging)
+		**out = **in
+	}
 	if in.ImagePullSecrets != nil {
 		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
 		*out = make([]v1.LocalObjectReference, len(*in))
@@ -2659,6 +3607,21 @@ func (in *FabricOrdererNodeSpec) DeepCopyInto(out *FabricOrdererNodeSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PKCS11 != nil {
+		in, out := &in.PKCS11, &out.PKCS11
+		*out = new(BCCSPPKCS11)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientAuth != nil {
+		in, out := &in.ClientAuth, &out.ClientAuth
+		*out = new(FabricTLSClientAuth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OrdererOverrides != nil {
+		in, out := &in.OrdererOverrides, &out.OrdererOverrides
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricOrdererNodeSpec.
@@ -2685,6 +3648,21 @@ func (in *FabricOrdererNodeStatus) DeepCopyInto(out *FabricOrdererNodeStatus) {
 		in, out := &in.LastCertificateUpdate, &out.LastCertificateUpdate
 		*out = (*in).DeepCopy()
 	}
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(FabricNodeHealth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Channels != nil {
+		in, out := &in.Channels, &out.Channels
+		*out = make([]FabricOrdererNodeChannelStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricOrdererNodeStatus.
@@ -2831,13 +3809,28 @@ func (in *FabricPeer) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricPeerChannelStatus) DeepCopyInto(out *FabricPeerChannelStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricPeerChannelStatus.
+func (in *FabricPeerChannelStatus) DeepCopy() *FabricPeerChannelStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricPeerChannelStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricPeerCouchDB) DeepCopyInto(out *FabricPeerCouchDB) {
 	*out = *in
 	if in.ExternalCouchDB != nil {
 		in, out := &in.ExternalCouchDB, &out.ExternalCouchDB
 		*out = new(FabricPeerExternalCouchDB)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -2851,6 +3844,77 @@ func (in *FabricPeerCouchDB) DeepCopy() *FabricPeerCouchDB {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricPeerCouchDBCredentials) DeepCopyInto(out *FabricPeerCouchDBCredentials) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricPeerCouchDBCredentials.
+func (in *FabricPeerCouchDBCredentials) DeepCopy() *FabricPeerCouchDBCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricPeerCouchDBCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricPeerCouchDBProxy) DeepCopyInto(out *FabricPeerCouchDBProxy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricPeerCouchDBProxy.
+func (in *FabricPeerCouchDBProxy) DeepCopy() *FabricPeerCouchDBProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricPeerCouchDBProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricPeerCouchDBStatus) DeepCopyInto(out *FabricPeerCouchDBStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricPeerCouchDBStatus.
+func (in *FabricPeerCouchDBStatus) DeepCopy() *FabricPeerCouchDBStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricPeerCouchDBStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricPeerCouchDBTLS) DeepCopyInto(out *FabricPeerCouchDBTLS) {
+	*out = *in
+	in.CACert.DeepCopyInto(&out.CACert)
+	if in.ClientCert != nil {
+		in, out := &in.ClientCert, &out.ClientCert
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientKey != nil {
+		in, out := &in.ClientKey, &out.ClientKey
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricPeerCouchDBTLS.
+func (in *FabricPeerCouchDBTLS) DeepCopy() *FabricPeerCouchDBTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricPeerCouchDBTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricPeerCouchdbExporter) DeepCopyInto(out *FabricPeerCouchdbExporter) {
 	*out = *in
@@ -2884,6 +3948,21 @@ func (in *FabricPeerDiscovery) DeepCopy() *FabricPeerDiscovery {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricPeerExternalCouchDB) DeepCopyInto(out *FabricPeerExternalCouchDB) {
 	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(FabricPeerCouchDBCredentials)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(FabricPeerCouchDBTLS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(FabricPeerCouchDBProxy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricPeerExternalCouchDB.
@@ -2983,6 +4062,18 @@ func (in *FabricPeerSpec) DeepCopyInto(out *FabricPeerSpec) {
 		*out = new(v1.Affinity)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(FabricPodDisruptionBudget)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ServiceMonitor != nil {
 		in, out := &in.ServiceMonitor, &out.ServiceMonitor
 		*out = new(ServiceMonitor)
@@ -3059,6 +4150,21 @@ func (in *FabricPeerSpec) DeepCopyInto(out *FabricPeerSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PKCS11 != nil {
+		in, out := &in.PKCS11, &out.PKCS11
+		*out = new(BCCSPPKCS11)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientAuth != nil {
+		in, out := &in.ClientAuth, &out.ClientAuth
+		*out = new(FabricTLSClientAuth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CoreOverrides != nil {
+		in, out := &in.CoreOverrides, &out.CoreOverrides
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricPeerSpec.
@@ -3086,6 +4192,29 @@ func (in *FabricPeerSpecGossip) DeepCopy() *FabricPeerSpecGossip {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricPeerStateDbMigration) DeepCopyInto(out *FabricPeerStateDbMigration) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricPeerStateDbMigration.
+func (in *FabricPeerStateDbMigration) DeepCopy() *FabricPeerStateDbMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricPeerStateDbMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricPeerStatus) DeepCopyInto(out *FabricPeerStatus) {
 	*out = *in
@@ -3100,6 +4229,31 @@ func (in *FabricPeerStatus) DeepCopyInto(out *FabricPeerStatus) {
 		in, out := &in.LastCertificateUpdate, &out.LastCertificateUpdate
 		*out = (*in).DeepCopy()
 	}
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(FabricNodeHealth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Channels != nil {
+		in, out := &in.Channels, &out.Channels
+		*out = make([]FabricPeerChannelStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.StateDbMigration != nil {
+		in, out := &in.StateDbMigration, &out.StateDbMigration
+		*out = new(FabricPeerStateDbMigration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CouchDB != nil {
+		in, out := &in.CouchDB, &out.CouchDB
+		*out = new(FabricPeerCouchDBStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricPeerStatus.
@@ -3130,6 +4284,26 @@ func (in *FabricPeerStorage) DeepCopy() *FabricPeerStorage {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricPodDisruptionBudget) DeepCopyInto(out *FabricPodDisruptionBudget) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricPodDisruptionBudget.
+func (in *FabricPodDisruptionBudget) DeepCopy() *FabricPodDisruptionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricPodDisruptionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FabricTLSCACrypto) DeepCopyInto(out *FabricTLSCACrypto) {
 	*out = *in
@@ -3146,6 +4320,46 @@ func (in *FabricTLSCACrypto) DeepCopy() *FabricTLSCACrypto {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricTLSClientAuth) DeepCopyInto(out *FabricTLSClientAuth) {
+	*out = *in
+	if in.CAs != nil {
+		in, out := &in.CAs, &out.CAs
+		*out = make([]FabricTLSClientAuthCA, len(*in))
+		copy(*out, *in)
+	}
+	if in.RootCerts != nil {
+		in, out := &in.RootCerts, &out.RootCerts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricTLSClientAuth.
+func (in *FabricTLSClientAuth) DeepCopy() *FabricTLSClientAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricTLSClientAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FabricTLSClientAuthCA) DeepCopyInto(out *FabricTLSClientAuthCA) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FabricTLSClientAuthCA.
+func (in *FabricTLSClientAuthCA) DeepCopy() *FabricTLSClientAuthCA {
+	if in == nil {
+		return nil
+	}
+	out := new(FabricTLSClientAuthCA)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GRPCProxy) DeepCopyInto(out *GRPCProxy) {
 	*out = *in
@@ -3258,6 +4472,21 @@ func (in *IngressPath) DeepCopy() *IngressPath {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyRequest) DeepCopyInto(out *KeyRequest) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRequest.
+func (in *KeyRequest) DeepCopy() *KeyRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OrdererCapabilities) DeepCopyInto(out *OrdererCapabilities) {
 	*out = *in
@@ -3276,7 +4505,7 @@ func (in *OrdererCapabilities) DeepCopy() *OrdererCapabilities {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OrdererEnrollment) DeepCopyInto(out *OrdererEnrollment) {
 	*out = *in
-	out.Component = in.Component
+	in.Component.DeepCopyInto(&out.Component)
 	in.TLS.DeepCopyInto(&out.TLS)
 }
 
@@ -3487,6 +4716,11 @@ func (in *TLS) DeepCopyInto(out *TLS) {
 	*out = *in
 	out.Catls = in.Catls
 	in.Csr.DeepCopyInto(&out.Csr)
+	if in.KeyRequest != nil {
+		in, out := &in.KeyRequest, &out.KeyRequest
+		*out = new(KeyRequest)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLS.
@@ -2,10 +2,8 @@ package ca
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"crypto/elliptic"
+	"crypto"
 	"crypto/rand"
-	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/base64"
@@ -22,11 +20,14 @@ import (
 	"net"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
 	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"helm.sh/helm/v3/pkg/action"
@@ -55,17 +56,8 @@ type FabricCAReconciler struct {
 	ClientSet *kubernetes.Clientset
 }
 
-func parseECDSAPrivateKey(contents []byte) (*ecdsa.PrivateKey, error) {
-	block, _ := pem.Decode(contents)
-	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-	if err != nil {
-		return nil, err
-	}
-	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
-	if !ok {
-		return nil, errors.New("private key is not of ECDSA type")
-	}
-	return ecdsaKey, nil
+func parsePrivateKey(contents []byte) (crypto.Signer, error) {
+	return utils.ParsePrivateKey(contents)
 }
 func parseX509Certificate(contents []byte) (*x509.Certificate, error) {
 	block, _ := pem.Decode(contents)
@@ -76,7 +68,7 @@ func parseX509Certificate(contents []byte) (*x509.Certificate, error) {
 	return crt, nil
 }
 
-func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, crypto.Signer, error) {
 	secretName := fmt.Sprintf("%s--tls-cryptomaterial", chartName)
 	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, v1.GetOptions{})
 	if err != nil {
@@ -84,7 +76,7 @@ func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namesp
 	}
 	tlsKeyData := secret.Data["tls.key"]
 	tlsCrtData := secret.Data["tls.crt"]
-	key, err := parseECDSAPrivateKey(tlsKeyData)
+	key, err := parsePrivateKey(tlsKeyData)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -95,7 +87,7 @@ func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namesp
 	return crt, key, nil
 }
 
-func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+func getExistingSignCrypto(client kubernetes.Interface, chartName string, namespace string) (*x509.Certificate, crypto.Signer, error) {
 	secretName := fmt.Sprintf("%s--msp-cryptomaterial", chartName)
 
 	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, v1.GetOptions{})
@@ -104,9 +96,13 @@ func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, names
 	}
 	tlsKeyData := secret.Data["keyfile"]
 	tlsCrtData := secret.Data["certfile"]
-	key, err := parseECDSAPrivateKey(tlsKeyData)
-	if err != nil {
-		return nil, nil, err
+	// the key is empty when it's kept in a PKCS#11 token
+	var key crypto.Signer
+	if len(tlsKeyData) > 0 {
+		key, err = parsePrivateKey(tlsKeyData)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 	crt, err := parseX509Certificate(tlsCrtData)
 	if err != nil {
@@ -115,7 +111,7 @@ func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, names
 	return crt, key, nil
 }
 
-func getExistingSignTLSCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+func getExistingSignTLSCrypto(client kubernetes.Interface, chartName string, namespace string) (*x509.Certificate, crypto.Signer, error) {
 	secretName := fmt.Sprintf("%s--msp-tls-cryptomaterial", chartName)
 
 	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, v1.GetOptions{})
@@ -124,9 +120,13 @@ func getExistingSignTLSCrypto(client *kubernetes.Clientset, chartName string, na
 	}
 	tlsKeyData := secret.Data["keyfile"]
 	tlsCrtData := secret.Data["certfile"]
-	key, err := parseECDSAPrivateKey(tlsKeyData)
-	if err != nil {
-		return nil, nil, err
+	// the key is empty when it's kept in a PKCS#11 token
+	var key crypto.Signer
+	if len(tlsKeyData) > 0 {
+		key, err = parsePrivateKey(tlsKeyData)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 	crt, err := parseX509Certificate(tlsCrtData)
 	if err != nil {
@@ -136,15 +136,10 @@ func getExistingSignTLSCrypto(client *kubernetes.Clientset, chartName string, na
 }
 
 // compute Subject Key Identifier
-func computeSKI(privKey *ecdsa.PrivateKey) []byte {
-	// Marshall the public key
-	raw := elliptic.Marshal(privKey.Curve, privKey.PublicKey.X, privKey.PublicKey.Y)
-
-	// Hash it
-	hash := sha256.Sum256(raw)
-	return hash[:]
+func computeSKI(privKey crypto.Signer) ([]byte, error) {
+	return utils.ComputeSKI(privKey.Public())
 }
-func CreateDefaultTLSCA(clientSet *kubernetes.Clientset, spec hlfv1alpha1.FabricCASpec) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+func CreateDefaultTLSCA(clientSet *kubernetes.Clientset, spec hlfv1alpha1.FabricCASpec) (*x509.Certificate, crypto.Signer, error) {
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
 	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
@@ -171,7 +166,14 @@ func CreateDefaultTLSCA(clientSet *kubernetes.Clientset, spec hlfv1alpha1.Fabric
 			ips = append(ips, addr)
 		}
 	}
-	caPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err := certs.ValidateSignKeyRequest(spec.TLSCA.KeyRequest); err != nil {
+		return nil, nil, err
+	}
+	caPrivKey, err := certs.GenerateKey(spec.TLSCA.KeyRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	ski, err := computeSKI(caPrivKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -193,10 +195,10 @@ func CreateDefaultTLSCA(clientSet *kubernetes.Clientset, spec hlfv1alpha1.Fabric
 		DNSNames:              dnsNames,
 		IPAddresses:           ips,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-		SubjectKeyId:          computeSKI(caPrivKey),
+		SubjectKeyId:          ski,
 	}
 
-	caBytes, err := x509.CreateCertificate(rand.Reader, x509Cert, x509Cert, &caPrivKey.PublicKey, caPrivKey)
+	caBytes, err := x509.CreateCertificate(rand.Reader, x509Cert, x509Cert, caPrivKey.Public(), caPrivKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -207,18 +209,28 @@ func CreateDefaultTLSCA(clientSet *kubernetes.Clientset, spec hlfv1alpha1.Fabric
 	return crt, caPrivKey, nil
 }
 
-func CreateDefaultCA(conf hlfv1alpha1.FabricCAItemConf) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+func CreateDefaultCA(conf hlfv1alpha1.FabricCAItemConf) (*x509.Certificate, crypto.Signer, error) {
+	if err := certs.ValidateSignKeyRequest(conf.KeyRequest); err != nil {
+		return nil, nil, err
+	}
+	caPrivKey, err := certs.GenerateKey(conf.KeyRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return createRootCACert(conf, caPrivKey)
+}
+
+// createRootCACert creates the self signed root certificate of the CA for the key
+func createRootCACert(conf hlfv1alpha1.FabricCAItemConf, caPrivKey crypto.Signer) (*x509.Certificate, crypto.Signer, error) {
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
 	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
-		log.Fatalf("Failed to generate serial number: %v", err)
 		return nil, nil, err
 	}
-	caPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ski, err := computeSKI(caPrivKey)
 	if err != nil {
 		return nil, nil, err
 	}
-
 	signCA := &x509.Certificate{
 		SerialNumber: serialNumber,
 		Subject: pkix.Name{
@@ -232,12 +244,12 @@ func CreateDefaultCA(conf hlfv1alpha1.FabricCAItemConf) (*x509.Certificate, *ecd
 		NotBefore:             time.Now().AddDate(0, 0, -1),
 		NotAfter:              time.Now().AddDate(10, 0, 0),
 		IsCA:                  true,
-		SubjectKeyId:          computeSKI(caPrivKey),
+		SubjectKeyId:          ski,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
 		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageKeyEncipherment,
 		BasicConstraintsValid: true,
 	}
-	caBytes, err := x509.CreateCertificate(rand.Reader, signCA, signCA, &caPrivKey.PublicKey, caPrivKey)
+	caBytes, err := x509.CreateCertificate(rand.Reader, signCA, signCA, caPrivKey.Public(), caPrivKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -269,7 +281,7 @@ func actionLogger(logger logr.Logger) func(format string, v ...interface{}) {
 		logger.Info(fmt.Sprintf(format, v...))
 	}
 }
-func mapCRDItemConfToChart(conf hlfv1alpha1.FabricCAItemConf) FabricCAChartItemConf {
+func mapCRDItemConfToChart(conf hlfv1alpha1.FabricCAItemConf, intermediate *intermediateCA, parentCertFile string, ldapURL string) FabricCAChartItemConf {
 	names := []FabricCAChartNames{}
 	for _, name := range conf.CSR.Names {
 		names = append(names, FabricCAChartNames{
@@ -379,29 +391,38 @@ func mapCRDItemConfToChart(conf hlfv1alpha1.FabricCAItemConf) FabricCAChartItemC
 			MaxEnrollments: conf.Registry.MaxEnrollments,
 			Identities:     identities,
 		},
-		Intermediate: FabricCAChartIntermediate{
-			ParentServer: FabricCAChartIntermediateParentServer{
-				URL:    conf.Intermediate.ParentServer.URL,
-				CAName: conf.Intermediate.ParentServer.CAName,
-			},
-		},
+		Intermediate: mapIntermediateToChart(conf, intermediate, parentCertFile),
 		Affiliations: affiliations,
 		BCCSP: FabricCAChartBCCSP{
 			Default: conf.BCCSP.Default,
 			SW: FabricCAChartBCCSPSW{
 				Hash:     conf.BCCSP.SW.Hash,
-				Security: conf.BCCSP.SW.Security,
+				Security: bccspSecurity(conf),
 			},
 		},
 	}
+	item.BCCSP.PKCS11, item.PKCS11PinSecret = mapPKCS11ToChart(conf)
+	item.LDAP = mapLDAPToChart(conf, ldapURL)
+	item.Idemix = mapIdemixToChart(conf)
 	return item
 }
-func parseCrypto(key string, cert string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+
+// bccspSecurity returns the security level of the BCCSP of the CA, raised to match a P-384 key of the CA
+func bccspSecurity(conf hlfv1alpha1.FabricCAItemConf) string {
+	keySecurity := certs.KeySecurityLevel(conf.KeyRequest)
+	security, err := strconv.Atoi(conf.BCCSP.SW.Security)
+	if err != nil || security < keySecurity {
+		return strconv.Itoa(keySecurity)
+	}
+	return conf.BCCSP.SW.Security
+}
+
+func parseCrypto(key string, cert string) (*x509.Certificate, crypto.Signer, error) {
 	keyBytes, err := base64.StdEncoding.DecodeString(key)
 	if err != nil {
 		return nil, nil, err
 	}
-	pk, err := utils.ParseECDSAPrivateKey(keyBytes)
+	pk, err := utils.ParsePrivateKey(keyBytes)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -415,8 +436,35 @@ func parseCrypto(key string, cert string) (*x509.Certificate, *ecdsa.PrivateKey,
 	}
 	return x509Cert, pk, nil
 }
-func GetConfig(conf *hlfv1alpha1.FabricCA, client *kubernetes.Clientset, chartName string, namespace string) (*FabricCAChart, error) {
+func GetConfig(
+	ctx context.Context,
+	conf *hlfv1alpha1.FabricCA,
+	client *kubernetes.Clientset,
+	hlfClientSet *operatorv1.Clientset,
+	chartName string,
+	namespace string,
+) (*FabricCAChart, error) {
 	spec := conf.Spec
+	for _, itemConf := range []hlfv1alpha1.FabricCAItemConf{spec.CA, spec.TLSCA} {
+		if err := validatePKCS11CA(itemConf); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateDatabase(spec); err != nil {
+		return nil, err
+	}
+	datasource, err := getDatasource(ctx, client, namespace, spec.Database)
+	if err != nil {
+		return nil, err
+	}
+	caLDAPURL, err := getLDAPURL(ctx, client, namespace, spec.CA)
+	if err != nil {
+		return nil, err
+	}
+	tlsCALDAPURL, err := getLDAPURL(ctx, client, namespace, spec.TLSCA)
+	if err != nil {
+		return nil, err
+	}
 	tlsCert, tlsKey, err := getExistingTLSCrypto(client, chartName, namespace)
 	if err != nil {
 		tlsCert, tlsKey, err = CreateDefaultTLSCA(client, spec)
@@ -424,27 +472,57 @@ func GetConfig(conf *hlfv1alpha1.FabricCA, client *kubernetes.Clientset, chartNa
 			return nil, err
 		}
 	}
-	signCert, signKey, err := getExistingSignCrypto(client, chartName, namespace)
-	if err != nil {
-		if conf.Spec.CA.CA != nil && conf.Spec.CA.CA.Key != "" && conf.Spec.CA.CA.Cert != "" {
-			signCert, signKey, err = parseCrypto(conf.Spec.CA.CA.Key, conf.Spec.CA.CA.Cert)
-		} else {
-			signCert, signKey, err = CreateDefaultCA(spec.CA)
+	var signIntermediate *intermediateCA
+	var signCert *x509.Certificate
+	var signKey crypto.Signer
+	if isIntermediateCA(spec.CA) {
+		signIntermediate, err = getExistingIntermediateCA(ctx, client, hlfClientSet, spec.CA, fmt.Sprintf("%s--msp-cryptomaterial", chartName), namespace, false)
+		if err != nil {
+			signIntermediate, err = getSpecIntermediateCA(ctx, hlfClientSet, spec.CA, false)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if signIntermediate == nil {
+			signIntermediate, err = enrollIntermediateCA(ctx, client, hlfClientSet, fabricParentCA{}, conf, spec.CA, chartName, namespace, false)
+			if err != nil {
+				return nil, err
+			}
 		}
+		signCert, signKey = signIntermediate.Cert, signIntermediate.Key
+	} else if signCert, signKey, err = getExistingSignCrypto(client, chartName, namespace); err != nil {
+		signCert, signKey, err = createRootCA(client, namespace, spec.CA)
 		if err != nil {
 			return nil, err
 		}
+	} else if err = checkRootCAKey(spec.CA, signKey); err != nil {
+		return nil, err
 	}
-	caTLSSignCert, caTLSSignKey, err := getExistingSignTLSCrypto(client, chartName, namespace)
-	if err != nil {
-		if conf.Spec.TLSCA.CA != nil && conf.Spec.TLSCA.CA.Key != "" && conf.Spec.TLSCA.CA.Cert != "" {
-			caTLSSignCert, caTLSSignKey, err = parseCrypto(conf.Spec.TLSCA.CA.Key, conf.Spec.TLSCA.CA.Cert)
-		} else {
-			caTLSSignCert, caTLSSignKey, err = CreateDefaultCA(spec.TLSCA)
+	var caTLSSignIntermediate *intermediateCA
+	var caTLSSignCert *x509.Certificate
+	var caTLSSignKey crypto.Signer
+	if isIntermediateCA(spec.TLSCA) {
+		caTLSSignIntermediate, err = getExistingIntermediateCA(ctx, client, hlfClientSet, spec.TLSCA, fmt.Sprintf("%s--msp-tls-cryptomaterial", chartName), namespace, true)
+		if err != nil {
+			caTLSSignIntermediate, err = getSpecIntermediateCA(ctx, hlfClientSet, spec.TLSCA, true)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if caTLSSignIntermediate == nil {
+			caTLSSignIntermediate, err = enrollIntermediateCA(ctx, client, hlfClientSet, fabricParentCA{}, conf, spec.TLSCA, chartName, namespace, true)
+			if err != nil {
+				return nil, err
+			}
 		}
+		caTLSSignCert, caTLSSignKey = caTLSSignIntermediate.Cert, caTLSSignIntermediate.Key
+	} else if caTLSSignCert, caTLSSignKey, err = getExistingSignTLSCrypto(client, chartName, namespace); err != nil {
+		caTLSSignCert, caTLSSignKey, err = createRootCA(client, namespace, spec.TLSCA)
 		if err != nil {
 			return nil, err
 		}
+	} else if err = checkRootCAKey(spec.TLSCA, caTLSSignKey); err != nil {
+		return nil, err
 	}
 	tlsCRTEncoded := pem.EncodeToMemory(&pem.Block{
 		Type:  "CERTIFICATE",
@@ -463,27 +541,19 @@ func GetConfig(conf *hlfv1alpha1.FabricCA, client *kubernetes.Clientset, chartNa
 		Type:  "CERTIFICATE",
 		Bytes: signCert.Raw,
 	})
-	signEncodedPK, err := x509.MarshalPKCS8PrivateKey(signKey)
+	signPEMEncodedPK, err := encodeCAKey(signKey)
 	if err != nil {
 		return nil, err
 	}
-	signPEMEncodedPK := pem.EncodeToMemory(&pem.Block{
-		Type:  "PRIVATE KEY",
-		Bytes: signEncodedPK,
-	})
 
 	caTLSSignCRTEncoded := pem.EncodeToMemory(&pem.Block{
 		Type:  "CERTIFICATE",
 		Bytes: caTLSSignCert.Raw,
 	})
-	caTLSSignEncodedPK, err := x509.MarshalPKCS8PrivateKey(caTLSSignKey)
+	caTLSSignPEMEncodedPK, err := encodeCAKey(caTLSSignKey)
 	if err != nil {
 		return nil, err
 	}
-	caTLSSignPEMEncodedPK := pem.EncodeToMemory(&pem.Block{
-		Type:  "PRIVATE KEY",
-		Bytes: caTLSSignEncodedPK,
-	})
 	istioPort := 443
 	if spec.Istio != nil && spec.Istio.Port != 0 {
 		istioPort = spec.Istio.Port
@@ -508,6 +578,14 @@ func GetConfig(conf *hlfv1alpha1.FabricCA, client *kubernetes.Clientset, chartNa
 	if conf.Spec.TLSCA.CA != nil {
 		msp.TLSCAChainfile = conf.Spec.TLSCA.CA.Chain
 	}
+	if signIntermediate != nil {
+		msp.Chainfile = signIntermediate.Chain
+		msp.ParentCertfile = signIntermediate.ParentTLSCert
+	}
+	if caTLSSignIntermediate != nil {
+		msp.TLSCAChainfile = caTLSSignIntermediate.Chain
+		msp.TLSCAParentCertfile = caTLSSignIntermediate.ParentTLSCert
+	}
 	var serviceMonitor ServiceMonitor
 	if spec.ServiceMonitor != nil && spec.ServiceMonitor.Enabled {
 		serviceMonitor = ServiceMonitor{
@@ -527,6 +605,10 @@ func GetConfig(conf *hlfv1alpha1.FabricCA, client *kubernetes.Clientset, chartNa
 		}
 	}
 
+	replicas := spec.Replicas
+	if replicas == 0 {
+		replicas = 1
+	}
 	var c = FabricCAChart{
 		ImagePullSecrets: spec.ImagePullSecrets,
 		EnvVars:          spec.Env,
@@ -552,11 +634,9 @@ func GetConfig(conf *hlfv1alpha1.FabricCA, client *kubernetes.Clientset, chartNa
 			AccessMode:   string(spec.Storage.AccessMode),
 			Size:         spec.Storage.Size,
 		},
-		Msp: msp,
-		Database: Database{
-			Type:       spec.Database.Type,
-			Datasource: spec.Database.Datasource,
-		},
+		Msp:      msp,
+		Replicas: replicas,
+		Database: mapDatabaseToChart(spec.Database, datasource),
 		Resources: Resources{
 			Requests: Requests{
 				CPU:    spec.Resources.Requests.Cpu().String(),
@@ -567,11 +647,13 @@ func GetConfig(conf *hlfv1alpha1.FabricCA, client *kubernetes.Clientset, chartNa
 				Memory: spec.Resources.Limits.Memory().String(),
 			},
 		},
-		NodeSelector: spec.NodeSelector,
-		Tolerations:  spec.Tolerations,
-		Affinity:     spec.Affinity,
-		Debug:        spec.Debug,
-		CLRSizeLimit: spec.CLRSizeLimit,
+		NodeSelector:              spec.NodeSelector,
+		Tolerations:               spec.Tolerations,
+		Affinity:                  spec.Affinity,
+		TopologySpreadConstraints: spec.TopologySpreadConstraints,
+		PriorityClassName:         spec.PriorityClassName,
+		Debug:                     spec.Debug,
+		CLRSizeLimit:              spec.CLRSizeLimit,
 		Metrics: FabricCAChartMetrics{
 			Provider: spec.Metrics.Provider,
 			Statsd: FabricCAChartMetricsStatsd{
@@ -582,8 +664,8 @@ func GetConfig(conf *hlfv1alpha1.FabricCA, client *kubernetes.Clientset, chartNa
 			},
 		},
 
-		Ca:    mapCRDItemConfToChart(spec.CA),
-		TLSCA: mapCRDItemConfToChart(spec.TLSCA),
+		Ca:    mapCRDItemConfToChart(spec.CA, signIntermediate, "/var/hyperledger/fabric-ca/msp-secret/parentcertfile", caLDAPURL),
+		TLSCA: mapCRDItemConfToChart(spec.TLSCA, caTLSSignIntermediate, "/var/hyperledger/fabric-ca/msp-tls-secret/parentcertfile", tlsCALDAPURL),
 		Cors: Cors{
 			Enabled: spec.Cors.Enabled,
 			Origins: spec.Cors.Origins,
@@ -594,12 +676,16 @@ func GetConfig(conf *hlfv1alpha1.FabricCA, client *kubernetes.Clientset, chartNa
 
 type Status struct {
 	Status    hlfv1alpha1.DeploymentStatus
+	Message   string
 	TlsCert   string
 	CACert    string
 	TLSCACert string
 	NodeURL   string
 	NodePort  int
 	NodeHost  string
+	// Idemix issuer keys of the CA, empty until the CA is running
+	IssuerPublicKey           string
+	IssuerRevocationPublicKey string
 }
 
 func GetServiceName(releaseName string) string {
@@ -652,6 +738,10 @@ func GetCAState(clientSet *kubernetes.Clientset, ca *hlfv1alpha1.FabricCA, relea
 			r.Status = hlfv1alpha1.PendingStatus
 		}
 	}
+	if err := checkDatabase(ca.Spec.Database); err != nil {
+		r.Status = hlfv1alpha1.FailedStatus
+		r.Message = err.Error()
+	}
 	svcName := GetServiceName(releaseName)
 	svc, err := clientSet.CoreV1().Services(ns).Get(ctx, svcName, v1.GetOptions{})
 	if err != nil {
@@ -678,6 +768,15 @@ func GetCAState(clientSet *kubernetes.Clientset, ca *hlfv1alpha1.FabricCA, relea
 		return nil, err
 	}
 	r.CACert = string(utils.EncodeX509Certificate(signCrt))
+	if isIntermediateCA(ca.Spec.CA) {
+		chain, err := getExistingChain(clientSet, fmt.Sprintf("%s--msp-cryptomaterial", releaseName), ns)
+		if err != nil {
+			return nil, err
+		}
+		if chain != "" {
+			r.CACert = chain
+		}
+	}
 	hlfmetrics.UpdateCertificateExpiry(
 		"ca",
 		"signca",
@@ -690,6 +789,15 @@ func GetCAState(clientSet *kubernetes.Clientset, ca *hlfv1alpha1.FabricCA, relea
 		return nil, err
 	}
 	r.TLSCACert = string(utils.EncodeX509Certificate(tlsCACrt))
+	if isIntermediateCA(ca.Spec.TLSCA) {
+		chain, err := getExistingChain(clientSet, fmt.Sprintf("%s--msp-tls-cryptomaterial", releaseName), ns)
+		if err != nil {
+			return nil, err
+		}
+		if chain != "" {
+			r.TLSCACert = chain
+		}
+	}
 	hlfmetrics.UpdateCertificateExpiry(
 		"ca",
 		"tlsca",
@@ -697,6 +805,9 @@ func GetCAState(clientSet *kubernetes.Clientset, ca *hlfv1alpha1.FabricCA, relea
 		releaseName,
 		ns,
 	)
+	if r.Status == hlfv1alpha1.RunningStatus {
+		r.IssuerPublicKey, r.IssuerRevocationPublicKey = getIdemixIssuerKeys(ca, r.TlsCert)
+	}
 	return r, nil
 }
 
@@ -796,6 +907,15 @@ func Reconcile(
 	if err != nil {
 		return ctrl.Result{}, err
 	}
+	hlfClientSet, err := operatorv1.NewForConfig(r.Config)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.reconcilePodDisruptionBudgets(ctx, clientSet, ns)
+	if err != nil {
+		setConditionStatus(hlf, hlfv1alpha1.FailedStatus, false, err, false)
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, hlf)
+	}
 
 	if exists {
 		// update
@@ -813,19 +933,29 @@ func Reconcile(
 			reqLogger.Error(err, "Failed to get CA.")
 			return ctrl.Result{}, err
 		}
+		rotationStatus, rotationInProgress, err := reconcileRotation(ctx, clientSet, hlfClientSet, hlf, s, releaseName, ns, isServingRoot)
+		if err != nil {
+			setConditionStatus(hlf, hlfv1alpha1.FailedStatus, false, err, false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, hlf)
+		}
 		fca := hlf.DeepCopy()
+		fca.Status.Rotation = rotationStatus
 		fca.Status.Status = s.Status
-		fca.Status.Message = ""
+		fca.Status.Message = s.Message
 		fca.Status.TlsCert = s.TlsCert
 		fca.Status.TLSCACert = s.TLSCACert
 		fca.Status.CACert = s.CACert
 		fca.Status.NodePort = s.NodePort
+		if s.IssuerPublicKey != "" {
+			fca.Status.IssuerPublicKey = s.IssuerPublicKey
+			fca.Status.IssuerRevocationPublicKey = s.IssuerRevocationPublicKey
+		}
 		fca.Status.Conditions.SetCondition(status.Condition{
 			Type:               status.ConditionType(s.Status),
 			Status:             "True",
 			LastTransitionTime: v1.Time{},
 		})
-		c, err := GetConfig(hlf, clientSet, releaseName, req.Namespace)
+		c, err := GetConfig(ctx, hlf, clientSet, hlfClientSet, releaseName, req.Namespace)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -866,6 +996,12 @@ func Reconcile(
 				RequeueAfter: 10 * time.Second,
 			}, nil
 		case hlfv1alpha1.RunningStatus:
+			if rotationInProgress {
+				log.Infof("CA %s rotating its root certificates, refreshing state in %v", fca.Name, caRotationRequeueInterval)
+				return ctrl.Result{
+					RequeueAfter: caRotationRequeueInterval,
+				}, nil
+			}
 			return ctrl.Result{}, nil
 		default:
 			return ctrl.Result{
@@ -883,7 +1019,7 @@ func Reconcile(
 		if err != nil {
 			return ctrl.Result{}, err
 		}
-		c, err := GetConfig(hlf, clientSet, name, req.Namespace)
+		c, err := GetConfig(ctx, hlf, clientSet, hlfClientSet, name, req.Namespace)
 		if err != nil {
 			reqLogger.Error(err, "Failed to get config")
 			return ctrl.Result{}, err
@@ -0,0 +1,80 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	. "github.com/onsi/gomega"
+)
+
+func keyMatchesCert(crt *x509.Certificate, key crypto.Signer) bool {
+	return key.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(crt.PublicKey)
+}
+
+func TestCreateDefaultCAKeyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyRequest *hlfv1alpha1.KeyRequest
+		wantCurve  elliptic.Curve
+	}{
+		{name: "default", wantCurve: elliptic.P256()},
+		{name: "P-384", keyRequest: &hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmECDSA, Curve: "P384"}, wantCurve: elliptic.P384()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			crt, key, err := CreateDefaultCA(hlfv1alpha1.FabricCAItemConf{
+				Name:       "ca",
+				Subject:    hlfv1alpha1.FabricCASubject{CN: "ca", O: "Org1"},
+				KeyRequest: tt.keyRequest,
+			})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(crt.PublicKey.(*ecdsa.PublicKey).Curve).To(Equal(tt.wantCurve))
+
+			keyPEM, err := encodeCAKey(key)
+			g.Expect(err).NotTo(HaveOccurred())
+			parsedKey, err := parsePrivateKey(keyPEM)
+			g.Expect(err).NotTo(HaveOccurred())
+			parsedCrt, err := parseX509Certificate(utils.EncodeX509Certificate(crt))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(keyMatchesCert(parsedCrt, parsedKey)).To(BeTrue())
+			ski, err := computeSKI(parsedKey)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ski).To(Equal(parsedCrt.SubjectKeyId))
+		})
+	}
+}
+
+func TestCreateDefaultCARejectsRSA(t *testing.T) {
+	g := NewWithT(t)
+	_, _, err := CreateDefaultCA(hlfv1alpha1.FabricCAItemConf{
+		Name:       "ca",
+		KeyRequest: &hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmRSA},
+	})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestRSAKeyRoundTrip(t *testing.T) {
+	g := NewWithT(t)
+	key, err := certs.GenerateKey(&hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmRSA, Size: 3072})
+	g.Expect(err).NotTo(HaveOccurred())
+	crt, _, err := createRootCACert(hlfv1alpha1.FabricCAItemConf{Subject: hlfv1alpha1.FabricCASubject{CN: "tls"}}, key)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	keyPEM, err := utils.EncodePrivateKey(key)
+	g.Expect(err).NotTo(HaveOccurred())
+	parsedKey, err := parsePrivateKey(keyPEM)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(parsedKey.(*rsa.PrivateKey).N.BitLen()).To(Equal(3072))
+	g.Expect(keyMatchesCert(crt, parsedKey)).To(BeTrue())
+	ski, err := computeSKI(parsedKey)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ski).To(Equal(crt.SubjectKeyId))
+}
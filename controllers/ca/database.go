@@ -0,0 +1,196 @@
+package ca
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	postgresDatabase = "postgres"
+	mysqlDatabase    = "mysql"
+
+	defaultPostgresPort = 5432
+	defaultMySQLPort    = 3306
+	defaultDBName       = "fabric_ca"
+
+	databaseDialTimeout = 5 * time.Second
+)
+
+var (
+	postgresHostRegexp = regexp.MustCompile(`(?:^|\s)host=(\S+)`)
+	postgresPortRegexp = regexp.MustCompile(`(?:^|\s)port=(\d+)`)
+	mysqlAddressRegexp = regexp.MustCompile(`@tcp\(([^)]+)\)`)
+)
+
+func isSQLDatabase(db hlfv1alpha1.FabricCADatabase) bool {
+	return db.Type == postgresDatabase || db.Type == mysqlDatabase
+}
+
+// validateDatabase returns an error if the database of the CA can't be used with its replicas, SQLite is stored in the
+// volume of a single pod so several replicas need a shared postgres or mysql server
+func validateDatabase(spec hlfv1alpha1.FabricCASpec) error {
+	db := spec.Database
+	if db.Host != "" && !isSQLDatabase(db) {
+		return errors.Errorf("the database host can only be set for postgres and mysql, the database type is %s", db.Type)
+	}
+	if isSQLDatabase(db) && db.Host == "" && db.Datasource == "" {
+		return errors.Errorf("the %s database needs a host or a datasource", db.Type)
+	}
+	if spec.Replicas <= 1 {
+		return nil
+	}
+	if !isSQLDatabase(db) {
+		return errors.Errorf("the CA can only run %d replicas with a postgres or mysql database, the database type is %s", spec.Replicas, db.Type)
+	}
+	if spec.Storage.AccessMode != corev1.ReadWriteMany {
+		return errors.Errorf("the CA can only run %d replicas with storage that has the %s access mode", spec.Replicas, corev1.ReadWriteMany)
+	}
+	return nil
+}
+
+func getDatabasePort(db hlfv1alpha1.FabricCADatabase) int {
+	if db.Port != 0 {
+		return db.Port
+	}
+	if db.Type == mysqlDatabase {
+		return defaultMySQLPort
+	}
+	return defaultPostgresPort
+}
+
+// getDatasource returns the datasource of the database, rendered from its host and the credentials in its secret when
+// the host is set. The chart stores the datasource of postgres and mysql in a secret and not in the ConfigMaps with the
+// configuration of the CA
+func getDatasource(ctx context.Context, client kubernetes.Interface, namespace string, db hlfv1alpha1.FabricCADatabase) (string, error) {
+	if db.Host == "" {
+		return db.Datasource, nil
+	}
+	var user, password string
+	if db.Credentials != nil {
+		secret, err := client.CoreV1().Secrets(namespace).Get(ctx, db.Credentials.SecretName, v1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get the secret %s with the database credentials", db.Credentials.SecretName)
+		}
+		userKey := db.Credentials.UserKey
+		if userKey == "" {
+			userKey = "username"
+		}
+		passwordKey := db.Credentials.PasswordKey
+		if passwordKey == "" {
+			passwordKey = "password"
+		}
+		user = string(secret.Data[userKey])
+		password = string(secret.Data[passwordKey])
+	}
+	dbName := db.DBName
+	if dbName == "" {
+		dbName = defaultDBName
+	}
+	port := getDatabasePort(db)
+	if db.Type == mysqlDatabase {
+		if strings.Contains(user, ":") {
+			return "", errors.Errorf("the mysql user %s can't contain ':'", user)
+		}
+		mysqlConfig := mysql.NewConfig()
+		mysqlConfig.User = user
+		mysqlConfig.Passwd = password
+		mysqlConfig.Net = "tcp"
+		mysqlConfig.Addr = net.JoinHostPort(db.Host, strconv.Itoa(port))
+		mysqlConfig.DBName = dbName
+		mysqlConfig.ParseTime = true
+		if db.TLS != nil {
+			// the CA registers the TLS settings of the db section with the custom name
+			mysqlConfig.TLSConfig = "custom"
+		}
+		return mysqlConfig.FormatDSN(), nil
+	}
+	sslMode := "disable"
+	if db.TLS != nil {
+		sslMode = "verify-full"
+	}
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		db.Host,
+		port,
+		quotePostgresValue(user),
+		quotePostgresValue(password),
+		quotePostgresValue(dbName),
+		sslMode,
+	), nil
+}
+
+// quotePostgresValue quotes a value of a postgres connection string so it can contain spaces and quotes
+func quotePostgresValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return fmt.Sprintf("'%s'", value)
+}
+
+func mapDatabaseToChart(db hlfv1alpha1.FabricCADatabase, datasource string) Database {
+	chartDB := Database{
+		Type:       db.Type,
+		Datasource: datasource,
+	}
+	if db.TLS == nil {
+		return chartDB
+	}
+	chartDB.TLS = &DatabaseTLS{
+		CACert: SecretKey{Name: db.TLS.CACert.Name, Key: db.TLS.CACert.Key},
+	}
+	if db.TLS.ClientCert != nil && db.TLS.ClientKey != nil {
+		chartDB.TLS.ClientCert = &SecretKey{Name: db.TLS.ClientCert.Name, Key: db.TLS.ClientCert.Key}
+		chartDB.TLS.ClientKey = &SecretKey{Name: db.TLS.ClientKey.Name, Key: db.TLS.ClientKey.Key}
+	}
+	return chartDB
+}
+
+// getDatabaseAddress returns the address of the postgres or mysql server of the CA, empty if it can't be found
+func getDatabaseAddress(db hlfv1alpha1.FabricCADatabase) string {
+	if !isSQLDatabase(db) {
+		return ""
+	}
+	if db.Host != "" {
+		return net.JoinHostPort(db.Host, strconv.Itoa(getDatabasePort(db)))
+	}
+	if db.Type == mysqlDatabase {
+		match := mysqlAddressRegexp.FindStringSubmatch(db.Datasource)
+		if match == nil {
+			return ""
+		}
+		return match[1]
+	}
+	match := postgresHostRegexp.FindStringSubmatch(db.Datasource)
+	if match == nil {
+		return ""
+	}
+	port := strconv.Itoa(defaultPostgresPort)
+	if portMatch := postgresPortRegexp.FindStringSubmatch(db.Datasource); portMatch != nil {
+		port = portMatch[1]
+	}
+	return net.JoinHostPort(match[1], port)
+}
+
+// checkDatabase returns an error if the postgres or mysql server of the CA can't be reached
+func checkDatabase(db hlfv1alpha1.FabricCADatabase) error {
+	address := getDatabaseAddress(db)
+	if address == "" {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", address, databaseDialTimeout)
+	if err != nil {
+		return errors.Wrapf(err, "failed to connect to the %s database %s", db.Type, address)
+	}
+	return conn.Close()
+}
@@ -0,0 +1,284 @@
+package ca
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testDBPassword = "p@ss:w/rd?#& 'x'"
+
+func TestValidateDatabase(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    hlfv1alpha1.FabricCASpec
+		wantErr bool
+	}{
+		{
+			name: "sqlite with one replica",
+			spec: hlfv1alpha1.FabricCASpec{Database: hlfv1alpha1.FabricCADatabase{Type: "sqlite3", Datasource: "fabric-ca-server.db"}},
+		},
+		{
+			name:    "host with sqlite",
+			spec:    hlfv1alpha1.FabricCASpec{Database: hlfv1alpha1.FabricCADatabase{Type: "sqlite3", Host: "postgres"}},
+			wantErr: true,
+		},
+		{
+			name:    "postgres without host or datasource",
+			spec:    hlfv1alpha1.FabricCASpec{Database: hlfv1alpha1.FabricCADatabase{Type: "postgres"}},
+			wantErr: true,
+		},
+		{
+			name: "postgres with datasource",
+			spec: hlfv1alpha1.FabricCASpec{Database: hlfv1alpha1.FabricCADatabase{Type: "postgres", Datasource: "host=postgres"}},
+		},
+		{
+			name: "replicas with sqlite",
+			spec: hlfv1alpha1.FabricCASpec{
+				Replicas: 2,
+				Database: hlfv1alpha1.FabricCADatabase{Type: "sqlite3"},
+				Storage:  hlfv1alpha1.Storage{AccessMode: corev1.ReadWriteMany},
+			},
+			wantErr: true,
+		},
+		{
+			name: "replicas with ReadWriteOnce storage",
+			spec: hlfv1alpha1.FabricCASpec{
+				Replicas: 2,
+				Database: hlfv1alpha1.FabricCADatabase{Type: "mysql", Host: "mysql"},
+				Storage:  hlfv1alpha1.Storage{AccessMode: corev1.ReadWriteOnce},
+			},
+			wantErr: true,
+		},
+		{
+			name: "replicas with mysql and ReadWriteMany storage",
+			spec: hlfv1alpha1.FabricCASpec{
+				Replicas: 3,
+				Database: hlfv1alpha1.FabricCADatabase{Type: "mysql", Host: "mysql"},
+				Storage:  hlfv1alpha1.Storage{AccessMode: corev1.ReadWriteMany},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := validateDatabase(tt.spec)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestGetDatasource(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "db", Namespace: "default"},
+			Data:       map[string][]byte{"username": []byte("fabric"), "password": []byte(testDBPassword)},
+		},
+		&corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "db-custom", Namespace: "default"},
+			Data:       map[string][]byte{"user": []byte("ca:admin"), "pass": []byte("secret")},
+		},
+	)
+	credentials := &hlfv1alpha1.FabricCADatabaseCredentials{SecretName: "db"}
+	tests := []struct {
+		name    string
+		db      hlfv1alpha1.FabricCADatabase
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "raw datasource without host",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "sqlite3", Datasource: "fabric-ca-server.db"},
+			want: "fabric-ca-server.db",
+		},
+		{
+			name: "postgres with quoted credentials",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "postgres", Host: "postgres", Credentials: credentials},
+			want: `host=postgres port=5432 user='fabric' password='p@ss:w/rd?#& \'x\'' dbname='fabric_ca' sslmode=disable`,
+		},
+		{
+			name: "postgres with TLS, port and database name",
+			db: hlfv1alpha1.FabricCADatabase{
+				Type:        "postgres",
+				Host:        "postgres",
+				Port:        6432,
+				DBName:      "ca",
+				Credentials: credentials,
+				TLS:         &hlfv1alpha1.FabricCADatabaseTLS{},
+			},
+			want: `host=postgres port=6432 user='fabric' password='p@ss:w/rd?#& \'x\'' dbname='ca' sslmode=verify-full`,
+		},
+		{
+			name: "postgres without credentials",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "postgres", Host: "postgres"},
+			want: `host=postgres port=5432 user='' password='' dbname='fabric_ca' sslmode=disable`,
+		},
+		{
+			name: "mysql",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "mysql", Host: "mysql", Credentials: credentials},
+			want: "fabric:p@ss:w/rd?#& 'x'@tcp(mysql:3306)/fabric_ca?parseTime=true",
+		},
+		{
+			name: "mysql with TLS",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "mysql", Host: "mysql", Credentials: credentials, TLS: &hlfv1alpha1.FabricCADatabaseTLS{}},
+			want: "fabric:p@ss:w/rd?#& 'x'@tcp(mysql:3306)/fabric_ca?parseTime=true&tls=custom",
+		},
+		{
+			name: "mysql user with a colon",
+			db: hlfv1alpha1.FabricCADatabase{
+				Type:        "mysql",
+				Host:        "mysql",
+				Credentials: &hlfv1alpha1.FabricCADatabaseCredentials{SecretName: "db-custom", UserKey: "user", PasswordKey: "pass"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing secret",
+			db:      hlfv1alpha1.FabricCADatabase{Type: "postgres", Host: "postgres", Credentials: &hlfv1alpha1.FabricCADatabaseCredentials{SecretName: "missing"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			datasource, err := getDatasource(context.Background(), client, "default", tt.db)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(datasource).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGetDatasourceMySQLRoundTrip(t *testing.T) {
+	g := NewWithT(t)
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "db", Namespace: "default"},
+		Data:       map[string][]byte{"username": []byte("fabric"), "password": []byte(testDBPassword)},
+	})
+	datasource, err := getDatasource(context.Background(), client, "default", hlfv1alpha1.FabricCADatabase{
+		Type:        "mysql",
+		Host:        "mysql",
+		Port:        3307,
+		Credentials: &hlfv1alpha1.FabricCADatabaseCredentials{SecretName: "db"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	config, err := mysql.ParseDSN(datasource)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(config.User).To(Equal("fabric"))
+	g.Expect(config.Passwd).To(Equal(testDBPassword))
+	g.Expect(config.Addr).To(Equal("mysql:3307"))
+	g.Expect(config.DBName).To(Equal("fabric_ca"))
+	g.Expect(config.ParseTime).To(BeTrue())
+}
+
+func TestGetDatabaseAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		db   hlfv1alpha1.FabricCADatabase
+		want string
+	}{
+		{
+			name: "sqlite",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "sqlite3", Datasource: "fabric-ca-server.db"},
+			want: "",
+		},
+		{
+			name: "postgres host with the default port",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "postgres", Host: "postgres"},
+			want: "postgres:5432",
+		},
+		{
+			name: "mysql host with the default port",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "mysql", Host: "mysql"},
+			want: "mysql:3306",
+		},
+		{
+			name: "ipv6 host",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "postgres", Host: "::1", Port: 6432},
+			want: "[::1]:6432",
+		},
+		{
+			name: "postgres datasource with port",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "postgres", Datasource: "host=db.example.com port=6432 user=fabric dbname=ca"},
+			want: "db.example.com:6432",
+		},
+		{
+			name: "postgres datasource without port",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "postgres", Datasource: "user=fabric host=db.example.com dbname=ca"},
+			want: "db.example.com:5432",
+		},
+		{
+			name: "postgres datasource without host",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "postgres", Datasource: "user=fabric dbname=ca"},
+			want: "",
+		},
+		{
+			name: "mysql datasource",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "mysql", Datasource: "fabric:pw@tcp(mysql.example.com:3307)/ca?parseTime=true"},
+			want: "mysql.example.com:3307",
+		},
+		{
+			name: "mysql datasource without tcp address",
+			db:   hlfv1alpha1.FabricCADatabase{Type: "mysql", Datasource: "fabric:pw@/ca"},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(getDatabaseAddress(tt.db)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestDatabaseChartKeepsCredentialsOutOfConfigMaps(t *testing.T) {
+	g := NewWithT(t)
+	ch, err := loader.Load(filepath.Join("..", "..", "charts", "hlf-ca"))
+	g.Expect(err).NotTo(HaveOccurred())
+	datasource := fmt.Sprintf("host=postgres port=5432 user='fabric' password=%s dbname='fabric_ca' sslmode=disable", quotePostgresValue(testDBPassword))
+	chart := FabricCAChart{
+		FullNameOverride: "org1-ca",
+		Database:         mapDatabaseToChart(hlfv1alpha1.FabricCADatabase{Type: "postgres"}, datasource),
+	}
+	var inInterface map[string]interface{}
+	inrec, err := json.Marshal(chart)
+	g.Expect(err).NotTo(HaveOccurred())
+	err = json.Unmarshal(inrec, &inInterface)
+	g.Expect(err).NotTo(HaveOccurred())
+	values, err := chartutil.ToRenderValues(ch, inInterface, chartutil.ReleaseOptions{Name: "org1-ca", Namespace: "default"}, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	manifests, err := engine.Render(ch, values)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	for _, name := range []string{"configmap--config.yaml", "configmap--config-tls.yaml"} {
+		configMap := manifests[filepath.Join("hlf-ca", "templates", name)]
+		g.Expect(configMap).To(ContainSubstring("datasource: __DB_DATASOURCE__"))
+		g.Expect(configMap).NotTo(ContainSubstring("p@ss"))
+	}
+	secret := manifests[filepath.Join("hlf-ca", "templates", "secret--database.yaml")]
+	encodedDatasource := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%q", datasource)))
+	g.Expect(secret).To(ContainSubstring("DB_DATASOURCE: " + fmt.Sprintf("%q", encodedDatasource)))
+	deployment := manifests[filepath.Join("hlf-ca", "templates", "deployment.yaml")]
+	g.Expect(deployment).To(ContainSubstring("name: org1-ca--database"))
+	g.Expect(deployment).To(ContainSubstring(`ENVIRON["DB_DATASOURCE"]`))
+}
@@ -0,0 +1,58 @@
+package ca
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultIdemixRHPoolSize         = 1000
+	defaultIdemixNonceExpiration    = "15s"
+	defaultIdemixNonceSweepInterval = "15m"
+)
+
+func mapIdemixToChart(conf hlfv1alpha1.FabricCAItemConf) *FabricCAChartIdemix {
+	if conf.Idemix == nil {
+		return nil
+	}
+	idemix := &FabricCAChartIdemix{
+		RHPoolSize:         conf.Idemix.RHPoolSize,
+		NonceExpiration:    conf.Idemix.NonceExpiration,
+		NonceSweepInterval: conf.Idemix.NonceSweepInterval,
+		Curve:              conf.Idemix.Curve,
+	}
+	if idemix.RHPoolSize == 0 {
+		idemix.RHPoolSize = defaultIdemixRHPoolSize
+	}
+	if idemix.NonceExpiration == "" {
+		idemix.NonceExpiration = defaultIdemixNonceExpiration
+	}
+	if idemix.NonceSweepInterval == "" {
+		idemix.NonceSweepInterval = defaultIdemixNonceSweepInterval
+	}
+	return idemix
+}
+
+// getIdemixIssuerKeys returns the base64 encoded issuer public key and the PEM encoded revocation public key of the
+// sign CA, the CA generates them the first time it starts, so they are empty if they can't be retrieved
+func getIdemixIssuerKeys(fabricCA *hlfv1alpha1.FabricCA, tlsCert string) (string, string) {
+	caInfo, err := certs.GetCAInfo(certs.GetCAInfoRequest{
+		TLSCert: tlsCert,
+		URL:     fmt.Sprintf("https://%s", helpers.GetCAPrivateURL(*fabricCA)),
+		Name:    fabricCA.Spec.CA.Name,
+		MSPID:   fmt.Sprintf("%sMSP", fabricCA.Name),
+	})
+	if err != nil {
+		log.Infof("Failed to get the Idemix issuer keys of CA %s/%s: %v", fabricCA.Namespace, fabricCA.Name, err)
+		return "", ""
+	}
+	if len(caInfo.IssuerPublicKey) == 0 {
+		return "", ""
+	}
+	return base64.StdEncoding.EncodeToString(caInfo.IssuerPublicKey), string(caInfo.IssuerRevocationPublicKey)
+}
@@ -0,0 +1,32 @@
+package ca
+
+import (
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+)
+
+func TestMapIdemixToChart(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(mapIdemixToChart(hlfv1alpha1.FabricCAItemConf{})).To(BeNil())
+
+	// the defaults of Fabric CA are set for the fields left empty
+	g.Expect(mapIdemixToChart(hlfv1alpha1.FabricCAItemConf{Idemix: &hlfv1alpha1.FabricCAIdemix{}})).To(Equal(&FabricCAChartIdemix{
+		RHPoolSize:         1000,
+		NonceExpiration:    "15s",
+		NonceSweepInterval: "15m",
+	}))
+
+	g.Expect(mapIdemixToChart(hlfv1alpha1.FabricCAItemConf{Idemix: &hlfv1alpha1.FabricCAIdemix{
+		RHPoolSize:         100,
+		NonceExpiration:    "30s",
+		NonceSweepInterval: "1h",
+		Curve:              "gurvy.Bn254",
+	}})).To(Equal(&FabricCAChartIdemix{
+		RHPoolSize:         100,
+		NonceExpiration:    "30s",
+		NonceSweepInterval: "1h",
+		Curve:              "gurvy.Bn254",
+	}))
+}
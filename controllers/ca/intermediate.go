@@ -0,0 +1,395 @@
+package ca
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/msp/api"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type intermediateCA struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+	// PEM encoded chain of the intermediate CA, the root certificate first and the intermediate CA certificate last
+	Chain string
+	// PEM encoded TLS certificate of the parent server
+	ParentTLSCert string
+	ParentURL     string
+	ParentCAName  string
+}
+
+func isIntermediateCA(conf hlfv1alpha1.FabricCAItemConf) bool {
+	return conf.Intermediate.Parent != nil
+}
+
+// getParentCA returns the parent FabricCA of an intermediate CA and the name of the CA of the parent that signs it
+func getParentCA(ctx context.Context, hlfClientSet operatorv1.Interface, conf hlfv1alpha1.FabricCAItemConf, tlsCA bool) (*hlfv1alpha1.FabricCA, hlfv1alpha1.FabricCAItemConf, string, error) {
+	parentRef := conf.Intermediate.Parent
+	parent, err := hlfClientSet.HlfV1alpha1().FabricCAs(parentRef.Namespace).Get(ctx, parentRef.Name, v1.GetOptions{})
+	if err != nil {
+		return nil, hlfv1alpha1.FabricCAItemConf{}, "", errors.Wrapf(err, "failed to get parent FabricCA %s/%s", parentRef.Namespace, parentRef.Name)
+	}
+	parentConf := parent.Spec.CA
+	if tlsCA {
+		parentConf = parent.Spec.TLSCA
+	}
+	parentCAName := conf.Intermediate.ParentServer.CAName
+	if parentCAName == "" {
+		parentCAName = parentConf.Name
+	}
+	return parent, parentConf, parentCAName, nil
+}
+
+func getIntermediateEnrollmentSecretName(releaseName string) string {
+	return fmt.Sprintf("%s--intermediate-enrollment", releaseName)
+}
+
+// getIntermediateEnrollSecret returns the enroll secret of the identity of the intermediate CA on its parent, when the
+// spec doesn't set one a random secret is generated and stored in a secret owned by the FabricCA so that later
+// reconciles reuse it
+func getIntermediateEnrollSecret(
+	ctx context.Context,
+	clientSet kubernetes.Interface,
+	fabricCA *hlfv1alpha1.FabricCA,
+	conf hlfv1alpha1.FabricCAItemConf,
+	releaseName string,
+	ns string,
+	tlsCA bool,
+) (string, error) {
+	if conf.Intermediate.Parent.EnrollSecret != "" {
+		return conf.Intermediate.Parent.EnrollSecret, nil
+	}
+	key := "enrollsecret"
+	if tlsCA {
+		key = "tlsenrollsecret"
+	}
+	secretName := getIntermediateEnrollmentSecretName(releaseName)
+	secret, err := clientSet.CoreV1().Secrets(ns).Get(ctx, secretName, v1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", errors.Wrapf(err, "failed to get secret %s/%s", ns, secretName)
+		}
+		secret = nil
+	}
+	if secret != nil && len(secret.Data[key]) > 0 {
+		return string(secret.Data[key]), nil
+	}
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", errors.Wrap(err, "failed to generate the enroll secret of the intermediate CA")
+	}
+	enrollSecret := hex.EncodeToString(randomBytes)
+	if secret == nil {
+		_, err = clientSet.CoreV1().Secrets(ns).Create(ctx, &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      secretName,
+				Namespace: ns,
+				OwnerReferences: []v1.OwnerReference{
+					*v1.NewControllerRef(fabricCA, hlfv1alpha1.GroupVersion.WithKind("FabricCA")),
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{key: []byte(enrollSecret)},
+		}, v1.CreateOptions{})
+	} else {
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[key] = []byte(enrollSecret)
+		_, err = clientSet.CoreV1().Secrets(ns).Update(ctx, secret, v1.UpdateOptions{})
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to save the enroll secret of the intermediate CA in secret %s/%s", ns, secretName)
+	}
+	return enrollSecret, nil
+}
+
+// parentCAClient is the client of the Fabric CA server of the parent that registers and enrolls an intermediate CA
+type parentCAClient interface {
+	GetUser(req certs.GetUserRequest) (*api.IdentityResponse, error)
+	RegisterUser(req certs.RegisterUserRequest) (string, error)
+	EnrollUser(req certs.EnrollUserRequest) (*x509.Certificate, crypto.Signer, *x509.Certificate, error)
+	GetCAInfo(req certs.GetCAInfoRequest) (*api.GetCAInfoResponse, error)
+}
+
+// fabricParentCA calls the Fabric CA server of the parent with the certs package
+type fabricParentCA struct{}
+
+func (fabricParentCA) GetUser(req certs.GetUserRequest) (*api.IdentityResponse, error) {
+	return certs.GetUser(req)
+}
+
+func (fabricParentCA) RegisterUser(req certs.RegisterUserRequest) (string, error) {
+	return certs.RegisterUser(req)
+}
+
+func (fabricParentCA) EnrollUser(req certs.EnrollUserRequest) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
+	return certs.EnrollUser(req)
+}
+
+func (fabricParentCA) GetCAInfo(req certs.GetCAInfoRequest) (*api.GetCAInfoResponse, error) {
+	return certs.GetCAInfo(req)
+}
+
+// registerIntermediateCA registers the identity of the intermediate CA on its parent unless the registrar can already
+// look it up, e.g. when a previous reconcile registered it but failed to enroll
+func registerIntermediateCA(parentCA parentCAClient, req certs.RegisterUserRequest) error {
+	_, err := parentCA.GetUser(certs.GetUserRequest{
+		TLSCert:      req.TLSCert,
+		URL:          req.URL,
+		Name:         req.Name,
+		MSPID:        req.MSPID,
+		EnrollID:     req.EnrollID,
+		EnrollSecret: req.EnrollSecret,
+		User:         req.User,
+	})
+	if err == nil {
+		log.Infof("Intermediate CA identity %s already registered on %s", req.User, req.URL)
+		return nil
+	}
+	_, err = parentCA.RegisterUser(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to register intermediate CA %s on %s", req.User, req.URL)
+	}
+	return nil
+}
+
+// enrollIntermediateCA registers the identity of the intermediate CA on its parent FabricCA and enrolls it with the
+// CA profile of the parent
+func enrollIntermediateCA(
+	ctx context.Context,
+	clientSet kubernetes.Interface,
+	hlfClientSet operatorv1.Interface,
+	parentCA parentCAClient,
+	fabricCA *hlfv1alpha1.FabricCA,
+	conf hlfv1alpha1.FabricCAItemConf,
+	releaseName string,
+	ns string,
+	tlsCA bool,
+) (*intermediateCA, error) {
+	parent, parentConf, parentCAName, err := getParentCA(ctx, hlfClientSet, conf, tlsCA)
+	if err != nil {
+		return nil, err
+	}
+	if parent.Status.Status != hlfv1alpha1.RunningStatus || parent.Status.TlsCert == "" {
+		return nil, errors.Errorf("parent FabricCA %s/%s is not running yet", parent.Namespace, parent.Name)
+	}
+	var registrar *hlfv1alpha1.FabricCAIdentity
+	for idx, identity := range parentConf.Registry.Identities {
+		if identity.Attrs.IntermediateCA && identity.Attrs.RegistrarRoles != "" {
+			registrar = &parentConf.Registry.Identities[idx]
+			break
+		}
+	}
+	if registrar == nil {
+		return nil, errors.Errorf("no registrar allowed to register intermediate CAs found in the registry of FabricCA %s/%s", parent.Namespace, parent.Name)
+	}
+	parentRef := conf.Intermediate.Parent
+	enrollID := parentRef.EnrollID
+	if enrollID == "" {
+		enrollID = fmt.Sprintf("%s.%s", fabricCA.Name, fabricCA.Namespace)
+		if tlsCA {
+			enrollID = fmt.Sprintf("%s-tls", enrollID)
+		}
+	}
+	enrollSecret, err := getIntermediateEnrollSecret(ctx, clientSet, fabricCA, conf, releaseName, ns, tlsCA)
+	if err != nil {
+		return nil, err
+	}
+	parentURL := fmt.Sprintf("https://%s", helpers.GetCAPrivateURL(*parent))
+	mspID := parentRef.MSPID
+	err = registerIntermediateCA(parentCA, certs.RegisterUserRequest{
+		TLSCert:      parent.Status.TlsCert,
+		URL:          parentURL,
+		Name:         parentCAName,
+		MSPID:        mspID,
+		EnrollID:     registrar.Name,
+		EnrollSecret: registrar.Pass,
+		User:         enrollID,
+		Secret:       enrollSecret,
+		Type:         "client",
+		Attributes: []api.Attribute{
+			{Name: "hf.IntermediateCA", Value: "true"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	profile := conf.Intermediate.Enrollment.Profile
+	if profile == "" {
+		profile = "ca"
+	}
+	var hosts []string
+	if conf.Intermediate.Enrollment.Hosts != "" {
+		hosts = strings.Split(conf.Intermediate.Enrollment.Hosts, ",")
+	}
+	if err := certs.ValidateSignKeyRequest(conf.KeyRequest); err != nil {
+		return nil, err
+	}
+	crt, key, _, err := parentCA.EnrollUser(certs.EnrollUserRequest{
+		TLSCert:    parent.Status.TlsCert,
+		URL:        parentURL,
+		Name:       parentCAName,
+		MSPID:      mspID,
+		User:       enrollID,
+		Secret:     enrollSecret,
+		Hosts:      hosts,
+		Profile:    profile,
+		KeyRequest: conf.KeyRequest,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to enroll intermediate CA %s on %s", enrollID, parentURL)
+	}
+	if !crt.IsCA {
+		return nil, errors.Errorf("certificate issued by %s with profile %s is not a CA certificate", parentURL, profile)
+	}
+	caInfo, err := parentCA.GetCAInfo(certs.GetCAInfoRequest{
+		TLSCert: parent.Status.TlsCert,
+		URL:     parentURL,
+		Name:    parentCAName,
+		MSPID:   mspID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &intermediateCA{
+		Cert:          crt,
+		Key:           key,
+		Chain:         concatChain(string(caInfo.CAChain), crt),
+		ParentTLSCert: parent.Status.TlsCert,
+		ParentURL:     parentURL,
+		ParentCAName:  parentCAName,
+	}, nil
+}
+
+// getExistingIntermediateCA returns the intermediate CA stored in the crypto material secret of the chart
+func getExistingIntermediateCA(
+	ctx context.Context,
+	client *kubernetes.Clientset,
+	hlfClientSet *operatorv1.Clientset,
+	conf hlfv1alpha1.FabricCAItemConf,
+	secretName string,
+	namespace string,
+	tlsCA bool,
+) (*intermediateCA, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, secretName, v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if len(secret.Data["chainfile"]) == 0 {
+		return nil, errors.Errorf("secret %s has no certificate chain", secretName)
+	}
+	crt, err := parseX509Certificate(secret.Data["certfile"])
+	if err != nil {
+		return nil, err
+	}
+	key, err := parsePrivateKey(secret.Data["keyfile"])
+	if err != nil {
+		return nil, err
+	}
+	parent, _, parentCAName, err := getParentCA(ctx, hlfClientSet, conf, tlsCA)
+	if err != nil {
+		return nil, err
+	}
+	return &intermediateCA{
+		Cert:          crt,
+		Key:           key,
+		Chain:         string(secret.Data["chainfile"]),
+		ParentTLSCert: parent.Status.TlsCert,
+		ParentURL:     fmt.Sprintf("https://%s", helpers.GetCAPrivateURL(*parent)),
+		ParentCAName:  parentCAName,
+	}, nil
+}
+
+// getSpecIntermediateCA returns the intermediate CA with the key, certificate and chain set in the spec, e.g. by a
+// restore, it returns nil when the spec doesn't have them
+func getSpecIntermediateCA(
+	ctx context.Context,
+	hlfClientSet *operatorv1.Clientset,
+	conf hlfv1alpha1.FabricCAItemConf,
+	tlsCA bool,
+) (*intermediateCA, error) {
+	if conf.CA == nil || conf.CA.Key == "" || conf.CA.Cert == "" || conf.CA.Chain == "" {
+		return nil, nil
+	}
+	crt, key, err := parseCrypto(conf.CA.Key, conf.CA.Cert)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid certificate or key of intermediate CA %s", conf.Name)
+	}
+	if !crt.IsCA {
+		return nil, errors.Errorf("the certificate of intermediate CA %s is not a CA certificate", conf.Name)
+	}
+	parent, _, parentCAName, err := getParentCA(ctx, hlfClientSet, conf, tlsCA)
+	if err != nil {
+		return nil, err
+	}
+	return &intermediateCA{
+		Cert:          crt,
+		Key:           key,
+		Chain:         conf.CA.Chain,
+		ParentTLSCert: parent.Status.TlsCert,
+		ParentURL:     fmt.Sprintf("https://%s", helpers.GetCAPrivateURL(*parent)),
+		ParentCAName:  parentCAName,
+	}, nil
+}
+
+// getExistingChain returns the PEM encoded certificate chain stored in the crypto material secret of the chart
+func getExistingChain(client *kubernetes.Clientset, secretName string, namespace string) (string, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, v1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return string(secret.Data["chainfile"]), nil
+}
+
+func concatChain(parentChain string, crt *x509.Certificate) string {
+	if parentChain != "" && !strings.HasSuffix(parentChain, "\n") {
+		parentChain += "\n"
+	}
+	return parentChain + string(utils.EncodeX509Certificate(crt))
+}
+
+func mapIntermediateToChart(conf hlfv1alpha1.FabricCAItemConf, intermediate *intermediateCA, parentCertFile string) FabricCAChartIntermediate {
+	chartIntermediate := FabricCAChartIntermediate{
+		ParentServer: FabricCAChartIntermediateParentServer{
+			URL:    conf.Intermediate.ParentServer.URL,
+			CAName: conf.Intermediate.ParentServer.CAName,
+		},
+		Enrollment: FabricCAChartIntermediateEnrollment{
+			Hosts:   conf.Intermediate.Enrollment.Hosts,
+			Profile: conf.Intermediate.Enrollment.Profile,
+			Label:   conf.Intermediate.Enrollment.Label,
+		},
+		TLS: FabricCAChartIntermediateTLS{
+			CertFiles: conf.Intermediate.TLS.CertFiles,
+			Client: FabricCAChartIntermediateTLSClient{
+				CertFile: conf.Intermediate.TLS.Client.CertFile,
+				KeyFile:  conf.Intermediate.TLS.Client.KeyFile,
+			},
+		},
+	}
+	if intermediate != nil {
+		chartIntermediate.ParentServer = FabricCAChartIntermediateParentServer{
+			URL:    intermediate.ParentURL,
+			CAName: intermediate.ParentCAName,
+		}
+		chartIntermediate.TLS.CertFiles = []string{parentCertFile}
+	}
+	return chartIntermediate
+}
@@ -0,0 +1,187 @@
+package ca
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/msp/api"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	hlffake "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestIntermediate(g *WithT, cn string, issuer rootKeyPair) rootKeyPair {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"Org1"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	crtBytes, err := x509.CreateCertificate(rand.Reader, template, issuer.Cert, key.Public(), issuer.Key)
+	g.Expect(err).NotTo(HaveOccurred())
+	crt, err := x509.ParseCertificate(crtBytes)
+	g.Expect(err).NotTo(HaveOccurred())
+	return rootKeyPair{Cert: crt, Key: key}
+}
+
+// fakeParentCA is a Fabric CA server that signs intermediate CAs with its own intermediate certificate
+type fakeParentCA struct {
+	g          *WithT
+	root       rootKeyPair
+	signer     rootKeyPair
+	registered map[string]string
+	registers  []certs.RegisterUserRequest
+	enrolls    []certs.EnrollUserRequest
+	mspIDs     []string
+}
+
+func (f *fakeParentCA) GetUser(req certs.GetUserRequest) (*api.IdentityResponse, error) {
+	f.mspIDs = append(f.mspIDs, req.MSPID)
+	if _, ok := f.registered[req.User]; !ok {
+		return nil, errors.New("Response from server: Error Code: 63 - Failed to get User")
+	}
+	return &api.IdentityResponse{ID: req.User, CAName: req.Name}, nil
+}
+
+func (f *fakeParentCA) RegisterUser(req certs.RegisterUserRequest) (string, error) {
+	f.mspIDs = append(f.mspIDs, req.MSPID)
+	f.registers = append(f.registers, req)
+	if _, ok := f.registered[req.User]; ok {
+		return "", errors.New("Response from server: Error Code: 74 - Identity is already registered")
+	}
+	f.registered[req.User] = req.Secret
+	return req.Secret, nil
+}
+
+func (f *fakeParentCA) EnrollUser(req certs.EnrollUserRequest) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
+	f.mspIDs = append(f.mspIDs, req.MSPID)
+	f.enrolls = append(f.enrolls, req)
+	if secret, ok := f.registered[req.User]; !ok || secret != req.Secret {
+		return nil, nil, nil, errors.New("Response from server: Error Code: 20 - Authentication failure")
+	}
+	enrolled := newTestIntermediate(f.g, req.User, f.signer)
+	return enrolled.Cert, enrolled.Key, f.root.Cert, nil
+}
+
+func (f *fakeParentCA) GetCAInfo(req certs.GetCAInfoRequest) (*api.GetCAInfoResponse, error) {
+	f.mspIDs = append(f.mspIDs, req.MSPID)
+	chain := append(utils.EncodeX509Certificate(f.root.Cert), utils.EncodeX509Certificate(f.signer.Cert)...)
+	return &api.GetCAInfoResponse{CAName: req.Name, CAChain: chain}, nil
+}
+
+func TestEnrollIntermediateCA(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	root := newTestRoot(g, "root-ca")
+	parentCA := &fakeParentCA{
+		g:          g,
+		root:       root,
+		signer:     newTestIntermediate(g, "org1-ca", root),
+		registered: map[string]string{},
+	}
+	registry := hlfv1alpha1.FabricCARegistry{Identities: []hlfv1alpha1.FabricCAIdentity{
+		{Name: "enroll", Pass: "enrollpw", Attrs: hlfv1alpha1.FabricCAIdentityAttrs{RegistrarRoles: "*"}},
+		{Name: "admin", Pass: "adminpw", Attrs: hlfv1alpha1.FabricCAIdentityAttrs{RegistrarRoles: "*", IntermediateCA: true}},
+	}}
+	parent := &hlfv1alpha1.FabricCA{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-ca", Namespace: "default"},
+		Spec: hlfv1alpha1.FabricCASpec{
+			CA:    hlfv1alpha1.FabricCAItemConf{Name: "ca", Registry: registry},
+			TLSCA: hlfv1alpha1.FabricCAItemConf{Name: "tlsca", Registry: registry},
+		},
+		Status: hlfv1alpha1.FabricCAStatus{Status: hlfv1alpha1.RunningStatus, TlsCert: "parent-tls-cert"},
+	}
+	hlfClientSet := hlffake.NewSimpleClientset(parent)
+	clientSet := fake.NewSimpleClientset()
+	fabricCA := &hlfv1alpha1.FabricCA{ObjectMeta: v1.ObjectMeta{Name: "org1-int-ca", Namespace: "default"}}
+	conf := hlfv1alpha1.FabricCAItemConf{
+		Name: "ca",
+		Intermediate: hlfv1alpha1.FabricCAIntermediate{
+			Parent: &hlfv1alpha1.FabricCAIntermediateParent{Name: "org1-ca", Namespace: "default", MSPID: "Org1MSP"},
+		},
+	}
+
+	// the first reconcile registers the identity of the intermediate CA
+	intermediate, err := enrollIntermediateCA(ctx, clientSet, hlfClientSet, parentCA, fabricCA, conf, "org1-int-ca", "default", false)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(parentCA.registers).To(HaveLen(1))
+	registration := parentCA.registers[0]
+	g.Expect(registration.User).To(Equal("org1-int-ca.default"))
+	g.Expect(registration.EnrollID).To(Equal("admin"))
+	g.Expect(registration.Name).To(Equal("ca"))
+	g.Expect(registration.Attributes).To(Equal([]api.Attribute{{Name: "hf.IntermediateCA", Value: "true"}}))
+	g.Expect(registration.Secret).To(Equal(getSecretData(g, clientSet, "org1-int-ca--intermediate-enrollment", "enrollsecret")))
+	g.Expect(parentCA.enrolls).To(HaveLen(1))
+	g.Expect(parentCA.enrolls[0].Profile).To(Equal("ca"))
+	g.Expect(parentCA.mspIDs).NotTo(ContainElement(Not(Equal("Org1MSP"))))
+	g.Expect(parentCA.mspIDs).NotTo(BeEmpty())
+	g.Expect(intermediate.ParentURL).To(Equal("https://org1-ca.default:7054"))
+	g.Expect(intermediate.ParentCAName).To(Equal("ca"))
+	g.Expect(intermediate.ParentTLSCert).To(Equal("parent-tls-cert"))
+
+	// the chain goes from the root to the certificate of the intermediate CA
+	chain, err := utils.ParseX509Certificates([]byte(intermediate.Chain))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(chain).To(HaveLen(3))
+	g.Expect(chain[0].Equal(root.Cert)).To(BeTrue())
+	g.Expect(chain[1].Equal(parentCA.signer.Cert)).To(BeTrue())
+	g.Expect(chain[2].Equal(intermediate.Cert)).To(BeTrue())
+	for i := 1; i < len(chain); i++ {
+		g.Expect(chain[i].CheckSignatureFrom(chain[i-1])).To(Succeed())
+	}
+
+	// the identity is already registered, e.g. the crypto material secret was lost, so it's enrolled again with the
+	// stored enroll secret
+	_, err = enrollIntermediateCA(ctx, clientSet, hlfClientSet, parentCA, fabricCA, conf, "org1-int-ca", "default", false)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(parentCA.registers).To(HaveLen(1))
+	g.Expect(parentCA.enrolls).To(HaveLen(2))
+	g.Expect(parentCA.enrolls[1].Secret).To(Equal(registration.Secret))
+
+	// the TLS CA registers its own identity
+	_, err = enrollIntermediateCA(ctx, clientSet, hlfClientSet, parentCA, fabricCA, conf, "org1-int-ca", "default", true)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(parentCA.registers).To(HaveLen(2))
+	g.Expect(parentCA.registers[1].User).To(Equal("org1-int-ca.default-tls"))
+	g.Expect(parentCA.registers[1].Name).To(Equal("tlsca"))
+}
+
+func TestRegisterIntermediateCAFailure(t *testing.T) {
+	g := NewWithT(t)
+	parentCA := &fakeParentCA{g: g, registered: map[string]string{"org1-int-ca.default": "secret"}}
+	// the lookup fails, so the registration is tried and its error returned
+	err := registerIntermediateCA(&failingLookupCA{parentCA}, certs.RegisterUserRequest{
+		URL:    "https://org1-ca.default:7054",
+		User:   "org1-int-ca.default",
+		Secret: "other",
+	})
+	g.Expect(err).To(MatchError(ContainSubstring("failed to register intermediate CA org1-int-ca.default on https://org1-ca.default:7054")))
+	g.Expect(parentCA.registers).To(HaveLen(1))
+}
+
+// failingLookupCA is a parent CA the registrar can't look identities up on
+type failingLookupCA struct {
+	*fakeParentCA
+}
+
+func (f *failingLookupCA) GetUser(certs.GetUserRequest) (*api.IdentityResponse, error) {
+	return nil, errors.New("Response from server: Error Code: 71 - Authorization failure")
+}
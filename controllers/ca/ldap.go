@@ -0,0 +1,107 @@
+package ca
+
+import (
+	"context"
+	"net/url"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/pkg/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultLDAPUserFilter  = "(uid=%s)"
+	defaultLDAPGroupFilter = "(memberUid=%s)"
+)
+
+// getLDAPURL returns the URL of the LDAP server of the CA with the bind DN and the password of its secret, the Fabric
+// CA server reads the credentials of the search user from the URL. The chart stores the URL in a secret and not in the
+// ConfigMaps with the configuration of the CA
+func getLDAPURL(ctx context.Context, client kubernetes.Interface, namespace string, conf hlfv1alpha1.FabricCAItemConf) (string, error) {
+	if conf.LDAP == nil {
+		return "", nil
+	}
+	ldapURL, err := url.Parse(conf.LDAP.URL)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid LDAP URL of CA %s", conf.Name)
+	}
+	if ldapURL.Scheme != "ldap" && ldapURL.Scheme != "ldaps" {
+		return "", errors.Errorf("the LDAP URL of CA %s must start with ldap:// or ldaps://", conf.Name)
+	}
+	if conf.LDAP.BindDN == "" {
+		ldapURL.User = nil
+		return ldapURL.String(), nil
+	}
+	if conf.LDAP.BindPassword == nil {
+		ldapURL.User = url.User(conf.LDAP.BindDN)
+		return ldapURL.String(), nil
+	}
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, conf.LDAP.BindPassword.Name, v1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the secret %s with the LDAP bind password", conf.LDAP.BindPassword.Name)
+	}
+	password, ok := secret.Data[conf.LDAP.BindPassword.Key]
+	if !ok {
+		return "", errors.Errorf("secret %s has no key %s with the LDAP bind password", conf.LDAP.BindPassword.Name, conf.LDAP.BindPassword.Key)
+	}
+	ldapURL.User = url.UserPassword(conf.LDAP.BindDN, string(password))
+	return ldapURL.String(), nil
+}
+
+func mapLDAPToChart(conf hlfv1alpha1.FabricCAItemConf, ldapURL string) *FabricCAChartLDAP {
+	if conf.LDAP == nil {
+		return nil
+	}
+	ldap := conf.LDAP
+	userFilter := ldap.UserFilter
+	if userFilter == "" {
+		userFilter = defaultLDAPUserFilter
+	}
+	groupFilter := ldap.GroupFilter
+	if groupFilter == "" {
+		groupFilter = defaultLDAPGroupFilter
+	}
+	names := ldap.Attribute.Names
+	if len(names) == 0 {
+		names = []string{"uid", "member"}
+	}
+	converters := []FabricCAChartLDAPNameValue{}
+	for _, converter := range ldap.Attribute.Converters {
+		converters = append(converters, FabricCAChartLDAPNameValue{
+			Name:  converter.Name,
+			Value: converter.Value,
+		})
+	}
+	maps := map[string][]FabricCAChartLDAPNameValue{}
+	for _, ldapMap := range ldap.Attribute.Maps {
+		entries := []FabricCAChartLDAPNameValue{}
+		for _, entry := range ldapMap.Entries {
+			entries = append(entries, FabricCAChartLDAPNameValue{
+				Name:  entry.Name,
+				Value: entry.Value,
+			})
+		}
+		maps[ldapMap.Name] = entries
+	}
+	chartLDAP := &FabricCAChartLDAP{
+		URL:         ldapURL,
+		UserFilter:  userFilter,
+		GroupFilter: groupFilter,
+		Attribute: FabricCAChartLDAPAttribute{
+			Names:      names,
+			Converters: converters,
+			Maps:       maps,
+		},
+	}
+	if ldap.TLS != nil {
+		chartLDAP.TLS = &FabricCAChartLDAPTLS{
+			CACert: SecretKey{Name: ldap.TLS.CACert.Name, Key: ldap.TLS.CACert.Key},
+		}
+		if ldap.TLS.ClientCert != nil && ldap.TLS.ClientKey != nil {
+			chartLDAP.TLS.ClientCert = &SecretKey{Name: ldap.TLS.ClientCert.Name, Key: ldap.TLS.ClientCert.Key}
+			chartLDAP.TLS.ClientKey = &SecretKey{Name: ldap.TLS.ClientKey.Name, Key: ldap.TLS.ClientKey.Key}
+		}
+	}
+	return chartLDAP
+}
@@ -0,0 +1,216 @@
+package ca
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testBindPassword = "p@ss:w/rd?#&%"
+
+func ldapConf(bindPassword *corev1.SecretKeySelector) hlfv1alpha1.FabricCAItemConf {
+	return hlfv1alpha1.FabricCAItemConf{
+		Name: "ca",
+		LDAP: &hlfv1alpha1.FabricCALDAP{
+			URL:          "ldap://openldap:389/dc=example,dc=org",
+			BindDN:       "cn=admin,dc=example,dc=org",
+			BindPassword: bindPassword,
+		},
+	}
+}
+
+func TestGetLDAPURL(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "ldap-bind", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte(testBindPassword)},
+	})
+
+	ldapURL, err := getLDAPURL(ctx, client, "default", hlfv1alpha1.FabricCAItemConf{Name: "ca"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ldapURL).To(BeEmpty())
+
+	ldapURL, err = getLDAPURL(ctx, client, "default", ldapConf(&corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ldap-bind"}, Key: "password"}))
+	g.Expect(err).NotTo(HaveOccurred())
+	u, err := url.Parse(ldapURL)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(u.User.Username()).To(Equal("cn=admin,dc=example,dc=org"))
+	password, ok := u.User.Password()
+	g.Expect(ok).To(BeTrue())
+	g.Expect(password).To(Equal(testBindPassword))
+	g.Expect(u.Host).To(Equal("openldap:389"))
+	g.Expect(u.Path).To(Equal("/dc=example,dc=org"))
+
+	ldapURL, err = getLDAPURL(ctx, client, "default", ldapConf(nil))
+	g.Expect(err).NotTo(HaveOccurred())
+	u, err = url.Parse(ldapURL)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, ok = u.User.Password()
+	g.Expect(ok).To(BeFalse())
+
+	_, err = getLDAPURL(ctx, client, "default", ldapConf(&corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ldap-bind"}, Key: "missing"}))
+	g.Expect(err).To(HaveOccurred())
+	_, err = getLDAPURL(ctx, client, "default", ldapConf(&corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "missing"}, Key: "password"}))
+	g.Expect(err).To(HaveOccurred())
+
+	conf := ldapConf(nil)
+	conf.LDAP.URL = "http://openldap:389"
+	_, err = getLDAPURL(ctx, client, "default", conf)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestLDAPChartKeepsPasswordOutOfConfigMaps(t *testing.T) {
+	g := NewWithT(t)
+	ch, err := loader.Load(filepath.Join("..", "..", "charts", "hlf-ca"))
+	g.Expect(err).NotTo(HaveOccurred())
+	ldapURL := fmt.Sprintf("ldap://%s@openldap:389/dc=example,dc=org", url.UserPassword("cn=admin,dc=example,dc=org", testBindPassword).String())
+	ldap := mapLDAPToChart(ldapConf(nil), ldapURL)
+	chart := FabricCAChart{
+		FullNameOverride: "org1-ca",
+		Ca:               FabricCAChartItemConf{LDAP: ldap},
+		TLSCA:            FabricCAChartItemConf{LDAP: ldap},
+	}
+	var inInterface map[string]interface{}
+	inrec, err := json.Marshal(chart)
+	g.Expect(err).NotTo(HaveOccurred())
+	err = json.Unmarshal(inrec, &inInterface)
+	g.Expect(err).NotTo(HaveOccurred())
+	values, err := chartutil.ToRenderValues(ch, inInterface, chartutil.ReleaseOptions{Name: "org1-ca", Namespace: "default"}, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	manifests, err := engine.Render(ch, values)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	escapedPassword := strings.Split(strings.TrimPrefix(ldapURL, "ldap://cn=admin,dc=example,dc=org:"), "@")[0]
+	for _, name := range []string{"configmap--config.yaml", "configmap--config-tls.yaml"} {
+		configMap := manifests[filepath.Join("hlf-ca", "templates", name)]
+		g.Expect(configMap).To(ContainSubstring(`url: "__LDAP_URL__"`))
+		g.Expect(configMap).NotTo(ContainSubstring(escapedPassword))
+	}
+	secret := manifests[filepath.Join("hlf-ca", "templates", "secret--ldap.yaml")]
+	encodedURL := base64.StdEncoding.EncodeToString([]byte(ldapURL))
+	g.Expect(secret).To(ContainSubstring("CA_LDAP_URL: " + fmt.Sprintf("%q", encodedURL)))
+	g.Expect(secret).To(ContainSubstring("TLSCA_LDAP_URL: " + fmt.Sprintf("%q", encodedURL)))
+	deployment := manifests[filepath.Join("hlf-ca", "templates", "deployment.yaml")]
+	g.Expect(deployment).To(ContainSubstring("name: org1-ca--ldap"))
+	g.Expect(deployment).To(ContainSubstring(`ENVIRON["CA_LDAP_URL"]`))
+	g.Expect(deployment).To(ContainSubstring(`ENVIRON["TLSCA_LDAP_URL"]`))
+}
+
+// TestLDAPBind binds to an OpenLDAP server with the credentials in the URL the way the Fabric CA server does, set
+// LDAP_URL, LDAP_BIND_DN and LDAP_BIND_PASSWORD to run it
+func TestLDAPBind(t *testing.T) {
+	serverURL := os.Getenv("LDAP_URL")
+	if serverURL == "" {
+		t.Skip("LDAP_URL isn't set")
+	}
+	g := NewWithT(t)
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "ldap-bind", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte(os.Getenv("LDAP_BIND_PASSWORD"))},
+	})
+	conf := hlfv1alpha1.FabricCAItemConf{
+		Name: "ca",
+		LDAP: &hlfv1alpha1.FabricCALDAP{
+			URL:          serverURL,
+			BindDN:       os.Getenv("LDAP_BIND_DN"),
+			BindPassword: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ldap-bind"}, Key: "password"},
+		},
+	}
+	ldapURL, err := getLDAPURL(ctx, client, "default", conf)
+	g.Expect(err).NotTo(HaveOccurred())
+	u, err := url.Parse(ldapURL)
+	g.Expect(err).NotTo(HaveOccurred())
+	password, _ := u.User.Password()
+	resultCode, err := ldapSimpleBind(u.Host, u.User.Username(), password)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resultCode).To(Equal(0))
+
+	resultCode, err = ldapSimpleBind(u.Host, u.User.Username(), password+"wrong")
+	g.Expect(err).NotTo(HaveOccurred())
+	// invalidCredentials
+	g.Expect(resultCode).To(Equal(49))
+}
+
+type ldapBindRequest struct {
+	Version  int
+	Name     []byte
+	Password []byte `asn1:"tag:0"`
+}
+
+type ldapMessage struct {
+	ID         int
+	ProtocolOp asn1.RawValue
+}
+
+// ldapSimpleBind sends a BindRequest and returns the result code of the BindResponse
+func ldapSimpleBind(host string, bindDN string, password string) (int, error) {
+	request, err := asn1.Marshal(ldapBindRequest{Version: 3, Name: []byte(bindDN), Password: []byte(password)})
+	if err != nil {
+		return 0, err
+	}
+	// the bind request is an application SEQUENCE, replace the universal tag of the marshalled struct
+	var bindRequest asn1.RawValue
+	_, err = asn1.Unmarshal(request, &bindRequest)
+	if err != nil {
+		return 0, err
+	}
+	message, err := asn1.Marshal(ldapMessage{
+		ID:         1,
+		ProtocolOp: asn1.RawValue{Class: asn1.ClassApplication, Tag: 0, IsCompound: true, Bytes: bindRequest.Bytes},
+	})
+	if err != nil {
+		return 0, err
+	}
+	conn, err := net.DialTimeout("tcp", host, 10*time.Second)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	err = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if err != nil {
+		return 0, err
+	}
+	_, err = conn.Write(message)
+	if err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+	response := ldapMessage{}
+	_, err = asn1.Unmarshal(buf[:n], &response)
+	if err != nil {
+		return 0, err
+	}
+	if response.ProtocolOp.Class != asn1.ClassApplication || response.ProtocolOp.Tag != 1 {
+		return 0, fmt.Errorf("unexpected LDAP operation %d", response.ProtocolOp.Tag)
+	}
+	var resultCode asn1.Enumerated
+	_, err = asn1.Unmarshal(response.ProtocolOp.Bytes, &resultCode)
+	if err != nil {
+		return 0, err
+	}
+	return int(resultCode), nil
+}
@@ -0,0 +1,39 @@
+package ca
+
+import (
+	"context"
+	"fmt"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const caPodDisruptionBudgetKind = "cas"
+
+// reconcilePodDisruptionBudgets creates a PodDisruptionBudget for the replicas of each CA of the namespace that has it
+// enabled
+func (r *FabricCAReconciler) reconcilePodDisruptionBudgets(ctx context.Context, clientSet kubernetes.Interface, ns string) error {
+	caList := &hlfv1alpha1.FabricCAList{}
+	err := r.List(ctx, caList, client.InNamespace(ns))
+	if err != nil {
+		return err
+	}
+	var groups []utils.PodDisruptionBudgetGroup
+	for i := range caList.Items {
+		ca := &caList.Items[i]
+		pdb := ca.Spec.PodDisruptionBudget
+		if ca.DeletionTimestamp != nil || pdb == nil || !pdb.Enabled {
+			continue
+		}
+		groups = append(groups, utils.PodDisruptionBudgetGroup{
+			Name:           fmt.Sprintf("%s-pdb", ca.Name),
+			Releases:       []string{ca.Name},
+			MaxUnavailable: utils.GetMaxUnavailable(pdb.MaxUnavailable),
+			Owners:         []v1.OwnerReference{utils.NewOwnerReference(ca, hlfv1alpha1.GroupVersion.WithKind("FabricCA"))},
+		})
+	}
+	return utils.ReconcilePodDisruptionBudgets(ctx, clientSet, ns, caPodDisruptionBudgetKind, groups)
+}
@@ -0,0 +1,108 @@
+package ca
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"strconv"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+const pkcs11BCCSP = "PKCS11"
+
+func isPKCS11CA(conf hlfv1alpha1.FabricCAItemConf) bool {
+	return conf.BCCSP.Default == pkcs11BCCSP
+}
+
+// validatePKCS11CA returns an error if the CA uses the PKCS11 BCCSP in a way the operator doesn't support, the key of
+// the root certificate must be generated by the operator inside the token
+func validatePKCS11CA(conf hlfv1alpha1.FabricCAItemConf) error {
+	if !isPKCS11CA(conf) {
+		return nil
+	}
+	if conf.BCCSP.PKCS11 == nil {
+		return errors.Errorf("CA %s uses the PKCS11 BCCSP but has no pkcs11 settings", conf.Name)
+	}
+	if !certs.PKCS11Supported {
+		return errors.Wrapf(certs.ErrPKCS11NotSupported, "CA %s uses the PKCS11 BCCSP", conf.Name)
+	}
+	if isIntermediateCA(conf) {
+		return errors.Errorf("CA %s is an intermediate CA, its key can't be kept in a PKCS#11 token", conf.Name)
+	}
+	if conf.CA != nil && conf.CA.Key != "" {
+		return errors.Errorf("CA %s has a private key set in the spec, it can't be imported into a PKCS#11 token", conf.Name)
+	}
+	return nil
+}
+
+// createRootCA returns the root certificate of the CA set in the spec or a new one, the key of a new root is generated
+// inside the PKCS#11 token when the CA uses the PKCS11 BCCSP
+func createRootCA(client *kubernetes.Clientset, namespace string, conf hlfv1alpha1.FabricCAItemConf) (*x509.Certificate, crypto.Signer, error) {
+	if conf.CA != nil && conf.CA.Key != "" && conf.CA.Cert != "" {
+		return parseCrypto(conf.CA.Key, conf.CA.Cert)
+	}
+	if !isPKCS11CA(conf) {
+		return CreateDefaultCA(conf)
+	}
+	opts, err := certs.GetPKCS11Opts(context.Background(), client, namespace, conf.BCCSP.PKCS11)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := certs.GenerateHSMKey(opts, conf.KeyRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return createRootCACert(conf, key)
+}
+
+// checkRootCAKey returns an error if the key of the CA isn't stored where its BCCSP expects it, the key of an existing
+// root can't be moved between the secret and a PKCS#11 token
+func checkRootCAKey(conf hlfv1alpha1.FabricCAItemConf, key crypto.Signer) error {
+	if isPKCS11CA(conf) && key != nil {
+		return errors.Errorf("the key of CA %s is stored in a secret, it can't be moved to a PKCS#11 token", conf.Name)
+	}
+	if !isPKCS11CA(conf) && key == nil {
+		return errors.Errorf("the key of CA %s is kept in a PKCS#11 token, the CA must use the PKCS11 BCCSP", conf.Name)
+	}
+	return nil
+}
+
+// encodeCAKey returns the PEM encoded key of the CA, empty for a key kept in a PKCS#11 token
+func encodeCAKey(key crypto.Signer) ([]byte, error) {
+	if key == nil || certs.IsHSMKey(key) {
+		return nil, nil
+	}
+	return utils.EncodePrivateKey(key)
+}
+
+func mapPKCS11ToChart(conf hlfv1alpha1.FabricCAItemConf) (*FabricCAChartBCCSPPKCS11, *PKCS11PinSecret) {
+	if !isPKCS11CA(conf) || conf.BCCSP.PKCS11 == nil {
+		return nil, nil
+	}
+	pkcs11 := conf.BCCSP.PKCS11
+	hash := pkcs11.Hash
+	if hash == "" {
+		hash = "SHA2"
+	}
+	security := pkcs11.Security
+	if security == 0 {
+		security = 256
+	}
+	chart := &FabricCAChartBCCSPPKCS11{
+		Library:  pkcs11.Library,
+		Label:    pkcs11.Label,
+		Pin:      "",
+		Hash:     hash,
+		Security: strconv.Itoa(security),
+	}
+	pinSecret := &PKCS11PinSecret{
+		Name: pkcs11.Pin.Name,
+		Key:  pkcs11.Pin.Key,
+	}
+	return chart, pinSecret
+}
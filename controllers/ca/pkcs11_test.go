@@ -0,0 +1,98 @@
+package ca
+
+import (
+	"encoding/json"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newTestPKCS11Conf() hlfv1alpha1.FabricCAItemConf {
+	return hlfv1alpha1.FabricCAItemConf{
+		Name: "ca",
+		BCCSP: hlfv1alpha1.FabricCABCCSP{
+			Default: "PKCS11",
+			PKCS11: &hlfv1alpha1.BCCSPPKCS11{
+				Library: "/usr/lib/softhsm/libsofthsm2.so",
+				Label:   "fabric",
+				Pin:     corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "hsm-pin"}, Key: "pin"},
+			},
+		},
+	}
+}
+
+func TestMapPKCS11ToChart(t *testing.T) {
+	g := NewWithT(t)
+	conf := newTestPKCS11Conf()
+	pkcs11, pinSecret := mapPKCS11ToChart(conf)
+	// the PIN is added to the configuration from its secret when the CA starts
+	g.Expect(pkcs11).To(Equal(&FabricCAChartBCCSPPKCS11{
+		Library:  "/usr/lib/softhsm/libsofthsm2.so",
+		Label:    "fabric",
+		Hash:     "SHA2",
+		Security: "256",
+	}))
+	g.Expect(pinSecret).To(Equal(&PKCS11PinSecret{Name: "hsm-pin", Key: "pin"}))
+
+	conf.BCCSP.PKCS11.Hash = "SHA3"
+	conf.BCCSP.PKCS11.Security = 384
+	item := mapCRDItemConfToChart(conf, nil, "", "")
+	g.Expect(item.BCCSP.Default).To(Equal("PKCS11"))
+	g.Expect(item.BCCSP.PKCS11.Hash).To(Equal("SHA3"))
+	g.Expect(item.BCCSP.PKCS11.Security).To(Equal("384"))
+	g.Expect(item.PKCS11PinSecret).To(Equal(&PKCS11PinSecret{Name: "hsm-pin", Key: "pin"}))
+	values, err := json.Marshal(item.BCCSP)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(values)).To(ContainSubstring(`"pkcs11":{"library":"/usr/lib/softhsm/libsofthsm2.so","label":"fabric","pin":"","hash":"SHA3","security":"384"}`))
+
+	// the SW BCCSP has no PKCS#11 settings in the chart, even if the spec keeps them
+	conf.BCCSP.Default = "SW"
+	pkcs11, pinSecret = mapPKCS11ToChart(conf)
+	g.Expect(pkcs11).To(BeNil())
+	g.Expect(pinSecret).To(BeNil())
+	values, err = json.Marshal(mapCRDItemConfToChart(conf, nil, "", "").BCCSP)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(values)).NotTo(ContainSubstring("pkcs11"))
+}
+
+func TestValidatePKCS11CA(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(validatePKCS11CA(hlfv1alpha1.FabricCAItemConf{Name: "ca"})).To(Succeed())
+
+	conf := newTestPKCS11Conf()
+	conf.BCCSP.PKCS11 = nil
+	g.Expect(validatePKCS11CA(conf)).To(MatchError("CA ca uses the PKCS11 BCCSP but has no pkcs11 settings"))
+
+	conf = newTestPKCS11Conf()
+	if !certs.PKCS11Supported {
+		err := validatePKCS11CA(conf)
+		g.Expect(err).To(MatchError(ContainSubstring("CA ca uses the PKCS11 BCCSP")))
+		g.Expect(err).To(MatchError(ContainSubstring("built without the pkcs11 tag")))
+		return
+	}
+	g.Expect(validatePKCS11CA(conf)).To(Succeed())
+	conf.Intermediate.Parent = &hlfv1alpha1.FabricCAIntermediateParent{Name: "root-ca", Namespace: "default", MSPID: "Org1MSP"}
+	g.Expect(validatePKCS11CA(conf)).To(MatchError("CA ca is an intermediate CA, its key can't be kept in a PKCS#11 token"))
+	conf = newTestPKCS11Conf()
+	conf.CA = &hlfv1alpha1.FabricCACrypto{Key: "key", Cert: "cert"}
+	g.Expect(validatePKCS11CA(conf)).To(MatchError("CA ca has a private key set in the spec, it can't be imported into a PKCS#11 token"))
+}
+
+func TestCheckRootCAKey(t *testing.T) {
+	g := NewWithT(t)
+	root := newTestRoot(g, "ca")
+	g.Expect(checkRootCAKey(hlfv1alpha1.FabricCAItemConf{Name: "ca"}, root.Key)).To(Succeed())
+	g.Expect(checkRootCAKey(newTestPKCS11Conf(), nil)).To(Succeed())
+	g.Expect(checkRootCAKey(newTestPKCS11Conf(), root.Key)).To(MatchError("the key of CA ca is stored in a secret, it can't be moved to a PKCS#11 token"))
+	g.Expect(checkRootCAKey(hlfv1alpha1.FabricCAItemConf{Name: "ca"}, nil)).To(MatchError("the key of CA ca is kept in a PKCS#11 token, the CA must use the PKCS11 BCCSP"))
+
+	keyPEM, err := encodeCAKey(root.Key)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(keyPEM)).To(ContainSubstring("PRIVATE KEY"))
+	keyPEM, err = encodeCAKey(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(keyPEM).To(BeEmpty())
+}
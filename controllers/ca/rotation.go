@@ -0,0 +1,590 @@
+package ca
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-config/protolator"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	caRotationAnnotation      = "hlf.kungfusoftware.es/ca-rotation"
+	caRotationRequeueInterval = 30 * time.Second
+)
+
+// rootKeyPair is the key and certificate of a root CA
+type rootKeyPair struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+func getRotationSecretName(releaseName string) string {
+	return fmt.Sprintf("%s--rotation-cryptomaterial", releaseName)
+}
+
+// reconcileRotation moves the rotation of the root certificates of the CA to its next phase, it returns the rotation
+// status to store and whether the rotation needs to be reconciled again. isServingRoot tells whether the CA server
+// signs with the root certificate of caState
+func reconcileRotation(
+	ctx context.Context,
+	clientSet kubernetes.Interface,
+	hlfClientSet operatorv1.Interface,
+	fabricCA *hlfv1alpha1.FabricCA,
+	caState *Status,
+	releaseName string,
+	ns string,
+	isServingRoot func(fabricCA *hlfv1alpha1.FabricCA, caState *Status) bool,
+) (*hlfv1alpha1.FabricCARotationStatus, bool, error) {
+	rotation := fabricCA.Spec.Rotation
+	rotationStatus := fabricCA.Status.Rotation.DeepCopy()
+	if rotation == nil {
+		return rotationStatus, false, nil
+	}
+	inProgress := rotationStatus != nil &&
+		(rotationStatus.Phase == hlfv1alpha1.CARotationTransitionPhase || rotationStatus.Phase == hlfv1alpha1.CARotationSwitchedPhase)
+	if rotationStatus == nil || rotation.RequestTime.After(rotationStatus.RequestTime.Time) {
+		if inProgress {
+			log.Infof("Rotation of CA %s/%s requested while another one is in progress, ignoring it", ns, fabricCA.Name)
+		} else {
+			rotationStatus, err := startRotation(ctx, clientSet, fabricCA, releaseName, ns)
+			if err != nil {
+				return nil, false, err
+			}
+			return rotationStatus, rotationStatus.Phase == hlfv1alpha1.CARotationTransitionPhase, nil
+		}
+	}
+	if rotationStatus.Phase == hlfv1alpha1.CARotationFailedPhase {
+		return rotationStatus, false, nil
+	}
+	// the channels must trust the roots of the CA as they are after this reconciliation
+	desiredStatus := fabricCA.Status.DeepCopy()
+	desiredStatus.CACert = caState.CACert
+	desiredStatus.TLSCACert = caState.TLSCACert
+	desiredStatus.Rotation = rotationStatus
+	pendingChannels, err := syncRotationChannels(ctx, clientSet, hlfClientSet, fabricCA, *desiredStatus)
+	if err != nil {
+		return nil, false, err
+	}
+	switch rotationStatus.Phase {
+	case hlfv1alpha1.CARotationTransitionPhase:
+		if len(pendingChannels) > 0 {
+			rotationStatus.Message = fmt.Sprintf("Waiting for channels %s to trust the new root certificates", strings.Join(pendingChannels, ", "))
+			return rotationStatus, true, nil
+		}
+		err = switchRotation(ctx, clientSet, fabricCA, rotationStatus, caState, releaseName, ns)
+		if err != nil {
+			return nil, false, err
+		}
+		return rotationStatus, true, nil
+	case hlfv1alpha1.CARotationSwitchedPhase:
+		if caState.Status != hlfv1alpha1.RunningStatus || !isServingRoot(fabricCA, caState) {
+			rotationStatus.Message = "Waiting for the CA to sign with the new root certificates"
+			return rotationStatus, true, nil
+		}
+		if len(pendingChannels) > 0 {
+			rotationStatus.Message = fmt.Sprintf("Waiting for channels %s to use the new root certificates", strings.Join(pendingChannels, ", "))
+			return rotationStatus, true, nil
+		}
+		if rotationStatus.ReenrolledNodes == nil {
+			reenrolledNodes, err := reenrollNodes(ctx, hlfClientSet, fabricCA, rotation.TLSCA)
+			if err != nil {
+				return nil, false, err
+			}
+			rotationStatus.ReenrolledNodes = reenrolledNodes
+		}
+		transitionWindow, err := getTransitionWindow(rotation)
+		if err != nil {
+			return nil, false, err
+		}
+		completeTime := rotationStatus.SwitchTime.Add(transitionWindow)
+		if time.Now().Before(completeTime) {
+			rotationStatus.Message = fmt.Sprintf("Nodes re-enrolled, the previous root certificates are trusted until %s", completeTime.Format(time.RFC3339))
+			return rotationStatus, true, nil
+		}
+		rotationStatus.Phase = hlfv1alpha1.CARotationCompletedPhase
+		rotationStatus.Message = "Rotation completed, the previous root certificates are no longer trusted"
+		rotationStatus.TrustedCACert = ""
+		rotationStatus.TrustedTLSCACert = ""
+		return rotationStatus, true, nil
+	case hlfv1alpha1.CARotationCompletedPhase:
+		// the channels stop trusting the previous roots after the rotation completes
+		return rotationStatus, len(pendingChannels) > 0, nil
+	}
+	return rotationStatus, false, nil
+}
+
+// startRotation generates the new root certificates and stores them in the rotation secret, the CA keeps signing with
+// the previous keys until every channel trusts the new roots
+func startRotation(
+	ctx context.Context,
+	clientSet kubernetes.Interface,
+	fabricCA *hlfv1alpha1.FabricCA,
+	releaseName string,
+	ns string,
+) (*hlfv1alpha1.FabricCARotationStatus, error) {
+	rotation := fabricCA.Spec.Rotation
+	rotationStatus := &hlfv1alpha1.FabricCARotationStatus{
+		Phase:       hlfv1alpha1.CARotationTransitionPhase,
+		RequestTime: rotation.RequestTime,
+	}
+	err := validateRotation(fabricCA)
+	if err != nil {
+		rotationStatus.Phase = hlfv1alpha1.CARotationFailedPhase
+		rotationStatus.Message = err.Error()
+		return rotationStatus, nil
+	}
+	_, err = getTransitionWindow(rotation)
+	if err != nil {
+		rotationStatus.Phase = hlfv1alpha1.CARotationFailedPhase
+		rotationStatus.Message = err.Error()
+		return rotationStatus, nil
+	}
+	secretData := map[string][]byte{}
+	signCert, signKey, err := getExistingSignCrypto(clientSet, releaseName, ns)
+	if err != nil {
+		return nil, err
+	}
+	newSign, crossSignedSign, err := createRotatedRoot(fabricCA.Spec.CA, rootKeyPair{Cert: signCert, Key: signKey}, rotation.CrossSign)
+	if err != nil {
+		return nil, err
+	}
+	err = putRootKeyPair(secretData, "", newSign, crossSignedSign)
+	if err != nil {
+		return nil, err
+	}
+	rotationStatus.TrustedCACert = string(utils.EncodeX509Certificate(newSign.Cert))
+	if crossSignedSign != nil {
+		rotationStatus.CrossSignedCACert = string(utils.EncodeX509Certificate(crossSignedSign))
+	}
+	if rotation.TLSCA {
+		tlsCACert, tlsCAKey, err := getExistingSignTLSCrypto(clientSet, releaseName, ns)
+		if err != nil {
+			return nil, err
+		}
+		newTLSCA, crossSignedTLSCA, err := createRotatedRoot(fabricCA.Spec.TLSCA, rootKeyPair{Cert: tlsCACert, Key: tlsCAKey}, rotation.CrossSign)
+		if err != nil {
+			return nil, err
+		}
+		err = putRootKeyPair(secretData, "tlsca", newTLSCA, crossSignedTLSCA)
+		if err != nil {
+			return nil, err
+		}
+		rotationStatus.TrustedTLSCACert = string(utils.EncodeX509Certificate(newTLSCA.Cert))
+		if crossSignedTLSCA != nil {
+			rotationStatus.CrossSignedTLSCACert = string(utils.EncodeX509Certificate(crossSignedTLSCA))
+		}
+	}
+	err = saveRotationSecret(ctx, clientSet, fabricCA, getRotationSecretName(releaseName), ns, secretData)
+	if err != nil {
+		return nil, err
+	}
+	rotationStatus.Message = "Waiting for the channels to trust the new root certificates"
+	log.Infof("Rotation of the root certificates of CA %s/%s started", ns, fabricCA.Name)
+	return rotationStatus, nil
+}
+
+func validateRotation(fabricCA *hlfv1alpha1.FabricCA) error {
+	confs := []hlfv1alpha1.FabricCAItemConf{fabricCA.Spec.CA}
+	if fabricCA.Spec.Rotation.TLSCA {
+		confs = append(confs, fabricCA.Spec.TLSCA)
+	}
+	for _, conf := range confs {
+		if isIntermediateCA(conf) {
+			return errors.Errorf("CA %s is an intermediate CA, it must be rotated by its parent", conf.Name)
+		}
+		if conf.CA != nil && conf.CA.Chain != "" {
+			return errors.Errorf("CA %s has a certificate chain set in the spec, it can't be rotated", conf.Name)
+		}
+		if isPKCS11CA(conf) {
+			return errors.Errorf("CA %s keeps its key in a PKCS#11 token, it can't be rotated by the operator", conf.Name)
+		}
+	}
+	return nil
+}
+
+func getTransitionWindow(rotation *hlfv1alpha1.FabricCARotation) (time.Duration, error) {
+	if rotation.TransitionWindow == "" {
+		return 24 * time.Hour, nil
+	}
+	transitionWindow, err := time.ParseDuration(rotation.TransitionWindow)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid transition window %s", rotation.TransitionWindow)
+	}
+	return transitionWindow, nil
+}
+
+// createRotatedRoot creates a new root certificate with the subject of the CA, and the same certificate signed by the
+// previous root when crossSign is set
+func createRotatedRoot(conf hlfv1alpha1.FabricCAItemConf, previous rootKeyPair, crossSign bool) (rootKeyPair, *x509.Certificate, error) {
+	crt, key, err := CreateDefaultCA(conf)
+	if err != nil {
+		return rootKeyPair{}, nil, err
+	}
+	newRoot := rootKeyPair{Cert: crt, Key: key}
+	if !crossSign {
+		return newRoot, nil, nil
+	}
+	crossSigned, err := crossSignCertificate(newRoot, previous)
+	if err != nil {
+		return rootKeyPair{}, nil, err
+	}
+	return newRoot, crossSigned, nil
+}
+
+// crossSignCertificate issues the certificate of a root CA with the key of another root, certificates issued by the
+// first root can then be verified by clients that only trust the second one
+func crossSignCertificate(root rootKeyPair, signer rootKeyPair) (*x509.Certificate, error) {
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               root.Cert.Subject,
+		NotBefore:             root.Cert.NotBefore,
+		NotAfter:              root.Cert.NotAfter,
+		IsCA:                  true,
+		SubjectKeyId:          root.Cert.SubjectKeyId,
+		AuthorityKeyId:        signer.Cert.SubjectKeyId,
+		ExtKeyUsage:           root.Cert.ExtKeyUsage,
+		KeyUsage:              root.Cert.KeyUsage,
+		BasicConstraintsValid: true,
+	}
+	if signer.Cert.NotAfter.Before(template.NotAfter) {
+		template.NotAfter = signer.Cert.NotAfter
+	}
+	crtBytes, err := x509.CreateCertificate(rand.Reader, template, signer.Cert, root.Key.Public(), signer.Key)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(crtBytes)
+}
+
+func putRootKeyPair(data map[string][]byte, prefix string, root rootKeyPair, crossSigned *x509.Certificate) error {
+	keyPEM, err := utils.EncodePrivateKey(root.Key)
+	if err != nil {
+		return err
+	}
+	data[prefix+"keyfile"] = keyPEM
+	data[prefix+"certfile"] = utils.EncodeX509Certificate(root.Cert)
+	if crossSigned != nil {
+		data[prefix+"crosssignedcertfile"] = utils.EncodeX509Certificate(crossSigned)
+	}
+	return nil
+}
+
+func getRootKeyPair(data map[string][]byte, prefix string) (rootKeyPair, error) {
+	key, err := parsePrivateKey(data[prefix+"keyfile"])
+	if err != nil {
+		return rootKeyPair{}, err
+	}
+	crt, err := parseX509Certificate(data[prefix+"certfile"])
+	if err != nil {
+		return rootKeyPair{}, err
+	}
+	return rootKeyPair{Cert: crt, Key: key}, nil
+}
+
+// saveRotationSecret creates or replaces the secret with the key material of the new roots, the secret is owned by
+// the FabricCA so that it's removed with it
+func saveRotationSecret(ctx context.Context, clientSet kubernetes.Interface, fabricCA *hlfv1alpha1.FabricCA, secretName string, ns string, data map[string][]byte) error {
+	secret, err := clientSet.CoreV1().Secrets(ns).Get(ctx, secretName, v1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		_, err = clientSet.CoreV1().Secrets(ns).Create(ctx, &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      secretName,
+				Namespace: ns,
+				OwnerReferences: []v1.OwnerReference{
+					*v1.NewControllerRef(fabricCA, hlfv1alpha1.GroupVersion.WithKind("FabricCA")),
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		}, v1.CreateOptions{})
+		return err
+	}
+	secret.Data = data
+	_, err = clientSet.CoreV1().Secrets(ns).Update(ctx, secret, v1.UpdateOptions{})
+	return err
+}
+
+// switchRotation copies the new roots to the crypto material of the chart so the CA signs with the new keys once it
+// restarts, the previous roots stay trusted by the channels during the transition window
+func switchRotation(
+	ctx context.Context,
+	clientSet kubernetes.Interface,
+	fabricCA *hlfv1alpha1.FabricCA,
+	rotationStatus *hlfv1alpha1.FabricCARotationStatus,
+	caState *Status,
+	releaseName string,
+	ns string,
+) error {
+	rotationSecret, err := clientSet.CoreV1().Secrets(ns).Get(ctx, getRotationSecretName(releaseName), v1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the new root certificates of CA %s/%s", ns, fabricCA.Name)
+	}
+	newSign, err := getRootKeyPair(rotationSecret.Data, "")
+	if err != nil {
+		return err
+	}
+	err = replaceCryptoMaterial(ctx, clientSet, fmt.Sprintf("%s--msp-cryptomaterial", releaseName), ns, newSign)
+	if err != nil {
+		return err
+	}
+	rotationStatus.TrustedCACert = caState.CACert
+	caState.CACert = string(utils.EncodeX509Certificate(newSign.Cert))
+	if fabricCA.Spec.Rotation.TLSCA {
+		newTLSCA, err := getRootKeyPair(rotationSecret.Data, "tlsca")
+		if err != nil {
+			return err
+		}
+		err = replaceCryptoMaterial(ctx, clientSet, fmt.Sprintf("%s--msp-tls-cryptomaterial", releaseName), ns, newTLSCA)
+		if err != nil {
+			return err
+		}
+		rotationStatus.TrustedTLSCACert = caState.TLSCACert
+		caState.TLSCACert = string(utils.EncodeX509Certificate(newTLSCA.Cert))
+	}
+	now := v1.NewTime(time.Now())
+	rotationStatus.Phase = hlfv1alpha1.CARotationSwitchedPhase
+	rotationStatus.SwitchTime = &now
+	rotationStatus.Message = "Waiting for the CA to sign with the new root certificates"
+	log.Infof("CA %s/%s switched to the new root certificates", ns, fabricCA.Name)
+	return nil
+}
+
+func replaceCryptoMaterial(ctx context.Context, clientSet kubernetes.Interface, secretName string, ns string, root rootKeyPair) error {
+	secret, err := clientSet.CoreV1().Secrets(ns).Get(ctx, secretName, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	keyPEM, err := utils.EncodePrivateKey(root.Key)
+	if err != nil {
+		return err
+	}
+	secret.Data["keyfile"] = keyPEM
+	secret.Data["certfile"] = utils.EncodeX509Certificate(root.Cert)
+	secret.Data["chainfile"] = []byte{}
+	_, err = clientSet.CoreV1().Secrets(ns).Update(ctx, secret, v1.UpdateOptions{})
+	return err
+}
+
+// isServingRoot returns true if the CA server returns the sign root certificate stored in its crypto material, that
+// is, if the CA restarted after switching to the new keys
+func isServingRoot(fabricCA *hlfv1alpha1.FabricCA, caState *Status) bool {
+	caInfo, err := certs.GetCAInfo(certs.GetCAInfoRequest{
+		TLSCert: caState.TlsCert,
+		URL:     fmt.Sprintf("https://%s", helpers.GetCAPrivateURL(*fabricCA)),
+		Name:    fabricCA.Spec.CA.Name,
+		MSPID:   fmt.Sprintf("%sMSP", fabricCA.Name),
+	})
+	if err != nil {
+		log.Infof("Failed to get the info of CA %s/%s: %v", fabricCA.Namespace, fabricCA.Name, err)
+		return false
+	}
+	servedCerts, err := utils.ParseX509Certificates(caInfo.CAChain)
+	if err != nil {
+		return false
+	}
+	signCert, err := utils.ParseX509Certificate([]byte(caState.CACert))
+	if err != nil {
+		return false
+	}
+	for _, servedCert := range servedCerts {
+		if servedCert.Equal(signCert) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncRotationChannels returns the FabricMainChannels with organizations enrolled by the CA whose configuration doesn't
+// contain the root certificates of the CA yet, those channels are annotated so that they're reconciled
+func syncRotationChannels(
+	ctx context.Context,
+	clientSet kubernetes.Interface,
+	hlfClientSet operatorv1.Interface,
+	fabricCA *hlfv1alpha1.FabricCA,
+	caStatus hlfv1alpha1.FabricCAStatus,
+) ([]string, error) {
+	caRootCerts, err := helpers.GetCARootCerts(caStatus)
+	if err != nil {
+		return nil, err
+	}
+	channels, err := hlfClientSet.HlfV1alpha1().FabricMainChannels().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	annotation := fmt.Sprintf("%s/%s/%s/%d", fabricCA.Namespace, fabricCA.Name, caStatus.Rotation.Phase, caStatus.Rotation.RequestTime.Unix())
+	var pendingChannels []string
+	for _, channel := range channels.Items {
+		upToDate, err := channelTrustsRoots(ctx, clientSet, &channel, fabricCA, caRootCerts)
+		if err != nil {
+			return nil, err
+		}
+		if upToDate {
+			continue
+		}
+		pendingChannels = append(pendingChannels, channel.Name)
+		if channel.Annotations[caRotationAnnotation] == annotation {
+			continue
+		}
+		if channel.Annotations == nil {
+			channel.Annotations = map[string]string{}
+		}
+		channel.Annotations[caRotationAnnotation] = annotation
+		_, err = hlfClientSet.HlfV1alpha1().FabricMainChannels().Update(ctx, &channel, v1.UpdateOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to annotate channel %s", channel.Name)
+		}
+	}
+	return pendingChannels, nil
+}
+
+// channelTrustsRoots returns true if the MSP of every organization of the channel enrolled by the CA has its root
+// certificates, the configuration is read from the config map the FabricMainChannel controller keeps updated
+func channelTrustsRoots(
+	ctx context.Context,
+	clientSet kubernetes.Interface,
+	channel *hlfv1alpha1.FabricMainChannel,
+	fabricCA *hlfv1alpha1.FabricCA,
+	caRootCerts *helpers.CARootCerts,
+) (bool, error) {
+	var peerMSPIDs, ordererMSPIDs []string
+	for _, peerOrg := range channel.Spec.PeerOrganizations {
+		if peerOrg.CAName == fabricCA.Name && peerOrg.CANamespace == fabricCA.Namespace {
+			peerMSPIDs = append(peerMSPIDs, peerOrg.MSPID)
+		}
+	}
+	for _, ordererOrg := range channel.Spec.OrdererOrganizations {
+		if ordererOrg.CAName == fabricCA.Name && ordererOrg.CANamespace == fabricCA.Namespace {
+			ordererMSPIDs = append(ordererMSPIDs, ordererOrg.MSPID)
+		}
+	}
+	if len(peerMSPIDs) == 0 && len(ordererMSPIDs) == 0 {
+		return true, nil
+	}
+	configMap, err := clientSet.CoreV1().ConfigMaps("default").Get(ctx, fmt.Sprintf("%s-config", channel.Name), v1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	channelConfig := &cb.Config{}
+	err = protolator.DeepUnmarshalJSON(strings.NewReader(configMap.Data["channel.json"]), channelConfig)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to parse the configuration of channel %s", channel.Name)
+	}
+	configTx := configtx.New(channelConfig)
+	var msps []configtx.MSP
+	for _, mspID := range peerMSPIDs {
+		org := configTx.Application().Organization(mspID)
+		if org == nil {
+			continue
+		}
+		msp, err := org.MSP().Configuration()
+		if err != nil {
+			return false, err
+		}
+		msps = append(msps, msp)
+	}
+	for _, mspID := range ordererMSPIDs {
+		org := configTx.Orderer().Organization(mspID)
+		if org == nil {
+			continue
+		}
+		msp, err := org.MSP().Configuration()
+		if err != nil {
+			return false, err
+		}
+		msps = append(msps, msp)
+	}
+	for _, msp := range msps {
+		if !helpers.CertsEqual(msp.RootCerts, caRootCerts.RootCerts) ||
+			!helpers.CertsEqual(msp.TLSRootCerts, caRootCerts.TLSRootCerts) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// reenrollNodes requests the renewal of the certificates of the peers and orderers enrolled by the CA, it returns the
+// namespaced names of the nodes
+func reenrollNodes(ctx context.Context, hlfClientSet operatorv1.Interface, fabricCA *hlfv1alpha1.FabricCA, tlsCA bool) ([]string, error) {
+	caHosts := []string{
+		fabricCA.Name,
+		fmt.Sprintf("%s.%s", fabricCA.Name, fabricCA.Namespace),
+		fmt.Sprintf("%s.%s.svc", fabricCA.Name, fabricCA.Namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", fabricCA.Name, fabricCA.Namespace),
+	}
+	caHosts = append(caHosts, fabricCA.Spec.Hosts...)
+	if fabricCA.Spec.Istio != nil {
+		caHosts = append(caHosts, fabricCA.Spec.Istio.Hosts...)
+	}
+	enrolledByCA := func(enrollment hlfv1alpha1.Enrollment) bool {
+		component := enrollment.Component
+		if utils.Contains(caHosts, component.Cahost) && component.Caname == fabricCA.Spec.CA.Name {
+			return true
+		}
+		return tlsCA && utils.Contains(caHosts, enrollment.TLS.Cahost) && enrollment.TLS.Caname == fabricCA.Spec.TLSCA.Name
+	}
+	now := v1.NewTime(time.Now())
+	reenrolledNodes := []string{}
+	peers, err := hlfClientSet.HlfV1alpha1().FabricPeers("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, peer := range peers.Items {
+		if !enrolledByCA(peer.Spec.Secret.Enrollment) {
+			continue
+		}
+		peer.Spec.UpdateCertificateTime = &now
+		_, err = hlfClientSet.HlfV1alpha1().FabricPeers(peer.Namespace).Update(ctx, &peer, v1.UpdateOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to re-enroll peer %s/%s", peer.Namespace, peer.Name)
+		}
+		log.Infof("Re-enrolling peer %s/%s", peer.Namespace, peer.Name)
+		reenrolledNodes = append(reenrolledNodes, fmt.Sprintf("%s/%s", peer.Namespace, peer.Name))
+	}
+	ordererNodes, err := hlfClientSet.HlfV1alpha1().FabricOrdererNodes("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ordererNode := range ordererNodes.Items {
+		if ordererNode.Spec.Secret == nil || !enrolledByCA(ordererNode.Spec.Secret.Enrollment) {
+			continue
+		}
+		ordererNode.Spec.UpdateCertificateTime = &now
+		_, err = hlfClientSet.HlfV1alpha1().FabricOrdererNodes(ordererNode.Namespace).Update(ctx, &ordererNode, v1.UpdateOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to re-enroll orderer node %s/%s", ordererNode.Namespace, ordererNode.Name)
+		}
+		log.Infof("Re-enrolling orderer node %s/%s", ordererNode.Namespace, ordererNode.Name)
+		reenrolledNodes = append(reenrolledNodes, fmt.Sprintf("%s/%s", ordererNode.Namespace, ordererNode.Name))
+	}
+	return reenrolledNodes, nil
+}
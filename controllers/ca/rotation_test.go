@@ -0,0 +1,269 @@
+package ca
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	hlffake "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestRoot(g *WithT, cn string) rootKeyPair {
+	crt, key, err := CreateDefaultCA(hlfv1alpha1.FabricCAItemConf{
+		Name:    cn,
+		Subject: hlfv1alpha1.FabricCASubject{CN: cn, O: "Org1"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	return rootKeyPair{Cert: crt, Key: key}
+}
+
+func TestCrossSignCertificate(t *testing.T) {
+	g := NewWithT(t)
+	oldRoot := newTestRoot(g, "ca")
+	newRoot := newTestRoot(g, "ca")
+
+	crossSigned, err := crossSignCertificate(newRoot, oldRoot)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(crossSigned.CheckSignatureFrom(oldRoot.Cert)).To(Succeed())
+	g.Expect(crossSigned.CheckSignatureFrom(newRoot.Cert)).NotTo(Succeed())
+	g.Expect(crossSigned.Subject.String()).To(Equal(newRoot.Cert.Subject.String()))
+	g.Expect(crossSigned.SubjectKeyId).To(Equal(newRoot.Cert.SubjectKeyId))
+	g.Expect(crossSigned.AuthorityKeyId).To(Equal(oldRoot.Cert.SubjectKeyId))
+	g.Expect(keyMatchesCert(crossSigned, newRoot.Key)).To(BeTrue())
+	g.Expect(crossSigned.NotAfter.After(oldRoot.Cert.NotAfter)).To(BeFalse())
+
+	// a certificate issued by the new root is trusted by the clients that only trust the old root
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "peer0"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	leafBytes, err := x509.CreateCertificate(rand.Reader, leafTemplate, newRoot.Cert, newRoot.Key.Public(), newRoot.Key)
+	g.Expect(err).NotTo(HaveOccurred())
+	leaf, err := x509.ParseCertificate(leafBytes)
+	g.Expect(err).NotTo(HaveOccurred())
+	oldRoots := x509.NewCertPool()
+	oldRoots.AddCert(oldRoot.Cert)
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(crossSigned)
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: oldRoots, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: oldRoots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func newTestCryptoMaterial(g *WithT, name string, root rootKeyPair) *corev1.Secret {
+	keyPEM, err := utils.EncodePrivateKey(root.Key)
+	g.Expect(err).NotTo(HaveOccurred())
+	return &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+		Data: map[string][]byte{
+			"keyfile":  keyPEM,
+			"certfile": utils.EncodeX509Certificate(root.Cert),
+		},
+	}
+}
+
+func getSecretData(g *WithT, clientSet kubernetes.Interface, secretName string, key string) string {
+	secret, err := clientSet.CoreV1().Secrets("default").Get(context.Background(), secretName, v1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	return string(secret.Data[key])
+}
+
+func TestReconcileRotation(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	signRoot := newTestRoot(g, "ca")
+	tlsRoot := newTestRoot(g, "tlsca")
+	clientSet := fake.NewSimpleClientset(
+		newTestCryptoMaterial(g, "org1-ca--msp-cryptomaterial", signRoot),
+		newTestCryptoMaterial(g, "org1-ca--msp-tls-cryptomaterial", tlsRoot),
+	)
+	peer := &hlfv1alpha1.FabricPeer{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-peer0", Namespace: "default"},
+		Spec: hlfv1alpha1.FabricPeerSpec{
+			Secret: hlfv1alpha1.Secret{Enrollment: hlfv1alpha1.Enrollment{
+				Component: hlfv1alpha1.Component{Cahost: "org1-ca.default", Caname: "ca"},
+			}},
+		},
+	}
+	otherPeer := &hlfv1alpha1.FabricPeer{
+		ObjectMeta: v1.ObjectMeta{Name: "org2-peer0", Namespace: "default"},
+		Spec: hlfv1alpha1.FabricPeerSpec{
+			Secret: hlfv1alpha1.Secret{Enrollment: hlfv1alpha1.Enrollment{
+				Component: hlfv1alpha1.Component{Cahost: "org2-ca.default", Caname: "ca"},
+			}},
+		},
+	}
+	hlfClientSet := hlffake.NewSimpleClientset(peer, otherPeer)
+	requestTime := v1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	fabricCA := &hlfv1alpha1.FabricCA{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-ca", Namespace: "default"},
+		Spec: hlfv1alpha1.FabricCASpec{
+			CA:       hlfv1alpha1.FabricCAItemConf{Name: "ca", Subject: hlfv1alpha1.FabricCASubject{CN: "ca", O: "Org1"}},
+			TLSCA:    hlfv1alpha1.FabricCAItemConf{Name: "tlsca", Subject: hlfv1alpha1.FabricCASubject{CN: "tlsca", O: "Org1"}},
+			Rotation: &hlfv1alpha1.FabricCARotation{RequestTime: requestTime, CrossSign: true, TransitionWindow: "1h"},
+		},
+	}
+	caState := &Status{
+		Status:    hlfv1alpha1.RunningStatus,
+		CACert:    string(utils.EncodeX509Certificate(signRoot.Cert)),
+		TLSCACert: string(utils.EncodeX509Certificate(tlsRoot.Cert)),
+	}
+	servingRoot := false
+	reconcile := func() (*hlfv1alpha1.FabricCARotationStatus, bool) {
+		rotationStatus, requeue, err := reconcileRotation(ctx, clientSet, hlfClientSet, fabricCA, caState, "org1-ca", "default",
+			func(*hlfv1alpha1.FabricCA, *Status) bool { return servingRoot })
+		g.Expect(err).NotTo(HaveOccurred())
+		fabricCA.Status.Rotation = rotationStatus
+		return rotationStatus, requeue
+	}
+	getCertFile := func(secretName string, key string) string {
+		return getSecretData(g, clientSet, secretName, key)
+	}
+
+	// the new roots are generated and published while the CA keeps signing with the previous ones
+	rotationStatus, requeue := reconcile()
+	g.Expect(requeue).To(BeTrue())
+	g.Expect(rotationStatus.Phase).To(Equal(hlfv1alpha1.CARotationTransitionPhase))
+	g.Expect(rotationStatus.RequestTime).To(Equal(requestTime))
+	newCACert := getCertFile("org1-ca--rotation-cryptomaterial", "certfile")
+	g.Expect(rotationStatus.TrustedCACert).To(Equal(newCACert))
+	g.Expect(rotationStatus.CrossSignedCACert).To(Equal(getCertFile("org1-ca--rotation-cryptomaterial", "crosssignedcertfile")))
+	g.Expect(rotationStatus.TrustedTLSCACert).To(BeEmpty())
+	g.Expect(getCertFile("org1-ca--msp-cryptomaterial", "certfile")).To(Equal(caState.CACert))
+
+	// no channel uses the CA, so the CA switches to the new roots
+	previousCACert := caState.CACert
+	rotationStatus, requeue = reconcile()
+	g.Expect(requeue).To(BeTrue())
+	g.Expect(rotationStatus.Phase).To(Equal(hlfv1alpha1.CARotationSwitchedPhase))
+	g.Expect(rotationStatus.SwitchTime).NotTo(BeNil())
+	g.Expect(rotationStatus.TrustedCACert).To(Equal(previousCACert))
+	g.Expect(caState.CACert).To(Equal(newCACert))
+	g.Expect(getCertFile("org1-ca--msp-cryptomaterial", "certfile")).To(Equal(newCACert))
+	// the TLS CA isn't rotated
+	g.Expect(getCertFile("org1-ca--msp-tls-cryptomaterial", "certfile")).To(Equal(caState.TLSCACert))
+
+	// the CA hasn't restarted with the new keys yet
+	rotationStatus, requeue = reconcile()
+	g.Expect(requeue).To(BeTrue())
+	g.Expect(rotationStatus.Phase).To(Equal(hlfv1alpha1.CARotationSwitchedPhase))
+	g.Expect(rotationStatus.Message).To(Equal("Waiting for the CA to sign with the new root certificates"))
+	g.Expect(rotationStatus.ReenrolledNodes).To(BeNil())
+
+	// the nodes enrolled by the CA are re-enrolled once, the previous roots stay trusted during the transition window
+	servingRoot = true
+	rotationStatus, requeue = reconcile()
+	g.Expect(requeue).To(BeTrue())
+	g.Expect(rotationStatus.Phase).To(Equal(hlfv1alpha1.CARotationSwitchedPhase))
+	g.Expect(rotationStatus.ReenrolledNodes).To(Equal([]string{"default/org1-peer0"}))
+	g.Expect(rotationStatus.TrustedCACert).To(Equal(previousCACert))
+	reenrolledPeer, err := hlfClientSet.HlfV1alpha1().FabricPeers("default").Get(ctx, "org1-peer0", v1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reenrolledPeer.Spec.UpdateCertificateTime).NotTo(BeNil())
+	otherPeer, err = hlfClientSet.HlfV1alpha1().FabricPeers("default").Get(ctx, "org2-peer0", v1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(otherPeer.Spec.UpdateCertificateTime).To(BeNil())
+
+	// a new request is ignored while the rotation is in progress
+	fabricCA.Spec.Rotation.RequestTime = v1.NewTime(requestTime.Add(time.Second))
+	rotationStatus, _ = reconcile()
+	g.Expect(rotationStatus.Phase).To(Equal(hlfv1alpha1.CARotationSwitchedPhase))
+	g.Expect(rotationStatus.RequestTime).To(Equal(requestTime))
+
+	// the previous roots are no longer trusted after the transition window
+	switchTime := v1.NewTime(time.Now().Add(-2 * time.Hour))
+	fabricCA.Status.Rotation.SwitchTime = &switchTime
+	rotationStatus, requeue = reconcile()
+	g.Expect(requeue).To(BeTrue())
+	g.Expect(rotationStatus.Phase).To(Equal(hlfv1alpha1.CARotationCompletedPhase))
+	g.Expect(rotationStatus.TrustedCACert).To(BeEmpty())
+	g.Expect(rotationStatus.TrustedTLSCACert).To(BeEmpty())
+
+	// the request ignored during the rotation starts a new one
+	rotationStatus, requeue = reconcile()
+	g.Expect(requeue).To(BeTrue())
+	g.Expect(rotationStatus.Phase).To(Equal(hlfv1alpha1.CARotationTransitionPhase))
+	g.Expect(rotationStatus.RequestTime).To(Equal(fabricCA.Spec.Rotation.RequestTime))
+}
+
+func TestReconcileRotationWaitsForChannels(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	signRoot := newTestRoot(g, "ca")
+	tlsRoot := newTestRoot(g, "tlsca")
+	clientSet := fake.NewSimpleClientset(
+		newTestCryptoMaterial(g, "org1-ca--msp-cryptomaterial", signRoot),
+		newTestCryptoMaterial(g, "org1-ca--msp-tls-cryptomaterial", tlsRoot),
+	)
+	// the channel config map doesn't exist yet, so the channel doesn't trust the new roots
+	hlfClientSet := hlffake.NewSimpleClientset(&hlfv1alpha1.FabricMainChannel{
+		ObjectMeta: v1.ObjectMeta{Name: "demo"},
+		Spec: hlfv1alpha1.FabricMainChannelSpec{
+			PeerOrganizations: []hlfv1alpha1.FabricMainChannelPeerOrganization{
+				{MSPID: "Org1MSP", CAName: "org1-ca", CANamespace: "default"},
+			},
+		},
+	})
+	fabricCA := &hlfv1alpha1.FabricCA{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-ca", Namespace: "default"},
+		Spec: hlfv1alpha1.FabricCASpec{
+			CA:       hlfv1alpha1.FabricCAItemConf{Name: "ca", Subject: hlfv1alpha1.FabricCASubject{CN: "ca", O: "Org1"}},
+			TLSCA:    hlfv1alpha1.FabricCAItemConf{Name: "tlsca", Subject: hlfv1alpha1.FabricCASubject{CN: "tlsca", O: "Org1"}},
+			Rotation: &hlfv1alpha1.FabricCARotation{RequestTime: v1.NewTime(time.Now()), TLSCA: true},
+		},
+	}
+	caState := &Status{
+		Status:    hlfv1alpha1.RunningStatus,
+		CACert:    string(utils.EncodeX509Certificate(signRoot.Cert)),
+		TLSCACert: string(utils.EncodeX509Certificate(tlsRoot.Cert)),
+	}
+	isServingRoot := func(*hlfv1alpha1.FabricCA, *Status) bool { return true }
+
+	rotationStatus, _, err := reconcileRotation(ctx, clientSet, hlfClientSet, fabricCA, caState, "org1-ca", "default", isServingRoot)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rotationStatus.Phase).To(Equal(hlfv1alpha1.CARotationTransitionPhase))
+	g.Expect(rotationStatus.TrustedTLSCACert).NotTo(BeEmpty())
+	g.Expect(rotationStatus.CrossSignedCACert).To(BeEmpty())
+	fabricCA.Status.Rotation = rotationStatus
+
+	rotationStatus, requeue, err := reconcileRotation(ctx, clientSet, hlfClientSet, fabricCA, caState, "org1-ca", "default", isServingRoot)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requeue).To(BeTrue())
+	g.Expect(rotationStatus.Phase).To(Equal(hlfv1alpha1.CARotationTransitionPhase))
+	g.Expect(rotationStatus.Message).To(Equal("Waiting for channels demo to trust the new root certificates"))
+	channel, err := hlfClientSet.HlfV1alpha1().FabricMainChannels().Get(ctx, "demo", v1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(channel.Annotations).To(HaveKey(caRotationAnnotation))
+	g.Expect(getSecretData(g, clientSet, "org1-ca--msp-cryptomaterial", "certfile")).To(Equal(caState.CACert))
+}
+
+func TestReconcileRotationInvalid(t *testing.T) {
+	g := NewWithT(t)
+	fabricCA := &hlfv1alpha1.FabricCA{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-ca", Namespace: "default"},
+		Spec: hlfv1alpha1.FabricCASpec{
+			Rotation: &hlfv1alpha1.FabricCARotation{RequestTime: v1.NewTime(time.Now()), TransitionWindow: "one day"},
+		},
+	}
+	rotationStatus, requeue, err := reconcileRotation(context.Background(), fake.NewSimpleClientset(), hlffake.NewSimpleClientset(), fabricCA, &Status{}, "org1-ca", "default", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(requeue).To(BeFalse())
+	g.Expect(rotationStatus.Phase).To(Equal(hlfv1alpha1.CARotationFailedPhase))
+	g.Expect(rotationStatus.Message).To(ContainSubstring("invalid transition window one day"))
+}
@@ -3,26 +3,29 @@ package ca
 import corev1 "k8s.io/api/core/v1"
 
 type FabricCAChart struct {
-	Istio            Istio                         `json:"istio"`
-	FullNameOverride string                        `json:"fullnameOverride"`
-	Image            Image                         `json:"image"`
-	Service          Service                       `json:"service"`
-	Persistence      Persistence                   `json:"persistence"`
-	Msp              Msp                           `json:"msp"`
-	Database         Database                      `json:"db"`
-	Resources        Resources                     `json:"resources"`
-	NodeSelector     *corev1.NodeSelector          `json:"nodeSelector,omitempty"`
-	Tolerations      []corev1.Toleration           `json:"tolerations"`
-	Affinity         *corev1.Affinity              `json:"affinity,omitempty"`
-	Metrics          FabricCAChartMetrics          `json:"metrics"`
-	Debug            bool                          `json:"debug"`
-	CLRSizeLimit     int                           `json:"clrsizelimit"`
-	Ca               FabricCAChartItemConf         `json:"ca"`
-	TLSCA            FabricCAChartItemConf         `json:"tlsCA"`
-	Cors             Cors                          `json:"cors"`
-	ServiceMonitor   ServiceMonitor                `json:"serviceMonitor"`
-	EnvVars          []corev1.EnvVar               `json:"envVars"`
-	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets"`
+	Istio                     Istio                             `json:"istio"`
+	FullNameOverride          string                            `json:"fullnameOverride"`
+	Image                     Image                             `json:"image"`
+	Service                   Service                           `json:"service"`
+	Persistence               Persistence                       `json:"persistence"`
+	Msp                       Msp                               `json:"msp"`
+	Replicas                  int                               `json:"replicas"`
+	Database                  Database                          `json:"db"`
+	Resources                 Resources                         `json:"resources"`
+	NodeSelector              *corev1.NodeSelector              `json:"nodeSelector,omitempty"`
+	Tolerations               []corev1.Toleration               `json:"tolerations"`
+	Affinity                  *corev1.Affinity                  `json:"affinity,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	PriorityClassName         string                            `json:"priorityClassName,omitempty"`
+	Metrics                   FabricCAChartMetrics              `json:"metrics"`
+	Debug                     bool                              `json:"debug"`
+	CLRSizeLimit              int                               `json:"clrsizelimit"`
+	Ca                        FabricCAChartItemConf             `json:"ca"`
+	TLSCA                     FabricCAChartItemConf             `json:"tlsCA"`
+	Cors                      Cors                              `json:"cors"`
+	ServiceMonitor            ServiceMonitor                    `json:"serviceMonitor"`
+	EnvVars                   []corev1.EnvVar                   `json:"envVars"`
+	ImagePullSecrets          []corev1.LocalObjectReference     `json:"imagePullSecrets"`
 }
 type ServiceMonitor struct {
 	Enabled           bool              `json:"enabled"`
@@ -54,10 +57,54 @@ type FabricCAChartItemConf struct {
 	Intermediate FabricCAChartIntermediate `json:"intermediate"`
 	BCCSP        FabricCAChartBCCSP        `json:"bccsp"`
 	Affiliations []Affiliation             `json:"affiliations"`
+	// Secret with the PIN of the PKCS#11 token, set in the configuration when the CA starts
+	PKCS11PinSecret *PKCS11PinSecret     `json:"pkcs11PinSecret,omitempty"`
+	LDAP            *FabricCAChartLDAP   `json:"ldap,omitempty"`
+	Idemix          *FabricCAChartIdemix `json:"idemix,omitempty"`
+}
+type FabricCAChartIdemix struct {
+	RHPoolSize         int    `json:"rhpoolsize"`
+	NonceExpiration    string `json:"nonceexpiration"`
+	NonceSweepInterval string `json:"noncesweepinterval"`
+	Curve              string `json:"curve,omitempty"`
+}
+type FabricCAChartLDAP struct {
+	// URL with the bind DN and password, the chart keeps it in a secret and adds it to the configuration on startup
+	URL         string                     `json:"url"`
+	UserFilter  string                     `json:"userfilter"`
+	GroupFilter string                     `json:"groupfilter"`
+	TLS         *FabricCAChartLDAPTLS      `json:"tls,omitempty"`
+	Attribute   FabricCAChartLDAPAttribute `json:"attribute"`
+}
+type FabricCAChartLDAPTLS struct {
+	CACert     SecretKey  `json:"caCert"`
+	ClientCert *SecretKey `json:"clientCert,omitempty"`
+	ClientKey  *SecretKey `json:"clientKey,omitempty"`
+}
+type FabricCAChartLDAPAttribute struct {
+	Names      []string                                `json:"names"`
+	Converters []FabricCAChartLDAPNameValue            `json:"converters"`
+	Maps       map[string][]FabricCAChartLDAPNameValue `json:"maps"`
+}
+type FabricCAChartLDAPNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 type FabricCAChartBCCSP struct {
-	Default string               `json:"default"`
-	SW      FabricCAChartBCCSPSW `json:"sw"`
+	Default string                    `json:"default"`
+	SW      FabricCAChartBCCSPSW      `json:"sw"`
+	PKCS11  *FabricCAChartBCCSPPKCS11 `json:"pkcs11,omitempty"`
+}
+type FabricCAChartBCCSPPKCS11 struct {
+	Library  string `json:"library"`
+	Label    string `json:"label"`
+	Pin      string `json:"pin"`
+	Hash     string `json:"hash"`
+	Security string `json:"security"`
+}
+type PKCS11PinSecret struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
 }
 type FabricCAChartBCCSPSW struct {
 	Hash     string `json:"hash"`
@@ -66,6 +113,8 @@ type FabricCAChartBCCSPSW struct {
 
 type FabricCAChartIntermediate struct {
 	ParentServer FabricCAChartIntermediateParentServer `json:"parentServer"`
+	Enrollment   FabricCAChartIntermediateEnrollment   `json:"enrollment"`
+	TLS          FabricCAChartIntermediateTLS          `json:"tls"`
 }
 type FabricCAChartIntermediateParentServer struct {
 	URL    string `json:"url"`
@@ -181,6 +230,9 @@ type Msp struct {
 	TLSCAChainfile string `json:"tlsCAChainfile"`
 	TlsKeyFile     string `json:"tlsKeyFile"`
 	TlsCertFile    string `json:"tlsCertFile"`
+	// TLS certificate of the parent server of an intermediate CA
+	ParentCertfile      string `json:"parentCertfile"`
+	TLSCAParentCertfile string `json:"tlsCAParentCertfile"`
 }
 
 type ConfigurationFiles struct {
@@ -195,8 +247,18 @@ type Mysql struct {
 	ConfigurationFiles ConfigurationFiles `json:"configurationFiles"`
 }
 type Database struct {
-	Type       string `json:"type"`
-	Datasource string `json:"datasource"`
+	Type       string       `json:"type"`
+	Datasource string       `json:"datasource"`
+	TLS        *DatabaseTLS `json:"tls,omitempty"`
+}
+type DatabaseTLS struct {
+	CACert     SecretKey  `json:"caCert"`
+	ClientCert *SecretKey `json:"clientCert,omitempty"`
+	ClientKey  *SecretKey `json:"clientKey,omitempty"`
+}
+type SecretKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
 }
 
 type Names struct {
@@ -0,0 +1,94 @@
+package certs
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"io"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric/bccsp"
+	"github.com/pkg/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ErrPKCS11NotSupported is returned when a key has to be generated in a PKCS#11 token by a binary built without the
+// pkcs11 tag
+var ErrPKCS11NotSupported = errors.New("PKCS#11 isn't supported, this binary was built without the pkcs11 tag: use the hlf-operator-pkcs11 binary or build it with CGO_ENABLED=1 and -tags pkcs11")
+
+// PKCS11Opts are the settings to open a PKCS#11 token
+type PKCS11Opts struct {
+	Library  string
+	Label    string
+	Pin      string
+	Hash     string
+	Security int
+}
+
+// GetPKCS11Opts returns the options to open the PKCS#11 token of the spec, the PIN is read from its secret in namespace
+func GetPKCS11Opts(ctx context.Context, client kubernetes.Interface, namespace string, conf *hlfv1alpha1.BCCSPPKCS11) (*PKCS11Opts, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, conf.Pin.Name, v1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the secret %s with the PIN of the PKCS#11 token", conf.Pin.Name)
+	}
+	pin, ok := secret.Data[conf.Pin.Key]
+	if !ok {
+		return nil, errors.Errorf("secret %s has no key %s with the PIN of the PKCS#11 token", conf.Pin.Name, conf.Pin.Key)
+	}
+	hash := conf.Hash
+	if hash == "" {
+		hash = "SHA2"
+	}
+	security := conf.Security
+	if security == 0 {
+		security = 256
+	}
+	return &PKCS11Opts{
+		Library:  conf.Library,
+		Label:    conf.Label,
+		Pin:      string(pin),
+		Hash:     hash,
+		Security: security,
+	}, nil
+}
+
+// hsmSigner signs with a private key that stays in a PKCS#11 token
+type hsmSigner struct {
+	csp bccsp.BCCSP
+	key bccsp.Key
+	pub crypto.PublicKey
+}
+
+func newHSMSigner(csp bccsp.BCCSP, key bccsp.Key) (crypto.Signer, error) {
+	pubKey, err := key.PublicKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the public key")
+	}
+	raw, err := pubKey.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the public key")
+	}
+	return &hsmSigner{csp: csp, key: key, pub: pub}, nil
+}
+
+func (s *hsmSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s *hsmSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.csp.Sign(s.key, digest, opts)
+}
+
+// IsHSMKey returns true if the private key is kept in a PKCS#11 token and can't be exported
+func IsHSMKey(key crypto.Signer) bool {
+	_, ok := key.(*hsmSigner)
+	return ok
+}
@@ -0,0 +1,60 @@
+package certs
+
+import (
+	"context"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetPKCS11Opts(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "hsm-pin", Namespace: "default"},
+		Data:       map[string][]byte{"pin": []byte("98765432")},
+	})
+	pin := corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "hsm-pin"}, Key: "pin"}
+
+	opts, err := GetPKCS11Opts(ctx, client, "default", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(opts).To(BeNil())
+
+	// the hash and the security level default to the ones of the SW BCCSP
+	opts, err = GetPKCS11Opts(ctx, client, "default", &hlfv1alpha1.BCCSPPKCS11{
+		Library: "/usr/lib/softhsm/libsofthsm2.so",
+		Label:   "fabric",
+		Pin:     pin,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(opts).To(Equal(&PKCS11Opts{
+		Library:  "/usr/lib/softhsm/libsofthsm2.so",
+		Label:    "fabric",
+		Pin:      "98765432",
+		Hash:     "SHA2",
+		Security: 256,
+	}))
+
+	opts, err = GetPKCS11Opts(ctx, client, "default", &hlfv1alpha1.BCCSPPKCS11{
+		Library:  "/usr/lib/softhsm/libsofthsm2.so",
+		Label:    "fabric",
+		Pin:      pin,
+		Hash:     "SHA3",
+		Security: 384,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(opts.Hash).To(Equal("SHA3"))
+	g.Expect(opts.Security).To(Equal(384))
+
+	// the PIN is read from the namespace of the node
+	_, err = GetPKCS11Opts(ctx, client, "other", &hlfv1alpha1.BCCSPPKCS11{Pin: pin})
+	g.Expect(err).To(MatchError(ContainSubstring("failed to get the secret hsm-pin with the PIN of the PKCS#11 token")))
+	_, err = GetPKCS11Opts(ctx, client, "default", &hlfv1alpha1.BCCSPPKCS11{
+		Pin: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "hsm-pin"}, Key: "userpin"},
+	})
+	g.Expect(err).To(MatchError("secret hsm-pin has no key userpin with the PIN of the PKCS#11 token"))
+}
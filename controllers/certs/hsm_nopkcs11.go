@@ -0,0 +1,18 @@
+//go:build !pkcs11
+// +build !pkcs11
+
+package certs
+
+import (
+	"crypto"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+)
+
+// PKCS11Supported is true when the operator is built with the pkcs11 tag, PKCS#11 needs cgo
+const PKCS11Supported = false
+
+// GenerateHSMKey returns ErrPKCS11NotSupported, the binary was built without cgo
+func GenerateHSMKey(opts *PKCS11Opts, keyRequest *hlfv1alpha1.KeyRequest) (crypto.Signer, error) {
+	return nil, ErrPKCS11NotSupported
+}
@@ -0,0 +1,28 @@
+//go:build !pkcs11
+// +build !pkcs11
+
+package certs
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGenerateHSMKeyNotSupported(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := GenerateHSMKey(&PKCS11Opts{Library: "/usr/lib/softhsm/libsofthsm2.so"}, nil)
+	g.Expect(err).To(Equal(ErrPKCS11NotSupported))
+	g.Expect(err.Error()).To(ContainSubstring("built without the pkcs11 tag"))
+	g.Expect(PKCS11Supported).To(BeFalse())
+
+	// the enrollment fails before contacting the CA
+	_, _, _, err = EnrollUser(EnrollUserRequest{
+		URL:    "https://127.0.0.1:1",
+		User:   "peer0",
+		Secret: "peer0pw",
+		PKCS11: &PKCS11Opts{Library: "/usr/lib/softhsm/libsofthsm2.so", Label: "fabric"},
+	})
+	g.Expect(err).To(Equal(ErrPKCS11NotSupported))
+}
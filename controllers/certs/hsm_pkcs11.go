@@ -0,0 +1,44 @@
+//go:build pkcs11
+// +build pkcs11
+
+package certs
+
+import (
+	"crypto"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric/bccsp"
+	p11factory "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric/bccsp/factory/pkcs11"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric/bccsp/pkcs11"
+	"github.com/pkg/errors"
+)
+
+// PKCS11Supported is true when the operator is built with the pkcs11 tag, PKCS#11 needs cgo
+const PKCS11Supported = true
+
+// GenerateHSMKey generates an ECDSA key pair inside the PKCS#11 token, the private key never leaves the token and is
+// found by Fabric with the subject key identifier of the public key
+func GenerateHSMKey(opts *PKCS11Opts, keyRequest *hlfv1alpha1.KeyRequest) (crypto.Signer, error) {
+	if err := ValidateSignKeyRequest(keyRequest); err != nil {
+		return nil, err
+	}
+	csp, err := (&p11factory.PKCS11Factory{}).Get(&pkcs11.PKCS11Opts{
+		SecLevel:   opts.Security,
+		HashFamily: opts.Hash,
+		Library:    opts.Library,
+		Label:      opts.Label,
+		Pin:        opts.Pin,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the PKCS#11 token")
+	}
+	var keyGenOpts bccsp.KeyGenOpts = &bccsp.ECDSAP256KeyGenOpts{Temporary: false}
+	if KeySecurityLevel(keyRequest) == 384 {
+		keyGenOpts = &bccsp.ECDSAP384KeyGenOpts{Temporary: false}
+	}
+	key, err := csp.KeyGen(keyGenOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate the key in the PKCS#11 token")
+	}
+	return newHSMSigner(csp, key)
+}
@@ -0,0 +1,85 @@
+//go:build pkcs11
+// +build pkcs11
+
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"os"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+)
+
+// softHSMOpts returns the options of the SoftHSM2 token initialized for the tests, for example with
+// softhsm2-util --init-token --free --label fabric --pin 98765432 --so-pin 1234
+func softHSMOpts(t *testing.T) *PKCS11Opts {
+	library := os.Getenv("PKCS11_LIBRARY")
+	if library == "" {
+		t.Skip("PKCS11_LIBRARY isn't set, the SoftHSM2 tests need an initialized token")
+	}
+	label := os.Getenv("PKCS11_LABEL")
+	if label == "" {
+		label = "fabric"
+	}
+	pin := os.Getenv("PKCS11_PIN")
+	if pin == "" {
+		pin = "98765432"
+	}
+	return &PKCS11Opts{
+		Library:  library,
+		Label:    label,
+		Pin:      pin,
+		Hash:     "SHA2",
+		Security: 256,
+	}
+}
+
+func TestGenerateHSMKey(t *testing.T) {
+	g := NewWithT(t)
+	opts := softHSMOpts(t)
+
+	key, err := GenerateHSMKey(opts, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(IsHSMKey(key)).To(BeTrue())
+
+	pub, ok := key.Public().(*ecdsa.PublicKey)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(pub.Curve).To(Equal(elliptic.P256()))
+
+	digest := sha256.Sum256([]byte("hlf-operator"))
+	signature, err := key.Sign(nil, digest[:], nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ecdsa.VerifyASN1(pub, digest[:], signature)).To(BeTrue())
+}
+
+func TestGenerateHSMKeyP384(t *testing.T) {
+	g := NewWithT(t)
+	opts := softHSMOpts(t)
+
+	key, err := GenerateHSMKey(opts, &hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmECDSA, Curve: "P384"})
+	g.Expect(err).ToNot(HaveOccurred())
+	pub, ok := key.Public().(*ecdsa.PublicKey)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(pub.Curve).To(Equal(elliptic.P384()))
+}
+
+func TestGenerateHSMKeyRejectsRSA(t *testing.T) {
+	g := NewWithT(t)
+	opts := softHSMOpts(t)
+
+	_, err := GenerateHSMKey(opts, &hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmRSA})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestGenerateHSMKeyWrongPin(t *testing.T) {
+	g := NewWithT(t)
+	opts := softHSMOpts(t)
+	opts.Pin = "wrong-pin"
+
+	_, err := GenerateHSMKey(opts, nil)
+	g.Expect(err).To(HaveOccurred())
+}
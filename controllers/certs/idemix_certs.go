@@ -0,0 +1,69 @@
+package certs
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	mspproto "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/lib"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/lib/tls"
+	caapi "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/api"
+)
+
+// IdemixCredential is an Idemix credential in the format loaded by the Idemix MSP of Fabric
+type IdemixCredential struct {
+	// Proto bytes of the msp.IdemixMSPSignerConfig, stored as user/SignerConfig in the MSP folder
+	SignerConfig []byte
+	// Proto bytes of the issuer public key, stored as msp/IssuerPublicKey in the MSP folder
+	IssuerPublicKey []byte
+	// PEM encoded revocation public key of the issuer, stored as msp/RevocationPublicKey in the MSP folder
+	RevocationPublicKey []byte
+}
+
+// EnrollIdemix requests an Idemix credential for the user of params to the CA, the attributes, hosts and key request
+// of params are ignored since the CA sets the attributes of Idemix credentials
+func EnrollIdemix(params EnrollUserRequest) (*IdemixCredential, error) {
+	homeDir, err := ioutil.TempDir("", "enroll")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(homeDir)
+	client := &lib.Client{
+		HomeDir: homeDir,
+		Config: &lib.ClientConfig{
+			URL: params.URL,
+			TLS: tls.ClientTLSConfig{
+				Enabled:   strings.HasPrefix(params.URL, "https://"),
+				CertFiles: [][]byte{[]byte(params.TLSCert)},
+			},
+			CAName: params.Name,
+		},
+	}
+	resp, err := client.EnrollIdemix(&caapi.EnrollmentRequest{
+		Name:   params.User,
+		Secret: params.Secret,
+		CAName: params.Name,
+		Type:   "idemix",
+	})
+	if err != nil {
+		return nil, err
+	}
+	signerConfig, err := proto.Marshal(&mspproto.IdemixMSPSignerConfig{
+		Cred:                            resp.Credential,
+		Sk:                              resp.Sk,
+		OrganizationalUnitIdentifier:    resp.OU,
+		Role:                            int32(resp.Role),
+		EnrollmentId:                    resp.EnrollmentID,
+		CredentialRevocationInformation: resp.CRI,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &IdemixCredential{
+		SignerConfig:        signerConfig,
+		IssuerPublicKey:     resp.CAInfo.IssuerPublicKey,
+		RevocationPublicKey: resp.CAInfo.IssuerRevocationPublicKey,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package certs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/lib"
+	caapi "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/api"
+)
+
+// withRegistrar enrolls the registrar of the request in a temporary home directory and calls fn with its identity
+func withRegistrar(params FabricCAParams, fn func(registrar *lib.Identity) error) error {
+	homeDir, err := ioutil.TempDir("", "registrar")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(homeDir)
+	registrar, err := enrollRegistrar(params, homeDir)
+	if err != nil {
+		return err
+	}
+	return fn(registrar)
+}
+
+// ListIdentities returns the identities of the CA the registrar is allowed to see
+func ListIdentities(params FabricCAParams) ([]caapi.IdentityInfo, error) {
+	var identities []caapi.IdentityInfo
+	err := withRegistrar(params, func(registrar *lib.Identity) error {
+		return registrar.GetAllIdentities(params.Name, func(decoder *json.Decoder) error {
+			var identity caapi.IdentityInfo
+			err := decoder.Decode(&identity)
+			if err != nil {
+				return err
+			}
+			identities = append(identities, identity)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// ModifyIdentity updates the type, affiliation, attributes, max enrollments or secret of an identity
+func ModifyIdentity(params FabricCAParams, req caapi.ModifyIdentityRequest) (*caapi.IdentityResponse, error) {
+	var identityResponse *caapi.IdentityResponse
+	err := withRegistrar(params, func(registrar *lib.Identity) error {
+		var err error
+		req.CAName = params.Name
+		identityResponse, err = registrar.ModifyIdentity(&req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return identityResponse, nil
+}
+
+// RemoveIdentity removes an identity from the CA, the CA must allow removing identities
+func RemoveIdentity(params FabricCAParams, id string, force bool) (*caapi.IdentityResponse, error) {
+	var identityResponse *caapi.IdentityResponse
+	err := withRegistrar(params, func(registrar *lib.Identity) error {
+		var err error
+		identityResponse, err = registrar.RemoveIdentity(&caapi.RemoveIdentityRequest{
+			ID:     id,
+			Force:  force,
+			CAName: params.Name,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return identityResponse, nil
+}
+
+// ListAffiliations returns the affiliation tree of the CA the registrar is allowed to see
+func ListAffiliations(params FabricCAParams) (*caapi.AffiliationResponse, error) {
+	var affiliationResponse *caapi.AffiliationResponse
+	err := withRegistrar(params, func(registrar *lib.Identity) error {
+		var err error
+		affiliationResponse, err = registrar.GetAllAffiliations(params.Name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return affiliationResponse, nil
+}
+
+// AddAffiliation adds an affiliation to the CA, its parent affiliations are created too when force is set
+func AddAffiliation(params FabricCAParams, name string, force bool) (*caapi.AffiliationResponse, error) {
+	var affiliationResponse *caapi.AffiliationResponse
+	err := withRegistrar(params, func(registrar *lib.Identity) error {
+		var err error
+		affiliationResponse, err = registrar.AddAffiliation(&caapi.AddAffiliationRequest{
+			Name:   name,
+			Force:  force,
+			CAName: params.Name,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return affiliationResponse, nil
+}
+
+// RemoveAffiliation removes an affiliation from the CA, the CA must allow removing affiliations
+func RemoveAffiliation(params FabricCAParams, name string, force bool) (*caapi.AffiliationResponse, error) {
+	var affiliationResponse *caapi.AffiliationResponse
+	err := withRegistrar(params, func(registrar *lib.Identity) error {
+		var err error
+		affiliationResponse, err = registrar.RemoveAffiliation(&caapi.RemoveAffiliationRequest{
+			Name:   name,
+			Force:  force,
+			CAName: params.Name,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return affiliationResponse, nil
+}
@@ -0,0 +1,135 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/lib"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/lib/tls"
+	caapi "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/api"
+	"github.com/pkg/errors"
+)
+
+const defaultRSAKeySize = 2048
+
+// GenerateKey generates a private key with the algorithm and size of the request, a P-256 ECDSA key when it's nil
+func GenerateKey(keyRequest *hlfv1alpha1.KeyRequest) (crypto.Signer, error) {
+	if keyRequest == nil {
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	switch keyRequest.Algorithm {
+	case hlfv1alpha1.KeyAlgorithmECDSA, "":
+		curve, err := getCurve(keyRequest.Curve)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case hlfv1alpha1.KeyAlgorithmRSA:
+		size := keyRequest.Size
+		if size == 0 {
+			size = defaultRSAKeySize
+		}
+		if size < defaultRSAKeySize {
+			return nil, errors.Errorf("RSA key size %d is too small, it must be at least %d bits", size, defaultRSAKeySize)
+		}
+		return rsa.GenerateKey(rand.Reader, size)
+	default:
+		return nil, errors.Errorf("unsupported key algorithm %s", keyRequest.Algorithm)
+	}
+}
+
+func getCurve(name string) (elliptic.Curve, error) {
+	switch name {
+	case "", "P256":
+		return elliptic.P256(), nil
+	case "P384":
+		return elliptic.P384(), nil
+	default:
+		return nil, errors.Errorf("unsupported ECDSA curve %s", name)
+	}
+}
+
+// ValidateSignKeyRequest returns an error if the key of the request can't be used to sign with Fabric, the BCCSP of
+// peers, orderers and the Fabric CA server only import ECDSA private keys
+func ValidateSignKeyRequest(keyRequest *hlfv1alpha1.KeyRequest) error {
+	if keyRequest != nil && keyRequest.Algorithm == hlfv1alpha1.KeyAlgorithmRSA {
+		return errors.New("RSA keys can only be used for TLS certificates, Fabric signs with ECDSA keys")
+	}
+	return nil
+}
+
+// KeySecurityLevel returns the security level in bits of the BCCSP that matches the key of the request
+func KeySecurityLevel(keyRequest *hlfv1alpha1.KeyRequest) int {
+	if keyRequest != nil && keyRequest.Algorithm != hlfv1alpha1.KeyAlgorithmRSA && keyRequest.Curve == "P384" {
+		return 384
+	}
+	return 256
+}
+
+// enrollUserWithKey enrolls a user with a CSR signed by a private key generated as set in the key request of params,
+// inside the PKCS#11 token of params if it's set
+func enrollUserWithKey(params EnrollUserRequest) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
+	var key crypto.Signer
+	var err error
+	if params.PKCS11 != nil {
+		key, err = GenerateHSMKey(params.PKCS11, params.KeyRequest)
+	} else {
+		key, err = GenerateKey(params.KeyRequest)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	homeDir, err := ioutil.TempDir("", "enroll")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer os.RemoveAll(homeDir)
+	client := &lib.Client{
+		HomeDir: homeDir,
+		Config: &lib.ClientConfig{
+			URL: params.URL,
+			TLS: tls.ClientTLSConfig{
+				Enabled:   strings.HasPrefix(params.URL, "https://"),
+				CertFiles: [][]byte{[]byte(params.TLSCert)},
+			},
+			CAName: params.Name,
+		},
+	}
+	var attrReqs []*caapi.AttributeRequest
+	for _, attr := range params.Attributes {
+		attrReqs = append(attrReqs, &caapi.AttributeRequest{Name: attr.Name, Optional: attr.Optional})
+	}
+	crtPEM, caInfo, err := client.EnrollWithSigner(&caapi.EnrollmentRequest{
+		Name:     params.User,
+		Secret:   params.Secret,
+		CAName:   params.Name,
+		AttrReqs: attrReqs,
+		Profile:  params.Profile,
+		Type:     "x509",
+		CSR: &caapi.CSRInfo{
+			CN:    params.CN,
+			Hosts: params.Hosts,
+		},
+	}, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	userCrt, err := utils.ParseX509Certificate(crtPEM)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	rootCrt, err := utils.ParseX509Certificate(caInfo.CAChain)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return userCrt, key, rootCrt, nil
+}
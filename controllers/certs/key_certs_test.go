@@ -0,0 +1,95 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+)
+
+func TestGenerateKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyRequest *hlfv1alpha1.KeyRequest
+		wantCurve  elliptic.Curve
+		wantRSA    int
+		wantErr    bool
+	}{
+		{
+			name:      "default",
+			wantCurve: elliptic.P256(),
+		},
+		{
+			name:       "ECDSA without curve",
+			keyRequest: &hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmECDSA},
+			wantCurve:  elliptic.P256(),
+		},
+		{
+			name:       "ECDSA P-384",
+			keyRequest: &hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmECDSA, Curve: "P384"},
+			wantCurve:  elliptic.P384(),
+		},
+		{
+			name:       "unsupported curve",
+			keyRequest: &hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmECDSA, Curve: "P521"},
+			wantErr:    true,
+		},
+		{
+			name:       "RSA without size",
+			keyRequest: &hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmRSA},
+			wantRSA:    2048,
+		},
+		{
+			name:       "RSA 3072",
+			keyRequest: &hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmRSA, Size: 3072},
+			wantRSA:    3072,
+		},
+		{
+			name:       "RSA too small",
+			keyRequest: &hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmRSA, Size: 1024},
+			wantErr:    true,
+		},
+		{
+			name:       "unsupported algorithm",
+			keyRequest: &hlfv1alpha1.KeyRequest{Algorithm: "ED25519"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			key, err := GenerateKey(tt.keyRequest)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			if tt.wantCurve != nil {
+				ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+				g.Expect(ok).To(BeTrue())
+				g.Expect(ecdsaKey.Curve).To(Equal(tt.wantCurve))
+			} else {
+				rsaKey, ok := key.(*rsa.PrivateKey)
+				g.Expect(ok).To(BeTrue())
+				g.Expect(rsaKey.N.BitLen()).To(Equal(tt.wantRSA))
+			}
+		})
+	}
+}
+
+func TestValidateSignKeyRequest(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(ValidateSignKeyRequest(nil)).To(Succeed())
+	g.Expect(ValidateSignKeyRequest(&hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmECDSA, Curve: "P384"})).To(Succeed())
+	g.Expect(ValidateSignKeyRequest(&hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmRSA, Size: 4096})).NotTo(Succeed())
+}
+
+func TestKeySecurityLevel(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(KeySecurityLevel(nil)).To(Equal(256))
+	g.Expect(KeySecurityLevel(&hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmECDSA, Curve: "P384"})).To(Equal(384))
+	g.Expect(KeySecurityLevel(&hlfv1alpha1.KeyRequest{Algorithm: hlfv1alpha1.KeyAlgorithmRSA, Size: 4096})).To(Equal(256))
+}
@@ -1,9 +1,10 @@
 package certs
 
 import (
-	"crypto/ecdsa"
+	"crypto"
 	"crypto/x509"
 	"encoding/hex"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -18,6 +19,7 @@ import (
 	fabImpl "github.com/hyperledger/fabric-sdk-go/pkg/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/msp"
 	"github.com/hyperledger/fabric-sdk-go/pkg/msp/api"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
 	"github.com/kfsoftware/hlf-operator/controllers/utils"
 	"gopkg.in/yaml.v2"
 )
@@ -152,6 +154,10 @@ type EnrollUserRequest struct {
 	CN         string
 	Profile    string
 	Attributes []*api.AttributeRequest
+	// Private key to generate for the certificate, a P-256 ECDSA key when it's not set
+	KeyRequest *hlfv1alpha1.KeyRequest
+	// PKCS#11 token where the private key is generated, the returned key can't be exported when it's set
+	PKCS11 *PKCS11Opts
 }
 type GetCAInfoRequest struct {
 	TLSCert string
@@ -226,8 +232,29 @@ func GetCAInfo(params GetCAInfoRequest) (*api.GetCAInfoResponse, error) {
 	return caInfo, nil
 }
 
+// GetCAIntermediateCerts returns the PEM encoded certificates of the chain of the CA after the root certificate, it's
+// empty when the CA is a root CA
+func GetCAIntermediateCerts(params GetCAInfoRequest) (string, error) {
+	caInfo, err := GetCAInfo(params)
+	if err != nil {
+		return "", err
+	}
+	var intermediateCerts []byte
+	rest := caInfo.CAChain
+	for idx := 0; ; idx++ {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if idx > 0 {
+			intermediateCerts = append(intermediateCerts, pem.EncodeToMemory(block)...)
+		}
+	}
+	return string(intermediateCerts), nil
+}
 
-func ReEnrollUser(params EnrollUserRequest) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func ReEnrollUser(params EnrollUserRequest) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	keystorePath, err := ioutil.TempDir("", "enroll")
 	if err != nil {
 		return nil, nil, nil, err
@@ -266,7 +293,7 @@ func ReEnrollUser(params EnrollUserRequest) (*x509.Certificate, *ecdsa.PrivateKe
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	userKey, err := utils.ParseECDSAPrivateKey(pkBytes)
+	userKey, err := utils.ParsePrivateKey(pkBytes)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -285,8 +312,10 @@ func ReEnrollUser(params EnrollUserRequest) (*x509.Certificate, *ecdsa.PrivateKe
 	return userCrt, userKey, rootCrt, nil
 }
 
-
-func EnrollUser(params EnrollUserRequest) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func EnrollUser(params EnrollUserRequest) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
+	if params.KeyRequest != nil || params.PKCS11 != nil {
+		return enrollUserWithKey(params)
+	}
 	keystorePath, err := ioutil.TempDir("", "enroll")
 	if err != nil {
 		return nil, nil, nil, err
@@ -327,7 +356,7 @@ func EnrollUser(params EnrollUserRequest) (*x509.Certificate, *ecdsa.PrivateKey,
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	userKey, err := utils.ParseECDSAPrivateKey(pkBytes)
+	userKey, err := utils.ParsePrivateKey(pkBytes)
 	if err != nil {
 		return nil, nil, nil, err
 	}
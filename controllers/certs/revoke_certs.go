@@ -0,0 +1,127 @@
+package certs
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/lib"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/lib/tls"
+	caapi "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/api"
+)
+
+type RevokeUserRequest struct {
+	TLSCert      string
+	URL          string
+	Name         string
+	MSPID        string
+	EnrollID     string
+	EnrollSecret string
+	// Enrollment ID of the identity to revoke, all its certificates are revoked
+	User string
+	// Serial number and AKI of a single certificate to revoke
+	Serial string
+	AKI    string
+	Reason string
+	// Whether to return the CRL generated after the revocation
+	GenCRL bool
+}
+
+type GenCRLRequest struct {
+	TLSCert       string
+	URL           string
+	Name          string
+	MSPID         string
+	EnrollID      string
+	EnrollSecret  string
+	RevokedAfter  time.Time
+	RevokedBefore time.Time
+	ExpireAfter   time.Time
+	ExpireBefore  time.Time
+}
+
+// enrollRegistrar enrolls the registrar of the request and returns its identity to perform privileged operations
+func enrollRegistrar(params FabricCAParams, homeDir string) (*lib.Identity, error) {
+	_, _, _, cryptoSuite, err := GetClient(params, homeDir)
+	if err != nil {
+		return nil, err
+	}
+	client := &lib.Client{
+		HomeDir: homeDir,
+		Config: &lib.ClientConfig{
+			URL: params.URL,
+			TLS: tls.ClientTLSConfig{
+				Enabled:   strings.HasPrefix(params.URL, "https://"),
+				CertFiles: [][]byte{[]byte(params.TLSCert)},
+			},
+			CAName: params.Name,
+			CSP:    cryptoSuite,
+		},
+	}
+	enrollResponse, err := client.Enroll(&caapi.EnrollmentRequest{
+		Name:   params.EnrollID,
+		Secret: params.EnrollSecret,
+		CAName: params.Name,
+		Type:   "x509",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return enrollResponse.Identity, nil
+}
+
+func RevokeUser(params RevokeUserRequest) (*caapi.RevocationResponse, error) {
+	var revocationResponse *caapi.RevocationResponse
+	err := withRegistrar(FabricCAParams{
+		TLSCert:      params.TLSCert,
+		URL:          params.URL,
+		Name:         params.Name,
+		MSPID:        params.MSPID,
+		EnrollID:     params.EnrollID,
+		EnrollSecret: params.EnrollSecret,
+	}, func(registrar *lib.Identity) error {
+		var err error
+		revocationResponse, err = registrar.Revoke(&caapi.RevocationRequest{
+			Name:   params.User,
+			Serial: params.Serial,
+			AKI:    params.AKI,
+			Reason: params.Reason,
+			CAName: params.Name,
+			GenCRL: params.GenCRL,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revocationResponse, nil
+}
+
+// GenCRL returns the PEM encoded certificate revocation list of the CA
+func GenCRL(params GenCRLRequest) ([]byte, error) {
+	var crl []byte
+	err := withRegistrar(FabricCAParams{
+		TLSCert:      params.TLSCert,
+		URL:          params.URL,
+		Name:         params.Name,
+		MSPID:        params.MSPID,
+		EnrollID:     params.EnrollID,
+		EnrollSecret: params.EnrollSecret,
+	}, func(registrar *lib.Identity) error {
+		crlResponse, err := registrar.GenCRL(&caapi.GenCRLRequest{
+			CAName:        params.Name,
+			RevokedAfter:  params.RevokedAfter,
+			RevokedBefore: params.RevokedBefore,
+			ExpireAfter:   params.ExpireAfter,
+			ExpireBefore:  params.ExpireBefore,
+		})
+		if err != nil {
+			return err
+		}
+		crl = crlResponse.CRL
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return crl, nil
+}
@@ -0,0 +1,160 @@
+package chaincode
+
+import (
+	"context"
+	"reflect"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/pkg/errors"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultTargetCPUUtilizationPercentage = 80
+
+func isAutoscalingEnabled(fabricChaincode *hlfv1alpha1.FabricChaincode) bool {
+	return fabricChaincode.Spec.Autoscaling != nil && fabricChaincode.Spec.Autoscaling.Enabled
+}
+
+func getMinReplicas(autoscaling *hlfv1alpha1.FabricChaincodeAutoscaling) int32 {
+	if autoscaling.MinReplicas == nil {
+		return 1
+	}
+	return *autoscaling.MinReplicas
+}
+
+func validateAutoscaling(fabricChaincode *hlfv1alpha1.FabricChaincode) error {
+	if !isAutoscalingEnabled(fabricChaincode) {
+		return nil
+	}
+	autoscaling := fabricChaincode.Spec.Autoscaling
+	if autoscaling.MaxReplicas < getMinReplicas(autoscaling) {
+		return errors.Errorf("maxReplicas %d of the autoscaling is lower than minReplicas %d", autoscaling.MaxReplicas, getMinReplicas(autoscaling))
+	}
+	// the utilization is relative to the requests, the HorizontalPodAutoscaler can't compute it without them
+	for _, metric := range getAutoscalingMetrics(autoscaling) {
+		resource, ok := getUtilizationResource(metric)
+		if ok && !hasResourceRequest(fabricChaincode.Spec.Resources, resource) {
+			return errors.Errorf("the autoscaling targets the %s utilization but the chaincode has no %s request in resources", resource, resource)
+		}
+	}
+	return nil
+}
+
+// getUtilizationResource returns the resource of a metric with a utilization target
+func getUtilizationResource(metric autoscalingv2.MetricSpec) (corev1.ResourceName, bool) {
+	switch {
+	case metric.Type == autoscalingv2.ResourceMetricSourceType && metric.Resource != nil:
+		return metric.Resource.Name, metric.Resource.Target.Type == autoscalingv2.UtilizationMetricType
+	case metric.Type == autoscalingv2.ContainerResourceMetricSourceType && metric.ContainerResource != nil:
+		return metric.ContainerResource.Name, metric.ContainerResource.Target.Type == autoscalingv2.UtilizationMetricType
+	}
+	return "", false
+}
+
+// hasResourceRequest returns true when the resource is requested, the request defaults to the limit when only the
+// limit is set
+func hasResourceRequest(resources *corev1.ResourceRequirements, resource corev1.ResourceName) bool {
+	if resources == nil {
+		return false
+	}
+	if _, ok := resources.Requests[resource]; ok {
+		return true
+	}
+	_, ok := resources.Limits[resource]
+	return ok
+}
+
+func getUtilizationMetric(resource corev1.ResourceName, utilization int32) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: resource,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: &utilization,
+			},
+		},
+	}
+}
+
+// getAutoscalingMetrics returns the metrics of the HorizontalPodAutoscaler, the CPU utilization is targeted when no
+// metrics are set
+func getAutoscalingMetrics(autoscaling *hlfv1alpha1.FabricChaincodeAutoscaling) []autoscalingv2.MetricSpec {
+	var metrics []autoscalingv2.MetricSpec
+	if autoscaling.TargetCPUUtilizationPercentage != nil {
+		metrics = append(metrics, getUtilizationMetric(corev1.ResourceCPU, *autoscaling.TargetCPUUtilizationPercentage))
+	}
+	if autoscaling.TargetMemoryUtilizationPercentage != nil {
+		metrics = append(metrics, getUtilizationMetric(corev1.ResourceMemory, *autoscaling.TargetMemoryUtilizationPercentage))
+	}
+	metrics = append(metrics, autoscaling.Metrics...)
+	if len(metrics) == 0 {
+		metrics = append(metrics, getUtilizationMetric(corev1.ResourceCPU, defaultTargetCPUUtilizationPercentage))
+	}
+	return metrics
+}
+
+func getHorizontalPodAutoscalerSpec(deploymentName string, autoscaling *hlfv1alpha1.FabricChaincodeAutoscaling) autoscalingv2.HorizontalPodAutoscalerSpec {
+	minReplicas := getMinReplicas(autoscaling)
+	return autoscalingv2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       deploymentName,
+		},
+		MinReplicas: &minReplicas,
+		MaxReplicas: autoscaling.MaxReplicas,
+		Metrics:     getAutoscalingMetrics(autoscaling),
+		Behavior:    autoscaling.Behavior,
+	}
+}
+
+// reconcileHorizontalPodAutoscaler creates or updates the HorizontalPodAutoscaler of the chaincode deployment when the
+// autoscaling is enabled and deletes it otherwise
+func (r *FabricChaincodeReconciler) reconcileHorizontalPodAutoscaler(ctx context.Context, clientSet kubernetes.Interface, fabricChaincode *hlfv1alpha1.FabricChaincode, ns string, labels map[string]string) error {
+	hpaClient := clientSet.AutoscalingV2().HorizontalPodAutoscalers(ns)
+	name := r.getHorizontalPodAutoscalerName(fabricChaincode)
+	hpa, err := hpaClient.Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if !isAutoscalingEnabled(fabricChaincode) {
+		if !exists {
+			return nil
+		}
+		err = hpaClient.Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete HorizontalPodAutoscaler %s", name)
+		}
+		return nil
+	}
+	spec := getHorizontalPodAutoscalerSpec(r.getDeploymentName(fabricChaincode), fabricChaincode.Spec.Autoscaling)
+	if !exists {
+		_, err = hpaClient.Create(ctx, &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels:    labels,
+			},
+			Spec: spec,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to create HorizontalPodAutoscaler %s", name)
+		}
+		return nil
+	}
+	if reflect.DeepEqual(hpa.Spec, spec) {
+		return nil
+	}
+	hpa.Spec = spec
+	_, err = hpaClient.Update(ctx, hpa, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update HorizontalPodAutoscaler %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package chaincode
+
+import (
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func autoscaledChaincode(autoscaling hlfv1alpha1.FabricChaincodeAutoscaling, resources *corev1.ResourceRequirements) *hlfv1alpha1.FabricChaincode {
+	autoscaling.Enabled = true
+	return &hlfv1alpha1.FabricChaincode{
+		Spec: hlfv1alpha1.FabricChaincodeSpec{
+			Resources:   resources,
+			Autoscaling: &autoscaling,
+		},
+	}
+}
+
+func TestValidateAutoscaling(t *testing.T) {
+	g := NewWithT(t)
+	cpuRequest := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+	}
+	memoryLimit := &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+	}
+
+	g.Expect(validateAutoscaling(&hlfv1alpha1.FabricChaincode{})).To(Succeed())
+	g.Expect(validateAutoscaling(autoscaledChaincode(hlfv1alpha1.FabricChaincodeAutoscaling{MaxReplicas: 3}, cpuRequest))).To(Succeed())
+	g.Expect(validateAutoscaling(autoscaledChaincode(hlfv1alpha1.FabricChaincodeAutoscaling{MinReplicas: int32Ptr(4), MaxReplicas: 3}, cpuRequest))).
+		To(MatchError(ContainSubstring("lower than minReplicas")))
+
+	// the default CPU target needs a CPU request
+	g.Expect(validateAutoscaling(autoscaledChaincode(hlfv1alpha1.FabricChaincodeAutoscaling{MaxReplicas: 3}, nil))).
+		To(MatchError(ContainSubstring("no cpu request")))
+	g.Expect(validateAutoscaling(autoscaledChaincode(hlfv1alpha1.FabricChaincodeAutoscaling{MaxReplicas: 3}, memoryLimit))).
+		To(MatchError(ContainSubstring("no cpu request")))
+
+	// the request defaults to the limit
+	g.Expect(validateAutoscaling(autoscaledChaincode(hlfv1alpha1.FabricChaincodeAutoscaling{
+		MaxReplicas:                       3,
+		TargetMemoryUtilizationPercentage: int32Ptr(70),
+	}, memoryLimit))).To(Succeed())
+	g.Expect(validateAutoscaling(autoscaledChaincode(hlfv1alpha1.FabricChaincodeAutoscaling{
+		MaxReplicas:                       3,
+		TargetMemoryUtilizationPercentage: int32Ptr(70),
+	}, cpuRequest))).To(MatchError(ContainSubstring("no memory request")))
+
+	// metrics that aren't relative to the requests don't need them
+	averageValue := resource.MustParse("200m")
+	g.Expect(validateAutoscaling(autoscaledChaincode(hlfv1alpha1.FabricChaincodeAutoscaling{
+		MaxReplicas: 3,
+		Metrics: []autoscalingv2.MetricSpec{
+			{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name:   corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: &averageValue},
+				},
+			},
+		},
+	}, nil))).To(Succeed())
+	g.Expect(validateAutoscaling(autoscaledChaincode(hlfv1alpha1.FabricChaincodeAutoscaling{
+		MaxReplicas: 3,
+		Metrics: []autoscalingv2.MetricSpec{
+			{
+				Type: autoscalingv2.ContainerResourceMetricSourceType,
+				ContainerResource: &autoscalingv2.ContainerResourceMetricSource{
+					Name:      corev1.ResourceMemory,
+					Container: "chaincode",
+					Target:    autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: int32Ptr(60)},
+				},
+			},
+		},
+	}, cpuRequest))).To(MatchError(ContainSubstring("no memory request")))
+}
+
+func TestGetHorizontalPodAutoscalerSpec(t *testing.T) {
+	g := NewWithT(t)
+	spec := getHorizontalPodAutoscalerSpec("mycc", &hlfv1alpha1.FabricChaincodeAutoscaling{MaxReplicas: 5})
+	g.Expect(spec.ScaleTargetRef).To(Equal(autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "mycc"}))
+	g.Expect(*spec.MinReplicas).To(Equal(int32(1)))
+	g.Expect(spec.MaxReplicas).To(Equal(int32(5)))
+	g.Expect(spec.Metrics).To(Equal([]autoscalingv2.MetricSpec{getUtilizationMetric(corev1.ResourceCPU, defaultTargetCPUUtilizationPercentage)}))
+
+	spec = getHorizontalPodAutoscalerSpec("mycc", &hlfv1alpha1.FabricChaincodeAutoscaling{
+		MinReplicas:                       int32Ptr(2),
+		MaxReplicas:                       5,
+		TargetMemoryUtilizationPercentage: int32Ptr(70),
+	})
+	g.Expect(*spec.MinReplicas).To(Equal(int32(2)))
+	g.Expect(spec.Metrics).To(Equal([]autoscalingv2.MetricSpec{getUtilizationMetric(corev1.ResourceMemory, 70)}))
+}
+
+func TestGetHorizontalPodAutoscalerName(t *testing.T) {
+	g := NewWithT(t)
+	r := &FabricChaincodeReconciler{}
+	fabricChaincode := &hlfv1alpha1.FabricChaincode{}
+	fabricChaincode.Name = "mycc"
+	g.Expect(r.getHorizontalPodAutoscalerName(fabricChaincode)).To(Equal("mycc"))
+}
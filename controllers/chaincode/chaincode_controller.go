@@ -2,7 +2,7 @@ package chaincode
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"crypto"
 	"crypto/x509"
 	"encoding/base64"
 	"fmt"
@@ -45,7 +45,7 @@ type SecretChaincodeData struct {
 	RootCert    []byte
 }
 
-func CreateChaincodeCryptoMaterial(conf *hlfv1alpha1.FabricChaincode, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, hosts []string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func CreateChaincodeCryptoMaterial(conf *hlfv1alpha1.FabricChaincode, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, hosts []string) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	tlsCert, tlsKey, tlsRootCert, err := certs.EnrollUser(certs.EnrollUserRequest{
 		TLSCert:    tlsCertString,
 		URL:        caurl,
@@ -72,6 +72,9 @@ func (r *FabricChaincodeReconciler) getServiceName(fabricChaincode *hlfv1alpha1.
 func (r *FabricChaincodeReconciler) getSecretName(fabricChaincode *hlfv1alpha1.FabricChaincode) string {
 	return fmt.Sprintf("%s-certs", fabricChaincode.Name)
 }
+func (r *FabricChaincodeReconciler) getHorizontalPodAutoscalerName(fabricChaincode *hlfv1alpha1.FabricChaincode) string {
+	return fmt.Sprintf("%s", fabricChaincode.Name)
+}
 
 func (r *FabricChaincodeReconciler) finalizeChaincode(reqLogger logr.Logger, m *hlfv1alpha1.FabricChaincode) error {
 	ns := m.Namespace
@@ -115,6 +118,21 @@ func (r *FabricChaincodeReconciler) finalizeChaincode(reqLogger logr.Logger, m *
 			return err
 		}
 	}
+	hpaName := r.getHorizontalPodAutoscalerName(m)
+	err = kubeClientset.AutoscalingV2().HorizontalPodAutoscalers(ns).Delete(ctx, hpaName, metav1.DeleteOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			reqLogger.Info(fmt.Sprintf("HorizontalPodAutoscaler %s not found", hpaName))
+		} else {
+			reqLogger.Error(err, "Failed to delete HorizontalPodAutoscaler")
+			return err
+		}
+	}
+	err = r.reconcilePodDisruptionBudgets(ctx, kubeClientset, ns)
+	if err != nil {
+		reqLogger.Error(err, "Failed to delete PodDisruptionBudget")
+		return err
+	}
 	return nil
 }
 
@@ -256,6 +274,7 @@ func (r FabricChaincodeReconciler) getCryptoMaterial(ctx context.Context, labels
 // +kubebuilder:rbac:groups=hlf.kungfusoftware.es,resources=fabricchaincodes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=hlf.kungfusoftware.es,resources=fabricchaincodes/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=hlf.kungfusoftware.es,resources=fabricchaincodes/finalizers,verbs=get;update;patch
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 func (r *FabricChaincodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	reqLogger := r.Log.WithValues("hlf", req.NamespacedName)
 	fabricChaincode := &hlfv1alpha1.FabricChaincode{}
@@ -290,6 +309,16 @@ func (r *FabricChaincodeReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			return ctrl.Result{}, err
 		}
 	}
+	err = validateCouchDBIndexes(fabricChaincode)
+	if err != nil {
+		r.setConditionStatus(ctx, fabricChaincode, hlfv1alpha1.FailedStatus, false, err, false)
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricChaincode)
+	}
+	err = validateAutoscaling(fabricChaincode)
+	if err != nil {
+		r.setConditionStatus(ctx, fabricChaincode, hlfv1alpha1.FailedStatus, false, err, false)
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricChaincode)
+	}
 	r.Log.Info(fmt.Sprintf("Chaincode %s reconciled", req.NamespacedName))
 	ns := req.Namespace
 	if ns == "" {
@@ -398,6 +427,46 @@ func (r *FabricChaincodeReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	if len(fabricChaincode.Spec.Env) > 0 {
 		envVars = append(envVars, fabricChaincode.Spec.Env...)
 	}
+	livenessProbe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.IntOrString{
+					Type:   intstr.Int,
+					IntVal: int32(chaincodePort),
+				},
+			},
+		},
+		InitialDelaySeconds: 5,
+		TimeoutSeconds:      1,
+		PeriodSeconds:       5,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
+	}
+	if fabricChaincode.Spec.LivenessProbe != nil {
+		livenessProbe = fabricChaincode.Spec.LivenessProbe
+	}
+	readinessProbe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.IntOrString{
+					Type:   intstr.Int,
+					IntVal: int32(chaincodePort),
+				},
+			},
+		},
+		InitialDelaySeconds: 5,
+		TimeoutSeconds:      1,
+		PeriodSeconds:       5,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
+	}
+	if fabricChaincode.Spec.ReadinessProbe != nil {
+		readinessProbe = fabricChaincode.Spec.ReadinessProbe
+	}
+	var resources corev1.ResourceRequirements
+	if fabricChaincode.Spec.Resources != nil {
+		resources = *fabricChaincode.Spec.Resources
+	}
 
 	podSpec := corev1.PodSpec{
 		Volumes:        volumes,
@@ -410,36 +479,10 @@ func (r *FabricChaincodeReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 				Image:           fabricChaincode.Spec.Image,
 				ImagePullPolicy: fabricChaincode.Spec.ImagePullPolicy,
 				VolumeMounts:    volumeMounts,
-				LivenessProbe: &corev1.Probe{
-					ProbeHandler: corev1.ProbeHandler{
-						TCPSocket: &corev1.TCPSocketAction{
-							Port: intstr.IntOrString{
-								Type:   intstr.Int,
-								IntVal: int32(chaincodePort),
-							},
-						},
-					},
-					InitialDelaySeconds: 5,
-					TimeoutSeconds:      1,
-					PeriodSeconds:       5,
-					SuccessThreshold:    1,
-					FailureThreshold:    3,
-				},
-				ReadinessProbe: &corev1.Probe{
-					ProbeHandler: corev1.ProbeHandler{
-						TCPSocket: &corev1.TCPSocketAction{
-							Port: intstr.IntOrString{
-								Type:   intstr.Int,
-								IntVal: int32(chaincodePort),
-							},
-						},
-					},
-					InitialDelaySeconds: 5,
-					TimeoutSeconds:      1,
-					PeriodSeconds:       5,
-					SuccessThreshold:    1,
-					FailureThreshold:    3,
-				},
+				Resources:       resources,
+				LivenessProbe:   livenessProbe,
+				ReadinessProbe:  readinessProbe,
+				StartupProbe:    fabricChaincode.Spec.StartupProbe,
 			},
 		},
 		EphemeralContainers: nil,
@@ -449,6 +492,16 @@ func (r *FabricChaincodeReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		Tolerations:         fabricChaincode.Spec.Tolerations,
 	}
 	replicas := fabricChaincode.Spec.Replicas
+	if isAutoscalingEnabled(fabricChaincode) {
+		replicas = int(getMinReplicas(fabricChaincode.Spec.Autoscaling))
+	}
+	// the release label selects the pods of the chaincode in its PodDisruptionBudget
+	podLabels := map[string]string{
+		"release": fabricChaincode.Name,
+	}
+	for key, value := range labels {
+		podLabels[key] = value
+	}
 	appv1Deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      deploymentName,
@@ -462,14 +515,14 @@ func (r *FabricChaincodeReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels: podLabels,
 				},
 
 				Spec: podSpec,
 			},
 			Strategy: appsv1.DeploymentStrategy{
 				Type:          appsv1.RollingUpdateDeploymentStrategyType,
-				RollingUpdate: nil,
+				RollingUpdate: fabricChaincode.Spec.RollingUpdate,
 			},
 		},
 		Status: appsv1.DeploymentStatus{},
@@ -492,6 +545,10 @@ func (r *FabricChaincodeReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		r.setConditionStatus(ctx, fabricChaincode, hlfv1alpha1.FailedStatus, false, err, false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricChaincode)
 	} else {
+		if isAutoscalingEnabled(fabricChaincode) {
+			// the replicas are managed by the HorizontalPodAutoscaler
+			appv1Deployment.Spec.Replicas = deployment.Spec.Replicas
+		}
 		deployment.Spec = appv1Deployment.Spec
 		if cryptoData.Updated {
 			if deployment.Spec.Template.ObjectMeta.Annotations == nil {
@@ -564,7 +621,25 @@ func (r *FabricChaincodeReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricChaincode)
 		}
 	}
+	err = r.reconcileHorizontalPodAutoscaler(ctx, kubeClientset, fabricChaincode, ns, labels)
+	if err != nil {
+		r.setConditionStatus(ctx, fabricChaincode, hlfv1alpha1.FailedStatus, false, err, false)
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricChaincode)
+	}
+	err = r.reconcilePodDisruptionBudgets(ctx, kubeClientset, ns)
+	if err != nil {
+		r.setConditionStatus(ctx, fabricChaincode, hlfv1alpha1.FailedStatus, false, err, false)
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricChaincode)
+	}
+	err = r.reconcileCouchDBIndexes(ctx, kubeClientset, fabricChaincode)
+	if err != nil {
+		r.setConditionStatus(ctx, fabricChaincode, hlfv1alpha1.FailedStatus, false, err, false)
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricChaincode)
+	}
 	r.setConditionStatus(ctx, fabricChaincode, hlfv1alpha1.RunningStatus, true, nil, false)
+	if len(fabricChaincode.Spec.CouchDBIndexes) > 0 {
+		return ctrl.Result{RequeueAfter: couchDBIndexesResyncPeriod}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -0,0 +1,235 @@
+package chaincode
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/couchdb"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// couchDBIndexesResyncPeriod is how often the indexes are checked, so they're created in the peers that join the
+// channel later and their build status is refreshed
+const couchDBIndexesResyncPeriod = 1 * time.Minute
+
+// validateCouchDBIndexes checks the indexes of the chaincode with the rules the peer applies to the indexes of a
+// chaincode package
+func validateCouchDBIndexes(fabricChaincode *hlfv1alpha1.FabricChaincode) error {
+	for _, channelIndexes := range fabricChaincode.Spec.CouchDBIndexes {
+		for _, index := range channelIndexes.Indexes {
+			err := couchdb.ValidateIndex(index.Name, index.Collection, index.Definition.Raw)
+			if err != nil {
+				return errors.Wrapf(err, "invalid CouchDB index %s of channel %s", index.Name, channelIndexes.Channel)
+			}
+		}
+	}
+	return nil
+}
+
+// couchDBTarget is a CouchDB where the indexes of a peer are created
+type couchDBTarget struct {
+	pod    string
+	client *couchdb.Client
+	// set when the CouchDB can't be reached, every index of the peer gets this state
+	state   hlfv1alpha1.CouchDBIndexState
+	message string
+}
+
+// reconcileCouchDBIndexes creates or updates the indexes of the chaincode in the CouchDB of every peer joined to their
+// channels and stores their state in the status, the status is cleared when the chaincode has no indexes
+func (r *FabricChaincodeReconciler) reconcileCouchDBIndexes(ctx context.Context, clientSet kubernetes.Interface, fabricChaincode *hlfv1alpha1.FabricChaincode) error {
+	peerList := &hlfv1alpha1.FabricPeerList{}
+	if len(fabricChaincode.Spec.CouchDBIndexes) > 0 {
+		err := r.List(ctx, peerList)
+		if err != nil {
+			return errors.Wrap(err, "failed to list the peers")
+		}
+	}
+	var indexStatuses []hlfv1alpha1.FabricChaincodeCouchDBIndexStatus
+	for _, selected := range selectCouchDBIndexesPeers(peerList.Items, fabricChaincode.Spec.CouchDBIndexes) {
+		peer := selected.peer
+		var targets []couchDBTarget
+		if selected.skipReason != "" {
+			targets = []couchDBTarget{{state: hlfv1alpha1.CouchDBIndexSkipped, message: selected.skipReason}}
+		} else {
+			var err error
+			targets, err = getCouchDBTargets(ctx, clientSet, peer)
+			if err != nil {
+				return err
+			}
+		}
+		for _, target := range targets {
+			for _, indexes := range selected.indexes {
+				for _, index := range indexes.Indexes {
+					indexStatus := hlfv1alpha1.FabricChaincodeCouchDBIndexStatus{
+						Peer:       fmt.Sprintf("%s/%s", peer.Namespace, peer.Name),
+						Pod:        target.pod,
+						Channel:    indexes.Channel,
+						Index:      index.Name,
+						Collection: index.Collection,
+						Database:   couchdb.DatabaseName(indexes.Channel, indexes.ChaincodeName, index.Collection),
+						State:      target.state,
+						Message:    target.message,
+					}
+					if target.client != nil {
+						indexStatus.State, indexStatus.Message = ensureCouchDBIndex(ctx, target.client, indexStatus.Database, index)
+					}
+					indexStatuses = append(indexStatuses, indexStatus)
+				}
+			}
+			if target.client != nil {
+				target.client.Close()
+			}
+		}
+	}
+	sort.Slice(indexStatuses, func(i, j int) bool {
+		a, b := indexStatuses[i], indexStatuses[j]
+		if a.Peer != b.Peer {
+			return a.Peer < b.Peer
+		}
+		if a.Pod != b.Pod {
+			return a.Pod < b.Pod
+		}
+		if a.Channel != b.Channel {
+			return a.Channel < b.Channel
+		}
+		return a.Index < b.Index
+	})
+	fChaincode := fabricChaincode.DeepCopy()
+	fChaincode.Status.CouchDBIndexes = indexStatuses
+	if !reflect.DeepEqual(fChaincode.Status, fabricChaincode.Status) {
+		err := r.Status().Update(ctx, fChaincode)
+		if err != nil {
+			return err
+		}
+		fabricChaincode.Status = fChaincode.Status
+		fabricChaincode.ResourceVersion = fChaincode.ResourceVersion
+	}
+	return nil
+}
+
+// couchDBIndexesPeer is a peer joined to some of the channels of the indexes of a chaincode
+type couchDBIndexesPeer struct {
+	peer *hlfv1alpha1.FabricPeer
+	// indexes of the channels joined by the peer
+	indexes []hlfv1alpha1.FabricChaincodeCouchDBIndexes
+	// set when the indexes can't be created in the peer, they are reported as skipped
+	skipReason string
+}
+
+// selectCouchDBIndexesPeers returns the peers joined to the channels of the indexes, the peers that don't use CouchDB
+// are returned with the reason they are skipped
+func selectCouchDBIndexesPeers(peers []hlfv1alpha1.FabricPeer, couchDBIndexes []hlfv1alpha1.FabricChaincodeCouchDBIndexes) []couchDBIndexesPeer {
+	var selected []couchDBIndexesPeer
+	for i := range peers {
+		peer := &peers[i]
+		if peer.DeletionTimestamp != nil {
+			continue
+		}
+		var channelIndexes []hlfv1alpha1.FabricChaincodeCouchDBIndexes
+		for _, indexes := range couchDBIndexes {
+			if isPeerJoined(peer, indexes.Channel) {
+				channelIndexes = append(channelIndexes, indexes)
+			}
+		}
+		if len(channelIndexes) == 0 {
+			continue
+		}
+		var skipReason string
+		if peer.Spec.StateDb != hlfv1alpha1.StateDBCouchDB {
+			stateDB := peer.Spec.StateDb
+			if stateDB == "" {
+				stateDB = hlfv1alpha1.StateDBLevelDB
+			}
+			skipReason = fmt.Sprintf("the peer uses %s as state database", stateDB)
+		}
+		selected = append(selected, couchDBIndexesPeer{
+			peer:       peer,
+			indexes:    channelIndexes,
+			skipReason: skipReason,
+		})
+	}
+	return selected
+}
+
+func isPeerJoined(peer *hlfv1alpha1.FabricPeer, channel string) bool {
+	for _, peerChannel := range peer.Status.Channels {
+		if peerChannel.Name == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// getCouchDBTargets returns the external CouchDB of the peer, or the CouchDB container of each of its pods
+func getCouchDBTargets(ctx context.Context, clientSet kubernetes.Interface, peer *hlfv1alpha1.FabricPeer) ([]couchDBTarget, error) {
+	if couchdb.IsExternal(peer.Spec) {
+		couchClient, err := couchdb.NewExternalClient(ctx, clientSet, peer.Namespace, peer.Spec)
+		if err != nil {
+			return []couchDBTarget{{state: hlfv1alpha1.CouchDBIndexFailed, message: err.Error()}}, nil
+		}
+		return []couchDBTarget{{client: couchClient}}, nil
+	}
+	pods, err := clientSet.CoreV1().Pods(peer.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("release=%s", peer.Name),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the pods of peer %s", peer.Name)
+	}
+	var targets []couchDBTarget
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		targets = append(targets, couchDBTarget{
+			pod:    pod.Name,
+			client: couchdb.NewPodClient(peer, pod.Status.PodIP),
+		})
+	}
+	if len(targets) == 0 {
+		targets = append(targets, couchDBTarget{
+			state:   hlfv1alpha1.CouchDBIndexPending,
+			message: "the peer has no running pods",
+		})
+	}
+	return targets, nil
+}
+
+// ensureCouchDBIndex creates or updates the index in the database and starts its build, the database is created by
+// the peer when the chaincode writes to it for the first time
+func ensureCouchDBIndex(ctx context.Context, couchClient *couchdb.Client, db string, index hlfv1alpha1.FabricChaincodeCouchDBIndex) (hlfv1alpha1.CouchDBIndexState, string) {
+	exists, err := couchClient.DatabaseExists(ctx, db)
+	if err != nil {
+		return hlfv1alpha1.CouchDBIndexFailed, err.Error()
+	}
+	if !exists {
+		return hlfv1alpha1.CouchDBIndexPending, fmt.Sprintf("database %s doesn't exist yet", db)
+	}
+	result, err := couchClient.CreateIndex(ctx, db, index.Definition.Raw)
+	if err != nil {
+		return hlfv1alpha1.CouchDBIndexFailed, err.Error()
+	}
+	if result.Result == "created" {
+		log.Infof("CouchDB index %s created in database %s of %s", index.Name, db, couchClient.URL())
+	}
+	err = couchClient.WarmIndex(ctx, db, result.ID, result.Name)
+	if err != nil {
+		return hlfv1alpha1.CouchDBIndexFailed, err.Error()
+	}
+	info, err := couchClient.GetIndexInfo(ctx, db, result.ID)
+	if err != nil {
+		return hlfv1alpha1.CouchDBIndexFailed, err.Error()
+	}
+	if info.UpdaterRunning || info.UpdatesPending.Total > 0 {
+		return hlfv1alpha1.CouchDBIndexBuilding, ""
+	}
+	return hlfv1alpha1.CouchDBIndexReady, ""
+}
@@ -0,0 +1,106 @@
+package chaincode
+
+import (
+	"context"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestPeer(name string, stateDB hlfv1alpha1.StateDB, channels ...string) hlfv1alpha1.FabricPeer {
+	peer := hlfv1alpha1.FabricPeer{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       hlfv1alpha1.FabricPeerSpec{StateDb: stateDB},
+	}
+	for _, channel := range channels {
+		peer.Status.Channels = append(peer.Status.Channels, hlfv1alpha1.FabricPeerChannelStatus{Name: channel})
+	}
+	return peer
+}
+
+func newTestIndexes(channel string) hlfv1alpha1.FabricChaincodeCouchDBIndexes {
+	return hlfv1alpha1.FabricChaincodeCouchDBIndexes{
+		Channel:       channel,
+		ChaincodeName: "asset",
+		Indexes: []hlfv1alpha1.FabricChaincodeCouchDBIndex{{
+			Name:       "indexOwner",
+			Definition: apiextensionsv1.JSON{Raw: []byte(`{"index": {"fields": ["owner"]}, "name": "indexOwner", "type": "json"}`)},
+		}},
+	}
+}
+
+func TestSelectCouchDBIndexesPeers(t *testing.T) {
+	g := NewWithT(t)
+	deleted := newTestPeer("deleted", hlfv1alpha1.StateDBCouchDB, "demo")
+	deleted.DeletionTimestamp = &v1.Time{}
+	peers := []hlfv1alpha1.FabricPeer{
+		newTestPeer("couchdb", hlfv1alpha1.StateDBCouchDB, "demo", "other"),
+		newTestPeer("leveldb", hlfv1alpha1.StateDBLevelDB, "demo"),
+		newTestPeer("default", "", "other"),
+		newTestPeer("not-joined", hlfv1alpha1.StateDBCouchDB, "unrelated"),
+		deleted,
+	}
+	demo := newTestIndexes("demo")
+	other := newTestIndexes("other")
+
+	selected := selectCouchDBIndexesPeers(peers, []hlfv1alpha1.FabricChaincodeCouchDBIndexes{demo, other})
+	g.Expect(selected).To(HaveLen(3))
+	g.Expect(selected[0].peer.Name).To(Equal("couchdb"))
+	g.Expect(selected[0].indexes).To(Equal([]hlfv1alpha1.FabricChaincodeCouchDBIndexes{demo, other}))
+	g.Expect(selected[0].skipReason).To(BeEmpty())
+	g.Expect(selected[1].peer.Name).To(Equal("leveldb"))
+	g.Expect(selected[1].indexes).To(Equal([]hlfv1alpha1.FabricChaincodeCouchDBIndexes{demo}))
+	g.Expect(selected[1].skipReason).To(Equal("the peer uses leveldb as state database"))
+	g.Expect(selected[2].peer.Name).To(Equal("default"))
+	g.Expect(selected[2].skipReason).To(Equal("the peer uses leveldb as state database"))
+
+	g.Expect(selectCouchDBIndexesPeers(peers, nil)).To(BeEmpty())
+}
+
+func TestValidateCouchDBIndexes(t *testing.T) {
+	g := NewWithT(t)
+	fabricChaincode := &hlfv1alpha1.FabricChaincode{
+		Spec: hlfv1alpha1.FabricChaincodeSpec{
+			CouchDBIndexes: []hlfv1alpha1.FabricChaincodeCouchDBIndexes{newTestIndexes("demo")},
+		},
+	}
+	g.Expect(validateCouchDBIndexes(fabricChaincode)).To(Succeed())
+
+	fabricChaincode.Spec.CouchDBIndexes[0].Indexes[0].Definition.Raw = []byte(`{"index": {"fields": ["owner"]}, "type": "text"}`)
+	g.Expect(validateCouchDBIndexes(fabricChaincode)).To(MatchError(ContainSubstring("invalid CouchDB index indexOwner of channel demo")))
+}
+
+func TestGetCouchDBTargets(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	pod := func(name string, phase corev1.PodPhase, ip string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"release": "org1-peer0"}},
+			Status:     corev1.PodStatus{Phase: phase, PodIP: ip},
+		}
+	}
+	peer := newTestPeer("org1-peer0", hlfv1alpha1.StateDBCouchDB, "demo")
+
+	targets, err := getCouchDBTargets(ctx, fake.NewSimpleClientset(pod("org1-peer0-pending", corev1.PodPending, "")), &peer)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(targets).To(HaveLen(1))
+	g.Expect(targets[0].client).To(BeNil())
+	g.Expect(targets[0].state).To(Equal(hlfv1alpha1.CouchDBIndexPending))
+
+	clientSet := fake.NewSimpleClientset(
+		pod("org1-peer0-a", corev1.PodRunning, "10.0.0.1"),
+		pod("org1-peer0-b", corev1.PodRunning, "10.0.0.2"),
+		pod("org1-peer0-pending", corev1.PodPending, ""),
+	)
+	targets, err = getCouchDBTargets(ctx, clientSet, &peer)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(targets).To(HaveLen(2))
+	g.Expect(targets[0].pod).To(Equal("org1-peer0-a"))
+	g.Expect(targets[0].client.URL()).To(Equal("http://10.0.0.1:5984"))
+	g.Expect(targets[1].client.URL()).To(Equal("http://10.0.0.2:5984"))
+}
@@ -0,0 +1,39 @@
+package chaincode
+
+import (
+	"context"
+	"fmt"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const chaincodePodDisruptionBudgetKind = "chaincodes"
+
+// reconcilePodDisruptionBudgets creates a PodDisruptionBudget for the replicas of each chaincode of the namespace that
+// has it enabled
+func (r *FabricChaincodeReconciler) reconcilePodDisruptionBudgets(ctx context.Context, clientSet kubernetes.Interface, ns string) error {
+	chaincodeList := &hlfv1alpha1.FabricChaincodeList{}
+	err := r.List(ctx, chaincodeList, client.InNamespace(ns))
+	if err != nil {
+		return err
+	}
+	var groups []utils.PodDisruptionBudgetGroup
+	for i := range chaincodeList.Items {
+		chaincode := &chaincodeList.Items[i]
+		pdb := chaincode.Spec.PodDisruptionBudget
+		if chaincode.DeletionTimestamp != nil || pdb == nil || !pdb.Enabled {
+			continue
+		}
+		groups = append(groups, utils.PodDisruptionBudgetGroup{
+			Name:           fmt.Sprintf("%s-pdb", chaincode.Name),
+			Releases:       []string{chaincode.Name},
+			MaxUnavailable: utils.GetMaxUnavailable(pdb.MaxUnavailable),
+			Owners:         []v1.OwnerReference{utils.NewOwnerReference(chaincode, hlfv1alpha1.GroupVersion.WithKind("FabricChaincode"))},
+		})
+	}
+	return utils.ReconcilePodDisruptionBudgets(ctx, clientSet, ns, chaincodePodDisruptionBudgetKind, groups)
+}
@@ -0,0 +1,445 @@
+package channelupdate
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/go-logr/logr"
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mspproto "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/protoutil"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/mainchannel"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/kfsoftware/hlf-operator/pkg/nc"
+	"github.com/kfsoftware/hlf-operator/pkg/status"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"time"
+)
+
+// pendingRequeueInterval is the interval between the reviews of a proposal waiting for signatures
+const pendingRequeueInterval = time.Minute
+
+// FabricChannelUpdateProposalReconciler reconciles a FabricChannelUpdateProposal object
+type FabricChannelUpdateProposalReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	Config *rest.Config
+}
+
+// +kubebuilder:rbac:groups=hlf.kungfusoftware.es,resources=fabricchannelupdateproposals,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=hlf.kungfusoftware.es,resources=fabricchannelupdateproposals/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=hlf.kungfusoftware.es,resources=fabricchannelupdateproposals/finalizers,verbs=get;update;patch
+func (r *FabricChannelUpdateProposalReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("hlf", req.NamespacedName)
+	proposal := &hlfv1alpha1.FabricChannelUpdateProposal{}
+
+	err := r.Get(ctx, req.NamespacedName, proposal)
+	if err != nil {
+		log.Debugf("Error getting the object %s error=%v", req.NamespacedName, err)
+		if apierrors.IsNotFound(err) {
+			reqLogger.Info("ChannelUpdateProposal resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		reqLogger.Error(err, "Failed to get ChannelUpdateProposal.")
+		return ctrl.Result{}, err
+	}
+	if proposal.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+	if proposal.Status.Phase == hlfv1alpha1.ProposalSubmittedPhase ||
+		proposal.Status.Phase == hlfv1alpha1.ProposalRejectedPhase {
+		reqLogger.Info(fmt.Sprintf("Proposal already in phase %s, nothing to do", proposal.Status.Phase))
+		return ctrl.Result{}, nil
+	}
+	clientSet, err := utils.GetClientKubeWithConf(r.Config)
+	if err != nil {
+		return r.retry(ctx, proposal, err)
+	}
+	hlfClientSet, err := operatorv1.NewForConfig(r.Config)
+	if err != nil {
+		return r.retry(ctx, proposal, err)
+	}
+	idConfig, ok := proposal.Spec.Identities[proposal.Spec.SubmitterMSPID]
+	if !ok {
+		r.reject(proposal, fmt.Sprintf("identity not found for MSPID %s", proposal.Spec.SubmitterMSPID))
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, proposal)
+	}
+	importedSignatures, err := decodeSignatures(proposal)
+	if err != nil {
+		r.reject(proposal, err.Error())
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, proposal)
+	}
+	clientTLS, err := nc.GetClientTLS(ctx, clientSet, idConfig.ClientTLS)
+	if err != nil {
+		return r.retry(ctx, proposal, err)
+	}
+	var fabricMainChannel *hlfv1alpha1.FabricMainChannel
+	var ncResponse *nc.NetworkConfigResponse
+	var ordererEndpoints []string
+	if proposal.Spec.MainChannel != "" {
+		fabricMainChannel, err = hlfClientSet.HlfV1alpha1().FabricMainChannels().Get(ctx, proposal.Spec.MainChannel, v1.GetOptions{})
+		if err != nil {
+			return r.retry(ctx, proposal, err)
+		}
+		ncResponse, err = nc.GenerateNetworkConfig(fabricMainChannel, clientSet, hlfClientSet, proposal.Spec.SubmitterMSPID, clientTLS)
+		if err != nil {
+			return r.retry(ctx, proposal, errors.Wrapf(err, "failed to generate network config"))
+		}
+		for _, ordOrg := range fabricMainChannel.Spec.OrdererOrganizations {
+			ordererEndpoints = append(ordererEndpoints, ordOrg.OrdererEndpoints...)
+		}
+		for _, ordOrg := range fabricMainChannel.Spec.ExternalOrdererOrganizations {
+			ordererEndpoints = append(ordererEndpoints, ordOrg.OrdererEndpoints...)
+		}
+	} else {
+		ncResponse, err = nc.GenerateNetworkConfigForOrderers(proposal.Spec.Orderers, proposal.Spec.SubmitterMSPID, clientTLS)
+		if err != nil {
+			return r.retry(ctx, proposal, errors.Wrapf(err, "failed to generate network config"))
+		}
+		for _, orderer := range proposal.Spec.Orderers {
+			ordererEndpoints = append(ordererEndpoints, orderer.URL)
+		}
+	}
+	sdk, err := fabsdk.New(config.FromRaw([]byte(ncResponse.NetworkConfig), "yaml"))
+	if err != nil {
+		return r.retry(ctx, proposal, err)
+	}
+	defer sdk.Close()
+	submitterIdentity, err := utils.GetSigningIdentity(ctx, sdk, clientSet, proposal.Spec.SubmitterMSPID, utils.MainChannelIdentity(idConfig))
+	if err != nil {
+		return r.retry(ctx, proposal, err)
+	}
+	resClient, err := resmgmt.New(sdk.Context(
+		fabsdk.WithIdentity(submitterIdentity),
+		fabsdk.WithOrg(proposal.Spec.SubmitterMSPID),
+	))
+	if err != nil {
+		return r.retry(ctx, proposal, err)
+	}
+	resmgmtOptions := []resmgmt.RequestOption{}
+	for _, endpoint := range ordererEndpoints {
+		resmgmtOptions = append(resmgmtOptions, resmgmt.WithOrdererEndpoint(endpoint))
+	}
+	ordererChannelBlock, err := resClient.QueryConfigBlockFromOrderer(proposal.Spec.ChannelName, resmgmtOptions...)
+	if err != nil {
+		return r.retry(ctx, proposal, errors.Wrapf(err, "failed to get block from channel %s", proposal.Spec.ChannelName))
+	}
+	currentConfig, err := resource.ExtractConfigFromBlock(ordererChannelBlock)
+	if err != nil {
+		return r.retry(ctx, proposal, errors.Wrapf(err, "failed to extract config from channel block"))
+	}
+	if proposal.Status.ConfigUpdate == "" {
+		var configUpdateBytes []byte
+		if proposal.Spec.ConfigUpdate != "" {
+			configUpdateBytes, err = base64.StdEncoding.DecodeString(proposal.Spec.ConfigUpdate)
+			if err != nil {
+				r.reject(proposal, errors.Wrapf(err, "failed to decode config update").Error())
+				return r.updateCRStatusOrFailReconcile(ctx, r.Log, proposal)
+			}
+		} else if fabricMainChannel != nil {
+			configUpdateBytes, err = mainchannel.ComputeConfigUpdateEnvelope(r.Config, fabricMainChannel, currentConfig)
+			if err != nil {
+				return r.retry(ctx, proposal, errors.Wrapf(err, "error calculating config update"))
+			}
+			if configUpdateBytes == nil {
+				r.reject(proposal, "no differences detected between the channel and the main channel configuration")
+				return r.updateCRStatusOrFailReconcile(ctx, r.Log, proposal)
+			}
+		} else {
+			r.reject(proposal, "either configUpdate or mainChannel must be set")
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, proposal)
+		}
+		proposal.Status.ConfigUpdate = base64.StdEncoding.EncodeToString(configUpdateBytes)
+	}
+	configUpdateBytes, err := base64.StdEncoding.DecodeString(proposal.Status.ConfigUpdate)
+	if err != nil {
+		r.reject(proposal, errors.Wrapf(err, "failed to decode config update").Error())
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, proposal)
+	}
+	signatures, signedBy, err := r.collectSignatures(ctx, sdk, clientSet, proposal, configUpdateBytes)
+	if err != nil {
+		return r.retry(ctx, proposal, err)
+	}
+	signatures, signedBy = addImportedSignatures(signatures, signedBy, importedSignatures)
+	proposal.Status.SignedBy = signedBy
+	signedEnvelope, err := addSignaturesToEnvelope(configUpdateBytes, signatures)
+	if err != nil {
+		r.reject(proposal, err.Error())
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, proposal)
+	}
+	bundle, err := channelconfig.NewBundle(proposal.Spec.ChannelName, currentConfig, factory.GetDefault())
+	if err != nil {
+		return r.retry(ctx, proposal, errors.Wrapf(err, "failed to load channel configuration"))
+	}
+	if !r.reviewSignatures(proposal, currentConfig, bundle.PolicyManager(), signedEnvelope) {
+		result, err := r.updateCRStatusOrFailReconcile(ctx, r.Log, proposal)
+		if err != nil || proposal.Status.Phase != hlfv1alpha1.ProposalPendingPhase {
+			return result, err
+		}
+		// the signatures of the identities in the cluster are collected again, their secrets may have been added
+		reqLogger.Info(fmt.Sprintf("Proposal %s waiting for more signatures", proposal.Name))
+		return ctrl.Result{
+			RequeueAfter: pendingRequeueInterval,
+		}, nil
+	}
+	saveChannelOpts := []resmgmt.RequestOption{
+		resmgmt.WithConfigSignatures(signatures...),
+	}
+	saveChannelOpts = append(saveChannelOpts, resmgmtOptions...)
+	saveChannelResponse, err := resClient.SaveChannel(
+		resmgmt.SaveChannelRequest{
+			ChannelID:         proposal.Spec.ChannelName,
+			ChannelConfig:     bytes.NewReader(configUpdateBytes),
+			SigningIdentities: []msp.SigningIdentity{},
+		},
+		saveChannelOpts...,
+	)
+	if err != nil {
+		return r.retry(ctx, proposal, errors.Wrapf(err, "error submitting config update"))
+	}
+	log.Infof("Config update for channel %s submitted with transaction ID: %s", proposal.Spec.ChannelName, saveChannelResponse.TransactionID)
+	proposal.Status.Phase = hlfv1alpha1.ProposalSubmittedPhase
+	proposal.Status.Status = hlfv1alpha1.RunningStatus
+	proposal.Status.TransactionID = string(saveChannelResponse.TransactionID)
+	proposal.Status.Message = "Config update submitted"
+	proposal.Status.Conditions.SetCondition(status.Condition{
+		Type:   "SUBMITTED",
+		Status: "True",
+	})
+	return r.updateCRStatusOrFailReconcile(ctx, r.Log, proposal)
+}
+
+// reviewSignatures validates the signed config update against the current config of the channel, it returns true and
+// moves the proposal to the signed phase when the signatures satisfy the mod policies. The proposal stays pending while
+// they don't and it's rejected when the config update can't be applied to the channel
+func (r *FabricChannelUpdateProposalReconciler) reviewSignatures(proposal *hlfv1alpha1.FabricChannelUpdateProposal, currentConfig *cb.Config, policyManager policies.Manager, signedEnvelope *cb.Envelope) bool {
+	err := validateConfigUpdate(proposal.Spec.ChannelName, currentConfig, policyManager, signedEnvelope)
+	if err == nil {
+		proposal.Status.Phase = hlfv1alpha1.ProposalSignedPhase
+		proposal.Status.Status = hlfv1alpha1.PendingStatus
+		proposal.Status.Message = "Signatures satisfy the mod policies, submitting the config update"
+		return true
+	}
+	var policyErr *modPolicyError
+	if errors.As(err, &policyErr) {
+		proposal.Status.Phase = hlfv1alpha1.ProposalPendingPhase
+		proposal.Status.Status = hlfv1alpha1.PendingStatus
+		proposal.Status.Message = fmt.Sprintf("Waiting for more signatures: %v", err)
+		return false
+	}
+	r.reject(proposal, err.Error())
+	return false
+}
+
+// retry reports a failure that can be solved without changing the proposal, like an orderer that can't be reached or
+// a secret that doesn't exist yet, the error is returned so the proposal is reconciled again with backoff
+func (r *FabricChannelUpdateProposalReconciler) retry(ctx context.Context, proposal *hlfv1alpha1.FabricChannelUpdateProposal, err error) (ctrl.Result, error) {
+	r.setConditionStatus(ctx, proposal, hlfv1alpha1.FailedStatus, false, err, false)
+	_, updateErr := r.updateCRStatusOrFailReconcile(ctx, r.Log, proposal)
+	if updateErr != nil {
+		return ctrl.Result{}, updateErr
+	}
+	return ctrl.Result{}, err
+}
+
+func (r *FabricChannelUpdateProposalReconciler) reject(proposal *hlfv1alpha1.FabricChannelUpdateProposal, message string) {
+	proposal.Status.Phase = hlfv1alpha1.ProposalRejectedPhase
+	proposal.Status.Status = hlfv1alpha1.FailedStatus
+	proposal.Status.Message = message
+	proposal.Status.Conditions.SetCondition(status.Condition{
+		Type:    "REJECTED",
+		Status:  "True",
+		Message: message,
+	})
+}
+
+// collectSignatures signs the config update with the identities available in the cluster, returning the MSP IDs that
+// signed it
+func (r *FabricChannelUpdateProposalReconciler) collectSignatures(
+	ctx context.Context,
+	sdk *fabsdk.FabricSDK,
+	clientSet *kubernetes.Clientset,
+	proposal *hlfv1alpha1.FabricChannelUpdateProposal,
+	configUpdateBytes []byte,
+) ([]*cb.ConfigSignature, []string, error) {
+	var configSignatures []*cb.ConfigSignature
+	signedBy := []string{}
+	mspIDs := []string{}
+	for mspID := range proposal.Spec.Identities {
+		mspIDs = append(mspIDs, mspID)
+	}
+	sort.Strings(mspIDs)
+	for _, mspID := range mspIDs {
+		signingIdentity, err := utils.GetSigningIdentity(ctx, sdk, clientSet, mspID, utils.MainChannelIdentity(proposal.Spec.Identities[mspID]))
+		if err != nil {
+			return nil, nil, err
+		}
+		resClient, err := resmgmt.New(sdk.Context(
+			fabsdk.WithIdentity(signingIdentity),
+			fabsdk.WithOrg(mspID),
+		))
+		if err != nil {
+			return nil, nil, err
+		}
+		signature, err := resClient.CreateConfigSignatureFromReader(signingIdentity, bytes.NewReader(configUpdateBytes))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to sign config update with identity of %s", mspID)
+		}
+		configSignatures = append(configSignatures, signature)
+		signedBy = append(signedBy, mspID)
+	}
+	return configSignatures, signedBy, nil
+}
+
+// importedSignature is a signature of the config update from an organization without identities in the cluster
+type importedSignature struct {
+	mspID     string
+	signature *cb.ConfigSignature
+}
+
+// decodeSignatures decodes the signatures imported in the spec of the proposal
+func decodeSignatures(proposal *hlfv1alpha1.FabricChannelUpdateProposal) ([]importedSignature, error) {
+	var signatures []importedSignature
+	for idx, signature := range proposal.Spec.Signatures {
+		signatureBytes, err := base64.StdEncoding.DecodeString(signature.Signature)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode signature %d", idx)
+		}
+		configSignature := &cb.ConfigSignature{}
+		err = proto.Unmarshal(signatureBytes, configSignature)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed unmarshalling signature %d", idx)
+		}
+		mspID, err := getSignatureMSPID(configSignature)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid signature %d", idx)
+		}
+		signatures = append(signatures, importedSignature{mspID: mspID, signature: configSignature})
+	}
+	return signatures, nil
+}
+
+func addImportedSignatures(configSignatures []*cb.ConfigSignature, signedBy []string, importedSignatures []importedSignature) ([]*cb.ConfigSignature, []string) {
+	for _, imported := range importedSignatures {
+		configSignatures = append(configSignatures, imported.signature)
+		if !utils.Contains(signedBy, imported.mspID) {
+			signedBy = append(signedBy, imported.mspID)
+		}
+	}
+	return configSignatures, signedBy
+}
+
+func getSignatureMSPID(configSignature *cb.ConfigSignature) (string, error) {
+	signatureHeader, err := protoutil.UnmarshalSignatureHeader(configSignature.SignatureHeader)
+	if err != nil {
+		return "", err
+	}
+	serializedIdentity := &mspproto.SerializedIdentity{}
+	err = proto.Unmarshal(signatureHeader.Creator, serializedIdentity)
+	if err != nil {
+		return "", err
+	}
+	return serializedIdentity.Mspid, nil
+}
+
+func addSignaturesToEnvelope(envelopeBytes []byte, signatures []*cb.ConfigSignature) (*cb.Envelope, error) {
+	envelope := &cb.Envelope{}
+	err := proto.Unmarshal(envelopeBytes, envelope)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal config update envelope")
+	}
+	payload, err := protoutil.UnmarshalPayload(envelope.Payload)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal envelope payload")
+	}
+	configUpdateEnvelope := &cb.ConfigUpdateEnvelope{}
+	err = proto.Unmarshal(payload.Data, configUpdateEnvelope)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal config update")
+	}
+	configUpdateEnvelope.Signatures = append(configUpdateEnvelope.Signatures, signatures...)
+	payload.Data, err = proto.Marshal(configUpdateEnvelope)
+	if err != nil {
+		return nil, err
+	}
+	envelope.Payload, err = proto.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return envelope, nil
+}
+
+var (
+	ErrClientK8s = errors.New("k8sAPIClientError")
+)
+
+func (r *FabricChannelUpdateProposalReconciler) updateCRStatusOrFailReconcile(ctx context.Context, log logr.Logger, p *hlfv1alpha1.FabricChannelUpdateProposal) (
+	reconcile.Result, error) {
+	if err := r.Status().Update(ctx, p); err != nil {
+		log.Error(err, fmt.Sprintf("%v failed to update the application status", ErrClientK8s))
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+func (r *FabricChannelUpdateProposalReconciler) setConditionStatus(ctx context.Context, p *hlfv1alpha1.FabricChannelUpdateProposal, conditionType hlfv1alpha1.DeploymentStatus, statusFlag bool, err error, statusUnknown bool) (update bool) {
+	statusStr := func() corev1.ConditionStatus {
+		if statusUnknown {
+			return corev1.ConditionUnknown
+		}
+		if statusFlag {
+			return corev1.ConditionTrue
+		} else {
+			return corev1.ConditionFalse
+		}
+	}
+	p.Status.Status = conditionType
+	if err != nil {
+		p.Status.Message = err.Error()
+	}
+	condition := func() status.Condition {
+		if err != nil {
+			return status.Condition{
+				Type:    status.ConditionType(conditionType),
+				Status:  statusStr(),
+				Reason:  status.ConditionReason(err.Error()),
+				Message: err.Error(),
+			}
+		}
+		return status.Condition{
+			Type:   status.ConditionType(conditionType),
+			Status: statusStr(),
+		}
+	}
+	return p.Status.Conditions.SetCondition(condition())
+}
+
+func (r *FabricChannelUpdateProposalReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&hlfv1alpha1.FabricChannelUpdateProposal{}).
+		Complete(r)
+}
@@ -0,0 +1,140 @@
+package channelupdate
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mspproto "github.com/hyperledger/fabric-protos-go/msp"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestSignature(g *WithT, mspID string) string {
+	creator, err := proto.Marshal(&mspproto.SerializedIdentity{Mspid: mspID, IdBytes: []byte("cert")})
+	g.Expect(err).NotTo(HaveOccurred())
+	signatureHeader, err := proto.Marshal(&cb.SignatureHeader{Creator: creator, Nonce: []byte("nonce")})
+	g.Expect(err).NotTo(HaveOccurred())
+	signature, err := proto.Marshal(&cb.ConfigSignature{SignatureHeader: signatureHeader, Signature: []byte("signature")})
+	g.Expect(err).NotTo(HaveOccurred())
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func TestReviewSignatures(t *testing.T) {
+	g := NewWithT(t)
+	r := &FabricChannelUpdateProposalReconciler{}
+	envelope := newTestConfigUpdate(g, "mychannel")
+	proposal := &hlfv1alpha1.FabricChannelUpdateProposal{
+		Spec: hlfv1alpha1.FabricChannelUpdateProposalSpec{ChannelName: "mychannel"},
+	}
+
+	// the organizations that signed don't satisfy the mod policy yet
+	unsatisfied := fakePolicyManager{policy: fakePolicy{err: errors.New("signature set did not satisfy policy")}}
+	g.Expect(r.reviewSignatures(proposal, newTestConfig(), unsatisfied, envelope)).To(BeFalse())
+	g.Expect(proposal.Status.Phase).To(Equal(hlfv1alpha1.ProposalPendingPhase))
+	g.Expect(proposal.Status.Status).To(Equal(hlfv1alpha1.PendingStatus))
+	g.Expect(proposal.Status.Message).To(ContainSubstring("Waiting for more signatures"))
+
+	// the signature of another organization is imported
+	g.Expect(r.reviewSignatures(proposal, newTestConfig(), fakePolicyManager{policy: fakePolicy{}}, envelope)).To(BeTrue())
+	g.Expect(proposal.Status.Phase).To(Equal(hlfv1alpha1.ProposalSignedPhase))
+	g.Expect(proposal.Status.Message).NotTo(ContainSubstring("Waiting for more signatures"))
+
+	// the channel config changed since the update was computed
+	config := newTestConfig()
+	config.ChannelGroup.Values["Foo"].Version = 1
+	g.Expect(r.reviewSignatures(proposal, config, fakePolicyManager{policy: fakePolicy{}}, envelope)).To(BeFalse())
+	g.Expect(proposal.Status.Phase).To(Equal(hlfv1alpha1.ProposalRejectedPhase))
+	g.Expect(proposal.Status.Status).To(Equal(hlfv1alpha1.FailedStatus))
+}
+
+func TestDecodeSignatures(t *testing.T) {
+	g := NewWithT(t)
+	proposal := &hlfv1alpha1.FabricChannelUpdateProposal{
+		Spec: hlfv1alpha1.FabricChannelUpdateProposalSpec{
+			Signatures: []hlfv1alpha1.FabricChannelUpdateSignature{
+				{Signature: newTestSignature(g, "Org2MSP")},
+				{Signature: newTestSignature(g, "Org3MSP")},
+				{Signature: newTestSignature(g, "Org2MSP")},
+			},
+		},
+	}
+	importedSignatures, err := decodeSignatures(proposal)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(importedSignatures).To(HaveLen(3))
+
+	signatures, signedBy := addImportedSignatures([]*cb.ConfigSignature{{}}, []string{"Org1MSP"}, importedSignatures)
+	g.Expect(signatures).To(HaveLen(4))
+	g.Expect(signedBy).To(Equal([]string{"Org1MSP", "Org2MSP", "Org3MSP"}))
+
+	proposal.Spec.Signatures = append(proposal.Spec.Signatures, hlfv1alpha1.FabricChannelUpdateSignature{Signature: "not base64"})
+	_, err = decodeSignatures(proposal)
+	g.Expect(err).To(MatchError(ContainSubstring("failed to decode signature 3")))
+}
+
+func TestReconcileFailures(t *testing.T) {
+	scheme := runtime.NewScheme()
+	NewWithT(t).Expect(hlfv1alpha1.AddToScheme(scheme)).To(Succeed())
+	reconcileProposal := func(g *WithT, spec hlfv1alpha1.FabricChannelUpdateProposalSpec) (ctrl.Result, *hlfv1alpha1.FabricChannelUpdateProposal, error) {
+		proposal := &hlfv1alpha1.FabricChannelUpdateProposal{
+			ObjectMeta: v1.ObjectMeta{Name: "add-org3"},
+			Spec:       spec,
+		}
+		r := &FabricChannelUpdateProposalReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(proposal).Build(),
+			Log:    ctrl.Log.WithName("test"),
+			Scheme: scheme,
+			// nothing listens on the API server, so the secrets can't be read
+			Config: &rest.Config{Host: "http://127.0.0.1:1"},
+		}
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "add-org3"}})
+		updated := &hlfv1alpha1.FabricChannelUpdateProposal{}
+		g.Expect(r.Get(context.Background(), types.NamespacedName{Name: "add-org3"}, updated)).To(Succeed())
+		return result, updated, err
+	}
+
+	t.Run("invalid proposals are rejected", func(t *testing.T) {
+		g := NewWithT(t)
+		result, proposal, err := reconcileProposal(g, hlfv1alpha1.FabricChannelUpdateProposalSpec{
+			ChannelName:    "mychannel",
+			SubmitterMSPID: "Org1MSP",
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(result).To(Equal(ctrl.Result{}))
+		g.Expect(proposal.Status.Phase).To(Equal(hlfv1alpha1.ProposalRejectedPhase))
+		g.Expect(proposal.Status.Message).To(Equal("identity not found for MSPID Org1MSP"))
+
+		_, proposal, err = reconcileProposal(g, hlfv1alpha1.FabricChannelUpdateProposalSpec{
+			ChannelName:    "mychannel",
+			SubmitterMSPID: "Org1MSP",
+			Identities:     map[string]hlfv1alpha1.FabricMainChannelIdentity{"Org1MSP": {SecretName: "org1-admin"}},
+			Signatures:     []hlfv1alpha1.FabricChannelUpdateSignature{{Signature: "not base64"}},
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(proposal.Status.Phase).To(Equal(hlfv1alpha1.ProposalRejectedPhase))
+	})
+
+	t.Run("transient failures are retried", func(t *testing.T) {
+		g := NewWithT(t)
+		_, proposal, err := reconcileProposal(g, hlfv1alpha1.FabricChannelUpdateProposalSpec{
+			ChannelName:    "mychannel",
+			SubmitterMSPID: "Org1MSP",
+			Identities: map[string]hlfv1alpha1.FabricMainChannelIdentity{"Org1MSP": {
+				SecretName: "org1-admin",
+				ClientTLS:  &hlfv1alpha1.HLFIdentity{SecretName: "org1-admin-tls", SecretNamespace: "default", SecretKey: "user.yaml"},
+			}},
+		})
+		g.Expect(err).To(MatchError(ContainSubstring("failed to get the client TLS identity default/org1-admin-tls")))
+		g.Expect(proposal.Status.Phase).To(BeEmpty())
+		g.Expect(proposal.Status.Status).To(Equal(hlfv1alpha1.FailedStatus))
+	})
+}
@@ -0,0 +1,92 @@
+package channelupdate
+
+import (
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/configtx"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+)
+
+// modPolicyError is returned when the signatures of a config update don't satisfy the mod policy of an element
+type modPolicyError struct {
+	err error
+}
+
+func (e *modPolicyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *modPolicyError) Unwrap() error {
+	return e.err
+}
+
+// policyEvaluations records the policies that failed while validating a config update
+type policyEvaluations struct {
+	failed bool
+}
+
+// recordingPolicyManager wraps the policy manager of the channel to record the failed evaluations
+type recordingPolicyManager struct {
+	manager     policies.Manager
+	evaluations *policyEvaluations
+}
+
+func (m recordingPolicyManager) GetPolicy(id string) (policies.Policy, bool) {
+	policy, ok := m.manager.GetPolicy(id)
+	if policy == nil {
+		return policy, ok
+	}
+	return recordingPolicy{policy: policy, evaluations: m.evaluations}, ok
+}
+
+func (m recordingPolicyManager) Manager(path []string) (policies.Manager, bool) {
+	manager, ok := m.manager.Manager(path)
+	if manager == nil {
+		return manager, ok
+	}
+	return recordingPolicyManager{manager: manager, evaluations: m.evaluations}, ok
+}
+
+type recordingPolicy struct {
+	policy      policies.Policy
+	evaluations *policyEvaluations
+}
+
+func (p recordingPolicy) EvaluateSignedData(signatureSet []*protoutil.SignedData) error {
+	err := p.policy.EvaluateSignedData(signatureSet)
+	if err != nil {
+		p.evaluations.failed = true
+	}
+	return err
+}
+
+func (p recordingPolicy) EvaluateIdentities(identities []msp.Identity) error {
+	err := p.policy.EvaluateIdentities(identities)
+	if err != nil {
+		p.evaluations.failed = true
+	}
+	return err
+}
+
+// validateConfigUpdate validates the signed config update against the current configuration and policies of the
+// channel, a *modPolicyError is returned when the update is valid but the signatures don't satisfy the mod policies yet
+func validateConfigUpdate(channelID string, currentConfig *cb.Config, policyManager policies.Manager, signedEnvelope *cb.Envelope) error {
+	evaluations := &policyEvaluations{}
+	validator, err := configtx.NewValidatorImpl(
+		channelID,
+		currentConfig,
+		channelconfig.RootGroupKey,
+		recordingPolicyManager{manager: policyManager, evaluations: evaluations},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load channel configuration")
+	}
+	_, err = validator.ProposeConfigUpdate(signedEnvelope)
+	if err != nil && evaluations.failed {
+		return &modPolicyError{err: err}
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package channelupdate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/kfsoftware/hlf-operator/controllers/mainchannel"
+	. "github.com/onsi/gomega"
+)
+
+// fakePolicy fails every evaluation with err when it's set
+type fakePolicy struct {
+	err error
+}
+
+func (p fakePolicy) EvaluateSignedData(signatureSet []*protoutil.SignedData) error {
+	return p.err
+}
+
+func (p fakePolicy) EvaluateIdentities(identities []msp.Identity) error {
+	return p.err
+}
+
+// fakePolicyManager returns the same policy for every path
+type fakePolicyManager struct {
+	policy policies.Policy
+}
+
+func (m fakePolicyManager) GetPolicy(id string) (policies.Policy, bool) {
+	return m.policy, true
+}
+
+func (m fakePolicyManager) Manager(path []string) (policies.Manager, bool) {
+	return m, true
+}
+
+func newTestConfig() *cb.Config {
+	return &cb.Config{
+		ChannelGroup: &cb.ConfigGroup{
+			ModPolicy: "Admins",
+			Values: map[string]*cb.ConfigValue{
+				"Foo": {Value: []byte("foo"), ModPolicy: "Admins"},
+			},
+		},
+	}
+}
+
+// newTestConfigUpdate returns the envelope of a config update that modifies the Foo value of the channel
+func newTestConfigUpdate(g *WithT, channelID string) *cb.Envelope {
+	configUpdate := &cb.ConfigUpdate{
+		ReadSet: &cb.ConfigGroup{},
+		WriteSet: &cb.ConfigGroup{
+			Values: map[string]*cb.ConfigValue{
+				"Foo": {Value: []byte("bar"), ModPolicy: "Admins", Version: 1},
+			},
+		},
+	}
+	envelopeBytes, err := mainchannel.CreateConfigUpdateEnvelope(channelID, configUpdate)
+	g.Expect(err).NotTo(HaveOccurred())
+	envelope := &cb.Envelope{}
+	g.Expect(proto.Unmarshal(envelopeBytes, envelope)).To(Succeed())
+	return envelope
+}
+
+func TestValidateConfigUpdate(t *testing.T) {
+	g := NewWithT(t)
+	envelope := newTestConfigUpdate(g, "mychannel")
+
+	err := validateConfigUpdate("mychannel", newTestConfig(), fakePolicyManager{policy: fakePolicy{}}, envelope)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// the signatures don't satisfy the mod policy of the value
+	err = validateConfigUpdate("mychannel", newTestConfig(), fakePolicyManager{policy: fakePolicy{err: errors.New("signature set did not satisfy policy")}}, envelope)
+	var policyErr *modPolicyError
+	g.Expect(errors.As(err, &policyErr)).To(BeTrue())
+
+	// the update isn't valid regardless of the signatures
+	err = validateConfigUpdate("otherchannel", newTestConfig(), fakePolicyManager{policy: fakePolicy{err: errors.New("signature set did not satisfy policy")}}, envelope)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errors.As(err, &policyErr)).To(BeFalse())
+
+	staleUpdate := newTestConfigUpdate(g, "mychannel")
+	config := newTestConfig()
+	config.ChannelGroup.Values["Foo"].Version = 1
+	err = validateConfigUpdate("mychannel", config, fakePolicyManager{policy: fakePolicy{}}, staleUpdate)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errors.As(err, &policyErr)).To(BeFalse())
+}
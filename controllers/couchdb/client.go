@@ -0,0 +1,241 @@
+package couchdb
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const requestTimeout = 10 * time.Second
+
+// Client calls the HTTP API of CouchDB with the credentials of a peer, the names of the databases are prefixed with
+// the database prefix of the peer
+type Client struct {
+	url        string
+	user       string
+	password   string
+	prefix     string
+	httpClient *http.Client
+}
+
+// NewClient returns a client of the CouchDB served at url, for example https://couchdb.example.com:6984, tlsConfig
+// is only used for https URLs
+func NewClient(url string, tlsConfig *tls.Config, user string, password string, prefix string) *Client {
+	return &Client{
+		url:      strings.TrimSuffix(url, "/"),
+		user:     user,
+		password: password,
+		prefix:   prefix,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+			},
+			Timeout: requestTimeout,
+		},
+	}
+}
+
+// URL returns the URL of CouchDB
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Close releases the connections of the client
+func (c *Client) Close() {
+	c.httpClient.CloseIdleConnections()
+}
+
+type welcome struct {
+	Version string `json:"version"`
+}
+
+// GetVersion returns the version of CouchDB, it doesn't need credentials
+func (c *Client) GetVersion(ctx context.Context) (string, error) {
+	resp := &welcome{}
+	_, err := c.do(ctx, http.MethodGet, "/", nil, false, resp)
+	if err != nil {
+		return "", err
+	}
+	return resp.Version, nil
+}
+
+type session struct {
+	UserCtx struct {
+		Name *string `json:"name"`
+	} `json:"userCtx"`
+}
+
+// CheckCredentials returns an error if CouchDB doesn't authenticate the user of the client
+func (c *Client) CheckCredentials(ctx context.Context) error {
+	resp := &session{}
+	_, err := c.do(ctx, http.MethodGet, "/_session", nil, true, resp)
+	if err != nil {
+		return err
+	}
+	if resp.UserCtx.Name == nil {
+		return errors.Errorf("user %s isn't authenticated", c.user)
+	}
+	return nil
+}
+
+// DatabaseExists returns true if the database exists
+func (c *Client) DatabaseExists(ctx context.Context, db string) (bool, error) {
+	statusCode, err := c.do(ctx, http.MethodHead, c.dbPath(db), nil, true, nil)
+	if statusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IndexResult is the response of CouchDB to the creation of an index
+type IndexResult struct {
+	// created or exists
+	Result string `json:"result"`
+	// ID of the design document of the index
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// CreateIndex creates or updates a Mango index, CouchDB doesn't change an index that already has the same definition
+func (c *Client) CreateIndex(ctx context.Context, db string, definition []byte) (*IndexResult, error) {
+	result := &IndexResult{}
+	_, err := c.do(ctx, http.MethodPost, c.dbPath(db)+"/_index", definition, true, result)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the index in database %s", db)
+	}
+	return result, nil
+}
+
+// IndexInfo is the state of the view of an index
+type IndexInfo struct {
+	UpdaterRunning bool `json:"updater_running"`
+	UpdatesPending struct {
+		Total int64 `json:"total"`
+	} `json:"updates_pending"`
+}
+
+type designDocInfo struct {
+	ViewIndex IndexInfo `json:"view_index"`
+}
+
+// GetIndexInfo returns the state of the view of the design document of an index
+func (c *Client) GetIndexInfo(ctx context.Context, db string, ddocID string) (*IndexInfo, error) {
+	info := &designDocInfo{}
+	_, err := c.do(ctx, http.MethodGet, c.dbPath(db)+ddocPath(ddocID)+"/_info", nil, true, info)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the index %s of database %s", ddocID, db)
+	}
+	return &info.ViewIndex, nil
+}
+
+// WarmIndex starts the build of an index without waiting for it, the same way the peer warms its indexes
+func (c *Client) WarmIndex(ctx context.Context, db string, ddocID string, name string) error {
+	path := c.dbPath(db) + ddocPath(ddocID) + "/_view/" + url.PathEscape(name) + "?update=lazy&limit=0"
+	_, err := c.do(ctx, http.MethodGet, path, nil, true, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to warm the index %s of database %s", ddocID, db)
+	}
+	return nil
+}
+
+// ListDatabases returns the databases with the prefix of the client, without the prefix, the system databases whose
+// names start with an underscore are skipped
+func (c *Client) ListDatabases(ctx context.Context) ([]string, error) {
+	var names []string
+	_, err := c.do(ctx, http.MethodGet, "/_all_dbs", nil, true, &names)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the databases")
+	}
+	return filterDatabases(names, c.prefix), nil
+}
+
+func filterDatabases(names []string, prefix string) []string {
+	dbs := []string{}
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		db := strings.TrimPrefix(name, prefix)
+		if db == "" || strings.HasPrefix(db, "_") {
+			continue
+		}
+		dbs = append(dbs, db)
+	}
+	return dbs
+}
+
+// DeleteDatabase deletes a database, it doesn't fail when the database doesn't exist
+func (c *Client) DeleteDatabase(ctx context.Context, db string) error {
+	statusCode, err := c.do(ctx, http.MethodDelete, c.dbPath(db), nil, true, nil)
+	if statusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete database %s", db)
+	}
+	return nil
+}
+
+// DropApplicationDBs deletes the databases of the peer, as `peer node rebuild-dbs` does with CouchDB, and returns
+// their names
+func (c *Client) DropApplicationDBs(ctx context.Context) ([]string, error) {
+	dbs, err := c.ListDatabases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, db := range dbs {
+		err = c.DeleteDatabase(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dbs, nil
+}
+
+func (c *Client) dbPath(db string) string {
+	return "/" + url.PathEscape(c.prefix+db)
+}
+
+func ddocPath(ddocID string) string {
+	return "/_design/" + url.PathEscape(strings.TrimPrefix(ddocID, "_design/"))
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body []byte, auth bool, out interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth {
+		req.SetBasicAuth(c.user, c.password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, errors.Errorf("%s %s returned %d: %s", method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if out == nil || len(respBody) == 0 {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,91 @@
+package couchdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// fakeCouchDB serves _all_dbs and the deletion of databases of an in-memory CouchDB
+type fakeCouchDB struct {
+	mu  sync.Mutex
+	dbs map[string]bool
+}
+
+func (f *fakeCouchDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, password, ok := r.BasicAuth()
+	if !ok || user != "admin" || password != "adminpw" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/_all_dbs":
+		names := []string{}
+		for name := range f.dbs {
+			names = append(names, name)
+		}
+		_ = json.NewEncoder(w).Encode(names)
+	case r.Method == http.MethodDelete:
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if !f.dbs[name] {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not_found","reason":"Database does not exist."}`))
+			return
+		}
+		delete(f.dbs, name)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func newFakeCouchDB(names ...string) *fakeCouchDB {
+	f := &fakeCouchDB{dbs: map[string]bool{}}
+	for _, name := range names {
+		f.dbs[name] = true
+	}
+	return f
+}
+
+func TestFilterDatabases(t *testing.T) {
+	g := NewWithT(t)
+	names := []string{"_replicator", "_users", "mychannel_", "mychannel_mycc", "org1peer0_mychannel_", "org1peer0_fabric__internal", "org1peer0__users", "org2peer0_mychannel_"}
+	g.Expect(filterDatabases(names, "")).To(Equal([]string{"mychannel_", "mychannel_mycc", "org1peer0_mychannel_", "org1peer0_fabric__internal", "org1peer0__users", "org2peer0_mychannel_"}))
+	g.Expect(filterDatabases(names, "org1peer0_")).To(Equal([]string{"mychannel_", "fabric__internal"}))
+	g.Expect(filterDatabases(nil, "org1peer0_")).To(BeEmpty())
+}
+
+func TestDropApplicationDBs(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	couch := newFakeCouchDB("_users", "org1peer0_mychannel_", "org1peer0_mychannel_mycc", "org2peer0_mychannel_")
+	server := httptest.NewServer(couch)
+	defer server.Close()
+
+	client := NewClient(server.URL, nil, "admin", "adminpw", "org1peer0_")
+	defer client.Close()
+	dbs, err := client.ListDatabases(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dbs).To(ConsistOf("mychannel_", "mychannel_mycc"))
+
+	dropped, err := client.DropApplicationDBs(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(dropped).To(ConsistOf("mychannel_", "mychannel_mycc"))
+	g.Expect(couch.dbs).To(Equal(map[string]bool{"_users": true, "org2peer0_mychannel_": true}))
+
+	// the database is already gone
+	g.Expect(client.DeleteDatabase(ctx, "mychannel_")).To(Succeed())
+
+	unauthorized := NewClient(server.URL, nil, "admin", "wrong", "org1peer0_")
+	defer unauthorized.Close()
+	_, err = unauthorized.DropApplicationDBs(ctx)
+	g.Expect(err).To(MatchError(ContainSubstring("401")))
+}
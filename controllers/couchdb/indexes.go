@@ -0,0 +1,33 @@
+package couchdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/ledger/util/couchdb"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric/sdkinternal/ccmetadata"
+)
+
+// DatabaseName returns the name of the database the peer keeps the state of a chaincode in, or the private data of
+// one of its collections when collection isn't empty, without the database prefix of the peer
+func DatabaseName(channel string, chaincode string, collection string) string {
+	namespace := chaincode
+	if collection != "" {
+		namespace = fmt.Sprintf("%s$$p%s", chaincode, collection)
+	}
+	// the peer replaces the dots of the names before it creates the databases
+	return strings.ReplaceAll(couchdb.ConstructNamespaceDBName(channel, namespace), ".", "$")
+}
+
+// IndexPath returns the path the index would have in the META-INF directory of a chaincode package
+func IndexPath(name string, collection string) string {
+	if collection != "" {
+		return fmt.Sprintf("META-INF/statedb/couchdb/collections/%s/indexes/%s.json", collection, name)
+	}
+	return fmt.Sprintf("META-INF/statedb/couchdb/indexes/%s.json", name)
+}
+
+// ValidateIndex checks an index definition with the same rules the peer applies to the indexes of a chaincode package
+func ValidateIndex(name string, collection string, definition []byte) error {
+	return ccmetadata.ValidateMetadataFile(IndexPath(name, collection), definition)
+}
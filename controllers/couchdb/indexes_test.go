@@ -0,0 +1,75 @@
+package couchdb
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestIndexPath(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(IndexPath("indexOwner", "")).To(Equal("META-INF/statedb/couchdb/indexes/indexOwner.json"))
+	g.Expect(IndexPath("indexOwner", "collectionMarbles")).To(Equal("META-INF/statedb/couchdb/collections/collectionMarbles/indexes/indexOwner.json"))
+}
+
+func TestDatabaseName(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(DatabaseName("demo", "asset", "")).To(Equal("demo_asset"))
+	g.Expect(DatabaseName("demo", "asset", "private")).To(Equal("demo_asset$$pprivate"))
+	g.Expect(DatabaseName("demo", "asset.v1", "")).To(Equal("demo_asset$v1"))
+}
+
+func TestValidateIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		collection string
+		definition string
+		wantErr    string
+	}{
+		{
+			name:       "valid index",
+			definition: `{"index": {"fields": ["owner"]}, "ddoc": "indexOwnerDoc", "name": "indexOwner", "type": "json"}`,
+		},
+		{
+			name:       "valid index of a collection",
+			collection: "collectionMarbles",
+			definition: `{"index": {"fields": [{"owner": "asc"}]}, "ddoc": "indexOwnerDoc", "name": "indexOwner", "type": "json"}`,
+		},
+		{
+			name:       "invalid JSON",
+			definition: `{"index": `,
+			wantErr:    "not a valid JSON",
+		},
+		{
+			name:       "no index",
+			definition: `{"name": "indexOwner", "type": "json"}`,
+			wantErr:    `Index definition must include a "fields" definition`,
+		},
+		{
+			name:       "unsupported type",
+			definition: `{"index": {"fields": ["owner"]}, "name": "indexOwner", "type": "text"}`,
+			wantErr:    "Index type must be json",
+		},
+		{
+			name:       "invalid sort",
+			definition: `{"index": {"fields": [{"owner": "up"}]}, "name": "indexOwner", "type": "json"}`,
+			wantErr:    `Sort must be either "asc" or "desc"`,
+		},
+		{
+			name:       "unknown entry",
+			definition: `{"index": {"fields": ["owner"]}, "name": "indexOwner", "partial": true}`,
+			wantErr:    "Invalid Entry.  Entry partial",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := ValidateIndex("indexOwner", tt.collection, []byte(tt.definition))
+			if tt.wantErr == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(MatchError(ContainSubstring(tt.wantErr)))
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package couchdb
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strconv"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Port is the port of the CouchDB container that runs in the pod of the peer
+const Port = 5984
+
+// IsExternal returns true when the peer uses a CouchDB that runs outside its pod
+func IsExternal(spec hlfv1alpha1.FabricPeerSpec) bool {
+	return spec.StateDb == hlfv1alpha1.StateDBCouchDB && spec.CouchDB.ExternalCouchDB != nil && spec.CouchDB.ExternalCouchDB.Enabled
+}
+
+// GetUserKey returns the key of the user in the secret with the credentials of CouchDB
+func GetUserKey(credentials *hlfv1alpha1.FabricPeerCouchDBCredentials) string {
+	if credentials.UserKey == "" {
+		return "username"
+	}
+	return credentials.UserKey
+}
+
+// GetPasswordKey returns the key of the password in the secret with the credentials of CouchDB
+func GetPasswordKey(credentials *hlfv1alpha1.FabricPeerCouchDBCredentials) string {
+	if credentials.PasswordKey == "" {
+		return "password"
+	}
+	return credentials.PasswordKey
+}
+
+func getSecretKey(ctx context.Context, client kubernetes.Interface, ns string, selector corev1.SecretKeySelector) ([]byte, error) {
+	secret, err := client.CoreV1().Secrets(ns).Get(ctx, selector.Name, v1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get secret %s", selector.Name)
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return nil, errors.Errorf("key %s not found in secret %s", selector.Key, selector.Name)
+	}
+	return value, nil
+}
+
+// GetCredentials returns the user and password of CouchDB, read from the secret of the external CouchDB when it's set
+func GetCredentials(ctx context.Context, client kubernetes.Interface, ns string, spec hlfv1alpha1.FabricPeerSpec) (string, string, error) {
+	external := spec.CouchDB.ExternalCouchDB
+	if external == nil || !external.Enabled || external.Credentials == nil {
+		return spec.CouchDB.User, spec.CouchDB.Password, nil
+	}
+	secret, err := client.CoreV1().Secrets(ns).Get(ctx, external.Credentials.SecretName, v1.GetOptions{})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to get the secret %s with the CouchDB credentials", external.Credentials.SecretName)
+	}
+	return string(secret.Data[GetUserKey(external.Credentials)]), string(secret.Data[GetPasswordKey(external.Credentials)]), nil
+}
+
+func getTLSConfig(ctx context.Context, client kubernetes.Interface, ns string, couchTLS *hlfv1alpha1.FabricPeerCouchDBTLS, host string) (*tls.Config, error) {
+	caPem, err := getSecretKey(ctx, client, ns, couchTLS.CACert)
+	if err != nil {
+		return nil, err
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caPem) {
+		return nil, errors.Errorf("secret %s has no PEM certificates in key %s", couchTLS.CACert.Name, couchTLS.CACert.Key)
+	}
+	tlsConfig := &tls.Config{
+		RootCAs:    rootCAs,
+		ServerName: host,
+	}
+	if couchTLS.ClientCert != nil && couchTLS.ClientKey != nil {
+		certPem, err := getSecretKey(ctx, client, ns, *couchTLS.ClientCert)
+		if err != nil {
+			return nil, err
+		}
+		keyPem, err := getSecretKey(ctx, client, ns, *couchTLS.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		clientCert, err := tls.X509KeyPair(certPem, keyPem)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid CouchDB client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	return tlsConfig, nil
+}
+
+// NewExternalClient returns a client of the external CouchDB of the peer, with its TLS settings, credentials and
+// database prefix. The state database of the spec isn't checked, so the databases of the peer can be dropped when it
+// switches to or from CouchDB
+func NewExternalClient(ctx context.Context, client kubernetes.Interface, ns string, spec hlfv1alpha1.FabricPeerSpec) (*Client, error) {
+	external := spec.CouchDB.ExternalCouchDB
+	if external == nil || !external.Enabled {
+		return nil, errors.New("the peer has no external CouchDB")
+	}
+	user, password, err := GetCredentials(ctx, client, ns, spec)
+	if err != nil {
+		return nil, err
+	}
+	scheme := "http"
+	var tlsConfig *tls.Config
+	if external.TLS != nil {
+		scheme = "https"
+		tlsConfig, err = getTLSConfig(ctx, client, ns, external.TLS, external.Host)
+		if err != nil {
+			return nil, err
+		}
+	}
+	url := fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(external.Host, strconv.Itoa(external.Port)))
+	return NewClient(url, tlsConfig, user, password, external.DatabasePrefix), nil
+}
+
+// NewPodClient returns a client of the CouchDB container that runs in the pod of the peer with the given IP
+func NewPodClient(fabricPeer *hlfv1alpha1.FabricPeer, podIP string) *Client {
+	url := fmt.Sprintf("http://%s", net.JoinHostPort(podIP, strconv.Itoa(Port)))
+	return NewClient(url, nil, fabricPeer.Spec.CouchDB.User, fabricPeer.Spec.CouchDB.Password, "")
+}
@@ -10,23 +10,19 @@ import (
 	"github.com/hyperledger/fabric-config/protolator"
 	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
-	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
-	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
-	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
-	"github.com/hyperledger/fabric-sdk-go/pkg/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
-	mspimpl "github.com/hyperledger/fabric-sdk-go/pkg/msp"
 	"github.com/hyperledger/fabric/protoutil"
 	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
 	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/pkg/bft"
 	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
 	"github.com/kfsoftware/hlf-operator/pkg/nc"
 	"github.com/kfsoftware/hlf-operator/pkg/status"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -122,61 +118,25 @@ func (r *FabricFollowerChannelReconciler) Reconcile(ctx context.Context, req ctr
 	// join peers
 	mspID := fabricFollowerChannel.Spec.MSPID
 
-	ncResponse, err := nc.GenerateNetworkConfigForFollower(fabricFollowerChannel, clientSet, hlfClientSet, mspID)
-	if err != nil {
-		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "failed to generate network config"), false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
-	}
-	log.Infof("Generated network config: %s", ncResponse.NetworkConfig)
-	configBackend := config.FromRaw([]byte(ncResponse.NetworkConfig), "yaml")
-	sdk, err := fabsdk.New(configBackend)
-	if err != nil {
-		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, err, false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
-	}
-	idConfig := fabricFollowerChannel.Spec.HLFIdentity
-	secret, err := clientSet.CoreV1().Secrets(idConfig.SecretNamespace).Get(ctx, idConfig.SecretName, v1.GetOptions{})
-	if err != nil {
-		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, err, false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
-	}
-	secretData, ok := secret.Data[idConfig.SecretKey]
-	if !ok {
-		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, fmt.Errorf("secret key %s not found", idConfig.SecretKey), false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
-	}
-	id := &identity{}
-	err = yaml.Unmarshal(secretData, id)
-	if err != nil {
-		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, err, false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
-	}
-	sdkConfig, err := sdk.Config()
-	if err != nil {
-		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, err, false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
-	}
-	cryptoConfig := cryptosuite.ConfigFromBackend(sdkConfig)
-	cryptoSuite, err := sw.GetSuiteByConfig(cryptoConfig)
+	clientTLS, err := nc.GetClientTLS(ctx, clientSet, fabricFollowerChannel.Spec.ClientTLSIdentity)
 	if err != nil {
 		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, err, false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
 	}
-	userStore := mspimpl.NewMemoryUserStore()
-	endpointConfig, err := fab.ConfigFromBackend(sdkConfig)
+	ncResponse, err := nc.GenerateNetworkConfigForFollower(fabricFollowerChannel, clientSet, hlfClientSet, mspID, clientTLS)
 	if err != nil {
-		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, err, false)
+		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "failed to generate network config"), false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
 	}
-	identityManager, err := mspimpl.NewIdentityManager(mspID, userStore, cryptoSuite, endpointConfig)
+	// the network config holds the client TLS private key when client auth is used, so it's only logged in debug
+	log.Debugf("Generated network config: %s", ncResponse.NetworkConfig)
+	configBackend := config.FromRaw([]byte(ncResponse.NetworkConfig), "yaml")
+	sdk, err := fabsdk.New(configBackend)
 	if err != nil {
 		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, err, false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
 	}
-	signingIdentity, err := identityManager.CreateSigningIdentity(
-		msp.WithPrivateKey([]byte(id.Key.Pem)),
-		msp.WithCert([]byte(id.Cert.Pem)),
-	)
+	signingIdentity, err := utils.GetSigningIdentity(ctx, sdk, clientSet, mspID, fabricFollowerChannel.Spec.HLFIdentity)
 	if err != nil {
 		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, err, false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
@@ -264,6 +224,31 @@ func (r *FabricFollowerChannelReconciler) Reconcile(ctx context.Context, req ctr
 			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
 		}
 	}
+	if revocationList := fabricFollowerChannel.Spec.RevocationList; revocationList != nil {
+		if revocationList.CAName == "" {
+			r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, errors.New("caName is required to embed the revocation list"), false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
+		}
+		crl, err := helpers.GetCACRL(ctx, hlfClientSet, revocationList.CAName, revocationList.CANamespace, revocationList.EnrollID, mspID)
+		if err != nil {
+			r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, err, false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
+		}
+		orgMSP, err := app.MSP().Configuration()
+		if err != nil {
+			r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "failed to get MSP of organization %s", mspID), false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
+		}
+		if helpers.RevocationListChanged(orgMSP.RevocationList, crl) {
+			r.Log.Info(fmt.Sprintf("Updating revocation list of organization %s", mspID))
+			orgMSP.RevocationList = helpers.MergeRevocationList(orgMSP.RevocationList, crl)
+			err = app.SetMSP(orgMSP)
+			if err != nil {
+				r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "failed to set MSP of organization %s", mspID), false)
+				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
+			}
+		}
+	}
 	configUpdateBytes, err := cftxGen.ComputeMarshaledUpdate(fabricFollowerChannel.Spec.Name)
 	if err != nil {
 		if !strings.Contains(err.Error(), "no differences detected between original and updated config") {
@@ -292,7 +277,7 @@ func (r *FabricFollowerChannelReconciler) Reconcile(ctx context.Context, req ctr
 			r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, err, false)
 			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
 		}
-		log.Infof("anchor anchorPeers added: %s", chResponse.TransactionID)
+		log.Infof("anchor peers and revocation list updated: %s", chResponse.TransactionID)
 	}
 
 	// update config map with the configuration
@@ -307,7 +292,7 @@ func (r *FabricFollowerChannelReconciler) Reconcile(ctx context.Context, req ctr
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
 	}
 	var buf bytes.Buffer
-	err = protolator.DeepMarshalJSON(&buf, cmnConfig)
+	err = protolator.DeepMarshalJSON(&buf, bft.WithoutBFTMetadata(cmnConfig))
 	if err != nil {
 		r.setConditionStatus(ctx, fabricFollowerChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "error converting block to JSON"), false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricFollowerChannel)
@@ -423,15 +408,6 @@ func (r *FabricFollowerChannelReconciler) SetupWithManager(mgr ctrl.Manager) err
 		Complete(r)
 }
 
-type identity struct {
-	Cert Pem `json:"cert"`
-	Key  Pem `json:"key"`
-}
-
-type Pem struct {
-	Pem string
-}
-
 func CreateConfigUpdateEnvelope(channelID string, configUpdate *common.ConfigUpdate) ([]byte, error) {
 	configUpdate.ChannelId = channelID
 	configUpdateData, err := proto.Marshal(configUpdate)
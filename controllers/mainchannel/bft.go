@@ -0,0 +1,227 @@
+package mainchannel
+
+import (
+	"context"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/pkg/bft"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func isBFT(channel *hlfv1alpha1.FabricMainChannel) bool {
+	return channel.Spec.ChannelConfig != nil &&
+		channel.Spec.ChannelConfig.Orderer != nil &&
+		channel.Spec.ChannelConfig.Orderer.OrdererType == bft.ConsensusType
+}
+
+// resolveConsenters fills the fields that are not set in the consenters with the status of the referenced FabricOrdererNode
+func resolveConsenters(
+	ctx context.Context,
+	clientSet *kubernetes.Clientset,
+	hlfClientSet *operatorv1.Clientset,
+	channel *hlfv1alpha1.FabricMainChannel,
+) ([]hlfv1alpha1.FabricMainChannelConsenter, error) {
+	var consenters []hlfv1alpha1.FabricMainChannelConsenter
+	for _, consenter := range channel.Spec.Consenters {
+		if consenter.OrdererNode == nil {
+			consenters = append(consenters, consenter)
+			continue
+		}
+		ordererNode, err := hlfClientSet.HlfV1alpha1().FabricOrdererNodes(consenter.OrdererNode.Namespace).Get(
+			ctx,
+			consenter.OrdererNode.Name,
+			v1.GetOptions{},
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get orderer node %s/%s", consenter.OrdererNode.Namespace, consenter.OrdererNode.Name)
+		}
+		if consenter.Host == "" || consenter.Port == 0 {
+			host, port, err := helpers.GetOrdererHostAndPort(clientSet, ordererNode.Spec, ordererNode.Status)
+			if err != nil {
+				return nil, err
+			}
+			if consenter.Host == "" {
+				consenter.Host = host
+			}
+			if consenter.Port == 0 {
+				consenter.Port = port
+			}
+		}
+		if consenter.TLSCert == "" {
+			consenter.TLSCert = ordererNode.Status.TlsCert
+		}
+		if consenter.Identity == "" {
+			consenter.Identity = ordererNode.Status.SignCert
+		}
+		if consenter.MSPID == "" {
+			consenter.MSPID = ordererNode.Spec.MspID
+		}
+		if consenter.ID == 0 {
+			consenter.ID = ordererNode.Status.ConsenterID
+		}
+		if consenter.TLSCert == "" {
+			return nil, errors.Errorf("orderer node %s/%s has no TLS certificate yet", ordererNode.Namespace, ordererNode.Name)
+		}
+		consenters = append(consenters, consenter)
+	}
+	return consenters, nil
+}
+
+func mapBFTOptions(channel *hlfv1alpha1.FabricMainChannel) bft.Options {
+	options := bft.DefaultOptions()
+	if channel.Spec.ChannelConfig == nil ||
+		channel.Spec.ChannelConfig.Orderer == nil ||
+		channel.Spec.ChannelConfig.Orderer.SmartBFT == nil {
+		return options
+	}
+	smartBFT := channel.Spec.ChannelConfig.Orderer.SmartBFT
+	return bft.Options{
+		RequestBatchMaxCount:      smartBFT.RequestBatchMaxCount,
+		RequestBatchMaxBytes:      smartBFT.RequestBatchMaxBytes,
+		RequestBatchMaxInterval:   smartBFT.RequestBatchMaxInterval,
+		IncomingMessageBufferSize: smartBFT.IncomingMessageBufferSize,
+		RequestPoolSize:           smartBFT.RequestPoolSize,
+		RequestForwardTimeout:     smartBFT.RequestForwardTimeout,
+		RequestComplainTimeout:    smartBFT.RequestComplainTimeout,
+		RequestAutoRemoveTimeout:  smartBFT.RequestAutoRemoveTimeout,
+		ViewChangeResendInterval:  smartBFT.ViewChangeResendInterval,
+		ViewChangeTimeout:         smartBFT.ViewChangeTimeout,
+		LeaderHeartbeatTimeout:    smartBFT.LeaderHeartbeatTimeout,
+		LeaderHeartbeatCount:      smartBFT.LeaderHeartbeatCount,
+		CollectTimeout:            smartBFT.CollectTimeout,
+		SyncOnStart:               smartBFT.SyncOnStart,
+		SpeedUpViewChange:         smartBFT.SpeedUpViewChange,
+		LeaderRotation:            smartBFT.LeaderRotation,
+		DecisionsPerLeader:        smartBFT.DecisionsPerLeader,
+		RequestMaxBytes:           smartBFT.RequestMaxBytes,
+		RequestPoolSubmitTimeout:  smartBFT.RequestPoolSubmitTimeout,
+	}
+}
+
+func mapBFTConsenters(consenters []hlfv1alpha1.FabricMainChannelConsenter) ([]bft.Consenter, error) {
+	var bftConsenters []bft.Consenter
+	for _, consenter := range consenters {
+		if consenter.ID == 0 {
+			return nil, errors.Errorf("consenter %s:%d requires an ID for BFT ordering, set it or wait for its orderer node to get one", consenter.Host, consenter.Port)
+		}
+		if consenter.MSPID == "" || consenter.Identity == "" {
+			return nil, errors.Errorf("consenter %d requires an MSP ID and an identity for BFT ordering", consenter.ID)
+		}
+		bftConsenters = append(bftConsenters, bft.Consenter{
+			ID:            consenter.ID,
+			Host:          consenter.Host,
+			Port:          uint32(consenter.Port),
+			MSPID:         consenter.MSPID,
+			Identity:      []byte(consenter.Identity),
+			ClientTLSCert: []byte(consenter.TLSCert),
+			ServerTLSCert: []byte(consenter.TLSCert),
+		})
+	}
+	return bftConsenters, nil
+}
+
+// getBFTConsensus returns the consenters and the SmartBFT options of the channel, it's the only mapping of the BFT
+// consensus, used both for the genesis block and for the updates of the channel
+func getBFTConsensus(
+	ctx context.Context,
+	clientSet *kubernetes.Clientset,
+	hlfClientSet *operatorv1.Clientset,
+	channel *hlfv1alpha1.FabricMainChannel,
+) ([]bft.Consenter, bft.Options, error) {
+	consenters, err := resolveConsenters(ctx, clientSet, hlfClientSet, channel)
+	if err != nil {
+		return nil, bft.Options{}, err
+	}
+	bftConsenters, err := mapBFTConsenters(consenters)
+	if err != nil {
+		return nil, bft.Options{}, err
+	}
+	return bftConsenters, mapBFTOptions(channel), nil
+}
+
+// setBFTConsensus switches the genesis block of the channel to BFT ordering
+func (r *FabricMainChannelReconciler) setBFTConsensus(ctx context.Context, block *cb.Block, channel *hlfv1alpha1.FabricMainChannel) error {
+	clientSet, err := utils.GetClientKubeWithConf(r.Config)
+	if err != nil {
+		return err
+	}
+	hlfClientSet, err := operatorv1.NewForConfig(r.Config)
+	if err != nil {
+		return err
+	}
+	consenters, options, err := getBFTConsensus(ctx, clientSet, hlfClientSet, channel)
+	if err != nil {
+		return err
+	}
+	return bft.SetBlockConsensus(block, consenters, options)
+}
+
+// updateBFTConsensusConfigTx sets the consenters and the SmartBFT options of the spec in the config of a BFT channel
+// and returns whether they changed, the consensus type of an existing channel can't be changed
+func updateBFTConsensusConfigTx(
+	ctx context.Context,
+	clientSet *kubernetes.Clientset,
+	hlfClientSet *operatorv1.Clientset,
+	config *cb.Config,
+	channel *hlfv1alpha1.FabricMainChannel,
+) (bool, error) {
+	consensusType, err := bft.GetConsensusType(config)
+	if err != nil {
+		return false, err
+	}
+	if !isBFT(channel) {
+		if consensusType == bft.ConsensusType {
+			return false, errors.Errorf("channel %s uses BFT ordering, it can't be switched to etcdraft", channel.Spec.Name)
+		}
+		return false, nil
+	}
+	if consensusType != bft.ConsensusType {
+		return false, errors.Errorf("channel %s uses %s ordering, migrating it to BFT isn't supported", channel.Spec.Name, consensusType)
+	}
+	consenters, options, err := getBFTConsensus(ctx, clientSet, hlfClientSet, channel)
+	if err != nil {
+		return false, err
+	}
+	return bft.UpdateConsensus(config, consenters, options)
+}
+
+// recordConsenterIDs adds the IDs of the consenter mappings of the configs to the consenter IDs annotation of the
+// channel, the IDs of the removed consenters are kept so that they are never reused
+func recordConsenterIDs(
+	ctx context.Context,
+	hlfClientSet *operatorv1.Clientset,
+	channel *hlfv1alpha1.FabricMainChannel,
+	configs ...*cb.Config,
+) error {
+	recorded, err := utils.ParseConsenterIDs(channel.Annotations[utils.ConsenterIDsAnnotation])
+	if err != nil {
+		return errors.Wrapf(err, "invalid %s annotation", utils.ConsenterIDsAnnotation)
+	}
+	ids := recorded
+	for _, config := range configs {
+		configIDs, err := bft.GetConsenterIDs(config)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, configIDs...)
+	}
+	value := utils.FormatConsenterIDs(ids)
+	if value == "" || value == utils.FormatConsenterIDs(recorded) {
+		return nil
+	}
+	if channel.Annotations == nil {
+		channel.Annotations = map[string]string{}
+	}
+	channel.Annotations[utils.ConsenterIDsAnnotation] = value
+	updated, err := hlfClientSet.HlfV1alpha1().FabricMainChannels().Update(ctx, channel, v1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to record the consenter IDs of channel %s", channel.Name)
+	}
+	channel.ObjectMeta = updated.ObjectMeta
+	return nil
+}
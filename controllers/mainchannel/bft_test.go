@@ -0,0 +1,54 @@
+package mainchannel
+
+import (
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/pkg/bft"
+	. "github.com/onsi/gomega"
+)
+
+func TestMapBFTConsenters(t *testing.T) {
+	g := NewWithT(t)
+	consenters := []hlfv1alpha1.FabricMainChannelConsenter{
+		{ID: 4, Host: "orderer0.example.com", Port: 7050, TLSCert: "tls0", MSPID: "OrdererMSP", Identity: "sign0"},
+		{ID: 2, Host: "orderer1.example.com", Port: 7050, TLSCert: "tls1", MSPID: "OrdererMSP", Identity: "sign1"},
+	}
+
+	bftConsenters, err := mapBFTConsenters(consenters)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(bftConsenters).To(Equal([]bft.Consenter{
+		{ID: 4, Host: "orderer0.example.com", Port: 7050, MSPID: "OrdererMSP", Identity: []byte("sign0"), ClientTLSCert: []byte("tls0"), ServerTLSCert: []byte("tls0")},
+		{ID: 2, Host: "orderer1.example.com", Port: 7050, MSPID: "OrdererMSP", Identity: []byte("sign1"), ClientTLSCert: []byte("tls1"), ServerTLSCert: []byte("tls1")},
+	}))
+
+	consenters[1].ID = 0
+	_, err = mapBFTConsenters(consenters)
+	g.Expect(err).To(HaveOccurred())
+
+	consenters[1].ID = 2
+	consenters[1].Identity = ""
+	_, err = mapBFTConsenters(consenters)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestMapBFTOptions(t *testing.T) {
+	g := NewWithT(t)
+	channel := &hlfv1alpha1.FabricMainChannel{}
+
+	g.Expect(mapBFTOptions(channel)).To(Equal(bft.DefaultOptions()))
+
+	channel.Spec.ChannelConfig = &hlfv1alpha1.FabricMainChannelConfig{
+		Orderer: &hlfv1alpha1.FabricMainChannelOrdererConfig{
+			OrdererType: bft.ConsensusType,
+			SmartBFT: &hlfv1alpha1.FabricMainChannelSmartBFT{
+				RequestBatchMaxCount: 10,
+				LeaderRotation:       bft.RotationOff,
+			},
+		},
+	}
+	options := mapBFTOptions(channel)
+	g.Expect(options.RequestBatchMaxCount).To(Equal(uint64(10)))
+	g.Expect(options.LeaderRotation).To(Equal(bft.RotationOff))
+	g.Expect(isBFT(channel)).To(BeTrue())
+}
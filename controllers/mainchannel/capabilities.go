@@ -0,0 +1,488 @@
+package mainchannel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/hyperledger/fabric-config/configtx"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const defaultCapability = "V2_0"
+
+const (
+	// capabilityUpgradeRequeueAfter is how often the channel is checked while the orderers apply new capabilities
+	capabilityUpgradeRequeueAfter = 5 * time.Second
+	// capabilityUpgradeTimeout is how long the orderers have to apply new capabilities before the update is resubmitted
+	capabilityUpgradeTimeout = 30 * time.Second
+)
+
+var (
+	capabilityRegex = regexp.MustCompile(`^V(\d+)_(\d+)(?:_(\d+))?$`)
+	imageTagRegex   = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+)
+
+type capabilityGroup string
+
+const (
+	ordererCapabilityGroup     capabilityGroup = "orderer"
+	channelCapabilityGroup     capabilityGroup = "channel"
+	applicationCapabilityGroup capabilityGroup = "application"
+)
+
+// capabilityGroupsOrder is the order in which the capabilities must be upgraded, the orderer
+// capabilities first so that the ordering service can validate the new channel capabilities
+var capabilityGroupsOrder = []capabilityGroup{
+	ordererCapabilityGroup,
+	channelCapabilityGroup,
+	applicationCapabilityGroup,
+}
+
+type fabricVersion [3]int
+
+func (v fabricVersion) less(o fabricVersion) bool {
+	for i := range v {
+		if v[i] != o[i] {
+			return v[i] < o[i]
+		}
+	}
+	return false
+}
+
+func (v fabricVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+func parseVersionMatch(match []string) fabricVersion {
+	version := fabricVersion{}
+	for i := 1; i < len(match) && i <= 3; i++ {
+		if match[i] == "" {
+			continue
+		}
+		version[i-1], _ = strconv.Atoi(match[i])
+	}
+	return version
+}
+
+// requiredVersion returns the minimum fabric version needed to support all the capabilities
+func requiredVersion(capabilities []string) (fabricVersion, bool) {
+	found := false
+	version := fabricVersion{}
+	for _, capability := range capabilities {
+		match := capabilityRegex.FindStringSubmatch(capability)
+		if match == nil {
+			continue
+		}
+		capabilityVersion := parseVersionMatch(match)
+		if !found || version.less(capabilityVersion) {
+			version = capabilityVersion
+		}
+		found = true
+	}
+	return version, found
+}
+
+func versionFromTag(tag string) (fabricVersion, bool) {
+	match := imageTagRegex.FindStringSubmatch(tag)
+	if match == nil {
+		return fabricVersion{}, false
+	}
+	return parseVersionMatch(match), true
+}
+
+func capabilitiesOrDefault(capabilities []string) []string {
+	if len(capabilities) == 0 {
+		return []string{defaultCapability}
+	}
+	return capabilities
+}
+
+func desiredOrdererCapabilities(channel *hlfv1alpha1.FabricMainChannel) []string {
+	if channel.Spec.ChannelConfig != nil && channel.Spec.ChannelConfig.Orderer != nil {
+		return capabilitiesOrDefault(channel.Spec.ChannelConfig.Orderer.Capabilities)
+	}
+	return capabilitiesOrDefault(nil)
+}
+
+func desiredChannelCapabilities(channel *hlfv1alpha1.FabricMainChannel) []string {
+	if channel.Spec.ChannelConfig != nil {
+		return capabilitiesOrDefault(channel.Spec.ChannelConfig.Capabilities)
+	}
+	return capabilitiesOrDefault(nil)
+}
+
+func desiredApplicationCapabilities(channel *hlfv1alpha1.FabricMainChannel) []string {
+	if channel.Spec.ChannelConfig != nil && channel.Spec.ChannelConfig.Application != nil {
+		return capabilitiesOrDefault(channel.Spec.ChannelConfig.Application.Capabilities)
+	}
+	return capabilitiesOrDefault(nil)
+}
+
+func desiredCapabilities(channel *hlfv1alpha1.FabricMainChannel, group capabilityGroup) []string {
+	switch group {
+	case ordererCapabilityGroup:
+		return desiredOrdererCapabilities(channel)
+	case channelCapabilityGroup:
+		return desiredChannelCapabilities(channel)
+	default:
+		return desiredApplicationCapabilities(channel)
+	}
+}
+
+func currentCapabilities(configTx configtx.ConfigTx, group capabilityGroup) ([]string, error) {
+	switch group {
+	case ordererCapabilityGroup:
+		return configTx.Orderer().Capabilities()
+	case channelCapabilityGroup:
+		return configTx.Channel().Capabilities()
+	default:
+		return configTx.Application().Capabilities()
+	}
+}
+
+func setCapabilities(configTx configtx.ConfigTx, group capabilityGroup, current []string, desired []string) error {
+	var add func(string) error
+	var remove func(string) error
+	switch group {
+	case ordererCapabilityGroup:
+		add, remove = configTx.Orderer().AddCapability, configTx.Orderer().RemoveCapability
+	case channelCapabilityGroup:
+		add, remove = configTx.Channel().AddCapability, configTx.Channel().RemoveCapability
+	default:
+		add, remove = configTx.Application().AddCapability, configTx.Application().RemoveCapability
+	}
+	for _, capability := range current {
+		if !containsString(desired, capability) {
+			if err := remove(capability); err != nil {
+				return errors.Wrapf(err, "failed to remove %s capability %s", group, capability)
+			}
+		}
+	}
+	for _, capability := range desired {
+		if !containsString(current, capability) {
+			if err := add(capability); err != nil {
+				return errors.Wrapf(err, "failed to add %s capability %s", group, capability)
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+func sameCapabilities(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, capability := range a {
+		if !containsString(b, capability) {
+			return false
+		}
+	}
+	return true
+}
+
+// findOutdatedComponents returns the peers and orderers serving the channel whose image tag is older than
+// the version required by the capabilities that are going to be enabled
+func findOutdatedComponents(
+	ctx context.Context,
+	hlfClientSet *operatorv1.Clientset,
+	channel *hlfv1alpha1.FabricMainChannel,
+	peersVersion *fabricVersion,
+	orderersVersion *fabricVersion,
+) ([]string, error) {
+	var outdated []string
+	checkTag := func(kind string, namespace string, name string, tag string, required fabricVersion) {
+		version, ok := versionFromTag(tag)
+		if !ok {
+			outdated = append(outdated, fmt.Sprintf("%s %s/%s: unable to determine the version of image tag %q, %s required", kind, namespace, name, tag, required))
+			return
+		}
+		if version.less(required) {
+			outdated = append(outdated, fmt.Sprintf("%s %s/%s: image tag %s, %s required", kind, namespace, name, tag, required))
+		}
+	}
+	if peersVersion != nil {
+		peerMSPIDs := map[string]bool{}
+		for _, peerOrg := range channel.Spec.PeerOrganizations {
+			peerMSPIDs[peerOrg.MSPID] = true
+		}
+		peers, err := hlfClientSet.HlfV1alpha1().FabricPeers("").List(ctx, v1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list peers")
+		}
+		for _, peer := range peers.Items {
+			if !peerMSPIDs[peer.Spec.MspID] {
+				continue
+			}
+			checkTag("FabricPeer", peer.Namespace, peer.Name, peer.Spec.Tag, *peersVersion)
+		}
+	}
+	if orderersVersion != nil {
+		ordererMSPIDs := map[string]bool{}
+		orderersToJoin := map[string]bool{}
+		for _, ordererOrg := range channel.Spec.OrdererOrganizations {
+			ordererMSPIDs[ordererOrg.MSPID] = true
+			for _, ordererNode := range ordererOrg.OrderersToJoin {
+				orderersToJoin[fmt.Sprintf("%s/%s", ordererNode.Namespace, ordererNode.Name)] = true
+			}
+		}
+		ordererNodes, err := hlfClientSet.HlfV1alpha1().FabricOrdererNodes("").List(ctx, v1.ListOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list orderer nodes")
+		}
+		for _, ordererNode := range ordererNodes.Items {
+			key := fmt.Sprintf("%s/%s", ordererNode.Namespace, ordererNode.Name)
+			if !ordererMSPIDs[ordererNode.Spec.MspID] && !orderersToJoin[key] {
+				continue
+			}
+			checkTag("FabricOrdererNode", ordererNode.Namespace, ordererNode.Name, ordererNode.Spec.Tag, *orderersVersion)
+		}
+	}
+	sort.Strings(outdated)
+	return outdated, nil
+}
+
+// capabilitySigners returns the MSP IDs whose admins need to sign a change in the capabilities of the group
+func capabilitySigners(channel *hlfv1alpha1.FabricMainChannel, group capabilityGroup) []string {
+	var ordererSigners []string
+	if len(channel.Spec.AdminOrdererOrganizations) > 0 {
+		for _, adminOrg := range channel.Spec.AdminOrdererOrganizations {
+			ordererSigners = append(ordererSigners, adminOrg.MSPID)
+		}
+	} else {
+		for _, ordererOrg := range channel.Spec.OrdererOrganizations {
+			if _, ok := channel.Spec.Identities[ordererOrg.MSPID]; ok {
+				ordererSigners = append(ordererSigners, ordererOrg.MSPID)
+			}
+		}
+	}
+	var applicationSigners []string
+	if len(channel.Spec.AdminPeerOrganizations) > 0 {
+		for _, adminOrg := range channel.Spec.AdminPeerOrganizations {
+			applicationSigners = append(applicationSigners, adminOrg.MSPID)
+		}
+	} else {
+		for _, peerOrg := range channel.Spec.PeerOrganizations {
+			if _, ok := channel.Spec.Identities[peerOrg.MSPID]; ok {
+				applicationSigners = append(applicationSigners, peerOrg.MSPID)
+			}
+		}
+	}
+	switch group {
+	case ordererCapabilityGroup:
+		return ordererSigners
+	case applicationCapabilityGroup:
+		return applicationSigners
+	default:
+		return append(ordererSigners, applicationSigners...)
+	}
+}
+
+type capabilityUpgrader struct {
+	ctx            context.Context
+	channel        *hlfv1alpha1.FabricMainChannel
+	sdk            *fabsdk.FabricSDK
+	resClient      *resmgmt.Client
+	clientSet      *kubernetes.Clientset
+	hlfClientSet   *operatorv1.Clientset
+	resmgmtOptions []resmgmt.RequestOption
+}
+
+// upgradeCapabilities updates the orderer, channel and application capabilities of the channel, in that order,
+// to match the FabricMainChannel spec. It refuses the upgrade when any peer or orderer serving the channel runs a
+// version that doesn't support the new capabilities. A single group is updated on each call, the upgrade is
+// `IN_PROGRESS` until the orderers have applied the new capabilities of every group and the reconcile must be
+// requeued meanwhile.
+func (u *capabilityUpgrader) upgradeCapabilities(currentConfig *cb.Config) (*hlfv1alpha1.FabricMainChannelCapabilityUpgradeStatus, error) {
+	configTx := configtx.New(currentConfig)
+	current := map[capabilityGroup][]string{}
+	changedGroups := []capabilityGroup{}
+	for _, group := range capabilityGroupsOrder {
+		capabilities, err := currentCapabilities(configTx, group)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %s capabilities", group)
+		}
+		current[group] = capabilities
+		if !sameCapabilities(capabilities, desiredCapabilities(u.channel, group)) {
+			changedGroups = append(changedGroups, group)
+		}
+	}
+	upgradeStatus := &hlfv1alpha1.FabricMainChannelCapabilityUpgradeStatus{
+		OrdererCapabilities:     current[ordererCapabilityGroup],
+		ChannelCapabilities:     current[channelCapabilityGroup],
+		ApplicationCapabilities: current[applicationCapabilityGroup],
+	}
+	if u.channel.Status.CapabilityUpgrade != nil {
+		upgradeStatus.Phase = u.channel.Status.CapabilityUpgrade.Phase
+		upgradeStatus.Message = u.channel.Status.CapabilityUpgrade.Message
+		upgradeStatus.LastUpdateTime = u.channel.Status.CapabilityUpgrade.LastUpdateTime
+	}
+	if len(changedGroups) == 0 {
+		if upgradeStatus.Phase == hlfv1alpha1.CapabilityUpgradeInProgressPhase {
+			now := v1.Now()
+			upgradeStatus.LastUpdateTime = &now
+			upgradeStatus.Phase = hlfv1alpha1.CapabilityUpgradeCompletedPhase
+			upgradeStatus.Message = "Capabilities upgraded"
+		}
+		return upgradeStatus, nil
+	}
+	now := v1.Now()
+	group := changedGroups[0]
+	desired := desiredCapabilities(u.channel, group)
+	if capabilityUpgradePending(u.channel.Status.CapabilityUpgrade, group, desired, now.Time) {
+		log.Infof("Waiting for the orderers to apply the %s capabilities %v of channel %s", group, desired, u.channel.Spec.Name)
+		setGroupCapabilities(upgradeStatus, group, desired)
+		return upgradeStatus, nil
+	}
+	upgradeStatus.LastUpdateTime = &now
+	refuse := func(message string, outdated []string) error {
+		upgradeStatus.Phase = hlfv1alpha1.CapabilityUpgradeRefusedPhase
+		upgradeStatus.Message = message
+		upgradeStatus.OutdatedComponents = outdated
+		return errors.New(message)
+	}
+
+	var peersVersion *fabricVersion
+	var orderersVersion *fabricVersion
+	requireVersion := func(target **fabricVersion, version fabricVersion) {
+		if *target == nil || (*target).less(version) {
+			*target = &version
+		}
+	}
+	for _, group := range changedGroups {
+		desired := desiredCapabilities(u.channel, group)
+		desiredVersion, ok := requiredVersion(desired)
+		if !ok {
+			continue
+		}
+		currentVersion, ok := requiredVersion(current[group])
+		if ok && desiredVersion.less(currentVersion) {
+			return upgradeStatus, refuse(
+				fmt.Sprintf("capability upgrade refused: downgrading %s capabilities from %v to %v is not supported", group, current[group], desired),
+				nil,
+			)
+		}
+		switch group {
+		case ordererCapabilityGroup:
+			requireVersion(&orderersVersion, desiredVersion)
+		case applicationCapabilityGroup:
+			requireVersion(&peersVersion, desiredVersion)
+		default:
+			requireVersion(&orderersVersion, desiredVersion)
+			requireVersion(&peersVersion, desiredVersion)
+		}
+	}
+	outdated, err := findOutdatedComponents(u.ctx, u.hlfClientSet, u.channel, peersVersion, orderersVersion)
+	if err != nil {
+		return upgradeStatus, err
+	}
+	if len(outdated) > 0 {
+		return upgradeStatus, refuse(
+			fmt.Sprintf("capability upgrade refused: %d components run a version that doesn't support the new capabilities", len(outdated)),
+			outdated,
+		)
+	}
+	upgradeStatus.OutdatedComponents = nil
+	err = u.updateGroupCapabilities(currentConfig, group, current[group], desired)
+	if err != nil {
+		upgradeStatus.Phase = hlfv1alpha1.CapabilityUpgradeFailedPhase
+		upgradeStatus.Message = err.Error()
+		return upgradeStatus, err
+	}
+	upgradeStatus.Phase = hlfv1alpha1.CapabilityUpgradeInProgressPhase
+	upgradeStatus.Message = fmt.Sprintf("Upgrading %v capabilities", changedGroups)
+	setGroupCapabilities(upgradeStatus, group, desired)
+	return upgradeStatus, nil
+}
+
+// capabilityUpgradePending returns true when the capabilities of the group have been submitted and the orderers
+// haven't applied them yet, the update is resubmitted after capabilityUpgradeTimeout
+func capabilityUpgradePending(upgradeStatus *hlfv1alpha1.FabricMainChannelCapabilityUpgradeStatus, group capabilityGroup, desired []string, now time.Time) bool {
+	if upgradeStatus == nil || upgradeStatus.Phase != hlfv1alpha1.CapabilityUpgradeInProgressPhase || upgradeStatus.LastUpdateTime == nil {
+		return false
+	}
+	if now.Sub(upgradeStatus.LastUpdateTime.Time) > capabilityUpgradeTimeout {
+		return false
+	}
+	var submitted []string
+	switch group {
+	case ordererCapabilityGroup:
+		submitted = upgradeStatus.OrdererCapabilities
+	case channelCapabilityGroup:
+		submitted = upgradeStatus.ChannelCapabilities
+	default:
+		submitted = upgradeStatus.ApplicationCapabilities
+	}
+	return sameCapabilities(submitted, desired)
+}
+
+// setGroupCapabilities sets the capabilities of the group submitted to the channel in the status
+func setGroupCapabilities(upgradeStatus *hlfv1alpha1.FabricMainChannelCapabilityUpgradeStatus, group capabilityGroup, capabilities []string) {
+	switch group {
+	case ordererCapabilityGroup:
+		upgradeStatus.OrdererCapabilities = capabilities
+	case channelCapabilityGroup:
+		upgradeStatus.ChannelCapabilities = capabilities
+	default:
+		upgradeStatus.ApplicationCapabilities = capabilities
+	}
+}
+
+func (u *capabilityUpgrader) updateGroupCapabilities(currentConfig *cb.Config, group capabilityGroup, current []string, desired []string) error {
+	channelName := u.channel.Spec.Name
+	configTx := configtx.New(currentConfig)
+	err := setCapabilities(configTx, group, current, desired)
+	if err != nil {
+		return err
+	}
+	configUpdate, err := resmgmt.CalculateConfigUpdate(channelName, currentConfig, configTx.UpdatedConfig())
+	if err != nil {
+		return errors.Wrapf(err, "error calculating config update for %s capabilities", group)
+	}
+	channelConfigBytes, err := CreateConfigUpdateEnvelope(channelName, configUpdate)
+	if err != nil {
+		return errors.Wrapf(err, "error creating config update envelope")
+	}
+	configSignatures, err := signConfigUpdate(u.ctx, u.sdk, u.clientSet, u.resClient, u.channel, capabilitySigners(u.channel, group), channelConfigBytes)
+	if err != nil {
+		return err
+	}
+	saveChannelOpts := []resmgmt.RequestOption{
+		resmgmt.WithConfigSignatures(configSignatures...),
+	}
+	saveChannelOpts = append(saveChannelOpts, u.resmgmtOptions...)
+	saveChannelResponse, err := u.resClient.SaveChannel(
+		resmgmt.SaveChannelRequest{
+			ChannelID:         channelName,
+			ChannelConfig:     bytes.NewReader(channelConfigBytes),
+			SigningIdentities: []msp.SigningIdentity{},
+		},
+		saveChannelOpts...,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "error updating %s capabilities to %v", group, desired)
+	}
+	log.Infof("Channel %s %s capabilities updated to %v with transaction ID: %s", channelName, group, desired, saveChannelResponse.TransactionID)
+	return nil
+}
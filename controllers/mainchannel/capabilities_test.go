@@ -0,0 +1,49 @@
+package mainchannel
+
+import (
+	"testing"
+	"time"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCapabilityUpgradePending(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Now()
+	submittedAt := v1.NewTime(now.Add(-10 * time.Second))
+	upgradeStatus := &hlfv1alpha1.FabricMainChannelCapabilityUpgradeStatus{
+		Phase:                   hlfv1alpha1.CapabilityUpgradeInProgressPhase,
+		OrdererCapabilities:     []string{"V2_0"},
+		ChannelCapabilities:     []string{"V3_0"},
+		ApplicationCapabilities: []string{"V2_0"},
+		LastUpdateTime:          &submittedAt,
+	}
+
+	g.Expect(capabilityUpgradePending(nil, channelCapabilityGroup, []string{"V3_0"}, now)).To(BeFalse())
+	g.Expect(capabilityUpgradePending(upgradeStatus, channelCapabilityGroup, []string{"V3_0"}, now)).To(BeTrue())
+	// the capabilities of another group were submitted
+	g.Expect(capabilityUpgradePending(upgradeStatus, applicationCapabilityGroup, []string{"V2_5"}, now)).To(BeFalse())
+	// the orderers didn't apply the update in time, it's resubmitted
+	g.Expect(capabilityUpgradePending(upgradeStatus, channelCapabilityGroup, []string{"V3_0"}, now.Add(capabilityUpgradeTimeout))).To(BeFalse())
+
+	upgradeStatus.Phase = hlfv1alpha1.CapabilityUpgradeFailedPhase
+	g.Expect(capabilityUpgradePending(upgradeStatus, channelCapabilityGroup, []string{"V3_0"}, now)).To(BeFalse())
+	upgradeStatus.Phase = hlfv1alpha1.CapabilityUpgradeInProgressPhase
+	upgradeStatus.LastUpdateTime = nil
+	g.Expect(capabilityUpgradePending(upgradeStatus, channelCapabilityGroup, []string{"V3_0"}, now)).To(BeFalse())
+}
+
+func TestSetGroupCapabilities(t *testing.T) {
+	g := NewWithT(t)
+	upgradeStatus := &hlfv1alpha1.FabricMainChannelCapabilityUpgradeStatus{}
+	setGroupCapabilities(upgradeStatus, ordererCapabilityGroup, []string{"V2_0"})
+	setGroupCapabilities(upgradeStatus, channelCapabilityGroup, []string{"V3_0"})
+	setGroupCapabilities(upgradeStatus, applicationCapabilityGroup, []string{"V2_5"})
+	g.Expect(upgradeStatus).To(Equal(&hlfv1alpha1.FabricMainChannelCapabilityUpgradeStatus{
+		OrdererCapabilities:     []string{"V2_0"},
+		ChannelCapabilities:     []string{"V3_0"},
+		ApplicationCapabilities: []string{"V2_5"},
+	}))
+}
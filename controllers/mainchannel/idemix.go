@@ -0,0 +1,281 @@
+package mainchannel
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-config/configtx"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mb "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric/common/policydsl"
+	"github.com/hyperledger/fabric/protoutil"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	applicationGroupKey = "Application"
+	mspValueKey         = "MSP"
+	idemixMSPType       = 1
+	// the admins of an Idemix organization sign anonymously, so its configuration is managed by the admins of the
+	// application group
+	idemixOrgModPolicy = "/Channel/Application/Admins"
+)
+
+// idemixOrg is an Idemix organization of the channel with the issuer keys resolved
+type idemixOrg struct {
+	mspID               string
+	issuerPublicKey     []byte
+	revocationPublicKey []byte
+	policies            map[string]configtx.Policy
+}
+
+// resolveIdemixOrgs returns the Idemix organizations of the channel with the issuer keys of their FabricCA or the ones
+// set in the spec
+func resolveIdemixOrgs(ctx context.Context, hlfClientSet operatorv1.Interface, channel *hlfv1alpha1.FabricMainChannel) ([]idemixOrg, error) {
+	var orgs []idemixOrg
+	for _, org := range channel.Spec.IdemixOrganizations {
+		ipk := org.IssuerPublicKey
+		revocationPK := org.RevocationPublicKey
+		if org.CAName != "" {
+			fabricCA, err := hlfClientSet.HlfV1alpha1().FabricCAs(org.CANamespace).Get(ctx, org.CAName, v1.GetOptions{})
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get FabricCA %s/%s", org.CANamespace, org.CAName)
+			}
+			if fabricCA.Status.IssuerPublicKey == "" {
+				return nil, errors.Errorf("FabricCA %s/%s has no Idemix issuer keys yet", org.CANamespace, org.CAName)
+			}
+			ipk = fabricCA.Status.IssuerPublicKey
+			revocationPK = fabricCA.Status.IssuerRevocationPublicKey
+		}
+		if ipk == "" || revocationPK == "" {
+			return nil, errors.Errorf("Idemix organization %s needs a FabricCA or the issuer public key and revocation public key", org.MSPID)
+		}
+		ipkBytes, err := base64.StdEncoding.DecodeString(ipk)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid issuer public key of organization %s", org.MSPID)
+		}
+		orgs = append(orgs, idemixOrg{
+			mspID:               org.MSPID,
+			issuerPublicKey:     ipkBytes,
+			revocationPublicKey: []byte(revocationPK),
+			policies:            mapOrgPolicies(org.MSPID, org.Policies),
+		})
+	}
+	return orgs, nil
+}
+
+// mapPolicy converts a policy of the spec to its proto, signature rules are written with the policy DSL and implicit
+// meta rules as `<ANY|ALL|MAJORITY> <sub policy>`
+func mapPolicy(policy configtx.Policy) (*cb.ConfigPolicy, error) {
+	modPolicy := policy.ModPolicy
+	if modPolicy == "" {
+		modPolicy = idemixOrgModPolicy
+	}
+	switch policy.Type {
+	case configtx.SignaturePolicyType:
+		envelope, err := policydsl.FromString(policy.Rule)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid signature policy %s", policy.Rule)
+		}
+		value, err := proto.Marshal(envelope)
+		if err != nil {
+			return nil, err
+		}
+		return &cb.ConfigPolicy{
+			Policy:    &cb.Policy{Type: int32(cb.Policy_SIGNATURE), Value: value},
+			ModPolicy: modPolicy,
+		}, nil
+	case configtx.ImplicitMetaPolicyType:
+		args := strings.Fields(policy.Rule)
+		if len(args) != 2 {
+			return nil, errors.Errorf("invalid implicit meta policy %s", policy.Rule)
+		}
+		rule, ok := cb.ImplicitMetaPolicy_Rule_value[args[0]]
+		if !ok {
+			return nil, errors.Errorf("invalid implicit meta policy rule %s", args[0])
+		}
+		value, err := proto.Marshal(&cb.ImplicitMetaPolicy{
+			Rule:      cb.ImplicitMetaPolicy_Rule(rule),
+			SubPolicy: args[1],
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &cb.ConfigPolicy{
+			Policy:    &cb.Policy{Type: int32(cb.Policy_IMPLICIT_META), Value: value},
+			ModPolicy: modPolicy,
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported policy type %s", policy.Type)
+	}
+}
+
+// newIdemixOrgGroup returns the config group of an Idemix organization in the application group
+func newIdemixOrgGroup(org idemixOrg) (*cb.ConfigGroup, error) {
+	idemixConfig, err := proto.Marshal(&mb.IdemixMSPConfig{
+		Name:         org.mspID,
+		Ipk:          org.issuerPublicKey,
+		RevocationPk: org.revocationPublicKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	mspConfig, err := proto.Marshal(&mb.MSPConfig{
+		Type:   idemixMSPType,
+		Config: idemixConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	group := protoutil.NewConfigGroup()
+	group.ModPolicy = idemixOrgModPolicy
+	group.Values[mspValueKey] = &cb.ConfigValue{
+		Value:     mspConfig,
+		ModPolicy: idemixOrgModPolicy,
+	}
+	for name, policy := range org.policies {
+		group.Policies[name], err = mapPolicy(policy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to map policy %s of organization %s", name, org.mspID)
+		}
+	}
+	return group, nil
+}
+
+func isIdemixOrgGroup(group *cb.ConfigGroup) (bool, error) {
+	value, ok := group.Values[mspValueKey]
+	if !ok {
+		return false, nil
+	}
+	mspConfig := &mb.MSPConfig{}
+	err := proto.Unmarshal(value.Value, mspConfig)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to unmarshal MSP config")
+	}
+	return mspConfig.Type == idemixMSPType, nil
+}
+
+// detachIdemixOrgs removes the Idemix organizations from the application group of the config and returns them by
+// MSP ID, configtx only reads X.509 MSPs so they are detached while configtx updates the application group
+func detachIdemixOrgs(config *cb.Config) (map[string]*cb.ConfigGroup, error) {
+	detached := map[string]*cb.ConfigGroup{}
+	if config.ChannelGroup == nil {
+		return detached, nil
+	}
+	applicationGroup, ok := config.ChannelGroup.Groups[applicationGroupKey]
+	if !ok {
+		return detached, nil
+	}
+	for mspID, group := range applicationGroup.Groups {
+		isIdemix, err := isIdemixOrgGroup(group)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read MSP of organization %s", mspID)
+		}
+		if isIdemix {
+			detached[mspID] = group
+			delete(applicationGroup.Groups, mspID)
+		}
+	}
+	return detached, nil
+}
+
+// idemixOrgGroupEqual returns true if the MSP and policies of both groups are the same, regardless of their versions
+func idemixOrgGroupEqual(current *cb.ConfigGroup, desired *cb.ConfigGroup) bool {
+	if current.ModPolicy != desired.ModPolicy || len(current.Values) != len(desired.Values) || len(current.Policies) != len(desired.Policies) {
+		return false
+	}
+	for key, desiredValue := range desired.Values {
+		currentValue, ok := current.Values[key]
+		if !ok || currentValue.ModPolicy != desiredValue.ModPolicy || !bytes.Equal(currentValue.Value, desiredValue.Value) {
+			return false
+		}
+	}
+	for name, desiredPolicy := range desired.Policies {
+		currentPolicy, ok := current.Policies[name]
+		if !ok || currentPolicy.ModPolicy != desiredPolicy.ModPolicy || !proto.Equal(currentPolicy.Policy, desiredPolicy.Policy) {
+			return false
+		}
+	}
+	return true
+}
+
+// setIdemixOrgs sets the Idemix organizations in the application group of the config, the detached organizations
+// that didn't change are restored as they were and the ones not in the spec are removed
+func setIdemixOrgs(config *cb.Config, orgs []idemixOrg, detached map[string]*cb.ConfigGroup) error {
+	if config.ChannelGroup == nil {
+		return errors.New("config has no channel group")
+	}
+	applicationGroup, ok := config.ChannelGroup.Groups[applicationGroupKey]
+	if !ok {
+		return errors.New("config has no application group")
+	}
+	for _, org := range orgs {
+		if _, ok := applicationGroup.Groups[org.mspID]; ok {
+			return errors.Errorf("organization %s is both an Idemix and an X.509 organization", org.mspID)
+		}
+		group, err := newIdemixOrgGroup(org)
+		if err != nil {
+			return err
+		}
+		current, ok := detached[org.mspID]
+		switch {
+		case !ok:
+			log.Infof("Adding Idemix organization %s", org.mspID)
+		case idemixOrgGroupEqual(current, group):
+			group = current
+		default:
+			log.Infof("Updating Idemix organization %s", org.mspID)
+		}
+		applicationGroup.Groups[org.mspID] = group
+		delete(detached, org.mspID)
+	}
+	for mspID := range detached {
+		log.Infof("Removing Idemix organization %s", mspID)
+	}
+	return nil
+}
+
+// setBlockIdemixOrgs adds the Idemix organizations to the application group of the genesis block of a channel
+func setBlockIdemixOrgs(block *cb.Block, orgs []idemixOrg) error {
+	if block.Data == nil || len(block.Data.Data) != 1 {
+		return errors.New("the block is not a config block")
+	}
+	envelope, err := protoutil.UnmarshalEnvelope(block.Data.Data[0])
+	if err != nil {
+		return err
+	}
+	payload, err := protoutil.UnmarshalPayload(envelope.Payload)
+	if err != nil {
+		return err
+	}
+	configEnvelope := &cb.ConfigEnvelope{}
+	err = proto.Unmarshal(payload.Data, configEnvelope)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal config envelope")
+	}
+	err = setIdemixOrgs(configEnvelope.Config, orgs, map[string]*cb.ConfigGroup{})
+	if err != nil {
+		return err
+	}
+	payload.Data, err = proto.Marshal(configEnvelope)
+	if err != nil {
+		return err
+	}
+	envelope.Payload, err = proto.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	block.Data.Data[0], err = proto.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	block.Header.DataHash = protoutil.BlockDataHash(block.Data)
+	return nil
+}
@@ -0,0 +1,237 @@
+package mainchannel
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-config/configtx"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mb "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric/protoutil"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	hlffake "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testRevocationPK = "-----BEGIN PUBLIC KEY-----\nrevocation\n-----END PUBLIC KEY-----\n"
+
+func newTestIdemixOrg(mspID string, ipk string) idemixOrg {
+	return idemixOrg{
+		mspID:               mspID,
+		issuerPublicKey:     []byte(ipk),
+		revocationPublicKey: []byte(testRevocationPK),
+		policies:            mapOrgPolicies(mspID, nil),
+	}
+}
+
+func TestResolveIdemixOrgs(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	fabricCA := &hlfv1alpha1.FabricCA{
+		ObjectMeta: v1.ObjectMeta{Name: "org2-ca", Namespace: "default"},
+		Status: hlfv1alpha1.FabricCAStatus{
+			IssuerPublicKey:           base64.StdEncoding.EncodeToString([]byte("org2-ipk")),
+			IssuerRevocationPublicKey: testRevocationPK,
+		},
+	}
+	pendingCA := &hlfv1alpha1.FabricCA{ObjectMeta: v1.ObjectMeta{Name: "org4-ca", Namespace: "default"}}
+	hlfClientSet := hlffake.NewSimpleClientset(fabricCA, pendingCA)
+	channel := &hlfv1alpha1.FabricMainChannel{
+		Spec: hlfv1alpha1.FabricMainChannelSpec{
+			IdemixOrganizations: []hlfv1alpha1.FabricMainChannelIdemixOrganization{
+				{MSPID: "Org2MSP", CAName: "org2-ca", CANamespace: "default"},
+				{
+					MSPID:               "Org3MSP",
+					IssuerPublicKey:     base64.StdEncoding.EncodeToString([]byte("org3-ipk")),
+					RevocationPublicKey: testRevocationPK,
+					Policies: &map[string]hlfv1alpha1.FabricMainChannelPoliciesConfig{
+						"Writers": {Type: "ImplicitMeta", Rule: "ANY Readers"},
+					},
+				},
+			},
+		},
+	}
+	orgs, err := resolveIdemixOrgs(ctx, hlfClientSet, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(orgs).To(HaveLen(2))
+	// the keys of the FabricCA are read from its status
+	g.Expect(orgs[0].mspID).To(Equal("Org2MSP"))
+	g.Expect(orgs[0].issuerPublicKey).To(Equal([]byte("org2-ipk")))
+	g.Expect(orgs[0].revocationPublicKey).To(Equal([]byte(testRevocationPK)))
+	g.Expect(orgs[0].policies).To(Equal(mapOrgPolicies("Org2MSP", nil)))
+	g.Expect(orgs[1].issuerPublicKey).To(Equal([]byte("org3-ipk")))
+	g.Expect(orgs[1].policies["Writers"]).To(Equal(configtx.Policy{Type: "ImplicitMeta", Rule: "ANY Readers"}))
+	g.Expect(orgs[1].policies["Admins"]).To(Equal(configtx.Policy{Type: "Signature", Rule: "OR('Org3MSP.admin')"}))
+
+	tests := []struct {
+		name string
+		org  hlfv1alpha1.FabricMainChannelIdemixOrganization
+		err  string
+	}{
+		{
+			name: "missing FabricCA",
+			org:  hlfv1alpha1.FabricMainChannelIdemixOrganization{MSPID: "Org5MSP", CAName: "org5-ca", CANamespace: "default"},
+			err:  "failed to get FabricCA default/org5-ca",
+		},
+		{
+			name: "FabricCA without issuer keys",
+			org:  hlfv1alpha1.FabricMainChannelIdemixOrganization{MSPID: "Org4MSP", CAName: "org4-ca", CANamespace: "default"},
+			err:  "FabricCA default/org4-ca has no Idemix issuer keys yet",
+		},
+		{
+			name: "no issuer",
+			org:  hlfv1alpha1.FabricMainChannelIdemixOrganization{MSPID: "Org4MSP", IssuerPublicKey: "aXBr"},
+			err:  "Idemix organization Org4MSP needs a FabricCA or the issuer public key and revocation public key",
+		},
+		{
+			name: "issuer public key not base64",
+			org:  hlfv1alpha1.FabricMainChannelIdemixOrganization{MSPID: "Org4MSP", IssuerPublicKey: "not base64", RevocationPublicKey: testRevocationPK},
+			err:  "invalid issuer public key of organization Org4MSP",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			channel := &hlfv1alpha1.FabricMainChannel{
+				Spec: hlfv1alpha1.FabricMainChannelSpec{IdemixOrganizations: []hlfv1alpha1.FabricMainChannelIdemixOrganization{tt.org}},
+			}
+			_, err := resolveIdemixOrgs(ctx, hlfClientSet, channel)
+			g.Expect(err).To(MatchError(ContainSubstring(tt.err)))
+		})
+	}
+}
+
+func TestMapPolicy(t *testing.T) {
+	g := NewWithT(t)
+	policy, err := mapPolicy(configtx.Policy{Type: configtx.SignaturePolicyType, Rule: "OR('Org2MSP.member')"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(policy.ModPolicy).To(Equal("/Channel/Application/Admins"))
+	g.Expect(policy.Policy.Type).To(Equal(int32(cb.Policy_SIGNATURE)))
+	envelope := &cb.SignaturePolicyEnvelope{}
+	g.Expect(proto.Unmarshal(policy.Policy.Value, envelope)).To(Succeed())
+	g.Expect(envelope.Identities).To(HaveLen(1))
+
+	policy, err = mapPolicy(configtx.Policy{Type: configtx.ImplicitMetaPolicyType, Rule: "MAJORITY Admins", ModPolicy: "Admins"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(policy.ModPolicy).To(Equal("Admins"))
+	g.Expect(policy.Policy.Type).To(Equal(int32(cb.Policy_IMPLICIT_META)))
+	implicitMeta := &cb.ImplicitMetaPolicy{}
+	g.Expect(proto.Unmarshal(policy.Policy.Value, implicitMeta)).To(Succeed())
+	g.Expect(implicitMeta.Rule).To(Equal(cb.ImplicitMetaPolicy_MAJORITY))
+	g.Expect(implicitMeta.SubPolicy).To(Equal("Admins"))
+
+	_, err = mapPolicy(configtx.Policy{Type: configtx.SignaturePolicyType, Rule: "OR('Org2MSP.member'"})
+	g.Expect(err).To(MatchError(ContainSubstring("invalid signature policy")))
+	_, err = mapPolicy(configtx.Policy{Type: configtx.ImplicitMetaPolicyType, Rule: "ANY"})
+	g.Expect(err).To(MatchError("invalid implicit meta policy ANY"))
+	_, err = mapPolicy(configtx.Policy{Type: configtx.ImplicitMetaPolicyType, Rule: "SOME Readers"})
+	g.Expect(err).To(MatchError("invalid implicit meta policy rule SOME"))
+	_, err = mapPolicy(configtx.Policy{Type: "Unknown", Rule: "ANY Readers"})
+	g.Expect(err).To(MatchError("unsupported policy type Unknown"))
+}
+
+func TestNewIdemixOrgGroup(t *testing.T) {
+	g := NewWithT(t)
+	group, err := newIdemixOrgGroup(newTestIdemixOrg("Org2MSP", "org2-ipk"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(group.ModPolicy).To(Equal("/Channel/Application/Admins"))
+	g.Expect(group.Policies).To(HaveKey("Admins"))
+	g.Expect(group.Policies).To(HaveKey("Endorsement"))
+	isIdemix, err := isIdemixOrgGroup(group)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(isIdemix).To(BeTrue())
+
+	mspConfig := &mb.MSPConfig{}
+	g.Expect(proto.Unmarshal(group.Values["MSP"].Value, mspConfig)).To(Succeed())
+	idemixConfig := &mb.IdemixMSPConfig{}
+	g.Expect(proto.Unmarshal(mspConfig.Config, idemixConfig)).To(Succeed())
+	g.Expect(idemixConfig.Name).To(Equal("Org2MSP"))
+	g.Expect(idemixConfig.Ipk).To(Equal([]byte("org2-ipk")))
+	g.Expect(idemixConfig.RevocationPk).To(Equal([]byte(testRevocationPK)))
+
+	org := newTestIdemixOrg("Org2MSP", "org2-ipk")
+	org.policies["Readers"] = configtx.Policy{Type: "Unknown"}
+	_, err = newIdemixOrgGroup(org)
+	g.Expect(err).To(MatchError(ContainSubstring("failed to map policy Readers of organization Org2MSP")))
+}
+
+func TestSetIdemixOrgs(t *testing.T) {
+	g := NewWithT(t)
+	configTx := newTestOrgsConfigTx(g)
+	config := configTx.UpdatedConfig()
+	g.Expect(setIdemixOrgs(config, []idemixOrg{
+		newTestIdemixOrg("Org2MSP", "org2-ipk"),
+		newTestIdemixOrg("Org3MSP", "org3-ipk"),
+	}, map[string]*cb.ConfigGroup{})).To(Succeed())
+	applicationGroup := config.ChannelGroup.Groups["Application"]
+	g.Expect(applicationGroup.Groups).To(HaveKey("Org1MSP"))
+	g.Expect(applicationGroup.Groups).To(HaveKey("Org2MSP"))
+	g.Expect(applicationGroup.Groups).To(HaveKey("Org3MSP"))
+
+	// configtx only sees the X.509 organizations
+	detached, err := detachIdemixOrgs(config)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(detached).To(HaveLen(2))
+	g.Expect(applicationGroup.Groups).To(HaveLen(1))
+	g.Expect(applicationGroup.Groups).To(HaveKey("Org1MSP"))
+	detachedConfigTx := configtx.New(config)
+	_, err = detachedConfigTx.Application().Organization("Org1MSP").MSP().Configuration()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// the unchanged organization keeps its version, the updated one is replaced and the one not in the spec is removed
+	detached["Org2MSP"].Version = 3
+	unchanged := detached["Org2MSP"]
+	g.Expect(setIdemixOrgs(config, []idemixOrg{
+		newTestIdemixOrg("Org2MSP", "org2-ipk"),
+		newTestIdemixOrg("Org4MSP", "org4-ipk"),
+	}, detached)).To(Succeed())
+	g.Expect(applicationGroup.Groups).To(HaveLen(3))
+	g.Expect(applicationGroup.Groups["Org2MSP"]).To(BeIdenticalTo(unchanged))
+	g.Expect(applicationGroup.Groups).To(HaveKey("Org4MSP"))
+	g.Expect(applicationGroup.Groups).NotTo(HaveKey("Org3MSP"))
+
+	detached, err = detachIdemixOrgs(config)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(setIdemixOrgs(config, []idemixOrg{newTestIdemixOrg("Org2MSP", "org2-new-ipk")}, detached)).To(Succeed())
+	g.Expect(applicationGroup.Groups["Org2MSP"]).NotTo(BeIdenticalTo(unchanged))
+	g.Expect(idemixOrgGroupEqual(unchanged, applicationGroup.Groups["Org2MSP"])).To(BeFalse())
+
+	// an MSP ID can't be both an Idemix and an X.509 organization
+	err = setIdemixOrgs(config, []idemixOrg{newTestIdemixOrg("Org1MSP", "org1-ipk")}, map[string]*cb.ConfigGroup{})
+	g.Expect(err).To(MatchError("organization Org1MSP is both an Idemix and an X.509 organization"))
+	err = setIdemixOrgs(&cb.Config{ChannelGroup: newTestGroup()}, nil, map[string]*cb.ConfigGroup{})
+	g.Expect(err).To(MatchError("config has no application group"))
+}
+
+func TestSetBlockIdemixOrgs(t *testing.T) {
+	g := NewWithT(t)
+	configTx := newTestOrgsConfigTx(g)
+	config := configTx.UpdatedConfig()
+	configEnvelope, err := proto.Marshal(&cb.ConfigEnvelope{Config: config})
+	g.Expect(err).NotTo(HaveOccurred())
+	payload, err := proto.Marshal(&cb.Payload{Header: &cb.Header{}, Data: configEnvelope})
+	g.Expect(err).NotTo(HaveOccurred())
+	envelope, err := proto.Marshal(&cb.Envelope{Payload: payload})
+	g.Expect(err).NotTo(HaveOccurred())
+	block := protoutil.NewBlock(0, nil)
+	block.Data.Data = [][]byte{envelope}
+
+	g.Expect(setBlockIdemixOrgs(block, []idemixOrg{newTestIdemixOrg("Org2MSP", "org2-ipk")})).To(Succeed())
+	g.Expect(block.Header.DataHash).To(Equal(protoutil.BlockDataHash(block.Data)))
+	updatedConfig, err := protoutil.ExtractEnvelope(block, 0)
+	g.Expect(err).NotTo(HaveOccurred())
+	updatedPayload, err := protoutil.UnmarshalPayload(updatedConfig.Payload)
+	g.Expect(err).NotTo(HaveOccurred())
+	updatedEnvelope := &cb.ConfigEnvelope{}
+	g.Expect(proto.Unmarshal(updatedPayload.Data, updatedEnvelope)).To(Succeed())
+	applicationGroup := updatedEnvelope.Config.ChannelGroup.Groups["Application"]
+	g.Expect(applicationGroup.Groups).To(HaveKey("Org1MSP"))
+	isIdemix, err := isIdemixOrgGroup(applicationGroup.Groups["Org2MSP"])
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(isIdemix).To(BeTrue())
+
+	g.Expect(setBlockIdemixOrgs(protoutil.NewBlock(1, nil), nil)).To(MatchError("the block is not a config block"))
+}
@@ -19,34 +19,30 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
-	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
-	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
-	"github.com/hyperledger/fabric-sdk-go/pkg/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fab/resource"
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
-	mspimpl "github.com/hyperledger/fabric-sdk-go/pkg/msp"
 	"github.com/hyperledger/fabric/protoutil"
 	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
 	"github.com/kfsoftware/hlf-operator/controllers/utils"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/osnadmin"
+	"github.com/kfsoftware/hlf-operator/pkg/bft"
 	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
 	"github.com/kfsoftware/hlf-operator/pkg/nc"
 	"github.com/kfsoftware/hlf-operator/pkg/status"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v3"
 	"io/ioutil"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
-	"strings"
 	"time"
 )
 
@@ -139,6 +135,25 @@ func (r *FabricMainChannelReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 	}
+	if isBFT(fabricMainChannel) {
+		err = r.setBFTConsensus(ctx, block, fabricMainChannel)
+		if err != nil {
+			r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "failed to set BFT consensus"), false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
+		}
+	}
+	idemixOrgs, err := resolveIdemixOrgs(ctx, hlfClientSet, fabricMainChannel)
+	if err != nil {
+		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
+	}
+	if len(idemixOrgs) > 0 {
+		err = setBlockIdemixOrgs(block, idemixOrgs)
+		if err != nil {
+			r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "failed to add the Idemix organizations"), false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
+		}
+	}
 	blockBytes, err := proto.Marshal(block)
 	if err != nil {
 		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
@@ -174,18 +189,7 @@ func (r *FabricMainChannelReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, fmt.Errorf("identity not found for MSPID %s", ordererOrg.MSPID), false)
 			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 		}
-		secret, err := clientSet.CoreV1().Secrets(idConfig.SecretNamespace).Get(ctx, idConfig.SecretName, v1.GetOptions{})
-		if err != nil {
-			r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-		}
-		id := &identity{}
-		secretData, ok := secret.Data[idConfig.SecretKey]
-		if !ok {
-			r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, fmt.Errorf("secret key %s not found", idConfig.SecretKey), false)
-			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-		}
-		err = yaml.Unmarshal(secretData, id)
+		id, err := utils.GetIdentity(ctx, clientSet, utils.MainChannelIdentity(idConfig))
 		if err != nil {
 			r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
 			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
@@ -293,66 +297,31 @@ func (r *FabricMainChannelReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			}
 		}
 	}
-	ncResponse, err := nc.GenerateNetworkConfig(fabricMainChannel, clientSet, hlfClientSet, "")
-	if err != nil {
-		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "failed to generate network config"), false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-	}
-	log.Infof("Generated network config: %s", ncResponse.NetworkConfig)
-	configBackend := config.FromRaw([]byte(ncResponse.NetworkConfig), "yaml")
-	sdk, err := fabsdk.New(configBackend)
-	if err != nil {
-		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-	}
 	firstAdminOrgMSPID := fabricMainChannel.Spec.AdminPeerOrganizations[0].MSPID
 	idConfig, ok := fabricMainChannel.Spec.Identities[firstAdminOrgMSPID]
 	if !ok {
 		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, fmt.Errorf("identity not found for MSPID %s", firstAdminOrgMSPID), false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 	}
-	secret, err := clientSet.CoreV1().Secrets(idConfig.SecretNamespace).Get(ctx, idConfig.SecretName, v1.GetOptions{})
-	if err != nil {
-		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-	}
-	secretData, ok := secret.Data[idConfig.SecretKey]
-	if !ok {
-		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, fmt.Errorf("secret key %s not found", idConfig.SecretKey), false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-	}
-	id := &identity{}
-	err = yaml.Unmarshal(secretData, id)
-	if err != nil {
-		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-	}
-	sdkConfig, err := sdk.Config()
+	clientTLS, err := nc.GetClientTLS(ctx, clientSet, idConfig.ClientTLS)
 	if err != nil {
 		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 	}
-	cryptoConfig := cryptosuite.ConfigFromBackend(sdkConfig)
-	cryptoSuite, err := sw.GetSuiteByConfig(cryptoConfig)
+	ncResponse, err := nc.GenerateNetworkConfig(fabricMainChannel, clientSet, hlfClientSet, "", clientTLS)
 	if err != nil {
-		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-	}
-	userStore := mspimpl.NewMemoryUserStore()
-	endpointConfig, err := fab.ConfigFromBackend(sdkConfig)
-	if err != nil {
-		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
+		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "failed to generate network config"), false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 	}
-	identityManager, err := mspimpl.NewIdentityManager(firstAdminOrgMSPID, userStore, cryptoSuite, endpointConfig)
+	// the network config holds the client TLS private key when client auth is used, so it's only logged in debug
+	log.Debugf("Generated network config: %s", ncResponse.NetworkConfig)
+	configBackend := config.FromRaw([]byte(ncResponse.NetworkConfig), "yaml")
+	sdk, err := fabsdk.New(configBackend)
 	if err != nil {
 		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 	}
-	signingIdentity, err := identityManager.CreateSigningIdentity(
-		msp.WithPrivateKey([]byte(id.Key.Pem)),
-		msp.WithCert([]byte(id.Cert.Pem)),
-	)
+	signingIdentity, err := utils.GetSigningIdentity(ctx, sdk, clientSet, firstAdminOrgMSPID, utils.MainChannelIdentity(idConfig))
 	if err != nil {
 		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
@@ -398,6 +367,30 @@ func (r *FabricMainChannelReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "failed to extract config from channel block"), false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 	}
+	upgrader := &capabilityUpgrader{
+		ctx:            ctx,
+		channel:        fabricMainChannel,
+		sdk:            sdk,
+		resClient:      resClient,
+		clientSet:      clientSet,
+		hlfClientSet:   hlfClientSet,
+		resmgmtOptions: resmgmtOptions,
+	}
+	capabilityUpgrade, err := upgrader.upgradeCapabilities(cfgBlock)
+	if err != nil {
+		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
+		fabricMainChannel.Status.CapabilityUpgrade = capabilityUpgrade
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
+	}
+	fabricMainChannel.Status.CapabilityUpgrade = capabilityUpgrade
+	if capabilityUpgrade.Phase == hlfv1alpha1.CapabilityUpgradeInProgressPhase {
+		// the rest of the channel is updated once the orderers have applied the new capabilities
+		result, err := r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
+		if err != nil {
+			return result, err
+		}
+		return ctrl.Result{RequeueAfter: capabilityUpgradeRequeueAfter}, nil
+	}
 	currentConfigTx := configtx.New(cfgBlock)
 	newConfigTx, err := r.mapToConfigTX(fabricMainChannel)
 	if err != nil {
@@ -405,101 +398,51 @@ func (r *FabricMainChannelReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 	}
 	var buf2 bytes.Buffer
-	err = protolator.DeepMarshalJSON(&buf2, cfgBlock)
+	err = protolator.DeepMarshalJSON(&buf2, bft.WithoutBFTMetadata(cfgBlock))
 	if err != nil {
 		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "error converting block to JSON"), false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 	}
 	r.Log.Info(fmt.Sprintf("Config block main channel: %s", buf2.String()))
 	r.Log.Info(fmt.Sprintf("ConfigTX: %v", newConfigTx))
-	err = updateApplicationChannelConfigTx(currentConfigTx, newConfigTx)
+	changedOrgs, err := buildConfigUpdate(ctx, clientSet, hlfClientSet, fabricMainChannel, newConfigTx, idemixOrgs, currentConfigTx)
 	if err != nil {
-		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "failed to update application channel config"), false)
+		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 	}
-	configUpdate, err := resmgmt.CalculateConfigUpdate(fabricMainChannel.Spec.Name, cfgBlock, currentConfigTx.UpdatedConfig())
+	// the consenter IDs are recorded before the update is submitted, so they are never assigned to another orderer node
+	err = recordConsenterIDs(ctx, hlfClientSet, fabricMainChannel, cfgBlock, currentConfigTx.UpdatedConfig())
 	if err != nil {
-		if !strings.Contains(err.Error(), "no differences detected between original and updated config") {
-			r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "error calculating config update"), false)
-			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
+		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
+	}
+	// the anchor peers, policies and MSP of an organization can only be modified by the admins of the organization
+	signerMSPIDs := []string{}
+	for _, adminPeer := range fabricMainChannel.Spec.AdminPeerOrganizations {
+		signerMSPIDs = append(signerMSPIDs, adminPeer.MSPID)
+	}
+	for _, mspID := range changedOrgs {
+		if _, ok := fabricMainChannel.Spec.Identities[mspID]; ok && !utils.Contains(signerMSPIDs, mspID) {
+			signerMSPIDs = append(signerMSPIDs, mspID)
 		}
+	}
+	if proto.Equal(cfgBlock, currentConfigTx.UpdatedConfig()) {
 		log.Infof("No differences detected between original and updated config")
 	} else {
+		configUpdate, err := resmgmt.CalculateConfigUpdate(fabricMainChannel.Spec.Name, cfgBlock, currentConfigTx.UpdatedConfig())
+		if err != nil {
+			r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "error calculating config update"), false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
+		}
 		channelConfigBytes, err := CreateConfigUpdateEnvelope(fabricMainChannel.Spec.Name, configUpdate)
 		if err != nil {
 			r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "error creating config update envelope"), false)
 			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 		}
-		var configSignatures []*cb.ConfigSignature
-		for _, adminPeer := range fabricMainChannel.Spec.AdminPeerOrganizations {
-			configUpdateReader := bytes.NewReader(channelConfigBytes)
-			idConfig, ok := fabricMainChannel.Spec.Identities[adminPeer.MSPID]
-			if !ok {
-				r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, fmt.Errorf("identity not found for MSPID %s", adminPeer.MSPID), false)
-				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-			}
-			secret, err := clientSet.CoreV1().Secrets(idConfig.SecretNamespace).Get(ctx, idConfig.SecretName, v1.GetOptions{})
-			if err != nil {
-				r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-			}
-			secretData, ok := secret.Data[idConfig.SecretKey]
-			if !ok {
-				r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, fmt.Errorf("secret key %s not found", idConfig.SecretKey), false)
-				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-			}
-			id := &identity{}
-			err = yaml.Unmarshal(secretData, id)
-			if err != nil {
-				r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-			}
-			sdkConfig, err := sdk.Config()
-			if err != nil {
-				r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-			}
-			cryptoConfig := cryptosuite.ConfigFromBackend(sdkConfig)
-			cryptoSuite, err := sw.GetSuiteByConfig(cryptoConfig)
-			if err != nil {
-				r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-			}
-			userStore := mspimpl.NewMemoryUserStore()
-			endpointConfig, err := fab.ConfigFromBackend(sdkConfig)
-			if err != nil {
-				r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-			}
-			identityManager, err := mspimpl.NewIdentityManager(adminPeer.MSPID, userStore, cryptoSuite, endpointConfig)
-			if err != nil {
-				r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-			}
-			signingIdentity, err := identityManager.CreateSigningIdentity(
-				msp.WithPrivateKey([]byte(id.Key.Pem)),
-				msp.WithCert([]byte(id.Cert.Pem)),
-			)
-			if err != nil {
-				r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-			}
-
-			sdkContext := sdk.Context(
-				fabsdk.WithIdentity(signingIdentity),
-				fabsdk.WithOrg(adminPeer.MSPID),
-			)
-			resClient, err := resmgmt.New(sdkContext)
-			if err != nil {
-				r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-			}
-			signature, err := resClient.CreateConfigSignatureFromReader(signingIdentity, configUpdateReader)
-			if err != nil {
-				r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
-				return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
-			}
-			configSignatures = append(configSignatures, signature)
+		configSignatures, err := signConfigUpdate(ctx, sdk, clientSet, resClient, fabricMainChannel, signerMSPIDs, channelConfigBytes)
+		if err != nil {
+			r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, err, false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 		}
 		configUpdateReader := bytes.NewReader(channelConfigBytes)
 		saveChannelOpts := []resmgmt.RequestOption{
@@ -549,7 +492,7 @@ func (r *FabricMainChannelReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
 	}
 	var buf bytes.Buffer
-	err = protolator.DeepMarshalJSON(&buf, cmnConfig)
+	err = protolator.DeepMarshalJSON(&buf, bft.WithoutBFTMetadata(cmnConfig))
 	if err != nil {
 		r.setConditionStatus(ctx, fabricMainChannel, hlfv1alpha1.FailedStatus, false, errors.Wrapf(err, "error converting block to JSON"), false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricMainChannel)
@@ -669,8 +612,26 @@ func (r *FabricMainChannelReconciler) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 func (r *FabricMainChannelReconciler) mapToConfigTX(channel *hlfv1alpha1.FabricMainChannel) (configtx.Channel, error) {
+	clientSet, err := utils.GetClientKubeWithConf(r.Config)
+	if err != nil {
+		return configtx.Channel{}, err
+	}
+	hlfClientSet, err := operatorv1.NewForConfig(r.Config)
+	if err != nil {
+		return configtx.Channel{}, err
+	}
+	if isBFT(channel) {
+		err = bft.ValidateCapabilities(desiredChannelCapabilities(channel))
+		if err != nil {
+			return configtx.Channel{}, err
+		}
+	}
+	channelConsenters, err := resolveConsenters(context.Background(), clientSet, hlfClientSet, channel)
+	if err != nil {
+		return configtx.Channel{}, err
+	}
 	consenters := []orderer.Consenter{}
-	for _, consenter := range channel.Spec.Consenters {
+	for _, consenter := range channelConsenters {
 		tlsCert, err := utils.ParseX509Certificate([]byte(consenter.TLSCert))
 		if err != nil {
 			return configtx.Channel{}, err
@@ -685,18 +646,11 @@ func (r *FabricMainChannelReconciler) mapToConfigTX(channel *hlfv1alpha1.FabricM
 		}
 		consenters = append(consenters, channelConsenter)
 	}
-	clientSet, err := utils.GetClientKubeWithConf(r.Config)
-	if err != nil {
-		return configtx.Channel{}, err
-	}
-	hlfClientSet, err := operatorv1.NewForConfig(r.Config)
-	if err != nil {
-		return configtx.Channel{}, err
-	}
 	ordererOrgs := []configtx.Organization{}
 	for _, ordererOrg := range channel.Spec.OrdererOrganizations {
 		var tlsCACert *x509.Certificate
 		var caCert *x509.Certificate
+		var caRootCerts *helpers.CARootCerts
 		if ordererOrg.CAName != "" && ordererOrg.CANamespace != "" {
 			certAuth, err := helpers.GetCertAuthByName(
 				clientSet,
@@ -707,25 +661,24 @@ func (r *FabricMainChannelReconciler) mapToConfigTX(channel *hlfv1alpha1.FabricM
 			if err != nil {
 				return configtx.Channel{}, err
 			}
-			tlsCACert, err = utils.ParseX509Certificate([]byte(certAuth.Status.TLSCACert))
-			if err != nil {
-				return configtx.Channel{}, err
-			}
-			caCert, err = utils.ParseX509Certificate([]byte(certAuth.Status.CACert))
-			if err != nil {
-				return configtx.Channel{}, err
-			}
-		} else if ordererOrg.TLSCACert != "" && ordererOrg.SignCACert != "" {
-			tlsCACert, err = utils.ParseX509Certificate([]byte(ordererOrg.TLSCACert))
+			caRootCerts, err = helpers.GetCARootCerts(certAuth.Status)
 			if err != nil {
 				return configtx.Channel{}, err
 			}
-			caCert, err = utils.ParseX509Certificate([]byte(ordererOrg.SignCACert))
-			if err != nil {
-				return configtx.Channel{}, err
+		} else {
+			if ordererOrg.TLSCACert != "" && ordererOrg.SignCACert != "" {
+				tlsCACert, err = utils.ParseX509Certificate([]byte(ordererOrg.TLSCACert))
+				if err != nil {
+					return configtx.Channel{}, err
+				}
+				caCert, err = utils.ParseX509Certificate([]byte(ordererOrg.SignCACert))
+				if err != nil {
+					return configtx.Channel{}, err
+				}
 			}
+			caRootCerts = externalOrgRootCerts(caCert, tlsCACert)
 		}
-		ordererOrgs = append(ordererOrgs, r.mapOrdererOrg(ordererOrg.MSPID, ordererOrg.OrdererEndpoints, caCert, tlsCACert))
+		ordererOrgs = append(ordererOrgs, r.mapOrdererOrg(ordererOrg.MSPID, ordererOrg.OrdererEndpoints, caRootCerts, ordererOrg.Policies, ordererOrg.NodeOUs))
 	}
 	for _, ordererOrg := range channel.Spec.ExternalOrdererOrganizations {
 		tlsCACert, err := utils.ParseX509Certificate([]byte(ordererOrg.TLSRootCert))
@@ -736,7 +689,7 @@ func (r *FabricMainChannelReconciler) mapToConfigTX(channel *hlfv1alpha1.FabricM
 		if err != nil {
 			return configtx.Channel{}, err
 		}
-		ordererOrgs = append(ordererOrgs, r.mapOrdererOrg(ordererOrg.MSPID, ordererOrg.OrdererEndpoints, caCert, tlsCACert))
+		ordererOrgs = append(ordererOrgs, r.mapOrdererOrg(ordererOrg.MSPID, ordererOrg.OrdererEndpoints, externalOrgRootCerts(caCert, tlsCACert), ordererOrg.Policies, ordererOrg.NodeOUs))
 	}
 	etcdRaftOptions := orderer.EtcdRaftOptions{
 		TickInterval:         "500ms",
@@ -784,15 +737,17 @@ func (r *FabricMainChannelReconciler) mapToConfigTX(channel *hlfv1alpha1.FabricM
 			Rule: "ANY Writers",
 		},
 	}
+	// configtx only builds etcdraft orderer groups, the consensus of the BFT channels is replaced with the mapping of
+	// getBFTConsensus in the genesis block and in the updates
 	ordConfigtx := configtx.Orderer{
-		OrdererType:   "etcdraft",
+		OrdererType:   orderer.ConsensusTypeEtcdRaft,
 		Organizations: ordererOrgs,
 		EtcdRaft: orderer.EtcdRaft{
 			Consenters: consenters,
 			Options:    etcdRaftOptions,
 		},
 		Policies:     adminOrdererPolicies,
-		Capabilities: []string{"V2_0"},
+		Capabilities: desiredOrdererCapabilities(channel),
 		BatchSize: orderer.BatchSize{
 			MaxMessageCount:   100,
 			AbsoluteMaxBytes:  1024 * 1024,
@@ -828,15 +783,11 @@ func (r *FabricMainChannelReconciler) mapToConfigTX(channel *hlfv1alpha1.FabricM
 		if err != nil {
 			return configtx.Channel{}, err
 		}
-		tlsCACert, err := utils.ParseX509Certificate([]byte(certAuth.Status.TLSCACert))
+		caRootCerts, err := helpers.GetCARootCerts(certAuth.Status)
 		if err != nil {
 			return configtx.Channel{}, err
 		}
-		caCert, err := utils.ParseX509Certificate([]byte(certAuth.Status.CACert))
-		if err != nil {
-			return configtx.Channel{}, err
-		}
-		peerOrgs = append(peerOrgs, r.mapPeerOrg(peerOrg.MSPID, caCert, tlsCACert))
+		peerOrgs = append(peerOrgs, r.mapPeerOrg(peerOrg.MSPID, caRootCerts, peerOrg.AnchorPeers, peerOrg.Policies, peerOrg.NodeOUs))
 	}
 	for _, peerOrg := range channel.Spec.ExternalPeerOrganizations {
 		tlsCACert, err := utils.ParseX509Certificate([]byte(peerOrg.TLSRootCert))
@@ -847,7 +798,7 @@ func (r *FabricMainChannelReconciler) mapToConfigTX(channel *hlfv1alpha1.FabricM
 		if err != nil {
 			return configtx.Channel{}, err
 		}
-		peerOrgs = append(peerOrgs, r.mapPeerOrg(peerOrg.MSPID, caCert, tlsCACert))
+		peerOrgs = append(peerOrgs, r.mapPeerOrg(peerOrg.MSPID, externalOrgRootCerts(caCert, tlsCACert), peerOrg.AnchorPeers, peerOrg.Policies, peerOrg.NodeOUs))
 	}
 	var adminAppPolicy string
 	if len(channel.Spec.AdminPeerOrganizations) == 0 {
@@ -886,14 +837,14 @@ func (r *FabricMainChannelReconciler) mapToConfigTX(channel *hlfv1alpha1.FabricM
 	}
 	application := configtx.Application{
 		Organizations: peerOrgs,
-		Capabilities:  []string{"V2_0"},
+		Capabilities:  desiredApplicationCapabilities(channel),
 		Policies:      policies,
 		ACLs:          defaultACLs(),
 	}
 	channelConfig := configtx.Channel{
 		Orderer:      ordConfigtx,
 		Application:  application,
-		Capabilities: []string{"V2_0"},
+		Capabilities: desiredChannelCapabilities(channel),
 		Policies: map[string]configtx.Policy{
 			"Readers": {
 				Type: "ImplicitMeta",
@@ -912,56 +863,21 @@ func (r *FabricMainChannelReconciler) mapToConfigTX(channel *hlfv1alpha1.FabricM
 	return channelConfig, nil
 }
 
-func (r *FabricMainChannelReconciler) mapOrdererOrg(mspID string, ordererEndpoints []string, caCert *x509.Certificate, tlsCACert *x509.Certificate) configtx.Organization {
+func (r *FabricMainChannelReconciler) mapOrdererOrg(mspID string, ordererEndpoints []string, caRootCerts *helpers.CARootCerts, policies *map[string]hlfv1alpha1.FabricMainChannelPoliciesConfig, nodeOUs *hlfv1alpha1.FabricMainChannelNodeOUs) configtx.Organization {
 	return configtx.Organization{
-		Name: mspID,
-		Policies: map[string]configtx.Policy{
-			"Admins": {
-				Type: "Signature",
-				Rule: fmt.Sprintf("OR('%s.admin')", mspID),
-			},
-			"Readers": {
-				Type: "Signature",
-				Rule: fmt.Sprintf("OR('%s.member')", mspID),
-			},
-			"Writers": {
-				Type: "Signature",
-				Rule: fmt.Sprintf("OR('%s.member')", mspID),
-			},
-			"Endorsement": {
-				Type: "Signature",
-				Rule: fmt.Sprintf("OR('%s.member')", mspID),
-			},
-		},
+		Name:     mspID,
+		Policies: mapOrgPolicies(mspID, policies),
 		MSP: configtx.MSP{
-			Name:         mspID,
-			RootCerts:    []*x509.Certificate{caCert},
-			TLSRootCerts: []*x509.Certificate{tlsCACert},
-			NodeOUs: membership.NodeOUs{
-				Enable: true,
-				ClientOUIdentifier: membership.OUIdentifier{
-					Certificate:                  caCert,
-					OrganizationalUnitIdentifier: "client",
-				},
-				PeerOUIdentifier: membership.OUIdentifier{
-					Certificate:                  caCert,
-					OrganizationalUnitIdentifier: "peer",
-				},
-				AdminOUIdentifier: membership.OUIdentifier{
-					Certificate:                  caCert,
-					OrganizationalUnitIdentifier: "admin",
-				},
-				OrdererOUIdentifier: membership.OUIdentifier{
-					Certificate:                  caCert,
-					OrganizationalUnitIdentifier: "orderer",
-				},
-			},
+			Name:                          mspID,
+			RootCerts:                     append([]*x509.Certificate{}, caRootCerts.RootCerts...),
+			TLSRootCerts:                  append([]*x509.Certificate{}, caRootCerts.TLSRootCerts...),
+			NodeOUs:                       mapNodeOUs(caRootCerts.RootCerts[0], nodeOUs),
 			Admins:                        []*x509.Certificate{},
-			IntermediateCerts:             []*x509.Certificate{},
+			IntermediateCerts:             append([]*x509.Certificate{}, caRootCerts.IntermediateCerts...),
 			RevocationList:                []*pkix.CertificateList{},
 			OrganizationalUnitIdentifiers: []membership.OUIdentifier{},
 			CryptoConfig:                  membership.CryptoConfig{},
-			TLSIntermediateCerts:          []*x509.Certificate{},
+			TLSIntermediateCerts:          append([]*x509.Certificate{}, caRootCerts.TLSIntermediateCerts...),
 		},
 		AnchorPeers:      []configtx.Address{},
 		OrdererEndpoints: ordererEndpoints,
@@ -969,69 +885,131 @@ func (r *FabricMainChannelReconciler) mapOrdererOrg(mspID string, ordererEndpoin
 	}
 }
 
-func (r *FabricMainChannelReconciler) mapPeerOrg(mspID string, caCert *x509.Certificate, tlsCACert *x509.Certificate) configtx.Organization {
+func (r *FabricMainChannelReconciler) mapPeerOrg(mspID string, caRootCerts *helpers.CARootCerts, anchorPeers []hlfv1alpha1.FabricMainChannelAnchorPeer, policies *map[string]hlfv1alpha1.FabricMainChannelPoliciesConfig, nodeOUs *hlfv1alpha1.FabricMainChannelNodeOUs) configtx.Organization {
 	return configtx.Organization{
-		Name: mspID,
-		Policies: map[string]configtx.Policy{
-			"Admins": {
-				Type: "Signature",
-				Rule: fmt.Sprintf("OR('%s.admin')", mspID),
-			},
-			"Readers": {
-				Type: "Signature",
-				Rule: fmt.Sprintf("OR('%s.member')", mspID),
-			},
-			"Writers": {
-				Type: "Signature",
-				Rule: fmt.Sprintf("OR('%s.member')", mspID),
-			},
-			"Endorsement": {
-				Type: "Signature",
-				Rule: fmt.Sprintf("OR('%s.member')", mspID),
-			},
-		},
+		Name:     mspID,
+		Policies: mapOrgPolicies(mspID, policies),
 		MSP: configtx.MSP{
-			Name:         mspID,
-			RootCerts:    []*x509.Certificate{caCert},
-			TLSRootCerts: []*x509.Certificate{tlsCACert},
-			NodeOUs: membership.NodeOUs{
-				Enable: true,
-				ClientOUIdentifier: membership.OUIdentifier{
-					Certificate:                  caCert,
-					OrganizationalUnitIdentifier: "client",
-				},
-				PeerOUIdentifier: membership.OUIdentifier{
-					Certificate:                  caCert,
-					OrganizationalUnitIdentifier: "peer",
-				},
-				AdminOUIdentifier: membership.OUIdentifier{
-					Certificate:                  caCert,
-					OrganizationalUnitIdentifier: "admin",
-				},
-				OrdererOUIdentifier: membership.OUIdentifier{
-					Certificate:                  caCert,
-					OrganizationalUnitIdentifier: "orderer",
-				},
-			},
+			Name:                          mspID,
+			RootCerts:                     append([]*x509.Certificate{}, caRootCerts.RootCerts...),
+			TLSRootCerts:                  append([]*x509.Certificate{}, caRootCerts.TLSRootCerts...),
+			NodeOUs:                       mapNodeOUs(caRootCerts.RootCerts[0], nodeOUs),
 			Admins:                        []*x509.Certificate{},
-			IntermediateCerts:             []*x509.Certificate{},
+			IntermediateCerts:             append([]*x509.Certificate{}, caRootCerts.IntermediateCerts...),
 			RevocationList:                []*pkix.CertificateList{},
 			OrganizationalUnitIdentifiers: []membership.OUIdentifier{},
 			CryptoConfig:                  membership.CryptoConfig{},
-			TLSIntermediateCerts:          []*x509.Certificate{},
+			TLSIntermediateCerts:          append([]*x509.Certificate{}, caRootCerts.TLSIntermediateCerts...),
 		},
-		AnchorPeers:      []configtx.Address{},
+		AnchorPeers:      mapAnchorPeers(anchorPeers),
 		OrdererEndpoints: []string{},
 		ModPolicy:        "",
 	}
 }
 
-type identity struct {
-	Cert Pem `json:"cert"`
-	Key  Pem `json:"key"`
+func mapAnchorPeers(anchorPeers []hlfv1alpha1.FabricMainChannelAnchorPeer) []configtx.Address {
+	addresses := []configtx.Address{}
+	for _, anchorPeer := range anchorPeers {
+		addresses = append(addresses, configtx.Address{
+			Host: anchorPeer.Host,
+			Port: anchorPeer.Port,
+		})
+	}
+	return addresses
+}
+
+// updateAnchorPeersConfigTx sets the anchor peers of the peer organizations that declare them in the FabricMainChannel,
+// it returns the MSP IDs of the organizations whose anchor peers changed
+func updateAnchorPeersConfigTx(currentConfigTX configtx.ConfigTx, channel *hlfv1alpha1.FabricMainChannel) ([]string, error) {
+	desiredAnchorPeers := map[string][]hlfv1alpha1.FabricMainChannelAnchorPeer{}
+	var mspIDs []string
+	for _, peerOrg := range channel.Spec.PeerOrganizations {
+		if peerOrg.AnchorPeers != nil {
+			desiredAnchorPeers[peerOrg.MSPID] = peerOrg.AnchorPeers
+			mspIDs = append(mspIDs, peerOrg.MSPID)
+		}
+	}
+	for _, peerOrg := range channel.Spec.ExternalPeerOrganizations {
+		if peerOrg.AnchorPeers != nil {
+			desiredAnchorPeers[peerOrg.MSPID] = peerOrg.AnchorPeers
+			mspIDs = append(mspIDs, peerOrg.MSPID)
+		}
+	}
+	var changedOrgs []string
+	for _, mspID := range mspIDs {
+		org := currentConfigTX.Application().Organization(mspID)
+		if org == nil {
+			continue
+		}
+		currentAnchorPeers, err := org.AnchorPeers()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get anchor peers of organization %s", mspID)
+		}
+		desired := mapAnchorPeers(desiredAnchorPeers[mspID])
+		changed := false
+		for _, anchorPeer := range currentAnchorPeers {
+			if !containsAddress(desired, anchorPeer) {
+				log.Infof("Removing anchor peer %s:%d from organization %s", anchorPeer.Host, anchorPeer.Port, mspID)
+				err = org.RemoveAnchorPeer(anchorPeer)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to remove anchor peer %s:%d from organization %s", anchorPeer.Host, anchorPeer.Port, mspID)
+				}
+				changed = true
+			}
+		}
+		for _, anchorPeer := range desired {
+			if !containsAddress(currentAnchorPeers, anchorPeer) {
+				log.Infof("Adding anchor peer %s:%d to organization %s", anchorPeer.Host, anchorPeer.Port, mspID)
+				err = org.AddAnchorPeer(anchorPeer)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to add anchor peer %s:%d to organization %s", anchorPeer.Host, anchorPeer.Port, mspID)
+				}
+				changed = true
+			}
+		}
+		if changed {
+			changedOrgs = append(changedOrgs, mspID)
+		}
+	}
+	return changedOrgs, nil
+}
+
+func containsAddress(addresses []configtx.Address, address configtx.Address) bool {
+	for _, a := range addresses {
+		if a.Host == address.Host && a.Port == address.Port {
+			return true
+		}
+	}
+	return false
 }
-type Pem struct {
-	Pem string
+
+// signConfigUpdate signs the config update with the identities of the organizations in the FabricMainChannel
+func signConfigUpdate(
+	ctx context.Context,
+	sdk *fabsdk.FabricSDK,
+	clientSet kubernetes.Interface,
+	resClient *resmgmt.Client,
+	channel *hlfv1alpha1.FabricMainChannel,
+	mspIDs []string,
+	channelConfigBytes []byte,
+) ([]*cb.ConfigSignature, error) {
+	var configSignatures []*cb.ConfigSignature
+	for _, mspID := range mspIDs {
+		idConfig, ok := channel.Spec.Identities[mspID]
+		if !ok {
+			return nil, fmt.Errorf("identity not found for MSPID %s", mspID)
+		}
+		signingIdentity, err := utils.GetSigningIdentity(ctx, sdk, clientSet, mspID, utils.MainChannelIdentity(idConfig))
+		if err != nil {
+			return nil, err
+		}
+		signature, err := resClient.CreateConfigSignatureFromReader(signingIdentity, bytes.NewReader(channelConfigBytes))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to sign config update with identity of %s", mspID)
+		}
+		configSignatures = append(configSignatures, signature)
+	}
+	return configSignatures, nil
 }
 
 func CreateConfigUpdateEnvelope(channelID string, configUpdate *cb.ConfigUpdate) ([]byte, error) {
@@ -1053,6 +1031,94 @@ func CreateConfigUpdateEnvelope(channelID string, configUpdate *cb.ConfigUpdate)
 	return envelopeData, nil
 }
 
+// buildConfigUpdate applies the FabricMainChannel to the current config of the channel, it returns the organizations
+// whose admins have to sign the update because their groups were modified
+func buildConfigUpdate(
+	ctx context.Context,
+	clientSet *kubernetes.Clientset,
+	hlfClientSet *operatorv1.Clientset,
+	channel *hlfv1alpha1.FabricMainChannel,
+	newConfigTx configtx.Channel,
+	idemixOrgs []idemixOrg,
+	currentConfigTx configtx.ConfigTx,
+) ([]string, error) {
+	detachedIdemixOrgs, err := detachIdemixOrgs(currentConfigTx.UpdatedConfig())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the Idemix organizations")
+	}
+	err = updateApplicationChannelConfigTx(currentConfigTx, newConfigTx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update application channel config")
+	}
+	anchorPeersOrgs, err := updateAnchorPeersConfigTx(currentConfigTx, channel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update anchor peers")
+	}
+	rootCertsOrgs, err := updateRootCertsConfigTx(ctx, hlfClientSet, currentConfigTx, channel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update root certificates")
+	}
+	orgs, err := updateOrgsConfigTx(currentConfigTx, channel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update organizations")
+	}
+	revocationListOrgs, err := updateRevocationListsConfigTx(ctx, newCRLCache(hlfClientSet), currentConfigTx, channel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update revocation lists")
+	}
+	err = setIdemixOrgs(currentConfigTx.UpdatedConfig(), idemixOrgs, detachedIdemixOrgs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update Idemix organizations")
+	}
+	consensusChanged, err := updateBFTConsensusConfigTx(ctx, clientSet, hlfClientSet, currentConfigTx.UpdatedConfig(), channel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to update the BFT consensus")
+	}
+	changedOrgs := append(append(append(anchorPeersOrgs, rootCertsOrgs...), orgs...), revocationListOrgs...)
+	if consensusChanged {
+		// the consenters and the options are modified by the admins of the orderer group
+		changedOrgs = append(changedOrgs, capabilitySigners(channel, ordererCapabilityGroup)...)
+	}
+	return changedOrgs, nil
+}
+
+// ComputeConfigUpdateEnvelope calculates the config update envelope that moves the channel from
+// its current configuration to the configuration described by the FabricMainChannel, it returns
+// nil when the channel already has that configuration
+func ComputeConfigUpdateEnvelope(restConfig *rest.Config, fabricMainChannel *hlfv1alpha1.FabricMainChannel, currentConfig *cb.Config) ([]byte, error) {
+	ctx := context.Background()
+	r := &FabricMainChannelReconciler{Config: restConfig}
+	newConfigTx, err := r.mapToConfigTX(fabricMainChannel)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error mapping channel to configtx channel")
+	}
+	clientSet, err := utils.GetClientKubeWithConf(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	hlfClientSet, err := operatorv1.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	idemixOrgs, err := resolveIdemixOrgs(ctx, hlfClientSet, fabricMainChannel)
+	if err != nil {
+		return nil, err
+	}
+	currentConfigTx := configtx.New(currentConfig)
+	_, err = buildConfigUpdate(ctx, clientSet, hlfClientSet, fabricMainChannel, newConfigTx, idemixOrgs, currentConfigTx)
+	if err != nil {
+		return nil, err
+	}
+	if proto.Equal(currentConfig, currentConfigTx.UpdatedConfig()) {
+		return nil, nil
+	}
+	configUpdate, err := resmgmt.CalculateConfigUpdate(fabricMainChannel.Spec.Name, currentConfig, currentConfigTx.UpdatedConfig())
+	if err != nil {
+		return nil, err
+	}
+	return CreateConfigUpdateEnvelope(fabricMainChannel.Spec.Name, configUpdate)
+}
+
 func updateApplicationChannelConfigTx(currentConfigTX configtx.ConfigTx, newConfigTx configtx.Channel) error {
 	err := currentConfigTX.Application().SetPolicies(
 		newConfigTx.Application.Policies,
@@ -0,0 +1,101 @@
+package mainchannel
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+)
+
+func TestMapAnchorPeers(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(mapAnchorPeers(nil)).To(Equal([]configtx.Address{}))
+	g.Expect(mapAnchorPeers([]hlfv1alpha1.FabricMainChannelAnchorPeer{
+		{Host: "peer0.org1", Port: 7051},
+		{Host: "peer1.org1", Port: 8051},
+	})).To(Equal([]configtx.Address{
+		{Host: "peer0.org1", Port: 7051},
+		{Host: "peer1.org1", Port: 8051},
+	}))
+}
+
+func TestMapPeerOrgAnchorPeers(t *testing.T) {
+	g := NewWithT(t)
+	configTx := newTestOrgsConfigTx(g)
+	r := &FabricMainChannelReconciler{}
+	cert := newTestCACert(g, "org2-ca")
+	org := r.mapPeerOrg("Org2MSP", externalOrgRootCerts(cert, cert), []hlfv1alpha1.FabricMainChannelAnchorPeer{
+		{Host: "peer0.org2", Port: 7051},
+	}, nil, nil)
+	g.Expect(configTx.Application().SetOrganization(org)).To(Succeed())
+	anchorPeers, err := configTx.Application().Organization("Org2MSP").AnchorPeers()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(anchorPeers).To(Equal([]configtx.Address{{Host: "peer0.org2", Port: 7051}}))
+}
+
+func TestUpdateAnchorPeersConfigTx(t *testing.T) {
+	g := NewWithT(t)
+	configTx := newTestOrgsConfigTx(g)
+	r := &FabricMainChannelReconciler{}
+	cert := newTestCACert(g, "org2-ca")
+	g.Expect(configTx.Application().SetOrganization(r.mapPeerOrg("Org2MSP", externalOrgRootCerts(cert, cert), []hlfv1alpha1.FabricMainChannelAnchorPeer{
+		{Host: "peer0.org2", Port: 7051},
+	}, nil, nil))).To(Succeed())
+	configTx = configtx.New(configTx.UpdatedConfig())
+	anchorPeers := func(mspID string) []configtx.Address {
+		addresses, err := configTx.Application().Organization(mspID).AnchorPeers()
+		g.Expect(err).NotTo(HaveOccurred())
+		return addresses
+	}
+	channel := &hlfv1alpha1.FabricMainChannel{
+		Spec: hlfv1alpha1.FabricMainChannelSpec{
+			PeerOrganizations: []hlfv1alpha1.FabricMainChannelPeerOrganization{
+				{MSPID: "Org1MSP"},
+				// organizations that aren't in the channel yet are skipped
+				{MSPID: "Org3MSP", AnchorPeers: []hlfv1alpha1.FabricMainChannelAnchorPeer{{Host: "peer0.org3", Port: 7051}}},
+			},
+			ExternalPeerOrganizations: []hlfv1alpha1.FabricMainChannelExternalPeerOrganization{
+				{MSPID: "Org2MSP"},
+			},
+		},
+	}
+
+	// the anchor peers of the organizations that don't declare them are kept
+	changedOrgs, err := updateAnchorPeersConfigTx(configTx, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(BeEmpty())
+	g.Expect(anchorPeers("Org2MSP")).To(HaveLen(1))
+
+	channel.Spec.PeerOrganizations[0].AnchorPeers = []hlfv1alpha1.FabricMainChannelAnchorPeer{{Host: "peer0.org1", Port: 7051}}
+	changedOrgs, err = updateAnchorPeersConfigTx(configTx, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(Equal([]string{"Org1MSP"}))
+	g.Expect(anchorPeers("Org1MSP")).To(Equal([]configtx.Address{{Host: "peer0.org1", Port: 7051}}))
+
+	// the anchor peers are already set
+	configTx = configtx.New(configTx.UpdatedConfig())
+	changedOrgs, err = updateAnchorPeersConfigTx(configTx, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(BeEmpty())
+
+	// the anchor peers of an external organization are replaced
+	channel.Spec.ExternalPeerOrganizations[0].AnchorPeers = []hlfv1alpha1.FabricMainChannelAnchorPeer{
+		{Host: "peer1.org2", Port: 7051},
+		{Host: "peer2.org2", Port: 7051},
+	}
+	changedOrgs, err = updateAnchorPeersConfigTx(configTx, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(Equal([]string{"Org2MSP"}))
+	g.Expect(anchorPeers("Org2MSP")).To(ConsistOf(
+		configtx.Address{Host: "peer1.org2", Port: 7051},
+		configtx.Address{Host: "peer2.org2", Port: 7051},
+	))
+
+	// an empty list removes the anchor peers
+	channel.Spec.PeerOrganizations[0].AnchorPeers = []hlfv1alpha1.FabricMainChannelAnchorPeer{}
+	changedOrgs, err = updateAnchorPeersConfigTx(configTx, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(Equal([]string{"Org1MSP"}))
+	g.Expect(anchorPeers("Org1MSP")).To(BeEmpty())
+}
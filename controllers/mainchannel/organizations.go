@@ -0,0 +1,382 @@
+package mainchannel
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"github.com/hyperledger/fabric-config/configtx"
+	"github.com/hyperledger/fabric-config/configtx/membership"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+)
+
+// mapOrgPolicies returns the default policies of an organization overridden by the policies set in the spec
+func mapOrgPolicies(mspID string, policies *map[string]hlfv1alpha1.FabricMainChannelPoliciesConfig) map[string]configtx.Policy {
+	orgPolicies := map[string]configtx.Policy{
+		"Admins": {
+			Type: "Signature",
+			Rule: fmt.Sprintf("OR('%s.admin')", mspID),
+		},
+		"Readers": {
+			Type: "Signature",
+			Rule: fmt.Sprintf("OR('%s.member')", mspID),
+		},
+		"Writers": {
+			Type: "Signature",
+			Rule: fmt.Sprintf("OR('%s.member')", mspID),
+		},
+		"Endorsement": {
+			Type: "Signature",
+			Rule: fmt.Sprintf("OR('%s.member')", mspID),
+		},
+	}
+	if policies == nil {
+		return orgPolicies
+	}
+	for name, policy := range *policies {
+		orgPolicies[name] = configtx.Policy{
+			Type:      policy.Type,
+			Rule:      policy.Rule,
+			ModPolicy: policy.ModPolicy,
+		}
+	}
+	return orgPolicies
+}
+
+func mapNodeOUs(caCert *x509.Certificate, nodeOUs *hlfv1alpha1.FabricMainChannelNodeOUs) membership.NodeOUs {
+	ouIdentifier := func(identifier string, defaultIdentifier string) membership.OUIdentifier {
+		if identifier == "" {
+			identifier = defaultIdentifier
+		}
+		return membership.OUIdentifier{
+			Certificate:                  caCert,
+			OrganizationalUnitIdentifier: identifier,
+		}
+	}
+	if nodeOUs == nil {
+		nodeOUs = &hlfv1alpha1.FabricMainChannelNodeOUs{
+			Enable: true,
+		}
+	}
+	return membership.NodeOUs{
+		Enable:              nodeOUs.Enable,
+		ClientOUIdentifier:  ouIdentifier(nodeOUs.ClientOUIdentifier, "client"),
+		PeerOUIdentifier:    ouIdentifier(nodeOUs.PeerOUIdentifier, "peer"),
+		AdminOUIdentifier:   ouIdentifier(nodeOUs.AdminOUIdentifier, "admin"),
+		OrdererOUIdentifier: ouIdentifier(nodeOUs.OrdererOUIdentifier, "orderer"),
+	}
+}
+
+// externalOrgRootCerts returns the root certificates of an organization whose certificates are set in the spec
+func externalOrgRootCerts(caCert *x509.Certificate, tlsCACert *x509.Certificate) *helpers.CARootCerts {
+	return &helpers.CARootCerts{
+		RootCerts:    []*x509.Certificate{caCert},
+		TLSRootCerts: []*x509.Certificate{tlsCACert},
+	}
+}
+
+// updateOrgsConfigTx sets the policies and NodeOUs of the peer and orderer organizations already in the channel that
+// declare them in the FabricMainChannel, it returns the MSP IDs of the organizations whose configuration changed
+func updateOrgsConfigTx(currentConfigTX configtx.ConfigTx, channel *hlfv1alpha1.FabricMainChannel) ([]string, error) {
+	type orgConfig struct {
+		mspID       string
+		policies    *map[string]hlfv1alpha1.FabricMainChannelPoliciesConfig
+		nodeOUs     *hlfv1alpha1.FabricMainChannelNodeOUs
+		getPolicies func() (map[string]configtx.Policy, error)
+		setPolicies func(map[string]configtx.Policy) error
+		getMSP      func() (configtx.MSP, error)
+		setMSP      func(configtx.MSP) error
+	}
+	var orgConfigs []orgConfig
+	addPeerOrg := func(mspID string, policies *map[string]hlfv1alpha1.FabricMainChannelPoliciesConfig, nodeOUs *hlfv1alpha1.FabricMainChannelNodeOUs) {
+		org := currentConfigTX.Application().Organization(mspID)
+		if (policies == nil && nodeOUs == nil) || org == nil {
+			return
+		}
+		orgConfigs = append(orgConfigs, orgConfig{
+			mspID:       mspID,
+			policies:    policies,
+			nodeOUs:     nodeOUs,
+			getPolicies: org.Policies,
+			setPolicies: org.SetPolicies,
+			getMSP:      org.MSP().Configuration,
+			setMSP:      org.SetMSP,
+		})
+	}
+	addOrdererOrg := func(mspID string, policies *map[string]hlfv1alpha1.FabricMainChannelPoliciesConfig, nodeOUs *hlfv1alpha1.FabricMainChannelNodeOUs) {
+		org := currentConfigTX.Orderer().Organization(mspID)
+		if (policies == nil && nodeOUs == nil) || org == nil {
+			return
+		}
+		orgConfigs = append(orgConfigs, orgConfig{
+			mspID:       mspID,
+			policies:    policies,
+			nodeOUs:     nodeOUs,
+			getPolicies: org.Policies,
+			setPolicies: org.SetPolicies,
+			getMSP:      org.MSP().Configuration,
+			setMSP:      org.SetMSP,
+		})
+	}
+	for _, peerOrg := range channel.Spec.PeerOrganizations {
+		addPeerOrg(peerOrg.MSPID, peerOrg.Policies, peerOrg.NodeOUs)
+	}
+	for _, peerOrg := range channel.Spec.ExternalPeerOrganizations {
+		addPeerOrg(peerOrg.MSPID, peerOrg.Policies, peerOrg.NodeOUs)
+	}
+	for _, ordererOrg := range channel.Spec.OrdererOrganizations {
+		addOrdererOrg(ordererOrg.MSPID, ordererOrg.Policies, ordererOrg.NodeOUs)
+	}
+	for _, ordererOrg := range channel.Spec.ExternalOrdererOrganizations {
+		addOrdererOrg(ordererOrg.MSPID, ordererOrg.Policies, ordererOrg.NodeOUs)
+	}
+	var changedOrgs []string
+	for _, orgCfg := range orgConfigs {
+		changed := false
+		if orgCfg.policies != nil {
+			currentPolicies, err := orgCfg.getPolicies()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get policies of organization %s", orgCfg.mspID)
+			}
+			err = orgCfg.setPolicies(mapOrgPolicies(orgCfg.mspID, orgCfg.policies))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to set policies of organization %s", orgCfg.mspID)
+			}
+			updatedPolicies, err := orgCfg.getPolicies()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get policies of organization %s", orgCfg.mspID)
+			}
+			if !reflect.DeepEqual(currentPolicies, updatedPolicies) {
+				log.Infof("Updating policies of organization %s", orgCfg.mspID)
+				changed = true
+			}
+		}
+		if orgCfg.nodeOUs != nil {
+			currentMSP, err := orgCfg.getMSP()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get MSP of organization %s", orgCfg.mspID)
+			}
+			if len(currentMSP.RootCerts) == 0 {
+				return nil, errors.Errorf("organization %s has no root certificates", orgCfg.mspID)
+			}
+			nodeOUs := mapNodeOUs(currentMSP.RootCerts[0], orgCfg.nodeOUs)
+			if !reflect.DeepEqual(currentMSP.NodeOUs, nodeOUs) {
+				log.Infof("Updating NodeOUs of organization %s", orgCfg.mspID)
+				currentMSP.NodeOUs = nodeOUs
+				err = orgCfg.setMSP(currentMSP)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to set MSP of organization %s", orgCfg.mspID)
+				}
+				changed = true
+			}
+		}
+		if changed {
+			changedOrgs = append(changedOrgs, orgCfg.mspID)
+		}
+	}
+	return changedOrgs, nil
+}
+
+// crlCache keeps the CRLs generated by the FabricCAs during a reconcile, a FabricCA shared by several organizations
+// only generates its CRL once
+type crlCache struct {
+	getCRL func(ctx context.Context, name string, namespace string, enrollID string, mspID string) (*pkix.CertificateList, error)
+	crls   map[string]*pkix.CertificateList
+}
+
+func newCRLCache(hlfClientSet *operatorv1.Clientset) *crlCache {
+	return &crlCache{
+		getCRL: func(ctx context.Context, name string, namespace string, enrollID string, mspID string) (*pkix.CertificateList, error) {
+			return helpers.GetCACRL(ctx, hlfClientSet, name, namespace, enrollID, mspID)
+		},
+		crls: map[string]*pkix.CertificateList{},
+	}
+}
+
+func (c *crlCache) get(ctx context.Context, name string, namespace string, enrollID string, mspID string) (*pkix.CertificateList, error) {
+	key := fmt.Sprintf("%s/%s/%s", namespace, name, enrollID)
+	if crl, ok := c.crls[key]; ok {
+		return crl, nil
+	}
+	crl, err := c.getCRL(ctx, name, namespace, enrollID, mspID)
+	if err != nil {
+		return nil, err
+	}
+	c.crls[key] = crl
+	return crl, nil
+}
+
+// updateRevocationListsConfigTx embeds the current CRL of the FabricCA in the MSP of the peer and orderer organizations
+// that enable it, the CRLs of other issuers are kept. It returns the MSP IDs of the organizations whose revocation
+// list changed
+func updateRevocationListsConfigTx(
+	ctx context.Context,
+	crls *crlCache,
+	currentConfigTX configtx.ConfigTx,
+	channel *hlfv1alpha1.FabricMainChannel,
+) ([]string, error) {
+	type orgRevocationList struct {
+		mspID          string
+		caName         string
+		caNamespace    string
+		revocationList *hlfv1alpha1.FabricChannelRevocationList
+		getMSP         func() (configtx.MSP, error)
+		setMSP         func(configtx.MSP) error
+	}
+	var orgRevocationLists []orgRevocationList
+	for _, peerOrg := range channel.Spec.PeerOrganizations {
+		org := currentConfigTX.Application().Organization(peerOrg.MSPID)
+		if peerOrg.RevocationList == nil || org == nil {
+			continue
+		}
+		orgRevocationLists = append(orgRevocationLists, orgRevocationList{
+			mspID:          peerOrg.MSPID,
+			caName:         peerOrg.CAName,
+			caNamespace:    peerOrg.CANamespace,
+			revocationList: peerOrg.RevocationList,
+			getMSP:         org.MSP().Configuration,
+			setMSP:         org.SetMSP,
+		})
+	}
+	for _, ordererOrg := range channel.Spec.OrdererOrganizations {
+		org := currentConfigTX.Orderer().Organization(ordererOrg.MSPID)
+		if ordererOrg.RevocationList == nil || org == nil {
+			continue
+		}
+		orgRevocationLists = append(orgRevocationLists, orgRevocationList{
+			mspID:          ordererOrg.MSPID,
+			caName:         ordererOrg.CAName,
+			caNamespace:    ordererOrg.CANamespace,
+			revocationList: ordererOrg.RevocationList,
+			getMSP:         org.MSP().Configuration,
+			setMSP:         org.SetMSP,
+		})
+	}
+	var changedOrgs []string
+	for _, orgRL := range orgRevocationLists {
+		caName := orgRL.caName
+		caNamespace := orgRL.caNamespace
+		if orgRL.revocationList.CAName != "" {
+			caName = orgRL.revocationList.CAName
+			caNamespace = orgRL.revocationList.CANamespace
+		}
+		if caName == "" {
+			return nil, errors.Errorf("organization %s has no FabricCA to get the revocation list from", orgRL.mspID)
+		}
+		crl, err := crls.get(ctx, caName, caNamespace, orgRL.revocationList.EnrollID, orgRL.mspID)
+		if err != nil {
+			return nil, err
+		}
+		currentMSP, err := orgRL.getMSP()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get MSP of organization %s", orgRL.mspID)
+		}
+		if !helpers.RevocationListChanged(currentMSP.RevocationList, crl) {
+			continue
+		}
+		log.Infof("Updating revocation list of organization %s", orgRL.mspID)
+		currentMSP.RevocationList = helpers.MergeRevocationList(currentMSP.RevocationList, crl)
+		err = orgRL.setMSP(currentMSP)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to set MSP of organization %s", orgRL.mspID)
+		}
+		changedOrgs = append(changedOrgs, orgRL.mspID)
+	}
+	return changedOrgs, nil
+}
+
+// updateRootCertsConfigTx sets the root and intermediate certificates of the peer and orderer organizations enrolled
+// by a FabricCA that has been rotated, the NodeOUs point to the current root of the CA, it returns the MSP IDs of the
+// organizations whose MSP changed
+func updateRootCertsConfigTx(
+	ctx context.Context,
+	hlfClientSet *operatorv1.Clientset,
+	currentConfigTX configtx.ConfigTx,
+	channel *hlfv1alpha1.FabricMainChannel,
+) ([]string, error) {
+	type orgCA struct {
+		mspID       string
+		caName      string
+		caNamespace string
+		getMSP      func() (configtx.MSP, error)
+		setMSP      func(configtx.MSP) error
+	}
+	var orgCAs []orgCA
+	for _, peerOrg := range channel.Spec.PeerOrganizations {
+		org := currentConfigTX.Application().Organization(peerOrg.MSPID)
+		if org == nil {
+			continue
+		}
+		orgCAs = append(orgCAs, orgCA{
+			mspID:       peerOrg.MSPID,
+			caName:      peerOrg.CAName,
+			caNamespace: peerOrg.CANamespace,
+			getMSP:      org.MSP().Configuration,
+			setMSP:      org.SetMSP,
+		})
+	}
+	for _, ordererOrg := range channel.Spec.OrdererOrganizations {
+		org := currentConfigTX.Orderer().Organization(ordererOrg.MSPID)
+		if ordererOrg.CAName == "" || ordererOrg.CANamespace == "" || org == nil {
+			continue
+		}
+		orgCAs = append(orgCAs, orgCA{
+			mspID:       ordererOrg.MSPID,
+			caName:      ordererOrg.CAName,
+			caNamespace: ordererOrg.CANamespace,
+			getMSP:      org.MSP().Configuration,
+			setMSP:      org.SetMSP,
+		})
+	}
+	var changedOrgs []string
+	for _, orgCfg := range orgCAs {
+		fabricCA, err := hlfClientSet.HlfV1alpha1().FabricCAs(orgCfg.caNamespace).Get(ctx, orgCfg.caName, v1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get FabricCA %s/%s", orgCfg.caNamespace, orgCfg.caName)
+		}
+		if fabricCA.Status.Rotation == nil {
+			continue
+		}
+		caRootCerts, err := helpers.GetCARootCerts(fabricCA.Status)
+		if err != nil {
+			return nil, err
+		}
+		currentMSP, err := orgCfg.getMSP()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get MSP of organization %s", orgCfg.mspID)
+		}
+		if helpers.CertsEqual(currentMSP.RootCerts, caRootCerts.RootCerts) &&
+			helpers.CertsEqual(currentMSP.TLSRootCerts, caRootCerts.TLSRootCerts) &&
+			helpers.CertsEqual(currentMSP.IntermediateCerts, caRootCerts.IntermediateCerts) &&
+			helpers.CertsEqual(currentMSP.TLSIntermediateCerts, caRootCerts.TLSIntermediateCerts) {
+			continue
+		}
+		log.Infof("Updating root certificates of organization %s", orgCfg.mspID)
+		currentMSP.RootCerts = caRootCerts.RootCerts
+		currentMSP.TLSRootCerts = caRootCerts.TLSRootCerts
+		currentMSP.IntermediateCerts = append([]*x509.Certificate{}, caRootCerts.IntermediateCerts...)
+		currentMSP.TLSIntermediateCerts = append([]*x509.Certificate{}, caRootCerts.TLSIntermediateCerts...)
+		for _, ouIdentifier := range []*membership.OUIdentifier{
+			&currentMSP.NodeOUs.ClientOUIdentifier,
+			&currentMSP.NodeOUs.PeerOUIdentifier,
+			&currentMSP.NodeOUs.AdminOUIdentifier,
+			&currentMSP.NodeOUs.OrdererOUIdentifier,
+		} {
+			if ouIdentifier.Certificate != nil {
+				ouIdentifier.Certificate = caRootCerts.RootCerts[0]
+			}
+		}
+		err = orgCfg.setMSP(currentMSP)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to set MSP of organization %s", orgCfg.mspID)
+		}
+		changedOrgs = append(changedOrgs, orgCfg.mspID)
+	}
+	return changedOrgs, nil
+}
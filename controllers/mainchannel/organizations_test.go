@@ -0,0 +1,249 @@
+package mainchannel
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-config/configtx"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	. "github.com/onsi/gomega"
+)
+
+func newTestCACert(g *WithT, commonName string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	g.Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(certBytes)
+	g.Expect(err).NotTo(HaveOccurred())
+	return cert
+}
+
+func newTestGroup() *cb.ConfigGroup {
+	return &cb.ConfigGroup{
+		Groups:   map[string]*cb.ConfigGroup{},
+		Values:   map[string]*cb.ConfigValue{},
+		Policies: map[string]*cb.ConfigPolicy{},
+	}
+}
+
+// newTestOrgsConfigTx returns the config of a channel with the Org1MSP peer organization and the OrdererMSP orderer
+// organization created with the default policies and NodeOUs
+func newTestOrgsConfigTx(g *WithT) configtx.ConfigTx {
+	configTx := configtx.New(&cb.Config{ChannelGroup: newTestGroup()})
+	// the config is cloned without its empty maps
+	channelGroup := configTx.UpdatedConfig().ChannelGroup
+	channelGroup.Groups = map[string]*cb.ConfigGroup{"Application": newTestGroup(), "Orderer": newTestGroup()}
+	r := &FabricMainChannelReconciler{}
+	peerCert := newTestCACert(g, "org1-ca")
+	err := configTx.Application().SetOrganization(r.mapPeerOrg("Org1MSP", externalOrgRootCerts(peerCert, peerCert), nil, nil, nil))
+	g.Expect(err).NotTo(HaveOccurred())
+	ordererCert := newTestCACert(g, "orderer-ca")
+	err = configTx.Orderer().SetOrganization(r.mapOrdererOrg("OrdererMSP", []string{"orderer0:7050"}, &helpers.CARootCerts{
+		RootCerts:    []*x509.Certificate{ordererCert},
+		TLSRootCerts: []*x509.Certificate{ordererCert},
+	}, nil, nil))
+	g.Expect(err).NotTo(HaveOccurred())
+	return configtx.New(configTx.UpdatedConfig())
+}
+
+func TestUpdateOrgsConfigTx(t *testing.T) {
+	g := NewWithT(t)
+	channel := &hlfv1alpha1.FabricMainChannel{
+		Spec: hlfv1alpha1.FabricMainChannelSpec{
+			PeerOrganizations:    []hlfv1alpha1.FabricMainChannelPeerOrganization{{MSPID: "Org1MSP"}},
+			OrdererOrganizations: []hlfv1alpha1.FabricMainChannelOrdererOrganization{{MSPID: "OrdererMSP"}},
+		},
+	}
+
+	// nothing is declared in the spec
+	configTx := newTestOrgsConfigTx(g)
+	changedOrgs, err := updateOrgsConfigTx(configTx, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(BeEmpty())
+
+	// the spec declares the defaults
+	channel.Spec.PeerOrganizations[0].Policies = &map[string]hlfv1alpha1.FabricMainChannelPoliciesConfig{}
+	channel.Spec.OrdererOrganizations[0].NodeOUs = &hlfv1alpha1.FabricMainChannelNodeOUs{Enable: true}
+	changedOrgs, err = updateOrgsConfigTx(configTx, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(BeEmpty())
+
+	channel.Spec.OrdererOrganizations[0].Policies = &map[string]hlfv1alpha1.FabricMainChannelPoliciesConfig{
+		"Admins": {Type: "Signature", Rule: "OR('OrdererMSP.admin', 'OrdererMSP.orderer')"},
+	}
+	channel.Spec.OrdererOrganizations[0].NodeOUs = &hlfv1alpha1.FabricMainChannelNodeOUs{Enable: true, OrdererOUIdentifier: "osn"}
+	channel.Spec.PeerOrganizations[0].NodeOUs = &hlfv1alpha1.FabricMainChannelNodeOUs{Enable: false}
+	changedOrgs, err = updateOrgsConfigTx(configTx, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(Equal([]string{"Org1MSP", "OrdererMSP"}))
+
+	ordererOrg := configTx.Orderer().Organization("OrdererMSP")
+	policies, err := ordererOrg.Policies()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(policies["Admins"].Rule).To(Equal("OR('OrdererMSP.admin', 'OrdererMSP.orderer')"))
+	// the default policies are kept, a single principal is read back as AND
+	g.Expect(policies["Readers"].Rule).To(Equal("AND('OrdererMSP.member')"))
+	ordererMSP, err := ordererOrg.MSP().Configuration()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ordererMSP.NodeOUs.OrdererOUIdentifier.OrganizationalUnitIdentifier).To(Equal("osn"))
+	peerMSP, err := configTx.Application().Organization("Org1MSP").MSP().Configuration()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(peerMSP.NodeOUs.Enable).To(BeFalse())
+
+	// the update is idempotent
+	changedOrgs, err = updateOrgsConfigTx(configtx.New(configTx.UpdatedConfig()), channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(BeEmpty())
+
+	// organizations that aren't in the channel yet are skipped
+	channel.Spec.ExternalOrdererOrganizations = []hlfv1alpha1.FabricMainChannelExternalOrdererOrganization{
+		{MSPID: "Orderer2MSP", NodeOUs: &hlfv1alpha1.FabricMainChannelNodeOUs{Enable: true}},
+	}
+	changedOrgs, err = updateOrgsConfigTx(configtx.New(configTx.UpdatedConfig()), channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(BeEmpty())
+}
+
+type testCRLIssuer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCRLIssuer returns a CA with the common name able to sign CRLs
+func newTestCRLIssuer(g *WithT, commonName string) *testCRLIssuer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		SubjectKeyId:          []byte(commonName),
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	g.Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(certBytes)
+	g.Expect(err).NotTo(HaveOccurred())
+	return &testCRLIssuer{cert: cert, key: key}
+}
+
+// crl returns a CRL that revokes the serial numbers
+func (i *testCRLIssuer) crl(g *WithT, serialNumbers ...int64) *pkix.CertificateList {
+	var revoked []pkix.RevokedCertificate
+	for _, serialNumber := range serialNumbers {
+		revoked = append(revoked, pkix.RevokedCertificate{SerialNumber: big.NewInt(serialNumber), RevocationTime: time.Now()})
+	}
+	crlBytes, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:              big.NewInt(int64(len(serialNumbers))),
+		ThisUpdate:          time.Now(),
+		NextUpdate:          time.Now().Add(time.Hour),
+		RevokedCertificates: revoked,
+	}, i.cert, i.key)
+	g.Expect(err).NotTo(HaveOccurred())
+	crl, err := x509.ParseCRL(crlBytes)
+	g.Expect(err).NotTo(HaveOccurred())
+	return crl
+}
+
+func revokedSerials(crls []*pkix.CertificateList) []int64 {
+	var serialNumbers []int64
+	for _, crl := range crls {
+		for _, revoked := range crl.TBSCertList.RevokedCertificates {
+			serialNumbers = append(serialNumbers, revoked.SerialNumber.Int64())
+		}
+	}
+	return serialNumbers
+}
+
+func TestUpdateRevocationListsConfigTx(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	channel := &hlfv1alpha1.FabricMainChannel{
+		Spec: hlfv1alpha1.FabricMainChannelSpec{
+			PeerOrganizations: []hlfv1alpha1.FabricMainChannelPeerOrganization{{
+				MSPID:          "Org1MSP",
+				CAName:         "org1-ca",
+				CANamespace:    "default",
+				RevocationList: &hlfv1alpha1.FabricChannelRevocationList{},
+			}},
+			OrdererOrganizations: []hlfv1alpha1.FabricMainChannelOrdererOrganization{{
+				MSPID:          "OrdererMSP",
+				RevocationList: &hlfv1alpha1.FabricChannelRevocationList{CAName: "org1-ca", CANamespace: "default"},
+			}},
+		},
+	}
+	configTx := newTestOrgsConfigTx(g)
+	// the MSP of the peer organization already trusts the CRL of an intermediate CA
+	intermediateCRL := newTestCRLIssuer(g, "org1-intermediate-ca").crl(g, 7)
+	peerOrg := configTx.Application().Organization("Org1MSP")
+	peerMSP, err := peerOrg.MSP().Configuration()
+	g.Expect(err).NotTo(HaveOccurred())
+	peerMSP.RevocationList = []*pkix.CertificateList{intermediateCRL}
+	g.Expect(peerOrg.SetMSP(peerMSP)).To(Succeed())
+	configTx = configtx.New(configTx.UpdatedConfig())
+
+	ca := newTestCRLIssuer(g, "org1-ca")
+	caCRL := ca.crl(g, 1, 2)
+	calls := 0
+	crls := &crlCache{
+		getCRL: func(ctx context.Context, name string, namespace string, enrollID string, mspID string) (*pkix.CertificateList, error) {
+			calls++
+			g.Expect(name).To(Equal("org1-ca"))
+			g.Expect(namespace).To(Equal("default"))
+			return caCRL, nil
+		},
+		crls: map[string]*pkix.CertificateList{},
+	}
+	changedOrgs, err := updateRevocationListsConfigTx(ctx, crls, configTx, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(Equal([]string{"Org1MSP", "OrdererMSP"}))
+	// both organizations use the same FabricCA
+	g.Expect(calls).To(Equal(1))
+	peerMSP, err = configTx.Application().Organization("Org1MSP").MSP().Configuration()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(peerMSP.RevocationList).To(HaveLen(2))
+	g.Expect(revokedSerials(peerMSP.RevocationList)).To(Equal([]int64{7, 1, 2}))
+	ordererMSP, err := configTx.Orderer().Organization("OrdererMSP").MSP().Configuration()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(revokedSerials(ordererMSP.RevocationList)).To(Equal([]int64{1, 2}))
+
+	// a CRL regenerated with the same revoked certificates doesn't change the organizations
+	configTx = configtx.New(configTx.UpdatedConfig())
+	crls.crls = map[string]*pkix.CertificateList{}
+	caCRL = ca.crl(g, 1, 2)
+	changedOrgs, err = updateRevocationListsConfigTx(ctx, crls, configTx, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(BeEmpty())
+
+	// a new revocation replaces the CRL of the FabricCA and keeps the one of the intermediate CA
+	crls.crls = map[string]*pkix.CertificateList{}
+	caCRL = ca.crl(g, 1, 2, 3)
+	changedOrgs, err = updateRevocationListsConfigTx(ctx, crls, configTx, channel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changedOrgs).To(Equal([]string{"Org1MSP", "OrdererMSP"}))
+	peerMSP, err = configTx.Application().Organization("Org1MSP").MSP().Configuration()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(revokedSerials(peerMSP.RevocationList)).To(Equal([]int64{7, 1, 2, 3}))
+}
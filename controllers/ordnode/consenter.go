@@ -0,0 +1,82 @@
+package ordnode
+
+import (
+	"context"
+	"strconv"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getConsenterID returns the ID of the orderer node in the BFT consenter sets, it's persisted in an annotation of the
+// orderer node the first time. A node without one gets the next ID after the highest one of the orderer nodes and of
+// the consenter mappings of the channels, so the IDs don't depend on the order of the consenters and are never reused
+func getConsenterID(ctx context.Context, hlfClientSet *operatorv1.Clientset, ordNode *hlfv1alpha1.FabricOrdererNode) (uint32, error) {
+	if value, ok := ordNode.Annotations[utils.ConsenterIDAnnotation]; ok {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid %s annotation", utils.ConsenterIDAnnotation)
+		}
+		return uint32(id), nil
+	}
+	consenterID := ordNode.Status.ConsenterID
+	if consenterID == 0 {
+		nodes, err := hlfClientSet.HlfV1alpha1().FabricOrdererNodes("").List(ctx, v1.ListOptions{})
+		if err != nil {
+			return 0, err
+		}
+		channels, err := hlfClientSet.HlfV1alpha1().FabricMainChannels().List(ctx, v1.ListOptions{})
+		if err != nil {
+			return 0, err
+		}
+		consenterID, err = nextConsenterID(nodes.Items, channels.Items)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if ordNode.Annotations == nil {
+		ordNode.Annotations = map[string]string{}
+	}
+	ordNode.Annotations[utils.ConsenterIDAnnotation] = strconv.FormatUint(uint64(consenterID), 10)
+	updated, err := hlfClientSet.HlfV1alpha1().FabricOrdererNodes(ordNode.Namespace).Update(ctx, ordNode, v1.UpdateOptions{})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to persist the consenter ID of orderer node %s/%s", ordNode.Namespace, ordNode.Name)
+	}
+	ordNode.ObjectMeta = updated.ObjectMeta
+	return consenterID, nil
+}
+
+func nextConsenterID(nodes []hlfv1alpha1.FabricOrdererNode, channels []hlfv1alpha1.FabricMainChannel) (uint32, error) {
+	var maxID uint32
+	addID := func(id uint32) {
+		if id > maxID {
+			maxID = id
+		}
+	}
+	for _, node := range nodes {
+		addID(node.Status.ConsenterID)
+		if value, ok := node.Annotations[utils.ConsenterIDAnnotation]; ok {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return 0, errors.Wrapf(err, "invalid %s annotation in orderer node %s/%s", utils.ConsenterIDAnnotation, node.Namespace, node.Name)
+			}
+			addID(uint32(id))
+		}
+	}
+	for _, channel := range channels {
+		for _, consenter := range channel.Spec.Consenters {
+			addID(consenter.ID)
+		}
+		ids, err := utils.ParseConsenterIDs(channel.Annotations[utils.ConsenterIDsAnnotation])
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid %s annotation in channel %s", utils.ConsenterIDsAnnotation, channel.Name)
+		}
+		for _, id := range ids {
+			addID(id)
+		}
+	}
+	return maxID + 1, nil
+}
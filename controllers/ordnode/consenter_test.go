@@ -0,0 +1,47 @@
+package ordnode
+
+import (
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNextConsenterID(t *testing.T) {
+	g := NewWithT(t)
+	node := func(id uint32) hlfv1alpha1.FabricOrdererNode {
+		return hlfv1alpha1.FabricOrdererNode{Status: hlfv1alpha1.FabricOrdererNodeStatus{ConsenterID: id}}
+	}
+	annotatedNode := func(id string) hlfv1alpha1.FabricOrdererNode {
+		return hlfv1alpha1.FabricOrdererNode{ObjectMeta: v1.ObjectMeta{Annotations: map[string]string{utils.ConsenterIDAnnotation: id}}}
+	}
+	channel := func(consenterIDs string, specIDs ...uint32) hlfv1alpha1.FabricMainChannel {
+		channel := hlfv1alpha1.FabricMainChannel{ObjectMeta: v1.ObjectMeta{Annotations: map[string]string{utils.ConsenterIDsAnnotation: consenterIDs}}}
+		for _, id := range specIDs {
+			channel.Spec.Consenters = append(channel.Spec.Consenters, hlfv1alpha1.FabricMainChannelConsenter{ID: id})
+		}
+		return channel
+	}
+	nextID := func(nodes []hlfv1alpha1.FabricOrdererNode, channels []hlfv1alpha1.FabricMainChannel) uint32 {
+		id, err := nextConsenterID(nodes, channels)
+		g.Expect(err).NotTo(HaveOccurred())
+		return id
+	}
+
+	g.Expect(nextID(nil, nil)).To(Equal(uint32(1)))
+	g.Expect(nextID([]hlfv1alpha1.FabricOrdererNode{node(0), node(0)}, nil)).To(Equal(uint32(1)))
+	// the IDs of the deleted nodes in the middle aren't reused
+	g.Expect(nextID([]hlfv1alpha1.FabricOrdererNode{node(1), node(4), node(0)}, nil)).To(Equal(uint32(5)))
+	// the annotation survives the loss of the status
+	g.Expect(nextID([]hlfv1alpha1.FabricOrdererNode{node(1), annotatedNode("6")}, nil)).To(Equal(uint32(7)))
+	// the IDs of the consenter mappings aren't reused even when their orderer nodes are deleted
+	g.Expect(nextID([]hlfv1alpha1.FabricOrdererNode{node(1)}, []hlfv1alpha1.FabricMainChannel{channel("1,2,9")})).To(Equal(uint32(10)))
+	g.Expect(nextID([]hlfv1alpha1.FabricOrdererNode{node(1)}, []hlfv1alpha1.FabricMainChannel{channel("", 3, 11)})).To(Equal(uint32(12)))
+
+	_, err := nextConsenterID([]hlfv1alpha1.FabricOrdererNode{annotatedNode("first")}, nil)
+	g.Expect(err).To(HaveOccurred())
+	_, err = nextConsenterID(nil, []hlfv1alpha1.FabricMainChannel{channel("1,x")})
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,289 @@
+package ordnode
+
+import (
+	"context"
+	cryptotls "crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/operations"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/osnadmin"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/kfsoftware/hlf-operator/pkg/status"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	healthCheckTimeout  = 10 * time.Second
+	healthCheckInterval = time.Minute
+	// adminPort is the port of the service that targets the channel participation API of the orderer
+	adminPort = 7053
+	// maxHeightLag is the number of blocks an orderer can be behind the other orderers of a channel before it's
+	// considered degraded
+	maxHeightLag = 10
+)
+
+// refreshHealth refreshes the health, the channels and the raft roles of the running orderers every
+// healthCheckInterval until the context is done. Only the status of the orderers is updated, so the chart isn't
+// upgraded by the periodic checks
+func (r *FabricOrdererNodeReconciler) refreshHealth(ctx context.Context) error {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			r.refreshOrderersHealth(ctx)
+		}
+	}
+}
+
+func (r *FabricOrdererNodeReconciler) refreshOrderersHealth(ctx context.Context) {
+	clientSet, err := utils.GetClientKubeWithConf(r.Config)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to refresh the health of the orderers: %v", err))
+		return
+	}
+	hlfClientSet, err := operatorv1.NewForConfig(r.Config)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to refresh the health of the orderers: %v", err))
+		return
+	}
+	nodeList := &hlfv1alpha1.FabricOrdererNodeList{}
+	err = r.List(ctx, nodeList)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to refresh the health of the orderers: %v", err))
+		return
+	}
+	for i := range nodeList.Items {
+		ordNode := &nodeList.Items[i]
+		if ordNode.DeletionTimestamp != nil || !isHealthChecked(ordNode.Status.Status) {
+			continue
+		}
+		fOrderer := ordNode.DeepCopy()
+		resetHealth(&fOrderer.Status)
+		setOrdererHealth(ctx, r.Config, clientSet, hlfClientSet, ordNode, ordNode.Name, ordNode.Namespace, &fOrderer.Status)
+		if !ordNode.Status.Conditions.IsTrueFor(utils.LogSpecAppliedCondition) {
+			r.setLogSpec(ctx, ordNode, &fOrderer.Status)
+		}
+		fOrderer.Status.Conditions.SetCondition(status.Condition{
+			Type:   status.ConditionType(fOrderer.Status.Status),
+			Status: "True",
+		})
+		if reflect.DeepEqual(fOrderer.Status, ordNode.Status) {
+			continue
+		}
+		err = r.Status().Update(ctx, fOrderer)
+		if err != nil {
+			log.Warning(fmt.Sprintf("Failed to update the health of orderer %s: %v", ordNode.Name, err))
+		}
+	}
+}
+
+// isHealthChecked returns true for the orderers whose pods are running, the other orderers are requeued by the
+// reconcile until they're running
+func isHealthChecked(deploymentStatus hlfv1alpha1.DeploymentStatus) bool {
+	return deploymentStatus == hlfv1alpha1.RunningStatus || deploymentStatus == hlfv1alpha1.DegradedStatus
+}
+
+// resetHealth sets back a degraded orderer to running, so it only stays degraded while a health check fails
+func resetHealth(r *hlfv1alpha1.FabricOrdererNodeStatus) {
+	if r.Status == hlfv1alpha1.DegradedStatus {
+		r.Status = hlfv1alpha1.RunningStatus
+		r.Message = ""
+	}
+}
+
+// setOrdererHealth queries the operations endpoint and the channel participation API of a running orderer, the
+// orderer is degraded when a health check fails or when it lags behind the other orderers of a channel. The operations
+// endpoint is reached through the API server, when it can't be reached the status is kept and the OperationsReachable
+// condition is false
+func setOrdererHealth(ctx context.Context, config *rest.Config, clientSet *kubernetes.Clientset, hlfClientSet *operatorv1.Clientset, ordNode *hlfv1alpha1.FabricOrdererNode, releaseName string, ns string, r *hlfv1alpha1.FabricOrdererNodeStatus) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	opsClient, err := operations.NewServiceProxyClient(config, ns, ordNode.Name, operationsPort)
+	if err != nil {
+		r.Conditions.SetCondition(utils.GetOperationsReachableCondition(err))
+		return
+	}
+	health, err := opsClient.GetHealth(ctx)
+	r.Conditions.SetCondition(utils.GetOperationsReachableCondition(err))
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to query the health of orderer %s: %v", ordNode.Name, err))
+		return
+	}
+	r.Health = &hlfv1alpha1.FabricNodeHealth{
+		Status: health.Status,
+	}
+	var problems []string
+	for _, check := range health.FailedChecks {
+		r.Health.FailedChecks = append(r.Health.FailedChecks, hlfv1alpha1.FabricNodeFailedCheck{
+			Component: check.Component,
+			Reason:    check.Reason,
+		})
+		problems = append(problems, fmt.Sprintf("%s: %s", check.Component, check.Reason))
+	}
+	if !ordNode.Spec.ChannelParticipationEnabled {
+		setDegraded(r, problems)
+		return
+	}
+	channels, err := getOrdererChannels(ctx, clientSet, releaseName, ns)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to query the channels of orderer %s: %v", ordNode.Name, err))
+		setDegraded(r, problems)
+		return
+	}
+	err = setRaftRoles(ctx, opsClient, channels)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to get the raft roles of orderer %s: %v", ordNode.Name, err))
+	}
+	maxHeights, err := getMaxChannelHeights(ctx, hlfClientSet, ordNode)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to get the heights of the other orderers: %v", err))
+	}
+	for _, channel := range channels {
+		switch channel.Status {
+		case string(osnadmin.StatusFailed), string(osnadmin.StatusOnBoarding):
+			problems = append(problems, fmt.Sprintf("channel %s is %s", channel.Name, channel.Status))
+			continue
+		}
+		maxHeight := maxHeights[channel.Name]
+		if maxHeight > channel.Height+maxHeightLag {
+			problems = append(problems, fmt.Sprintf("channel %s is at height %d, other orderers are at height %d", channel.Name, channel.Height, maxHeight))
+		}
+	}
+	r.Channels = channels
+	setDegraded(r, problems)
+}
+
+func setDegraded(r *hlfv1alpha1.FabricOrdererNodeStatus, problems []string) {
+	if len(problems) == 0 {
+		return
+	}
+	r.Status = hlfv1alpha1.DegradedStatus
+	r.Message = strings.Join(problems, ", ")
+}
+
+// getOrdererChannels returns the channels joined by the orderer, the channel participation API is called with the
+// admin TLS certificate of the orderer, which is issued by the TLS CA trusted for the admin clients
+func getOrdererChannels(ctx context.Context, clientSet *kubernetes.Clientset, releaseName string, ns string) ([]hlfv1alpha1.FabricOrdererNodeChannelStatus, error) {
+	adminCrt, adminKey, adminRootCrt, _, err := getExistingTLSAdminCrypto(clientSet, releaseName, ns)
+	if err != nil {
+		return nil, err
+	}
+	certPool := x509.NewCertPool()
+	certPool.AddCert(adminRootCrt)
+	tlsClientCert := cryptotls.Certificate{
+		Certificate: [][]byte{adminCrt.Raw},
+		PrivateKey:  adminKey,
+	}
+	osnURL := fmt.Sprintf("https://%s.%s:%d", releaseName, ns, adminPort)
+	channelList := &osnadmin.ChannelList{}
+	resp, err := osnadmin.ListAllChannels(osnURL, certPool, tlsClientCert)
+	if err != nil {
+		return nil, err
+	}
+	err = decodeOsnAdminResponse(resp, channelList)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the channels of the orderer")
+	}
+	var channels []hlfv1alpha1.FabricOrdererNodeChannelStatus
+	for _, channel := range channelList.Channels {
+		resp, err = osnadmin.ListSingleChannel(osnURL, channel.Name, certPool, tlsClientCert)
+		if err != nil {
+			return nil, err
+		}
+		chInfo := &osnadmin.ChannelInfo{}
+		err = decodeOsnAdminResponse(resp, chInfo)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get channel %s of the orderer", channel.Name)
+		}
+		channels = append(channels, hlfv1alpha1.FabricOrdererNodeChannelStatus{
+			Name:              chInfo.Name,
+			Height:            chInfo.Height,
+			ConsensusRelation: string(chInfo.ConsensusRelation),
+			Status:            string(chInfo.Status),
+		})
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].Name < channels[j].Name
+	})
+	return channels, nil
+}
+
+func decodeOsnAdminResponse(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("channel participation API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// setRaftRoles sets the raft role of the channels where the orderer is a consenter, it's read from the
+// consensus_etcdraft_is_leader metric
+func setRaftRoles(ctx context.Context, opsClient *operations.Client, channels []hlfv1alpha1.FabricOrdererNodeChannelStatus) error {
+	metrics, err := opsClient.GetMetrics(ctx)
+	if err != nil {
+		return err
+	}
+	leaders := map[string]bool{}
+	if family, ok := metrics["consensus_etcdraft_is_leader"]; ok {
+		for _, metric := range family.Metric {
+			for _, label := range metric.Label {
+				if label.GetName() == "channel" && metric.Gauge != nil {
+					leaders[label.GetValue()] = metric.Gauge.GetValue() == 1
+				}
+			}
+		}
+	}
+	for i, channel := range channels {
+		if channel.ConsensusRelation != string(osnadmin.ConsensusRelationConsenter) {
+			continue
+		}
+		if leaders[channel.Name] {
+			channels[i].RaftRole = "leader"
+		} else {
+			channels[i].RaftRole = "follower"
+		}
+	}
+	return nil
+}
+
+// getMaxChannelHeights returns the highest height of each channel published in the status of the other orderer nodes,
+// the published heights are never ahead of the real ones, so a lag is never reported when there isn't one
+func getMaxChannelHeights(ctx context.Context, hlfClientSet *operatorv1.Clientset, ordNode *hlfv1alpha1.FabricOrdererNode) (map[string]uint64, error) {
+	nodes, err := hlfClientSet.HlfV1alpha1().FabricOrdererNodes("").List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	maxHeights := map[string]uint64{}
+	for _, node := range nodes.Items {
+		if node.Name == ordNode.Name && node.Namespace == ordNode.Namespace {
+			continue
+		}
+		for _, channel := range node.Status.Channels {
+			if channel.Height > maxHeights[channel.Name] {
+				maxHeights[channel.Name] = channel.Height
+			}
+		}
+	}
+	return maxHeights, nil
+}
@@ -2,7 +2,7 @@ package ordnode
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"crypto"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
@@ -12,7 +12,11 @@ import (
 	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
 	"github.com/kfsoftware/hlf-operator/controllers/certs"
 	"github.com/kfsoftware/hlf-operator/controllers/hlfmetrics"
+	"github.com/kfsoftware/hlf-operator/controllers/overrides"
 	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/operations"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
 	"github.com/kfsoftware/hlf-operator/pkg/status"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -33,8 +37,14 @@ import (
 	"os"
 	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 	"strings"
 	"time"
 )
@@ -144,11 +154,20 @@ func (r *FabricOrdererNodeReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	if err != nil {
 		return ctrl.Result{}, err
 	}
+	hlfClientSet, err := operatorv1.NewForConfig(r.Config)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.reconcilePodDisruptionBudgets(ctx, clientSet, ns)
+	if err != nil {
+		r.setConditionStatus(ctx, fabricOrdererNode, hlfv1alpha1.FailedStatus, false, err, false)
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricOrdererNode)
+	}
 	if exists {
 		// update
 
 		log.Printf("Status hasn't changed, skipping update")
-		c, err := getConfig(fabricOrdererNode, clientSet, releaseName, req.Namespace, false)
+		c, err := getConfig(fabricOrdererNode, clientSet, hlfClientSet, releaseName, req.Namespace, false)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -168,7 +187,7 @@ func (r *FabricOrdererNodeReconciler) Reconcile(ctx context.Context, req ctrl.Re
 					// update the certs
 					// scale up the peer
 					log.Infof("Trying to upgrade certs")
-					err := r.updateCerts(req, fabricOrdererNode, clientSet, releaseName, ctx, cfg, ns)
+					err := r.updateCerts(req, fabricOrdererNode, clientSet, hlfClientSet, releaseName, ctx, cfg, ns)
 					if err != nil {
 						log.Errorf("Error renewing certs: %v", err)
 						r.setConditionStatus(ctx, fabricOrdererNode, hlfv1alpha1.FailedStatus, false, err, false)
@@ -179,7 +198,7 @@ func (r *FabricOrdererNodeReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			}
 		} else if fabricOrdererNode.Status.LastCertificateUpdate == nil && fabricOrdererNode.Spec.UpdateCertificateTime != nil {
 			log.Infof("Trying to upgrade certs")
-			err := r.updateCerts(req, fabricOrdererNode, clientSet, releaseName, ctx, cfg, ns)
+			err := r.updateCerts(req, fabricOrdererNode, clientSet, hlfClientSet, releaseName, ctx, cfg, ns)
 			if err != nil {
 				log.Errorf("Error renewing certs: %v", err)
 				r.setConditionStatus(ctx, fabricOrdererNode, hlfv1alpha1.FailedStatus, false, err, false)
@@ -192,9 +211,23 @@ func (r *FabricOrdererNodeReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			log.Printf("Failed to get orderer state=%v", err)
 			return ctrl.Result{}, err
 		}
+		ordererOverrides, err := overrides.Orderer.Apply(fabricOrdererNode.Spec.OrdererOverrides)
+		if err != nil {
+			r.setConditionStatus(ctx, fabricOrdererNode, hlfv1alpha1.FailedStatus, false, err, false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricOrdererNode)
+		}
+		consenterID, err := getConsenterID(ctx, hlfClientSet, fabricOrdererNode)
+		if err != nil {
+			r.setConditionStatus(ctx, fabricOrdererNode, hlfv1alpha1.FailedStatus, false, err, false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricOrdererNode)
+		}
 		fOrderer := fabricOrdererNode.DeepCopy()
+		fOrderer.Status.ConsenterID = consenterID
 		fOrderer.Status.Status = s.Status
-		fOrderer.Status.Message = ""
+		fOrderer.Status.Message = s.Message
+		fOrderer.Status.Warnings = ordererOverrides.Warnings
+		fOrderer.Status.Health = s.Health
+		fOrderer.Status.Channels = s.Channels
 		fOrderer.Status.NodePort = s.NodePort
 		fOrderer.Status.TlsCert = s.TlsCert
 		fOrderer.Status.SignCert = s.SignCert
@@ -208,6 +241,12 @@ func (r *FabricOrdererNodeReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			Type:   status.ConditionType(s.Status),
 			Status: "True",
 		})
+		for _, condition := range s.Conditions {
+			fOrderer.Status.Conditions.SetCondition(condition)
+		}
+		if isHealthChecked(s.Status) {
+			r.setLogSpec(ctx, fabricOrdererNode, &fOrderer.Status)
+		}
 
 		if !reflect.DeepEqual(fOrderer.Status, fabricOrdererNode.Status) {
 			if err := r.Status().Update(ctx, fOrderer); err != nil {
@@ -222,7 +261,8 @@ func (r *FabricOrdererNodeReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			return ctrl.Result{
 				RequeueAfter: 10 * time.Second,
 			}, nil
-		case hlfv1alpha1.RunningStatus:
+		case hlfv1alpha1.RunningStatus, hlfv1alpha1.DegradedStatus:
+			// the health and the heights of the channels are refreshed by refreshHealth
 			return ctrl.Result{}, nil
 		case hlfv1alpha1.FailedStatus:
 			log.Infof("Orderer %s in failed status", fabricOrdererNode.Name)
@@ -246,7 +286,7 @@ func (r *FabricOrdererNodeReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		if err != nil {
 			return ctrl.Result{}, err
 		}
-		c, err := getConfig(fabricOrdererNode, clientSet, releaseName, req.Namespace, false)
+		c, err := getConfig(fabricOrdererNode, clientSet, hlfClientSet, releaseName, req.Namespace, false)
 		if err != nil {
 			reqLogger.Error(err, fmt.Sprintf("Failed to get config for orderer %s/%s", req.Namespace, req.Name))
 			return ctrl.Result{}, err
@@ -350,16 +390,34 @@ func (r *FabricOrdererNodeReconciler) setConditionStatus(
 }
 
 func (r *FabricOrdererNodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.Add(manager.RunnableFunc(r.refreshHealth))
+	if err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&hlfv1alpha1.FabricOrdererNode{}).
 		Owns(&appsv1.Deployment{}).
+		// the PodDisruptionBudgets depend on the consenters of the channels
+		Watches(
+			&source.Kind{Type: &hlfv1alpha1.FabricMainChannel{}},
+			handler.EnqueueRequestsFromMapFunc(func(object client.Object) []reconcile.Request {
+				nodeList := &hlfv1alpha1.FabricOrdererNodeList{}
+				err := r.List(context.Background(), nodeList)
+				if err != nil {
+					log.Errorf("Failed to list the orderer nodes: %v", err)
+					return nil
+				}
+				return getPodDisruptionBudgetRequests(nodeList.Items)
+			}),
+			builder.WithPredicates(predicate.Funcs{UpdateFunc: consensusChanged}),
+		).
 		Complete(r)
 }
 
-func (r *FabricOrdererNodeReconciler) updateCerts(req ctrl.Request, node *hlfv1alpha1.FabricOrdererNode, clientSet *kubernetes.Clientset, releaseName string, ctx context.Context, cfg *action.Configuration, ns string) error {
+func (r *FabricOrdererNodeReconciler) updateCerts(req ctrl.Request, node *hlfv1alpha1.FabricOrdererNode, clientSet *kubernetes.Clientset, hlfClientSet *operatorv1.Clientset, releaseName string, ctx context.Context, cfg *action.Configuration, ns string) error {
 	log.Infof("Trying to upgrade certs")
 	r.setConditionStatus(ctx, node, hlfv1alpha1.UpdatingCertificates, false, nil, false)
-	config, err := getConfig(node, clientSet, releaseName, req.Namespace, true)
+	config, err := getConfig(node, clientSet, hlfClientSet, releaseName, req.Namespace, true)
 	if err != nil {
 		log.Errorf("Error getting the config: %v", err)
 		return err
@@ -418,6 +476,14 @@ func (r *FabricOrdererNodeReconciler) upgradeChart(
 	if err != nil {
 		return err
 	}
+	upToDate, err := utils.ReleaseUpToDate(cfg, releaseName, ch, inInterface)
+	if err != nil {
+		return err
+	}
+	if upToDate {
+		log.Debugf("Release %s is up to date, skipping the upgrade", releaseName)
+		return nil
+	}
 	cmd.Wait = true
 	cmd.Timeout = time.Minute * 5
 	release, err := cmd.Run(releaseName, ch, inInterface)
@@ -487,7 +553,7 @@ func restartDeployment(config *rest.Config, deployment *appsv1.Deployment) error
 	}
 	return nil
 }
-func getExistingTLSAdminCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, *x509.Certificate, error) {
+func getExistingTLSAdminCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, crypto.Signer, *x509.Certificate, *x509.Certificate, error) {
 	secretName := fmt.Sprintf("%s-admin", chartName)
 	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, v1.GetOptions{})
 	if err != nil {
@@ -498,7 +564,7 @@ func getExistingTLSAdminCrypto(client *kubernetes.Clientset, chartName string, n
 	tlsCrtData := secret.Data["tls.crt"]
 	rootTLSCrtData := secret.Data["cacert.crt"]
 	clientRootCrtData := secret.Data["clientcacert.crt"]
-	key, err := utils.ParseECDSAPrivateKey(tlsKeyData)
+	key, err := utils.ParsePrivateKey(tlsKeyData)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
@@ -517,7 +583,7 @@ func getExistingTLSAdminCrypto(client *kubernetes.Clientset, chartName string, n
 	return crt, key, rootCrt, clientRootCrt, nil
 }
 
-func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	secretName := fmt.Sprintf("%s-tls", chartName)
 	tlsRootSecretName := fmt.Sprintf("%s-tlsrootcert", chartName)
 	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, v1.GetOptions{})
@@ -532,7 +598,7 @@ func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namesp
 	tlsKeyData := secret.Data["tls.key"]
 	tlsCrtData := secret.Data["tls.crt"]
 	rootTLSCrtData := rootCertSecret.Data["cacert.pem"]
-	key, err := utils.ParseECDSAPrivateKey(tlsKeyData)
+	key, err := utils.ParsePrivateKey(tlsKeyData)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -547,7 +613,7 @@ func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namesp
 	return crt, key, rootCrt, nil
 }
 
-func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	secretCrtName := fmt.Sprintf("%s-idcert", chartName)
 	secretKeyName := fmt.Sprintf("%s-idkey", chartName)
 	secretRootCrtName := fmt.Sprintf("%s-cacert", chartName)
@@ -574,14 +640,18 @@ func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, names
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	key, err := utils.ParseECDSAPrivateKey(signKeyData)
-	if err != nil {
-		return nil, nil, nil, err
+	// the key is empty when it's kept in a PKCS#11 token
+	var key crypto.Signer
+	if len(signKeyData) > 0 {
+		key, err = utils.ParsePrivateKey(signKeyData)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 	}
 	return crt, key, rootCrt, nil
 }
 
-func CreateTLSCryptoMaterial(conf *hlfv1alpha1.FabricOrdererNode, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, hosts []string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func CreateTLSCryptoMaterial(conf *hlfv1alpha1.FabricOrdererNode, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, hosts []string) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	tlsCert, tlsKey, tlsRootCert, err := certs.EnrollUser(certs.EnrollUserRequest{
 		TLSCert:    tlsCertString,
 		URL:        caurl,
@@ -593,6 +663,7 @@ func CreateTLSCryptoMaterial(conf *hlfv1alpha1.FabricOrdererNode, caName string,
 		CN:         "",
 		Profile:    "tls",
 		Attributes: nil,
+		KeyRequest: conf.Spec.Secret.Enrollment.TLS.KeyRequest,
 	})
 	if err != nil {
 		return nil, nil, nil, err
@@ -600,7 +671,7 @@ func CreateTLSCryptoMaterial(conf *hlfv1alpha1.FabricOrdererNode, caName string,
 	return tlsCert, tlsKey, tlsRootCert, nil
 }
 
-func CreateTLSAdminCryptoMaterial(conf *hlfv1alpha1.FabricOrdererNode, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, hosts []string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, *x509.Certificate, error) {
+func CreateTLSAdminCryptoMaterial(conf *hlfv1alpha1.FabricOrdererNode, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, hosts []string) (*x509.Certificate, crypto.Signer, *x509.Certificate, *x509.Certificate, error) {
 	tlsCert, tlsKey, tlsRootCert, err := certs.EnrollUser(
 		certs.EnrollUserRequest{
 			TLSCert:    tlsCertString,
@@ -613,6 +684,7 @@ func CreateTLSAdminCryptoMaterial(conf *hlfv1alpha1.FabricOrdererNode, caName st
 			CN:         "",
 			Profile:    "tls",
 			Attributes: nil,
+			KeyRequest: conf.Spec.Secret.Enrollment.TLS.KeyRequest,
 		},
 	)
 	if err != nil {
@@ -621,14 +693,20 @@ func CreateTLSAdminCryptoMaterial(conf *hlfv1alpha1.FabricOrdererNode, caName st
 	return tlsCert, tlsKey, tlsRootCert, tlsRootCert, nil
 }
 
-func CreateSignCryptoMaterial(conf *hlfv1alpha1.FabricOrdererNode, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func CreateSignCryptoMaterial(conf *hlfv1alpha1.FabricOrdererNode, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, pkcs11Opts *certs.PKCS11Opts) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
+	keyRequest := conf.Spec.Secret.Enrollment.Component.KeyRequest
+	if err := certs.ValidateSignKeyRequest(keyRequest); err != nil {
+		return nil, nil, nil, err
+	}
 	tlsCert, tlsKey, tlsRootCert, err := certs.EnrollUser(certs.EnrollUserRequest{
-		TLSCert: tlsCertString,
-		URL:     caurl,
-		Name:    caName,
-		MSPID:   conf.Spec.MspID,
-		User:    enrollID,
-		Secret:  enrollSecret,
+		TLSCert:    tlsCertString,
+		URL:        caurl,
+		Name:       caName,
+		MSPID:      conf.Spec.MspID,
+		User:       enrollID,
+		Secret:     enrollSecret,
+		KeyRequest: keyRequest,
+		PKCS11:     pkcs11Opts,
 	})
 	if err != nil {
 		return nil, nil, nil, err
@@ -636,9 +714,50 @@ func CreateSignCryptoMaterial(conf *hlfv1alpha1.FabricOrdererNode, caName string
 	return tlsCert, tlsKey, tlsRootCert, nil
 }
 
+// operationsPort is the port of the service that targets the operations endpoint of the orderer
+const operationsPort = 9443
+
+func getLogSpec(spec hlfv1alpha1.FabricOrdererNodeSpec) string {
+	if spec.Logging == nil || spec.Logging.Spec == "" {
+		return "info"
+	}
+	return spec.Logging.Spec
+}
+
+// setLogSpec applies the logging spec to the running orderer, a failure doesn't requeue the reconcile, it's reported in
+// the LogSpecApplied condition and retried by refreshHealth
+func (r *FabricOrdererNodeReconciler) setLogSpec(ctx context.Context, node *hlfv1alpha1.FabricOrdererNode, ordererStatus *hlfv1alpha1.FabricOrdererNodeStatus) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	err := applyLogSpec(ctx, r.Config, node)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to apply the logging spec to orderer %s: %v", node.Name, err))
+	}
+	ordererStatus.Conditions.SetCondition(utils.GetLogSpecAppliedCondition(err))
+}
+
+// applyLogSpec sets the logging spec on the running orderer through its operations endpoint, the config map keeps the
+// spec for the next restarts so the orderer doesn't need to be restarted
+func applyLogSpec(ctx context.Context, config *rest.Config, node *hlfv1alpha1.FabricOrdererNode) error {
+	spec := getLogSpec(node.Spec)
+	opsClient, err := operations.NewServiceProxyClient(config, node.Namespace, node.Name, operationsPort)
+	if err != nil {
+		return err
+	}
+	changed, err := opsClient.ApplyLogSpec(ctx, spec)
+	if err != nil {
+		return err
+	}
+	if changed {
+		log.Infof("Logging spec of orderer %s set to %s", node.Name, spec)
+	}
+	return nil
+}
+
 func getConfig(
 	conf *hlfv1alpha1.FabricOrdererNode,
 	client *kubernetes.Clientset,
+	hlfClientSet *operatorv1.Clientset,
 	chartName string,
 	namespace string,
 	refreshCerts bool,
@@ -650,7 +769,7 @@ func getConfig(
 	ingressHosts := []string{}
 	tlsHosts = append(tlsHosts, tlsParams.Csr.Hosts...)
 	var tlsCert, tlsRootCert, adminCert, adminRootCert, adminClientRootCert, signCert, signRootCert *x509.Certificate
-	var tlsKey, adminKey, signKey *ecdsa.PrivateKey
+	var tlsKey, adminKey, signKey crypto.Signer
 	var err error
 	if refreshCerts {
 		cacert, err := base64.StdEncoding.DecodeString(tlsParams.Catls.Cacert)
@@ -730,6 +849,10 @@ func getConfig(
 	}
 	signParams := conf.Spec.Secret.Enrollment.Component
 	caUrl := fmt.Sprintf("https://%s:%d", signParams.Cahost, signParams.Caport)
+	pkcs11Opts, err := certs.GetPKCS11Opts(context.Background(), client, namespace, spec.PKCS11)
+	if err != nil {
+		return nil, err
+	}
 	if refreshCerts {
 		cacert, err := base64.StdEncoding.DecodeString(signParams.Catls.Cacert)
 		if err != nil {
@@ -742,13 +865,15 @@ func getConfig(
 			signParams.Enrollid,
 			signParams.Enrollsecret,
 			string(cacert),
+			pkcs11Opts,
 		)
 		if err != nil {
 			return nil, err
 		}
 	} else {
 		signCert, signKey, signRootCert, err = getExistingSignCrypto(client, chartName, namespace)
-		if err != nil {
+		// the key is enrolled again when it isn't stored where the BCCSP of the orderer expects it
+		if err != nil || (signKey == nil) != (pkcs11Opts != nil) {
 			cacert, err := base64.StdEncoding.DecodeString(signParams.Catls.Cacert)
 			if err != nil {
 				return nil, err
@@ -760,6 +885,7 @@ func getConfig(
 				signParams.Enrollid,
 				signParams.Enrollsecret,
 				string(cacert),
+				pkcs11Opts,
 			)
 			if err != nil {
 				return nil, err
@@ -804,9 +930,12 @@ func getConfig(
 		Type:  "CERTIFICATE",
 		Bytes: signRootCert.Raw,
 	})
-	signEncodedPK, err := utils.EncodePrivateKey(signKey)
-	if err != nil {
-		return nil, err
+	var signEncodedPK []byte
+	if pkcs11Opts == nil {
+		signEncodedPK, err = utils.EncodePrivateKey(signKey)
+		if err != nil {
+			return nil, err
+		}
 	}
 	var hostAliases []HostAlias
 	for _, hostAlias := range spec.HostAliases {
@@ -877,6 +1006,16 @@ func getConfig(
 			Memory: spec.Resources.Limits.Memory().String(),
 		},
 	}
+	ordererOverrides, err := overrides.Orderer.Apply(spec.OrdererOverrides)
+	if err != nil {
+		return nil, err
+	}
+	// the env of the spec is set after the overrides so it takes precedence
+	envVars := append(ordererOverrides.EnvVars, spec.Env...)
+	clientAuth, clientCerts, err := getClientAuthValues(context.Background(), hlfClientSet, spec, string(tlsRootCRTEncoded))
+	if err != nil {
+		return nil, err
+	}
 	proxy := GRPCProxy{
 		Enabled:          false,
 		Image:            "",
@@ -904,9 +1043,12 @@ func getConfig(
 
 	fabricOrdChart := fabricOrdChart{
 		Affinity:                    spec.Affinity,
+		TopologySpreadConstraints:   spec.TopologySpreadConstraints,
+		PriorityClassName:           spec.PriorityClassName,
 		NodeSelector:                spec.NodeSelector,
 		ImagePullSecrets:            spec.ImagePullSecrets,
-		EnvVars:                     spec.Env,
+		EnvVars:                     envVars,
+		PKCS11:                      getPKCS11Chart(spec.PKCS11),
 		Resources:                   resources,
 		Istio:                       istio,
 		AdminIstio:                  adminIstio,
@@ -936,7 +1078,7 @@ func getConfig(
 		Service: service{
 			Type:               string(spec.Service.Type),
 			Port:               7050,
-			PortOperations:     9443,
+			PortOperations:     operationsPort,
 			NodePort:           spec.Service.NodePortRequest,
 			NodePortOperations: spec.Service.NodePortOperations,
 		},
@@ -959,14 +1101,12 @@ func getConfig(
 				Server: ordServer{
 					Enabled: true,
 				},
-				Client: ordClient{
-					Enabled: false,
-				},
+				Client: clientAuth,
 			},
 		},
-		Clientcerts:    clientcerts{},
+		Clientcerts:    clientCerts,
 		Hosts:          ingressHosts,
-		Logging:        Logging{Spec: "info"},
+		Logging:        Logging{Spec: getLogSpec(spec)},
 		ServiceMonitor: monitor,
 	}
 
@@ -1105,5 +1245,53 @@ func GetOrdererState(conf *action.Configuration, config *rest.Config, releaseNam
 			}
 		}
 	}
+	if r.Status == hlfv1alpha1.RunningStatus {
+		hlfClientSet, err := operatorv1.NewForConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		setOrdererHealth(ctx, config, clientSet, hlfClientSet, ordNode, releaseName, ns, r)
+	}
 	return r, nil
 }
+
+func getPKCS11Chart(conf *hlfv1alpha1.BCCSPPKCS11) *PKCS11 {
+	if conf == nil {
+		return nil
+	}
+	hash := conf.Hash
+	if hash == "" {
+		hash = "SHA2"
+	}
+	security := conf.Security
+	if security == 0 {
+		security = 256
+	}
+	return &PKCS11{
+		Library:  conf.Library,
+		Label:    conf.Label,
+		Hash:     hash,
+		Security: security,
+		PinSecret: PKCS11PinSecret{
+			Name: conf.Pin.Name,
+			Key:  conf.Pin.Key,
+		},
+	}
+}
+
+// getClientAuthValues returns the client TLS settings of the chart and the files of its client root CAs folder, the TLS
+// root of the orderer in cert.pem and the other trusted roots in cert-<index>.pem, the orderer expands the folder
+// before starting
+func getClientAuthValues(ctx context.Context, hlfClientSet operatorv1.Interface, spec hlfv1alpha1.FabricOrdererNodeSpec, tlsRootCert string) (ordClient, map[string]string, error) {
+	clientRootCerts, err := helpers.GetClientAuthRootCerts(ctx, hlfClientSet, spec.MspID, spec.ClientAuth)
+	if err != nil {
+		return ordClient{}, nil, errors.Wrap(err, "failed to get the client root certificates")
+	}
+	clientCerts := map[string]string{
+		"cert.pem": tlsRootCert,
+	}
+	for i, rootCert := range clientRootCerts {
+		clientCerts[fmt.Sprintf("cert-%d.pem", i)] = rootCert
+	}
+	return ordClient{Enabled: spec.ClientAuth != nil && spec.ClientAuth.Enabled}, clientCerts, nil
+}
@@ -0,0 +1,172 @@
+package ordnode
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	hlffake "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestApplyLogSpec(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	active := "info"
+	var puts []string
+	// the operations endpoint of ord-node1 behind the service proxy of the API server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/default/services/http:ord-node1:9443/proxy/logspec" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]string{"spec": active})
+		case http.MethodPut:
+			spec := map[string]string{}
+			_ = json.NewDecoder(r.Body).Decode(&spec)
+			if spec["spec"] == "invalid" {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid logging specification"})
+				return
+			}
+			active = spec["spec"]
+			puts = append(puts, active)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	config := &rest.Config{Host: server.URL}
+	node := &hlfv1alpha1.FabricOrdererNode{
+		ObjectMeta: v1.ObjectMeta{Name: "ord-node1", Namespace: "default"},
+	}
+
+	// info is the default spec of the orderers
+	g.Expect(applyLogSpec(ctx, config, node)).To(Succeed())
+	g.Expect(puts).To(BeEmpty())
+
+	node.Spec.Logging = &hlfv1alpha1.FabricOrdererNodeLogging{Spec: "info:orderer.consensus.etcdraft=debug"}
+	g.Expect(applyLogSpec(ctx, config, node)).To(Succeed())
+	g.Expect(puts).To(Equal([]string{"info:orderer.consensus.etcdraft=debug"}))
+	g.Expect(applyLogSpec(ctx, config, node)).To(Succeed())
+	g.Expect(puts).To(HaveLen(1))
+
+	node.Spec.Logging.Spec = "invalid"
+	g.Expect(applyLogSpec(ctx, config, node)).To(MatchError(ContainSubstring("invalid logging specification")))
+
+	// the failure is reported in the status instead of failing the reconcile
+	r := &FabricOrdererNodeReconciler{Config: config}
+	ordererStatus := &hlfv1alpha1.FabricOrdererNodeStatus{}
+	r.setLogSpec(ctx, node, ordererStatus)
+	condition := ordererStatus.Conditions.GetCondition(utils.LogSpecAppliedCondition)
+	g.Expect(condition).NotTo(BeNil())
+	g.Expect(condition.IsFalse()).To(BeTrue())
+	g.Expect(condition.Message).To(ContainSubstring("invalid logging specification"))
+
+	node.Spec.Logging.Spec = "debug"
+	r.setLogSpec(ctx, node, ordererStatus)
+	g.Expect(ordererStatus.Conditions.IsTrueFor(utils.LogSpecAppliedCondition)).To(BeTrue())
+	g.Expect(active).To(Equal("debug"))
+}
+
+func TestGetClientAuthValues(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	hlfClientSet := hlffake.NewSimpleClientset(&hlfv1alpha1.FabricCA{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-ca", Namespace: "default"},
+		Status:     hlfv1alpha1.FabricCAStatus{TLSCACert: "org1-tls-root"},
+	})
+
+	// the TLS root of the orderer is always in the client root CAs folder
+	client, clientCerts, err := getClientAuthValues(ctx, hlfClientSet, hlfv1alpha1.FabricOrdererNodeSpec{MspID: "OrdererMSP"}, "orderer-tls-root")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client).To(Equal(ordClient{Enabled: false}))
+	g.Expect(clientCerts).To(Equal(map[string]string{"cert.pem": "orderer-tls-root"}))
+
+	client, clientCerts, err = getClientAuthValues(ctx, hlfClientSet, hlfv1alpha1.FabricOrdererNodeSpec{
+		MspID: "OrdererMSP",
+		ClientAuth: &hlfv1alpha1.FabricTLSClientAuth{
+			Enabled:   true,
+			CAs:       []hlfv1alpha1.FabricTLSClientAuthCA{{Name: "org1-ca", Namespace: "default"}},
+			RootCerts: []string{"org2-tls-root"},
+		},
+	}, "orderer-tls-root")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client).To(Equal(ordClient{Enabled: true}))
+	g.Expect(clientCerts).To(Equal(map[string]string{
+		"cert.pem":   "orderer-tls-root",
+		"cert-0.pem": "org1-tls-root",
+		"cert-1.pem": "org2-tls-root",
+	}))
+
+	// the chart writes every entry of clientcerts as a file of the folder
+	values, err := json.Marshal(fabricOrdChart{Clientcerts: clientCerts})
+	g.Expect(err).NotTo(HaveOccurred())
+	chartValues := map[string]interface{}{}
+	g.Expect(json.Unmarshal(values, &chartValues)).To(Succeed())
+	g.Expect(chartValues["clientcerts"]).To(Equal(map[string]interface{}{
+		"cert.pem":   "orderer-tls-root",
+		"cert-0.pem": "org1-tls-root",
+		"cert-1.pem": "org2-tls-root",
+	}))
+
+	// a FabricCA without a TLS CA certificate yet
+	_, _, err = getClientAuthValues(ctx, hlffake.NewSimpleClientset(&hlfv1alpha1.FabricCA{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-ca", Namespace: "default"},
+	}), hlfv1alpha1.FabricOrdererNodeSpec{
+		MspID: "OrdererMSP",
+		ClientAuth: &hlfv1alpha1.FabricTLSClientAuth{
+			Enabled: true,
+			CAs:     []hlfv1alpha1.FabricTLSClientAuthCA{{Name: "org1-ca", Namespace: "default"}},
+		},
+	}, "orderer-tls-root")
+	g.Expect(err).To(MatchError(ContainSubstring("FabricCA default/org1-ca has no TLS CA certificate yet")))
+}
+
+func TestGetPKCS11Chart(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(getPKCS11Chart(nil)).To(BeNil())
+
+	pin := corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "hsm-pin"}, Key: "pin"}
+	// the hash and the security level default to the ones of the SW BCCSP
+	pkcs11 := getPKCS11Chart(&hlfv1alpha1.BCCSPPKCS11{
+		Library: "/usr/lib/softhsm/libsofthsm2.so",
+		Label:   "fabric",
+		Pin:     pin,
+	})
+	g.Expect(pkcs11).To(Equal(&PKCS11{
+		Library:   "/usr/lib/softhsm/libsofthsm2.so",
+		Label:     "fabric",
+		Hash:      "SHA2",
+		Security:  256,
+		PinSecret: PKCS11PinSecret{Name: "hsm-pin", Key: "pin"},
+	}))
+
+	pkcs11 = getPKCS11Chart(&hlfv1alpha1.BCCSPPKCS11{
+		Library:  "/usr/lib/softhsm/libsofthsm2.so",
+		Label:    "fabric",
+		Pin:      pin,
+		Hash:     "SHA3",
+		Security: 384,
+	})
+	// the PIN stays in its secret, the chart only references it
+	values, err := json.Marshal(fabricOrdChart{PKCS11: pkcs11})
+	g.Expect(err).NotTo(HaveOccurred())
+	chartValues := map[string]interface{}{}
+	g.Expect(json.Unmarshal(values, &chartValues)).To(Succeed())
+	g.Expect(chartValues["pkcs11"]).To(Equal(map[string]interface{}{
+		"library":   "/usr/lib/softhsm/libsofthsm2.so",
+		"label":     "fabric",
+		"hash":      "SHA3",
+		"security":  float64(384),
+		"pinSecret": map[string]interface{}{"name": "hsm-pin", "key": "pin"},
+	}))
+}
@@ -0,0 +1,224 @@
+package ordnode
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/pkg/bft"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const ordererPodDisruptionBudgetKind = "orderers"
+
+// getFaultTolerance returns the consenters of the channel that can be down without losing the quorum
+func getFaultTolerance(channel *hlfv1alpha1.FabricMainChannel) int {
+	n := len(channel.Spec.Consenters)
+	if n == 0 {
+		return 0
+	}
+	if isBFT(channel) {
+		return n - bft.Quorum(n)
+	}
+	// raft needs a majority of the consenters
+	return n - (n/2 + 1)
+}
+
+func isConsenter(consenter hlfv1alpha1.FabricMainChannelConsenter, node *hlfv1alpha1.FabricOrdererNode) bool {
+	if consenter.OrdererNode != nil {
+		return consenter.OrdererNode.Name == node.Name && consenter.OrdererNode.Namespace == node.Namespace
+	}
+	return consenter.TLSCert != "" && strings.TrimSpace(consenter.TLSCert) == strings.TrimSpace(node.Status.TlsCert)
+}
+
+// getNamespaceFaultTolerance returns the consenters of the channel in the namespace that can be evicted at the same
+// time. A PodDisruptionBudget only counts the pods of its namespace, so when the consenters in the cluster are in
+// several namespaces the fault tolerance of the channel is split between them
+func getNamespaceFaultTolerance(channel *hlfv1alpha1.FabricMainChannel, consenters []*hlfv1alpha1.FabricOrdererNode, ns string) int {
+	var namespaces []string
+	for _, node := range consenters {
+		if !utils.Contains(namespaces, node.Namespace) {
+			namespaces = append(namespaces, node.Namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	faultTolerance := getFaultTolerance(channel)
+	for i, namespace := range namespaces {
+		if namespace != ns {
+			continue
+		}
+		share := faultTolerance / len(namespaces)
+		if i < faultTolerance%len(namespaces) {
+			share++
+		}
+		return share
+	}
+	return faultTolerance
+}
+
+// reconcilePodDisruptionBudgets creates a PodDisruptionBudget for each group of orderer nodes of the namespace that
+// have it enabled
+func (r *FabricOrdererNodeReconciler) reconcilePodDisruptionBudgets(ctx context.Context, clientSet kubernetes.Interface, ns string) error {
+	nodeList := &hlfv1alpha1.FabricOrdererNodeList{}
+	err := r.List(ctx, nodeList)
+	if err != nil {
+		return err
+	}
+	channelList := &hlfv1alpha1.FabricMainChannelList{}
+	err = r.List(ctx, channelList)
+	if err != nil {
+		return err
+	}
+	groups := getPodDisruptionBudgetGroups(nodeList.Items, channelList.Items, ns)
+	return utils.ReconcilePodDisruptionBudgets(ctx, clientSet, ns, ordererPodDisruptionBudgetKind, groups)
+}
+
+// getPodDisruptionBudgetGroups groups the orderer nodes of the namespace that have a PodDisruptionBudget enabled.
+// Orderer nodes that are consenters of the same channel are in the same group, and the pods of a group that can be
+// evicted at the same time are limited so every channel keeps its quorum. A group with a consenter of a channel that
+// can't lose any consenter gets no PodDisruptionBudget, it would block the node drains forever. A group whose share of
+// the fault tolerance of a channel split between namespaces is 0 gets a PodDisruptionBudget with maxUnavailable 0, so
+// the consenters of the other namespaces can be evicted without losing the quorum
+func getPodDisruptionBudgetGroups(
+	nodeList []hlfv1alpha1.FabricOrdererNode,
+	channels []hlfv1alpha1.FabricMainChannel,
+	ns string,
+) []utils.PodDisruptionBudgetGroup {
+	maxUnavailable := map[string]int{}
+	noFaultTolerance := map[string]bool{}
+	parents := map[string]string{}
+	var find func(name string) string
+	find = func(name string) string {
+		if parents[name] == name {
+			return name
+		}
+		parents[name] = find(parents[name])
+		return parents[name]
+	}
+	nodes := map[string]*hlfv1alpha1.FabricOrdererNode{}
+	for i := range nodeList {
+		node := &nodeList[i]
+		pdb := node.Spec.PodDisruptionBudget
+		if node.Namespace != ns || node.DeletionTimestamp != nil || pdb == nil || !pdb.Enabled {
+			continue
+		}
+		nodes[node.Name] = node
+		parents[node.Name] = node.Name
+		maxUnavailable[node.Name] = utils.GetMaxUnavailable(pdb.MaxUnavailable)
+	}
+	for i := range channels {
+		channel := &channels[i]
+		var consenters []*hlfv1alpha1.FabricOrdererNode
+		for j := range nodeList {
+			node := &nodeList[j]
+			for _, consenter := range channel.Spec.Consenters {
+				if isConsenter(consenter, node) {
+					consenters = append(consenters, node)
+					break
+				}
+			}
+		}
+		channelFaultTolerance := getFaultTolerance(channel)
+		faultTolerance := getNamespaceFaultTolerance(channel, consenters, ns)
+		var first string
+		for _, node := range consenters {
+			if _, ok := nodes[node.Name]; !ok || node.Namespace != ns {
+				continue
+			}
+			if channelFaultTolerance == 0 {
+				noFaultTolerance[node.Name] = true
+			}
+			if faultTolerance < maxUnavailable[node.Name] {
+				maxUnavailable[node.Name] = faultTolerance
+			}
+			if first == "" {
+				first = node.Name
+				continue
+			}
+			parents[find(node.Name)] = find(first)
+		}
+	}
+	groupsByRoot := map[string]*utils.PodDisruptionBudgetGroup{}
+	skippedRoots := map[string]bool{}
+	var names []string
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		root := find(name)
+		if noFaultTolerance[name] {
+			skippedRoots[root] = true
+		}
+		group, ok := groupsByRoot[root]
+		if !ok {
+			// the names are sorted, so the group is named after its first orderer node
+			group = &utils.PodDisruptionBudgetGroup{
+				Name:           fmt.Sprintf("%s-pdb", name),
+				MaxUnavailable: maxUnavailable[name],
+			}
+			groupsByRoot[root] = group
+		}
+		if maxUnavailable[name] < group.MaxUnavailable {
+			group.MaxUnavailable = maxUnavailable[name]
+		}
+		group.Releases = append(group.Releases, name)
+		group.Owners = append(group.Owners, utils.NewOwnerReference(nodes[name], hlfv1alpha1.GroupVersion.WithKind("FabricOrdererNode")))
+	}
+	var groups []utils.PodDisruptionBudgetGroup
+	for root, group := range groupsByRoot {
+		if skippedRoots[root] {
+			continue
+		}
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// getPodDisruptionBudgetRequests returns a request for an orderer node with a PodDisruptionBudget enabled in each
+// namespace, reconciling one of them recomputes the PodDisruptionBudgets of its namespace
+func getPodDisruptionBudgetRequests(nodes []hlfv1alpha1.FabricOrdererNode) []reconcile.Request {
+	var requests []reconcile.Request
+	namespaces := map[string]bool{}
+	for _, node := range nodes {
+		pdb := node.Spec.PodDisruptionBudget
+		if namespaces[node.Namespace] || node.DeletionTimestamp != nil || pdb == nil || !pdb.Enabled {
+			continue
+		}
+		namespaces[node.Namespace] = true
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: node.Name, Namespace: node.Namespace},
+		})
+	}
+	return requests
+}
+
+// consensusChanged returns true if the update of a channel changes its consenters or its consensus type, which
+// changes the PodDisruptionBudgets of the orderer nodes
+func consensusChanged(e event.UpdateEvent) bool {
+	oldChannel, ok := e.ObjectOld.(*hlfv1alpha1.FabricMainChannel)
+	if !ok {
+		return true
+	}
+	newChannel, ok := e.ObjectNew.(*hlfv1alpha1.FabricMainChannel)
+	if !ok {
+		return true
+	}
+	return !reflect.DeepEqual(oldChannel.Spec.Consenters, newChannel.Spec.Consenters) || isBFT(oldChannel) != isBFT(newChannel)
+}
+
+func isBFT(channel *hlfv1alpha1.FabricMainChannel) bool {
+	return channel.Spec.ChannelConfig != nil &&
+		channel.Spec.ChannelConfig.Orderer != nil &&
+		channel.Spec.ChannelConfig.Orderer.OrdererType == bft.ConsensusType
+}
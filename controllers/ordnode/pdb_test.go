@@ -0,0 +1,241 @@
+package ordnode
+
+import (
+	"fmt"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/pkg/bft"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newTestOrdererNode(name string, ns string, pdb *hlfv1alpha1.FabricPodDisruptionBudget) hlfv1alpha1.FabricOrdererNode {
+	return hlfv1alpha1.FabricOrdererNode{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: ns},
+		Spec:       hlfv1alpha1.FabricOrdererNodeSpec{PodDisruptionBudget: pdb},
+		Status:     hlfv1alpha1.FabricOrdererNodeStatus{TlsCert: fmt.Sprintf("%s-%s-tls", ns, name)},
+	}
+}
+
+func newTestChannel(ordererType string, nodes ...hlfv1alpha1.FabricOrdererNode) hlfv1alpha1.FabricMainChannel {
+	channel := hlfv1alpha1.FabricMainChannel{
+		Spec: hlfv1alpha1.FabricMainChannelSpec{
+			ChannelConfig: &hlfv1alpha1.FabricMainChannelConfig{
+				Orderer: &hlfv1alpha1.FabricMainChannelOrdererConfig{OrdererType: ordererType},
+			},
+		},
+	}
+	for _, node := range nodes {
+		channel.Spec.Consenters = append(channel.Spec.Consenters, hlfv1alpha1.FabricMainChannelConsenter{
+			OrdererNode: &hlfv1alpha1.FabricMainChannelOrdererNode{Name: node.Name, Namespace: node.Namespace},
+		})
+	}
+	return channel
+}
+
+func newTestConsenters(n int) []hlfv1alpha1.FabricOrdererNode {
+	var nodes []hlfv1alpha1.FabricOrdererNode
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, newTestOrdererNode(fmt.Sprintf("orderer%d", i), "default", nil))
+	}
+	return nodes
+}
+
+func TestGetFaultTolerance(t *testing.T) {
+	tests := []struct {
+		ordererType string
+		consenters  int
+		want        int
+	}{
+		{ordererType: "etcdraft", consenters: 0, want: 0},
+		{ordererType: "etcdraft", consenters: 1, want: 0},
+		{ordererType: "etcdraft", consenters: 2, want: 0},
+		{ordererType: "etcdraft", consenters: 3, want: 1},
+		{ordererType: "etcdraft", consenters: 4, want: 1},
+		{ordererType: "etcdraft", consenters: 5, want: 2},
+		{ordererType: bft.ConsensusType, consenters: 2, want: 0},
+		{ordererType: bft.ConsensusType, consenters: 3, want: 1},
+		{ordererType: bft.ConsensusType, consenters: 4, want: 1},
+		{ordererType: bft.ConsensusType, consenters: 7, want: 2},
+		{ordererType: bft.ConsensusType, consenters: 10, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s-%d", tt.ordererType, tt.consenters), func(t *testing.T) {
+			g := NewWithT(t)
+			channel := newTestChannel(tt.ordererType, newTestConsenters(tt.consenters)...)
+			g.Expect(getFaultTolerance(&channel)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGetNamespaceFaultTolerance(t *testing.T) {
+	g := NewWithT(t)
+	var consenters []*hlfv1alpha1.FabricOrdererNode
+	var nodes []hlfv1alpha1.FabricOrdererNode
+	for i, ns := range []string{"org1", "org1", "org2", "org2", "org3"} {
+		node := newTestOrdererNode(fmt.Sprintf("orderer%d", i), ns, nil)
+		nodes = append(nodes, node)
+		consenters = append(consenters, &node)
+	}
+	// raft with 5 consenters tolerates 2 failures, split between the sorted namespaces
+	channel := newTestChannel("etcdraft", nodes...)
+	g.Expect(getNamespaceFaultTolerance(&channel, consenters, "org1")).To(Equal(1))
+	g.Expect(getNamespaceFaultTolerance(&channel, consenters, "org2")).To(Equal(1))
+	g.Expect(getNamespaceFaultTolerance(&channel, consenters, "org3")).To(Equal(0))
+	// the consenters are all in the namespace
+	g.Expect(getNamespaceFaultTolerance(&channel, consenters[:1], "org1")).To(Equal(2))
+}
+
+func TestGetPodDisruptionBudgetGroups(t *testing.T) {
+	enabled := &hlfv1alpha1.FabricPodDisruptionBudget{Enabled: true}
+	two := 2
+	enabledTwo := &hlfv1alpha1.FabricPodDisruptionBudget{Enabled: true, MaxUnavailable: &two}
+	releases := func(groups []utils.PodDisruptionBudgetGroup) map[string][]string {
+		result := map[string][]string{}
+		for _, group := range groups {
+			result[group.Name] = group.Releases
+		}
+		return result
+	}
+
+	t.Run("consenters of the same channels are grouped", func(t *testing.T) {
+		g := NewWithT(t)
+		nodes := []hlfv1alpha1.FabricOrdererNode{
+			newTestOrdererNode("ord1", "default", enabledTwo),
+			newTestOrdererNode("ord2", "default", enabledTwo),
+			newTestOrdererNode("ord3", "default", enabledTwo),
+			newTestOrdererNode("ord4", "default", enabledTwo),
+			newTestOrdererNode("ord5", "default", enabledTwo),
+			newTestOrdererNode("standalone", "default", enabled),
+			newTestOrdererNode("disabled", "default", nil),
+			newTestOrdererNode("other", "other", enabled),
+		}
+		channels := []hlfv1alpha1.FabricMainChannel{
+			newTestChannel("etcdraft", nodes[0], nodes[1], nodes[2]),
+			// links ord3 with the consenters of the first channel
+			newTestChannel("etcdraft", nodes[2], nodes[3], nodes[4]),
+		}
+		groups := getPodDisruptionBudgetGroups(nodes, channels, "default")
+		g.Expect(releases(groups)).To(Equal(map[string][]string{
+			"ord1-pdb":       {"ord1", "ord2", "ord3", "ord4", "ord5"},
+			"standalone-pdb": {"standalone"},
+		}))
+		// each channel of 3 raft consenters tolerates one failure
+		g.Expect(groups[0].MaxUnavailable).To(Equal(1))
+		g.Expect(groups[0].Owners).To(HaveLen(5))
+		g.Expect(groups[1].MaxUnavailable).To(Equal(1))
+	})
+
+	t.Run("consenters referenced by TLS certificate", func(t *testing.T) {
+		g := NewWithT(t)
+		nodes := newTestConsenters(5)
+		for i := range nodes {
+			nodes[i].Spec.PodDisruptionBudget = enabledTwo
+		}
+		channel := newTestChannel("etcdraft")
+		for _, node := range nodes {
+			channel.Spec.Consenters = append(channel.Spec.Consenters, hlfv1alpha1.FabricMainChannelConsenter{TLSCert: node.Status.TlsCert + "\n"})
+		}
+		groups := getPodDisruptionBudgetGroups(nodes, []hlfv1alpha1.FabricMainChannel{channel}, "default")
+		g.Expect(groups).To(HaveLen(1))
+		g.Expect(groups[0].Releases).To(HaveLen(5))
+		g.Expect(groups[0].MaxUnavailable).To(Equal(2))
+	})
+
+	t.Run("no PodDisruptionBudget without fault tolerance", func(t *testing.T) {
+		g := NewWithT(t)
+		nodes := []hlfv1alpha1.FabricOrdererNode{
+			newTestOrdererNode("ord1", "default", enabled),
+			newTestOrdererNode("ord2", "default", enabled),
+			newTestOrdererNode("ord3", "default", enabled),
+			newTestOrdererNode("ord4", "default", enabled),
+		}
+		channels := []hlfv1alpha1.FabricMainChannel{
+			// two raft consenters can't lose any of them
+			newTestChannel("etcdraft", nodes[0], nodes[1]),
+			newTestChannel(bft.ConsensusType, nodes[1], nodes[2]),
+			newTestChannel("etcdraft", nodes[3]),
+		}
+		g.Expect(getPodDisruptionBudgetGroups(nodes, channels, "default")).To(BeEmpty())
+	})
+
+	t.Run("consenters split between namespaces", func(t *testing.T) {
+		g := NewWithT(t)
+		nodes := []hlfv1alpha1.FabricOrdererNode{
+			newTestOrdererNode("ord1", "org1", enabled),
+			newTestOrdererNode("ord2", "org2", enabled),
+			newTestOrdererNode("ord3", "org3", enabled),
+		}
+		// the raft channel tolerates one failure, it goes to the first namespace
+		channels := []hlfv1alpha1.FabricMainChannel{
+			newTestChannel("etcdraft", nodes...),
+		}
+		groups := getPodDisruptionBudgetGroups(nodes, channels, "org1")
+		g.Expect(groups).To(HaveLen(1))
+		g.Expect(groups[0].Releases).To(Equal([]string{"ord1"}))
+		g.Expect(groups[0].MaxUnavailable).To(Equal(1))
+		// the other namespaces can't evict their consenter, a drain of each namespace would lose the quorum
+		for _, ns := range []string{"org2", "org3"} {
+			groups = getPodDisruptionBudgetGroups(nodes, channels, ns)
+			g.Expect(groups).To(HaveLen(1))
+			g.Expect(groups[0].MaxUnavailable).To(Equal(0))
+		}
+
+		// a channel without fault tolerance still gets no PodDisruptionBudget in any namespace
+		channels = []hlfv1alpha1.FabricMainChannel{
+			newTestChannel("etcdraft", nodes[0], nodes[1]),
+		}
+		g.Expect(getPodDisruptionBudgetGroups(nodes, channels, "org1")).To(BeEmpty())
+		g.Expect(getPodDisruptionBudgetGroups(nodes, channels, "org2")).To(BeEmpty())
+	})
+
+	t.Run("explicit max unavailable of 0 is kept", func(t *testing.T) {
+		g := NewWithT(t)
+		zero := 0
+		nodes := []hlfv1alpha1.FabricOrdererNode{
+			newTestOrdererNode("ord1", "default", &hlfv1alpha1.FabricPodDisruptionBudget{Enabled: true, MaxUnavailable: &zero}),
+		}
+		groups := getPodDisruptionBudgetGroups(nodes, nil, "default")
+		g.Expect(groups).To(HaveLen(1))
+		g.Expect(groups[0].MaxUnavailable).To(Equal(0))
+	})
+}
+
+func TestGetPodDisruptionBudgetRequests(t *testing.T) {
+	g := NewWithT(t)
+	enabled := &hlfv1alpha1.FabricPodDisruptionBudget{Enabled: true}
+	deleted := newTestOrdererNode("deleted", "org1", enabled)
+	deleted.DeletionTimestamp = &v1.Time{}
+	nodes := []hlfv1alpha1.FabricOrdererNode{
+		newTestOrdererNode("disabled", "org1", nil),
+		deleted,
+		newTestOrdererNode("ord1", "org1", enabled),
+		newTestOrdererNode("ord2", "org1", enabled),
+		newTestOrdererNode("ord1", "org2", enabled),
+	}
+	g.Expect(getPodDisruptionBudgetRequests(nodes)).To(Equal([]reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "ord1", Namespace: "org1"}},
+		{NamespacedName: types.NamespacedName{Name: "ord1", Namespace: "org2"}},
+	}))
+}
+
+func TestConsensusChanged(t *testing.T) {
+	g := NewWithT(t)
+	nodes := newTestConsenters(3)
+	channel := newTestChannel("etcdraft", nodes...)
+
+	unchanged := channel.DeepCopy()
+	unchanged.Spec.Name = "renamed"
+	g.Expect(consensusChanged(event.UpdateEvent{ObjectOld: &channel, ObjectNew: unchanged})).To(BeFalse())
+
+	removed := newTestChannel("etcdraft", nodes[:2]...)
+	g.Expect(consensusChanged(event.UpdateEvent{ObjectOld: &channel, ObjectNew: &removed})).To(BeTrue())
+
+	switched := newTestChannel(bft.ConsensusType, nodes...)
+	g.Expect(consensusChanged(event.UpdateEvent{ObjectOld: &channel, ObjectNew: &switched})).To(BeTrue())
+}
@@ -3,35 +3,38 @@ package ordnode
 import corev1 "k8s.io/api/core/v1"
 
 type fabricOrdChart struct {
-	Istio                       Istio                         `json:"istio"`
-	AdminIstio                  Istio                         `json:"adminIstio"`
-	Replicas                    int                           `json:"replicas"`
-	Genesis                     string                        `json:"genesis"`
-	ChannelParticipationEnabled bool                          `json:"channelParticipationEnabled"`
-	BootstrapMethod             string                        `json:"bootstrapMethod"`
-	Admin                       admin                         `json:"admin"`
-	Cacert                      string                        `json:"cacert"`
-	NodeSelector                *corev1.NodeSelector          `json:"nodeSelector,omitempty"`
-	Tlsrootcert                 string                        `json:"tlsrootcert"`
-	AdminCert                   string                        `json:"adminCert"`
-	Affinity                    *corev1.Affinity              `json:"affinity,omitempty"`
-	Cert                        string                        `json:"cert"`
-	Key                         string                        `json:"key"`
-	TLS                         tls                           `json:"tls"`
-	Tolerations                 []corev1.Toleration           `json:"tolerations,omitempty"`
-	Resources                   Resources                     `json:"resources,omitempty"`
-	FullnameOverride            string                        `json:"fullnameOverride"`
-	HostAliases                 []HostAlias                   `json:"hostAliases"`
-	Service                     service                       `json:"service"`
-	Image                       image                         `json:"image"`
-	Persistence                 persistence                   `json:"persistence"`
-	Ord                         ord                           `json:"ord"`
-	Clientcerts                 clientcerts                   `json:"clientcerts"`
-	Hosts                       []string                      `json:"hosts"`
-	Logging                     Logging                       `json:"logging"`
-	ServiceMonitor              ServiceMonitor                `json:"serviceMonitor"`
-	EnvVars                     []corev1.EnvVar               `json:"envVars"`
-	ImagePullSecrets            []corev1.LocalObjectReference `json:"imagePullSecrets"`
+	Istio                       Istio                             `json:"istio"`
+	AdminIstio                  Istio                             `json:"adminIstio"`
+	Replicas                    int                               `json:"replicas"`
+	Genesis                     string                            `json:"genesis"`
+	ChannelParticipationEnabled bool                              `json:"channelParticipationEnabled"`
+	BootstrapMethod             string                            `json:"bootstrapMethod"`
+	Admin                       admin                             `json:"admin"`
+	Cacert                      string                            `json:"cacert"`
+	NodeSelector                *corev1.NodeSelector              `json:"nodeSelector,omitempty"`
+	Tlsrootcert                 string                            `json:"tlsrootcert"`
+	AdminCert                   string                            `json:"adminCert"`
+	Affinity                    *corev1.Affinity                  `json:"affinity,omitempty"`
+	TopologySpreadConstraints   []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	PriorityClassName           string                            `json:"priorityClassName,omitempty"`
+	Cert                        string                            `json:"cert"`
+	Key                         string                            `json:"key"`
+	TLS                         tls                               `json:"tls"`
+	Tolerations                 []corev1.Toleration               `json:"tolerations,omitempty"`
+	Resources                   Resources                         `json:"resources,omitempty"`
+	FullnameOverride            string                            `json:"fullnameOverride"`
+	HostAliases                 []HostAlias                       `json:"hostAliases"`
+	Service                     service                           `json:"service"`
+	Image                       image                             `json:"image"`
+	Persistence                 persistence                       `json:"persistence"`
+	Ord                         ord                               `json:"ord"`
+	Clientcerts                 map[string]string                 `json:"clientcerts"`
+	Hosts                       []string                          `json:"hosts"`
+	Logging                     Logging                           `json:"logging"`
+	ServiceMonitor              ServiceMonitor                    `json:"serviceMonitor"`
+	EnvVars                     []corev1.EnvVar                   `json:"envVars"`
+	ImagePullSecrets            []corev1.LocalObjectReference     `json:"imagePullSecrets"`
+	PKCS11                      *PKCS11                           `json:"pkcs11"`
 
 	Proxy GRPCProxy `json:"proxy"`
 }
@@ -126,12 +129,21 @@ type ord struct {
 	MspID string           `json:"mspID"`
 	TLS   tlsConfiguration `json:"tls"`
 }
-type clientcerts struct {
-	CertPem string `json:"cert.pem,omitempty"`
-}
 
 type Istio struct {
 	Port           int      `json:"port"`
 	Hosts          []string `json:"hosts"`
 	IngressGateway string   `json:"ingressGateway"`
 }
+
+type PKCS11 struct {
+	Library   string          `json:"library"`
+	Label     string          `json:"label"`
+	Hash      string          `json:"hash"`
+	Security  int             `json:"security"`
+	PinSecret PKCS11PinSecret `json:"pinSecret"`
+}
+type PKCS11PinSecret struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
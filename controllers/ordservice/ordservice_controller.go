@@ -2,7 +2,7 @@ package ordservice
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"crypto"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
@@ -52,7 +52,7 @@ type FabricOrderingServiceReconciler struct {
 func getOrdererName(chartName string, idx int) string {
 	return fmt.Sprintf("%s--ord-%d-hlf-ordnode", chartName, idx)
 }
-func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namespace string, idx int) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namespace string, idx int) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	baseName := getOrdererName(chartName, idx)
 	secretName := fmt.Sprintf("%s-tls", baseName)
 	tlsRootSecretName := fmt.Sprintf("%s-tlsrootcert", baseName)
@@ -68,7 +68,7 @@ func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namesp
 	tlsKeyData := secret.Data["tls.key"]
 	tlsCrtData := secret.Data["tls.crt"]
 	rootTLSCrtData := rootCertSecret.Data["cacert.pem"]
-	key, err := utils.ParseECDSAPrivateKey(tlsKeyData)
+	key, err := utils.ParsePrivateKey(tlsKeyData)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -83,7 +83,7 @@ func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namesp
 	return crt, key, rootCrt, nil
 }
 
-func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, namespace string, idx int) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, namespace string, idx int) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	baseName := getOrdererName(chartName, idx)
 	secretCrtName := fmt.Sprintf("%s-idcert", baseName)
 	secretKeyName := fmt.Sprintf("%s-idkey", baseName)
@@ -112,7 +112,7 @@ func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, names
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	key, err := utils.ParseECDSAPrivateKey(signKeyData)
+	key, err := utils.ParsePrivateKey(signKeyData)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -0,0 +1,155 @@
+package overrides
+
+// Core is the schema of the core.yaml of the peer
+var Core = Schema{
+	EnvPrefix: "CORE",
+	Keys: map[string]Kind{
+		// set from the spec of the FabricPeer
+		"peer.id":                                   ManagedKind,
+		"peer.listenaddress":                        ManagedKind,
+		"peer.address":                              ManagedKind,
+		"peer.addressautodetect":                    ManagedKind,
+		"peer.chaincodeaddress":                     ManagedKind,
+		"peer.chaincodelistenaddress":               ManagedKind,
+		"peer.gossip.bootstrap":                     ManagedKind,
+		"peer.gossip.endpoint":                      ManagedKind,
+		"peer.gossip.externalendpoint":              ManagedKind,
+		"peer.gossip.useleaderelection":             ManagedKind,
+		"peer.gossip.orgleader":                     ManagedKind,
+		"peer.tls":                                  ManagedKind,
+		"peer.filesystempath":                       ManagedKind,
+		"peer.bccsp":                                ManagedKind,
+		"peer.mspconfigpath":                        ManagedKind,
+		"peer.localmspid":                           ManagedKind,
+		"peer.localmsptype":                         ManagedKind,
+		"peer.handlers":                             ManagedKind,
+		"vm":                                        ManagedKind,
+		"chaincode.externalbuilders":                ManagedKind,
+		"chaincode.system":                          ManagedKind,
+		"ledger.state.statedatabase":                ManagedKind,
+		"ledger.state.couchdbconfig.couchdbaddress": ManagedKind,
+		"ledger.state.couchdbconfig.username":       ManagedKind,
+		"ledger.state.couchdbconfig.password":       ManagedKind,
+		"ledger.snapshots.rootdir":                  ManagedKind,
+		"operations":                                ManagedKind,
+
+		"peer.networkid": StringKind,
+
+		"peer.keepalive.interval":                DurationKind,
+		"peer.keepalive.timeout":                 DurationKind,
+		"peer.keepalive.mininterval":             DurationKind,
+		"peer.keepalive.client.interval":         DurationKind,
+		"peer.keepalive.client.timeout":          DurationKind,
+		"peer.keepalive.deliveryclient.interval": DurationKind,
+		"peer.keepalive.deliveryclient.timeout":  DurationKind,
+
+		"peer.gossip.membershiptrackerinterval":  DurationKind,
+		"peer.gossip.maxblockcounttostore":       IntKind,
+		"peer.gossip.maxpropagationburstlatency": DurationKind,
+		"peer.gossip.maxpropagationburstsize":    IntKind,
+		"peer.gossip.propagateiterations":        IntKind,
+		"peer.gossip.propagatepeernum":           IntKind,
+		"peer.gossip.pullinterval":               DurationKind,
+		"peer.gossip.pullpeernum":                IntKind,
+		"peer.gossip.requeststateinfointerval":   DurationKind,
+		"peer.gossip.publishstateinfointerval":   DurationKind,
+		"peer.gossip.stateinforetentioninterval": DurationKind,
+		"peer.gossip.publishcertperiod":          DurationKind,
+		"peer.gossip.skipblockverification":      BoolKind,
+		"peer.gossip.dialtimeout":                DurationKind,
+		"peer.gossip.conntimeout":                DurationKind,
+		"peer.gossip.recvbuffsize":               IntKind,
+		"peer.gossip.sendbuffsize":               IntKind,
+		"peer.gossip.digestwaittime":             DurationKind,
+		"peer.gossip.requestwaittime":            DurationKind,
+		"peer.gossip.responsewaittime":           DurationKind,
+		"peer.gossip.alivetimeinterval":          DurationKind,
+		"peer.gossip.aliveexpirationtimeout":     DurationKind,
+		"peer.gossip.reconnectinterval":          DurationKind,
+		"peer.gossip.maxconnectionattempts":      IntKind,
+		"peer.gossip.msgexpirationfactor":        IntKind,
+
+		"peer.gossip.election.startupgraceperiod":       DurationKind,
+		"peer.gossip.election.membershipsampleinterval": DurationKind,
+		"peer.gossip.election.leaderalivethreshold":     DurationKind,
+		"peer.gossip.election.leaderelectionduration":   DurationKind,
+
+		"peer.gossip.pvtdata.pullretrythreshold":                                      DurationKind,
+		"peer.gossip.pvtdata.transientstoremaxblockretention":                         IntKind,
+		"peer.gossip.pvtdata.pushacktimeout":                                          DurationKind,
+		"peer.gossip.pvtdata.btlpullmargin":                                           IntKind,
+		"peer.gossip.pvtdata.reconcilebatchsize":                                      IntKind,
+		"peer.gossip.pvtdata.reconcilesleepinterval":                                  DurationKind,
+		"peer.gossip.pvtdata.reconciliationenabled":                                   BoolKind,
+		"peer.gossip.pvtdata.skippullinginvalidtransactionsduringcommit":              BoolKind,
+		"peer.gossip.pvtdata.implicitcollectiondisseminationpolicy.requiredpeercount": IntKind,
+		"peer.gossip.pvtdata.implicitcollectiondisseminationpolicy.maxpeercount":      IntKind,
+
+		"peer.gossip.state.enabled":         BoolKind,
+		"peer.gossip.state.checkinterval":   DurationKind,
+		"peer.gossip.state.responsetimeout": DurationKind,
+		"peer.gossip.state.batchsize":       IntKind,
+		"peer.gossip.state.blockbuffersize": IntKind,
+		"peer.gossip.state.maxretries":      IntKind,
+
+		"peer.authentication.timewindow": DurationKind,
+		"peer.client.conntimeout":        DurationKind,
+
+		"peer.deliveryclient.blockgossipenabled":          BoolKind,
+		"peer.deliveryclient.reconnecttotaltimethreshold": DurationKind,
+		"peer.deliveryclient.conntimeout":                 DurationKind,
+		"peer.deliveryclient.reconnectbackoffthreshold":   DurationKind,
+
+		"peer.validatorpoolsize": IntKind,
+		"peer.maxrecvmsgsize":    IntKind,
+		"peer.maxsendmsgsize":    IntKind,
+
+		"peer.gateway.enabled":            BoolKind,
+		"peer.gateway.endorsementtimeout": DurationKind,
+		"peer.gateway.broadcasttimeout":   DurationKind,
+		"peer.gateway.dialtimeout":        DurationKind,
+
+		"peer.profile.enabled":       BoolKind,
+		"peer.profile.listenaddress": StringKind,
+
+		"peer.discovery.enabled":                      BoolKind,
+		"peer.discovery.authcacheenabled":             BoolKind,
+		"peer.discovery.authcachemaxsize":             IntKind,
+		"peer.discovery.authcachepurgeretentionratio": FloatKind,
+		"peer.discovery.orgmembersallowedaccess":      BoolKind,
+
+		"peer.limits.concurrency.endorserservice": IntKind,
+		"peer.limits.concurrency.deliverservice":  IntKind,
+		"peer.limits.concurrency.gatewayservice":  IntKind,
+
+		"chaincode.installtimeout":     DurationKind,
+		"chaincode.startuptimeout":     DurationKind,
+		"chaincode.executetimeout":     DurationKind,
+		"chaincode.keepalive":          IntKind,
+		"chaincode.logging.level":      StringKind,
+		"chaincode.logging.shim":       StringKind,
+		"chaincode.logging.format":     StringKind,
+		"chaincode.golang.dynamiclink": BoolKind,
+
+		"ledger.state.totalquerylimit":                            IntKind,
+		"ledger.state.couchdbconfig.maxretries":                   IntKind,
+		"ledger.state.couchdbconfig.maxretriesonstartup":          IntKind,
+		"ledger.state.couchdbconfig.requesttimeout":               DurationKind,
+		"ledger.state.couchdbconfig.internalquerylimit":           IntKind,
+		"ledger.state.couchdbconfig.maxbatchupdatesize":           IntKind,
+		"ledger.state.couchdbconfig.warmindexesafternblocks":      IntKind,
+		"ledger.state.couchdbconfig.createglobalchangesdb":        BoolKind,
+		"ledger.state.couchdbconfig.cachesize":                    IntKind,
+		"ledger.history.enablehistorydatabase":                    BoolKind,
+		"ledger.pvtdatastore.collelgprocmaxdbbatchsize":           IntKind,
+		"ledger.pvtdatastore.collelgprocdbbatchesinterval":        IntKind,
+		"ledger.pvtdatastore.deprioritizeddatareconcilerinterval": DurationKind,
+		"ledger.pvtdatastore.purgeinterval":                       IntKind,
+
+		"metrics.provider":             StringKind,
+		"metrics.statsd.network":       StringKind,
+		"metrics.statsd.address":       StringKind,
+		"metrics.statsd.writeinterval": DurationKind,
+		"metrics.statsd.prefix":        StringKind,
+	},
+}
@@ -0,0 +1,68 @@
+package overrides
+
+// Orderer is the schema of the orderer.yaml of the orderer
+var Orderer = Schema{
+	EnvPrefix: "ORDERER",
+	Keys: map[string]Kind{
+		// set from the spec of the FabricOrdererNode
+		"general.listenaddress":             ManagedKind,
+		"general.listenport":                ManagedKind,
+		"general.tls":                       ManagedKind,
+		"general.cluster.clientcertificate": ManagedKind,
+		"general.cluster.clientprivatekey":  ManagedKind,
+		"general.cluster.rootcas":           ManagedKind,
+		"general.cluster.listenaddress":     ManagedKind,
+		"general.cluster.listenport":        ManagedKind,
+		"general.cluster.servercertificate": ManagedKind,
+		"general.cluster.serverprivatekey":  ManagedKind,
+		"general.bootstrapmethod":           ManagedKind,
+		"general.bootstrapfile":             ManagedKind,
+		"general.localmspdir":               ManagedKind,
+		"general.localmspid":                ManagedKind,
+		"general.bccsp":                     ManagedKind,
+		"fileledger.location":               ManagedKind,
+		"consensus.waldir":                  ManagedKind,
+		"consensus.snapdir":                 ManagedKind,
+		"operations":                        ManagedKind,
+		"admin":                             ManagedKind,
+		"channelparticipation.enabled":      ManagedKind,
+
+		"general.keepalive.servermininterval": DurationKind,
+		"general.keepalive.serverinterval":    DurationKind,
+		"general.keepalive.servertimeout":     DurationKind,
+
+		"general.maxrecvmsgsize": IntKind,
+		"general.maxsendmsgsize": IntKind,
+
+		"general.cluster.sendbuffersize":                       IntKind,
+		"general.cluster.dialtimeout":                          DurationKind,
+		"general.cluster.rpctimeout":                           DurationKind,
+		"general.cluster.replicationbuffersize":                IntKind,
+		"general.cluster.replicationpulltimeout":               DurationKind,
+		"general.cluster.replicationretrytimeout":              DurationKind,
+		"general.cluster.replicationbackgroundrefreshinterval": DurationKind,
+		"general.cluster.replicationmaxretries":                IntKind,
+		"general.cluster.certexpirationwarningthreshold":       DurationKind,
+		"general.cluster.tlshandshaketimeshift":                DurationKind,
+
+		"general.authentication.timewindow":         DurationKind,
+		"general.authentication.noexpirationchecks": BoolKind,
+
+		"general.profile.enabled": BoolKind,
+		"general.profile.address": StringKind,
+
+		"debug.broadcasttracedir": StringKind,
+		"debug.delivertracedir":   StringKind,
+
+		"consensus.evictionsuspicion":    DurationKind,
+		"consensus.tickintervaloverride": DurationKind,
+
+		"channelparticipation.maxrequestbodysize": IntKind,
+
+		"metrics.provider":             StringKind,
+		"metrics.statsd.network":       StringKind,
+		"metrics.statsd.address":       StringKind,
+		"metrics.statsd.writeinterval": DurationKind,
+		"metrics.statsd.prefix":        StringKind,
+	},
+}
@@ -0,0 +1,190 @@
+package overrides
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Kind is the type of the value of a configuration key
+type Kind string
+
+const (
+	BoolKind     Kind = "bool"
+	IntKind      Kind = "int"
+	FloatKind    Kind = "float"
+	DurationKind Kind = "duration"
+	StringKind   Kind = "string"
+	// ManagedKind marks the keys, or the sections, set by the operator from the spec of the node
+	ManagedKind Kind = "managed"
+)
+
+// Schema is the set of known keys of a Fabric configuration file, the overrides are applied with the environment
+// variables read by viper, `<prefix>_<KEY PATH>` with the dots replaced by underscores
+type Schema struct {
+	// Prefix of the environment variables, CORE for the peer and ORDERER for the orderer
+	EnvPrefix string
+	// Kind of the known keys, the paths are lowercase
+	Keys map[string]Kind
+}
+
+// Result is the outcome of applying the overrides of a node to a schema
+type Result struct {
+	// Environment variables with the overrides, sorted by name
+	EnvVars []corev1.EnvVar
+	// Warnings about the keys that aren't in the schema, they are applied without validation
+	Warnings []string
+}
+
+// Apply validates the overrides against the schema and returns the environment variables that set them, the keys
+// are matched case insensitively since viper ignores the case of the keys
+func (s Schema) Apply(overrides *apiextensionsv1.JSON) (*Result, error) {
+	result := &Result{
+		EnvVars: []corev1.EnvVar{},
+	}
+	if overrides == nil || len(overrides.Raw) == 0 {
+		return result, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(overrides.Raw))
+	decoder.UseNumber()
+	var values map[string]interface{}
+	err := decoder.Decode(&values)
+	if err != nil {
+		return nil, errors.Wrap(err, "overrides must be an object")
+	}
+	flattened := map[string]interface{}{}
+	err = flatten("", values, flattened)
+	if err != nil {
+		return nil, err
+	}
+	var validationErrors []string
+	for path, value := range flattened {
+		kind, known := s.kind(path)
+		if kind == ManagedKind {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s is managed by the operator", path))
+			continue
+		}
+		var envValue string
+		if known {
+			envValue, err = formatValue(kind, value)
+			if err != nil {
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: %v", path, err))
+				continue
+			}
+		} else {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("unknown key %s is applied without validation", path))
+			envValue = fmt.Sprint(value)
+		}
+		result.EnvVars = append(result.EnvVars, corev1.EnvVar{
+			Name:  s.envName(path),
+			Value: envValue,
+		})
+	}
+	if len(validationErrors) > 0 {
+		sort.Strings(validationErrors)
+		return nil, errors.Errorf("invalid overrides: %s", strings.Join(validationErrors, ", "))
+	}
+	sort.Slice(result.EnvVars, func(i, j int) bool {
+		return result.EnvVars[i].Name < result.EnvVars[j].Name
+	})
+	sort.Strings(result.Warnings)
+	return result, nil
+}
+
+// kind returns the kind of a key and whether it's known, a key inside a managed section is managed
+func (s Schema) kind(path string) (Kind, bool) {
+	key := strings.ToLower(path)
+	if kind, ok := s.Keys[key]; ok {
+		return kind, true
+	}
+	for section, kind := range s.Keys {
+		if kind == ManagedKind && strings.HasPrefix(key, section+".") {
+			return ManagedKind, true
+		}
+	}
+	return "", false
+}
+
+func (s Schema) envName(path string) string {
+	return fmt.Sprintf("%s_%s", s.EnvPrefix, strings.ToUpper(strings.ReplaceAll(path, ".", "_")))
+}
+
+func flatten(prefix string, values map[string]interface{}, flattened map[string]interface{}) error {
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = fmt.Sprintf("%s.%s", prefix, key)
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			err := flatten(path, v, flattened)
+			if err != nil {
+				return err
+			}
+		case []interface{}:
+			return errors.Errorf("%s: lists can't be overridden", path)
+		case nil:
+			return errors.Errorf("%s: value is null", path)
+		default:
+			flattened[path] = v
+		}
+	}
+	return nil
+}
+
+func formatValue(kind Kind, value interface{}) (string, error) {
+	switch kind {
+	case BoolKind:
+		b, ok := value.(bool)
+		if !ok {
+			return "", errors.Errorf("expected a boolean, got %v", value)
+		}
+		return strconv.FormatBool(b), nil
+	case IntKind:
+		n, ok := value.(json.Number)
+		if !ok {
+			return "", errors.Errorf("expected an integer, got %v", value)
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return "", errors.Errorf("expected an integer, got %v", value)
+		}
+		return strconv.FormatInt(i, 10), nil
+	case FloatKind:
+		n, ok := value.(json.Number)
+		if !ok {
+			return "", errors.Errorf("expected a number, got %v", value)
+		}
+		_, err := n.Float64()
+		if err != nil {
+			return "", errors.Errorf("expected a number, got %v", value)
+		}
+		return n.String(), nil
+	case DurationKind:
+		d, ok := value.(string)
+		if !ok {
+			return "", errors.Errorf("expected a duration, got %v", value)
+		}
+		_, err := time.ParseDuration(d)
+		if err != nil {
+			return "", errors.Errorf("expected a duration, got %s", d)
+		}
+		return d, nil
+	case StringKind:
+		str, ok := value.(string)
+		if !ok {
+			return "", errors.Errorf("expected a string, got %v", value)
+		}
+		return str, nil
+	default:
+		return "", errors.Errorf("unsupported kind %s", kind)
+	}
+}
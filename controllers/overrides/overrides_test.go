@@ -0,0 +1,168 @@
+package overrides
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name         string
+		schema       Schema
+		overrides    string
+		wantEnvVars  []corev1.EnvVar
+		wantWarnings []string
+		wantErr      string
+	}{
+		{
+			name:        "no overrides",
+			schema:      Core,
+			overrides:   "",
+			wantEnvVars: []corev1.EnvVar{},
+		},
+		{
+			name:      "nested maps are flattened to env names",
+			schema:    Core,
+			overrides: `{"peer": {"gossip": {"pullPeerNum": 5, "skipBlockVerification": true}, "networkId": "prod"}}`,
+			wantEnvVars: []corev1.EnvVar{
+				{Name: "CORE_PEER_GOSSIP_PULLPEERNUM", Value: "5"},
+				{Name: "CORE_PEER_GOSSIP_SKIPBLOCKVERIFICATION", Value: "true"},
+				{Name: "CORE_PEER_NETWORKID", Value: "prod"},
+			},
+		},
+		{
+			name:      "durations and floats",
+			schema:    Core,
+			overrides: `{"peer": {"keepalive": {"interval": "7200s"}, "discovery": {"authCachePurgeRetentionRatio": 0.75}}}`,
+			wantEnvVars: []corev1.EnvVar{
+				{Name: "CORE_PEER_DISCOVERY_AUTHCACHEPURGERETENTIONRATIO", Value: "0.75"},
+				{Name: "CORE_PEER_KEEPALIVE_INTERVAL", Value: "7200s"},
+			},
+		},
+		{
+			name:      "orderer keys use the orderer prefix",
+			schema:    Orderer,
+			overrides: `{"General": {"Cluster": {"DialTimeout": "10s"}, "MaxRecvMsgSize": 104857600}}`,
+			wantEnvVars: []corev1.EnvVar{
+				{Name: "ORDERER_GENERAL_CLUSTER_DIALTIMEOUT", Value: "10s"},
+				{Name: "ORDERER_GENERAL_MAXRECVMSGSIZE", Value: "104857600"},
+			},
+		},
+		{
+			name:      "unknown keys become warnings",
+			schema:    Core,
+			overrides: `{"peer": {"custom": {"setting": "value"}}}`,
+			wantEnvVars: []corev1.EnvVar{
+				{Name: "CORE_PEER_CUSTOM_SETTING", Value: "value"},
+			},
+			wantWarnings: []string{"unknown key peer.custom.setting is applied without validation"},
+		},
+		{
+			name:      "integer with a string",
+			schema:    Core,
+			overrides: `{"peer": {"gossip": {"pullPeerNum": "5"}}}`,
+			wantErr:   "peer.gossip.pullPeerNum: expected an integer",
+		},
+		{
+			name:      "integer with a decimal",
+			schema:    Core,
+			overrides: `{"peer": {"gossip": {"pullPeerNum": 1.5}}}`,
+			wantErr:   "peer.gossip.pullPeerNum: expected an integer",
+		},
+		{
+			name:      "boolean with a string",
+			schema:    Core,
+			overrides: `{"peer": {"gossip": {"skipBlockVerification": "yes"}}}`,
+			wantErr:   "peer.gossip.skipBlockVerification: expected a boolean",
+		},
+		{
+			name:      "invalid duration",
+			schema:    Core,
+			overrides: `{"peer": {"keepalive": {"interval": "2 hours"}}}`,
+			wantErr:   "peer.keepalive.interval: expected a duration",
+		},
+		{
+			name:      "duration with a number",
+			schema:    Core,
+			overrides: `{"peer": {"keepalive": {"interval": 7200}}}`,
+			wantErr:   "peer.keepalive.interval: expected a duration",
+		},
+		{
+			name:      "string with a number",
+			schema:    Core,
+			overrides: `{"peer": {"networkId": 1}}`,
+			wantErr:   "peer.networkId: expected a string",
+		},
+		{
+			name:      "managed key",
+			schema:    Core,
+			overrides: `{"peer": {"localMspId": "Org1MSP"}}`,
+			wantErr:   "peer.localMspId is managed by the operator",
+		},
+		{
+			name:      "key inside a managed section",
+			schema:    Orderer,
+			overrides: `{"General": {"TLS": {"Enabled": false}}}`,
+			wantErr:   "General.TLS.Enabled is managed by the operator",
+		},
+		{
+			name:      "lists",
+			schema:    Core,
+			overrides: `{"peer": {"gossip": {"bootstrap": ["peer0:7051"]}}}`,
+			wantErr:   "peer.gossip.bootstrap: lists can't be overridden",
+		},
+		{
+			name:      "null values",
+			schema:    Core,
+			overrides: `{"peer": {"networkId": null}}`,
+			wantErr:   "peer.networkId: value is null",
+		},
+		{
+			name:      "not an object",
+			schema:    Core,
+			overrides: `["peer"]`,
+			wantErr:   "overrides must be an object",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var overrides *apiextensionsv1.JSON
+			if tt.overrides != "" {
+				overrides = &apiextensionsv1.JSON{Raw: []byte(tt.overrides)}
+			}
+			result, err := tt.schema.Apply(overrides)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result.EnvVars).To(Equal(tt.wantEnvVars))
+			g.Expect(result.Warnings).To(Equal(tt.wantWarnings))
+		})
+	}
+}
+
+func TestApplyReportsAllInvalidKeys(t *testing.T) {
+	g := NewWithT(t)
+	_, err := Core.Apply(&apiextensionsv1.JSON{Raw: []byte(`{"peer": {"id": "peer0", "gossip": {"pullPeerNum": "5"}}}`)})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("invalid overrides: peer.gossip.pullPeerNum: expected an integer, got 5, peer.id is managed by the operator"))
+}
+
+func TestSchemaKeys(t *testing.T) {
+	for _, schema := range []Schema{Core, Orderer} {
+		t.Run(schema.EnvPrefix, func(t *testing.T) {
+			g := NewWithT(t)
+			for key, kind := range schema.Keys {
+				g.Expect(key).To(Equal(strings.ToLower(key)), "keys are matched in lowercase")
+				g.Expect(kind).To(BeElementOf(BoolKind, IntKind, FloatKind, DurationKind, StringKind, ManagedKind), key)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package peer
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/couchdb"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/action"
+	"k8s.io/client-go/kubernetes"
+)
+
+func mapExternalCouchDBToChart(external *hlfv1alpha1.FabricPeerExternalCouchDB) CouchDBExternal {
+	if external == nil || !external.Enabled {
+		return CouchDBExternal{}
+	}
+	chartExternal := CouchDBExternal{
+		Enabled:        true,
+		Host:           external.Host,
+		Port:           external.Port,
+		DatabasePrefix: external.DatabasePrefix,
+		Proxy: CouchDBProxy{
+			// the peer only reaches CouchDB over plain HTTP and with the names of the databases it chooses
+			Enabled:    external.TLS != nil || external.DatabasePrefix != "",
+			Image:      helpers.DefaultCouchDBProxyImage,
+			Tag:        helpers.DefaultCouchDBProxyVersion,
+			PullPolicy: string(hlfv1alpha1.DefaultImagePullPolicy),
+		},
+	}
+	if external.Proxy != nil && external.Proxy.Image != "" && external.Proxy.Tag != "" {
+		chartExternal.Proxy.Image = external.Proxy.Image
+		chartExternal.Proxy.Tag = external.Proxy.Tag
+	}
+	if external.Proxy != nil && external.Proxy.PullPolicy != "" {
+		chartExternal.Proxy.PullPolicy = string(external.Proxy.PullPolicy)
+	}
+	if external.Credentials != nil {
+		chartExternal.Credentials = &CouchDBCredentials{
+			SecretName:  external.Credentials.SecretName,
+			UserKey:     couchdb.GetUserKey(external.Credentials),
+			PasswordKey: couchdb.GetPasswordKey(external.Credentials),
+		}
+	}
+	if external.TLS != nil {
+		chartExternal.TLS = &CouchDBTLS{
+			CACert: SecretKey{Name: external.TLS.CACert.Name, Key: external.TLS.CACert.Key},
+		}
+		if external.TLS.ClientCert != nil && external.TLS.ClientKey != nil {
+			chartExternal.TLS.ClientCert = &SecretKey{Name: external.TLS.ClientCert.Name, Key: external.TLS.ClientCert.Key}
+			chartExternal.TLS.ClientKey = &SecretKey{Name: external.TLS.ClientKey.Name, Key: external.TLS.ClientKey.Key}
+		}
+	}
+	return chartExternal
+}
+
+// checkExternalCouchDB connects to the external CouchDB of the peer with its TLS settings and credentials and returns
+// the version of CouchDB, it fails when CouchDB can't be reached or the credentials are rejected
+func checkExternalCouchDB(ctx context.Context, client kubernetes.Interface, ns string, spec hlfv1alpha1.FabricPeerSpec) (string, error) {
+	couchClient, err := couchdb.NewExternalClient(ctx, client, ns, spec)
+	if err != nil {
+		return "", err
+	}
+	defer couchClient.Close()
+	version, err := couchClient.GetVersion(ctx)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to connect to CouchDB %s", couchClient.URL())
+	}
+	err = couchClient.CheckCredentials(ctx)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to authenticate to CouchDB %s", couchClient.URL())
+	}
+	return version, nil
+}
+
+// setCouchDBStatus reports whether the external CouchDB of the peer is reachable from the operator, a running peer is
+// degraded when it isn't
+func setCouchDBStatus(ctx context.Context, client kubernetes.Interface, ns string, spec hlfv1alpha1.FabricPeerSpec, r *hlfv1alpha1.FabricPeerStatus) {
+	if !couchdb.IsExternal(spec) {
+		return
+	}
+	version, err := checkExternalCouchDB(ctx, client, ns, spec)
+	if err != nil {
+		r.CouchDB = &hlfv1alpha1.FabricPeerCouchDBStatus{
+			Reachable: false,
+			Message:   err.Error(),
+		}
+		if r.Status == hlfv1alpha1.RunningStatus {
+			r.Status = hlfv1alpha1.DegradedStatus
+			r.Message = err.Error()
+		}
+		return
+	}
+	r.CouchDB = &hlfv1alpha1.FabricPeerCouchDBStatus{
+		Reachable: true,
+		Version:   version,
+	}
+}
+
+// externalCouchDBChanged returns true when the external CouchDB of the chart is different from the one of the
+// deployed release, so the connectivity is checked before the peer is pointed to it
+func externalCouchDBChanged(cfg *action.Configuration, releaseName string, c *FabricPeerChart) (bool, error) {
+	values, err := action.NewGetValues(cfg).Run(releaseName)
+	if err != nil {
+		return false, err
+	}
+	var released interface{}
+	if couchValues, ok := values["couchdb"].(map[string]interface{}); ok {
+		released = couchValues["external"]
+	}
+	// the values of the release are decoded from JSON, so the new ones are compared once decoded the same way
+	data, err := json.Marshal(c.CouchDB.External)
+	if err != nil {
+		return false, err
+	}
+	var desired interface{}
+	err = json.Unmarshal(data, &desired)
+	if err != nil {
+		return false, err
+	}
+	return !reflect.DeepEqual(released, desired), nil
+}
+
+// preCheckExternalCouchDB checks the connectivity to the external CouchDB of the peer before it's deployed or pointed
+// to a different CouchDB, so a wrong host, CA or credentials fail the reconcile instead of the peer
+func preCheckExternalCouchDB(ctx context.Context, cfg *action.Configuration, client kubernetes.Interface, fabricPeer *hlfv1alpha1.FabricPeer, releaseName string, ns string, c *FabricPeerChart, exists bool) error {
+	if !couchdb.IsExternal(fabricPeer.Spec) {
+		return nil
+	}
+	if exists {
+		changed, err := externalCouchDBChanged(cfg, releaseName, c)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+	}
+	_, err := checkExternalCouchDB(ctx, client, ns, fabricPeer.Spec)
+	return err
+}
@@ -0,0 +1,92 @@
+package peer
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestMapExternalCouchDBToChart(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(mapExternalCouchDBToChart(nil)).To(Equal(CouchDBExternal{}))
+	g.Expect(mapExternalCouchDBToChart(&hlfv1alpha1.FabricPeerExternalCouchDB{Host: "couchdb"})).To(Equal(CouchDBExternal{}))
+
+	external := mapExternalCouchDBToChart(&hlfv1alpha1.FabricPeerExternalCouchDB{
+		Enabled: true,
+		Host:    "couchdb",
+		Port:    5984,
+	})
+	g.Expect(external.Proxy.Enabled).To(BeFalse())
+	g.Expect(external.Proxy.Image).To(Equal(helpers.DefaultCouchDBProxyImage))
+
+	external = mapExternalCouchDBToChart(&hlfv1alpha1.FabricPeerExternalCouchDB{
+		Enabled:        true,
+		Host:           "couchdb",
+		Port:           6984,
+		DatabasePrefix: "org1peer0_",
+		TLS: &hlfv1alpha1.FabricPeerCouchDBTLS{
+			CACert: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "couchdb-tls"}, Key: "ca.crt"},
+		},
+	})
+	g.Expect(external.Proxy.Enabled).To(BeTrue())
+	g.Expect(external.DatabasePrefix).To(Equal("org1peer0_"))
+	g.Expect(external.TLS).To(Equal(&CouchDBTLS{CACert: SecretKey{Name: "couchdb-tls", Key: "ca.crt"}}))
+}
+
+func renderCouchDBProxy(g *WithT, external CouchDBExternal) map[string]string {
+	ch, err := loader.Load(filepath.Join("..", "..", "charts", "hlf-peer"))
+	g.Expect(err).NotTo(HaveOccurred())
+	var inInterface map[string]interface{}
+	inrec, err := json.Marshal(FabricPeerChart{
+		FullnameOverride: "org1-peer0",
+		Peer:             Peer{DatabaseType: "CouchDB"},
+		CouchDB:          CouchDB{External: external},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(json.Unmarshal(inrec, &inInterface)).To(Succeed())
+	values, err := chartutil.ToRenderValues(ch, inInterface, chartutil.ReleaseOptions{Name: "org1-peer0", Namespace: "default"}, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	manifests, err := engine.Render(ch, values)
+	g.Expect(err).NotTo(HaveOccurred())
+	return manifests
+}
+
+func TestCouchDBProxyFiltersAllDBs(t *testing.T) {
+	g := NewWithT(t)
+	manifests := renderCouchDBProxy(g, mapExternalCouchDBToChart(&hlfv1alpha1.FabricPeerExternalCouchDB{
+		Enabled:        true,
+		Host:           "couchdb",
+		Port:           5984,
+		DatabasePrefix: "org1peer0_",
+	}))
+	configMap := manifests[filepath.Join("hlf-peer", "templates", "configmap--couchdb-proxy.yaml")]
+	g.Expect(configMap).To(ContainSubstring("load_module modules/ngx_http_js_module.so;"))
+	g.Expect(configMap).To(ContainSubstring("js_content couchdb.allDBs;"))
+	g.Expect(configMap).To(ContainSubstring("proxy_pass http://couchdb/_all_dbs;"))
+	g.Expect(configMap).To(ContainSubstring(`var prefix = "org1peer0_";`))
+	g.Expect(configMap).NotTo(ContainSubstring("return 403"))
+	deployment := manifests[filepath.Join("hlf-peer", "templates", "deployment.yaml")]
+	g.Expect(deployment).To(ContainSubstring("mountPath: /etc/nginx/couchdb-proxy.js"))
+
+	manifests = renderCouchDBProxy(g, mapExternalCouchDBToChart(&hlfv1alpha1.FabricPeerExternalCouchDB{
+		Enabled: true,
+		Host:    "couchdb",
+		Port:    6984,
+		TLS: &hlfv1alpha1.FabricPeerCouchDBTLS{
+			CACert: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "couchdb-tls"}, Key: "ca.crt"},
+		},
+	}))
+	configMap = manifests[filepath.Join("hlf-peer", "templates", "configmap--couchdb-proxy.yaml")]
+	g.Expect(configMap).NotTo(ContainSubstring("ngx_http_js_module"))
+	g.Expect(configMap).NotTo(ContainSubstring("couchdb-proxy.js"))
+	deployment = manifests[filepath.Join("hlf-peer", "templates", "deployment.yaml")]
+	g.Expect(deployment).NotTo(ContainSubstring("couchdb-proxy.js"))
+}
@@ -0,0 +1,317 @@
+package peer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mb "github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/protoutil"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	bccsputils "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric/bccsp/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/operations"
+	"github.com/kfsoftware/hlf-operator/pkg/status"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	healthCheckTimeout  = 10 * time.Second
+	healthCheckInterval = time.Minute
+)
+
+// refreshHealth refreshes the health and the channels of the running peers every healthCheckInterval until the
+// context is done. Only the status of the peers is updated, so the chart isn't upgraded by the periodic checks
+func (r *FabricPeerReconciler) refreshHealth(ctx context.Context) error {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			r.refreshPeersHealth(ctx)
+		}
+	}
+}
+
+func (r *FabricPeerReconciler) refreshPeersHealth(ctx context.Context) {
+	clientSet, err := utils.GetClientKubeWithConf(r.Config)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to refresh the health of the peers: %v", err))
+		return
+	}
+	peers := &hlfv1alpha1.FabricPeerList{}
+	err = r.List(ctx, peers)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to refresh the health of the peers: %v", err))
+		return
+	}
+	for i := range peers.Items {
+		fabricPeer := &peers.Items[i]
+		if fabricPeer.DeletionTimestamp != nil || !isHealthChecked(fabricPeer.Status.Status) {
+			continue
+		}
+		ns := getNamespace(fabricPeer)
+		svc, err := clientSet.CoreV1().Services(ns).Get(ctx, getServiceName(fabricPeer), v1.GetOptions{})
+		if err != nil {
+			log.Warning(fmt.Sprintf("Failed to refresh the health of peer %s: %v", fabricPeer.Name, err))
+			continue
+		}
+		fPeer := fabricPeer.DeepCopy()
+		resetHealth(&fPeer.Status)
+		setPeerHealth(ctx, r.Config, clientSet, fabricPeer, getReleaseName(fabricPeer), ns, svc, &fPeer.Status)
+		if !fabricPeer.Status.Conditions.IsTrueFor(utils.LogSpecAppliedCondition) {
+			r.setLogSpec(ctx, fabricPeer, svc, &fPeer.Status)
+		}
+		fPeer.Status.Conditions.SetCondition(status.Condition{
+			Type:   status.ConditionType(fPeer.Status.Status),
+			Status: "True",
+		})
+		if reflect.DeepEqual(fPeer.Status, fabricPeer.Status) {
+			continue
+		}
+		err = r.Status().Update(ctx, fPeer)
+		if err != nil {
+			log.Warning(fmt.Sprintf("Failed to update the health of peer %s: %v", fabricPeer.Name, err))
+		}
+	}
+}
+
+// isHealthChecked returns true for the peers whose pods are running, the other peers are requeued by the reconcile
+// until they're running
+func isHealthChecked(deploymentStatus hlfv1alpha1.DeploymentStatus) bool {
+	return deploymentStatus == hlfv1alpha1.RunningStatus || deploymentStatus == hlfv1alpha1.DegradedStatus
+}
+
+// resetHealth sets back a degraded peer to running, so it only stays degraded while a health check fails
+func resetHealth(r *hlfv1alpha1.FabricPeerStatus) {
+	if r.Status == hlfv1alpha1.DegradedStatus {
+		r.Status = hlfv1alpha1.RunningStatus
+		r.Message = ""
+	}
+}
+
+// setPeerHealth queries the operations endpoint and the ledger of a running peer, the peer is degraded when a health
+// check fails. The operations endpoint is reached through the API server, when it can't be reached the status is kept
+// and the OperationsReachable condition is false, the channels are left empty when the ledger can't be queried
+func setPeerHealth(ctx context.Context, config *rest.Config, clientSet *kubernetes.Clientset, fabricPeer *hlfv1alpha1.FabricPeer, releaseName string, ns string, svc *corev1.Service, r *hlfv1alpha1.FabricPeerStatus) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	health, err := getPeerHealth(ctx, config, svc)
+	r.Conditions.SetCondition(utils.GetOperationsReachableCondition(err))
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to query the health of peer %s: %v", fabricPeer.Name, err))
+	} else {
+		r.Health = mapHealth(health)
+		if len(health.FailedChecks) > 0 {
+			r.Status = hlfv1alpha1.DegradedStatus
+			r.Message = failedChecksMessage(health.FailedChecks)
+		}
+	}
+	channels, err := getPeerChannels(ctx, clientSet, fabricPeer, releaseName, ns, svc)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to query the channels of peer %s: %v", fabricPeer.Name, err))
+		return
+	}
+	r.Channels = channels
+}
+
+func getPeerHealth(ctx context.Context, config *rest.Config, svc *corev1.Service) (*operations.HealthStatus, error) {
+	opsClient, err := operations.NewServiceClient(config, svc)
+	if err != nil {
+		return nil, err
+	}
+	return opsClient.GetHealth(ctx)
+}
+
+func mapHealth(health *operations.HealthStatus) *hlfv1alpha1.FabricNodeHealth {
+	nodeHealth := &hlfv1alpha1.FabricNodeHealth{
+		Status: health.Status,
+	}
+	for _, check := range health.FailedChecks {
+		nodeHealth.FailedChecks = append(nodeHealth.FailedChecks, hlfv1alpha1.FabricNodeFailedCheck{
+			Component: check.Component,
+			Reason:    check.Reason,
+		})
+	}
+	return nodeHealth
+}
+
+func failedChecksMessage(checks []operations.FailedCheck) string {
+	var reasons []string
+	for _, check := range checks {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", check.Component, check.Reason))
+	}
+	return fmt.Sprintf("health checks failed: %s", strings.Join(reasons, ", "))
+}
+
+// getPeerChannels returns the channels joined by the peer with their heights, it queries CSCC and QSCC signed with the
+// identity of the peer, presenting the TLS certificate of the peer in case the peer requires client authentication
+func getPeerChannels(ctx context.Context, clientSet *kubernetes.Clientset, fabricPeer *hlfv1alpha1.FabricPeer, releaseName string, ns string, svc *corev1.Service) ([]hlfv1alpha1.FabricPeerChannelStatus, error) {
+	signCrt, signKey, _, err := getExistingSignCrypto(clientSet, releaseName, ns)
+	if err != nil {
+		return nil, err
+	}
+	if signKey == nil {
+		return nil, errors.New("the sign key of the peer is kept in a PKCS#11 token")
+	}
+	tlsCrt, tlsKey, tlsRootCrt, err := getExistingTLSCrypto(clientSet, releaseName, ns)
+	if err != nil {
+		return nil, err
+	}
+	var peerPort int32
+	for _, port := range svc.Spec.Ports {
+		if port.Name == PeerPortName {
+			peerPort = port.Port
+		}
+	}
+	if peerPort == 0 {
+		return nil, errors.Errorf("peer port not found in service %s", svc.Name)
+	}
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(tlsRootCrt)
+	tlsConfig := &tls.Config{
+		RootCAs: rootCAs,
+		Certificates: []tls.Certificate{
+			{
+				Certificate: [][]byte{tlsCrt.Raw},
+				PrivateKey:  tlsKey,
+			},
+		},
+		// the peer presents its own TLS certificate, so any of its names is valid
+		ServerName: tlsServerName(tlsCrt),
+	}
+	conn, err := grpc.DialContext(
+		ctx,
+		fmt.Sprintf("%s.%s:%d", svc.Name, ns, peerPort),
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to peer %s", svc.Name)
+	}
+	defer conn.Close()
+	signer := &peerSigner{
+		mspID: fabricPeer.Spec.MspID,
+		cert:  signCrt,
+		key:   signKey,
+	}
+	endorserClient := pb.NewEndorserClient(conn)
+	payload, err := querySystemChaincode(ctx, endorserClient, signer, "cscc", "GetChannels")
+	if err != nil {
+		return nil, err
+	}
+	channelsResponse := &pb.ChannelQueryResponse{}
+	err = proto.Unmarshal(payload, channelsResponse)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the channels of the peer")
+	}
+	var channels []hlfv1alpha1.FabricPeerChannelStatus
+	for _, channel := range channelsResponse.Channels {
+		payload, err = querySystemChaincode(ctx, endorserClient, signer, "qscc", "GetChainInfo", channel.ChannelId)
+		if err != nil {
+			return nil, err
+		}
+		chainInfo := &cb.BlockchainInfo{}
+		err = proto.Unmarshal(payload, chainInfo)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal the chain info of channel %s", channel.ChannelId)
+		}
+		channels = append(channels, hlfv1alpha1.FabricPeerChannelStatus{
+			Name:   channel.ChannelId,
+			Height: chainInfo.Height,
+		})
+	}
+	return channels, nil
+}
+
+func tlsServerName(crt *x509.Certificate) string {
+	if len(crt.DNSNames) > 0 {
+		return crt.DNSNames[0]
+	}
+	return crt.Subject.CommonName
+}
+
+func querySystemChaincode(ctx context.Context, endorserClient pb.EndorserClient, signer *peerSigner, chaincode string, args ...string) ([]byte, error) {
+	creator, err := signer.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	var input [][]byte
+	for _, arg := range args {
+		input = append(input, []byte(arg))
+	}
+	prop, _, err := protoutil.CreateChaincodeProposal(
+		cb.HeaderType_ENDORSER_TRANSACTION,
+		"",
+		&pb.ChaincodeInvocationSpec{
+			ChaincodeSpec: &pb.ChaincodeSpec{
+				Type:        pb.ChaincodeSpec_GOLANG,
+				ChaincodeId: &pb.ChaincodeID{Name: chaincode},
+				Input:       &pb.ChaincodeInput{Args: input},
+			},
+		},
+		creator,
+	)
+	if err != nil {
+		return nil, err
+	}
+	signedProp, err := protoutil.GetSignedProposal(prop, signer)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := endorserClient.ProcessProposal(ctx, signedProp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query %s %s", chaincode, args[0])
+	}
+	if resp.Response == nil || resp.Response.Status != 200 {
+		return nil, errors.Errorf("%s %s failed: %s", chaincode, args[0], resp.Response.GetMessage())
+	}
+	return resp.Response.Payload, nil
+}
+
+// peerSigner signs the proposals with the enrollment certificate of the peer
+type peerSigner struct {
+	mspID string
+	cert  *x509.Certificate
+	key   crypto.Signer
+}
+
+func (s *peerSigner) Sign(msg []byte) ([]byte, error) {
+	ecdsaKey, ok := s.key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("only ECDSA keys can sign proposals")
+	}
+	digest := sha256.Sum256(msg)
+	signature, err := ecdsa.SignASN1(rand.Reader, ecdsaKey, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	return bccsputils.SignatureToLowS(&ecdsaKey.PublicKey, signature)
+}
+
+func (s *peerSigner) Serialize() ([]byte, error) {
+	return proto.Marshal(&mb.SerializedIdentity{
+		Mspid:   s.mspID,
+		IdBytes: utils.EncodeX509Certificate(s.cert),
+	})
+}
@@ -0,0 +1,44 @@
+package peer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/pkg/k8sbuilder"
+)
+
+// k8sBuilderPath is the directory of the peer container where the builder provided by the operator is installed
+const k8sBuilderPath = "/builders/k8s"
+
+// getK8sBuilder maps the builder provided by the operator to the chart, its image is the one of the builder, the
+// default image of the operator or the latest image of the operator
+func getK8sBuilder(builder *hlfv1alpha1.ExternalBuilderKubernetes, defaultImage string) (K8sBuilder, error) {
+	image := defaultImage
+	if builder.Image != "" && builder.Tag != "" {
+		image = fmt.Sprintf("%s:%s", builder.Image, builder.Tag)
+	} else if image == "" {
+		image = fmt.Sprintf("%s:%s", helpers.DefaultK8sBuilderImage, helpers.DefaultK8sBuilderVersion)
+	}
+	pullPolicy := builder.PullPolicy
+	if pullPolicy == "" {
+		pullPolicy = hlfv1alpha1.DefaultImagePullPolicy
+	}
+	config, err := json.Marshal(k8sbuilder.Config{
+		PullPolicy:         builder.ChaincodePullPolicy,
+		ImagePullSecrets:   builder.ImagePullSecrets,
+		Resources:          builder.Resources,
+		ServiceAccountName: builder.ServiceAccountName,
+	})
+	if err != nil {
+		return K8sBuilder{}, err
+	}
+	return K8sBuilder{
+		Enabled:    true,
+		Path:       k8sBuilderPath,
+		Image:      image,
+		PullPolicy: string(pullPolicy),
+		Config:     string(config),
+	}, nil
+}
@@ -0,0 +1,64 @@
+package peer
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const peerPodDisruptionBudgetKind = "peers"
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// getPeerPodDisruptionBudgetName returns the name of the PodDisruptionBudget of the peers of an organization
+func getPeerPodDisruptionBudgetName(mspID string) string {
+	name := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(mspID), "-"), "-")
+	return name + "-peers"
+}
+
+// reconcilePodDisruptionBudgets creates a PodDisruptionBudget for the peers of each organization in the namespace that
+// have it enabled, so a node drain doesn't stop all the peers of an organization at once
+func (r *FabricPeerReconciler) reconcilePodDisruptionBudgets(ctx context.Context, clientSet kubernetes.Interface, ns string) error {
+	peerList := &hlfv1alpha1.FabricPeerList{}
+	err := r.List(ctx, peerList, client.InNamespace(ns))
+	if err != nil {
+		return err
+	}
+	groupsByName := map[string]*utils.PodDisruptionBudgetGroup{}
+	for i := range peerList.Items {
+		peer := &peerList.Items[i]
+		pdb := peer.Spec.PodDisruptionBudget
+		if peer.DeletionTimestamp != nil || pdb == nil || !pdb.Enabled {
+			continue
+		}
+		maxUnavailable := utils.GetMaxUnavailable(pdb.MaxUnavailable)
+		name := getPeerPodDisruptionBudgetName(peer.Spec.MspID)
+		group, ok := groupsByName[name]
+		if !ok {
+			group = &utils.PodDisruptionBudgetGroup{
+				Name:           name,
+				MaxUnavailable: maxUnavailable,
+			}
+			groupsByName[name] = group
+		}
+		if maxUnavailable < group.MaxUnavailable {
+			group.MaxUnavailable = maxUnavailable
+		}
+		group.Releases = append(group.Releases, peer.Name)
+		group.Owners = append(group.Owners, utils.NewOwnerReference(peer, hlfv1alpha1.GroupVersion.WithKind("FabricPeer")))
+	}
+	var groups []utils.PodDisruptionBudgetGroup
+	for _, group := range groupsByName {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return utils.ReconcilePodDisruptionBudgets(ctx, clientSet, ns, peerPodDisruptionBudgetKind, groups)
+}
@@ -2,14 +2,17 @@ package peer
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"crypto"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"github.com/kfsoftware/hlf-operator/controllers/hlfmetrics"
+	"github.com/kfsoftware/hlf-operator/controllers/overrides"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/operations"
+	"github.com/kfsoftware/hlf-operator/pkg/k8sbuilder"
 	"k8s.io/apimachinery/pkg/types"
 	"os"
 	"reflect"
@@ -24,12 +27,14 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/kubernetes/pkg/api/v1/pod"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/go-logr/logr"
 	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
 	"github.com/kfsoftware/hlf-operator/controllers/certs"
 	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/storage/driver"
@@ -52,6 +57,8 @@ type FabricPeerReconciler struct {
 	Log       logr.Logger
 	Scheme    *runtime.Scheme
 	Config    *rest.Config
+	// K8sBuilderImage is the default image of the builder that launches the chaincodes in kubernetes
+	K8sBuilderImage string
 }
 
 func (r *FabricPeerReconciler) addFinalizer(reqLogger logr.Logger, m *hlfv1alpha1.FabricPeer) error {
@@ -134,7 +141,7 @@ func GetPeerDeployment(conf *action.Configuration, config *rest.Config, releaseN
 	return nil, errors.Errorf("Deployment not found")
 
 }
-func GetPeerState(conf *action.Configuration, config *rest.Config, releaseName string, ns string, svc *corev1.Service) (*hlfv1alpha1.FabricPeerStatus, error) {
+func GetPeerState(conf *action.Configuration, config *rest.Config, releaseName string, ns string, svc *corev1.Service, fabricPeer *hlfv1alpha1.FabricPeer) (*hlfv1alpha1.FabricPeerStatus, error) {
 	ctx := context.Background()
 	cmd := action.NewGet(conf)
 	rel, err := cmd.Run(releaseName)
@@ -225,6 +232,10 @@ func GetPeerState(conf *action.Configuration, config *rest.Config, releaseName s
 		releaseName,
 		ns,
 	)
+	if r.Status == hlfv1alpha1.RunningStatus {
+		setPeerHealth(ctx, config, clientSet, fabricPeer, releaseName, ns, svc, r)
+	}
+	setCouchDBStatus(ctx, clientSet, ns, fabricPeer.Spec, r)
 	return r, nil
 }
 
@@ -252,6 +263,8 @@ const chartName = "hlf-peer"
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
 
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=podmonitors,verbs=get;list;watch;create;update;patch;delete
@@ -275,6 +288,7 @@ const chartName = "hlf-peer"
 // +kubebuilder:rbac:groups=apps,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=extensions,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services/proxy,verbs=get;create;update
 
 // +kubebuilder:rbac:groups=apps,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
@@ -292,6 +306,10 @@ const chartName = "hlf-peer"
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=extensions,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+
 // +kubebuilder:rbac:groups=networking.istio.io,resources=gateways,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices,verbs=get;list;watch;create;update;patch;delete
 
@@ -362,6 +380,11 @@ func (r *FabricPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricPeer)
 	}
+	hlfClientSet, err := operatorv1.NewForConfig(r.Config)
+	if err != nil {
+		r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricPeer)
+	}
 	svc, err := createPeerService(
 		clientSet,
 		chartName,
@@ -372,9 +395,42 @@ func (r *FabricPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricPeer)
 	}
 	reqLogger.Info(fmt.Sprintf("Service %s created", svc.Name))
+	err = r.reconcilePodDisruptionBudgets(ctx, clientSet, ns)
+	if err != nil {
+		r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
+		return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricPeer)
+	}
 	if exists {
+		// the peer is stopped while the state database is migrated, the chart is upgraded in the last step
+		migrating, err := r.reconcileStateDbMigration(ctx, cfg, clientSet, fabricPeer, releaseName, ns, func() error {
+			c, err := GetConfig(fabricPeer, clientSet, hlfClientSet, releaseName, req.Namespace, svc, false, r.K8sBuilderImage)
+			if err != nil {
+				return err
+			}
+			// the peer starts using CouchDB at this step, so it's always checked
+			err = preCheckExternalCouchDB(ctx, cfg, clientSet, fabricPeer, releaseName, ns, c, false)
+			if err != nil {
+				return err
+			}
+			return r.upgradeChart(cfg, err, ns, releaseName, c)
+		})
+		if err != nil {
+			r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricPeer)
+		}
+		if migrating {
+			log.Infof("Peer %s is migrating its state database", fabricPeer.Name)
+			return ctrl.Result{
+				RequeueAfter: 10 * time.Second,
+			}, nil
+		}
 		// update
-		c, err := GetConfig(fabricPeer, clientSet, releaseName, req.Namespace, svc, false)
+		c, err := GetConfig(fabricPeer, clientSet, hlfClientSet, releaseName, req.Namespace, svc, false, r.K8sBuilderImage)
+		if err != nil {
+			r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricPeer)
+		}
+		err = preCheckExternalCouchDB(ctx, cfg, clientSet, fabricPeer, releaseName, ns, c, true)
 		if err != nil {
 			r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
 			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricPeer)
@@ -395,7 +451,7 @@ func (r *FabricPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				// update the certs
 				// scale up the peer
 				log.Infof("Trying to upgrade certs")
-				err := r.updateCerts(req, fabricPeer, clientSet, releaseName, svc, ctx, cfg, ns)
+				err := r.updateCerts(req, fabricPeer, clientSet, hlfClientSet, releaseName, svc, ctx, cfg, ns)
 				if err != nil {
 					log.Errorf("Error renewing certs: %v", err)
 					r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
@@ -405,7 +461,7 @@ func (r *FabricPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}
 		} else if fabricPeer.Status.LastCertificateUpdate == nil && fabricPeer.Spec.UpdateCertificateTime != nil {
 			log.Infof("Trying to upgrade certs")
-			err := r.updateCerts(req, fabricPeer, clientSet, releaseName, svc, ctx, cfg, ns)
+			err := r.updateCerts(req, fabricPeer, clientSet, hlfClientSet, releaseName, svc, ctx, cfg, ns)
 			if err != nil {
 				log.Errorf("Error renewing certs: %v", err)
 				r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
@@ -413,7 +469,13 @@ func (r *FabricPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}
 			lastTimeCertsRenewed = fabricPeer.Spec.UpdateCertificateTime
 		}
-		s, err := GetPeerState(cfg, r.Config, releaseName, ns, svc)
+		s, err := GetPeerState(cfg, r.Config, releaseName, ns, svc, fabricPeer)
+		if err != nil {
+			r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricPeer)
+		}
+
+		coreOverrides, err := overrides.Core.Apply(fabricPeer.Spec.CoreOverrides)
 		if err != nil {
 			r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
 			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricPeer)
@@ -421,7 +483,11 @@ func (r *FabricPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 		fPeer := fabricPeer.DeepCopy()
 		fPeer.Status.Status = s.Status
-		fPeer.Status.Message = ""
+		fPeer.Status.Message = s.Message
+		fPeer.Status.Warnings = coreOverrides.Warnings
+		fPeer.Status.Health = s.Health
+		fPeer.Status.Channels = s.Channels
+		fPeer.Status.CouchDB = s.CouchDB
 		fPeer.Status.TlsCert = s.TlsCert
 		fPeer.Status.TlsCACert = s.TlsCACert
 		fPeer.Status.SignCert = s.SignCert
@@ -432,6 +498,12 @@ func (r *FabricPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			Type:   status.ConditionType(s.Status),
 			Status: "True",
 		})
+		for _, condition := range s.Conditions {
+			fPeer.Status.Conditions.SetCondition(condition)
+		}
+		if isHealthChecked(s.Status) {
+			r.setLogSpec(ctx, fabricPeer, svc, &fPeer.Status)
+		}
 		if !reflect.DeepEqual(fPeer.Status, fabricPeer.Status) {
 			if err := r.Status().Update(ctx, fPeer); err != nil {
 				log.Errorf("Error updating the status: %v", err)
@@ -451,7 +523,8 @@ func (r *FabricPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			return ctrl.Result{
 				RequeueAfter: 10 * time.Second,
 			}, nil
-		case hlfv1alpha1.RunningStatus:
+		case hlfv1alpha1.RunningStatus, hlfv1alpha1.DegradedStatus:
+			// the health and the heights of the channels are refreshed by refreshHealth
 			return ctrl.Result{}, nil
 		default:
 			return ctrl.Result{
@@ -475,15 +548,22 @@ func (r *FabricPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		c, err := GetConfig(
 			fabricPeer,
 			clientSet,
+			hlfClientSet,
 			name,
 			req.Namespace,
 			svc,
 			false,
+			r.K8sBuilderImage,
 		)
 		if err != nil {
 			r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
 			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricPeer)
 		}
+		err = preCheckExternalCouchDB(ctx, cfg, clientSet, fabricPeer, releaseName, ns, c, false)
+		if err != nil {
+			r.setConditionStatus(ctx, fabricPeer, hlfv1alpha1.FailedStatus, false, err, false)
+			return r.updateCRStatusOrFailReconcile(ctx, r.Log, fabricPeer)
+		}
 		var inInterface map[string]interface{}
 		inrec, err := json.Marshal(c)
 		if err != nil {
@@ -519,10 +599,10 @@ func (r *FabricPeerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 }
 
-func (r *FabricPeerReconciler) updateCerts(req ctrl.Request, fPeer *hlfv1alpha1.FabricPeer, clientSet *kubernetes.Clientset, releaseName string, svc *corev1.Service, ctx context.Context, cfg *action.Configuration, ns string) error {
+func (r *FabricPeerReconciler) updateCerts(req ctrl.Request, fPeer *hlfv1alpha1.FabricPeer, clientSet *kubernetes.Clientset, hlfClientSet *operatorv1.Clientset, releaseName string, svc *corev1.Service, ctx context.Context, cfg *action.Configuration, ns string) error {
 	log.Infof("Trying to upgrade certs")
 	r.setConditionStatus(ctx, fPeer, hlfv1alpha1.UpdatingCertificates, false, nil, false)
-	config, err := GetConfig(fPeer, clientSet, releaseName, req.Namespace, svc, true)
+	config, err := GetConfig(fPeer, clientSet, hlfClientSet, releaseName, req.Namespace, svc, true, r.K8sBuilderImage)
 	if err != nil {
 		log.Errorf("Error getting the config: %v", err)
 		return err
@@ -578,6 +658,14 @@ func (r *FabricPeerReconciler) upgradeChart(
 	if err != nil {
 		return err
 	}
+	upToDate, err := utils.ReleaseUpToDate(cfg, releaseName, ch, inInterface)
+	if err != nil {
+		return err
+	}
+	if upToDate {
+		log.Debugf("Release %s is up to date, skipping the upgrade", releaseName)
+		return nil
+	}
 	cmd.Wait = true
 	cmd.Timeout = time.Minute * 5
 	release, err := cmd.Run(releaseName, ch, inInterface)
@@ -627,7 +715,7 @@ func (r *FabricPeerReconciler) setConditionStatus(ctx context.Context, p *hlfv1a
 	return p.Status.Conditions.SetCondition(condition())
 }
 
-func getExistingTLSOPSCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func getExistingTLSOPSCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	secretName := fmt.Sprintf("%s-tls-ops", chartName)
 	tlsRootSecretName := fmt.Sprintf("%s-tlsrootcert", chartName)
 	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, v1.GetOptions{})
@@ -642,7 +730,7 @@ func getExistingTLSOPSCrypto(client *kubernetes.Clientset, chartName string, nam
 	tlsKeyData := secret.Data["tls.key"]
 	tlsCrtData := secret.Data["tls.crt"]
 	rootTLSCrtData := rootCertSecret.Data["cacert.pem"]
-	key, err := utils.ParseECDSAPrivateKey(tlsKeyData)
+	key, err := utils.ParsePrivateKey(tlsKeyData)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -670,7 +758,7 @@ func (r *FabricPeerReconciler) updateCRStatusOrFailReconcile(ctx context.Context
 	return reconcile.Result{}, nil
 }
 
-func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	secretName := fmt.Sprintf("%s-tls", chartName)
 	tlsRootSecretName := fmt.Sprintf("%s-tlsrootcert", chartName)
 	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, v1.GetOptions{})
@@ -685,7 +773,7 @@ func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namesp
 	tlsKeyData := secret.Data["tls.key"]
 	tlsCrtData := secret.Data["tls.crt"]
 	rootTLSCrtData := rootCertSecret.Data["cacert.pem"]
-	key, err := utils.ParseECDSAPrivateKey(tlsKeyData)
+	key, err := utils.ParsePrivateKey(tlsKeyData)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -700,7 +788,7 @@ func getExistingTLSCrypto(client *kubernetes.Clientset, chartName string, namesp
 	return crt, key, rootCrt, nil
 }
 
-func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, namespace string) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	secretCrtName := fmt.Sprintf("%s-idcert", chartName)
 	secretKeyName := fmt.Sprintf("%s-idkey", chartName)
 	secretRootCrtName := fmt.Sprintf("%s-cacert", chartName)
@@ -728,14 +816,41 @@ func getExistingSignCrypto(client *kubernetes.Clientset, chartName string, names
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	key, err := utils.ParseECDSAPrivateKey(signKeyData)
-	if err != nil {
-		return nil, nil, nil, err
+	// the key is empty when it's kept in a PKCS#11 token
+	var key crypto.Signer
+	if len(signKeyData) > 0 {
+		key, err = utils.ParsePrivateKey(signKeyData)
+		if err != nil {
+			return nil, nil, nil, err
+		}
 	}
 	return crt, key, rootCrt, nil
 }
 
-func CreateTLSCryptoMaterial(conf *hlfv1alpha1.FabricPeer, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, hosts []string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+// getIntermediateCerts returns the intermediate certificates between the certificate of the peer and the root
+// certificate, they're read from the chart secret when they still sign the certificate or from the CA otherwise
+func getIntermediateCerts(
+	client *kubernetes.Clientset,
+	secretName string,
+	namespace string,
+	crt *x509.Certificate,
+	rootCrt *x509.Certificate,
+	caInfoRequest certs.GetCAInfoRequest,
+) (string, error) {
+	if crt.CheckSignatureFrom(rootCrt) == nil {
+		return "", nil
+	}
+	secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, v1.GetOptions{})
+	if err == nil {
+		intCrts, err := utils.ParseX509Certificates(secret.Data["intcacert.pem"])
+		if err == nil && len(intCrts) > 0 && crt.CheckSignatureFrom(intCrts[len(intCrts)-1]) == nil {
+			return string(secret.Data["intcacert.pem"]), nil
+		}
+	}
+	return certs.GetCAIntermediateCerts(caInfoRequest)
+}
+
+func CreateTLSCryptoMaterial(conf *hlfv1alpha1.FabricPeer, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, hosts []string) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	tlsCert, tlsKey, tlsRootCert, err := certs.EnrollUser(certs.EnrollUserRequest{
 		TLSCert:    tlsCertString,
 		URL:        caurl,
@@ -747,6 +862,7 @@ func CreateTLSCryptoMaterial(conf *hlfv1alpha1.FabricPeer, caName string, caurl
 		CN:         "",
 		Profile:    "tls",
 		Attributes: nil,
+		KeyRequest: conf.Spec.Secret.Enrollment.TLS.KeyRequest,
 	})
 	if err != nil {
 		return nil, nil, nil, err
@@ -754,7 +870,7 @@ func CreateTLSCryptoMaterial(conf *hlfv1alpha1.FabricPeer, caName string, caurl
 	return tlsCert, tlsKey, tlsRootCert, nil
 }
 
-func CreateTLSOPSCryptoMaterial(conf *hlfv1alpha1.FabricPeer, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, hosts []string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func CreateTLSOPSCryptoMaterial(conf *hlfv1alpha1.FabricPeer, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, hosts []string) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
 	tlsCert, tlsKey, tlsRootCert, err := certs.EnrollUser(
 		certs.EnrollUserRequest{
 			TLSCert:    tlsCertString,
@@ -767,6 +883,7 @@ func CreateTLSOPSCryptoMaterial(conf *hlfv1alpha1.FabricPeer, caName string, cau
 			CN:         "",
 			Profile:    "tls",
 			Attributes: nil,
+			KeyRequest: conf.Spec.Secret.Enrollment.TLS.KeyRequest,
 		},
 	)
 	if err != nil {
@@ -775,14 +892,20 @@ func CreateTLSOPSCryptoMaterial(conf *hlfv1alpha1.FabricPeer, caName string, cau
 	return tlsCert, tlsKey, tlsRootCert, nil
 }
 
-func CreateSignCryptoMaterial(conf *hlfv1alpha1.FabricPeer, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate, error) {
+func CreateSignCryptoMaterial(conf *hlfv1alpha1.FabricPeer, caName string, caurl string, enrollID string, enrollSecret string, tlsCertString string, pkcs11Opts *certs.PKCS11Opts) (*x509.Certificate, crypto.Signer, *x509.Certificate, error) {
+	keyRequest := conf.Spec.Secret.Enrollment.Component.KeyRequest
+	if err := certs.ValidateSignKeyRequest(keyRequest); err != nil {
+		return nil, nil, nil, err
+	}
 	tlsCert, tlsKey, tlsRootCert, err := certs.EnrollUser(certs.EnrollUserRequest{
-		TLSCert: tlsCertString,
-		URL:     caurl,
-		Name:    caName,
-		MSPID:   conf.Spec.MspID,
-		User:    enrollID,
-		Secret:  enrollSecret,
+		TLSCert:    tlsCertString,
+		URL:        caurl,
+		Name:       caName,
+		MSPID:      conf.Spec.MspID,
+		User:       enrollID,
+		Secret:     enrollSecret,
+		KeyRequest: keyRequest,
+		PKCS11:     pkcs11Opts,
 	})
 	if err != nil {
 		return nil, nil, nil, err
@@ -793,10 +916,12 @@ func CreateSignCryptoMaterial(conf *hlfv1alpha1.FabricPeer, caName string, caurl
 func GetConfig(
 	conf *hlfv1alpha1.FabricPeer,
 	client *kubernetes.Clientset,
+	hlfClientSet *operatorv1.Clientset,
 	chartName string,
 	namespace string,
 	svc *corev1.Service,
 	refreshCerts bool,
+	k8sBuilderImage string,
 ) (*FabricPeerChart, error) {
 	spec := conf.Spec
 	tlsParams := conf.Spec.Secret.Enrollment.TLS
@@ -806,7 +931,7 @@ func GetConfig(
 	hosts = append(hosts, tlsParams.Csr.Hosts...)
 	hosts = append(hosts, ingressHosts...)
 	var tlsCert, tlsRootCert, tlsOpsCert, signCert, signRootCert *x509.Certificate
-	var tlsKey, tlsOpsKey, signKey *ecdsa.PrivateKey
+	var tlsKey, tlsOpsKey, signKey crypto.Signer
 	var err error
 	if refreshCerts {
 		cacert, err := base64.StdEncoding.DecodeString(tlsParams.Catls.Cacert)
@@ -886,6 +1011,10 @@ func GetConfig(
 	}
 	signParams := conf.Spec.Secret.Enrollment.Component
 	caUrl := fmt.Sprintf("https://%s:%d", signParams.Cahost, signParams.Caport)
+	pkcs11Opts, err := certs.GetPKCS11Opts(context.Background(), client, namespace, spec.PKCS11)
+	if err != nil {
+		return nil, err
+	}
 	if refreshCerts {
 		cacert, err := base64.StdEncoding.DecodeString(signParams.Catls.Cacert)
 		if err != nil {
@@ -898,13 +1027,15 @@ func GetConfig(
 			signParams.Enrollid,
 			signParams.Enrollsecret,
 			string(cacert),
+			pkcs11Opts,
 		)
 		if err != nil {
 			return nil, err
 		}
 	} else {
 		signCert, signKey, signRootCert, err = getExistingSignCrypto(client, chartName, namespace)
-		if err != nil {
+		// the key is enrolled again when it isn't stored where the BCCSP of the peer expects it
+		if err != nil || (signKey == nil) != (pkcs11Opts != nil) {
 			cacert, err := base64.StdEncoding.DecodeString(signParams.Catls.Cacert)
 			if err != nil {
 				return nil, err
@@ -916,12 +1047,53 @@ func GetConfig(
 				signParams.Enrollid,
 				signParams.Enrollsecret,
 				string(cacert),
+				pkcs11Opts,
 			)
 			if err != nil {
 				return nil, err
 			}
 		}
 	}
+	tlsCACert, err := base64.StdEncoding.DecodeString(tlsParams.Catls.Cacert)
+	if err != nil {
+		return nil, err
+	}
+	intTLSCACert, err := getIntermediateCerts(
+		client,
+		fmt.Sprintf("%s-tlsintcacert", chartName),
+		namespace,
+		tlsCert,
+		tlsRootCert,
+		certs.GetCAInfoRequest{
+			TLSCert: string(tlsCACert),
+			URL:     tlsCAUrl,
+			Name:    tlsParams.Caname,
+			MSPID:   conf.Spec.MspID,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	signCACert, err := base64.StdEncoding.DecodeString(signParams.Catls.Cacert)
+	if err != nil {
+		return nil, err
+	}
+	intCACert, err := getIntermediateCerts(
+		client,
+		fmt.Sprintf("%s-intcacert", chartName),
+		namespace,
+		signCert,
+		signRootCert,
+		certs.GetCAInfoRequest{
+			TLSCert: string(signCACert),
+			URL:     caUrl,
+			Name:    signParams.Caname,
+			MSPID:   conf.Spec.MspID,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
 	tlsCRTEncoded := pem.EncodeToMemory(&pem.Block{
 		Type:  "CERTIFICATE",
 		Bytes: tlsCert.Raw,
@@ -959,14 +1131,17 @@ func GetConfig(
 		Type:  "CERTIFICATE",
 		Bytes: signRootCert.Raw,
 	})
-	signEncodedPK, err := x509.MarshalPKCS8PrivateKey(signKey)
-	if err != nil {
-		return nil, err
+	var signPEMEncodedPK []byte
+	if pkcs11Opts == nil {
+		signEncodedPK, err := x509.MarshalPKCS8PrivateKey(signKey)
+		if err != nil {
+			return nil, err
+		}
+		signPEMEncodedPK = pem.EncodeToMemory(&pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: signEncodedPK,
+		})
 	}
-	signPEMEncodedPK := pem.EncodeToMemory(&pem.Block{
-		Type:  "PRIVATE KEY",
-		Bytes: signEncodedPK,
-	})
 	var externalEndpoint string
 	if spec.ExternalEndpoint != "" {
 		externalEndpoint = spec.ExternalEndpoint
@@ -991,7 +1166,20 @@ func GetConfig(
 		gossipEndpoint = externalEndpoint
 	}
 	externalBuilders := []ExternalBuilder{}
+	k8sBuilder := K8sBuilder{}
 	for _, builder := range spec.ExternalBuilders {
+		if builder.Kubernetes != nil {
+			k8sBuilder, err = getK8sBuilder(builder.Kubernetes, k8sBuilderImage)
+			if err != nil {
+				return nil, err
+			}
+			externalBuilders = append(externalBuilders, ExternalBuilder{
+				Name:                 builder.Name,
+				Path:                 k8sBuilder.Path,
+				PropagateEnvironment: append(append([]string{}, k8sbuilder.PropagateEnvironment...), builder.PropagateEnvironment...),
+			})
+			continue
+		}
 		externalBuilders = append(externalBuilders, ExternalBuilder{
 			Name:                 builder.Name,
 			Path:                 builder.Path,
@@ -1084,13 +1272,8 @@ func GetConfig(
 		}
 	}
 
-	couchDB := CouchDB{}
-	if spec.CouchDB.ExternalCouchDB != nil && spec.CouchDB.ExternalCouchDB.Enabled {
-		couchDB.External = CouchDBExternal{
-			Enabled: true,
-			Host:    spec.CouchDB.ExternalCouchDB.Host,
-			Port:    spec.CouchDB.ExternalCouchDB.Port,
-		}
+	couchDB := CouchDB{
+		External: mapExternalCouchDBToChart(spec.CouchDB.ExternalCouchDB),
 	}
 	if spec.CouchDB.Image != "" && spec.CouchDB.Tag != "" {
 		couchDB.Image = spec.CouchDB.Image
@@ -1119,6 +1302,16 @@ func GetConfig(
 		fsServer.Tag = helpers.DefaultFSServerVersion
 		fsServer.PullPolicy = string(hlfv1alpha1.DefaultImagePullPolicy)
 	}
+	coreOverrides, err := overrides.Core.Apply(spec.CoreOverrides)
+	if err != nil {
+		return nil, err
+	}
+	// the env of the spec is set after the overrides so it takes precedence
+	envVars := append(coreOverrides.EnvVars, spec.Env...)
+	clientAuth, clientRootCerts, err := getClientAuthValues(context.Background(), hlfClientSet, spec)
+	if err != nil {
+		return nil, err
+	}
 	proxy := GRPCProxy{
 		Enabled:          false,
 		Image:            "",
@@ -1156,7 +1349,8 @@ func GetConfig(
 	}
 
 	var c = FabricPeerChart{
-		EnvVars:          spec.Env,
+		EnvVars:          envVars,
+		PKCS11:           getPKCS11Chart(spec.PKCS11),
 		Replicas:         spec.Replicas,
 		ImagePullSecrets: spec.ImagePullSecrets,
 		Istio:            istio,
@@ -1168,6 +1362,7 @@ func GetConfig(
 		Proxy:            proxy,
 		ServiceMonitor:   monitor,
 		ExternalBuilders: externalBuilders,
+		K8sBuilder:       k8sBuilder,
 		DockerSocketPath: spec.DockerSocketPath,
 		CouchDBExporter:  exporter,
 		CouchDB:          couchDB,
@@ -1184,12 +1379,12 @@ func GetConfig(
 			},
 			TLS: TLSAuth{
 				Server: Server{Enabled: true},
-				Client: Client{Enabled: false},
+				Client: clientAuth,
 			},
 		},
 		ExternalChaincodeBuilder: conf.Spec.ExternalChaincodeBuilder,
-		CouchdbPassword:          conf.Spec.CouchDB.User,
-		CouchdbUsername:          conf.Spec.CouchDB.Password,
+		CouchdbPassword:          conf.Spec.CouchDB.Password,
+		CouchdbUsername:          conf.Spec.CouchDB.User,
 		Rbac:                     RBAC{Ns: namespace},
 		Cert:                     string(signCRTEncoded),
 		Key:                      string(signPEMEncodedPK),
@@ -1202,9 +1397,11 @@ func GetConfig(
 			Cert: string(tlsOpsCRTEncoded),
 			Key:  string(tlsOpsPEMEncodedPK),
 		},
-		Cacert:      string(signRootCRTEncoded),
-		IntCacert:   ``,
-		Tlsrootcert: string(tlsRootCRTEncoded),
+		Cacert:          string(signRootCRTEncoded),
+		IntCacert:       intCACert,
+		IntTLSCacert:    intTLSCACert,
+		Tlsrootcert:     string(tlsRootCRTEncoded),
+		ClientRootCerts: clientRootCerts,
 		Resources: PeerResources{
 			Peer: Resources{
 				Requests: Requests{
@@ -1239,12 +1436,14 @@ func GetConfig(
 			CouchDBExporter: couchDBExporterResources,
 			Proxy:           proxyResources,
 		},
-		NodeSelector:     spec.NodeSelector,
-		Tolerations:      spec.Tolerations,
-		Affinity:         spec.Affinity,
-		ExternalHost:     externalEndpoint,
-		FullnameOverride: conf.Name,
-		HostAliases:      hostAliases,
+		NodeSelector:              spec.NodeSelector,
+		Tolerations:               spec.Tolerations,
+		Affinity:                  spec.Affinity,
+		TopologySpreadConstraints: spec.TopologySpreadConstraints,
+		PriorityClassName:         spec.PriorityClassName,
+		ExternalHost:              externalEndpoint,
+		FullnameOverride:          conf.Name,
+		HostAliases:               hostAliases,
 		Service: Service{
 			Type: string(spec.Service.Type),
 		},
@@ -1286,6 +1485,10 @@ func GetConfig(
 }
 
 func (r *FabricPeerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.Add(manager.RunnableFunc(r.refreshHealth))
+	if err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&hlfv1alpha1.FabricPeer{}).
 		Owns(&appsv1.Deployment{}).
@@ -1335,7 +1538,38 @@ func (r *FabricPeerReconciler) finalizePeer(reqLogger logr.Logger, peer *hlfv1al
 const PeerPortName = "peer"
 const ChaincodePortName = "chaincode"
 const EventPortName = "event"
-const OperationsPortName = "operations"
+
+// setLogSpec applies the logging spec to the running peer, a failure doesn't requeue the reconcile, it's reported in the
+// LogSpecApplied condition and retried by refreshHealth
+func (r *FabricPeerReconciler) setLogSpec(ctx context.Context, fabricPeer *hlfv1alpha1.FabricPeer, svc *corev1.Service, peerStatus *hlfv1alpha1.FabricPeerStatus) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	err := applyLogSpec(ctx, r.Config, svc, fabricPeer.Spec.Logging.Level)
+	if err != nil {
+		log.Warning(fmt.Sprintf("Failed to apply the logging spec to peer %s: %v", fabricPeer.Name, err))
+	}
+	peerStatus.Conditions.SetCondition(utils.GetLogSpecAppliedCondition(err))
+}
+
+// applyLogSpec sets the logging spec on the running peer through its operations endpoint, the config map keeps the
+// spec for the next restarts so the peer doesn't need to be restarted
+func applyLogSpec(ctx context.Context, config *rest.Config, svc *corev1.Service, spec string) error {
+	if spec == "" {
+		return nil
+	}
+	opsClient, err := operations.NewServiceClient(config, svc)
+	if err != nil {
+		return err
+	}
+	changed, err := opsClient.ApplyLogSpec(ctx, spec)
+	if err != nil {
+		return err
+	}
+	if changed {
+		log.Infof("Logging spec of peer %s set to %s", svc.Name, spec)
+	}
+	return nil
+}
 
 func getRequestNodePort(svc *corev1.Service) (int, error) {
 	for _, port := range svc.Spec.Ports {
@@ -1421,7 +1655,7 @@ func createPeerService(
 					},
 				},
 				{
-					Name:     OperationsPortName,
+					Name:     operations.PortName,
 					Protocol: "TCP",
 					Port:     9443,
 					TargetPort: intstr.IntOrString{
@@ -1457,3 +1691,37 @@ func actionLogger(logger logr.Logger) func(format string, v ...interface{}) {
 		logger.Info(fmt.Sprintf(format, v...))
 	}
 }
+
+func getPKCS11Chart(conf *hlfv1alpha1.BCCSPPKCS11) *PKCS11 {
+	if conf == nil {
+		return nil
+	}
+	hash := conf.Hash
+	if hash == "" {
+		hash = "SHA2"
+	}
+	security := conf.Security
+	if security == 0 {
+		security = 256
+	}
+	return &PKCS11{
+		Library:  conf.Library,
+		Label:    conf.Label,
+		Hash:     hash,
+		Security: security,
+		PinSecret: PKCS11PinSecret{
+			Name: conf.Pin.Name,
+			Key:  conf.Pin.Key,
+		},
+	}
+}
+
+// getClientAuthValues returns the client TLS settings of the chart and the root certificates trusted to issue client
+// certificates besides the TLS root of the peer, which the chart always trusts
+func getClientAuthValues(ctx context.Context, hlfClientSet operatorv1.Interface, spec hlfv1alpha1.FabricPeerSpec) (Client, []string, error) {
+	clientRootCerts, err := helpers.GetClientAuthRootCerts(ctx, hlfClientSet, spec.MspID, spec.ClientAuth)
+	if err != nil {
+		return Client{}, nil, errors.Wrap(err, "failed to get the client root certificates")
+	}
+	return Client{Enabled: spec.ClientAuth != nil && spec.ClientAuth.Enabled}, clientRootCerts, nil
+}
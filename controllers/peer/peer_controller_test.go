@@ -0,0 +1,188 @@
+package peer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	hlffake "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// newTestOperationsServer serves the logging spec of the operations endpoint of org1-peer0 behind the service proxy of
+// the API server
+func newTestOperationsServer(active *string, puts *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/default/services/http:org1-peer0:9443/proxy/logspec" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]string{"spec": *active})
+		case http.MethodPut:
+			spec := map[string]string{}
+			_ = json.NewDecoder(r.Body).Decode(&spec)
+			*active = spec["spec"]
+			*puts++
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestApplyLogSpec(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	active := "info"
+	puts := 0
+	server := newTestOperationsServer(&active, &puts)
+	defer server.Close()
+	config := &rest.Config{Host: server.URL}
+	svc := &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-peer0", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "operations", Port: 9443}},
+		},
+	}
+
+	// the spec of the chart is kept when the peer has no logging level
+	g.Expect(applyLogSpec(ctx, config, svc, "")).To(Succeed())
+	g.Expect(applyLogSpec(ctx, config, svc, "info")).To(Succeed())
+	g.Expect(puts).To(Equal(0))
+
+	g.Expect(applyLogSpec(ctx, config, svc, "info:gossip=debug")).To(Succeed())
+	g.Expect(active).To(Equal("info:gossip=debug"))
+	g.Expect(puts).To(Equal(1))
+
+	svc.Name = "org1-peer1"
+	g.Expect(applyLogSpec(ctx, config, svc, "debug")).To(MatchError(ContainSubstring("returned 404")))
+	svc.Spec.Ports = nil
+	g.Expect(applyLogSpec(ctx, config, svc, "debug")).To(MatchError(ContainSubstring("operations port not found")))
+}
+
+func TestSetLogSpec(t *testing.T) {
+	g := NewWithT(t)
+	active := "info"
+	puts := 0
+	server := newTestOperationsServer(&active, &puts)
+	defer server.Close()
+	r := &FabricPeerReconciler{Config: &rest.Config{Host: server.URL}}
+	fabricPeer := &hlfv1alpha1.FabricPeer{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-peer0", Namespace: "default"},
+		Spec:       hlfv1alpha1.FabricPeerSpec{Logging: hlfv1alpha1.FabricPeerLogging{Level: "debug"}},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-peer0", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "operations", Port: 9443}},
+		},
+	}
+	peerStatus := &hlfv1alpha1.FabricPeerStatus{}
+	r.setLogSpec(context.Background(), fabricPeer, svc, peerStatus)
+	g.Expect(peerStatus.Conditions.IsTrueFor(utils.LogSpecAppliedCondition)).To(BeTrue())
+	g.Expect(active).To(Equal("debug"))
+
+	// the operations endpoint can't be reached
+	server.Close()
+	r.setLogSpec(context.Background(), fabricPeer, svc, peerStatus)
+	g.Expect(peerStatus.Conditions.IsFalseFor(utils.LogSpecAppliedCondition)).To(BeTrue())
+	g.Expect(peerStatus.Conditions.GetCondition(utils.LogSpecAppliedCondition).Message).To(ContainSubstring("failed to get the logging spec"))
+}
+
+func TestGetClientAuthValues(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	hlfClientSet := hlffake.NewSimpleClientset(&hlfv1alpha1.FabricCA{
+		ObjectMeta: v1.ObjectMeta{Name: "org2-ca", Namespace: "default"},
+		Status:     hlfv1alpha1.FabricCAStatus{TLSCACert: "org2-tls-root"},
+	})
+
+	// mutual TLS is disabled by default
+	client, clientRootCerts, err := getClientAuthValues(ctx, hlfClientSet, hlfv1alpha1.FabricPeerSpec{MspID: "Org1MSP"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client).To(Equal(Client{Enabled: false}))
+	g.Expect(clientRootCerts).To(BeEmpty())
+
+	// the TLS root of a FabricCA comes first, followed by the root certificates of the spec without duplicates
+	client, clientRootCerts, err = getClientAuthValues(ctx, hlfClientSet, hlfv1alpha1.FabricPeerSpec{
+		MspID: "Org1MSP",
+		ClientAuth: &hlfv1alpha1.FabricTLSClientAuth{
+			Enabled:   true,
+			CAs:       []hlfv1alpha1.FabricTLSClientAuthCA{{Name: "org2-ca", Namespace: "default"}},
+			RootCerts: []string{"org3-tls-root", "org2-tls-root"},
+		},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client).To(Equal(Client{Enabled: true}))
+	g.Expect(clientRootCerts).To(Equal([]string{"org2-tls-root", "org3-tls-root"}))
+
+	// the chart lists the root certificates in the peer values
+	values, err := json.Marshal(FabricPeerChart{
+		Peer:            Peer{TLS: TLSAuth{Server: Server{Enabled: true}, Client: client}},
+		ClientRootCerts: clientRootCerts,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	chartValues := map[string]interface{}{}
+	g.Expect(json.Unmarshal(values, &chartValues)).To(Succeed())
+	g.Expect(chartValues["clientRootCerts"]).To(Equal([]interface{}{"org2-tls-root", "org3-tls-root"}))
+	g.Expect(chartValues["peer"]).To(HaveKeyWithValue("tls", map[string]interface{}{
+		"server": map[string]interface{}{"enabled": true},
+		"client": map[string]interface{}{"enabled": true},
+	}))
+
+	_, _, err = getClientAuthValues(ctx, hlfClientSet, hlfv1alpha1.FabricPeerSpec{
+		MspID: "Org1MSP",
+		ClientAuth: &hlfv1alpha1.FabricTLSClientAuth{
+			Enabled: true,
+			CAs:     []hlfv1alpha1.FabricTLSClientAuthCA{{Name: "org4-ca", Namespace: "default"}},
+		},
+	})
+	g.Expect(err).To(MatchError(ContainSubstring("failed to get the client root certificates")))
+}
+
+func TestGetPKCS11Chart(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(getPKCS11Chart(nil)).To(BeNil())
+
+	pin := corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "hsm-pin"}, Key: "pin"}
+	// the hash and the security level default to the ones of the SW BCCSP
+	pkcs11 := getPKCS11Chart(&hlfv1alpha1.BCCSPPKCS11{
+		Library: "/usr/lib/softhsm/libsofthsm2.so",
+		Label:   "fabric",
+		Pin:     pin,
+	})
+	g.Expect(pkcs11).To(Equal(&PKCS11{
+		Library:   "/usr/lib/softhsm/libsofthsm2.so",
+		Label:     "fabric",
+		Hash:      "SHA2",
+		Security:  256,
+		PinSecret: PKCS11PinSecret{Name: "hsm-pin", Key: "pin"},
+	}))
+
+	pkcs11 = getPKCS11Chart(&hlfv1alpha1.BCCSPPKCS11{
+		Library:  "/usr/lib/softhsm/libsofthsm2.so",
+		Label:    "fabric",
+		Pin:      pin,
+		Hash:     "SHA3",
+		Security: 384,
+	})
+	// the PIN stays in its secret, the chart only references it
+	values, err := json.Marshal(FabricPeerChart{PKCS11: pkcs11})
+	g.Expect(err).NotTo(HaveOccurred())
+	chartValues := map[string]interface{}{}
+	g.Expect(json.Unmarshal(values, &chartValues)).To(Succeed())
+	g.Expect(chartValues["pkcs11"]).To(Equal(map[string]interface{}{
+		"library":   "/usr/lib/softhsm/libsofthsm2.so",
+		"label":     "fabric",
+		"hash":      "SHA3",
+		"security":  float64(384),
+		"pinSecret": map[string]interface{}{"name": "hsm-pin", "key": "pin"},
+	}))
+}
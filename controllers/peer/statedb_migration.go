@@ -0,0 +1,315 @@
+package peer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/couchdb"
+	"github.com/kfsoftware/hlf-operator/pkg/status"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/action"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	peerContainerName  = "peer"
+	ledgerVolumeName   = "data"
+	rebuildDbsJobLabel = "hlf.kungfusoftware.es/rebuild-dbs"
+)
+
+func getRebuildDbsJobName(releaseName string) string {
+	return fmt.Sprintf("%s-rebuild-dbs", releaseName)
+}
+
+// getDesiredStateDb returns the state database of the spec, LevelDB is used when it's not set
+func getDesiredStateDb(spec hlfv1alpha1.FabricPeerSpec) hlfv1alpha1.StateDB {
+	if spec.StateDb == hlfv1alpha1.StateDBCouchDB {
+		return hlfv1alpha1.StateDBCouchDB
+	}
+	return hlfv1alpha1.StateDBLevelDB
+}
+
+// getReleaseStateDb returns the state database the deployed release of the peer was configured with
+func getReleaseStateDb(cfg *action.Configuration, releaseName string) (hlfv1alpha1.StateDB, error) {
+	values, err := action.NewGetValues(cfg).Run(releaseName)
+	if err != nil {
+		return "", err
+	}
+	peerValues, ok := values["peer"].(map[string]interface{})
+	if !ok {
+		return "", errors.Errorf("release %s has no peer values", releaseName)
+	}
+	if peerValues["databaseType"] == "CouchDB" {
+		return hlfv1alpha1.StateDBCouchDB, nil
+	}
+	return hlfv1alpha1.StateDBLevelDB, nil
+}
+
+// reconcileStateDbMigration switches the state database of a peer when the state database of the spec is different
+// from the one of the release. The peer is stopped, `peer node rebuild-dbs` runs as a job against its ledger and the
+// peer is started again with the new state database, which is rebuilt from the blocks of the ledger. Every step is
+// recorded in the status and the function returns true while the migration is in progress, upgrade is called to
+// deploy the peer with the new state database
+func (r *FabricPeerReconciler) reconcileStateDbMigration(
+	ctx context.Context,
+	cfg *action.Configuration,
+	clientSet *kubernetes.Clientset,
+	fabricPeer *hlfv1alpha1.FabricPeer,
+	releaseName string,
+	ns string,
+	upgrade func() error,
+) (bool, error) {
+	migration := fabricPeer.Status.StateDbMigration.DeepCopy()
+	desiredStateDb := getDesiredStateDb(fabricPeer.Spec)
+	inProgress := migration != nil && migration.Step != hlfv1alpha1.StateDbMigrationCompleted
+	if !inProgress {
+		currentStateDb, err := getReleaseStateDb(cfg, releaseName)
+		if err != nil {
+			return false, err
+		}
+		if currentStateDb == desiredStateDb {
+			return false, nil
+		}
+		log.Infof("Migrating the state database of peer %s from %s to %s", fabricPeer.Name, currentStateDb, desiredStateDb)
+		now := v1.Now()
+		migration = &hlfv1alpha1.FabricPeerStateDbMigration{
+			From:      currentStateDb,
+			To:        desiredStateDb,
+			Step:      hlfv1alpha1.StateDbMigrationScalingDown,
+			StartTime: &now,
+		}
+	}
+	// the state database can be changed again while migrating, the rebuild doesn't depend on it
+	migration.To = desiredStateDb
+	dep, err := GetPeerDeployment(cfg, r.Config, releaseName, ns)
+	if err != nil {
+		return false, err
+	}
+	jobName := getRebuildDbsJobName(releaseName)
+	switch migration.Step {
+	case hlfv1alpha1.StateDbMigrationScalingDown:
+		stopped, err := scaleDownPeer(ctx, clientSet, dep, releaseName)
+		if err != nil {
+			return false, err
+		}
+		if stopped {
+			migration.Step = hlfv1alpha1.StateDbMigrationRebuildingDbs
+			migration.Message = ""
+			if !hasLedgerClaim(dep) {
+				// the ledger isn't persisted, the peer pulls the blocks again when it starts
+				migration.Step = hlfv1alpha1.StateDbMigrationStarting
+			}
+		} else {
+			migration.Message = "waiting for the peer to stop"
+		}
+	case hlfv1alpha1.StateDbMigrationRebuildingDbs:
+		job, err := clientSet.BatchV1().Jobs(ns).Get(ctx, jobName, v1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return false, err
+			}
+			err = dropExternalCouchDBs(ctx, clientSet, ns, fabricPeer)
+			if err != nil {
+				return false, err
+			}
+			job, err = newRebuildDbsJob(dep, jobName)
+			if err != nil {
+				return false, err
+			}
+			err = controllerutil.SetControllerReference(fabricPeer, job, r.Scheme)
+			if err != nil {
+				return false, err
+			}
+			_, err = clientSet.BatchV1().Jobs(ns).Create(ctx, job, v1.CreateOptions{})
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to create job %s", jobName)
+			}
+			log.Infof("Job %s created to rebuild the databases of peer %s", jobName, fabricPeer.Name)
+			migration.Message = fmt.Sprintf("job %s is rebuilding the databases", jobName)
+		} else if isJobFinished(job, batchv1.JobComplete) {
+			migration.Step = hlfv1alpha1.StateDbMigrationStarting
+			migration.Message = ""
+		} else if isJobFinished(job, batchv1.JobFailed) {
+			migration.Step = hlfv1alpha1.StateDbMigrationFailed
+			migration.Message = fmt.Sprintf("job %s failed, check its logs and delete it to retry", jobName)
+		}
+	case hlfv1alpha1.StateDbMigrationFailed:
+		_, err := clientSet.BatchV1().Jobs(ns).Get(ctx, jobName, v1.GetOptions{})
+		if err == nil {
+			break
+		}
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		migration.Step = hlfv1alpha1.StateDbMigrationRebuildingDbs
+		migration.Message = ""
+	case hlfv1alpha1.StateDbMigrationStarting:
+		err = upgrade()
+		if err != nil {
+			return false, err
+		}
+		err = scalePeer(ctx, clientSet, dep, int32(fabricPeer.Spec.Replicas))
+		if err != nil {
+			return false, err
+		}
+		propagation := v1.DeletePropagationBackground
+		err = clientSet.BatchV1().Jobs(ns).Delete(ctx, jobName, v1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		now := v1.Now()
+		migration.Step = hlfv1alpha1.StateDbMigrationCompleted
+		migration.Message = ""
+		migration.CompletionTime = &now
+		log.Infof("State database of peer %s migrated to %s", fabricPeer.Name, migration.To)
+	}
+	fPeer := fabricPeer.DeepCopy()
+	fPeer.Status.StateDbMigration = migration
+	inProgress = migration.Step != hlfv1alpha1.StateDbMigrationCompleted
+	if inProgress {
+		fPeer.Status.Status = hlfv1alpha1.MigratingStateDb
+		fPeer.Status.Message = migration.Message
+		fPeer.Status.Conditions.SetCondition(status.Condition{
+			Type:   status.ConditionType(hlfv1alpha1.MigratingStateDb),
+			Status: "True",
+		})
+	}
+	if !reflect.DeepEqual(fPeer.Status, fabricPeer.Status) {
+		err = r.Status().Update(ctx, fPeer)
+		if err != nil {
+			return false, err
+		}
+		fabricPeer.Status = fPeer.Status
+		fabricPeer.ResourceVersion = fPeer.ResourceVersion
+	}
+	return inProgress, nil
+}
+
+// scaleDownPeer scales the deployment of the peer to zero replicas and returns true once all the pods are gone
+func scaleDownPeer(ctx context.Context, clientSet *kubernetes.Clientset, dep *appsv1.Deployment, releaseName string) (bool, error) {
+	err := scalePeer(ctx, clientSet, dep, 0)
+	if err != nil {
+		return false, err
+	}
+	pods, err := clientSet.CoreV1().Pods(dep.Namespace).List(ctx, v1.ListOptions{
+		LabelSelector: fmt.Sprintf("release=%s", releaseName),
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(pods.Items) == 0, nil
+}
+
+func scalePeer(ctx context.Context, clientSet *kubernetes.Clientset, dep *appsv1.Deployment, replicas int32) error {
+	if dep.Spec.Replicas != nil && *dep.Spec.Replicas == replicas {
+		return nil
+	}
+	scale, err := clientSet.AppsV1().Deployments(dep.Namespace).GetScale(ctx, dep.Name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	scale.Spec.Replicas = replicas
+	_, err = clientSet.AppsV1().Deployments(dep.Namespace).UpdateScale(ctx, dep.Name, scale, v1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to scale deployment %s to %d replicas", dep.Name, replicas)
+	}
+	return nil
+}
+
+func hasLedgerClaim(dep *appsv1.Deployment) bool {
+	for _, volume := range dep.Spec.Template.Spec.Volumes {
+		if volume.Name == ledgerVolumeName {
+			return volume.PersistentVolumeClaim != nil
+		}
+	}
+	return false
+}
+
+// dropExternalCouchDBs deletes the databases of the peer in its external CouchDB before the rebuild, so the peer
+// doesn't find the state of a previous migration when it switches to CouchDB, and the databases aren't left behind
+// when it switches to LevelDB
+func dropExternalCouchDBs(ctx context.Context, clientSet kubernetes.Interface, ns string, fabricPeer *hlfv1alpha1.FabricPeer) error {
+	external := fabricPeer.Spec.CouchDB.ExternalCouchDB
+	if external == nil || !external.Enabled {
+		return nil
+	}
+	couchClient, err := couchdb.NewExternalClient(ctx, clientSet, ns, fabricPeer.Spec)
+	if err != nil {
+		return err
+	}
+	defer couchClient.Close()
+	dbs, err := couchClient.DropApplicationDBs(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to drop the databases of peer %s in CouchDB %s", fabricPeer.Name, couchClient.URL())
+	}
+	log.Infof("Dropped %d databases of peer %s in CouchDB %s", len(dbs), fabricPeer.Name, couchClient.URL())
+	return nil
+}
+
+// newRebuildDbsJob returns a job that runs `peer node rebuild-dbs` with the volumes and the configuration of the peer
+// container. It runs with LevelDB so it doesn't need CouchDB to be running, the databases of an external CouchDB are
+// dropped by dropExternalCouchDBs and the volume of the CouchDB container is deleted by the upgrade of the chart
+func newRebuildDbsJob(dep *appsv1.Deployment, jobName string) (*batchv1.Job, error) {
+	podSpec := dep.Spec.Template.Spec.DeepCopy()
+	var peerContainer *corev1.Container
+	for i, container := range podSpec.Containers {
+		if container.Name == peerContainerName {
+			peerContainer = &podSpec.Containers[i]
+		}
+	}
+	if peerContainer == nil {
+		return nil, errors.Errorf("container %s not found in deployment %s", peerContainerName, dep.Name)
+	}
+	container := *peerContainer
+	container.Name = "rebuild-dbs"
+	container.Command = []string{"peer", "node", "rebuild-dbs"}
+	container.Args = nil
+	container.Ports = nil
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  "CORE_LEDGER_STATE_STATEDATABASE",
+		Value: "goleveldb",
+	})
+	podSpec.Containers = []corev1.Container{container}
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	backoffLimit := int32(1)
+	labels := map[string]string{
+		rebuildDbsJobLabel: dep.Name,
+	}
+	return &batchv1.Job{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      jobName,
+			Namespace: dep.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: *podSpec,
+			},
+		},
+	}, nil
+}
+
+func isJobFinished(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package peer
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDropExternalCouchDBs(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`["_users","org1peer0_mychannel_","org1peer0_mychannel_mycc","org2peer0_mychannel_"]`))
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer server.Close()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	g.Expect(err).NotTo(HaveOccurred())
+	portNumber, err := strconv.Atoi(port)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	clientSet := fake.NewSimpleClientset()
+	fabricPeer := &hlfv1alpha1.FabricPeer{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-peer0", Namespace: "default"},
+		Spec: hlfv1alpha1.FabricPeerSpec{
+			StateDb: hlfv1alpha1.StateDBLevelDB,
+		},
+	}
+	// without an external CouchDB there is nothing to drop
+	g.Expect(dropExternalCouchDBs(ctx, clientSet, "default", fabricPeer)).To(Succeed())
+
+	// the peer switches from its external CouchDB to LevelDB
+	fabricPeer.Spec.CouchDB = hlfv1alpha1.FabricPeerCouchDB{
+		User:     "admin",
+		Password: "adminpw",
+		ExternalCouchDB: &hlfv1alpha1.FabricPeerExternalCouchDB{
+			Enabled:        true,
+			Host:           host,
+			Port:           portNumber,
+			DatabasePrefix: "org1peer0_",
+		},
+	}
+	g.Expect(dropExternalCouchDBs(ctx, clientSet, "default", fabricPeer)).To(Succeed())
+	g.Expect(deleted).To(Equal([]string{"/org1peer0_mychannel_", "/org1peer0_mychannel_mycc"}))
+}
+
+func TestNewRebuildDbsJob(t *testing.T) {
+	g := NewWithT(t)
+	dep := &appsv1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-peer0", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{{Name: ledgerVolumeName}},
+					Containers: []corev1.Container{
+						{Name: "couchdb", Image: "couchdb"},
+						{
+							Name:           peerContainerName,
+							Image:          "hyperledger/fabric-peer",
+							Env:            []corev1.EnvVar{{Name: "CORE_LEDGER_STATE_STATEDATABASE", Value: "CouchDB"}},
+							LivenessProbe:  &corev1.Probe{},
+							ReadinessProbe: &corev1.Probe{},
+						},
+					},
+				},
+			},
+		},
+	}
+	job, err := newRebuildDbsJob(dep, getRebuildDbsJobName("org1-peer0"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(job.Name).To(Equal("org1-peer0-rebuild-dbs"))
+	g.Expect(job.Spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+	containers := job.Spec.Template.Spec.Containers
+	g.Expect(containers).To(HaveLen(1))
+	g.Expect(containers[0].Command).To(Equal([]string{"peer", "node", "rebuild-dbs"}))
+	g.Expect(containers[0].LivenessProbe).To(BeNil())
+	// the last value of a duplicated variable wins
+	g.Expect(containers[0].Env[len(containers[0].Env)-1]).To(Equal(corev1.EnvVar{Name: "CORE_LEDGER_STATE_STATEDATABASE", Value: "goleveldb"}))
+	g.Expect(job.Spec.Template.Spec.Volumes).To(Equal(dep.Spec.Template.Spec.Volumes))
+
+	dep.Spec.Template.Spec.Containers = dep.Spec.Template.Spec.Containers[:1]
+	_, err = newRebuildDbsJob(dep, "org1-peer0-rebuild-dbs")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -12,9 +12,33 @@ type CouchDB struct {
 	PullPolicy string          `json:"pullPolicy"`
 }
 type CouchDBExternal struct {
-	Enabled bool   `json:"enabled"`
-	Host    string `json:"host"`
-	Port    int    `json:"port"`
+	Enabled        bool                `json:"enabled"`
+	Host           string              `json:"host"`
+	Port           int                 `json:"port"`
+	DatabasePrefix string              `json:"databasePrefix"`
+	Credentials    *CouchDBCredentials `json:"credentials,omitempty"`
+	TLS            *CouchDBTLS         `json:"tls,omitempty"`
+	Proxy          CouchDBProxy        `json:"proxy"`
+}
+type CouchDBCredentials struct {
+	SecretName  string `json:"secretName"`
+	UserKey     string `json:"userKey"`
+	PasswordKey string `json:"passwordKey"`
+}
+type CouchDBTLS struct {
+	CACert     SecretKey  `json:"caCert"`
+	ClientCert *SecretKey `json:"clientCert,omitempty"`
+	ClientKey  *SecretKey `json:"clientKey,omitempty"`
+}
+type SecretKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+type CouchDBProxy struct {
+	Enabled    bool   `json:"enabled"`
+	Image      string `json:"image"`
+	Tag        string `json:"tag"`
+	PullPolicy string `json:"pullPolicy"`
 }
 type FSServer struct {
 	Image      string `json:"image"`
@@ -23,41 +47,47 @@ type FSServer struct {
 }
 
 type FabricPeerChart struct {
-	FSServer                 FSServer                      `json:"fsServer"`
-	Istio                    Istio                         `json:"istio"`
-	Replicas                 int                           `json:"replicas"`
-	ExternalChaincodeBuilder bool                          `json:"externalChaincodeBuilder"`
-	CouchdbUsername          string                        `json:"couchdbUsername"`
-	CouchdbPassword          string                        `json:"couchdbPassword"`
-	Image                    Image                         `json:"image"`
-	CouchDB                  CouchDB                       `json:"couchdb"`
-	Rbac                     RBAC                          `json:"rbac"`
-	DockerSocketPath         string                        `json:"dockerSocketPath"`
-	Peer                     Peer                          `json:"peer"`
-	Cert                     string                        `json:"cert"`
-	Key                      string                        `json:"key"`
-	Hosts                    []string                      `json:"hosts"`
-	Proxy                    GRPCProxy                     `json:"proxy"`
-	TLS                      TLS                           `json:"tls"`
-	OPSTLS                   TLS                           `json:"opsTLS"`
-	Cacert                   string                        `json:"cacert"`
-	IntCacert                string                        `json:"intCAcert"`
-	Tlsrootcert              string                        `json:"tlsrootcert"`
-	Resources                PeerResources                 `json:"resources,omitempty"`
-	NodeSelector             *corev1.NodeSelector          `json:"nodeSelector,omitempty"`
-	Tolerations              []corev1.Toleration           `json:"tolerations,omitempty"`
-	ImagePullSecrets         []corev1.LocalObjectReference `json:"imagePullSecrets"`
-	Affinity                 *corev1.Affinity              `json:"affinity,omitempty"`
-	ExternalHost             string                        `json:"externalHost"`
-	FullnameOverride         string                        `json:"fullnameOverride"`
-	CouchDBExporter          CouchDBExporter               `json:"couchdbExporter"`
-	HostAliases              []HostAlias                   `json:"hostAliases"`
-	Service                  Service                       `json:"service"`
-	Persistence              PeerPersistence               `json:"persistence"`
-	Logging                  Logging                       `json:"logging"`
-	ExternalBuilders         []ExternalBuilder             `json:"externalBuilders"`
-	ServiceMonitor           ServiceMonitor                `json:"serviceMonitor"`
-	EnvVars                  []corev1.EnvVar               `json:"envVars"`
+	FSServer                  FSServer                          `json:"fsServer"`
+	Istio                     Istio                             `json:"istio"`
+	Replicas                  int                               `json:"replicas"`
+	ExternalChaincodeBuilder  bool                              `json:"externalChaincodeBuilder"`
+	CouchdbUsername           string                            `json:"couchdbUsername"`
+	CouchdbPassword           string                            `json:"couchdbPassword"`
+	Image                     Image                             `json:"image"`
+	CouchDB                   CouchDB                           `json:"couchdb"`
+	Rbac                      RBAC                              `json:"rbac"`
+	DockerSocketPath          string                            `json:"dockerSocketPath"`
+	Peer                      Peer                              `json:"peer"`
+	Cert                      string                            `json:"cert"`
+	Key                       string                            `json:"key"`
+	Hosts                     []string                          `json:"hosts"`
+	Proxy                     GRPCProxy                         `json:"proxy"`
+	TLS                       TLS                               `json:"tls"`
+	OPSTLS                    TLS                               `json:"opsTLS"`
+	Cacert                    string                            `json:"cacert"`
+	IntCacert                 string                            `json:"intCACert"`
+	IntTLSCacert              string                            `json:"intTLSCACert"`
+	Tlsrootcert               string                            `json:"tlsrootcert"`
+	ClientRootCerts           []string                          `json:"clientRootCerts"`
+	Resources                 PeerResources                     `json:"resources,omitempty"`
+	NodeSelector              *corev1.NodeSelector              `json:"nodeSelector,omitempty"`
+	Tolerations               []corev1.Toleration               `json:"tolerations,omitempty"`
+	ImagePullSecrets          []corev1.LocalObjectReference     `json:"imagePullSecrets"`
+	Affinity                  *corev1.Affinity                  `json:"affinity,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	PriorityClassName         string                            `json:"priorityClassName,omitempty"`
+	ExternalHost              string                            `json:"externalHost"`
+	FullnameOverride          string                            `json:"fullnameOverride"`
+	CouchDBExporter           CouchDBExporter                   `json:"couchdbExporter"`
+	HostAliases               []HostAlias                       `json:"hostAliases"`
+	Service                   Service                           `json:"service"`
+	Persistence               PeerPersistence                   `json:"persistence"`
+	Logging                   Logging                           `json:"logging"`
+	ExternalBuilders          []ExternalBuilder                 `json:"externalBuilders"`
+	K8sBuilder                K8sBuilder                        `json:"k8sBuilder"`
+	ServiceMonitor            ServiceMonitor                    `json:"serviceMonitor"`
+	EnvVars                   []corev1.EnvVar                   `json:"envVars"`
+	PKCS11                    *PKCS11                           `json:"pkcs11"`
 }
 type GRPCProxy struct {
 	Enabled          bool                          `json:"enabled"`
@@ -85,6 +115,14 @@ type ExternalBuilder struct {
 	PropagateEnvironment []string `json:"propagateEnvironment"`
 }
 
+type K8sBuilder struct {
+	Enabled    bool   `json:"enabled"`
+	Path       string `json:"path"`
+	Image      string `json:"image"`
+	PullPolicy string `json:"pullPolicy"`
+	Config     string `json:"config"`
+}
+
 type Istio struct {
 	Port           int      `json:"port"`
 	Hosts          []string `json:"hosts"`
@@ -186,3 +224,15 @@ type Logging struct {
 	Msp      string `json:"msp"`
 	Policies string `json:"policies"`
 }
+
+type PKCS11 struct {
+	Library   string          `json:"library"`
+	Label     string          `json:"label"`
+	Hash      string          `json:"hash"`
+	Security  int             `json:"security"`
+	PinSecret PKCS11PinSecret `json:"pinSecret"`
+}
+type PKCS11PinSecret struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
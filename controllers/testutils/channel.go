@@ -3,11 +3,13 @@ package testutils
 import (
 	"context"
 	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"github.com/hyperledger/fabric-config/configtx"
 	"github.com/hyperledger/fabric-config/configtx/membership"
 	"github.com/hyperledger/fabric-config/configtx/orderer"
 	cb "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/kfsoftware/hlf-operator/pkg/bft"
 	"github.com/pkg/errors"
 	"time"
 )
@@ -25,9 +27,12 @@ type PeerOrg struct {
 }
 
 type Consenter struct {
-	host    string
-	port    int
-	tlsCert *x509.Certificate
+	id       uint32
+	host     string
+	port     int
+	mspID    string
+	tlsCert  *x509.Certificate
+	identity *x509.Certificate
 }
 type channelStore struct {
 }
@@ -38,6 +43,7 @@ type CreateChannelOptions struct {
 	name          string
 	batchSize     *orderer.BatchSize
 	batchDuration *time.Duration
+	consensusType string
 }
 
 func (o CreateChannelOptions) validate() error {
@@ -50,6 +56,9 @@ func (o CreateChannelOptions) validate() error {
 	if len(o.ordererOrgs) == 0 {
 		return errors.New("at least 1 orderer org is required")
 	}
+	if o.consensusType != "etcdraft" && o.consensusType != bft.ConsensusType {
+		return errors.Errorf("consensus type %s not supported", o.consensusType)
+	}
 
 	return nil
 }
@@ -87,6 +96,13 @@ func WithPeerOrgs(peerOrgs ...PeerOrg) ChannelOption {
 		o.peerOrgs = peerOrgs
 	}
 }
+
+// WithConsensusType sets the orderer type of the channel, `etcdraft` or `BFT`
+func WithConsensusType(consensusType string) ChannelOption {
+	return func(o *CreateChannelOptions) {
+		o.consensusType = consensusType
+	}
+}
 func CreateConsenter(host string, port int, tlsCert *x509.Certificate) Consenter {
 	return Consenter{
 		host:    host,
@@ -94,6 +110,16 @@ func CreateConsenter(host string, port int, tlsCert *x509.Certificate) Consenter
 		tlsCert: tlsCert,
 	}
 }
+func CreateBFTConsenter(id uint32, host string, port int, mspID string, tlsCert *x509.Certificate, identity *x509.Certificate) Consenter {
+	return Consenter{
+		id:       id,
+		host:     host,
+		port:     port,
+		mspID:    mspID,
+		tlsCert:  tlsCert,
+		identity: identity,
+	}
+}
 func CreateOrdererOrg(mspID string, tlsRootCert *x509.Certificate, signRootCert *x509.Certificate, ordererUrls []string) OrdererOrg {
 	return OrdererOrg{
 		mspID:        mspID,
@@ -115,10 +141,11 @@ func NewChannelStore() *channelStore {
 }
 func (s channelStore) GetApplicationChannelBlock(ctx context.Context, opts ...ChannelOption) (*cb.Block, error) {
 	o := &CreateChannelOptions{
-		consenters:  []Consenter{},
-		ordererOrgs: []OrdererOrg{},
-		peerOrgs:    []PeerOrg{},
-		name:        "",
+		consenters:    []Consenter{},
+		ordererOrgs:   []OrdererOrg{},
+		peerOrgs:      []PeerOrg{},
+		name:          "",
+		consensusType: "etcdraft",
 	}
 	for _, opt := range opts {
 		opt(o)
@@ -249,11 +276,36 @@ func (s channelStore) GetApplicationChannelBlock(ctx context.Context, opts ...Ch
 	if o.batchDuration != nil {
 		channelConfig.Orderer.BatchTimeout = *o.batchDuration
 	}
+	if o.consensusType == bft.ConsensusType {
+		channelConfig.Capabilities = []string{bft.RequiredChannelCapability}
+	}
 	channelID := o.name
 	genesisBlock, err := configtx.NewApplicationChannelGenesisBlock(channelConfig, channelID)
 	if err != nil {
 		return nil, err
 	}
+	if o.consensusType == bft.ConsensusType {
+		var bftConsenters []bft.Consenter
+		for _, consenter := range o.consenters {
+			if consenter.identity == nil {
+				return nil, errors.Errorf("consenter %s:%d has no identity", consenter.host, consenter.port)
+			}
+			tlsCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: consenter.tlsCert.Raw})
+			bftConsenters = append(bftConsenters, bft.Consenter{
+				ID:            consenter.id,
+				Host:          consenter.host,
+				Port:          uint32(consenter.port),
+				MSPID:         consenter.mspID,
+				Identity:      pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: consenter.identity.Raw}),
+				ClientTLSCert: tlsCert,
+				ServerTLSCert: tlsCert,
+			})
+		}
+		err = bft.SetBlockConsensus(genesisBlock, bftConsenters, bft.DefaultOptions())
+		if err != nil {
+			return nil, err
+		}
+	}
 	return genesisBlock, nil
 }
 func defaultACLs() map[string]string {
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ConsenterIDAnnotation holds the BFT consenter ID of a FabricOrdererNode, it's kept in the metadata so that the ID
+// survives the loss of the status
+const ConsenterIDAnnotation = "hlf.kungfusoftware.es/consenter-id"
+
+// ConsenterIDsAnnotation holds the comma separated consenter IDs that have been in the consenter mapping of a
+// FabricMainChannel, they are never assigned to another orderer node
+const ConsenterIDsAnnotation = "hlf.kungfusoftware.es/consenter-ids"
+
+// ParseConsenterIDs parses the value of the ConsenterIDsAnnotation
+func ParseConsenterIDs(value string) ([]uint32, error) {
+	var ids []uint32
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(item, 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid consenter ID %s", item)
+		}
+		ids = append(ids, uint32(id))
+	}
+	return ids, nil
+}
+
+// FormatConsenterIDs returns the value of the ConsenterIDsAnnotation with the sorted IDs without duplicates
+func FormatConsenterIDs(ids []uint32) string {
+	sorted := append([]uint32{}, ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var items []string
+	for idx, id := range sorted {
+		if idx > 0 && sorted[idx-1] == id {
+			continue
+		}
+		items = append(items, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(items, ",")
+}
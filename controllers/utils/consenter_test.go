@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestConsenterIDs(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(FormatConsenterIDs([]uint32{9, 1, 3, 1})).To(Equal("1,3,9"))
+	g.Expect(FormatConsenterIDs(nil)).To(Equal(""))
+
+	ids, err := ParseConsenterIDs("1, 3,9")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids).To(Equal([]uint32{1, 3, 9}))
+	ids, err = ParseConsenterIDs("")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids).To(BeEmpty())
+	_, err = ParseConsenterIDs("1,x")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"github.com/kfsoftware/hlf-operator/pkg/status"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// OperationsReachableCondition is the condition of a peer or an orderer whose operations endpoint was queried by the
+// operator, a node that can't be reached keeps the status of its pods
+const OperationsReachableCondition status.ConditionType = "OperationsReachable"
+
+// GetOperationsReachableCondition returns the condition for the result of the last query to the operations endpoint
+func GetOperationsReachableCondition(err error) status.Condition {
+	if err != nil {
+		return status.Condition{
+			Type:    OperationsReachableCondition,
+			Status:  corev1.ConditionFalse,
+			Reason:  "OperationsUnreachable",
+			Message: err.Error(),
+		}
+	}
+	return status.Condition{
+		Type:   OperationsReachableCondition,
+		Status: corev1.ConditionTrue,
+	}
+}
+
+// LogSpecAppliedCondition is the condition of a peer or an orderer whose logging spec was applied by the operator
+// through its operations endpoint, the spec is also kept in the config of the node for the next restarts
+const LogSpecAppliedCondition status.ConditionType = "LogSpecApplied"
+
+// GetLogSpecAppliedCondition returns the condition for the result of the last attempt to apply the logging spec
+func GetLogSpecAppliedCondition(err error) status.Condition {
+	if err != nil {
+		return status.Condition{
+			Type:    LogSpecAppliedCondition,
+			Status:  corev1.ConditionFalse,
+			Reason:  "LogSpecNotApplied",
+			Message: err.Error(),
+		}
+	}
+	return status.Condition{
+		Type:   LogSpecAppliedCondition,
+		Status: corev1.ConditionTrue,
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"bytes"
+	"reflect"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// ReleaseUpToDate returns true when the last revision of the release is deployed with the same chart and values,
+// upgrading it again would only create a new revision of the release
+func ReleaseUpToDate(cfg *action.Configuration, releaseName string, ch *chart.Chart, values map[string]interface{}) (bool, error) {
+	rel, err := cfg.Releases.Last(releaseName)
+	if err != nil {
+		return false, err
+	}
+	if rel.Info == nil || rel.Info.Status != release.StatusDeployed {
+		return false, nil
+	}
+	if !sameChart(rel.Chart, ch) {
+		return false, nil
+	}
+	if len(rel.Config) == 0 && len(values) == 0 {
+		return true, nil
+	}
+	return reflect.DeepEqual(rel.Config, values), nil
+}
+
+func sameChart(deployed *chart.Chart, ch *chart.Chart) bool {
+	if deployed == nil || deployed.Metadata == nil || ch.Metadata == nil {
+		return false
+	}
+	if deployed.Metadata.Version != ch.Metadata.Version || len(deployed.Templates) != len(ch.Templates) {
+		return false
+	}
+	for i, template := range deployed.Templates {
+		if template.Name != ch.Templates[i].Name || !bytes.Equal(template.Data, ch.Templates[i].Data) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+func newTestChart(version string, deployment string) *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{Name: "hlf-peer", Version: version},
+		Templates: []*chart.File{
+			{Name: "templates/deployment.yaml", Data: []byte(deployment)},
+		},
+	}
+}
+
+func TestReleaseUpToDate(t *testing.T) {
+	g := NewWithT(t)
+	cfg := &action.Configuration{Releases: storage.Init(driver.NewMemory())}
+	values := map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "hyperledger/fabric-peer", "tag": "2.5.0"},
+		"replicas": float64(1),
+	}
+	deployed := newTestChart("1.0.0", "kind: Deployment")
+	g.Expect(cfg.Releases.Create(&release.Release{
+		Name:    "org1-peer0",
+		Version: 1,
+		Chart:   deployed,
+		Config:  values,
+		Info:    &release.Info{Status: release.StatusDeployed},
+	})).To(Succeed())
+
+	upToDate, err := ReleaseUpToDate(cfg, "org1-peer0", newTestChart("1.0.0", "kind: Deployment"), map[string]interface{}{
+		"replicas": float64(1),
+		"image":    map[string]interface{}{"tag": "2.5.0", "repository": "hyperledger/fabric-peer"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(upToDate).To(BeTrue())
+
+	upToDate, err = ReleaseUpToDate(cfg, "org1-peer0", deployed, map[string]interface{}{
+		"replicas": float64(2),
+		"image":    map[string]interface{}{"tag": "2.5.0", "repository": "hyperledger/fabric-peer"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(upToDate).To(BeFalse())
+
+	// the operator ships a new chart
+	upToDate, err = ReleaseUpToDate(cfg, "org1-peer0", newTestChart("1.0.1", "kind: Deployment"), values)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(upToDate).To(BeFalse())
+	upToDate, err = ReleaseUpToDate(cfg, "org1-peer0", newTestChart("1.0.0", "kind: StatefulSet"), values)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(upToDate).To(BeFalse())
+
+	// the last upgrade failed, so it's retried
+	g.Expect(cfg.Releases.Create(&release.Release{
+		Name:    "org1-peer0",
+		Version: 2,
+		Chart:   deployed,
+		Config:  values,
+		Info:    &release.Info{Status: release.StatusFailed},
+	})).To(Succeed())
+	upToDate, err = ReleaseUpToDate(cfg, "org1-peer0", deployed, values)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(upToDate).To(BeFalse())
+
+	_, err = ReleaseUpToDate(cfg, "org2-peer0", deployed, values)
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodDisruptionBudgetLabel is set in the PodDisruptionBudgets created by the operator, its value is the kind of nodes
+// they protect
+const PodDisruptionBudgetLabel = "hlf.kungfusoftware.es/pdb"
+
+// PodDisruptionBudgetGroup is a PodDisruptionBudget for the pods of several helm releases
+type PodDisruptionBudgetGroup struct {
+	Name           string
+	Releases       []string
+	MaxUnavailable int
+	Owners         []v1.OwnerReference
+}
+
+// ReconcilePodDisruptionBudgets creates or updates the PodDisruptionBudgets of the groups in the namespace and deletes
+// the ones of the same kind that aren't in groups anymore
+func ReconcilePodDisruptionBudgets(ctx context.Context, clientSet kubernetes.Interface, ns string, kind string, groups []PodDisruptionBudgetGroup) error {
+	pdbClient := clientSet.PolicyV1().PodDisruptionBudgets(ns)
+	desired := map[string]bool{}
+	for _, group := range groups {
+		desired[group.Name] = true
+		releases := append([]string{}, group.Releases...)
+		sort.Strings(releases)
+		maxUnavailable := intstr.FromInt(group.MaxUnavailable)
+		spec := policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &v1.LabelSelector{
+				MatchExpressions: []v1.LabelSelectorRequirement{
+					{
+						Key:      "release",
+						Operator: v1.LabelSelectorOpIn,
+						Values:   releases,
+					},
+				},
+			},
+		}
+		pdb, err := pdbClient.Get(ctx, group.Name, v1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			_, err = pdbClient.Create(ctx, &policyv1.PodDisruptionBudget{
+				ObjectMeta: v1.ObjectMeta{
+					Name:            group.Name,
+					Namespace:       ns,
+					Labels:          map[string]string{PodDisruptionBudgetLabel: kind},
+					OwnerReferences: group.Owners,
+				},
+				Spec: spec,
+			}, v1.CreateOptions{})
+			if err != nil {
+				return errors.Wrapf(err, "failed to create PodDisruptionBudget %s", group.Name)
+			}
+			continue
+		}
+		if reflect.DeepEqual(pdb.Spec.MaxUnavailable, spec.MaxUnavailable) &&
+			reflect.DeepEqual(pdb.Spec.Selector, spec.Selector) &&
+			reflect.DeepEqual(pdb.OwnerReferences, group.Owners) {
+			continue
+		}
+		pdb.Spec.MaxUnavailable = spec.MaxUnavailable
+		pdb.Spec.MinAvailable = nil
+		pdb.Spec.Selector = spec.Selector
+		pdb.OwnerReferences = group.Owners
+		_, err = pdbClient.Update(ctx, pdb, v1.UpdateOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to update PodDisruptionBudget %s", group.Name)
+		}
+	}
+	pdbs, err := pdbClient.List(ctx, v1.ListOptions{
+		LabelSelector: PodDisruptionBudgetLabel + "=" + kind,
+	})
+	if err != nil {
+		return err
+	}
+	for _, pdb := range pdbs.Items {
+		if desired[pdb.Name] {
+			continue
+		}
+		err = pdbClient.Delete(ctx, pdb.Name, v1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete PodDisruptionBudget %s", pdb.Name)
+		}
+	}
+	return nil
+}
+
+// NewOwnerReference returns an owner reference to obj that isn't a controller reference, so an object can have
+// several owners and is garbage collected when all of them are deleted
+func NewOwnerReference(obj v1.Object, gvk schema.GroupVersionKind) v1.OwnerReference {
+	return v1.OwnerReference{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+	}
+}
+
+// GetMaxUnavailable returns the pods of a PodDisruptionBudget that can be evicted at the same time, 1 when it's not set
+func GetMaxUnavailable(maxUnavailable *int) int {
+	if maxUnavailable == nil {
+		return 1
+	}
+	return *maxUnavailable
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/msp"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite"
+	"github.com/hyperledger/fabric-sdk-go/pkg/core/cryptosuite/bccsp/sw"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
+	mspimpl "github.com/hyperledger/fabric-sdk-go/pkg/msp"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Identity is the certificate and private key of an identity as stored by `kubectl hlf ca enroll` in a secret
+type Identity struct {
+	Cert Pem `json:"cert"`
+	Key  Pem `json:"key"`
+}
+
+type Pem struct {
+	Pem string
+}
+
+// GetIdentity reads the identity stored in the key of the secret
+func GetIdentity(ctx context.Context, clientSet kubernetes.Interface, idConfig hlfv1alpha1.HLFIdentity) (*Identity, error) {
+	secret, err := clientSet.CoreV1().Secrets(idConfig.SecretNamespace).Get(ctx, idConfig.SecretName, v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	secretData, ok := secret.Data[idConfig.SecretKey]
+	if !ok {
+		return nil, fmt.Errorf("secret key %s not found", idConfig.SecretKey)
+	}
+	id := &Identity{}
+	err = yaml.Unmarshal(secretData, id)
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// GetSigningIdentity returns the signing identity of the MSP with the certificate and private key stored in the secret
+func GetSigningIdentity(
+	ctx context.Context,
+	sdk *fabsdk.FabricSDK,
+	clientSet kubernetes.Interface,
+	mspID string,
+	idConfig hlfv1alpha1.HLFIdentity,
+) (msp.SigningIdentity, error) {
+	id, err := GetIdentity(ctx, clientSet, idConfig)
+	if err != nil {
+		return nil, err
+	}
+	sdkConfig, err := sdk.Config()
+	if err != nil {
+		return nil, err
+	}
+	cryptoSuite, err := sw.GetSuiteByConfig(cryptosuite.ConfigFromBackend(sdkConfig))
+	if err != nil {
+		return nil, err
+	}
+	endpointConfig, err := fab.ConfigFromBackend(sdkConfig)
+	if err != nil {
+		return nil, err
+	}
+	identityManager, err := mspimpl.NewIdentityManager(mspID, mspimpl.NewMemoryUserStore(), cryptoSuite, endpointConfig)
+	if err != nil {
+		return nil, err
+	}
+	return identityManager.CreateSigningIdentity(
+		msp.WithPrivateKey([]byte(id.Key.Pem)),
+		msp.WithCert([]byte(id.Cert.Pem)),
+	)
+}
+
+// MainChannelIdentity returns the secret of the identity of a FabricMainChannel
+func MainChannelIdentity(idConfig hlfv1alpha1.FabricMainChannelIdentity) hlfv1alpha1.HLFIdentity {
+	return hlfv1alpha1.HLFIdentity{
+		SecretName:      idConfig.SecretName,
+		SecretNamespace: idConfig.SecretNamespace,
+		SecretKey:       idConfig.SecretKey,
+	}
+}
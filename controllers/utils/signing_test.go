@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetIdentity(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	clientSet := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "wallet", Namespace: "default"},
+		Data: map[string][]byte{
+			"org1-admin.yaml": []byte("cert:\n  pem: |\n    CERT\nkey:\n  pem: |\n    KEY\n"),
+		},
+	})
+
+	id, err := GetIdentity(ctx, clientSet, hlfv1alpha1.HLFIdentity{SecretName: "wallet", SecretNamespace: "default", SecretKey: "org1-admin.yaml"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(id.Cert.Pem).To(Equal("CERT\n"))
+	g.Expect(id.Key.Pem).To(Equal("KEY\n"))
+
+	_, err = GetIdentity(ctx, clientSet, hlfv1alpha1.HLFIdentity{SecretName: "wallet", SecretNamespace: "default", SecretKey: "missing.yaml"})
+	g.Expect(err).To(MatchError(ContainSubstring("secret key missing.yaml not found")))
+	_, err = GetIdentity(ctx, clientSet, hlfv1alpha1.HLFIdentity{SecretName: "missing", SecretNamespace: "default", SecretKey: "org1-admin.yaml"})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestMainChannelIdentity(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(MainChannelIdentity(hlfv1alpha1.FabricMainChannelIdentity{
+		SecretNamespace: "default",
+		SecretName:      "wallet",
+		SecretKey:       "org1-admin.yaml",
+	})).To(Equal(hlfv1alpha1.HLFIdentity{SecretName: "wallet", SecretNamespace: "default", SecretKey: "org1-admin.yaml"}))
+}
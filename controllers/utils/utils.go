@@ -2,7 +2,11 @@ package utils
 
 import (
 	"context"
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
@@ -68,6 +72,49 @@ func ParseECDSAPrivateKey(contents []byte) (*ecdsa.PrivateKey, error) {
 	}
 	return ecdsaKey, nil
 }
+
+// ParsePrivateKey parses a PEM encoded ECDSA or RSA private key in PKCS#8, SEC 1 or PKCS#1 form
+func ParsePrivateKey(contents []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, errors.New("no PEM data found in private key")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		switch key := key.(type) {
+		case *ecdsa.PrivateKey:
+			return key, nil
+		case *rsa.PrivateKey:
+			return key, nil
+		default:
+			return nil, errors.New("private key is not of ECDSA or RSA type")
+		}
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("failed to parse private key")
+	}
+	return key, nil
+}
+
+// ComputeSKI returns the subject key identifier Fabric uses for a public key, the SHA-256 hash of the ECDSA point or of
+// the PKCS#1 encoding of the RSA key
+func ComputeSKI(pub crypto.PublicKey) ([]byte, error) {
+	var raw []byte
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		raw = elliptic.Marshal(pub.Curve, pub.X, pub.Y)
+	case *rsa.PublicKey:
+		raw = x509.MarshalPKCS1PublicKey(pub)
+	default:
+		return nil, errors.New("public key is not of ECDSA or RSA type")
+	}
+	hash := sha256.Sum256(raw)
+	return hash[:], nil
+}
+
 func ParseX509Certificate(contents []byte) (*x509.Certificate, error) {
 	block, _ := pem.Decode(contents)
 	crt, err := x509.ParseCertificate(block.Bytes)
@@ -77,6 +124,24 @@ func ParseX509Certificate(contents []byte) (*x509.Certificate, error) {
 	return crt, nil
 }
 
+// ParseX509Certificates parses all the certificates of a PEM encoded chain
+func ParseX509Certificates(contents []byte) ([]*x509.Certificate, error) {
+	var crts []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, contents = pem.Decode(contents)
+		if block == nil {
+			break
+		}
+		crt, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		crts = append(crts, crt)
+	}
+	return crts, nil
+}
+
 func EncodeX509Certificate(crt *x509.Certificate) []byte {
 	pemPk := pem.EncodeToMemory(&pem.Block{
 		Type:  "CERTIFICATE",
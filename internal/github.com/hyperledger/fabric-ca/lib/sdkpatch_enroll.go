@@ -0,0 +1,71 @@
+/*
+Notice: This file has been added to the vendored Fabric CA client for hlf-operator usage.
+*/
+
+package lib
+
+import (
+	"crypto"
+
+	"github.com/cloudflare/cfssl/csr"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/api"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/util"
+	log "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkpatch/logbridge"
+	"github.com/pkg/errors"
+)
+
+// EnrollWithSigner enrolls an identity with a CSR signed by a private key that is not managed by the BCCSP of the
+// client, so RSA keys and any ECDSA curve supported by the server can be used. It returns the PEM encoded certificate
+// and the information of the CA.
+func (c *Client) EnrollWithSigner(req *api.EnrollmentRequest, signer crypto.Signer) ([]byte, *GetCAInfoResponse, error) {
+	log.Debugf("Enrolling with signer %+v", req)
+
+	err := c.Init()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cr := c.newCertificateRequest(req.CSR)
+	cr.CN = req.Name
+	csrPEM, err := csr.Generate(signer, cr)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "Failure generating CSR")
+	}
+
+	reqNet := &api.EnrollmentRequestNet{
+		CAName:   req.CAName,
+		AttrReqs: req.AttrReqs,
+	}
+	if req.CSR != nil {
+		reqNet.SignRequest.Hosts = req.CSR.Hosts
+	}
+	reqNet.SignRequest.Request = string(csrPEM)
+	reqNet.SignRequest.Profile = req.Profile
+	reqNet.SignRequest.Label = req.Label
+
+	body, err := util.Marshal(reqNet, "SignRequest")
+	if err != nil {
+		return nil, nil, err
+	}
+	post, err := c.newPost("enroll", body)
+	if err != nil {
+		return nil, nil, err
+	}
+	post.SetBasicAuth(req.Name, req.Secret)
+	var result api.EnrollmentResponseNet
+	err = c.SendReq(post, &result)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certByte, err := util.B64Decode(result.Cert)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "Invalid response format from server")
+	}
+	caInfo := &GetCAInfoResponse{}
+	err = c.net2LocalCAInfo(&result.ServerInfo, caInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return certByte, caInfo, nil
+}
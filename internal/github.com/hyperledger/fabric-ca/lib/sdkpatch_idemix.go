@@ -0,0 +1,122 @@
+/*
+Notice: This file has been added to the vendored Fabric CA client for hlf-operator usage.
+*/
+
+package lib
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/idemix"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/api"
+	"github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/util"
+	log "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkpatch/logbridge"
+	"github.com/pkg/errors"
+)
+
+// idemixEnrollmentRequestNet is the body of the /idemix/credential request, the first request has no credential
+// request and returns the nonce of the issuer
+type idemixEnrollmentRequestNet struct {
+	CredRequest *idemix.CredRequest `json:"request"`
+	CAName      string              `json:"caname"`
+}
+
+// IdemixEnrollmentResponse is the Idemix credential issued by the CA with the secret key of the user
+type IdemixEnrollmentResponse struct {
+	// Proto bytes of the idemix.Credential
+	Credential []byte
+	// Secret key of the user
+	Sk []byte
+	// Proto bytes of the idemix.CredentialRevocationInformation
+	CRI          []byte
+	OU           string
+	Role         int
+	EnrollmentID string
+	CAInfo       GetCAInfoResponse
+}
+
+// EnrollIdemix requests an Idemix credential to the CA authenticating with the name and secret of the request, the
+// issuer public key is retrieved from the CA to build the credential request
+func (c *Client) EnrollIdemix(req *api.EnrollmentRequest) (*IdemixEnrollmentResponse, error) {
+	log.Debugf("Enrolling Idemix credential %+v", req)
+
+	err := c.Init()
+	if err != nil {
+		return nil, err
+	}
+	caInfo, err := c.GetCAInfo(&api.GetCAInfoRequest{CAName: req.CAName})
+	if err != nil {
+		return nil, err
+	}
+	if len(caInfo.IssuerPublicKey) == 0 {
+		return nil, errors.Errorf("CA %s is not an Idemix issuer", req.CAName)
+	}
+	ipk := &idemix.IssuerPublicKey{}
+	err = proto.Unmarshal(caInfo.IssuerPublicKey, ipk)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal the issuer public key")
+	}
+
+	reqNet := &idemixEnrollmentRequestNet{CAName: req.CAName}
+	var nonceResult api.IdemixEnrollmentResponseNet
+	err = c.sendIdemixRequest(req, reqNet, &nonceResult)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := util.B64Decode(nonceResult.Nonce)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Invalid nonce in the response from server")
+	}
+
+	rng, err := idemix.GetRand()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get a random number generator")
+	}
+	sk := idemix.RandModOrder(rng)
+	reqNet.CredRequest = idemix.NewCredRequest(sk, nonce, ipk, rng)
+	var result api.IdemixEnrollmentResponseNet
+	err = c.sendIdemixRequest(req, reqNet, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := util.B64Decode(result.Credential)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Invalid credential in the response from server")
+	}
+	cri, err := util.B64Decode(result.CRI)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Invalid credential revocation information in the response from server")
+	}
+	resp := &IdemixEnrollmentResponse{
+		Credential: credential,
+		Sk:         idemix.BigToBytes(sk),
+		CRI:        cri,
+		CAInfo:     *caInfo,
+	}
+	if ou, ok := result.Attrs["OU"].(string); ok {
+		resp.OU = ou
+	}
+	if role, ok := result.Attrs["Role"].(float64); ok {
+		resp.Role = int(role)
+	}
+	if enrollmentID, ok := result.Attrs["EnrollmentID"].(string); ok {
+		resp.EnrollmentID = enrollmentID
+	}
+	if resp.EnrollmentID == "" {
+		resp.EnrollmentID = req.Name
+	}
+	return resp, nil
+}
+
+func (c *Client) sendIdemixRequest(req *api.EnrollmentRequest, reqNet *idemixEnrollmentRequestNet, result *api.IdemixEnrollmentResponseNet) error {
+	body, err := util.Marshal(reqNet, "CredentialRequest")
+	if err != nil {
+		return err
+	}
+	post, err := c.newPost("idemix/credential", body)
+	if err != nil {
+		return err
+	}
+	post.SetBasicAuth(req.Name, req.Secret)
+	return c.SendReq(post, result)
+}
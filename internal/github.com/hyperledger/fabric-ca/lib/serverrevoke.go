@@ -19,6 +19,11 @@ type revocationResponseNet struct {
 	CRL          string
 }
 
+type genCRLResponseNet struct {
+	// Base64 encoding of PEM-encoded CRL
+	CRL string
+}
+
 // CertificateStatus represents status of an enrollment certificate
 type CertificateStatus string
 
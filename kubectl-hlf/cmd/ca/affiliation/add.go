@@ -0,0 +1,59 @@
+package affiliation
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type addAffiliationCmd struct {
+	caOpts      helpers.CARegistrarOptions
+	affiliation string
+	force       bool
+}
+
+func (c *addAffiliationCmd) validate() error {
+	if err := c.caOpts.Validate(); err != nil {
+		return err
+	}
+	if c.affiliation == "" {
+		return errors.New("--affiliation is required")
+	}
+	return nil
+}
+
+func (c *addAffiliationCmd) run(out io.Writer) error {
+	params, err := c.caOpts.GetFabricCAParams()
+	if err != nil {
+		return err
+	}
+	affiliationResponse, err := certs.AddAffiliation(params, c.affiliation, c.force)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Affiliation %s added\n", affiliationResponse.Name)
+	return nil
+}
+
+func newAddAffiliationCMD(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := &addAffiliationCmd{}
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add an affiliation to a Fabric CA",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(out)
+		},
+	}
+	f := cmd.Flags()
+	c.caOpts.AddFlags(f)
+	f.StringVarP(&c.affiliation, "affiliation", "", "", "Affiliation to add, e.g org1.department1")
+	f.BoolVarP(&c.force, "force", "", false, "Create the parent affiliations that don't exist")
+	return cmd
+}
@@ -0,0 +1,20 @@
+package affiliation
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func NewAffiliationCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
+	affiliationCmd := &cobra.Command{
+		Use:   "affiliation",
+		Short: "Manage the affiliations of a Fabric CA",
+	}
+	affiliationCmd.AddCommand(
+		newListAffiliationCMD(stdOut, stdErr),
+		newAddAffiliationCMD(stdOut, stdErr),
+		newRemoveAffiliationCMD(stdOut, stdErr),
+	)
+	return affiliationCmd
+}
@@ -0,0 +1,64 @@
+package affiliation
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	caapi "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/api"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/spf13/cobra"
+)
+
+type listAffiliationCmd struct {
+	caOpts helpers.CARegistrarOptions
+	output string
+}
+
+func (c *listAffiliationCmd) validate() error {
+	if err := c.caOpts.Validate(); err != nil {
+		return err
+	}
+	return helpers.ValidateOutputFormat(c.output)
+}
+
+// affiliationRows flattens the affiliation tree, one row per affiliation
+func affiliationRows(affiliations []caapi.AffiliationInfo) [][]string {
+	var rows [][]string
+	for _, affiliation := range affiliations {
+		rows = append(rows, []string{affiliation.Name, strconv.Itoa(len(affiliation.Identities))})
+		rows = append(rows, affiliationRows(affiliation.Affiliations)...)
+	}
+	return rows
+}
+
+func (c *listAffiliationCmd) run(out io.Writer) error {
+	params, err := c.caOpts.GetFabricCAParams()
+	if err != nil {
+		return err
+	}
+	affiliationResponse, err := certs.ListAffiliations(params)
+	if err != nil {
+		return err
+	}
+	rows := affiliationRows(affiliationResponse.Affiliations)
+	return helpers.PrintOutput(out, c.output, affiliationResponse.AffiliationInfo, []string{"Name", "Identities"}, rows)
+}
+
+func newListAffiliationCMD(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := &listAffiliationCmd{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the affiliations of a Fabric CA",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(out)
+		},
+	}
+	f := cmd.Flags()
+	c.caOpts.AddFlags(f)
+	f.StringVarP(&c.output, "output", "o", helpers.OutputTable, "Output format, one of table, json or yaml")
+	return cmd
+}
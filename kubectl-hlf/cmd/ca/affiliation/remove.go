@@ -0,0 +1,59 @@
+package affiliation
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type removeAffiliationCmd struct {
+	caOpts      helpers.CARegistrarOptions
+	affiliation string
+	force       bool
+}
+
+func (c *removeAffiliationCmd) validate() error {
+	if err := c.caOpts.Validate(); err != nil {
+		return err
+	}
+	if c.affiliation == "" {
+		return errors.New("--affiliation is required")
+	}
+	return nil
+}
+
+func (c *removeAffiliationCmd) run(out io.Writer) error {
+	params, err := c.caOpts.GetFabricCAParams()
+	if err != nil {
+		return err
+	}
+	affiliationResponse, err := certs.RemoveAffiliation(params, c.affiliation, c.force)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Affiliation %s removed\n", affiliationResponse.Name)
+	return nil
+}
+
+func newRemoveAffiliationCMD(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := &removeAffiliationCmd{}
+	cmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Remove an affiliation of a Fabric CA, the CA must allow removing affiliations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(out)
+		},
+	}
+	f := cmd.Flags()
+	c.caOpts.AddFlags(f)
+	f.StringVarP(&c.affiliation, "affiliation", "", "", "Affiliation to remove, e.g org1.department1")
+	f.BoolVarP(&c.force, "force", "", false, "Remove the sub affiliations and the identities of the affiliation")
+	return cmd
+}
@@ -0,0 +1,456 @@
+package ca
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	backupVersion = 1
+	// backupMagic is the header of the encrypted archive, followed by the salt, the nonce and the ciphertext
+	backupMagic   = "HLFCABK1"
+	backupSaltLen = 16
+	backupKeyLen  = 32
+
+	caHome        = "/var/hyperledger/fabric-ca"
+	caContainer   = "ca"
+	caPodInterval = 2 * time.Second
+
+	defaultPostgresClientImage = "postgres:15"
+	defaultMySQLClientImage    = "mysql:8.0"
+	defaultSQLiteClientImage   = "keinos/sqlite3:3.42.0"
+)
+
+// sqliteBackupScript copies the home of the CA and replaces its SQLite databases with copies made by the online backup
+// of sqlite3, the copies are consistent while the CA keeps writing to the databases
+const sqliteBackupScript = `set -e
+mkdir -p /tmp/home
+cp -a ` + caHome + `/. /tmp/home/
+cd ` + caHome + `
+find . -name '*.db' | while read -r db; do
+  rm -f "/tmp/home/$db" "/tmp/home/$db-journal" "/tmp/home/$db-wal" "/tmp/home/$db-shm"
+  sqlite3 "$db" ".backup '/tmp/home/$db'"
+done
+tar -czf - -C /tmp/home .`
+
+// caBackup is the content of the archive created by `ca backup`
+type caBackup struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	// Spec of the FabricCA, without the keys of the CA
+	Spec v1alpha1.FabricCASpec `json:"spec"`
+	// Root certificate and key of the CA that signs the enrollment certificates
+	SignCA v1alpha1.FabricCACrypto `json:"signCA"`
+	// Root certificate and key of the CA that signs the TLS certificates
+	TLSCA v1alpha1.FabricCACrypto `json:"tlsCA"`
+	// Gzipped tar of the home of the CA server, with the SQLite database and the Idemix issuer keys
+	Home []byte `json:"home"`
+	// SQL dump of the postgres or mysql database
+	Database []byte `json:"database,omitempty"`
+	// Secrets the spec refers to, restored when they don't exist
+	Secrets []backupSecret `json:"secrets,omitempty"`
+}
+
+type backupSecret struct {
+	Name string            `json:"name"`
+	Type corev1.SecretType `json:"type"`
+	Data map[string][]byte `json:"data"`
+}
+
+// getDependentSecretNames returns the secrets the spec of the CA refers to: the credentials and TLS certificates of the
+// database, the bind passwords and TLS certificates of the LDAP servers and the image pull secrets
+func getDependentSecretNames(spec v1alpha1.FabricCASpec) []string {
+	var names []string
+	add := func(name string) {
+		if name != "" && !utils.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	addSelector := func(selector *corev1.SecretKeySelector) {
+		if selector != nil {
+			add(selector.Name)
+		}
+	}
+	if spec.Database.Credentials != nil {
+		add(spec.Database.Credentials.SecretName)
+	}
+	if tls := spec.Database.TLS; tls != nil {
+		addSelector(&tls.CACert)
+		addSelector(tls.ClientCert)
+		addSelector(tls.ClientKey)
+	}
+	for _, conf := range []v1alpha1.FabricCAItemConf{spec.CA, spec.TLSCA} {
+		if conf.LDAP == nil {
+			continue
+		}
+		addSelector(conf.LDAP.BindPassword)
+		if tls := conf.LDAP.TLS; tls != nil {
+			addSelector(&tls.CACert)
+			addSelector(tls.ClientCert)
+			addSelector(tls.ClientKey)
+		}
+	}
+	for _, imagePullSecret := range spec.ImagePullSecrets {
+		add(imagePullSecret.Name)
+	}
+	return names
+}
+
+func readBackupPassword(password string, passwordFile string) (string, error) {
+	if passwordFile != "" {
+		contents, err := ioutil.ReadFile(passwordFile)
+		if err != nil {
+			return "", err
+		}
+		password = strings.TrimRight(string(contents), "\r\n")
+	}
+	if password == "" {
+		return "", errors.New("the password of the backup must be set with --password or --password-file")
+	}
+	return password, nil
+}
+
+func deriveBackupKey(password string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(password), salt, 1<<15, 8, 1, backupKeyLen)
+}
+
+// encryptBackup compresses the backup and encrypts it with AES-GCM and a key derived from the password
+func encryptBackup(backup *caBackup, password string) ([]byte, error) {
+	data, err := json.Marshal(backup)
+	if err != nil {
+		return nil, err
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	salt := make([]byte, backupSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := deriveBackupKey(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append([]byte(backupMagic), salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, compressed.Bytes(), []byte(backupMagic)), nil
+}
+
+// decryptBackup decrypts an archive created by encryptBackup
+func decryptBackup(data []byte, password string) (*caBackup, error) {
+	if !bytes.HasPrefix(data, []byte(backupMagic)) {
+		return nil, errors.New("the file isn't a backup of a CA")
+	}
+	data = data[len(backupMagic):]
+	if len(data) < backupSaltLen {
+		return nil, errors.New("the backup is truncated")
+	}
+	salt, data := data[:backupSaltLen], data[backupSaltLen:]
+	key, err := deriveBackupKey(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("the backup is truncated")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	compressed, err := gcm.Open(nil, nonce, ciphertext, []byte(backupMagic))
+	if err != nil {
+		return nil, errors.New("failed to decrypt the backup, the password is wrong or the file is corrupted")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	contents, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	backup := &caBackup{}
+	if err := json.Unmarshal(contents, backup); err != nil {
+		return nil, err
+	}
+	if backup.Version != backupVersion {
+		return nil, errors.Errorf("unsupported backup version %d", backup.Version)
+	}
+	return backup, nil
+}
+
+// waitForCAPod waits until a pod of the CA is ready and returns its name
+func waitForCAPod(ctx context.Context, clientSet kubernetes.Interface, name string, ns string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		pods, err := clientSet.CoreV1().Pods(ns).List(ctx, v1.ListOptions{
+			LabelSelector: fmt.Sprintf("release=%s", name),
+		})
+		if err != nil {
+			return "", err
+		}
+		for _, pod := range pods.Items {
+			if pod.DeletionTimestamp == nil && isPodReady(pod) {
+				return pod.Name, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return "", errors.Errorf("timed out waiting for a ready pod of the CA %s", name)
+		}
+		time.Sleep(caPodInterval)
+	}
+}
+
+// backupSQLiteHome returns a gzipped tar of the home of the CA, it's copied by a pod on the node of the CA that mounts
+// its volume, so the SQLite databases are copied with the online backup of sqlite3
+func backupSQLiteHome(ctx context.Context, clientSet kubernetes.Interface, caPodName string, ns string, image string, timeout time.Duration) ([]byte, error) {
+	caPod, err := clientSet.CoreV1().Pods(ns).Get(ctx, caPodName, v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var claimName string
+	for _, volume := range caPod.Spec.Volumes {
+		if volume.Name == "data" && volume.PersistentVolumeClaim != nil {
+			claimName = volume.PersistentVolumeClaim.ClaimName
+		}
+	}
+	if claimName == "" {
+		return nil, errors.Errorf("pod %s has no persistent volume with the home of the CA", caPodName)
+	}
+	if image == "" {
+		image = defaultSQLiteClientImage
+	}
+	root := int64(0)
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-sqlite-backup-", caPod.Labels["release"]),
+			Namespace:    ns,
+		},
+		Spec: corev1.PodSpec{
+			// the volume of the CA can only be mounted on its node
+			NodeName:      caPod.Spec.NodeName,
+			RestartPolicy: corev1.RestartPolicyNever,
+			Volumes: []corev1.Volume{
+				{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+					},
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:    "sqlite",
+					Image:   image,
+					Command: []string{"sleep", "3600"},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "data", MountPath: "/var/hyperledger"},
+					},
+					// the keys in the home of the CA are only readable by root
+					SecurityContext: &corev1.SecurityContext{RunAsUser: &root},
+				},
+			},
+		},
+	}
+	podName, err := startClientPod(ctx, clientSet, pod, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer clientSet.CoreV1().Pods(ns).Delete(ctx, podName, v1.DeleteOptions{})
+	return helpers.ExecKubectl(ctx, "exec", "-n", ns, podName, "--", "sh", "-c", sqliteBackupScript)
+}
+
+func isPodReady(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// dbClientPod runs the postgres or mysql client next to the database of a CA to dump and restore it
+type dbClientPod struct {
+	clientSet kubernetes.Interface
+	name      string
+	ns        string
+	db        v1alpha1.FabricCADatabase
+}
+
+func newDBClientPod(ctx context.Context, clientSet kubernetes.Interface, caName string, ns string, db v1alpha1.FabricCADatabase, image string, timeout time.Duration) (*dbClientPod, error) {
+	if db.Host == "" {
+		return nil, errors.Errorf("the %s database of the CA has no host, only databases with a host and credentials secret can be backed up", db.Type)
+	}
+	if image == "" {
+		image = defaultPostgresClientImage
+		if db.Type == "mysql" {
+			image = defaultMySQLClientImage
+		}
+	}
+	port := db.Port
+	if port == 0 {
+		port = 5432
+		if db.Type == "mysql" {
+			port = 3306
+		}
+	}
+	dbName := db.DBName
+	if dbName == "" {
+		dbName = "fabric_ca"
+	}
+	env := []corev1.EnvVar{
+		{Name: "DB_HOST", Value: db.Host},
+		{Name: "DB_PORT", Value: strconv.Itoa(port)},
+		{Name: "DB_NAME", Value: dbName},
+	}
+	if db.Credentials != nil {
+		userKey := db.Credentials.UserKey
+		if userKey == "" {
+			userKey = "username"
+		}
+		passwordKey := db.Credentials.PasswordKey
+		if passwordKey == "" {
+			passwordKey = "password"
+		}
+		env = append(env,
+			secretEnvVar("DB_USER", db.Credentials.SecretName, userKey),
+			secretEnvVar("DB_PASSWORD", db.Credentials.SecretName, passwordKey),
+		)
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-db-client-", caName),
+			Namespace:    ns,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:    "db-client",
+					Image:   image,
+					Command: []string{"sleep", "3600"},
+					Env:     env,
+				},
+			},
+		},
+	}
+	podName, err := startClientPod(ctx, clientSet, pod, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &dbClientPod{clientSet: clientSet, name: podName, ns: ns, db: db}, nil
+}
+
+// startClientPod creates the pod and waits until it's running, the pod is deleted if it doesn't start
+func startClientPod(ctx context.Context, clientSet kubernetes.Interface, pod *corev1.Pod, timeout time.Duration) (string, error) {
+	ns := pod.Namespace
+	pod, err := clientSet.CoreV1().Pods(ns).Create(ctx, pod, v1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	name := pod.Name
+	deadline := time.Now().Add(timeout)
+	for {
+		pod, err = clientSet.CoreV1().Pods(ns).Get(ctx, name, v1.GetOptions{})
+		if err != nil {
+			_ = clientSet.CoreV1().Pods(ns).Delete(ctx, name, v1.DeleteOptions{})
+			return "", err
+		}
+		if pod.Status.Phase == corev1.PodRunning {
+			return name, nil
+		}
+		if pod.Status.Phase == corev1.PodFailed || time.Now().After(deadline) {
+			_ = clientSet.CoreV1().Pods(ns).Delete(ctx, name, v1.DeleteOptions{})
+			return "", errors.Errorf("the client pod %s didn't start", name)
+		}
+		time.Sleep(caPodInterval)
+	}
+}
+
+func secretEnvVar(name string, secretName string, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+func (p *dbClientPod) command(postgres string, mysql string) string {
+	if p.db.Type == "mysql" {
+		if p.db.TLS != nil {
+			mysql += " --ssl-mode=REQUIRED"
+		}
+		return fmt.Sprintf(`MYSQL_PWD="$DB_PASSWORD" %s -h "$DB_HOST" -P "$DB_PORT" -u "$DB_USER" "$DB_NAME"`, mysql)
+	}
+	sslMode := "disable"
+	if p.db.TLS != nil {
+		sslMode = "require"
+	}
+	return fmt.Sprintf(`PGPASSWORD="$DB_PASSWORD" PGSSLMODE=%s %s -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" "$DB_NAME"`, sslMode, postgres)
+}
+
+// dump returns a SQL dump of the database of the CA
+func (p *dbClientPod) dump(ctx context.Context) ([]byte, error) {
+	cmd := p.command("pg_dump --clean --if-exists --no-owner", "mysqldump --single-transaction")
+	return helpers.ExecKubectl(ctx, "exec", "-n", p.ns, p.name, "--", "sh", "-c", cmd)
+}
+
+// restore loads a SQL dump into the database of the CA
+func (p *dbClientPod) restore(ctx context.Context, dump io.Reader) error {
+	cmd := p.command("psql -v ON_ERROR_STOP=1 --quiet", "mysql")
+	_, err := helpers.ExecKubectlWithInput(ctx, dump, "exec", "-i", "-n", p.ns, p.name, "--", "sh", "-c", cmd)
+	return err
+}
+
+func (p *dbClientPod) delete(ctx context.Context) {
+	_ = p.clientSet.CoreV1().Pods(p.ns).Delete(ctx, p.name, v1.DeleteOptions{})
+}
@@ -0,0 +1,157 @@
+package ca
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testBackup() *caBackup {
+	return &caBackup{
+		Version:   backupVersion,
+		CreatedAt: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		Name:      "org1-ca",
+		Namespace: "default",
+		Spec: v1alpha1.FabricCASpec{
+			Database: v1alpha1.FabricCADatabase{Type: "sqlite3"},
+		},
+		SignCA:   v1alpha1.FabricCACrypto{Key: "sign-key", Cert: "sign-cert"},
+		TLSCA:    v1alpha1.FabricCACrypto{Key: "tls-key", Cert: "tls-cert", Chain: "tls-chain"},
+		Home:     []byte("home"),
+		Database: []byte("dump"),
+		Secrets: []backupSecret{
+			{Name: "db-credentials", Type: corev1.SecretTypeOpaque, Data: map[string][]byte{"password": []byte("secret")}},
+		},
+	}
+}
+
+func TestEncryptDecryptBackup(t *testing.T) {
+	g := NewWithT(t)
+	backup := testBackup()
+	data, err := encryptBackup(backup, "password")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data[:len(backupMagic)])).To(Equal(backupMagic))
+	g.Expect(string(data)).NotTo(ContainSubstring("sign-key"))
+
+	decrypted, err := decryptBackup(data, "password")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(decrypted).To(Equal(backup))
+
+	// the salt and the nonce are random
+	other, err := encryptBackup(backup, "password")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(other).NotTo(Equal(data))
+}
+
+func TestDecryptBackupErrors(t *testing.T) {
+	g := NewWithT(t)
+	data, err := encryptBackup(testBackup(), "password")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = decryptBackup(data, "wrong")
+	g.Expect(err).To(MatchError(ContainSubstring("password is wrong")))
+
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = decryptBackup(tampered, "password")
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = decryptBackup([]byte("not a backup"), "password")
+	g.Expect(err).To(MatchError(ContainSubstring("isn't a backup")))
+
+	_, err = decryptBackup(data[:len(backupMagic)+backupSaltLen-1], "password")
+	g.Expect(err).To(MatchError(ContainSubstring("truncated")))
+	_, err = decryptBackup(data[:len(backupMagic)+backupSaltLen+1], "password")
+	g.Expect(err).To(MatchError(ContainSubstring("truncated")))
+
+	backup := testBackup()
+	backup.Version = backupVersion + 1
+	data, err = encryptBackup(backup, "password")
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = decryptBackup(data, "password")
+	g.Expect(err).To(MatchError(ContainSubstring("unsupported backup version")))
+}
+
+func TestGetDependentSecretNames(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(getDependentSecretNames(v1alpha1.FabricCASpec{})).To(BeEmpty())
+
+	selector := func(name string) *corev1.SecretKeySelector {
+		return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: "key"}
+	}
+	spec := v1alpha1.FabricCASpec{
+		Database: v1alpha1.FabricCADatabase{
+			Type:        "postgres",
+			Credentials: &v1alpha1.FabricCADatabaseCredentials{SecretName: "db-credentials"},
+			TLS: &v1alpha1.FabricCADatabaseTLS{
+				CACert:     *selector("db-tls"),
+				ClientCert: selector("db-client"),
+				ClientKey:  selector("db-client"),
+			},
+		},
+		CA: v1alpha1.FabricCAItemConf{
+			LDAP: &v1alpha1.FabricCALDAP{
+				BindPassword: selector("ldap-bind"),
+				TLS:          &v1alpha1.FabricCALDAPTLS{CACert: *selector("ldap-tls")},
+			},
+		},
+		TLSCA: v1alpha1.FabricCAItemConf{
+			LDAP: &v1alpha1.FabricCALDAP{BindPassword: selector("ldap-bind")},
+		},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+	}
+	g.Expect(getDependentSecretNames(spec)).To(Equal([]string{"db-credentials", "db-tls", "db-client", "ldap-bind", "ldap-tls", "registry"}))
+}
+
+func TestRestoreCrypto(t *testing.T) {
+	g := NewWithT(t)
+	crypto := v1alpha1.FabricCACrypto{Key: "key", Cert: "cert", Chain: "chain"}
+
+	root := v1alpha1.FabricCAItemConf{Name: "ca"}
+	restoreCrypto(&root, crypto)
+	g.Expect(root.CA).To(Equal(&v1alpha1.FabricCACrypto{
+		Key:  base64.StdEncoding.EncodeToString([]byte("key")),
+		Cert: base64.StdEncoding.EncodeToString([]byte("cert")),
+	}))
+
+	intermediate := v1alpha1.FabricCAItemConf{
+		Name:         "ca",
+		Intermediate: v1alpha1.FabricCAIntermediate{Parent: &v1alpha1.FabricCAIntermediateParent{Name: "root-ca", Namespace: "default"}},
+	}
+	restoreCrypto(&intermediate, crypto)
+	g.Expect(intermediate.CA).To(Equal(&v1alpha1.FabricCACrypto{
+		Key:   base64.StdEncoding.EncodeToString([]byte("key")),
+		Cert:  base64.StdEncoding.EncodeToString([]byte("cert")),
+		Chain: "chain",
+	}))
+}
+
+func TestRestoreSecrets(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	clientSet := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "ldap-bind", Namespace: "restored"},
+		Data:       map[string][]byte{"password": []byte("current")},
+	})
+	err := restoreSecrets(ctx, clientSet, "restored", []backupSecret{
+		{Name: "db-credentials", Type: corev1.SecretTypeOpaque, Data: map[string][]byte{"password": []byte("db")}},
+		{Name: "ldap-bind", Type: corev1.SecretTypeOpaque, Data: map[string][]byte{"password": []byte("backup")}},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	secret, err := clientSet.CoreV1().Secrets("restored").Get(ctx, "db-credentials", v1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Type).To(Equal(corev1.SecretTypeOpaque))
+	g.Expect(secret.Data).To(HaveKeyWithValue("password", []byte("db")))
+
+	secret, err = clientSet.CoreV1().Secrets("restored").Get(ctx, "ldap-bind", v1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(secret.Data).To(HaveKeyWithValue("password", []byte("current")))
+}
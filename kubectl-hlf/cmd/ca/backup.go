@@ -0,0 +1,171 @@
+package ca
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type BackupOptions struct {
+	Name              string
+	NS                string
+	Output            string
+	Password          string
+	PasswordFile      string
+	DBClientImage     string
+	SQLiteClientImage string
+	Timeout           time.Duration
+}
+
+func (o BackupOptions) Validate() error {
+	if o.Name == "" {
+		return errors.New("--name is required")
+	}
+	if o.Output == "" {
+		return errors.New("--output is required")
+	}
+	return nil
+}
+
+type backupCmd struct {
+	out        io.Writer
+	errOut     io.Writer
+	backupOpts BackupOptions
+}
+
+func (c *backupCmd) validate() error {
+	return c.backupOpts.Validate()
+}
+
+// getCACrypto returns the root certificate and key stored by the operator in the secret of one of the CAs
+func getCACrypto(ctx context.Context, clientSet kubernetes.Interface, secretName string, ns string) (v1alpha1.FabricCACrypto, error) {
+	secret, err := clientSet.CoreV1().Secrets(ns).Get(ctx, secretName, v1.GetOptions{})
+	if err != nil {
+		return v1alpha1.FabricCACrypto{}, err
+	}
+	if len(secret.Data["keyfile"]) == 0 {
+		return v1alpha1.FabricCACrypto{}, errors.Errorf("the key in %s is kept in a PKCS#11 token, it can't be backed up", secretName)
+	}
+	return v1alpha1.FabricCACrypto{
+		Key:   string(secret.Data["keyfile"]),
+		Cert:  string(secret.Data["certfile"]),
+		Chain: string(secret.Data["chainfile"]),
+	}, nil
+}
+
+func (c *backupCmd) run() error {
+	password, err := readBackupPassword(c.backupOpts.Password, c.backupOpts.PasswordFile)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	ns := c.backupOpts.NS
+	fabricCA, err := oclient.HlfV1alpha1().FabricCAs(ns).Get(ctx, c.backupOpts.Name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	backup := &caBackup{
+		Version:   backupVersion,
+		CreatedAt: time.Now().UTC(),
+		Name:      fabricCA.Name,
+		Namespace: fabricCA.Namespace,
+		Spec:      fabricCA.Spec,
+	}
+	backup.SignCA, err = getCACrypto(ctx, clientSet, fmt.Sprintf("%s--msp-cryptomaterial", fabricCA.Name), ns)
+	if err != nil {
+		return err
+	}
+	backup.TLSCA, err = getCACrypto(ctx, clientSet, fmt.Sprintf("%s--msp-tls-cryptomaterial", fabricCA.Name), ns)
+	if err != nil {
+		return err
+	}
+	for _, secretName := range getDependentSecretNames(fabricCA.Spec) {
+		secret, err := clientSet.CoreV1().Secrets(ns).Get(ctx, secretName, v1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the secret %s referenced by the CA", secretName)
+		}
+		backup.Secrets = append(backup.Secrets, backupSecret{
+			Name: secret.Name,
+			Type: secret.Type,
+			Data: secret.Data,
+		})
+	}
+	podName, err := waitForCAPod(ctx, clientSet, fabricCA.Name, ns, c.backupOpts.Timeout)
+	if err != nil {
+		return err
+	}
+	sqlDatabase := fabricCA.Spec.Database.Type == "postgres" || fabricCA.Spec.Database.Type == "mysql"
+	if sqlDatabase {
+		log.Infof("Copying the home of the CA from pod %s", podName)
+		backup.Home, err = helpers.ExecKubectl(ctx, "exec", "-n", ns, podName, "-c", caContainer, "--", "tar", "-czf", "-", "-C", caHome, ".")
+	} else {
+		log.Infof("Copying the home and the SQLite database of the CA from the volume of pod %s", podName)
+		backup.Home, err = backupSQLiteHome(ctx, clientSet, podName, ns, c.backupOpts.SQLiteClientImage, c.backupOpts.Timeout)
+	}
+	if err != nil {
+		return err
+	}
+	if sqlDatabase {
+		log.Infof("Dumping the %s database of the CA", fabricCA.Spec.Database.Type)
+		dbPod, err := newDBClientPod(ctx, clientSet, fabricCA.Name, ns, fabricCA.Spec.Database, c.backupOpts.DBClientImage, c.backupOpts.Timeout)
+		if err != nil {
+			return err
+		}
+		defer dbPod.delete(ctx)
+		backup.Database, err = dbPod.dump(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	data, err := encryptBackup(backup, password)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(c.backupOpts.Output, data, 0600); err != nil {
+		return err
+	}
+	log.Infof("Backup of the CA %s written to %s", fabricCA.Name, c.backupOpts.Output)
+	return nil
+}
+
+func newCABackupCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := backupCmd{out: out, errOut: errOut}
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up the keys, database and spec of a Certificate Authority into an encrypted archive",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.backupOpts.Name, "name", "", "Name of the Certificate Authority to back up")
+	f.StringVarP(&c.backupOpts.NS, "namespace", "n", helpers.DefaultNamespace, "Namespace scope for this request")
+	f.StringVarP(&c.backupOpts.Output, "output", "o", "", "File to write the encrypted backup to")
+	f.StringVar(&c.backupOpts.Password, "password", "", "Password to encrypt the backup")
+	f.StringVar(&c.backupOpts.PasswordFile, "password-file", "", "File with the password to encrypt the backup")
+	f.StringVar(&c.backupOpts.DBClientImage, "db-client-image", "", "Image with pg_dump or mysqldump to dump the database, postgres:15 or mysql:8.0 by default")
+	f.StringVar(&c.backupOpts.SQLiteClientImage, "sqlite-client-image", "", "Image with sqlite3 to back up the SQLite database, keinos/sqlite3:3.42.0 by default")
+	f.DurationVar(&c.backupOpts.Timeout, "timeout", 5*time.Minute, "Time to wait for the pods of the CA and the database client")
+	return cmd
+}
@@ -1,6 +1,8 @@
 package ca
 
 import (
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/ca/affiliation"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/ca/identity"
 	"github.com/spf13/cobra"
 	"io"
 )
@@ -13,5 +15,11 @@ func NewCACmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.AddCommand(newCADeleteCmd(out, errOut))
 	cmd.AddCommand(newCARegisterCmd(out, errOut))
 	cmd.AddCommand(newCAEnrollCmd(out, errOut))
+	cmd.AddCommand(newCARevokeCmd(out, errOut))
+	cmd.AddCommand(newCAGenCRLCmd(out, errOut))
+	cmd.AddCommand(newCABackupCmd(out, errOut))
+	cmd.AddCommand(newCARestoreCmd(out, errOut))
+	cmd.AddCommand(identity.NewIdentityCmd(out, errOut))
+	cmd.AddCommand(affiliation.NewAffiliationCmd(out, errOut))
 	return cmd
 }
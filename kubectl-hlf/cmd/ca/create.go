@@ -29,6 +29,11 @@ type Options struct {
 	Hosts            []string
 	DBType           string
 	DBDataSource     string
+	DBHost           string
+	DBPort           int
+	DBName           string
+	DBSecret         string
+	Replicas         int
 	ImagePullSecrets []string
 }
 
@@ -105,6 +110,27 @@ func (c *createCmd) run(_ []string) error {
 	if err != nil {
 		return err
 	}
+	database := v1alpha1.FabricCADatabase{
+		Type:       c.caOpts.DBType,
+		Datasource: c.caOpts.DBDataSource,
+	}
+	if c.caOpts.DBHost != "" {
+		database.Datasource = ""
+		database.Host = c.caOpts.DBHost
+		database.Port = c.caOpts.DBPort
+		database.DBName = c.caOpts.DBName
+	}
+	if c.caOpts.DBSecret != "" {
+		database.Credentials = &v1alpha1.FabricCADatabaseCredentials{
+			SecretName:  c.caOpts.DBSecret,
+			UserKey:     "username",
+			PasswordKey: "password",
+		}
+	}
+	accessMode := corev1.ReadWriteOnce
+	if c.caOpts.Replicas > 1 {
+		accessMode = corev1.ReadWriteMany
+	}
 	fabricCA := &v1alpha1.FabricCA{
 		TypeMeta: v1.TypeMeta{
 			Kind:       "FabricCA",
@@ -115,11 +141,9 @@ func (c *createCmd) run(_ []string) error {
 			Namespace: c.caOpts.NS,
 		},
 		Spec: v1alpha1.FabricCASpec{
-			Database: v1alpha1.FabricCADatabase{
-				Type:       c.caOpts.DBType,
-				Datasource: c.caOpts.DBDataSource,
-			},
-			Hosts: hosts,
+			Database: database,
+			Replicas: c.caOpts.Replicas,
+			Hosts:    hosts,
 			Service: v1alpha1.FabricCASpecService{
 				ServiceType: serviceType,
 			},
@@ -247,7 +271,7 @@ func (c *createCmd) run(_ []string) error {
 			Storage: v1alpha1.Storage{
 				Size:         c.caOpts.Capacity,
 				StorageClass: c.caOpts.StorageClass,
-				AccessMode:   "ReadWriteOnce",
+				AccessMode:   accessMode,
 			},
 			ServiceMonitor: nil,
 			Metrics: v1alpha1.FabricCAMetrics{
@@ -306,6 +330,11 @@ func newCreateCACmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	f.StringVarP(&c.caOpts.EnrollSecret, "enroll-pw", "", "enrollpw", "Enroll secret of the CA")
 	f.StringVarP(&c.caOpts.DBType, "db.type", "", "sqlite3", "Database type of the CA")
 	f.StringVarP(&c.caOpts.DBDataSource, "db.datasource", "", "fabric-ca-server.db", "Database datasource of the CA")
+	f.StringVarP(&c.caOpts.DBHost, "db.host", "", "", "Host of the postgres or mysql database, the datasource is rendered from the host, port, name and secret")
+	f.IntVarP(&c.caOpts.DBPort, "db.port", "", 0, "Port of the postgres or mysql database")
+	f.StringVarP(&c.caOpts.DBName, "db.name", "", "fabric_ca", "Name of the postgres or mysql database")
+	f.StringVarP(&c.caOpts.DBSecret, "db.secret", "", "", "Secret with the username and password keys to connect to the database")
+	f.IntVarP(&c.caOpts.Replicas, "replicas", "", 1, "Number of replicas of the CA, more than one requires a postgres or mysql database")
 
 	f.BoolVarP(&c.caOpts.Output, "output", "o", false, "Output in yaml")
 	f.StringArrayVarP(&c.caOpts.Hosts, "hosts", "", []string{}, "Hosts for Istio")
@@ -3,6 +3,8 @@ package ca
 import (
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
@@ -11,12 +13,18 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/ghodss/yaml"
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
 	"github.com/kfsoftware/hlf-operator/controllers/certs"
 	"github.com/kfsoftware/hlf-operator/controllers/utils"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
 	"github.com/spf13/cobra"
 )
 
+const (
+	enrollmentTypeX509   = "x509"
+	enrollmentTypeIdemix = "idemix"
+)
+
 type EnrollOptions struct {
 	Name       string
 	NS         string
@@ -32,12 +40,57 @@ type EnrollOptions struct {
 	WalletUser string
 	Attributes string
 	CAURL      string
+	KeyAlgo    string
+	KeyCurve   string
+	KeySize    int
+	// Type of the credential to request, x509 or idemix
+	EnrollmentType string
 }
 
 func (o EnrollOptions) Validate() error {
+	switch o.EnrollmentType {
+	case "", enrollmentTypeX509:
+	case enrollmentTypeIdemix:
+		if o.KeyAlgo != "" || o.KeyCurve != "" || o.KeySize != 0 {
+			return errors.New("the key of an Idemix credential can't be set")
+		}
+		if o.WalletPath != "" {
+			return errors.New("Idemix credentials can't be stored in a wallet")
+		}
+	default:
+		return errors.Errorf("invalid enrollment type %s, must be one of x509 or idemix", o.EnrollmentType)
+	}
+	switch hlfv1alpha1.KeyAlgorithm(o.KeyAlgo) {
+	case "", hlfv1alpha1.KeyAlgorithmECDSA:
+		if o.KeySize != 0 {
+			return errors.New("--key-size can only be set for RSA keys")
+		}
+	case hlfv1alpha1.KeyAlgorithmRSA:
+		if o.KeyCurve != "" {
+			return errors.New("--key-curve can only be set for ECDSA keys")
+		}
+	default:
+		return errors.Errorf("invalid key algorithm %s, must be one of ECDSA or RSA", o.KeyAlgo)
+	}
 	return nil
 }
 
+// keyRequest returns the private key to generate for the user, nil for the default P-256 ECDSA key
+func (o EnrollOptions) keyRequest() *hlfv1alpha1.KeyRequest {
+	if o.KeyAlgo == "" && o.KeyCurve == "" && o.KeySize == 0 {
+		return nil
+	}
+	algorithm := hlfv1alpha1.KeyAlgorithm(o.KeyAlgo)
+	if algorithm == "" {
+		algorithm = hlfv1alpha1.KeyAlgorithmECDSA
+	}
+	return &hlfv1alpha1.KeyRequest{
+		Algorithm: algorithm,
+		Curve:     o.KeyCurve,
+		Size:      o.KeySize,
+	}
+}
+
 type enrollCmd struct {
 	out        io.Writer
 	errOut     io.Writer
@@ -103,10 +156,14 @@ func (c *enrollCmd) run(args []string) error {
 		CN:         c.enrollOpts.CN,
 		Profile:    c.enrollOpts.Profile,
 		Attributes: nil,
+		KeyRequest: c.enrollOpts.keyRequest(),
 	}
 	if len(attributes) > 0 {
 		request.Attributes = attributes
 	}
+	if c.enrollOpts.EnrollmentType == enrollmentTypeIdemix {
+		return c.writeIdemixCredential(request)
+	}
 	crt, pk, _, err := certs.EnrollUser(request)
 	if err != nil {
 		return err
@@ -149,6 +206,32 @@ func (c *enrollCmd) run(args []string) error {
 
 	return nil
 }
+
+// writeIdemixCredential enrolls an Idemix credential and writes it in the output folder with the layout of an Idemix
+// MSP, the issuer keys in msp/ and the signer config in user/
+func (c *enrollCmd) writeIdemixCredential(request certs.EnrollUserRequest) error {
+	credential, err := certs.EnrollIdemix(request)
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		filepath.Join("msp", "IssuerPublicKey"):     credential.IssuerPublicKey,
+		filepath.Join("msp", "RevocationPublicKey"): credential.RevocationPublicKey,
+		filepath.Join("user", "SignerConfig"):       credential.SignerConfig,
+	}
+	for name, contents := range files {
+		path := filepath.Join(c.fileOutput, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+			return err
+		}
+	}
+	log.Infof("Idemix credential of %s written to %s", c.enrollOpts.User, c.fileOutput)
+	return nil
+}
+
 func newCAEnrollCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	c := enrollCmd{out: out, errOut: errOut}
 	cmd := &cobra.Command{
@@ -176,8 +259,13 @@ func newCAEnrollCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	f.StringSliceVarP(&c.enrollOpts.Hosts, "hosts", "", []string{}, "Hosts")
 	f.StringVarP(&c.enrollOpts.Attributes, "attributes", "", "", "Attributes of the user")
 	f.StringVarP(&c.enrollOpts.CAURL, "ca-url", "", "", "Fabric CA URL")
+	f.StringVarP(&c.enrollOpts.KeyAlgo, "key-algorithm", "", "", "Algorithm of the private key, ECDSA or RSA, RSA keys can only be used for TLS")
+	f.StringVarP(&c.enrollOpts.KeyCurve, "key-curve", "", "", "Curve of the ECDSA private key, P256 or P384")
+	f.IntVarP(&c.enrollOpts.KeySize, "key-size", "", 0, "Size in bits of the RSA private key, 2048 by default")
+
+	f.StringVarP(&c.enrollOpts.EnrollmentType, "enrollment-type", "", enrollmentTypeX509, "Type of the credential, x509 or idemix")
 
-	f.StringVar(&c.fileOutput, "output", "", "output file")
+	f.StringVar(&c.fileOutput, "output", "", "output file, the output folder for Idemix credentials")
 
 	return cmd
 }
@@ -0,0 +1,34 @@
+package ca
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestEnrollOptionsValidateIdemix(t *testing.T) {
+	tests := []struct {
+		name string
+		opts EnrollOptions
+		err  string
+	}{
+		{name: "x509 by default", opts: EnrollOptions{}},
+		{name: "x509 with a key", opts: EnrollOptions{EnrollmentType: "x509", KeyAlgo: "RSA", KeySize: 2048}},
+		{name: "idemix", opts: EnrollOptions{EnrollmentType: "idemix"}},
+		{name: "idemix with a key algorithm", opts: EnrollOptions{EnrollmentType: "idemix", KeyAlgo: "ECDSA"}, err: "the key of an Idemix credential can't be set"},
+		{name: "idemix with a key curve", opts: EnrollOptions{EnrollmentType: "idemix", KeyCurve: "P-384"}, err: "the key of an Idemix credential can't be set"},
+		{name: "idemix in a wallet", opts: EnrollOptions{EnrollmentType: "idemix", WalletPath: "wallet"}, err: "Idemix credentials can't be stored in a wallet"},
+		{name: "unknown type", opts: EnrollOptions{EnrollmentType: "tls"}, err: "invalid enrollment type tls, must be one of x509 or idemix"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := tt.opts.Validate()
+			if tt.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(MatchError(tt.err))
+		})
+	}
+}
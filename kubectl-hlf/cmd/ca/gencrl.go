@@ -0,0 +1,141 @@
+package ca
+
+import (
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type GenCRLOptions struct {
+	Name          string
+	NS            string
+	CAName        string
+	MspID         string
+	EnrollID      string
+	EnrollSecret  string
+	RevokedAfter  string
+	RevokedBefore string
+	ExpireAfter   string
+	ExpireBefore  string
+	CAURL         string
+}
+
+func (o GenCRLOptions) Validate() error {
+	return nil
+}
+
+type genCRLCmd struct {
+	out        io.Writer
+	errOut     io.Writer
+	crlOpts    GenCRLOptions
+	fileOutput string
+}
+
+func (c *genCRLCmd) validate() error {
+	return c.crlOpts.Validate()
+}
+
+func parseCRLTime(name string, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid %s, it must be in RFC3339 format", name)
+	}
+	return t.UTC(), nil
+}
+
+func (c *genCRLCmd) run(args []string) error {
+	revokedAfter, err := parseCRLTime("revoked-after", c.crlOpts.RevokedAfter)
+	if err != nil {
+		return err
+	}
+	revokedBefore, err := parseCRLTime("revoked-before", c.crlOpts.RevokedBefore)
+	if err != nil {
+		return err
+	}
+	expireAfter, err := parseCRLTime("expire-after", c.crlOpts.ExpireAfter)
+	if err != nil {
+		return err
+	}
+	expireBefore, err := parseCRLTime("expire-before", c.crlOpts.ExpireBefore)
+	if err != nil {
+		return err
+	}
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	certAuth, err := helpers.GetCertAuthByName(clientSet, oclient, c.crlOpts.Name, c.crlOpts.NS)
+	if err != nil {
+		return err
+	}
+	var url string
+	if c.crlOpts.CAURL != "" {
+		url = c.crlOpts.CAURL
+	} else {
+		url, err = helpers.GetURLForCA(certAuth)
+		if err != nil {
+			return err
+		}
+	}
+	crl, err := certs.GenCRL(certs.GenCRLRequest{
+		TLSCert:       certAuth.Status.TlsCert,
+		URL:           url,
+		Name:          c.crlOpts.CAName,
+		MSPID:         c.crlOpts.MspID,
+		EnrollID:      c.crlOpts.EnrollID,
+		EnrollSecret:  c.crlOpts.EnrollSecret,
+		RevokedAfter:  revokedAfter,
+		RevokedBefore: revokedBefore,
+		ExpireAfter:   expireAfter,
+		ExpireBefore:  expireBefore,
+	})
+	if err != nil {
+		return err
+	}
+	if c.fileOutput == "" {
+		_, err = c.out.Write(crl)
+		return err
+	}
+	return ioutil.WriteFile(c.fileOutput, crl, 0644)
+}
+func newCAGenCRLCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := genCRLCmd{out: out, errOut: errOut}
+	cmd := &cobra.Command{
+		Use:   "gencrl",
+		Short: "Generate the certificate revocation list of a Certificate Authority",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(args)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.crlOpts.Name, "name", "", "Name of the Certificate Authority in the cluster, e.g ca.default")
+	f.StringVarP(&c.crlOpts.NS, "namespace", "n", helpers.DefaultNamespace, "Namespace scope for this request")
+	f.StringVarP(&c.crlOpts.CAName, "ca-name", "", "ca", "CA name of the Certificate Authority, ca or tlsca")
+	f.StringVarP(&c.crlOpts.MspID, "mspid", "", "", "MSP ID of the organization")
+	f.StringVarP(&c.crlOpts.EnrollID, "enroll-id", "", "", "Enroll ID of a registrar with the hf.GenCRL attribute")
+	f.StringVarP(&c.crlOpts.EnrollSecret, "enroll-secret", "", "", "Enroll secret of the registrar")
+	f.StringVarP(&c.crlOpts.RevokedAfter, "revoked-after", "", "", "Include only certificates revoked after this time (RFC3339)")
+	f.StringVarP(&c.crlOpts.RevokedBefore, "revoked-before", "", "", "Include only certificates revoked before this time (RFC3339)")
+	f.StringVarP(&c.crlOpts.ExpireAfter, "expire-after", "", "", "Include only certificates expiring after this time (RFC3339)")
+	f.StringVarP(&c.crlOpts.ExpireBefore, "expire-before", "", "", "Include only certificates expiring before this time (RFC3339)")
+	f.StringVarP(&c.crlOpts.CAURL, "ca-url", "", "", "Fabric CA URL")
+
+	f.StringVar(&c.fileOutput, "output", "", "output file, the CRL is printed to stdout if not set")
+
+	return cmd
+}
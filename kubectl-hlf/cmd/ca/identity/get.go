@@ -0,0 +1,86 @@
+package identity
+
+import (
+	"io"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/msp/api"
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	caapi "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/api"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type getIdentityCmd struct {
+	caOpts helpers.CARegistrarOptions
+	user   string
+	output string
+}
+
+func (c *getIdentityCmd) validate() error {
+	if err := c.caOpts.Validate(); err != nil {
+		return err
+	}
+	if c.user == "" {
+		return errors.New("--user is required")
+	}
+	return helpers.ValidateOutputFormat(c.output)
+}
+
+func (c *getIdentityCmd) run(out io.Writer) error {
+	params, err := c.caOpts.GetFabricCAParams()
+	if err != nil {
+		return err
+	}
+	identityResponse, err := certs.GetUser(certs.GetUserRequest{
+		TLSCert:      params.TLSCert,
+		URL:          params.URL,
+		Name:         params.Name,
+		MSPID:        params.MSPID,
+		EnrollID:     params.EnrollID,
+		EnrollSecret: params.EnrollSecret,
+		User:         c.user,
+	})
+	if err != nil {
+		return err
+	}
+	identity := toIdentityInfo(identityResponse)
+	return helpers.PrintOutput(out, c.output, identity, identityHeader, [][]string{identityRow(identity)})
+}
+
+// toIdentityInfo converts the identity returned by the SDK to the identity printed by list
+func toIdentityInfo(identityResponse *api.IdentityResponse) caapi.IdentityInfo {
+	identity := caapi.IdentityInfo{
+		ID:             identityResponse.ID,
+		Type:           identityResponse.Type,
+		Affiliation:    identityResponse.Affiliation,
+		MaxEnrollments: identityResponse.MaxEnrollments,
+	}
+	for _, attr := range identityResponse.Attributes {
+		identity.Attributes = append(identity.Attributes, caapi.Attribute{
+			Name:  attr.Name,
+			Value: attr.Value,
+			ECert: attr.ECert,
+		})
+	}
+	return identity
+}
+
+func newGetIdentityCMD(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := &getIdentityCmd{}
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Get an identity of a Fabric CA",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(out)
+		},
+	}
+	f := cmd.Flags()
+	c.caOpts.AddFlags(f)
+	f.StringVarP(&c.user, "user", "", "", "Enroll ID of the identity")
+	f.StringVarP(&c.output, "output", "o", helpers.OutputTable, "Output format, one of table, json or yaml")
+	return cmd
+}
@@ -0,0 +1,43 @@
+package identity
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	caapi "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/api"
+	"github.com/spf13/cobra"
+)
+
+func NewIdentityCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
+	identityCmd := &cobra.Command{
+		Use:   "identity",
+		Short: "Manage the identities of a Fabric CA",
+	}
+	identityCmd.AddCommand(
+		newListIdentityCMD(stdOut, stdErr),
+		newGetIdentityCMD(stdOut, stdErr),
+		newModifyIdentityCMD(stdOut, stdErr),
+		newRemoveIdentityCMD(stdOut, stdErr),
+	)
+	return identityCmd
+}
+
+var identityHeader = []string{"ID", "Type", "Affiliation", "Max Enrollments", "Attributes"}
+
+func identityRow(identity caapi.IdentityInfo) []string {
+	var attrs []string
+	for _, attr := range identity.Attributes {
+		attrs = append(attrs, fmt.Sprintf("%s=%s", attr.Name, attr.Value))
+	}
+	sort.Strings(attrs)
+	return []string{
+		identity.ID,
+		identity.Type,
+		identity.Affiliation,
+		strconv.Itoa(identity.MaxEnrollments),
+		strings.Join(attrs, ","),
+	}
+}
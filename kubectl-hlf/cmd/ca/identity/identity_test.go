@@ -0,0 +1,127 @@
+package identity
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/msp/api"
+	caapi "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/api"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	. "github.com/onsi/gomega"
+)
+
+var registrarArgs = []string{"--name=org1-ca", "--enroll-id=enroll", "--enroll-secret=enrollpw"}
+
+func TestValidateFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "list without the CA", args: []string{"list", "--enroll-id=enroll", "--enroll-secret=enrollpw"}, err: "--name is required"},
+		{name: "list without the registrar secret", args: []string{"list", "--name=org1-ca", "--enroll-id=enroll"}, err: "--enroll-id and --enroll-secret are required"},
+		{name: "list with an invalid output", args: append([]string{"list", "-o", "xml"}, registrarArgs...), err: "invalid output format xml"},
+		{name: "get without the user", args: append([]string{"get"}, registrarArgs...), err: "--user is required"},
+		{name: "get with an invalid output", args: append([]string{"get", "--user=peer0", "-o", "xml"}, registrarArgs...), err: "invalid output format xml"},
+		{name: "modify without the user", args: append([]string{"modify", "--type=admin"}, registrarArgs...), err: "--user is required"},
+		{name: "remove without the user", args: append([]string{"remove", "--force"}, registrarArgs...), err: "--user is required"},
+		{name: "remove without the registrar", args: []string{"remove", "--name=org1-ca", "--user=peer0"}, err: "--enroll-id and --enroll-secret are required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			out := &bytes.Buffer{}
+			cmd := NewIdentityCmd(out, out)
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(out)
+			cmd.SetErr(out)
+			g.Expect(cmd.Execute()).To(MatchError(ContainSubstring(tt.err)))
+		})
+	}
+}
+
+func TestParseAttributes(t *testing.T) {
+	g := NewWithT(t)
+	attrs, err := parseAttributes("app.role=admin:ecert,department=,hf.Revoker=true")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(attrs).To(Equal([]caapi.Attribute{
+		{Name: "app.role", Value: "admin", ECert: true},
+		{Name: "department", Value: ""},
+		{Name: "hf.Revoker", Value: "true"},
+	}))
+
+	attrs, err = parseAttributes("")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(attrs).To(BeEmpty())
+
+	_, err = parseAttributes("app.role=admin,department")
+	g.Expect(err).To(MatchError("attribute 'department' must be of the form <name>=<value>"))
+}
+
+func TestModifyRequest(t *testing.T) {
+	g := NewWithT(t)
+	modify := &modifyIdentityCmd{
+		user:           "peer0",
+		identityType:   "admin",
+		affiliation:    "org1.department1",
+		attributes:     "app.role=admin:ecert,department=",
+		maxEnrollments: -1,
+		secret:         "newpw",
+	}
+	req, err := modify.modifyRequest()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(req).To(Equal(caapi.ModifyIdentityRequest{
+		ID:          "peer0",
+		Type:        "admin",
+		Affiliation: "org1.department1",
+		Attributes: []caapi.Attribute{
+			{Name: "app.role", Value: "admin", ECert: true},
+			{Name: "department", Value: ""},
+		},
+		MaxEnrollments: -1,
+		Secret:         "newpw",
+	}))
+
+	// only the user is set, so nothing else is modified
+	modify = &modifyIdentityCmd{user: "peer0"}
+	req, err = modify.modifyRequest()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(req).To(Equal(caapi.ModifyIdentityRequest{ID: "peer0"}))
+
+	modify.attributes = "department"
+	_, err = modify.modifyRequest()
+	g.Expect(err).To(MatchError(ContainSubstring("must be of the form <name>=<value>")))
+}
+
+func TestPrintIdentity(t *testing.T) {
+	g := NewWithT(t)
+	identity := toIdentityInfo(&api.IdentityResponse{
+		ID:             "peer0",
+		Type:           "peer",
+		Affiliation:    "org1",
+		MaxEnrollments: -1,
+		Attributes: []api.Attribute{
+			{Name: "hf.Revoker", Value: "true"},
+			{Name: "app.role", Value: "admin", ECert: true},
+		},
+		CAName: "ca",
+	})
+	g.Expect(identity).To(Equal(caapi.IdentityInfo{
+		ID:             "peer0",
+		Type:           "peer",
+		Affiliation:    "org1",
+		MaxEnrollments: -1,
+		Attributes: []caapi.Attribute{
+			{Name: "hf.Revoker", Value: "true"},
+			{Name: "app.role", Value: "admin", ECert: true},
+		},
+	}))
+	g.Expect(identityRow(identity)).To(Equal([]string{"peer0", "peer", "org1", "-1", "app.role=admin,hf.Revoker=true"}))
+
+	out := &bytes.Buffer{}
+	g.Expect(helpers.PrintOutput(out, helpers.OutputJSON, identity, identityHeader, [][]string{identityRow(identity)})).To(Succeed())
+	var printed caapi.IdentityInfo
+	g.Expect(json.Unmarshal(out.Bytes(), &printed)).To(Succeed())
+	g.Expect(printed).To(Equal(identity))
+}
@@ -0,0 +1,55 @@
+package identity
+
+import (
+	"io"
+
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/spf13/cobra"
+)
+
+type listIdentityCmd struct {
+	caOpts helpers.CARegistrarOptions
+	output string
+}
+
+func (c *listIdentityCmd) validate() error {
+	if err := c.caOpts.Validate(); err != nil {
+		return err
+	}
+	return helpers.ValidateOutputFormat(c.output)
+}
+
+func (c *listIdentityCmd) run(out io.Writer) error {
+	params, err := c.caOpts.GetFabricCAParams()
+	if err != nil {
+		return err
+	}
+	identities, err := certs.ListIdentities(params)
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, identity := range identities {
+		rows = append(rows, identityRow(identity))
+	}
+	return helpers.PrintOutput(out, c.output, identities, identityHeader, rows)
+}
+
+func newListIdentityCMD(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := &listIdentityCmd{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the identities of a Fabric CA",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(out)
+		},
+	}
+	f := cmd.Flags()
+	c.caOpts.AddFlags(f)
+	f.StringVarP(&c.output, "output", "o", helpers.OutputTable, "Output format, one of table, json or yaml")
+	return cmd
+}
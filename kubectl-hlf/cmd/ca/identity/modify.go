@@ -0,0 +1,116 @@
+package identity
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	caapi "github.com/kfsoftware/hlf-operator/internal/github.com/hyperledger/fabric-ca/sdkinternal/pkg/api"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type modifyIdentityCmd struct {
+	caOpts         helpers.CARegistrarOptions
+	user           string
+	identityType   string
+	affiliation    string
+	attributes     string
+	maxEnrollments int
+	secret         string
+}
+
+func (c *modifyIdentityCmd) validate() error {
+	if err := c.caOpts.Validate(); err != nil {
+		return err
+	}
+	if c.user == "" {
+		return errors.New("--user is required")
+	}
+	return nil
+}
+
+// parseAttributes parses attributes in the form <name>=<value>[:ecert], an empty value removes the attribute
+func parseAttributes(attributes string) ([]caapi.Attribute, error) {
+	var attrs []caapi.Attribute
+	for _, attr := range strings.Split(attributes, ",") {
+		if len(attr) == 0 {
+			continue
+		}
+		sattr := strings.SplitN(attr, "=", 2)
+		if len(sattr) != 2 {
+			return nil, errors.Errorf("attribute '%s' must be of the form <name>=<value>", attr)
+		}
+		value := sattr[1]
+		ecert := false
+		if strings.HasSuffix(strings.ToLower(value), ":ecert") {
+			value = value[:len(value)-len(":ecert")]
+			ecert = true
+		}
+		attrs = append(attrs, caapi.Attribute{
+			Name:  sattr[0],
+			Value: value,
+			ECert: ecert,
+		})
+	}
+	return attrs, nil
+}
+
+// modifyRequest returns the request with the fields of the identity set in the flags, the fields left empty are not
+// modified
+func (c *modifyIdentityCmd) modifyRequest() (caapi.ModifyIdentityRequest, error) {
+	attrs, err := parseAttributes(c.attributes)
+	if err != nil {
+		return caapi.ModifyIdentityRequest{}, err
+	}
+	return caapi.ModifyIdentityRequest{
+		ID:             c.user,
+		Type:           c.identityType,
+		Affiliation:    c.affiliation,
+		Attributes:     attrs,
+		MaxEnrollments: c.maxEnrollments,
+		Secret:         c.secret,
+	}, nil
+}
+
+func (c *modifyIdentityCmd) run(out io.Writer) error {
+	req, err := c.modifyRequest()
+	if err != nil {
+		return err
+	}
+	params, err := c.caOpts.GetFabricCAParams()
+	if err != nil {
+		return err
+	}
+	identityResponse, err := certs.ModifyIdentity(params, req)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Identity %s modified\n", identityResponse.ID)
+	return nil
+}
+
+func newModifyIdentityCMD(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := &modifyIdentityCmd{}
+	cmd := &cobra.Command{
+		Use:   "modify",
+		Short: "Modify an identity of a Fabric CA",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(out)
+		},
+	}
+	f := cmd.Flags()
+	c.caOpts.AddFlags(f)
+	f.StringVarP(&c.user, "user", "", "", "Enroll ID of the identity to modify")
+	f.StringVarP(&c.identityType, "type", "", "", "New type of the identity (peer/client/orderer/admin)")
+	f.StringVarP(&c.affiliation, "affiliation", "", "", "New affiliation of the identity, . for the root affiliation")
+	f.StringVarP(&c.attributes, "attributes", "", "", "Attributes to add or update, e.g attr1=value1,attr2=value2:ecert, an empty value removes the attribute")
+	f.IntVarP(&c.maxEnrollments, "max-enrollments", "", 0, "New maximum number of enrollments, -1 for unlimited")
+	f.StringVarP(&c.secret, "secret", "", "", "New enroll secret of the identity")
+	return cmd
+}
@@ -0,0 +1,59 @@
+package identity
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type removeIdentityCmd struct {
+	caOpts helpers.CARegistrarOptions
+	user   string
+	force  bool
+}
+
+func (c *removeIdentityCmd) validate() error {
+	if err := c.caOpts.Validate(); err != nil {
+		return err
+	}
+	if c.user == "" {
+		return errors.New("--user is required")
+	}
+	return nil
+}
+
+func (c *removeIdentityCmd) run(out io.Writer) error {
+	params, err := c.caOpts.GetFabricCAParams()
+	if err != nil {
+		return err
+	}
+	identityResponse, err := certs.RemoveIdentity(params, c.user, c.force)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Identity %s removed\n", identityResponse.ID)
+	return nil
+}
+
+func newRemoveIdentityCMD(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := &removeIdentityCmd{}
+	cmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Remove an identity of a Fabric CA, the CA must allow removing identities",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(out)
+		},
+	}
+	f := cmd.Flags()
+	c.caOpts.AddFlags(f)
+	f.StringVarP(&c.user, "user", "", "", "Enroll ID of the identity to remove")
+	f.BoolVarP(&c.force, "force", "", false, "Remove the identity even if it's the registrar")
+	return cmd
+}
@@ -0,0 +1,211 @@
+package ca
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type RestoreOptions struct {
+	File          string
+	Name          string
+	NS            string
+	Password      string
+	PasswordFile  string
+	StorageClass  string
+	Hosts         []string
+	DBClientImage string
+	Timeout       time.Duration
+}
+
+func (o RestoreOptions) Validate() error {
+	if o.File == "" {
+		return errors.New("--file is required")
+	}
+	return nil
+}
+
+type restoreCmd struct {
+	out         io.Writer
+	errOut      io.Writer
+	restoreOpts RestoreOptions
+}
+
+func (c *restoreCmd) validate() error {
+	return c.restoreOpts.Validate()
+}
+
+// restoreCrypto sets the certificate and key of the backup in the CA, so the operator imports them instead of
+// generating new ones or enrolling the intermediate CAs again
+func restoreCrypto(conf *v1alpha1.FabricCAItemConf, crypto v1alpha1.FabricCACrypto) {
+	conf.CA = &v1alpha1.FabricCACrypto{
+		Key:  base64.StdEncoding.EncodeToString([]byte(crypto.Key)),
+		Cert: base64.StdEncoding.EncodeToString([]byte(crypto.Cert)),
+	}
+	if isIntermediateCA(*conf) {
+		conf.CA.Chain = crypto.Chain
+	}
+}
+
+func isIntermediateCA(conf v1alpha1.FabricCAItemConf) bool {
+	return conf.Intermediate.Parent != nil
+}
+
+// restoreSecrets creates the secrets referenced by the spec of the CA, the existing ones are kept
+func restoreSecrets(ctx context.Context, clientSet kubernetes.Interface, ns string, secrets []backupSecret) error {
+	for _, backupSecret := range secrets {
+		secret := &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      backupSecret.Name,
+				Namespace: ns,
+			},
+			Type: backupSecret.Type,
+			Data: backupSecret.Data,
+		}
+		_, err := clientSet.CoreV1().Secrets(ns).Create(ctx, secret, v1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			log.Warnf("Secret %s already exists, keeping it", backupSecret.Name)
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to restore the secret %s", backupSecret.Name)
+		}
+		log.Infof("Secret %s restored", backupSecret.Name)
+	}
+	return nil
+}
+
+func (c *restoreCmd) run() error {
+	password, err := readBackupPassword(c.restoreOpts.Password, c.restoreOpts.PasswordFile)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(c.restoreOpts.File)
+	if err != nil {
+		return err
+	}
+	backup, err := decryptBackup(data, password)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	name := c.restoreOpts.Name
+	if name == "" {
+		name = backup.Name
+	}
+	ns := c.restoreOpts.NS
+	spec := backup.Spec
+	// the roots of the backup are the current ones, a pending rotation would replace them
+	spec.Rotation = nil
+	restoreCrypto(&spec.CA, backup.SignCA)
+	restoreCrypto(&spec.TLSCA, backup.TLSCA)
+	for _, host := range append([]string{name, fmt.Sprintf("%s.%s", name, ns)}, c.restoreOpts.Hosts...) {
+		if !utils.Contains(spec.Hosts, host) {
+			spec.Hosts = append(spec.Hosts, host)
+		}
+	}
+	if c.restoreOpts.StorageClass != "" {
+		spec.Storage.StorageClass = c.restoreOpts.StorageClass
+	}
+	if err := restoreSecrets(ctx, clientSet, ns, backup.Secrets); err != nil {
+		return err
+	}
+	if len(backup.Database) > 0 {
+		// the database is restored before the CA starts, so the CA doesn't create empty tables first
+		log.Infof("Restoring the %s database of the CA", spec.Database.Type)
+		dbPod, err := newDBClientPod(ctx, clientSet, name, ns, spec.Database, c.restoreOpts.DBClientImage, c.restoreOpts.Timeout)
+		if err != nil {
+			return err
+		}
+		err = dbPod.restore(ctx, bytes.NewReader(backup.Database))
+		dbPod.delete(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	fabricCA := &v1alpha1.FabricCA{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "FabricCA",
+			APIVersion: v1alpha1.GroupVersion.String(),
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: spec,
+	}
+	fabricCA, err = oclient.HlfV1alpha1().FabricCAs(ns).Create(ctx, fabricCA, v1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	log.Infof("Certificate authority %s created on namespace %s, waiting for it to be ready", fabricCA.Name, fabricCA.Namespace)
+	podName, err := waitForCAPod(ctx, clientSet, name, ns, c.restoreOpts.Timeout)
+	if err != nil {
+		return err
+	}
+	log.Infof("Restoring the home of the CA in pod %s", podName)
+	_, err = helpers.ExecKubectlWithInput(ctx, bytes.NewReader(backup.Home), "exec", "-i", "-n", ns, podName, "-c", caContainer, "--", "tar", "-xzf", "-", "-C", caHome)
+	if err != nil {
+		return err
+	}
+	// the pods are restarted to load the restored database and Idemix keys
+	err = clientSet.CoreV1().Pods(ns).DeleteCollection(ctx, v1.DeleteOptions{}, v1.ListOptions{
+		LabelSelector: fmt.Sprintf("release=%s", name),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := waitForCAPod(ctx, clientSet, name, ns, c.restoreOpts.Timeout); err != nil {
+		return err
+	}
+	log.Infof("Certificate authority %s restored from %s", name, c.restoreOpts.File)
+	return nil
+}
+
+func newCARestoreCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := restoreCmd{out: out, errOut: errOut}
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore a Certificate Authority from an encrypted archive created with the backup command",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&c.restoreOpts.File, "file", "f", "", "Encrypted backup of the Certificate Authority")
+	f.StringVar(&c.restoreOpts.Name, "name", "", "Name of the restored Certificate Authority, the name in the backup by default")
+	f.StringVarP(&c.restoreOpts.NS, "namespace", "n", helpers.DefaultNamespace, "Namespace to restore the Certificate Authority in")
+	f.StringVar(&c.restoreOpts.Password, "password", "", "Password to decrypt the backup")
+	f.StringVar(&c.restoreOpts.PasswordFile, "password-file", "", "File with the password to decrypt the backup")
+	f.StringVarP(&c.restoreOpts.StorageClass, "storage-class", "s", "", "Storage class of the restored Certificate Authority, the one in the backup by default")
+	f.StringArrayVarP(&c.restoreOpts.Hosts, "hosts", "", []string{}, "Additional hosts of the restored Certificate Authority")
+	f.StringVar(&c.restoreOpts.DBClientImage, "db-client-image", "", "Image with psql or mysql to restore the database, postgres:15 or mysql:8.0 by default")
+	f.DurationVar(&c.restoreOpts.Timeout, "timeout", 5*time.Minute, "Time to wait for the pods of the CA and the database client")
+	return cmd
+}
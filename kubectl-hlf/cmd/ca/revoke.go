@@ -0,0 +1,121 @@
+package ca
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type RevokeOptions struct {
+	Name         string
+	NS           string
+	CAName       string
+	MspID        string
+	EnrollID     string
+	EnrollSecret string
+	User         string
+	Serial       string
+	AKI          string
+	Reason       string
+	CAURL        string
+	CRLOutput    string
+}
+
+func (o RevokeOptions) Validate() error {
+	if o.User == "" && (o.Serial == "" || o.AKI == "") {
+		return errors.New("either --user or both --serial and --aki must be specified")
+	}
+	return nil
+}
+
+type revokeCmd struct {
+	out        io.Writer
+	errOut     io.Writer
+	revokeOpts RevokeOptions
+}
+
+func (c *revokeCmd) validate() error {
+	return c.revokeOpts.Validate()
+}
+func (c *revokeCmd) run(args []string) error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	clientSet, err := helpers.GetKubeClient()
+	if err != nil {
+		return err
+	}
+	certAuth, err := helpers.GetCertAuthByName(clientSet, oclient, c.revokeOpts.Name, c.revokeOpts.NS)
+	if err != nil {
+		return err
+	}
+	var url string
+	if c.revokeOpts.CAURL != "" {
+		url = c.revokeOpts.CAURL
+	} else {
+		url, err = helpers.GetURLForCA(certAuth)
+		if err != nil {
+			return err
+		}
+	}
+	revocationResponse, err := certs.RevokeUser(certs.RevokeUserRequest{
+		TLSCert:      certAuth.Status.TlsCert,
+		URL:          url,
+		Name:         c.revokeOpts.CAName,
+		MSPID:        c.revokeOpts.MspID,
+		EnrollID:     c.revokeOpts.EnrollID,
+		EnrollSecret: c.revokeOpts.EnrollSecret,
+		User:         c.revokeOpts.User,
+		Serial:       c.revokeOpts.Serial,
+		AKI:          c.revokeOpts.AKI,
+		Reason:       c.revokeOpts.Reason,
+		GenCRL:       c.revokeOpts.CRLOutput != "",
+	})
+	if err != nil {
+		return err
+	}
+	for _, revokedCert := range revocationResponse.RevokedCerts {
+		fmt.Fprintf(c.out, "Revoked certificate serial=%s aki=%s\n", revokedCert.Serial, revokedCert.AKI)
+	}
+	if c.revokeOpts.CRLOutput != "" {
+		err = ioutil.WriteFile(c.revokeOpts.CRLOutput, revocationResponse.CRL, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func newCARevokeCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := revokeCmd{out: out, errOut: errOut}
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke the certificates of a user",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run(args)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.revokeOpts.Name, "name", "", "Name of the Certificate Authority in the cluster, e.g ca.default")
+	f.StringVarP(&c.revokeOpts.NS, "namespace", "n", helpers.DefaultNamespace, "Namespace scope for this request")
+	f.StringVarP(&c.revokeOpts.CAName, "ca-name", "", "ca", "CA name of the Certificate Authority, ca or tlsca")
+	f.StringVarP(&c.revokeOpts.MspID, "mspid", "", "", "MSP ID of the organization")
+	f.StringVarP(&c.revokeOpts.EnrollID, "enroll-id", "", "", "Enroll ID of a registrar with the hf.Revoker attribute")
+	f.StringVarP(&c.revokeOpts.EnrollSecret, "enroll-secret", "", "", "Enroll secret of the registrar")
+	f.StringVarP(&c.revokeOpts.User, "user", "", "", "Username whose certificates are revoked")
+	f.StringVarP(&c.revokeOpts.Serial, "serial", "", "", "Serial number of the certificate to revoke")
+	f.StringVarP(&c.revokeOpts.AKI, "aki", "", "", "Authority key identifier of the certificate to revoke")
+	f.StringVarP(&c.revokeOpts.Reason, "reason", "", "", "Reason of the revocation, e.g keycompromise, superseded")
+	f.StringVarP(&c.revokeOpts.CAURL, "ca-url", "", "", "Fabric CA URL")
+	f.StringVar(&c.revokeOpts.CRLOutput, "crl-output", "", "File to write the CRL generated after the revocation")
+
+	return cmd
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/kfsoftware/hlf-operator/controllers/testutils"
 	"github.com/kfsoftware/hlf-operator/controllers/utils"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/pkg/bft"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -24,6 +25,7 @@ type generateChannelCmd struct {
 	maxMessageCount      int
 	absoluteMaxBytes     int
 	preferredMaxBytes    int
+	consensus            string
 }
 
 func (c generateChannelCmd) validate() error {
@@ -39,6 +41,9 @@ func (c generateChannelCmd) validate() error {
 	if c.output == "" {
 		return errors.Errorf("--output is required")
 	}
+	if c.consensus != "etcdraft" && c.consensus != "bft" {
+		return errors.Errorf("--consensus must be etcdraft or bft")
+	}
 	return nil
 }
 
@@ -90,11 +95,31 @@ func (c generateChannelCmd) run() error {
 		if err != nil {
 			return err
 		}
-		createConsenter := testutils.CreateConsenter(
-			consenterHost,
-			consenterPort,
-			tlsCert,
-		)
+		var createConsenter testutils.Consenter
+		if c.consensus == "bft" {
+			identity, err := utils.ParseX509Certificate([]byte(consenter.Status.SignCert))
+			if err != nil {
+				return err
+			}
+			// the ID assigned by the operator doesn't depend on the order of the orderers
+			if consenter.Status.ConsenterID == 0 {
+				return errors.Errorf("orderer node %s has no consenter ID yet", consenter.Name)
+			}
+			createConsenter = testutils.CreateBFTConsenter(
+				consenter.Status.ConsenterID,
+				consenterHost,
+				consenterPort,
+				consenter.Spec.MspID,
+				tlsCert,
+				identity,
+			)
+		} else {
+			createConsenter = testutils.CreateConsenter(
+				consenterHost,
+				consenterPort,
+				tlsCert,
+			)
+		}
 		consenters = append(consenters, createConsenter)
 		_, ok := ordererMap[consenter.Spec.MspID]
 		if !ok {
@@ -178,9 +203,14 @@ func (c generateChannelCmd) run() error {
 	log.Infof("Peer organizations=%v", peerOrgs)
 	log.Infof("Orderer organizations=%v", ordererOrgs)
 
+	consensusType := "etcdraft"
+	if c.consensus == "bft" {
+		consensusType = bft.ConsensusType
+	}
 	block, err := chStore.GetApplicationChannelBlock(
 		ctx,
 		testutils.WithName(c.channelName),
+		testutils.WithConsensusType(consensusType),
 		testutils.WithOrdererOrgs(ordererOrgs...),
 		testutils.WithPeerOrgs(peerOrgs...),
 		testutils.WithConsenters(consenters...),
@@ -223,6 +253,7 @@ func newGenerateChannelCMD(io.Writer, io.Writer) *cobra.Command {
 	persistentFlags.IntVarP(&c.maxMessageCount, "maxMessageCount", "", 100, "Max transactions per block")
 	persistentFlags.IntVarP(&c.absoluteMaxBytes, "absoluteMaxBytes", "", 1024*1024, "Max size per block")
 	persistentFlags.IntVarP(&c.preferredMaxBytes, "preferredMaxBytes", "", 512*1024, "Max size per block")
+	persistentFlags.StringVarP(&c.consensus, "consensus", "", "etcdraft", "Consensus type of the channel, etcdraft or bft")
 	cmd.MarkPersistentFlagRequired("name")
 	cmd.MarkPersistentFlagRequired("organizations")
 	cmd.MarkPersistentFlagRequired("ordererOrganizations")
@@ -9,6 +9,7 @@ import (
 	"github.com/hyperledger/fabric-sdk-go/pkg/fabsdk"
 	"github.com/hyperledger/fabric/common/tools/protolator"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/kfsoftware/hlf-operator/pkg/bft"
 	"github.com/spf13/cobra"
 	"io"
 )
@@ -61,7 +62,7 @@ func (c *inspectChannelCmd) run(out io.Writer) error {
 		return err
 	}
 	var buf bytes.Buffer
-	err = protolator.DeepMarshalJSON(&buf, cmnConfig)
+	err = protolator.DeepMarshalJSON(&buf, bft.WithoutBFTMetadata(cmnConfig))
 	if err != nil {
 		return err
 	}
@@ -3,6 +3,7 @@ package channelcrd
 import (
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/channelcrd/follower"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/channelcrd/mainchannel"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/channelcrd/proposal"
 	"io"
 
 	"github.com/spf13/cobra"
@@ -15,6 +16,7 @@ func NewChannelCRDCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
 	channelCmd.AddCommand(
 		mainchannel.NewChannelMainCmd(stdOut, stdErr),
 		follower.NewChannelFollowerCmd(stdOut, stdErr),
+		proposal.NewChannelProposalCmd(stdOut, stdErr),
 	)
 	return channelCmd
 }
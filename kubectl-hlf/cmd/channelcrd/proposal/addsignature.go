@@ -0,0 +1,96 @@
+package proposal
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mspproto "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"io/ioutil"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type addSignatureCmd struct {
+	out        io.Writer
+	errOut     io.Writer
+	name       string
+	signatures []string
+}
+
+func (c *addSignatureCmd) validate() error {
+	if c.name == "" {
+		return fmt.Errorf("--name is required")
+	}
+	if len(c.signatures) == 0 {
+		return fmt.Errorf("--signatures is required")
+	}
+	return nil
+}
+func (c *addSignatureCmd) run() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	proposal, err := oclient.HlfV1alpha1().FabricChannelUpdateProposals().Get(ctx, c.name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for _, signatureFile := range c.signatures {
+		signatureBytes, err := ioutil.ReadFile(signatureFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read signature file %s", signatureFile)
+		}
+		configSignature := &cb.ConfigSignature{}
+		err = proto.Unmarshal(signatureBytes, configSignature)
+		if err != nil {
+			return errors.Wrapf(err, "failed unmarshalling signature %s", signatureFile)
+		}
+		signatureHeader, err := protoutil.UnmarshalSignatureHeader(configSignature.SignatureHeader)
+		if err != nil {
+			return errors.Wrapf(err, "failed unmarshalling signature header %s", signatureFile)
+		}
+		serializedIdentity := &mspproto.SerializedIdentity{}
+		err = proto.Unmarshal(signatureHeader.Creator, serializedIdentity)
+		if err != nil {
+			return errors.Wrapf(err, "failed unmarshalling signature creator %s", signatureFile)
+		}
+		proposal.Spec.Signatures = append(proposal.Spec.Signatures, v1alpha1.FabricChannelUpdateSignature{
+			MSPID:     serializedIdentity.Mspid,
+			Signature: base64.StdEncoding.EncodeToString(signatureBytes),
+		})
+		log.Infof("Adding signature of %s from %s", serializedIdentity.Mspid, signatureFile)
+	}
+	_, err = oclient.HlfV1alpha1().FabricChannelUpdateProposals().Update(ctx, proposal, v1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	log.Infof("Channel update proposal %s updated", c.name)
+	return nil
+}
+
+func newAddSignatureProposalCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := addSignatureCmd{out: out, errOut: errOut}
+	cmd := &cobra.Command{
+		Use:   "addsignature",
+		Short: "Import signatures produced with `kubectl hlf channel signupdate` into a channel update proposal",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.name, "name", "", "Name of the channel update proposal")
+	f.StringSliceVarP(&c.signatures, "signatures", "s", []string{}, "Raw signature files of the config update")
+	return cmd
+}
@@ -0,0 +1,164 @@
+package proposal
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"io/ioutil"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+)
+
+type CreateOptions struct {
+	Name                string
+	Output              bool
+	ChannelName         string
+	MainChannel         string
+	File                string
+	SubmitterMSPID      string
+	Identities          []string
+	OrdererURLs         []string
+	OrdererCertificates []string
+}
+
+func (o CreateOptions) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("--name is required")
+	}
+	if o.ChannelName == "" {
+		return fmt.Errorf("--channel-name is required")
+	}
+	if o.SubmitterMSPID == "" {
+		return fmt.Errorf("--submitter-mspid is required")
+	}
+	if o.MainChannel == "" && o.File == "" {
+		return fmt.Errorf("either --main-channel or --file is required")
+	}
+	if o.MainChannel == "" && len(o.OrdererURLs) == 0 {
+		return fmt.Errorf("--orderer-urls is required when --main-channel is not set")
+	}
+	return nil
+}
+
+type createCmd struct {
+	out          io.Writer
+	errOut       io.Writer
+	proposalOpts CreateOptions
+}
+
+func (c *createCmd) validate() error {
+	return c.proposalOpts.Validate()
+}
+func (c *createCmd) run() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	var configUpdate string
+	if c.proposalOpts.File != "" {
+		configUpdateBytes, err := ioutil.ReadFile(c.proposalOpts.File)
+		if err != nil {
+			return err
+		}
+		configUpdate = base64.StdEncoding.EncodeToString(configUpdateBytes)
+	}
+	orderers := []v1alpha1.FabricFollowerChannelOrderer{}
+	for idx, orderer := range c.proposalOpts.OrdererURLs {
+		if len(c.proposalOpts.OrdererCertificates)-1 < idx {
+			return fmt.Errorf("orderer certificate not found for orderer %s", orderer)
+		}
+		ordererCrtFile := c.proposalOpts.OrdererCertificates[idx]
+		ordererCertificate, err := ioutil.ReadFile(ordererCrtFile)
+		if err != nil {
+			return fmt.Errorf("error reading orderer certificate file %s: %s", ordererCrtFile, err)
+		}
+		orderers = append(orderers, v1alpha1.FabricFollowerChannelOrderer{
+			URL:         orderer,
+			Certificate: string(ordererCertificate),
+		})
+	}
+	identities := map[string]v1alpha1.FabricMainChannelIdentity{}
+	for _, identity := range c.proposalOpts.Identities {
+		// <mspid>=<secret namespace>/<secret name>/<secret key>
+		chunks := strings.SplitN(identity, "=", 2)
+		if len(chunks) != 2 {
+			return fmt.Errorf("invalid identity format: %s", identity)
+		}
+		secretChunks := strings.Split(chunks[1], "/")
+		if len(secretChunks) != 3 {
+			return fmt.Errorf("invalid identity format: %s", identity)
+		}
+		identities[chunks[0]] = v1alpha1.FabricMainChannelIdentity{
+			SecretNamespace: secretChunks[0],
+			SecretName:      secretChunks[1],
+			SecretKey:       secretChunks[2],
+		}
+	}
+	proposal := &v1alpha1.FabricChannelUpdateProposal{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "FabricChannelUpdateProposal",
+			APIVersion: v1alpha1.GroupVersion.String(),
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name: c.proposalOpts.Name,
+		},
+		Spec: v1alpha1.FabricChannelUpdateProposalSpec{
+			ChannelName:    c.proposalOpts.ChannelName,
+			MainChannel:    c.proposalOpts.MainChannel,
+			Orderers:       orderers,
+			ConfigUpdate:   configUpdate,
+			SubmitterMSPID: c.proposalOpts.SubmitterMSPID,
+			Identities:     identities,
+			Signatures:     []v1alpha1.FabricChannelUpdateSignature{},
+		},
+	}
+	if c.proposalOpts.Output {
+		ot, err := helpers.MarshallWithoutStatus(&proposal)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(ot))
+	} else {
+		ctx := context.Background()
+		_, err = oclient.HlfV1alpha1().FabricChannelUpdateProposals().Create(
+			ctx,
+			proposal,
+			v1.CreateOptions{},
+		)
+		if err != nil {
+			return err
+		}
+		log.Infof("Channel update proposal %s created", proposal.Name)
+	}
+	return nil
+}
+
+func newCreateProposalCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := createCmd{out: out, errOut: errOut}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a channel update proposal",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.proposalOpts.Name, "name", "", "Name of the channel update proposal to create")
+	f.StringVar(&c.proposalOpts.ChannelName, "channel-name", "", "Name of the channel to update")
+	f.StringVar(&c.proposalOpts.MainChannel, "main-channel", "", "Name of the FabricMainChannel to compute the config update from")
+	f.StringVarP(&c.proposalOpts.File, "file", "f", "", "Config update envelope file to collect signatures for")
+	f.StringVar(&c.proposalOpts.SubmitterMSPID, "submitter-mspid", "", "MSP ID of the identity used to submit the config update")
+	f.StringArrayVar(&c.proposalOpts.Identities, "identities", []string{}, "Identities to sign the config update with, e.g. Org1MSP=default/org1-admin/user.yaml")
+	f.StringArrayVar(&c.proposalOpts.OrdererURLs, "orderer-urls", []string{}, "Orderer URLs of the channel, e.g grpcs://<host>:<port>")
+	f.StringArrayVar(&c.proposalOpts.OrdererCertificates, "orderer-certificates", []string{}, "Orderer certificates of the channel")
+	f.BoolVarP(&c.proposalOpts.Output, "output", "o", false, "Output in yaml")
+	return cmd
+}
@@ -0,0 +1,75 @@
+package proposal
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"io"
+	"io/ioutil"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type exportCmd struct {
+	out    io.Writer
+	errOut io.Writer
+	name   string
+	output string
+}
+
+func (c *exportCmd) validate() error {
+	if c.name == "" {
+		return fmt.Errorf("--name is required")
+	}
+	if c.output == "" {
+		return fmt.Errorf("--output is required")
+	}
+	return nil
+}
+func (c *exportCmd) run() error {
+	oclient, err := helpers.GetKubeOperatorClient()
+	if err != nil {
+		return err
+	}
+	proposal, err := oclient.HlfV1alpha1().FabricChannelUpdateProposals().Get(context.Background(), c.name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	configUpdate := proposal.Status.ConfigUpdate
+	if configUpdate == "" {
+		configUpdate = proposal.Spec.ConfigUpdate
+	}
+	if configUpdate == "" {
+		return fmt.Errorf("the config update of the proposal %s has not been computed yet", c.name)
+	}
+	configUpdateBytes, err := base64.StdEncoding.DecodeString(configUpdate)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(c.output, configUpdateBytes, 0644)
+	if err != nil {
+		return err
+	}
+	log.Infof("Config update of proposal %s exported to %s", c.name, c.output)
+	return nil
+}
+
+func newExportProposalCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := exportCmd{out: out, errOut: errOut}
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the config update envelope of a channel update proposal to be signed with `kubectl hlf channel signupdate`",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.name, "name", "", "Name of the channel update proposal")
+	f.StringVarP(&c.output, "output", "o", "", "Output file for the config update envelope")
+	return cmd
+}
@@ -0,0 +1,20 @@
+package proposal
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func NewChannelProposalCmd(stdOut io.Writer, stdErr io.Writer) *cobra.Command {
+	channelCmd := &cobra.Command{
+		Use: "proposal",
+	}
+	channelCmd.AddCommand(
+		newCreateProposalCmd(stdOut, stdErr),
+		newExportProposalCmd(stdOut, stdErr),
+		newAddSignatureProposalCmd(stdOut, stdErr),
+		newDeleteProposalCmd(stdOut, stdErr),
+	)
+	return channelCmd
+}
@@ -0,0 +1,69 @@
+package helpers
+
+import (
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// CARegistrarOptions are the flags shared by the commands that administer a FabricCA of the cluster as a registrar
+type CARegistrarOptions struct {
+	Name         string
+	NS           string
+	CAName       string
+	MspID        string
+	EnrollID     string
+	EnrollSecret string
+	CAURL        string
+}
+
+func (o *CARegistrarOptions) AddFlags(f *pflag.FlagSet) {
+	f.StringVar(&o.Name, "name", "", "Name of the Certificate Authority in the cluster, e.g ca.default")
+	f.StringVarP(&o.NS, "namespace", "n", DefaultNamespace, "Namespace scope for this request")
+	f.StringVarP(&o.CAName, "ca-name", "", "ca", "CA name of the Certificate Authority, ca or tlsca")
+	f.StringVarP(&o.MspID, "mspid", "", "", "MSP ID of the organization")
+	f.StringVarP(&o.EnrollID, "enroll-id", "", "", "Enroll ID of the registrar")
+	f.StringVarP(&o.EnrollSecret, "enroll-secret", "", "", "Enroll secret of the registrar")
+	f.StringVarP(&o.CAURL, "ca-url", "", "", "Fabric CA URL")
+}
+
+func (o CARegistrarOptions) Validate() error {
+	if o.Name == "" {
+		return errors.New("--name is required")
+	}
+	if o.EnrollID == "" || o.EnrollSecret == "" {
+		return errors.New("--enroll-id and --enroll-secret are required")
+	}
+	return nil
+}
+
+// GetFabricCAParams returns the parameters to connect to the FabricCA as the registrar
+func (o CARegistrarOptions) GetFabricCAParams() (certs.FabricCAParams, error) {
+	oclient, err := GetKubeOperatorClient()
+	if err != nil {
+		return certs.FabricCAParams{}, err
+	}
+	clientSet, err := GetKubeClient()
+	if err != nil {
+		return certs.FabricCAParams{}, err
+	}
+	certAuth, err := GetCertAuthByName(clientSet, oclient, o.Name, o.NS)
+	if err != nil {
+		return certs.FabricCAParams{}, err
+	}
+	url := o.CAURL
+	if url == "" {
+		url, err = GetURLForCA(certAuth)
+		if err != nil {
+			return certs.FabricCAParams{}, err
+		}
+	}
+	return certs.FabricCAParams{
+		TLSCert:      certAuth.Status.TlsCert,
+		URL:          url,
+		Name:         o.CAName,
+		MSPID:        o.MspID,
+		EnrollID:     o.EnrollID,
+		EnrollSecret: o.EnrollSecret,
+	}, nil
+}
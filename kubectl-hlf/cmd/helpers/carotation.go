@@ -0,0 +1,77 @@
+package helpers
+
+import (
+	"crypto/x509"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/pkg/errors"
+)
+
+// CARootCerts are the certificates of a FabricCA that the MSP of an organization enrolled by it must contain
+type CARootCerts struct {
+	RootCerts            []*x509.Certificate
+	IntermediateCerts    []*x509.Certificate
+	TLSRootCerts         []*x509.Certificate
+	TLSIntermediateCerts []*x509.Certificate
+}
+
+// GetCARootCerts returns the root and intermediate certificates of the sign and TLS CAs of a FabricCA, the current root
+// goes first and the root trusted during a rotation of the CA after it
+func GetCARootCerts(caStatus hlfv1alpha1.FabricCAStatus) (*CARootCerts, error) {
+	rootCerts, intermediateCerts, err := parseCAChain(caStatus.CACert)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the sign CA certificate")
+	}
+	tlsRootCerts, tlsIntermediateCerts, err := parseCAChain(caStatus.TLSCACert)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the TLS CA certificate")
+	}
+	if caStatus.Rotation != nil {
+		if caStatus.Rotation.TrustedCACert != "" {
+			trustedCert, err := utils.ParseX509Certificate([]byte(caStatus.Rotation.TrustedCACert))
+			if err != nil {
+				return nil, err
+			}
+			rootCerts = append(rootCerts, trustedCert)
+		}
+		if caStatus.Rotation.TrustedTLSCACert != "" {
+			trustedCert, err := utils.ParseX509Certificate([]byte(caStatus.Rotation.TrustedTLSCACert))
+			if err != nil {
+				return nil, err
+			}
+			tlsRootCerts = append(tlsRootCerts, trustedCert)
+		}
+	}
+	return &CARootCerts{
+		RootCerts:            rootCerts,
+		IntermediateCerts:    intermediateCerts,
+		TLSRootCerts:         tlsRootCerts,
+		TLSIntermediateCerts: tlsIntermediateCerts,
+	}, nil
+}
+
+// parseCAChain splits the PEM encoded chain of a CA, root certificate first, in the root and the intermediates
+func parseCAChain(chain string) ([]*x509.Certificate, []*x509.Certificate, error) {
+	crts, err := utils.ParseX509Certificates([]byte(chain))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(crts) == 0 {
+		return nil, nil, errors.New("no certificates found in the CA chain")
+	}
+	return crts[:1], crts[1:], nil
+}
+
+// CertsEqual returns true if both lists contain the same certificates in the same order
+func CertsEqual(a []*x509.Certificate, b []*x509.Certificate) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if !a[idx].Equal(b[idx]) {
+			return false
+		}
+	}
+	return true
+}
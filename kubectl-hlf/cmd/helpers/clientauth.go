@@ -0,0 +1,111 @@
+package helpers
+
+import (
+	"context"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetClientAuthRootCerts returns the PEM encoded root certificates trusted to issue the client certificates presented to
+// a node of the MSP ID, when the client auth has neither CAs nor root certificates the TLS roots of the organizations of
+// the FabricMainChannels the MSP ID belongs to are returned
+func GetClientAuthRootCerts(ctx context.Context, hlfClientSet operatorv1.Interface, mspID string, clientAuth *hlfv1alpha1.FabricTLSClientAuth) ([]string, error) {
+	if clientAuth == nil || !clientAuth.Enabled {
+		return []string{}, nil
+	}
+	rootCerts := []string{}
+	if len(clientAuth.CAs) == 0 && len(clientAuth.RootCerts) == 0 {
+		channelRootCerts, err := getChannelOrgsTLSRootCerts(ctx, hlfClientSet, mspID)
+		if err != nil {
+			return nil, err
+		}
+		return channelRootCerts, nil
+	}
+	for _, ca := range clientAuth.CAs {
+		tlsRootCert, err := getCATLSRootCert(ctx, hlfClientSet, ca.Name, ca.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		rootCerts = appendRootCert(rootCerts, tlsRootCert)
+	}
+	for _, rootCert := range clientAuth.RootCerts {
+		rootCerts = appendRootCert(rootCerts, rootCert)
+	}
+	return rootCerts, nil
+}
+
+func getChannelOrgsTLSRootCerts(ctx context.Context, hlfClientSet operatorv1.Interface, mspID string) ([]string, error) {
+	channels, err := hlfClientSet.HlfV1alpha1().FabricMainChannels().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the FabricMainChannels")
+	}
+	rootCerts := []string{}
+	for _, channel := range channels.Items {
+		if !utils.Contains(getMainChannelMSPIDs(channel), mspID) {
+			continue
+		}
+		for _, org := range channel.Spec.PeerOrganizations {
+			tlsRootCert, err := getCATLSRootCert(ctx, hlfClientSet, org.CAName, org.CANamespace)
+			if err != nil {
+				return nil, err
+			}
+			rootCerts = appendRootCert(rootCerts, tlsRootCert)
+		}
+		for _, org := range channel.Spec.ExternalPeerOrganizations {
+			rootCerts = appendRootCert(rootCerts, org.TLSRootCert)
+		}
+		for _, org := range channel.Spec.OrdererOrganizations {
+			tlsRootCert := org.TLSCACert
+			if tlsRootCert == "" {
+				tlsRootCert, err = getCATLSRootCert(ctx, hlfClientSet, org.CAName, org.CANamespace)
+				if err != nil {
+					return nil, err
+				}
+			}
+			rootCerts = appendRootCert(rootCerts, tlsRootCert)
+		}
+		for _, org := range channel.Spec.ExternalOrdererOrganizations {
+			rootCerts = appendRootCert(rootCerts, org.TLSRootCert)
+		}
+	}
+	return rootCerts, nil
+}
+
+func getMainChannelMSPIDs(channel hlfv1alpha1.FabricMainChannel) []string {
+	var mspIDs []string
+	for _, org := range channel.Spec.PeerOrganizations {
+		mspIDs = append(mspIDs, org.MSPID)
+	}
+	for _, org := range channel.Spec.ExternalPeerOrganizations {
+		mspIDs = append(mspIDs, org.MSPID)
+	}
+	for _, org := range channel.Spec.OrdererOrganizations {
+		mspIDs = append(mspIDs, org.MSPID)
+	}
+	for _, org := range channel.Spec.ExternalOrdererOrganizations {
+		mspIDs = append(mspIDs, org.MSPID)
+	}
+	return mspIDs
+}
+
+func getCATLSRootCert(ctx context.Context, hlfClientSet operatorv1.Interface, name string, namespace string) (string, error) {
+	fabricCA, err := hlfClientSet.HlfV1alpha1().FabricCAs(namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get FabricCA %s/%s", namespace, name)
+	}
+	if fabricCA.Status.TLSCACert == "" {
+		return "", errors.Errorf("FabricCA %s/%s has no TLS CA certificate yet", namespace, name)
+	}
+	return fabricCA.Status.TLSCACert, nil
+}
+
+func appendRootCert(rootCerts []string, rootCert string) []string {
+	if rootCert == "" || utils.Contains(rootCerts, rootCert) {
+		return rootCerts
+	}
+	return append(rootCerts, rootCert)
+}
@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+
+	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	hlffake "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestFabricCA(name string, tlsCACert string) *hlfv1alpha1.FabricCA {
+	return &hlfv1alpha1.FabricCA{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+		Status:     hlfv1alpha1.FabricCAStatus{TLSCACert: tlsCACert},
+	}
+}
+
+func TestGetClientAuthRootCerts(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	hlfClientSet := hlffake.NewSimpleClientset(
+		newTestFabricCA("org1-ca", "org1-tls-root"),
+		newTestFabricCA("org2-ca", "org2-tls-root"),
+		newTestFabricCA("orderer-ca", "orderer-tls-root"),
+		&hlfv1alpha1.FabricMainChannel{
+			ObjectMeta: v1.ObjectMeta{Name: "mychannel"},
+			Spec: hlfv1alpha1.FabricMainChannelSpec{
+				PeerOrganizations: []hlfv1alpha1.FabricMainChannelPeerOrganization{
+					{MSPID: "Org1MSP", CAName: "org1-ca", CANamespace: "default"},
+					{MSPID: "Org2MSP", CAName: "org2-ca", CANamespace: "default"},
+				},
+				ExternalPeerOrganizations: []hlfv1alpha1.FabricMainChannelExternalPeerOrganization{
+					{MSPID: "Org3MSP", TLSRootCert: "org3-tls-root"},
+				},
+				OrdererOrganizations: []hlfv1alpha1.FabricMainChannelOrdererOrganization{
+					{MSPID: "OrdererMSP", CAName: "orderer-ca", CANamespace: "default"},
+				},
+				ExternalOrdererOrganizations: []hlfv1alpha1.FabricMainChannelExternalOrdererOrganization{
+					{MSPID: "Orderer2MSP", TLSRootCert: "orderer2-tls-root"},
+				},
+			},
+		},
+		&hlfv1alpha1.FabricMainChannel{
+			ObjectMeta: v1.ObjectMeta{Name: "otherchannel"},
+			Spec: hlfv1alpha1.FabricMainChannelSpec{
+				ExternalPeerOrganizations: []hlfv1alpha1.FabricMainChannelExternalPeerOrganization{
+					{MSPID: "Org5MSP", TLSRootCert: "org5-tls-root"},
+				},
+				OrdererOrganizations: []hlfv1alpha1.FabricMainChannelOrdererOrganization{
+					{MSPID: "OrdererMSP", TLSCACert: "orderer-tls-root"},
+				},
+			},
+		},
+	)
+
+	rootCerts, err := GetClientAuthRootCerts(ctx, hlfClientSet, "Org1MSP", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rootCerts).To(BeEmpty())
+	rootCerts, err = GetClientAuthRootCerts(ctx, hlfClientSet, "Org1MSP", &hlfv1alpha1.FabricTLSClientAuth{
+		Enabled:   false,
+		RootCerts: []string{"org3-tls-root"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rootCerts).To(BeEmpty())
+
+	// without CAs nor root certificates, the TLS roots of the organizations of the channels of the MSP ID are trusted
+	rootCerts, err = GetClientAuthRootCerts(ctx, hlfClientSet, "Org1MSP", &hlfv1alpha1.FabricTLSClientAuth{Enabled: true})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rootCerts).To(Equal([]string{"org1-tls-root", "org2-tls-root", "org3-tls-root", "orderer-tls-root", "orderer2-tls-root"}))
+	rootCerts, err = GetClientAuthRootCerts(ctx, hlfClientSet, "OrdererMSP", &hlfv1alpha1.FabricTLSClientAuth{Enabled: true})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rootCerts).To(ConsistOf("org1-tls-root", "org2-tls-root", "org3-tls-root", "orderer-tls-root", "orderer2-tls-root", "org5-tls-root"))
+	rootCerts, err = GetClientAuthRootCerts(ctx, hlfClientSet, "Org9MSP", &hlfv1alpha1.FabricTLSClientAuth{Enabled: true})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rootCerts).To(BeEmpty())
+
+	// the CAs and root certificates of the spec replace the roots of the channels
+	rootCerts, err = GetClientAuthRootCerts(ctx, hlfClientSet, "Org1MSP", &hlfv1alpha1.FabricTLSClientAuth{
+		Enabled:   true,
+		CAs:       []hlfv1alpha1.FabricTLSClientAuthCA{{Name: "org2-ca", Namespace: "default"}},
+		RootCerts: []string{"org2-tls-root", "", "external-tls-root"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rootCerts).To(Equal([]string{"org2-tls-root", "external-tls-root"}))
+
+	_, err = GetClientAuthRootCerts(ctx, hlfClientSet, "Org1MSP", &hlfv1alpha1.FabricTLSClientAuth{
+		Enabled: true,
+		CAs:     []hlfv1alpha1.FabricTLSClientAuthCA{{Name: "org4-ca", Namespace: "default"}},
+	})
+	g.Expect(err).To(MatchError(ContainSubstring("failed to get FabricCA default/org4-ca")))
+}
@@ -28,6 +28,12 @@ const (
 	DefaultCouchDBImage   = "couchdb"
 	DefaultCouchDBVersion = "3.1.1"
 
+	DefaultCouchDBProxyImage   = "nginx"
+	DefaultCouchDBProxyVersion = "1.25-alpine"
+
+	DefaultK8sBuilderImage   = "quay.io/kfsoftware/hlf-operator"
+	DefaultK8sBuilderVersion = "latest"
+
 	DefaultOrdererImage   = "hyperledger/fabric-orderer"
 	DefaultOrdererVersion = "amd64-2.3.0"
 )
@@ -0,0 +1,102 @@
+package helpers
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/kfsoftware/hlf-operator/controllers/certs"
+	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var oidExtensionAuthorityKeyID = asn1.ObjectIdentifier{2, 5, 29, 35}
+
+// GetCACRL generates the certificate revocation list of the signing CA of a FabricCA with an identity of its registry,
+// the first identity with the hf.GenCRL attribute is used if enrollID is empty
+func GetCACRL(ctx context.Context, hlfClientSet *operatorv1.Clientset, name string, namespace string, enrollID string, mspID string) (*pkix.CertificateList, error) {
+	fabricCA, err := hlfClientSet.HlfV1alpha1().FabricCAs(namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get FabricCA %s/%s", namespace, name)
+	}
+	var enrollSecret string
+	for _, identity := range fabricCA.Spec.CA.Registry.Identities {
+		if (enrollID == "" && identity.Attrs.GenCRL) || identity.Name == enrollID {
+			enrollID = identity.Name
+			enrollSecret = identity.Pass
+			break
+		}
+	}
+	if enrollSecret == "" {
+		return nil, errors.Errorf("no identity allowed to generate the CRL found in the registry of FabricCA %s/%s", namespace, name)
+	}
+	crlPem, err := certs.GenCRL(certs.GenCRLRequest{
+		TLSCert:      fabricCA.Status.TlsCert,
+		URL:          fmt.Sprintf("https://%s", GetCAPrivateURL(*fabricCA)),
+		Name:         fabricCA.Spec.CA.Name,
+		MSPID:        mspID,
+		EnrollID:     enrollID,
+		EnrollSecret: enrollSecret,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate the CRL of FabricCA %s/%s", namespace, name)
+	}
+	crl, err := x509.ParseCRL(crlPem)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the CRL of FabricCA %s/%s", namespace, name)
+	}
+	return crl, nil
+}
+
+// RevocationListChanged reports whether the certificates revoked by the CRL differ from the ones revoked by the CRLs of
+// the same issuer in the current revocation list of an MSP, a CRL is regenerated with a new timestamp every time so only
+// the serial numbers are compared
+func RevocationListChanged(currentCRLs []*pkix.CertificateList, crl *pkix.CertificateList) bool {
+	var issuerCRLs []*pkix.CertificateList
+	for _, currentCRL := range currentCRLs {
+		if crlIssuer(currentCRL) == crlIssuer(crl) {
+			issuerCRLs = append(issuerCRLs, currentCRL)
+		}
+	}
+	return !reflect.DeepEqual(revokedSerialNumbers(issuerCRLs), revokedSerialNumbers([]*pkix.CertificateList{crl}))
+}
+
+// MergeRevocationList replaces the CRLs of the issuer of the CRL in the revocation list of an MSP, the CRLs of other
+// issuers, like an intermediate CA or the previous root of a rotated CA, are kept
+func MergeRevocationList(currentCRLs []*pkix.CertificateList, crl *pkix.CertificateList) []*pkix.CertificateList {
+	crls := []*pkix.CertificateList{}
+	for _, currentCRL := range currentCRLs {
+		if crlIssuer(currentCRL) != crlIssuer(crl) {
+			crls = append(crls, currentCRL)
+		}
+	}
+	return append(crls, crl)
+}
+
+// crlIssuer identifies the CA that signed a CRL by its name and, since a rotated root can keep the name of the previous
+// one, by the authority key identifier of the CRL
+func crlIssuer(crl *pkix.CertificateList) string {
+	issuer := crl.TBSCertList.Issuer.String()
+	for _, extension := range crl.TBSCertList.Extensions {
+		if extension.Id.Equal(oidExtensionAuthorityKeyID) {
+			return fmt.Sprintf("%s/%x", issuer, extension.Value)
+		}
+	}
+	return issuer
+}
+
+func revokedSerialNumbers(crls []*pkix.CertificateList) []string {
+	serialNumbers := []string{}
+	for _, crl := range crls {
+		for _, revokedCert := range crl.TBSCertList.RevokedCertificates {
+			serialNumbers = append(serialNumbers, revokedCert.SerialNumber.String())
+		}
+	}
+	sort.Strings(serialNumbers)
+	return serialNumbers
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/pkg/errors"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -34,6 +35,15 @@ func GetKubeClient() (*kubernetes.Clientset, error) {
 	return kubeClientset, nil
 }
 
+// GetKubeConfig provides the k8s rest config for kubeconfig
+func GetKubeConfig() (*rest.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	configOverrides := &clientcmd.ConfigOverrides{}
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+
+	return kubeConfig.ClientConfig()
+}
+
 // GetKubeExtensionClient provides k8s client for CRDs
 func GetKubeExtensionClient() (*apiextension.Clientset, error) {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
@@ -84,6 +94,20 @@ func ExecKubectl(ctx context.Context, args ...string) ([]byte, error) {
 	return stdout.Bytes(), nil
 }
 
+// ExecKubectlWithInput executes the given command using `kubectl` with input as its standard input
+func ExecKubectlWithInput(ctx context.Context, input io.Reader, args ...string) ([]byte, error) {
+	var stdout, stderr, combined bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	cmd.Stdin = input
+	cmd.Stdout = io.MultiWriter(&stdout, &combined)
+	cmd.Stderr = io.MultiWriter(&stderr, &combined)
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Errorf("kubectl command failed (%s). output=%s", err, combined.String())
+	}
+	return stdout.Bytes(), nil
+}
+
 // ToYaml takes a slice of values, and returns corresponding YAML
 // representation as a string slice
 func ToYaml(objs []runtime.Object) ([]string, error) {
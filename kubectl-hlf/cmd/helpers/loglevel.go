@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"context"
+
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers/operations"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetOrSetLogSpec sets the logging spec of the peer or orderer behind the service when spec isn't empty and returns
+// the active one, the operations endpoint is reached through the proxy of the Kubernetes API server
+func GetOrSetLogSpec(ctx context.Context, ns string, serviceName string, spec string) (string, error) {
+	kubeClientset, err := GetKubeClient()
+	if err != nil {
+		return "", err
+	}
+	svc, err := kubeClientset.CoreV1().Services(ns).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the service %s", serviceName)
+	}
+	kubeConfig, err := GetKubeConfig()
+	if err != nil {
+		return "", err
+	}
+	opsClient, err := operations.NewServiceClient(kubeConfig, svc)
+	if err != nil {
+		return "", err
+	}
+	if spec != "" {
+		err = opsClient.SetLogSpec(ctx, spec)
+		if err != nil {
+			return "", err
+		}
+	}
+	return opsClient.GetLogSpec(ctx)
+}
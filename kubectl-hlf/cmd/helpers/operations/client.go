@@ -0,0 +1,225 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// Client calls the operations endpoint of a peer or an orderer
+type Client struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewClient returns a client of the operations endpoint served at url, for example
+// http://org1-peer0.default:9443
+func NewClient(url string) *Client {
+	return &Client{
+		url: strings.TrimSuffix(url, "/"),
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// NewServiceProxyClient returns a client that reaches the operations port of a service through the proxy of the
+// Kubernetes API server, so the operations endpoint doesn't need to be exposed outside of the cluster
+func NewServiceProxyClient(config *rest.Config, namespace string, serviceName string, port int) (*Client, error) {
+	httpClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+	serverURL, _, err := rest.DefaultServerURL(config.Host, config.APIPath, schema.GroupVersion{}, rest.IsConfigTransportTLS(*config))
+	if err != nil {
+		return nil, err
+	}
+	proxyURL := *serverURL
+	proxyURL.Path = path.Join(
+		serverURL.Path,
+		"/api/v1/namespaces",
+		namespace,
+		"services",
+		fmt.Sprintf("http:%s:%d", serviceName, port),
+		"proxy",
+	)
+	return &Client{
+		url:        proxyURL.String(),
+		httpClient: httpClient,
+	}, nil
+}
+
+// PortName is the name of the port of the service of a peer or an orderer that targets the operations endpoint
+const PortName = "operations"
+
+// GetServicePort returns the port of the service that targets the operations endpoint
+func GetServicePort(svc *corev1.Service) (int, error) {
+	for _, port := range svc.Spec.Ports {
+		if port.Name == PortName {
+			return int(port.Port), nil
+		}
+	}
+	return 0, errors.Errorf("operations port not found in service %s", svc.Name)
+}
+
+// NewServiceClient returns a client of the operations endpoint of the peer or orderer behind the service, reached
+// through the proxy of the Kubernetes API server
+func NewServiceClient(config *rest.Config, svc *corev1.Service) (*Client, error) {
+	port, err := GetServicePort(svc)
+	if err != nil {
+		return nil, err
+	}
+	return NewServiceProxyClient(config, svc.Namespace, svc.Name, port)
+}
+
+type logSpec struct {
+	Spec string `json:"spec"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// GetLogSpec returns the active logging spec of the node
+func (c *Client) GetLogSpec(ctx context.Context) (string, error) {
+	var spec logSpec
+	err := c.do(ctx, http.MethodGet, "/logspec", nil, &spec)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get the logging spec")
+	}
+	return spec.Spec, nil
+}
+
+// SetLogSpec changes the logging spec of the node, the change lasts until the node restarts
+func (c *Client) SetLogSpec(ctx context.Context, spec string) error {
+	body, err := json.Marshal(logSpec{Spec: spec})
+	if err != nil {
+		return err
+	}
+	err = c.do(ctx, http.MethodPut, "/logspec", body, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set the logging spec to %s", spec)
+	}
+	return nil
+}
+
+// ApplyLogSpec sets the logging spec of the node if the active one is different and returns whether it changed
+func (c *Client) ApplyLogSpec(ctx context.Context, spec string) (bool, error) {
+	current, err := c.GetLogSpec(ctx)
+	if err != nil {
+		return false, err
+	}
+	if current == spec {
+		return false, nil
+	}
+	err = c.SetLogSpec(ctx, spec)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// HealthStatus is the response of the /healthz endpoint
+type HealthStatus struct {
+	// OK or Service Unavailable
+	Status       string        `json:"status"`
+	Time         time.Time     `json:"time"`
+	FailedChecks []FailedCheck `json:"failed_checks,omitempty"`
+}
+
+// FailedCheck is a component that failed its health check
+type FailedCheck struct {
+	Component string `json:"component"`
+	Reason    string `json:"reason"`
+}
+
+// GetHealth returns the result of the health checks of the node, the node answers with 503 when a check fails, which
+// isn't an error of the request
+func (c *Client) GetHealth(ctx context.Context) (*HealthStatus, error) {
+	statusCode, respBody, err := c.send(ctx, http.MethodGet, "/healthz", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the health of the node")
+	}
+	if statusCode != http.StatusOK && statusCode != http.StatusServiceUnavailable {
+		return nil, errors.Errorf("GET /healthz returned %d: %s", statusCode, strings.TrimSpace(string(respBody)))
+	}
+	health := &HealthStatus{}
+	err = json.Unmarshal(respBody, health)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the health of the node")
+	}
+	return health, nil
+}
+
+// GetMetrics returns the metrics of the node by name, the node must use the prometheus metrics provider
+func (c *Client) GetMetrics(ctx context.Context) (map[string]*dto.MetricFamily, error) {
+	statusCode, respBody, err := c.send(ctx, http.MethodGet, "/metrics", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the metrics of the node")
+	}
+	if statusCode != http.StatusOK {
+		return nil, errors.Errorf("GET /metrics returned %d: %s", statusCode, strings.TrimSpace(string(respBody)))
+	}
+	var parser expfmt.TextParser
+	metrics, err := parser.TextToMetricFamilies(bytes.NewReader(respBody))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the metrics of the node")
+	}
+	return metrics, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, endpoint string, body []byte, out interface{}) error {
+	statusCode, respBody, err := c.send(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		var errResp errorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			return errors.Errorf("%s %s returned %d: %s", method, endpoint, statusCode, errResp.Error)
+		}
+		return errors.Errorf("%s %s returned %d: %s", method, endpoint, statusCode, strings.TrimSpace(string(respBody)))
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+func (c *Client) send(ctx context.Context, method string, endpoint string, body []byte) (int, []byte, error) {
+	u, err := url.Parse(c.url + endpoint)
+	if err != nil {
+		return 0, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, respBody, nil
+}
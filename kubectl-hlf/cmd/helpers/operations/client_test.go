@@ -0,0 +1,83 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestNewServiceClient(t *testing.T) {
+	g := NewWithT(t)
+	svc := &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "org1-peer0", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "peer", Port: 7051},
+				{Name: PortName, Port: 9443},
+			},
+		},
+	}
+	port, err := GetServicePort(svc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(port).To(Equal(9443))
+
+	client, err := NewServiceClient(&rest.Config{Host: "https://kubernetes.default:443"}, svc)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client.url).To(Equal("https://kubernetes.default:443/api/v1/namespaces/default/services/http:org1-peer0:9443/proxy"))
+
+	svc.Spec.Ports = svc.Spec.Ports[:1]
+	_, err = GetServicePort(svc)
+	g.Expect(err).To(HaveOccurred())
+	_, err = NewServiceClient(&rest.Config{Host: "https://kubernetes.default:443"}, svc)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestApplyLogSpec(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	active := "info"
+	puts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/logspec" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(logSpec{Spec: active})
+		case http.MethodPut:
+			spec := logSpec{}
+			_ = json.NewDecoder(r.Body).Decode(&spec)
+			if spec.Spec == "invalid" {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(errorResponse{Error: "invalid logging specification"})
+				return
+			}
+			active = spec.Spec
+			puts++
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL + "/")
+	changed, err := client.ApplyLogSpec(ctx, "info")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+	g.Expect(puts).To(Equal(0))
+
+	changed, err = client.ApplyLogSpec(ctx, "info:gossip=debug")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(active).To(Equal("info:gossip=debug"))
+
+	_, err = client.ApplyLogSpec(ctx, "invalid")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid logging specification")))
+}
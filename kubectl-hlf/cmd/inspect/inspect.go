@@ -31,9 +31,14 @@ type inspectCmd struct {
 	namespaces    []string
 	ordererNodes  []string
 	channels      []string
+	clientTLSCert string
+	clientTLSKey  string
 }
 
 func (c *inspectCmd) validate() error {
+	if (c.clientTLSCert == "") != (c.clientTLSKey == "") {
+		return fmt.Errorf("--client-tls-cert and --client-tls-key must be set together")
+	}
 	return nil
 }
 
@@ -55,6 +60,16 @@ name: hlf-network
 version: 1.0.0
 client:
   organization: "{{ .Organization }}"
+{{- with .ClientTLS }}
+  tlsCerts:
+    client:
+      key:
+        pem: |
+{{ .Key | indent 10 }}
+      cert:
+        pem: |
+{{ .Cert | indent 10 }}
+{{- end }}
 {{- if not .Organizations }}
 organizations: {}
 {{- else }}
@@ -262,6 +277,32 @@ func (c *inspectCmd) run(out io.Writer) error {
 			orderers = append(orderers, orderer)
 		}
 	}
+	var clientTLS *helpers.Identity
+	if c.clientTLSCert != "" {
+		clientTLSCert, err := ioutil.ReadFile(c.clientTLSCert)
+		if err != nil {
+			return err
+		}
+		clientTLSKey, err := ioutil.ReadFile(c.clientTLSKey)
+		if err != nil {
+			return err
+		}
+		clientTLS = &helpers.Identity{
+			Cert: string(clientTLSCert),
+			Key:  string(clientTLSKey),
+		}
+	} else {
+		for _, peer := range peers {
+			if peer.Spec.ClientAuth != nil && peer.Spec.ClientAuth.Enabled {
+				log.Warning(fmt.Sprintf("Peer %s requires client authentication, set --client-tls-cert and --client-tls-key to connect to it", peer.Name))
+			}
+		}
+		for _, orderer := range orderers {
+			if orderer.Spec.ClientAuth != nil && orderer.Spec.ClientAuth.Enabled {
+				log.Warning(fmt.Sprintf("Orderer %s requires client authentication, set --client-tls-cert and --client-tls-key to connect to it", orderer.Name))
+			}
+		}
+	}
 	tmpl, err := template.New("test").Funcs(sprig.HermeticTxtFuncMap()).Parse(tmplGoConfig)
 	if err != nil {
 		return err
@@ -274,6 +315,7 @@ func (c *inspectCmd) run(out io.Writer) error {
 		"CertAuths":     certAuthsFiltered,
 		"Internal":      c.internal,
 		"Channels":      c.channels,
+		"ClientTLS":     clientTLS,
 	})
 	if err != nil {
 		return err
@@ -332,6 +374,8 @@ func NewInspectHLFConfig(out io.Writer) *cobra.Command {
 	f.StringVar(&c.format, "format", yamlFormat, "Connection profile output format (yaml/json)")
 	f.StringArrayVarP(&c.namespaces, "namespace", "n", []string{}, "Namespace scope for this request")
 	f.StringArrayVarP(&c.channels, "channels", "c", []string{"_default"}, "Channels for the network config")
+	f.StringVar(&c.clientTLSCert, "client-tls-cert", "", "TLS certificate presented to the peers and orderers that require client authentication")
+	f.StringVar(&c.clientTLSKey, "client-tls-key", "", "Private key of the client TLS certificate")
 
 	return cmd
 }
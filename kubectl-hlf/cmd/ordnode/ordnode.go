@@ -19,6 +19,7 @@ func NewOrdNodeCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 		newRemoveChannelCMD(out, errOut),
 		newUpgradeOrdererCMD(out, errOut),
 		newUpdateOrdererCMD(out, errOut),
+		newLogLevelCmd(out, errOut),
 	)
 	return cmd
 }
@@ -44,6 +44,11 @@ type Options struct {
 	CouchDBImage                    string
 	CouchDBTag                      string
 	CouchDBPassword                 string
+	CouchDBHost                     string
+	CouchDBPort                     int
+	CouchDBPrefix                   string
+	CouchDBSecret                   string
+	CouchDBCASecret                 string
 	CAPort                          int
 	CAHost                          string
 	ImagePullSecrets                []string
@@ -170,6 +175,29 @@ func (c *createCmd) run() error {
 		couchDB.Image = c.peerOpts.CouchDBImage
 		couchDB.Tag = c.peerOpts.CouchDBTag
 	}
+	if c.peerOpts.CouchDBHost != "" {
+		couchDB.ExternalCouchDB = &v1alpha1.FabricPeerExternalCouchDB{
+			Enabled:        true,
+			Host:           c.peerOpts.CouchDBHost,
+			Port:           c.peerOpts.CouchDBPort,
+			DatabasePrefix: c.peerOpts.CouchDBPrefix,
+		}
+		if c.peerOpts.CouchDBSecret != "" {
+			couchDB.ExternalCouchDB.Credentials = &v1alpha1.FabricPeerCouchDBCredentials{
+				SecretName:  c.peerOpts.CouchDBSecret,
+				UserKey:     "username",
+				PasswordKey: "password",
+			}
+		}
+		if c.peerOpts.CouchDBCASecret != "" {
+			couchDB.ExternalCouchDB.TLS = &v1alpha1.FabricPeerCouchDBTLS{
+				CACert: corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: c.peerOpts.CouchDBCASecret},
+					Key:                  "ca.crt",
+				},
+			}
+		}
+	}
 	caHost := k8sIP
 	caPort := certAuth.Status.NodePort
 	serviceType := corev1.ServiceTypeNodePort
@@ -463,5 +491,10 @@ func newCreatePeerCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	f.StringVarP(&c.peerOpts.CouchDBImage, "couchdb-repository", "", helpers.DefaultCouchDBImage, "CouchDB image")
 	f.StringVarP(&c.peerOpts.CouchDBTag, "couchdb-tag", "", helpers.DefaultCouchDBVersion, "CouchDB version")
 	f.StringVarP(&c.peerOpts.CouchDBPassword, "couchdb-password", "", "", "CouchDB password")
+	f.StringVarP(&c.peerOpts.CouchDBHost, "couchdb-host", "", "", "Host of an external CouchDB, the CouchDB container isn't deployed when it's set")
+	f.IntVarP(&c.peerOpts.CouchDBPort, "couchdb-port", "", 5984, "Port of the external CouchDB")
+	f.StringVarP(&c.peerOpts.CouchDBPrefix, "couchdb-prefix", "", "", "Prefix of the databases of the peer in the external CouchDB")
+	f.StringVarP(&c.peerOpts.CouchDBSecret, "couchdb-secret", "", "", "Secret with the username and password keys to connect to the external CouchDB")
+	f.StringVarP(&c.peerOpts.CouchDBCASecret, "couchdb-ca-secret", "", "", "Secret with the ca.crt key to connect to the external CouchDB over HTTPS")
 	return cmd
 }
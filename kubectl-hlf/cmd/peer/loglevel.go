@@ -0,0 +1,63 @@
+package peer
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	logLevelDesc = `
+'loglevel' command shows or changes the logging spec of a running peer without restarting it, the change lasts until
+the peer restarts or the operator reconciles the logging of the FabricPeer`
+	logLevelExample = `  kubectl hlf peer loglevel --name org1-peer0 --namespace default
+  kubectl hlf peer loglevel --name org1-peer0 --namespace default --spec info:gossip=debug`
+)
+
+type logLevelCmd struct {
+	out  io.Writer
+	name string
+	ns   string
+	spec string
+}
+
+func newLogLevelCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	c := &logLevelCmd{out: out}
+	cmd := &cobra.Command{
+		Use:     "loglevel",
+		Short:   "Show or change the logging spec of a running peer",
+		Long:    logLevelDesc,
+		Example: logLevelExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.validate(); err != nil {
+				return err
+			}
+			return c.run()
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&c.name, "name", "", "Name of the Fabric Peer")
+	f.StringVarP(&c.ns, "namespace", "n", helpers.DefaultNamespace, "Namespace scope for this request")
+	f.StringVar(&c.spec, "spec", "", "Logging spec to set, the current one is shown if empty")
+	return cmd
+}
+
+func (c *logLevelCmd) validate() error {
+	if c.name == "" {
+		return errors.New("--name is required")
+	}
+	return nil
+}
+
+func (c *logLevelCmd) run() error {
+	spec, err := helpers.GetOrSetLogSpec(context.Background(), c.ns, c.name, c.spec)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out, spec)
+	return nil
+}
@@ -15,6 +15,7 @@ func NewPeerCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 		newRenewChannelCMD(out, errOut),
 		newUpgradePeerCMD(out, errOut),
 		newUpdatePeerCMD(out, errOut),
+		newLogLevelCmd(out, errOut),
 	)
 	return cmd
 }
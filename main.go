@@ -19,6 +19,7 @@ package main
 import (
 	"flag"
 	"github.com/kfsoftware/hlf-operator/controllers/chaincode"
+	"github.com/kfsoftware/hlf-operator/controllers/channelupdate"
 	"github.com/kfsoftware/hlf-operator/controllers/console"
 	"github.com/kfsoftware/hlf-operator/controllers/followerchannel"
 	"github.com/kfsoftware/hlf-operator/controllers/hlfmetrics"
@@ -28,6 +29,7 @@ import (
 	"github.com/kfsoftware/hlf-operator/controllers/operatorui"
 	"github.com/kfsoftware/hlf-operator/controllers/ordnode"
 	"github.com/kfsoftware/hlf-operator/controllers/utils"
+	"github.com/kfsoftware/hlf-operator/pkg/k8sbuilder"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/client-go/rest"
 	"os"
@@ -63,12 +65,26 @@ func init() {
 }
 
 func main() {
+	// the binary is also the external builder that the peers use to launch the chaincodes in kubernetes
+	if k8sbuilder.IsBuilderCommand(os.Args[0]) {
+		os.Exit(k8sbuilder.Main(os.Args))
+	}
+	if len(os.Args) == 3 && os.Args[1] == k8sbuilder.InstallCommand {
+		err := k8sbuilder.Install(os.Args[2])
+		if err != nil {
+			log.Fatalf("Failed to install the builder in %s: %v", os.Args[2], err)
+		}
+		return
+	}
 	var metricsAddr string
 	var enableLeaderElection bool
+	var k8sBuilderImage string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8090", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&k8sBuilderImage, "k8s-builder-image", "",
+		"Image of the builder that the peers use to launch the chaincodes in kubernetes, usually the image of the operator.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -102,11 +118,12 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&peer.FabricPeerReconciler{
-		Client:    mgr.GetClient(),
-		Log:       ctrl.Log.WithName("controllers").WithName("FabricPeer"),
-		Scheme:    mgr.GetScheme(),
-		Config:    mgr.GetConfig(),
-		ChartPath: peerChartPath,
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("FabricPeer"),
+		Scheme:          mgr.GetScheme(),
+		Config:          mgr.GetConfig(),
+		ChartPath:       peerChartPath,
+		K8sBuilderImage: k8sBuilderImage,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "FabricPeer")
 		os.Exit(1)
@@ -242,6 +259,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&channelupdate.FabricChannelUpdateProposalReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("FabricChannelUpdateProposal"),
+		Scheme: mgr.GetScheme(),
+		Config: mgr.GetConfig(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "FabricChannelUpdateProposal")
+		os.Exit(1)
+	}
+
 	if err = (&chaincode.FabricChaincodeReconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("FabricChaincode"),
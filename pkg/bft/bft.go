@@ -0,0 +1,473 @@
+package bft
+
+import (
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	mb "github.com/hyperledger/fabric-protos-go/msp"
+	ob "github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/hyperledger/fabric/common/policydsl"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ConsensusType is the orderer type used by Fabric for SmartBFT ordering
+const ConsensusType = "BFT"
+
+// RequiredChannelCapability is the channel capability needed by the orderers to use BFT ordering
+const RequiredChannelCapability = "V3_0"
+
+const (
+	RotationUnspecified = "ROTATION_UNSPECIFIED"
+	RotationOff         = "ROTATION_OFF"
+	RotationOn          = "ROTATION_ON"
+)
+
+const (
+	ordererGroupKey          = "Orderer"
+	consensusTypeKey         = "ConsensusType"
+	ordererConsentersKey     = "Orderers"
+	blockValidationPolicyKey = "BlockValidation"
+	adminsPolicyKey          = "Admins"
+)
+
+// Consenter is a member of the BFT consenter set of a channel
+type Consenter struct {
+	ID            uint32
+	Host          string
+	Port          uint32
+	MSPID         string
+	Identity      []byte
+	ClientTLSCert []byte
+	ServerTLSCert []byte
+}
+
+// Options holds the SmartBFT options of a channel, durations are expressed as Go durations (e.g. `2s`)
+type Options struct {
+	RequestBatchMaxCount      uint64
+	RequestBatchMaxBytes      uint64
+	RequestBatchMaxInterval   string
+	IncomingMessageBufferSize uint64
+	RequestPoolSize           uint64
+	RequestForwardTimeout     string
+	RequestComplainTimeout    string
+	RequestAutoRemoveTimeout  string
+	ViewChangeResendInterval  string
+	ViewChangeTimeout         string
+	LeaderHeartbeatTimeout    string
+	LeaderHeartbeatCount      uint64
+	CollectTimeout            string
+	SyncOnStart               bool
+	SpeedUpViewChange         bool
+	LeaderRotation            string
+	DecisionsPerLeader        uint64
+	RequestMaxBytes           uint64
+	RequestPoolSubmitTimeout  string
+}
+
+// DefaultOptions returns the SmartBFT options used by the Fabric sample configuration
+func DefaultOptions() Options {
+	return Options{
+		RequestBatchMaxCount:      100,
+		RequestBatchMaxBytes:      10 * 1024 * 1024,
+		RequestBatchMaxInterval:   "50ms",
+		IncomingMessageBufferSize: 200,
+		RequestPoolSize:           100000,
+		RequestForwardTimeout:     "2s",
+		RequestComplainTimeout:    "20s",
+		RequestAutoRemoveTimeout:  "3m0s",
+		ViewChangeResendInterval:  "5s",
+		ViewChangeTimeout:         "20s",
+		LeaderHeartbeatTimeout:    "1m0s",
+		LeaderHeartbeatCount:      10,
+		CollectTimeout:            "1s",
+		LeaderRotation:            RotationUnspecified,
+	}
+}
+
+func rotationValue(rotation string) (uint64, error) {
+	switch rotation {
+	case "", RotationUnspecified:
+		return 0, nil
+	case RotationOff:
+		return 1, nil
+	case RotationOn:
+		return 2, nil
+	default:
+		return 0, errors.Errorf("invalid leader rotation %s", rotation)
+	}
+}
+
+// MarshalOptions encodes the options as the `orderer.smartbft.Options` message
+func MarshalOptions(o Options) ([]byte, error) {
+	rotation, err := rotationValue(o.LeaderRotation)
+	if err != nil {
+		return nil, err
+	}
+	var b []byte
+	appendUint := func(num protowire.Number, v uint64) {
+		if v == 0 {
+			return
+		}
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		b = protowire.AppendVarint(b, v)
+	}
+	appendString := func(num protowire.Number, v string) {
+		if v == "" {
+			return
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendString(b, v)
+	}
+	appendBool := func(num protowire.Number, v bool) {
+		if v {
+			appendUint(num, 1)
+		}
+	}
+	appendUint(1, o.RequestBatchMaxCount)
+	appendUint(2, o.RequestBatchMaxBytes)
+	appendString(3, o.RequestBatchMaxInterval)
+	appendUint(4, o.IncomingMessageBufferSize)
+	appendUint(5, o.RequestPoolSize)
+	appendString(6, o.RequestForwardTimeout)
+	appendString(7, o.RequestComplainTimeout)
+	appendString(8, o.RequestAutoRemoveTimeout)
+	appendString(9, o.ViewChangeResendInterval)
+	appendString(10, o.ViewChangeTimeout)
+	appendString(11, o.LeaderHeartbeatTimeout)
+	appendUint(12, o.LeaderHeartbeatCount)
+	appendString(13, o.CollectTimeout)
+	appendBool(14, o.SyncOnStart)
+	appendBool(15, o.SpeedUpViewChange)
+	appendUint(16, rotation)
+	appendUint(17, o.DecisionsPerLeader)
+	appendUint(18, o.RequestMaxBytes)
+	appendString(19, o.RequestPoolSubmitTimeout)
+	return b, nil
+}
+
+// MarshalConsenters encodes the consenters as the `common.Orderers` message
+func MarshalConsenters(consenters []Consenter) []byte {
+	var b []byte
+	for _, consenter := range consenters {
+		var c []byte
+		if consenter.ID != 0 {
+			c = protowire.AppendTag(c, 1, protowire.VarintType)
+			c = protowire.AppendVarint(c, uint64(consenter.ID))
+		}
+		if consenter.Host != "" {
+			c = protowire.AppendTag(c, 2, protowire.BytesType)
+			c = protowire.AppendString(c, consenter.Host)
+		}
+		if consenter.Port != 0 {
+			c = protowire.AppendTag(c, 3, protowire.VarintType)
+			c = protowire.AppendVarint(c, uint64(consenter.Port))
+		}
+		if consenter.MSPID != "" {
+			c = protowire.AppendTag(c, 4, protowire.BytesType)
+			c = protowire.AppendString(c, consenter.MSPID)
+		}
+		for idx, value := range [][]byte{consenter.Identity, consenter.ClientTLSCert, consenter.ServerTLSCert} {
+			if len(value) == 0 {
+				continue
+			}
+			c = protowire.AppendTag(c, protowire.Number(5+idx), protowire.BytesType)
+			c = protowire.AppendBytes(c, value)
+		}
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, c)
+	}
+	return b
+}
+
+// Quorum returns the number of consenters that need to sign a block, following the formula used by Fabric
+func Quorum(n int) int {
+	f := (n - 1) / 3
+	return (n + f + 2) / 2
+}
+
+func blockValidationPolicy(consenters []Consenter) (*cb.Policy, error) {
+	var identities [][]byte
+	var signedBy []*cb.SignaturePolicy
+	for idx, consenter := range consenters {
+		identity, err := proto.Marshal(&mb.SerializedIdentity{
+			Mspid:   consenter.MSPID,
+			IdBytes: consenter.Identity,
+		})
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+		signedBy = append(signedBy, policydsl.SignedBy(int32(idx)))
+	}
+	envelope := policydsl.Envelope(policydsl.NOutOf(int32(Quorum(len(consenters))), signedBy), identities)
+	value, err := proto.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return &cb.Policy{
+		Type:  int32(cb.Policy_SIGNATURE),
+		Value: value,
+	}, nil
+}
+
+func validateConsenters(consenters []Consenter) error {
+	if len(consenters) == 0 {
+		return errors.New("at least 1 consenter is required")
+	}
+	ids := map[uint32]bool{}
+	for _, consenter := range consenters {
+		if consenter.ID == 0 {
+			return errors.Errorf("consenter %s:%d has no ID", consenter.Host, consenter.Port)
+		}
+		if ids[consenter.ID] {
+			return errors.Errorf("consenter ID %d is duplicated", consenter.ID)
+		}
+		ids[consenter.ID] = true
+		if consenter.MSPID == "" || len(consenter.Identity) == 0 {
+			return errors.Errorf("consenter %d requires an MSP ID and an identity", consenter.ID)
+		}
+		if len(consenter.ClientTLSCert) == 0 || len(consenter.ServerTLSCert) == 0 {
+			return errors.Errorf("consenter %d requires the TLS certificates", consenter.ID)
+		}
+	}
+	return nil
+}
+
+// SetConsensus switches the orderer group of the config to BFT ordering with the given consenters and options
+func SetConsensus(config *cb.Config, consenters []Consenter, options Options) error {
+	err := validateConsenters(consenters)
+	if err != nil {
+		return err
+	}
+	ordererGroup, err := getOrdererGroup(config)
+	if err != nil {
+		return err
+	}
+	if ordererGroup.Values == nil {
+		ordererGroup.Values = map[string]*cb.ConfigValue{}
+	}
+	if ordererGroup.Policies == nil {
+		ordererGroup.Policies = map[string]*cb.ConfigPolicy{}
+	}
+	consensusType := &ob.ConsensusType{}
+	if consensusTypeValue, ok := ordererGroup.Values[consensusTypeKey]; ok {
+		err = proto.Unmarshal(consensusTypeValue.Value, consensusType)
+		if err != nil {
+			return errors.Wrapf(err, "failed to unmarshal consensus type")
+		}
+	}
+	consensusType.Type = ConsensusType
+	consensusType.Metadata, err = MarshalOptions(options)
+	if err != nil {
+		return err
+	}
+	consensusTypeBytes, err := proto.Marshal(consensusType)
+	if err != nil {
+		return err
+	}
+	ordererGroup.Values[consensusTypeKey] = &cb.ConfigValue{
+		Value:     consensusTypeBytes,
+		ModPolicy: adminsPolicyKey,
+	}
+	ordererGroup.Values[ordererConsentersKey] = &cb.ConfigValue{
+		Value:     MarshalConsenters(consenters),
+		ModPolicy: adminsPolicyKey,
+	}
+	policy, err := blockValidationPolicy(consenters)
+	if err != nil {
+		return err
+	}
+	ordererGroup.Policies[blockValidationPolicyKey] = &cb.ConfigPolicy{
+		Policy:    policy,
+		ModPolicy: adminsPolicyKey,
+	}
+	return nil
+}
+
+// GetConsensusType returns the consensus type of the orderer group of the config
+func GetConsensusType(config *cb.Config) (string, error) {
+	ordererGroup, err := getOrdererGroup(config)
+	if err != nil {
+		return "", err
+	}
+	consensusTypeValue, ok := ordererGroup.Values[consensusTypeKey]
+	if !ok {
+		return "", errors.New("config has no consensus type")
+	}
+	consensusType := &ob.ConsensusType{}
+	err = proto.Unmarshal(consensusTypeValue.Value, consensusType)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal consensus type")
+	}
+	return consensusType.Type, nil
+}
+
+// UpdateConsensus sets the consenters and the options of a channel that already uses BFT ordering and returns whether
+// the orderer group changed
+func UpdateConsensus(config *cb.Config, consenters []Consenter, options Options) (bool, error) {
+	ordererGroup, err := getOrdererGroup(config)
+	if err != nil {
+		return false, err
+	}
+	current := proto.Clone(ordererGroup).(*cb.ConfigGroup)
+	err = SetConsensus(config, consenters, options)
+	if err != nil {
+		return false, err
+	}
+	return !proto.Equal(current, ordererGroup), nil
+}
+
+// GetConsenterIDs returns the IDs of the consenter mapping of the config, a config without one returns no IDs
+func GetConsenterIDs(config *cb.Config) ([]uint32, error) {
+	ordererGroup, err := getOrdererGroup(config)
+	if err != nil {
+		return nil, err
+	}
+	consentersValue, ok := ordererGroup.Values[ordererConsentersKey]
+	if !ok {
+		return nil, nil
+	}
+	var ids []uint32
+	b := consentersValue.Value
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, errors.Wrapf(protowire.ParseError(n), "failed to unmarshal the consenter mapping")
+		}
+		b = b[n:]
+		if num != 1 || typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return nil, errors.Wrapf(protowire.ParseError(n), "failed to unmarshal the consenter mapping")
+			}
+			b = b[n:]
+			continue
+		}
+		consenter, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, errors.Wrapf(protowire.ParseError(n), "failed to unmarshal the consenter mapping")
+		}
+		b = b[n:]
+		id, err := consumeConsenterID(consenter)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func consumeConsenterID(b []byte) (uint32, error) {
+	var id uint32
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return 0, errors.Wrapf(protowire.ParseError(n), "failed to unmarshal the consenter")
+		}
+		b = b[n:]
+		if num == 1 && typ == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, errors.Wrapf(protowire.ParseError(n), "failed to unmarshal the consenter ID")
+			}
+			id = uint32(v)
+			b = b[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return 0, errors.Wrapf(protowire.ParseError(n), "failed to unmarshal the consenter")
+		}
+		b = b[n:]
+	}
+	return id, nil
+}
+
+func getOrdererGroup(config *cb.Config) (*cb.ConfigGroup, error) {
+	if config.ChannelGroup == nil {
+		return nil, errors.New("config has no channel group")
+	}
+	ordererGroup, ok := config.ChannelGroup.Groups[ordererGroupKey]
+	if !ok {
+		return nil, errors.New("config has no orderer group")
+	}
+	return ordererGroup, nil
+}
+
+// SetBlockConsensus switches the genesis block of a channel to BFT ordering
+func SetBlockConsensus(block *cb.Block, consenters []Consenter, options Options) error {
+	if block.Data == nil || len(block.Data.Data) != 1 {
+		return errors.New("the block is not a config block")
+	}
+	envelope, err := protoutil.UnmarshalEnvelope(block.Data.Data[0])
+	if err != nil {
+		return err
+	}
+	payload, err := protoutil.UnmarshalPayload(envelope.Payload)
+	if err != nil {
+		return err
+	}
+	configEnvelope := &cb.ConfigEnvelope{}
+	err = proto.Unmarshal(payload.Data, configEnvelope)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unmarshal config envelope")
+	}
+	err = SetConsensus(configEnvelope.Config, consenters, options)
+	if err != nil {
+		return err
+	}
+	payload.Data, err = proto.Marshal(configEnvelope)
+	if err != nil {
+		return err
+	}
+	envelope.Payload, err = proto.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	block.Data.Data[0], err = proto.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	block.Header.DataHash = protoutil.BlockDataHash(block.Data)
+	return nil
+}
+
+// WithoutBFTMetadata returns a copy of the config without the BFT consenter mapping and the SmartBFT options, which
+// can't be decoded by the protolator version in use, so that the config can be converted to JSON
+func WithoutBFTMetadata(config *cb.Config) *cb.Config {
+	if config.ChannelGroup == nil {
+		return config
+	}
+	ordererGroup, ok := config.ChannelGroup.Groups[ordererGroupKey]
+	if !ok {
+		return config
+	}
+	if _, ok := ordererGroup.Values[ordererConsentersKey]; !ok {
+		return config
+	}
+	configCopy := proto.Clone(config).(*cb.Config)
+	ordererGroup = configCopy.ChannelGroup.Groups[ordererGroupKey]
+	delete(ordererGroup.Values, ordererConsentersKey)
+	if consensusTypeValue, ok := ordererGroup.Values[consensusTypeKey]; ok {
+		consensusType := &ob.ConsensusType{}
+		if err := proto.Unmarshal(consensusTypeValue.Value, consensusType); err == nil {
+			consensusType.Metadata = nil
+			if value, err := proto.Marshal(consensusType); err == nil {
+				consensusTypeValue.Value = value
+			}
+		}
+	}
+	return configCopy
+}
+
+// ValidateCapabilities checks that the channel capabilities allow BFT ordering
+func ValidateCapabilities(channelCapabilities []string) error {
+	for _, capability := range channelCapabilities {
+		if capability == RequiredChannelCapability {
+			return nil
+		}
+	}
+	return fmt.Errorf("BFT ordering requires the %s channel capability, got %v", RequiredChannelCapability, channelCapabilities)
+}
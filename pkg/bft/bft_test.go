@@ -0,0 +1,402 @@
+package bft
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	ob "github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/hyperledger/fabric/protoutil"
+	. "github.com/onsi/gomega"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type field struct {
+	name     string
+	number   int32
+	kind     descriptorpb.FieldDescriptorProto_Type
+	typeName string
+	repeated bool
+}
+
+func message(name string, fields ...field) *descriptorpb.DescriptorProto {
+	msg := &descriptorpb.DescriptorProto{Name: protov2.String(name)}
+	for _, f := range fields {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if f.repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		fieldProto := &descriptorpb.FieldDescriptorProto{
+			Name:   protov2.String(f.name),
+			Number: protov2.Int32(f.number),
+			Label:  label.Enum(),
+			Type:   f.kind.Enum(),
+		}
+		if f.typeName != "" {
+			fieldProto.TypeName = protov2.String(f.typeName)
+		}
+		msg.Field = append(msg.Field, fieldProto)
+	}
+	return msg
+}
+
+const (
+	typeUint64  = descriptorpb.FieldDescriptorProto_TYPE_UINT64
+	typeUint32  = descriptorpb.FieldDescriptorProto_TYPE_UINT32
+	typeString  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+	typeBytes   = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+	typeBool    = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+	typeEnum    = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+	typeMessage = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+)
+
+// fabricDescriptors returns the messages of common/configuration.proto and orderer/smartbft/configuration.proto of
+// fabric-protos v2.5, which aren't in the fabric-protos-go version the operator depends on
+func fabricDescriptors(t *testing.T) (consenters protoreflect.MessageDescriptor, options protoreflect.MessageDescriptor) {
+	t.Helper()
+	commonFile := &descriptorpb.FileDescriptorProto{
+		Name:    protov2.String("common/configuration.proto"),
+		Package: protov2.String("common"),
+		Syntax:  protov2.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			message("Orderers",
+				field{name: "consenter_mapping", number: 1, kind: typeMessage, typeName: ".common.Consenter", repeated: true},
+			),
+			message("Consenter",
+				field{name: "id", number: 1, kind: typeUint32},
+				field{name: "host", number: 2, kind: typeString},
+				field{name: "port", number: 3, kind: typeUint32},
+				field{name: "msp_id", number: 4, kind: typeString},
+				field{name: "identity", number: 5, kind: typeBytes},
+				field{name: "client_tls_cert", number: 6, kind: typeBytes},
+				field{name: "server_tls_cert", number: 7, kind: typeBytes},
+			),
+		},
+	}
+	optionsMessage := message("Options",
+		field{name: "request_batch_max_count", number: 1, kind: typeUint64},
+		field{name: "request_batch_max_bytes", number: 2, kind: typeUint64},
+		field{name: "request_batch_max_interval", number: 3, kind: typeString},
+		field{name: "incoming_message_buffer_size", number: 4, kind: typeUint64},
+		field{name: "request_pool_size", number: 5, kind: typeUint64},
+		field{name: "request_forward_timeout", number: 6, kind: typeString},
+		field{name: "request_complain_timeout", number: 7, kind: typeString},
+		field{name: "request_auto_remove_timeout", number: 8, kind: typeString},
+		field{name: "view_change_resend_interval", number: 9, kind: typeString},
+		field{name: "view_change_timeout", number: 10, kind: typeString},
+		field{name: "leader_heartbeat_timeout", number: 11, kind: typeString},
+		field{name: "leader_heartbeat_count", number: 12, kind: typeUint64},
+		field{name: "collect_timeout", number: 13, kind: typeString},
+		field{name: "sync_on_start", number: 14, kind: typeBool},
+		field{name: "speed_up_view_change", number: 15, kind: typeBool},
+		field{name: "leader_rotation", number: 16, kind: typeEnum, typeName: ".orderer.smartbft.Options.Rotation"},
+		field{name: "decisions_per_leader", number: 17, kind: typeUint64},
+		field{name: "request_max_bytes", number: 18, kind: typeUint64},
+		field{name: "request_pool_submit_timeout", number: 19, kind: typeString},
+	)
+	optionsMessage.EnumType = []*descriptorpb.EnumDescriptorProto{
+		{
+			Name: protov2.String("Rotation"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: protov2.String(RotationUnspecified), Number: protov2.Int32(0)},
+				{Name: protov2.String(RotationOff), Number: protov2.Int32(1)},
+				{Name: protov2.String(RotationOn), Number: protov2.Int32(2)},
+			},
+		},
+	}
+	smartBFTFile := &descriptorpb.FileDescriptorProto{
+		Name:        protov2.String("orderer/smartbft/configuration.proto"),
+		Package:     protov2.String("orderer.smartbft"),
+		Syntax:      protov2.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{optionsMessage},
+	}
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{commonFile, smartBFTFile},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	consentersDesc, err := files.FindDescriptorByName("common.Orderers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	optionsDesc, err := files.FindDescriptorByName("orderer.smartbft.Options")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return consentersDesc.(protoreflect.MessageDescriptor), optionsDesc.(protoreflect.MessageDescriptor)
+}
+
+func testConsenters() []Consenter {
+	var consenters []Consenter
+	for _, id := range []uint32{3, 7, 9, 12} {
+		consenters = append(consenters, Consenter{
+			ID:            id,
+			Host:          "orderer.example.com",
+			Port:          7050 + id,
+			MSPID:         "OrdererMSP",
+			Identity:      []byte("identity"),
+			ClientTLSCert: []byte("client-tls"),
+			ServerTLSCert: []byte("server-tls"),
+		})
+	}
+	return consenters
+}
+
+func TestMarshalConsentersRoundTrip(t *testing.T) {
+	g := NewWithT(t)
+	consentersDesc, _ := fabricDescriptors(t)
+	consenters := testConsenters()
+
+	msg := dynamicpb.NewMessage(consentersDesc)
+	g.Expect(protov2.Unmarshal(MarshalConsenters(consenters), msg)).To(Succeed())
+
+	mapping := msg.Get(consentersDesc.Fields().ByName("consenter_mapping")).List()
+	g.Expect(mapping.Len()).To(Equal(len(consenters)))
+	for i, consenter := range consenters {
+		item := mapping.Get(i).Message()
+		fields := item.Descriptor().Fields()
+		g.Expect(uint32(item.Get(fields.ByName("id")).Uint())).To(Equal(consenter.ID))
+		g.Expect(item.Get(fields.ByName("host")).String()).To(Equal(consenter.Host))
+		g.Expect(uint32(item.Get(fields.ByName("port")).Uint())).To(Equal(consenter.Port))
+		g.Expect(item.Get(fields.ByName("msp_id")).String()).To(Equal(consenter.MSPID))
+		g.Expect(item.Get(fields.ByName("identity")).Bytes()).To(Equal(consenter.Identity))
+		g.Expect(item.Get(fields.ByName("client_tls_cert")).Bytes()).To(Equal(consenter.ClientTLSCert))
+		g.Expect(item.Get(fields.ByName("server_tls_cert")).Bytes()).To(Equal(consenter.ServerTLSCert))
+	}
+	// the encoding matches the one of the protobuf runtime
+	expected, err := protov2.MarshalOptions{Deterministic: true}.Marshal(msg)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(MarshalConsenters(consenters)).To(Equal(expected))
+}
+
+func TestMarshalOptionsRoundTrip(t *testing.T) {
+	g := NewWithT(t)
+	_, optionsDesc := fabricDescriptors(t)
+	options := DefaultOptions()
+	options.SyncOnStart = true
+	options.SpeedUpViewChange = true
+	options.LeaderRotation = RotationOn
+	options.DecisionsPerLeader = 3
+	options.RequestMaxBytes = 10 * 1024
+	options.RequestPoolSubmitTimeout = "5s"
+
+	data, err := MarshalOptions(options)
+	g.Expect(err).ToNot(HaveOccurred())
+	msg := dynamicpb.NewMessage(optionsDesc)
+	g.Expect(protov2.Unmarshal(data, msg)).To(Succeed())
+
+	fields := optionsDesc.Fields()
+	uints := map[string]uint64{
+		"request_batch_max_count":      options.RequestBatchMaxCount,
+		"request_batch_max_bytes":      options.RequestBatchMaxBytes,
+		"incoming_message_buffer_size": options.IncomingMessageBufferSize,
+		"request_pool_size":            options.RequestPoolSize,
+		"leader_heartbeat_count":       options.LeaderHeartbeatCount,
+		"decisions_per_leader":         options.DecisionsPerLeader,
+		"request_max_bytes":            options.RequestMaxBytes,
+	}
+	for name, value := range uints {
+		g.Expect(msg.Get(fields.ByName(protoreflect.Name(name))).Uint()).To(Equal(value), name)
+	}
+	strings := map[string]string{
+		"request_batch_max_interval":  options.RequestBatchMaxInterval,
+		"request_forward_timeout":     options.RequestForwardTimeout,
+		"request_complain_timeout":    options.RequestComplainTimeout,
+		"request_auto_remove_timeout": options.RequestAutoRemoveTimeout,
+		"view_change_resend_interval": options.ViewChangeResendInterval,
+		"view_change_timeout":         options.ViewChangeTimeout,
+		"leader_heartbeat_timeout":    options.LeaderHeartbeatTimeout,
+		"collect_timeout":             options.CollectTimeout,
+		"request_pool_submit_timeout": options.RequestPoolSubmitTimeout,
+	}
+	for name, value := range strings {
+		g.Expect(msg.Get(fields.ByName(protoreflect.Name(name))).String()).To(Equal(value), name)
+	}
+	g.Expect(msg.Get(fields.ByName("sync_on_start")).Bool()).To(BeTrue())
+	g.Expect(msg.Get(fields.ByName("speed_up_view_change")).Bool()).To(BeTrue())
+	rotation := fields.ByName("leader_rotation")
+	g.Expect(string(rotation.Enum().Values().ByNumber(msg.Get(rotation).Enum()).Name())).To(Equal(RotationOn))
+
+	expected, err := protov2.MarshalOptions{Deterministic: true}.Marshal(msg)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(data).To(Equal(expected))
+}
+
+func TestMarshalOptionsInvalidRotation(t *testing.T) {
+	g := NewWithT(t)
+	options := DefaultOptions()
+	options.LeaderRotation = "ROTATION_SOMETIMES"
+
+	_, err := MarshalOptions(options)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestQuorum(t *testing.T) {
+	g := NewWithT(t)
+	// n = 3f + 1 consenters tolerate f faults with a quorum of 2f + 1
+	for n, quorum := range map[int]int{1: 1, 2: 2, 3: 2, 4: 3, 5: 4, 6: 4, 7: 5, 10: 7} {
+		g.Expect(Quorum(n)).To(Equal(quorum), "n=%d", n)
+	}
+}
+
+func TestValidateConsenters(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(validateConsenters(nil)).ToNot(Succeed())
+	g.Expect(validateConsenters(testConsenters())).To(Succeed())
+
+	withoutID := testConsenters()
+	withoutID[1].ID = 0
+	g.Expect(validateConsenters(withoutID)).ToNot(Succeed())
+
+	duplicated := testConsenters()
+	duplicated[1].ID = duplicated[0].ID
+	g.Expect(validateConsenters(duplicated)).ToNot(Succeed())
+
+	withoutIdentity := testConsenters()
+	withoutIdentity[2].Identity = nil
+	g.Expect(validateConsenters(withoutIdentity)).ToNot(Succeed())
+
+	withoutTLS := testConsenters()
+	withoutTLS[3].ServerTLSCert = nil
+	g.Expect(validateConsenters(withoutTLS)).ToNot(Succeed())
+}
+
+func testConfig(t *testing.T) *cb.Config {
+	t.Helper()
+	consensusType, err := proto.Marshal(&ob.ConsensusType{Type: "etcdraft", State: ob.ConsensusType_STATE_NORMAL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cb.Config{
+		ChannelGroup: &cb.ConfigGroup{
+			Groups: map[string]*cb.ConfigGroup{
+				ordererGroupKey: {
+					Values: map[string]*cb.ConfigValue{
+						consensusTypeKey: {Value: consensusType, ModPolicy: adminsPolicyKey},
+					},
+					Policies: map[string]*cb.ConfigPolicy{},
+				},
+			},
+		},
+	}
+}
+
+func TestSetConsensus(t *testing.T) {
+	g := NewWithT(t)
+	config := testConfig(t)
+	consenters := testConsenters()
+
+	consensusType, err := GetConsensusType(config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(consensusType).To(Equal("etcdraft"))
+
+	g.Expect(SetConsensus(config, consenters, DefaultOptions())).To(Succeed())
+	consensusType, err = GetConsensusType(config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(consensusType).To(Equal(ConsensusType))
+
+	ordererGroup := config.ChannelGroup.Groups[ordererGroupKey]
+	g.Expect(ordererGroup.Values[ordererConsentersKey].Value).To(Equal(MarshalConsenters(consenters)))
+	ct := &ob.ConsensusType{}
+	g.Expect(proto.Unmarshal(ordererGroup.Values[consensusTypeKey].Value, ct)).To(Succeed())
+	g.Expect(ct.State).To(Equal(ob.ConsensusType_STATE_NORMAL))
+
+	policy := ordererGroup.Policies[blockValidationPolicyKey].Policy
+	g.Expect(policy.Type).To(Equal(int32(cb.Policy_SIGNATURE)))
+	envelope := &cb.SignaturePolicyEnvelope{}
+	g.Expect(proto.Unmarshal(policy.Value, envelope)).To(Succeed())
+	g.Expect(envelope.Identities).To(HaveLen(len(consenters)))
+	g.Expect(envelope.Rule.GetNOutOf().N).To(Equal(int32(Quorum(len(consenters)))))
+	g.Expect(envelope.Rule.GetNOutOf().Rules).To(HaveLen(len(consenters)))
+}
+
+func TestUpdateConsensus(t *testing.T) {
+	g := NewWithT(t)
+	config := testConfig(t)
+	consenters := testConsenters()
+	g.Expect(SetConsensus(config, consenters, DefaultOptions())).To(Succeed())
+
+	changed, err := UpdateConsensus(config, consenters, DefaultOptions())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+
+	options := DefaultOptions()
+	options.RequestBatchMaxCount = 500
+	changed, err = UpdateConsensus(config, consenters, options)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+
+	changed, err = UpdateConsensus(config, consenters[:3], options)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+}
+
+func TestGetConsenterIDs(t *testing.T) {
+	g := NewWithT(t)
+	config := testConfig(t)
+
+	ids, err := GetConsenterIDs(config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ids).To(BeEmpty())
+
+	g.Expect(SetConsensus(config, testConsenters(), DefaultOptions())).To(Succeed())
+	ids, err = GetConsenterIDs(config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ids).To(Equal([]uint32{3, 7, 9, 12}))
+
+	config.ChannelGroup.Groups[ordererGroupKey].Values[ordererConsentersKey].Value = []byte{0x0a, 0xff}
+	_, err = GetConsenterIDs(config)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestSetBlockConsensus(t *testing.T) {
+	g := NewWithT(t)
+	config := testConfig(t)
+	payload, err := proto.Marshal(&cb.Payload{
+		Header: &cb.Header{},
+		Data:   protoutil.MarshalOrPanic(&cb.ConfigEnvelope{Config: config}),
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	block := protoutil.NewBlock(0, nil)
+	block.Data.Data = [][]byte{protoutil.MarshalOrPanic(&cb.Envelope{Payload: payload})}
+
+	g.Expect(SetBlockConsensus(block, testConsenters(), DefaultOptions())).To(Succeed())
+	g.Expect(block.Header.DataHash).To(Equal(protoutil.BlockDataHash(block.Data)))
+	envelope, err := protoutil.UnmarshalEnvelope(block.Data.Data[0])
+	g.Expect(err).ToNot(HaveOccurred())
+	blockPayload, err := protoutil.UnmarshalPayload(envelope.Payload)
+	g.Expect(err).ToNot(HaveOccurred())
+	configEnvelope := &cb.ConfigEnvelope{}
+	g.Expect(proto.Unmarshal(blockPayload.Data, configEnvelope)).To(Succeed())
+	consensusType, err := GetConsensusType(configEnvelope.Config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(consensusType).To(Equal(ConsensusType))
+}
+
+func TestWithoutBFTMetadata(t *testing.T) {
+	g := NewWithT(t)
+	config := testConfig(t)
+	g.Expect(SetConsensus(config, testConsenters(), DefaultOptions())).To(Succeed())
+
+	stripped := WithoutBFTMetadata(config)
+	ordererGroup := stripped.ChannelGroup.Groups[ordererGroupKey]
+	g.Expect(ordererGroup.Values).ToNot(HaveKey(ordererConsentersKey))
+	ct := &ob.ConsensusType{}
+	g.Expect(proto.Unmarshal(ordererGroup.Values[consensusTypeKey].Value, ct)).To(Succeed())
+	g.Expect(ct.Metadata).To(BeEmpty())
+	// the original config isn't modified
+	g.Expect(config.ChannelGroup.Groups[ordererGroupKey].Values).To(HaveKey(ordererConsentersKey))
+}
+
+func TestValidateCapabilities(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(ValidateCapabilities([]string{"V2_0", RequiredChannelCapability})).To(Succeed())
+	g.Expect(ValidateCapabilities([]string{"V2_0"})).ToNot(Succeed())
+}
@@ -33,6 +33,10 @@ func (c *FakeHlfV1alpha1) FabricChaincodes(namespace string) v1alpha1.FabricChai
 	return &FakeFabricChaincodes{c, namespace}
 }
 
+func (c *FakeHlfV1alpha1) FabricChannelUpdateProposals() v1alpha1.FabricChannelUpdateProposalInterface {
+	return &FakeFabricChannelUpdateProposals{c}
+}
+
 func (c *FakeHlfV1alpha1) FabricExplorers(namespace string) v1alpha1.FabricExplorerInterface {
 	return &FakeFabricExplorers{c, namespace}
 }
@@ -19,6 +19,8 @@ type FabricCAExpansion interface{}
 
 type FabricChaincodeExpansion interface{}
 
+type FabricChannelUpdateProposalExpansion interface{}
+
 type FabricExplorerExpansion interface{}
 
 type FabricFollowerChannelExpansion interface{}
@@ -27,6 +27,7 @@ type HlfV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	FabricCAsGetter
 	FabricChaincodesGetter
+	FabricChannelUpdateProposalsGetter
 	FabricExplorersGetter
 	FabricFollowerChannelsGetter
 	FabricMainChannelsGetter
@@ -52,6 +53,10 @@ func (c *HlfV1alpha1Client) FabricChaincodes(namespace string) FabricChaincodeIn
 	return newFabricChaincodes(c, namespace)
 }
 
+func (c *HlfV1alpha1Client) FabricChannelUpdateProposals() FabricChannelUpdateProposalInterface {
+	return newFabricChannelUpdateProposals(c)
+}
+
 func (c *HlfV1alpha1Client) FabricExplorers(namespace string) FabricExplorerInterface {
 	return newFabricExplorers(c, namespace)
 }
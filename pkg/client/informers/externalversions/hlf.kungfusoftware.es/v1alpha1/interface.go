@@ -25,6 +25,8 @@ type Interface interface {
 	FabricCAs() FabricCAInformer
 	// FabricChaincodes returns a FabricChaincodeInformer.
 	FabricChaincodes() FabricChaincodeInformer
+	// FabricChannelUpdateProposals returns a FabricChannelUpdateProposalInformer.
+	FabricChannelUpdateProposals() FabricChannelUpdateProposalInformer
 	// FabricExplorers returns a FabricExplorerInformer.
 	FabricExplorers() FabricExplorerInformer
 	// FabricFollowerChannels returns a FabricFollowerChannelInformer.
@@ -68,6 +70,11 @@ func (v *version) FabricChaincodes() FabricChaincodeInformer {
 	return &fabricChaincodeInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// FabricChannelUpdateProposals returns a FabricChannelUpdateProposalInformer.
+func (v *version) FabricChannelUpdateProposals() FabricChannelUpdateProposalInformer {
+	return &fabricChannelUpdateProposalInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // FabricExplorers returns a FabricExplorerInformer.
 func (v *version) FabricExplorers() FabricExplorerInformer {
 	return &fabricExplorerInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -31,6 +31,10 @@ type FabricChaincodeListerExpansion interface{}
 // FabricChaincodeNamespaceLister.
 type FabricChaincodeNamespaceListerExpansion interface{}
 
+// FabricChannelUpdateProposalListerExpansion allows custom methods to be added to
+// FabricChannelUpdateProposalLister.
+type FabricChannelUpdateProposalListerExpansion interface{}
+
 // FabricExplorerListerExpansion allows custom methods to be added to
 // FabricExplorerLister.
 type FabricExplorerListerExpansion interface{}
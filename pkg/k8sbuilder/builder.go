@@ -0,0 +1,227 @@
+package k8sbuilder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ChaincodeType is the type in the metadata.json of the chaincode packages launched by the builder
+const ChaincodeType = "k8s"
+
+// InstallCommand is the argument of the operator binary that copies it as the builder in a directory
+const InstallCommand = "install-k8s-builder"
+
+const (
+	detectCommand  = "detect"
+	buildCommand   = "build"
+	releaseCommand = "release"
+	runCommand     = "run"
+)
+
+const binaryName = "hlf-operator"
+
+// ImageReference is the content of the image.json file of the chaincode packages
+type ImageReference struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+}
+
+// String returns the image pinned to its digest when it's set
+func (i ImageReference) String() string {
+	if i.Digest == "" {
+		return i.Name
+	}
+	return fmt.Sprintf("%s@%s", i.Name, i.Digest)
+}
+
+type packageMetadata struct {
+	Type string `json:"type"`
+}
+
+// IsBuilderCommand returns true when the binary was executed through one of the links of the builder
+func IsBuilderCommand(arg0 string) bool {
+	switch filepath.Base(arg0) {
+	case detectCommand, buildCommand, releaseCommand, runCommand:
+		return true
+	}
+	return false
+}
+
+// Main executes the command of the external builder with the name the binary was executed with, the peer considers
+// the command failed when the returned exit code isn't 0
+func Main(args []string) int {
+	var err error
+	command := filepath.Base(args[0])
+	switch command {
+	case detectCommand:
+		if len(args) != 3 {
+			err = errors.Errorf("usage: %s CHAINCODE_SOURCE_DIR CHAINCODE_METADATA_DIR", command)
+			break
+		}
+		var detected bool
+		detected, err = detect(args[2])
+		if err == nil && !detected {
+			return 1
+		}
+	case buildCommand:
+		if len(args) != 4 {
+			err = errors.Errorf("usage: %s CHAINCODE_SOURCE_DIR CHAINCODE_METADATA_DIR BUILD_OUTPUT_DIR", command)
+			break
+		}
+		err = build(args[1], args[3])
+	case releaseCommand:
+		if len(args) != 3 {
+			err = errors.Errorf("usage: %s BUILD_OUTPUT_DIR RELEASE_OUTPUT_DIR", command)
+			break
+		}
+		err = release(args[1], args[2])
+	case runCommand:
+		if len(args) != 3 {
+			err = errors.Errorf("usage: %s BUILD_OUTPUT_DIR RUN_METADATA_DIR", command)
+			break
+		}
+		err = run(args[1], args[2])
+	default:
+		err = errors.Errorf("unknown command %s", command)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", command, err)
+		return 2
+	}
+	return 0
+}
+
+// Install copies the running binary to dir and links the commands of the external builder to it in dir/bin
+func Install(dir string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	binDir := filepath.Join(dir, "bin")
+	err = os.MkdirAll(binDir, 0755)
+	if err != nil {
+		return err
+	}
+	binaryPath := filepath.Join(dir, binaryName)
+	err = copyFile(executable, binaryPath, 0755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to copy %s", executable)
+	}
+	for _, command := range []string{detectCommand, buildCommand, releaseCommand, runCommand} {
+		link := filepath.Join(binDir, command)
+		err = os.Remove(link)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		err = os.Symlink(filepath.Join("..", binaryName), link)
+		if err != nil {
+			return errors.Wrapf(err, "failed to link %s", command)
+		}
+	}
+	return nil
+}
+
+func detect(metadataDir string) (bool, error) {
+	content, err := ioutil.ReadFile(filepath.Join(metadataDir, "metadata.json"))
+	if err != nil {
+		return false, err
+	}
+	metadata := &packageMetadata{}
+	err = json.Unmarshal(content, metadata)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to parse metadata.json")
+	}
+	return strings.ToLower(metadata.Type) == ChaincodeType, nil
+}
+
+func readImageReference(dir string) (*ImageReference, error) {
+	content, err := ioutil.ReadFile(filepath.Join(dir, "image.json"))
+	if err != nil {
+		return nil, err
+	}
+	image := &ImageReference{}
+	err = json.Unmarshal(content, image)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse image.json")
+	}
+	if image.Name == "" {
+		return nil, errors.New("the name of the image is missing in image.json")
+	}
+	if image.Digest != "" && !strings.Contains(image.Digest, ":") {
+		return nil, errors.Errorf("invalid digest %s in image.json", image.Digest)
+	}
+	return image, nil
+}
+
+// build copies the image reference and the META-INF directory of the package to the output, the image is built
+// before packaging the chaincode
+func build(sourceDir string, outputDir string) error {
+	image, err := readImageReference(sourceDir)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(image)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filepath.Join(outputDir, "image.json"), content, 0644)
+	if err != nil {
+		return err
+	}
+	metaInf := filepath.Join(sourceDir, "META-INF")
+	if _, err := os.Stat(metaInf); os.IsNotExist(err) {
+		return nil
+	}
+	return copyDir(metaInf, filepath.Join(outputDir, "META-INF"))
+}
+
+// release copies the CouchDB indexes of the package where the peer expects them
+func release(buildOutputDir string, releaseDir string) error {
+	statedb := filepath.Join(buildOutputDir, "META-INF", "statedb")
+	if _, err := os.Stat(statedb); os.IsNotExist(err) {
+		return nil
+	}
+	return copyDir(statedb, filepath.Join(releaseDir, "statedb"))
+}
+
+func copyDir(src string, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target, info.Mode().Perm())
+	})
+}
+
+func copyFile(src string, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,132 @@
+package k8sbuilder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func writeFile(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadImageReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		image   string
+		wantErr bool
+	}{
+		{
+			name:    "name only",
+			content: `{"name": "ghcr.io/org/chaincode:1.0"}`,
+			image:   "ghcr.io/org/chaincode:1.0",
+		},
+		{
+			name:    "name and digest",
+			content: `{"name": "ghcr.io/org/chaincode", "digest": "sha256:0123abcd"}`,
+			image:   "ghcr.io/org/chaincode@sha256:0123abcd",
+		},
+		{
+			name:    "missing name",
+			content: `{"digest": "sha256:0123abcd"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid digest",
+			content: `{"name": "ghcr.io/org/chaincode", "digest": "0123abcd"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			content: `{"name": `,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			dir := t.TempDir()
+			writeFile(t, dir, "image.json", tt.content)
+
+			image, err := readImageReference(dir)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(image.String()).To(Equal(tt.image))
+		})
+	}
+}
+
+func TestReadImageReferenceMissingFile(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := readImageReference(t.TempDir())
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestDetect(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	writeFile(t, dir, "metadata.json", `{"type": "K8S", "label": "asset"}`)
+	detected, err := detect(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(detected).To(BeTrue())
+
+	writeFile(t, dir, "metadata.json", `{"type": "ccaas", "label": "asset"}`)
+	detected, err = detect(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(detected).To(BeFalse())
+}
+
+func TestBuildAndRelease(t *testing.T) {
+	g := NewWithT(t)
+	sourceDir := t.TempDir()
+	outputDir := t.TempDir()
+	releaseDir := t.TempDir()
+	index := `{"index": {"fields": ["owner"]}, "name": "indexOwner", "type": "json"}`
+	writeFile(t, sourceDir, "image.json", `{"name": "ghcr.io/org/chaincode", "digest": "sha256:0123abcd"}`)
+	writeFile(t, sourceDir, "META-INF/statedb/couchdb/indexes/indexOwner.json", index)
+
+	g.Expect(build(sourceDir, outputDir)).To(Succeed())
+	image, err := readImageReference(outputDir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(image.String()).To(Equal("ghcr.io/org/chaincode@sha256:0123abcd"))
+
+	g.Expect(release(outputDir, releaseDir)).To(Succeed())
+	content, err := ioutil.ReadFile(filepath.Join(releaseDir, "statedb/couchdb/indexes/indexOwner.json"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(Equal(index))
+}
+
+func TestReleaseWithoutIndexes(t *testing.T) {
+	g := NewWithT(t)
+	outputDir := t.TempDir()
+	releaseDir := t.TempDir()
+
+	g.Expect(release(outputDir, releaseDir)).To(Succeed())
+	_, err := os.Stat(filepath.Join(releaseDir, "statedb"))
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+}
+
+func TestIsBuilderCommand(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(IsBuilderCommand("/builders/k8s/bin/detect")).To(BeTrue())
+	g.Expect(IsBuilderCommand("/builders/k8s/bin/run")).To(BeTrue())
+	g.Expect(IsBuilderCommand("/hlf-operator")).To(BeFalse())
+}
@@ -0,0 +1,397 @@
+package k8sbuilder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Environment variables set in the peer container for the builder
+const (
+	EnvNamespace = "HLF_K8S_BUILDER_NAMESPACE"
+	EnvPeer      = "HLF_K8S_BUILDER_PEER"
+	EnvPodName   = "HLF_K8S_BUILDER_POD_NAME"
+	EnvPodUID    = "HLF_K8S_BUILDER_POD_UID"
+	EnvConfig    = "HLF_K8S_BUILDER_CONFIG"
+)
+
+// PropagateEnvironment are the environment variables the peer needs to pass to the builder
+var PropagateEnvironment = []string{
+	"KUBERNETES_SERVICE_HOST",
+	"KUBERNETES_SERVICE_PORT",
+	EnvNamespace,
+	EnvPeer,
+	EnvPodName,
+	EnvPodUID,
+	EnvConfig,
+}
+
+// ChaincodeIDAnnotation is set in the deployments of the chaincodes with the package ID they run
+const ChaincodeIDAnnotation = "hlf.kungfusoftware.es/chaincode-id"
+
+const (
+	tlsMountPath      = "/etc/hyperledger/chaincode/tls"
+	statusCheckPeriod = 10 * time.Second
+)
+
+// Config is the configuration of the chaincode pods, it's passed as JSON in the HLF_K8S_BUILDER_CONFIG variable
+type Config struct {
+	PullPolicy         corev1.PullPolicy             `json:"pullPolicy,omitempty"`
+	ImagePullSecrets   []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	Resources          *corev1.ResourceRequirements  `json:"resources,omitempty"`
+	ServiceAccountName string                        `json:"serviceAccountName,omitempty"`
+}
+
+// chaincodeRunConfig is the content of the chaincode.json file the peer writes in the run metadata directory
+type chaincodeRunConfig struct {
+	ChaincodeID string `json:"chaincode_id"`
+	PeerAddress string `json:"peer_address"`
+	ClientCert  string `json:"client_cert"`
+	ClientKey   string `json:"client_key"`
+	RootCert    string `json:"root_cert"`
+	MSPID       string `json:"mspid"`
+}
+
+type peerEnvironment struct {
+	Namespace string
+	Peer      string
+	PodName   string
+	PodUID    string
+	Config    Config
+}
+
+func getPeerEnvironment() (*peerEnvironment, error) {
+	env := &peerEnvironment{
+		Namespace: os.Getenv(EnvNamespace),
+		Peer:      os.Getenv(EnvPeer),
+		PodName:   os.Getenv(EnvPodName),
+		PodUID:    os.Getenv(EnvPodUID),
+	}
+	if env.Namespace == "" || env.Peer == "" {
+		return nil, errors.Errorf("%s and %s must be set", EnvNamespace, EnvPeer)
+	}
+	if config := os.Getenv(EnvConfig); config != "" {
+		err := json.Unmarshal([]byte(config), &env.Config)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", EnvConfig)
+		}
+	}
+	return env, nil
+}
+
+func readChaincodeRunConfig(runMetadataDir string) (*chaincodeRunConfig, error) {
+	content, err := ioutil.ReadFile(filepath.Join(runMetadataDir, "chaincode.json"))
+	if err != nil {
+		return nil, err
+	}
+	runConfig := &chaincodeRunConfig{}
+	err = json.Unmarshal(content, runConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse chaincode.json")
+	}
+	return runConfig, nil
+}
+
+// GetDeploymentName returns the name of the deployment of a chaincode launched by a peer
+func GetDeploymentName(peer string, chaincodeID string) string {
+	return fmt.Sprintf("%s-cc-%s", peer, getChaincodeIDHash(chaincodeID))
+}
+
+func getChaincodeIDHash(chaincodeID string) string {
+	hash := sha256.Sum256([]byte(chaincodeID))
+	return hex.EncodeToString(hash[:])[:10]
+}
+
+// run launches the chaincode in a deployment and blocks until the peer stops it or the deployment is deleted, the
+// deployment is owned by the pod of the peer so it's deleted with it. When the process is killed with SIGKILL the
+// deployment and its secret are left until the pod of the peer is deleted or the peer launches the package again
+func run(buildOutputDir string, runMetadataDir string) error {
+	image, err := readImageReference(buildOutputDir)
+	if err != nil {
+		return err
+	}
+	runConfig, err := readChaincodeRunConfig(runMetadataDir)
+	if err != nil {
+		return err
+	}
+	env, err := getPeerEnvironment()
+	if err != nil {
+		return err
+	}
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return err
+	}
+	clientSet, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	name := GetDeploymentName(env.Peer, runConfig.ChaincodeID)
+	labels := map[string]string{
+		"app":          "fabric-chaincode",
+		"peer":         env.Peer,
+		"chaincode-id": getChaincodeIDHash(runConfig.ChaincodeID),
+	}
+	var ownerReferences []metav1.OwnerReference
+	if env.PodName != "" && env.PodUID != "" {
+		ownerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Name:       env.PodName,
+				UID:        types.UID(env.PodUID),
+			},
+		}
+	}
+	objectMeta := metav1.ObjectMeta{
+		Name:            name,
+		Namespace:       env.Namespace,
+		Labels:          labels,
+		Annotations:     map[string]string{ChaincodeIDAnnotation: runConfig.ChaincodeID},
+		OwnerReferences: ownerReferences,
+	}
+	tlsEnabled := runConfig.ClientCert != ""
+	if tlsEnabled {
+		err = applySecret(ctx, clientSet, &corev1.Secret{
+			ObjectMeta: objectMeta,
+			StringData: map[string]string{
+				// the shims read the base64 encoded key pair from the *_PATH variables
+				"client.key":     base64.StdEncoding.EncodeToString([]byte(runConfig.ClientKey)),
+				"client.crt":     base64.StdEncoding.EncodeToString([]byte(runConfig.ClientCert)),
+				"client_pem.key": runConfig.ClientKey,
+				"client_pem.crt": runConfig.ClientCert,
+				"root.crt":       runConfig.RootCert,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	deployment := getDeployment(objectMeta, image, runConfig, env.Config, tlsEnabled)
+	err = applyDeployment(ctx, clientSet, deployment)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "chaincode %s launched in deployment %s/%s with image %s\n", runConfig.ChaincodeID, env.Namespace, name, image)
+
+	err = waitForDeployment(ctx, clientSet, env.Namespace, name)
+	if err != nil {
+		return err
+	}
+	// the peer stopped the chaincode
+	return deleteChaincode(clientSet, env.Namespace, name, tlsEnabled)
+}
+
+func getDeployment(objectMeta metav1.ObjectMeta, image *ImageReference, runConfig *chaincodeRunConfig, config Config, tlsEnabled bool) *appsv1.Deployment {
+	envVars := []corev1.EnvVar{
+		{
+			Name:  "CORE_CHAINCODE_ID_NAME",
+			Value: runConfig.ChaincodeID,
+		},
+		{
+			Name:  "CORE_PEER_ADDRESS",
+			Value: runConfig.PeerAddress,
+		},
+		{
+			Name:  "CORE_PEER_LOCALMSPID",
+			Value: runConfig.MSPID,
+		},
+		{
+			Name:  "CORE_PEER_TLS_ENABLED",
+			Value: fmt.Sprintf("%t", tlsEnabled),
+		},
+	}
+	var volumes []corev1.Volume
+	var volumeMounts []corev1.VolumeMount
+	if tlsEnabled {
+		envVars = append(envVars, []corev1.EnvVar{
+			{
+				Name:  "CORE_TLS_CLIENT_KEY_PATH",
+				Value: filepath.Join(tlsMountPath, "client.key"),
+			},
+			{
+				Name:  "CORE_TLS_CLIENT_CERT_PATH",
+				Value: filepath.Join(tlsMountPath, "client.crt"),
+			},
+			{
+				Name:  "CORE_TLS_CLIENT_KEY_FILE",
+				Value: filepath.Join(tlsMountPath, "client_pem.key"),
+			},
+			{
+				Name:  "CORE_TLS_CLIENT_CERT_FILE",
+				Value: filepath.Join(tlsMountPath, "client_pem.crt"),
+			},
+			{
+				Name:  "CORE_PEER_TLS_ROOTCERT_FILE",
+				Value: filepath.Join(tlsMountPath, "root.crt"),
+			},
+		}...)
+		volumes = append(volumes, corev1.Volume{
+			Name: "tls",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: objectMeta.Name,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "tls",
+			ReadOnly:  true,
+			MountPath: tlsMountPath,
+		})
+	}
+	pullPolicy := config.PullPolicy
+	if pullPolicy == "" {
+		pullPolicy = corev1.PullIfNotPresent
+	}
+	container := corev1.Container{
+		Name:            "chaincode",
+		Image:           image.String(),
+		ImagePullPolicy: pullPolicy,
+		Env:             envVars,
+		VolumeMounts:    volumeMounts,
+	}
+	if config.Resources != nil {
+		container.Resources = *config.Resources
+	}
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		ObjectMeta: objectMeta,
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: objectMeta.Labels,
+			},
+			// the peer only keeps one connection per chaincode
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      objectMeta.Labels,
+					Annotations: map[string]string{ChaincodeIDAnnotation: runConfig.ChaincodeID},
+				},
+				Spec: corev1.PodSpec{
+					Containers:         []corev1.Container{container},
+					Volumes:            volumes,
+					RestartPolicy:      corev1.RestartPolicyAlways,
+					ImagePullSecrets:   config.ImagePullSecrets,
+					ServiceAccountName: config.ServiceAccountName,
+				},
+			},
+		},
+	}
+}
+
+func applySecret(ctx context.Context, clientSet kubernetes.Interface, secret *corev1.Secret) error {
+	secretClient := clientSet.CoreV1().Secrets(secret.Namespace)
+	current, err := secretClient.Get(ctx, secret.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		_, err = secretClient.Create(ctx, secret, metav1.CreateOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to create secret %s", secret.Name)
+		}
+		return nil
+	}
+	current.Labels = secret.Labels
+	current.Annotations = secret.Annotations
+	current.OwnerReferences = secret.OwnerReferences
+	current.Data = nil
+	current.StringData = secret.StringData
+	_, err = secretClient.Update(ctx, current, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update secret %s", secret.Name)
+	}
+	return nil
+}
+
+func applyDeployment(ctx context.Context, clientSet kubernetes.Interface, deployment *appsv1.Deployment) error {
+	deploymentClient := clientSet.AppsV1().Deployments(deployment.Namespace)
+	current, err := deploymentClient.Get(ctx, deployment.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		_, err = deploymentClient.Create(ctx, deployment, metav1.CreateOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to create deployment %s", deployment.Name)
+		}
+		return nil
+	}
+	// a deployment left by a previous pod of the peer is updated, so the chaincode restarts with the new credentials
+	current.Labels = deployment.Labels
+	current.Annotations = deployment.Annotations
+	current.OwnerReferences = deployment.OwnerReferences
+	current.Spec = deployment.Spec
+	current.Spec.Template.Annotations["hlf.kungfusoftware.es/updatedsecrettime"] = time.Now().UTC().Format(time.RFC3339)
+	_, err = deploymentClient.Update(ctx, current, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update deployment %s", deployment.Name)
+	}
+	return nil
+}
+
+// waitForDeployment returns nil when the context is done and an error when the deployment is deleted
+func waitForDeployment(ctx context.Context, clientSet kubernetes.Interface, ns string, name string) error {
+	ticker := time.NewTicker(statusCheckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			_, err := clientSet.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+			if err == nil {
+				continue
+			}
+			if apierrors.IsNotFound(err) {
+				return errors.Errorf("deployment %s of the chaincode was deleted", name)
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "failed to get deployment %s: %v\n", name, err)
+		}
+	}
+}
+
+func deleteChaincode(clientSet kubernetes.Interface, ns string, name string, tlsEnabled bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := clientSet.AppsV1().Deployments(ns).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete deployment %s", name)
+	}
+	if !tlsEnabled {
+		return nil
+	}
+	err = clientSet.CoreV1().Secrets(ns).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete secret %s", name)
+	}
+	return nil
+}
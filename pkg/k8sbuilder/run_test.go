@@ -0,0 +1,161 @@
+package k8sbuilder
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetDeploymentName(t *testing.T) {
+	g := NewWithT(t)
+
+	name := GetDeploymentName("org1-peer0", "asset:1a2b3c")
+	g.Expect(name).To(HavePrefix("org1-peer0-cc-"))
+	g.Expect(name).To(HaveLen(len("org1-peer0-cc-") + 10))
+	// the name is stable for a package and different between packages
+	g.Expect(GetDeploymentName("org1-peer0", "asset:1a2b3c")).To(Equal(name))
+	g.Expect(GetDeploymentName("org1-peer0", "asset:4d5e6f")).ToNot(Equal(name))
+	g.Expect(GetDeploymentName("org1-peer1", "asset:1a2b3c")).ToNot(Equal(name))
+}
+
+func TestReadChaincodeRunConfig(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	writeFile(t, dir, "chaincode.json", `{
+  "chaincode_id": "asset:1a2b3c",
+  "peer_address": "10.0.0.1:7052",
+  "client_cert": "CERT",
+  "client_key": "KEY",
+  "root_cert": "ROOT",
+  "mspid": "Org1MSP"
+}`)
+
+	runConfig, err := readChaincodeRunConfig(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(runConfig).To(Equal(&chaincodeRunConfig{
+		ChaincodeID: "asset:1a2b3c",
+		PeerAddress: "10.0.0.1:7052",
+		ClientCert:  "CERT",
+		ClientKey:   "KEY",
+		RootCert:    "ROOT",
+		MSPID:       "Org1MSP",
+	}))
+}
+
+func TestReadChaincodeRunConfigErrors(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	_, err := readChaincodeRunConfig(dir)
+	g.Expect(err).To(HaveOccurred())
+
+	writeFile(t, dir, "chaincode.json", `{"chaincode_id": `)
+	_, err = readChaincodeRunConfig(dir)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func getTestObjectMeta() metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      "org1-peer0-cc-0123456789",
+		Namespace: "default",
+		Labels: map[string]string{
+			"app":          "fabric-chaincode",
+			"peer":         "org1-peer0",
+			"chaincode-id": "0123456789",
+		},
+	}
+}
+
+func envValue(container corev1.Container, name string) (string, bool) {
+	for _, env := range container.Env {
+		if env.Name == name {
+			return env.Value, true
+		}
+	}
+	return "", false
+}
+
+func TestGetDeploymentWithTLS(t *testing.T) {
+	g := NewWithT(t)
+	objectMeta := getTestObjectMeta()
+	image := &ImageReference{Name: "ghcr.io/org/chaincode", Digest: "sha256:0123abcd"}
+	runConfig := &chaincodeRunConfig{
+		ChaincodeID: "asset:1a2b3c",
+		PeerAddress: "10.0.0.1:7052",
+		ClientCert:  "CERT",
+		ClientKey:   "KEY",
+		RootCert:    "ROOT",
+		MSPID:       "Org1MSP",
+	}
+	resources := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("100m"),
+		},
+	}
+	config := Config{
+		PullPolicy:         corev1.PullAlways,
+		ImagePullSecrets:   []corev1.LocalObjectReference{{Name: "registry"}},
+		Resources:          resources,
+		ServiceAccountName: "chaincode",
+	}
+
+	deployment := getDeployment(objectMeta, image, runConfig, config, true)
+	g.Expect(deployment.ObjectMeta).To(Equal(objectMeta))
+	g.Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+	g.Expect(deployment.Spec.Strategy.Type).To(Equal(appsv1.RecreateDeploymentStrategyType))
+	g.Expect(deployment.Spec.Selector.MatchLabels).To(Equal(objectMeta.Labels))
+	g.Expect(deployment.Spec.Template.Labels).To(Equal(objectMeta.Labels))
+	g.Expect(deployment.Spec.Template.Annotations).To(HaveKeyWithValue(ChaincodeIDAnnotation, "asset:1a2b3c"))
+
+	podSpec := deployment.Spec.Template.Spec
+	g.Expect(podSpec.ImagePullSecrets).To(Equal(config.ImagePullSecrets))
+	g.Expect(podSpec.ServiceAccountName).To(Equal("chaincode"))
+	g.Expect(podSpec.Volumes).To(HaveLen(1))
+	g.Expect(podSpec.Volumes[0].Secret.SecretName).To(Equal(objectMeta.Name))
+
+	g.Expect(podSpec.Containers).To(HaveLen(1))
+	container := podSpec.Containers[0]
+	g.Expect(container.Image).To(Equal("ghcr.io/org/chaincode@sha256:0123abcd"))
+	g.Expect(container.ImagePullPolicy).To(Equal(corev1.PullAlways))
+	g.Expect(container.Resources).To(Equal(*resources))
+	g.Expect(container.VolumeMounts).To(HaveLen(1))
+	g.Expect(container.VolumeMounts[0].MountPath).To(Equal(tlsMountPath))
+	for name, value := range map[string]string{
+		"CORE_CHAINCODE_ID_NAME":      "asset:1a2b3c",
+		"CORE_PEER_ADDRESS":           "10.0.0.1:7052",
+		"CORE_PEER_LOCALMSPID":        "Org1MSP",
+		"CORE_PEER_TLS_ENABLED":       "true",
+		"CORE_TLS_CLIENT_KEY_PATH":    tlsMountPath + "/client.key",
+		"CORE_TLS_CLIENT_CERT_PATH":   tlsMountPath + "/client.crt",
+		"CORE_PEER_TLS_ROOTCERT_FILE": tlsMountPath + "/root.crt",
+	} {
+		actual, ok := envValue(container, name)
+		g.Expect(ok).To(BeTrue(), name)
+		g.Expect(actual).To(Equal(value), name)
+	}
+}
+
+func TestGetDeploymentWithoutTLS(t *testing.T) {
+	g := NewWithT(t)
+	runConfig := &chaincodeRunConfig{
+		ChaincodeID: "asset:1a2b3c",
+		PeerAddress: "10.0.0.1:7052",
+		MSPID:       "Org1MSP",
+	}
+
+	deployment := getDeployment(getTestObjectMeta(), &ImageReference{Name: "ghcr.io/org/chaincode:1.0"}, runConfig, Config{}, false)
+	podSpec := deployment.Spec.Template.Spec
+	g.Expect(podSpec.Volumes).To(BeEmpty())
+	container := podSpec.Containers[0]
+	g.Expect(container.Image).To(Equal("ghcr.io/org/chaincode:1.0"))
+	g.Expect(container.ImagePullPolicy).To(Equal(corev1.PullIfNotPresent))
+	g.Expect(container.VolumeMounts).To(BeEmpty())
+	tlsEnabled, _ := envValue(container, "CORE_PEER_TLS_ENABLED")
+	g.Expect(tlsEnabled).To(Equal("false"))
+	_, ok := envValue(container, "CORE_TLS_CLIENT_KEY_PATH")
+	g.Expect(ok).To(BeFalse())
+}
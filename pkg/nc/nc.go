@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"github.com/Masterminds/sprig/v3"
 	hlfv1alpha1 "github.com/kfsoftware/hlf-operator/api/hlf.kungfusoftware.es/v1alpha1"
+	"github.com/kfsoftware/hlf-operator/controllers/utils"
 	"github.com/kfsoftware/hlf-operator/kubectl-hlf/cmd/helpers"
 	operatorv1 "github.com/kfsoftware/hlf-operator/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"text/template"
@@ -40,11 +42,27 @@ type Orderer struct {
 	TLSCACert string
 }
 
+// ClientTLS is the TLS key pair presented to the peers and orderers that require client authentication
+type ClientTLS struct {
+	Cert string
+	Key  string
+}
+
 const tmplGoConfig = `
 name: hlf-network
 version: 1.0.0
 client:
   organization: "{{ .Organization }}"
+{{- with .ClientTLS }}
+  tlsCerts:
+    client:
+      key:
+        pem: |
+{{ .Key | indent 10 }}
+      cert:
+        pem: |
+{{ .Cert | indent 10 }}
+{{- end }}
 {{- if not .Organizations }}
 organizations: {}
 {{- else }}
@@ -165,7 +183,7 @@ type NetworkConfigResponse struct {
 	NetworkConfig string
 }
 
-func GenerateNetworkConfig(channel *hlfv1alpha1.FabricMainChannel, kubeClientset *kubernetes.Clientset, hlfClientSet *operatorv1.Clientset, mspID string) (*NetworkConfigResponse, error) {
+func GenerateNetworkConfig(channel *hlfv1alpha1.FabricMainChannel, kubeClientset *kubernetes.Clientset, hlfClientSet *operatorv1.Clientset, mspID string, clientTLS *ClientTLS) (*NetworkConfigResponse, error) {
 	tmpl, err := template.New("networkConfig").Funcs(sprig.HermeticTxtFuncMap()).Parse(tmplGoConfig)
 	if err != nil {
 		return nil, err
@@ -309,6 +327,7 @@ func GenerateNetworkConfig(channel *hlfv1alpha1.FabricMainChannel, kubeClientset
 		"CertAuths":     certAuths,
 		"Organization":  mspID,
 		"Internal":      false,
+		"ClientTLS":     clientTLS,
 	})
 	if err != nil {
 		return nil, err
@@ -318,7 +337,7 @@ func GenerateNetworkConfig(channel *hlfv1alpha1.FabricMainChannel, kubeClientset
 	}, nil
 }
 
-func GenerateNetworkConfigForFollower(channel *hlfv1alpha1.FabricFollowerChannel, kubeClientset *kubernetes.Clientset, hlfClientSet *operatorv1.Clientset, mspID string) (*NetworkConfigResponse, error) {
+func GenerateNetworkConfigForFollower(channel *hlfv1alpha1.FabricFollowerChannel, kubeClientset *kubernetes.Clientset, hlfClientSet *operatorv1.Clientset, mspID string, clientTLS *ClientTLS) (*NetworkConfigResponse, error) {
 	tmpl, err := template.New("networkConfig").Funcs(sprig.HermeticTxtFuncMap()).Parse(tmplGoConfig)
 	if err != nil {
 		return nil, err
@@ -377,6 +396,7 @@ func GenerateNetworkConfigForFollower(channel *hlfv1alpha1.FabricFollowerChannel
 		"CertAuths":     certAuths,
 		"Organization":  mspID,
 		"Internal":      false,
+		"ClientTLS":     clientTLS,
 	})
 	if err != nil {
 		return nil, err
@@ -385,3 +405,56 @@ func GenerateNetworkConfigForFollower(channel *hlfv1alpha1.FabricFollowerChannel
 		NetworkConfig: buf.String(),
 	}, nil
 }
+
+func GenerateNetworkConfigForOrderers(orderers []hlfv1alpha1.FabricFollowerChannelOrderer, mspID string, clientTLS *ClientTLS) (*NetworkConfigResponse, error) {
+	tmpl, err := template.New("networkConfig").Funcs(sprig.HermeticTxtFuncMap()).Parse(tmplGoConfig)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	var ordererNodes []*Orderer
+	org := &Org{
+		MSPID:     mspID,
+		CertAuths: []string{},
+		Peers:     []string{},
+		Orderers:  []string{},
+	}
+	for _, orderer := range orderers {
+		org.Orderers = append(org.Orderers, orderer.URL)
+		ordererNodes = append(ordererNodes, &Orderer{
+			URL:       orderer.URL,
+			Name:      orderer.URL,
+			TLSCACert: orderer.Certificate,
+		})
+	}
+	err = tmpl.Execute(&buf, map[string]interface{}{
+		"Peers":         []*Peer{},
+		"Orderers":      ordererNodes,
+		"Organizations": []*Org{org},
+		"CertAuths":     []*CA{},
+		"Organization":  mspID,
+		"Internal":      false,
+		"ClientTLS":     clientTLS,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &NetworkConfigResponse{
+		NetworkConfig: buf.String(),
+	}, nil
+}
+
+// GetClientTLS reads the TLS key pair stored in the secret of an identity, it returns nil if the identity is nil
+func GetClientTLS(ctx context.Context, kubeClientset *kubernetes.Clientset, hlfIdentity *hlfv1alpha1.HLFIdentity) (*ClientTLS, error) {
+	if hlfIdentity == nil {
+		return nil, nil
+	}
+	id, err := utils.GetIdentity(ctx, kubeClientset, *hlfIdentity)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the client TLS identity %s/%s", hlfIdentity.SecretNamespace, hlfIdentity.SecretName)
+	}
+	return &ClientTLS{
+		Cert: id.Cert.Pem,
+		Key:  id.Key.Pem,
+	}, nil
+}
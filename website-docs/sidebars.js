@@ -34,7 +34,7 @@ module.exports = {
       "channel-management/manage",
     ],
     "Kubectl Plugin": ["kubectl-plugin/installation", "kubectl-plugin/upgrade"],
-    CouchDB: ["couchdb/external-couchdb", "couchdb/custom-image"],
+    CouchDB: ["couchdb/external-couchdb", "couchdb/custom-image", "couchdb/indexes"],
     Reference: ["reference/reference"],
     "GRPC Proxy": ["grpc-proxy/enable-peers", "grpc-proxy/enable-orderers"],
     "Operations Console": [